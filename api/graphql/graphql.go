@@ -0,0 +1,106 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package graphql implements the GraphQL schema served by the reporting
+// API over the device and deployment documents. The query fields are
+// resolved with the searches and the aggregations of the reporting app;
+// the queries are limited in the number of fields they resolve and in
+// depth.
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"sync/atomic"
+
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	// MaxRootFields is the maximum number of fields of a query: each of
+	// them is resolved with a search or an aggregation
+	MaxRootFields = 8
+	// MaxDepth is the maximum depth of the selections of a query, enough
+	// for the default depth of the nested aggregations
+	MaxDepth = 16
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+// Request is a GraphQL request
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Scope is the scope of the queries of a request: the tenant, and the
+// restrictions of the role of the user
+type Scope struct {
+	TenantID string
+	// Groups are the device groups the user is restricted to, if any
+	Groups []string
+	// VisibleAttributes are the attributes visible to the user, if
+	// restricted
+	VisibleAttributes *model.AttributesFilter
+
+	fields int32
+}
+
+// resolve counts the resolution of a field of the query, and fails if the
+// query selects more than MaxRootFields fields
+func (s *Scope) resolve() error {
+	if atomic.AddInt32(&s.fields, 1) > MaxRootFields {
+		return errors.Errorf("the query selects more than %d fields", MaxRootFields)
+	}
+	return nil
+}
+
+type scopeContextKey struct{}
+
+func scopeFromContext(ctx context.Context) (*Scope, error) {
+	scope, _ := ctx.Value(scopeContextKey{}).(*Scope)
+	if scope == nil {
+		return nil, errors.New("missing scope from the context")
+	}
+	return scope, scope.resolve()
+}
+
+// Schema executes the GraphQL queries with the reporting app
+type Schema struct {
+	schema *gql.Schema
+}
+
+// NewSchema returns the GraphQL schema resolved by the given app
+func NewSchema(app reporting.App) *Schema {
+	return &Schema{
+		schema: gql.MustParseSchema(schemaString, &resolver{app: app},
+			gql.UseFieldResolvers(),
+			gql.MaxDepth(MaxDepth),
+			gql.MaxParallelism(MaxRootFields),
+		),
+	}
+}
+
+// Execute executes the query of the request within the given scope; the
+// data of the response is nil if the query is not valid
+func (s *Schema) Execute(ctx context.Context, scope *Scope, req *Request) *gql.Response {
+	ctx = context.WithValue(ctx, scopeContextKey{}, scope)
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
@@ -0,0 +1,289 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+var contextMatcher = mock.MatchedBy(func(_ context.Context) bool { return true })
+
+func TestSchemaExecute(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	max := 42.0
+	type testCase struct {
+		Name string
+
+		App     func(*testing.T) *mapp.App
+		Scope   *Scope
+		Request Request
+
+		Response string
+	}
+	testCases := []testCase{{
+		Name: "ok, devices",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher, &model.SearchParams{
+				Page:    1,
+				PerPage: 20,
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "cpus",
+					Type:      "$in",
+					Value:     []interface{}{float64(2), float64(4)},
+				}},
+				TenantID: tenantID,
+				Groups:   []string{"prod"},
+			}).Return([]inventory.Device{{
+				ID:        "1",
+				CreatedTs: created,
+				Attributes: inventory.DeviceAttributes{{
+					Scope: model.ScopeInventory,
+					Name:  "cpus",
+					Value: float64(4),
+				}},
+			}}, 1, nil)
+			return app
+		},
+		Scope: &Scope{TenantID: tenantID, Groups: []string{"prod"}},
+		Request: Request{
+			Query: `query ($cpus: JSON) {
+				devices(filters: [{scope: "inventory", attribute: "cpus", type: "$in", value: $cpus}]) {
+					total
+					devices { id created_ts updated_ts attributes { name value } }
+				}
+			}`,
+			Variables: map[string]interface{}{
+				"cpus": []interface{}{2, 4},
+			},
+		},
+
+		Response: `{"devices": {"total": 1, "devices": [{
+			"id": "1",
+			"created_ts": "2023-01-02T03:04:05Z",
+			"updated_ts": null,
+			"attributes": [{"name": "cpus", "value": 4}]
+		}]}}`,
+	}, {
+		Name: "ok, nested aggregations",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateDevices", contextMatcher, &model.AggregateParams{
+				Aggregations: []model.AggregationTerm{{
+					Name:      "types",
+					Scope:     model.ScopeInventory,
+					Attribute: "device_type",
+					Limit:     5,
+					Aggregations: []model.AggregationTerm{{
+						Name:      "memory",
+						Scope:     model.ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      model.AggregationTypeStats,
+					}},
+				}},
+				TenantID: tenantID,
+			}).Return([]model.DeviceAggregation{{
+				Name: "types",
+				Items: []model.DeviceAggregationItem{{
+					Key:   "rpi4",
+					Count: 3,
+					Aggregations: []model.DeviceAggregation{{
+						Name: "memory",
+						Stats: &model.DeviceAggregationStats{
+							Count: 3,
+							Max:   &max,
+							Sum:   100,
+						},
+					}},
+				}},
+			}}, nil)
+			return app
+		},
+		Scope: &Scope{TenantID: tenantID},
+		Request: Request{
+			Query: `{
+				aggregate_devices(aggregations: [{
+					name: "types", scope: "inventory", attribute: "device_type", limit: 5,
+					aggregations: [{
+						name: "memory", scope: "inventory", attribute: "mem_total_kB",
+						type: "stats"
+					}]
+				}]) {
+					name
+					other_count
+					items {
+						key
+						count
+						aggregations { name stats { count min max sum } percentiles }
+					}
+				}
+			}`,
+		},
+
+		Response: `{"aggregate_devices": [{
+			"name": "types",
+			"other_count": 0,
+			"items": [{
+				"key": "rpi4",
+				"count": 3,
+				"aggregations": [{
+					"name": "memory",
+					"stats": {"count": 3, "min": null, "max": 42, "sum": 100},
+					"percentiles": null
+				}]
+			}]
+		}]}`,
+	}, {
+		Name: "ok, deployments",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDeployments", contextMatcher, &model.DeploymentsSearchParams{
+				Page:    2,
+				PerPage: 10,
+				Filters: []model.DeploymentsFilterPredicate{{
+					Attribute: "device_status",
+					Type:      "$eq",
+					Value:     "failure",
+				}},
+				TenantID: tenantID,
+			}).Return([]model.Deployment{{
+				ID:                      "5975e1e6-49a6-4218-a46d-f181154a98cc",
+				DeviceID:                "1",
+				DeviceCreated:           &created,
+				DeviceStatus:            "failure",
+				DeviceAttempts:          2,
+				ImageSize:               1024,
+				ImageProvides:           map[string]string{"rootfs-image.version": "v1"},
+				DeploymentArtifactSizes: []int64{1024},
+				DeviceStatusHistory: []model.DeviceStatusTransition{{
+					Status:    "failure",
+					Timestamp: created,
+				}},
+			}}, 11, nil)
+			return app
+		},
+		Scope: &Scope{TenantID: tenantID},
+		Request: Request{
+			Query: `{
+				deployments(page: 2, per_page: 10, filters: [
+					{attribute: "device_status", type: "$eq", value: "failure"}
+				]) {
+					total
+					deployments {
+						device_id
+						device_created
+						device_finished
+						device_attempts
+						image_size
+						image_provides
+						image_depends
+						deployment_artifact_sizes
+						device_status_history { status timestamp }
+					}
+				}
+			}`,
+		},
+
+		Response: `{"deployments": {"total": 11, "deployments": [{
+			"device_id": "1",
+			"device_created": "2023-01-02T03:04:05Z",
+			"device_finished": null,
+			"device_attempts": 2,
+			"image_size": 1024,
+			"image_provides": {"rootfs-image.version": "v1"},
+			"image_depends": null,
+			"deployment_artifact_sizes": [1024],
+			"device_status_history": [
+				{"status": "failure", "timestamp": "2023-01-02T03:04:05Z"}
+			]
+		}]}}`,
+	}, {
+		Name: "ok, introspection",
+
+		Scope: &Scope{TenantID: tenantID},
+		Request: Request{
+			Query: `{ __schema { queryType { fields { name } } } }`,
+		},
+
+		Response: `{"__schema": {"queryType": {"fields": [
+			{"name": "devices"},
+			{"name": "aggregate_devices"},
+			{"name": "deployments"},
+			{"name": "aggregate_deployments"}
+		]}}}`,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			if tc.App != nil {
+				app = tc.App(t)
+			}
+			defer app.AssertExpectations(t)
+
+			res := NewSchema(app).Execute(context.Background(), tc.Scope, &tc.Request)
+			assert.Empty(t, res.Errors)
+			assert.JSONEq(t, tc.Response, string(res.Data))
+		})
+	}
+}
+
+func TestSchemaExecuteMaxRootFields(t *testing.T) {
+	t.Parallel()
+	app := new(mapp.App)
+	app.On("SearchDevices", contextMatcher, mock.AnythingOfType("*model.SearchParams")).
+		Return(nil, 0, nil).
+		Times(MaxRootFields)
+	defer app.AssertExpectations(t)
+
+	query := "{"
+	for i := 0; i <= MaxRootFields; i++ {
+		query += fmt.Sprintf(" d%d: devices { total }", i)
+	}
+	query += " }"
+	res := NewSchema(app).Execute(context.Background(),
+		&Scope{TenantID: "123456789012345678901234"},
+		&Request{Query: query},
+	)
+
+	var data map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(res.Data, &data)) {
+		assert.Len(t, data, MaxRootFields+1)
+	}
+	if assert.Len(t, res.Errors, 1) {
+		assert.Equal(t,
+			fmt.Sprintf("the query selects more than %d fields", MaxRootFields),
+			res.Errors[0].Message,
+		)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+// resolver resolves the fields of the query
+type resolver struct {
+	app reporting.App
+}
+
+type searchDevicesArgs struct {
+	Page         int32              `json:"page"`
+	PerPage      int32              `json:"per_page"`
+	Filters      *[]filterPredicate `json:"filters,omitempty"`
+	FilterGroups *[]filterGroup     `json:"filter_groups,omitempty"`
+	Sort         *[]sortCriteria    `json:"sort,omitempty"`
+	Attributes   *[]selectAttribute `json:"attributes,omitempty"`
+	DeviceIDs    *[]string          `json:"device_ids,omitempty"`
+	Text         *string            `json:"text,omitempty"`
+	Highlight    *bool              `json:"highlight,omitempty"`
+	Groups       *[]string          `json:"groups,omitempty"`
+}
+
+func (r *resolver) Devices(ctx context.Context, args searchDevicesArgs) (*devices, error) {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params model.SearchParams
+	if err := decodeArguments(args, &params); err != nil {
+		return nil, err
+	}
+	params.TenantID = scope.TenantID
+	params.Groups = scope.Groups
+	params.VisibleAttributes = scope.VisibleAttributes
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	res, total, err := r.app.SearchDevices(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	return newDevices(res, total), nil
+}
+
+type aggregateDevicesArgs struct {
+	Aggregations []aggregationTerm  `json:"aggregations"`
+	Filters      *[]filterPredicate `json:"filters,omitempty"`
+}
+
+func (r *resolver) AggregateDevices(
+	ctx context.Context,
+	args aggregateDevicesArgs,
+) (*[]*deviceAggregation, error) {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params model.AggregateParams
+	if err := decodeArguments(args, &params); err != nil {
+		return nil, err
+	}
+	params.TenantID = scope.TenantID
+	params.Groups = scope.Groups
+	params.VisibleAttributes = scope.VisibleAttributes
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	res, err := r.app.AggregateDevices(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	aggregations := newDeviceAggregations(res)
+	return &aggregations, nil
+}
+
+type searchDeploymentsArgs struct {
+	Page          int32                         `json:"page"`
+	PerPage       int32                         `json:"per_page"`
+	Filters       *[]deploymentsFilterPredicate `json:"filters,omitempty"`
+	Sort          *[]deploymentsSortCriteria    `json:"sort,omitempty"`
+	Attributes    *[]deploymentsSelectAttribute `json:"attributes,omitempty"`
+	DeviceIDs     *[]string                     `json:"device_ids,omitempty"`
+	DeploymentIDs *[]string                     `json:"deployment_ids,omitempty"`
+}
+
+func (r *resolver) Deployments(
+	ctx context.Context,
+	args searchDeploymentsArgs,
+) (*deployments, error) {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params model.DeploymentsSearchParams
+	if err := decodeArguments(args, &params); err != nil {
+		return nil, err
+	}
+	params.TenantID = scope.TenantID
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	res, total, err := r.app.SearchDeployments(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	return newDeployments(res, total), nil
+}
+
+type aggregateDeploymentsArgs struct {
+	Aggregations []deploymentsAggregationTerm  `json:"aggregations"`
+	Filters      *[]deploymentsFilterPredicate `json:"filters,omitempty"`
+}
+
+func (r *resolver) AggregateDeployments(
+	ctx context.Context,
+	args aggregateDeploymentsArgs,
+) (*[]*deviceAggregation, error) {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params model.AggregateDeploymentsParams
+	if err := decodeArguments(args, &params); err != nil {
+		return nil, err
+	}
+	params.TenantID = scope.TenantID
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	res, err := r.app.AggregateDeployments(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	aggregations := newDeviceAggregations(res)
+	return &aggregations, nil
+}
+
+// decodeArguments decodes the arguments of a query field into the
+// parameters of the search or the aggregation, the same way as the JSON
+// request bodies of the REST API
+func decodeArguments(args, params interface{}) error {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(json.Unmarshal(b, params), "invalid arguments")
+}
@@ -0,0 +1,352 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package graphql
+
+import (
+	"encoding/json"
+	"time"
+
+	gql "github.com/graph-gophers/graphql-go"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const typeJSON = "JSON"
+
+// JSON is a scalar with any JSON value, e.g. the values of the attributes
+type JSON struct {
+	Value interface{}
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == typeJSON
+}
+
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	j.Value = input
+	return nil
+}
+
+// Nullable marks the JSON values as nullable, null is a valid JSON value
+func (j *JSON) Nullable() {}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Value)
+}
+
+func newJSON(value interface{}) *JSON {
+	if value == nil {
+		return nil
+	}
+	return &JSON{Value: value}
+}
+
+func newTime(t *time.Time) *gql.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return &gql.Time{Time: *t}
+}
+
+// input types; the JSON names are the names of the properties of the
+// request bodies of the REST API
+
+type filterPredicate struct {
+	Scope     string `json:"scope"`
+	Attribute string `json:"attribute"`
+	Type      string `json:"type"`
+	Value     JSON   `json:"value"`
+}
+
+type filterGroup struct {
+	Type    string             `json:"type"`
+	Filters *[]filterPredicate `json:"filters,omitempty"`
+	Groups  *[]filterGroup     `json:"groups,omitempty"`
+}
+
+type sortCriteria struct {
+	Scope     string  `json:"scope"`
+	Attribute string  `json:"attribute"`
+	Order     string  `json:"order"`
+	Missing   *string `json:"missing,omitempty"`
+}
+
+type selectAttribute struct {
+	Scope     string `json:"scope"`
+	Attribute string `json:"attribute"`
+}
+
+type aggregationTerm struct {
+	Name         string             `json:"name"`
+	Attribute    string             `json:"attribute"`
+	Scope        string             `json:"scope"`
+	Limit        *int32             `json:"limit,omitempty"`
+	Type         *string            `json:"type,omitempty"`
+	Interval     *string            `json:"interval,omitempty"`
+	TimeZone     *string            `json:"time_zone,omitempty"`
+	Percents     *[]float64         `json:"percents,omitempty"`
+	Aggregations *[]aggregationTerm `json:"aggregations,omitempty"`
+}
+
+type deploymentsFilterPredicate struct {
+	Attribute string `json:"attribute"`
+	Type      string `json:"type"`
+	Value     JSON   `json:"value"`
+}
+
+type deploymentsSortCriteria struct {
+	Attribute string `json:"attribute"`
+	Order     string `json:"order"`
+}
+
+type deploymentsSelectAttribute struct {
+	Attribute string `json:"attribute"`
+}
+
+type deploymentsAggregationTerm struct {
+	Name         string                        `json:"name"`
+	Attribute    string                        `json:"attribute"`
+	Limit        *int32                        `json:"limit,omitempty"`
+	Type         *string                       `json:"type,omitempty"`
+	Interval     *string                       `json:"interval,omitempty"`
+	TimeZone     *string                       `json:"time_zone,omitempty"`
+	Aggregations *[]deploymentsAggregationTerm `json:"aggregations,omitempty"`
+}
+
+// output types; the fields of the documents whose types are not GraphQL
+// types are resolved by methods
+
+type devices struct {
+	Devices []*device
+	Total   int32
+}
+
+func newDevices(res []inventory.Device, total int) *devices {
+	devices := &devices{
+		Devices: make([]*device, len(res)),
+		Total:   int32(total),
+	}
+	for i := range res {
+		devices.Devices[i] = &device{Device: res[i]}
+	}
+	return devices
+}
+
+type device struct {
+	inventory.Device
+}
+
+func (d *device) ID() string {
+	return string(d.Device.ID)
+}
+
+func (d *device) Attributes() []*deviceAttribute {
+	return newDeviceAttributes(d.Device.Attributes)
+}
+
+func (d *device) Highlights() []*deviceAttribute {
+	return newDeviceAttributes(d.Device.Highlights)
+}
+
+func (d *device) CreatedTs() *gql.Time {
+	return newTime(&d.Device.CreatedTs)
+}
+
+func (d *device) UpdatedTs() *gql.Time {
+	return newTime(&d.Device.UpdatedTs)
+}
+
+type deviceAttribute struct {
+	inventory.DeviceAttribute
+}
+
+func newDeviceAttributes(attrs inventory.DeviceAttributes) []*deviceAttribute {
+	res := make([]*deviceAttribute, len(attrs))
+	for i := range attrs {
+		res[i] = &deviceAttribute{DeviceAttribute: attrs[i]}
+	}
+	return res
+}
+
+func (a *deviceAttribute) Value() *JSON {
+	return newJSON(a.DeviceAttribute.Value)
+}
+
+type deviceAggregation struct {
+	model.DeviceAggregation
+}
+
+func newDeviceAggregations(aggs []model.DeviceAggregation) []*deviceAggregation {
+	res := make([]*deviceAggregation, len(aggs))
+	for i := range aggs {
+		res[i] = &deviceAggregation{DeviceAggregation: aggs[i]}
+	}
+	return res
+}
+
+func (a *deviceAggregation) Items() []*deviceAggregationItem {
+	items := make([]*deviceAggregationItem, len(a.DeviceAggregation.Items))
+	for i := range a.DeviceAggregation.Items {
+		items[i] = &deviceAggregationItem{
+			DeviceAggregationItem: a.DeviceAggregation.Items[i],
+		}
+	}
+	return items
+}
+
+func (a *deviceAggregation) OtherCount() int32 {
+	return int32(a.DeviceAggregation.OtherCount)
+}
+
+func (a *deviceAggregation) Stats() *deviceAggregationStats {
+	if a.DeviceAggregation.Stats == nil {
+		return nil
+	}
+	return &deviceAggregationStats{
+		DeviceAggregationStats: *a.DeviceAggregation.Stats,
+	}
+}
+
+func (a *deviceAggregation) Percentiles() *JSON {
+	if a.DeviceAggregation.Percentiles == nil {
+		return nil
+	}
+	return newJSON(a.DeviceAggregation.Percentiles)
+}
+
+type deviceAggregationItem struct {
+	model.DeviceAggregationItem
+}
+
+func (i *deviceAggregationItem) Count() int32 {
+	return int32(i.DeviceAggregationItem.Count)
+}
+
+func (i *deviceAggregationItem) Aggregations() []*deviceAggregation {
+	return newDeviceAggregations(i.DeviceAggregationItem.Aggregations)
+}
+
+type deviceAggregationStats struct {
+	model.DeviceAggregationStats
+}
+
+func (s *deviceAggregationStats) Count() int32 {
+	return int32(s.DeviceAggregationStats.Count)
+}
+
+type deployments struct {
+	Deployments []*deployment
+	Total       int32
+}
+
+func newDeployments(res []model.Deployment, total int) *deployments {
+	deployments := &deployments{
+		Deployments: make([]*deployment, len(res)),
+		Total:       int32(total),
+	}
+	for i := range res {
+		deployments.Deployments[i] = &deployment{Deployment: res[i]}
+	}
+	return deployments
+}
+
+type deployment struct {
+	model.Deployment
+}
+
+func (d *deployment) DeploymentCreated() *gql.Time {
+	return newTime(d.Deployment.DeploymentCreated)
+}
+
+func (d *deployment) DeploymentRetries() int32 {
+	return int32(d.Deployment.DeploymentRetries)
+}
+
+func (d *deployment) DeploymentMaxDevices() int32 {
+	return int32(d.Deployment.DeploymentMaxDevices)
+}
+
+func (d *deployment) DeploymentArtifactSizes() []float64 {
+	sizes := make([]float64, len(d.Deployment.DeploymentArtifactSizes))
+	for i, size := range d.Deployment.DeploymentArtifactSizes {
+		sizes[i] = float64(size)
+	}
+	return sizes
+}
+
+func (d *deployment) DeviceCreated() *gql.Time {
+	return newTime(d.Deployment.DeviceCreated)
+}
+
+func (d *deployment) DeviceFinished() *gql.Time {
+	return newTime(d.Deployment.DeviceFinished)
+}
+
+func (d *deployment) DeviceElapsedSeconds() int32 {
+	return int32(d.Deployment.DeviceElapsedSeconds)
+}
+
+func (d *deployment) DeviceDeleted() *gql.Time {
+	return newTime(d.Deployment.DeviceDeleted)
+}
+
+func (d *deployment) DeviceRetries() int32 {
+	return int32(d.Deployment.DeviceRetries)
+}
+
+func (d *deployment) DeviceAttempts() int32 {
+	return int32(d.Deployment.DeviceAttempts)
+}
+
+func (d *deployment) ImageArtifactInfoVersion() int32 {
+	return int32(d.Deployment.ImageArtifactInfoVersion)
+}
+
+func (d *deployment) ImageProvides() *JSON {
+	if d.Deployment.ImageProvides == nil {
+		return nil
+	}
+	return newJSON(d.Deployment.ImageProvides)
+}
+
+func (d *deployment) ImageDepends() *JSON {
+	if d.Deployment.ImageDepends == nil {
+		return nil
+	}
+	return newJSON(d.Deployment.ImageDepends)
+}
+
+func (d *deployment) ImageSize() float64 {
+	return float64(d.Deployment.ImageSize)
+}
+
+func (d *deployment) DeviceStatusHistory() []*deviceStatusTransition {
+	history := make([]*deviceStatusTransition, len(d.Deployment.DeviceStatusHistory))
+	for i := range d.Deployment.DeviceStatusHistory {
+		history[i] = &deviceStatusTransition{
+			DeviceStatusTransition: d.Deployment.DeviceStatusHistory[i],
+		}
+	}
+	return history
+}
+
+type deviceStatusTransition struct {
+	model.DeviceStatusTransition
+}
+
+func (t *deviceStatusTransition) Timestamp() gql.Time {
+	return gql.Time{Time: t.DeviceStatusTransition.Timestamp}
+}
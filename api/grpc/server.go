@@ -0,0 +1,280 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/api/grpc/pb"
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	defaultPage    = 1
+	defaultPerPage = 20
+
+	filterTypeIn    = "$in"
+	filterTypeNotIn = "$nin"
+)
+
+type reportingServer struct {
+	pb.UnimplementedReportingServer
+	reporting reporting.App
+}
+
+// NewServer returns a gRPC server exposing the internal API of the
+// reporting service
+func NewServer(reporting reporting.App, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	pb.RegisterReportingServer(srv, &reportingServer{reporting: reporting})
+	return srv
+}
+
+func (s *reportingServer) SearchDevices(
+	ctx context.Context,
+	req *pb.SearchDevicesRequest,
+) (*pb.SearchDevicesResponse, error) {
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: req.TenantId})
+
+	params := &model.SearchParams{
+		Page:      int(req.Page),
+		PerPage:   int(req.PerPage),
+		Filters:   filtersFromPb(req.Filters),
+		DeviceIDs: req.DeviceIds,
+		Text:      req.Text,
+		TenantID:  req.TenantId,
+	}
+	for _, sort := range req.Sort {
+		params.Sort = append(params.Sort, model.SortCriteria{
+			Scope:     sort.Scope,
+			Attribute: sort.Attribute,
+			Order:     sort.Order,
+		})
+	}
+	for _, attr := range req.Attributes {
+		params.Attributes = append(params.Attributes, model.SelectAttribute{
+			Scope:     attr.Scope,
+			Attribute: attr.Attribute,
+		})
+	}
+	if params.PerPage <= 0 {
+		params.PerPage = defaultPerPage
+	}
+	if params.Page <= 0 {
+		params.Page = defaultPage
+	}
+	if err := params.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	devices, total, err := s.reporting.SearchDevices(ctx, params)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	res := &pb.SearchDevicesResponse{
+		Devices: make([]*pb.Device, 0, len(devices)),
+		Total:   int64(total),
+	}
+	for _, device := range devices {
+		res.Devices = append(res.Devices, deviceToPb(device))
+	}
+	return res, nil
+}
+
+func (s *reportingServer) AggregateDevices(
+	ctx context.Context,
+	req *pb.AggregateDevicesRequest,
+) (*pb.AggregateDevicesResponse, error) {
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: req.TenantId})
+
+	params := &model.AggregateParams{
+		Aggregations: aggregationTermsFromPb(req.Aggregations),
+		Filters:      filtersFromPb(req.Filters),
+		TenantID:     req.TenantId,
+	}
+	if err := params.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	aggregations, err := s.reporting.AggregateDevices(ctx, params)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return &pb.AggregateDevicesResponse{
+		Aggregations: aggregationsToPb(aggregations),
+	}, nil
+}
+
+func (s *reportingServer) ReindexDevices(
+	ctx context.Context,
+	req *pb.ReindexDevicesRequest,
+) (*pb.ReindexDevicesResponse, error) {
+	params := model.ReindexDevicesRequest{
+		DeviceIDs: req.DeviceIds,
+	}
+	if err := params.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.reporting.ReindexDevices(ctx, req.TenantId, params.DeviceIDs)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+	return &pb.ReindexDevicesResponse{}, nil
+}
+
+// statusFromError maps the errors of the reporting app to the gRPC status
+// codes, the same way the HTTP API maps them to the HTTP status codes
+func statusFromError(err error) error {
+	switch {
+	case errors.Is(err, reporting.ErrAggregationAttributeNotNumeric),
+		errors.Is(err, reporting.ErrFilterValueType):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, reporting.ErrReindexNotAvailable),
+		errors.Is(err, store.ErrTooManyQueries):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func filtersFromPb(filters []*pb.FilterPredicate) []model.FilterPredicate {
+	var ret []model.FilterPredicate
+	for _, filter := range filters {
+		predicate := model.FilterPredicate{
+			Scope:     filter.Scope,
+			Attribute: filter.Attribute,
+			Type:      filter.Type,
+		}
+		switch {
+		case filter.Type == filterTypeIn || filter.Type == filterTypeNotIn:
+			values := make([]interface{}, 0, len(filter.Values))
+			for _, value := range filter.Values {
+				values = append(values, valueFromPb(value))
+			}
+			predicate.Value = values
+		case len(filter.Values) > 0:
+			predicate.Value = valueFromPb(filter.Values[0])
+		}
+		ret = append(ret, predicate)
+	}
+	return ret
+}
+
+func aggregationTermsFromPb(terms []*pb.AggregationTerm) []model.AggregationTerm {
+	var ret []model.AggregationTerm
+	for _, term := range terms {
+		ret = append(ret, model.AggregationTerm{
+			Name:         term.Name,
+			Scope:        term.Scope,
+			Attribute:    term.Attribute,
+			Limit:        int(term.Limit),
+			Aggregations: aggregationTermsFromPb(term.Aggregations),
+		})
+	}
+	return ret
+}
+
+func aggregationsToPb(aggregations []model.DeviceAggregation) []*pb.Aggregation {
+	ret := make([]*pb.Aggregation, 0, len(aggregations))
+	for _, aggregation := range aggregations {
+		items := make([]*pb.AggregationItem, 0, len(aggregation.Items))
+		for _, item := range aggregation.Items {
+			items = append(items, &pb.AggregationItem{
+				Key:          item.Key,
+				Count:        int64(item.Count),
+				Aggregations: aggregationsToPb(item.Aggregations),
+			})
+		}
+		ret = append(ret, &pb.Aggregation{
+			Name:       aggregation.Name,
+			Items:      items,
+			OtherCount: int64(aggregation.OtherCount),
+		})
+	}
+	return ret
+}
+
+func deviceToPb(device inventory.Device) *pb.Device {
+	ret := &pb.Device{
+		Id:         string(device.ID),
+		Attributes: make([]*pb.DeviceAttribute, 0, len(device.Attributes)),
+	}
+	if !device.UpdatedTs.IsZero() {
+		ret.UpdatedTs = timestamppb.New(device.UpdatedTs)
+	}
+	for _, attr := range device.Attributes {
+		var values []*pb.Value
+		if array, ok := attr.Value.([]interface{}); ok {
+			for _, value := range array {
+				values = append(values, valueToPb(value))
+			}
+		} else if attr.Value != nil {
+			values = []*pb.Value{valueToPb(attr.Value)}
+		}
+		ret.Attributes = append(ret.Attributes, &pb.DeviceAttribute{
+			Scope:  attr.Scope,
+			Name:   attr.Name,
+			Values: values,
+		})
+	}
+	return ret
+}
+
+func valueFromPb(value *pb.Value) interface{} {
+	switch v := value.GetValue().(type) {
+	case *pb.Value_String_:
+		return v.String_
+	case *pb.Value_Number:
+		return v.Number
+	case *pb.Value_Boolean:
+		return v.Boolean
+	default:
+		return nil
+	}
+}
+
+func valueToPb(value interface{}) *pb.Value {
+	switch v := value.(type) {
+	case string:
+		return &pb.Value{Value: &pb.Value_String_{String_: v}}
+	case bool:
+		return &pb.Value{Value: &pb.Value_Boolean{Boolean: v}}
+	case float64:
+		return &pb.Value{Value: &pb.Value_Number{Number: v}}
+	case float32:
+		return &pb.Value{Value: &pb.Value_Number{Number: float64(v)}}
+	case int:
+		return &pb.Value{Value: &pb.Value_Number{Number: float64(v)}}
+	case int64:
+		return &pb.Value{Value: &pb.Value_Number{Number: float64(v)}}
+	default:
+		return &pb.Value{Value: &pb.Value_String_{String_: fmt.Sprint(v)}}
+	}
+}
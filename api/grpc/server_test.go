@@ -0,0 +1,426 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/api/grpc/pb"
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const tenantID = "123456789012345678901234"
+
+var contextMatcher = mock.MatchedBy(func(ctx context.Context) bool {
+	id := identity.FromContext(ctx)
+	return id != nil && id.Tenant == tenantID
+})
+
+func newTestClient(t *testing.T, app reporting.App) pb.ReportingClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer(app)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewReportingClient(conn)
+}
+
+func TestSearchDevices(t *testing.T) {
+	t.Parallel()
+	updatedTs := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		Name string
+
+		Request *pb.SearchDevicesRequest
+		App     func(*testing.T) *mapp.App
+
+		Response *pb.SearchDevicesResponse
+		Code     codes.Code
+	}{{
+		Name: "ok",
+
+		Request: &pb.SearchDevicesRequest{
+			TenantId: tenantID,
+			PerPage:  10,
+			Filters: []*pb.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "mac",
+				Type:      "$in",
+				Values: []*pb.Value{
+					{Value: &pb.Value_String_{String_: "00:11"}},
+					{Value: &pb.Value_String_{String_: "00:12"}},
+				},
+			}, {
+				Scope:     model.ScopeInventory,
+				Attribute: "cpus",
+				Type:      "$gt",
+				Values:    []*pb.Value{{Value: &pb.Value_Number{Number: 2}}},
+			}},
+			Sort: []*pb.SortCriteria{{
+				Scope:     model.ScopeInventory,
+				Attribute: "mac",
+				Order:     "asc",
+			}},
+			Attributes: []*pb.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "mac",
+			}},
+		},
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher, &model.SearchParams{
+				Page:    1,
+				PerPage: 10,
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+					Type:      "$in",
+					Value:     []interface{}{"00:11", "00:12"},
+				}, {
+					Scope:     model.ScopeInventory,
+					Attribute: "cpus",
+					Type:      "$gt",
+					Value:     float64(2),
+				}},
+				Sort: []model.SortCriteria{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+					Order:     "asc",
+				}},
+				Attributes: []model.SelectAttribute{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+				}},
+				TenantID: tenantID,
+			}).Return([]inventory.Device{{
+				ID: "1",
+				Attributes: inventory.DeviceAttributes{{
+					Scope: model.ScopeInventory,
+					Name:  "mac",
+					Value: "00:11",
+				}, {
+					Scope: model.ScopeInventory,
+					Name:  "ips",
+					Value: []interface{}{"10.0.0.1", "10.0.0.2"},
+				}, {
+					Scope: model.ScopeInventory,
+					Name:  "cpus",
+					Value: float64(4),
+				}},
+				UpdatedTs: updatedTs,
+			}}, 21, nil)
+			return app
+		},
+
+		Response: &pb.SearchDevicesResponse{
+			Devices: []*pb.Device{{
+				Id: "1",
+				Attributes: []*pb.DeviceAttribute{{
+					Scope:  model.ScopeInventory,
+					Name:   "mac",
+					Values: []*pb.Value{{Value: &pb.Value_String_{String_: "00:11"}}},
+				}, {
+					Scope: model.ScopeInventory,
+					Name:  "ips",
+					Values: []*pb.Value{
+						{Value: &pb.Value_String_{String_: "10.0.0.1"}},
+						{Value: &pb.Value_String_{String_: "10.0.0.2"}},
+					},
+				}, {
+					Scope:  model.ScopeInventory,
+					Name:   "cpus",
+					Values: []*pb.Value{{Value: &pb.Value_Number{Number: 4}}},
+				}},
+				UpdatedTs: timestamppb.New(updatedTs),
+			}},
+			Total: 21,
+		},
+	}, {
+		Name: "error, invalid request",
+
+		Request: &pb.SearchDevicesRequest{
+			TenantId: tenantID,
+			Filters: []*pb.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "mac",
+				Type:      "$bogus",
+			}},
+		},
+		App: func(t *testing.T) *mapp.App {
+			return new(mapp.App)
+		},
+
+		Code: codes.InvalidArgument,
+	}, {
+		Name: "error, internal error",
+
+		Request: &pb.SearchDevicesRequest{
+			TenantId: tenantID,
+		},
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher, mock.AnythingOfType("*model.SearchParams")).
+				Return(nil, 0, errors.New("internal error"))
+			return app
+		},
+
+		Code: codes.Internal,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.App(t)
+			defer app.AssertExpectations(t)
+
+			client := newTestClient(t, app)
+			res, err := client.SearchDevices(context.Background(), tc.Request)
+			if tc.Code != codes.OK {
+				assert.Equal(t, tc.Code, status.Code(err))
+			} else if assert.NoError(t, err) {
+				assert.True(t, proto.Equal(tc.Response, res), res.String())
+			}
+		})
+	}
+}
+
+func TestAggregateDevices(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		Request *pb.AggregateDevicesRequest
+		App     func(*testing.T) *mapp.App
+
+		Response *pb.AggregateDevicesResponse
+		Code     codes.Code
+	}{{
+		Name: "ok",
+
+		Request: &pb.AggregateDevicesRequest{
+			TenantId: tenantID,
+			Filters: []*pb.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "online",
+				Type:      "$eq",
+				Values:    []*pb.Value{{Value: &pb.Value_Boolean{Boolean: true}}},
+			}},
+			Aggregations: []*pb.AggregationTerm{{
+				Name:      "device_type",
+				Scope:     model.ScopeInventory,
+				Attribute: "device_type",
+				Limit:     10,
+				Aggregations: []*pb.AggregationTerm{{
+					Name:      "artifact_name",
+					Scope:     model.ScopeInventory,
+					Attribute: "artifact_name",
+				}},
+			}},
+		},
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateDevices", contextMatcher, &model.AggregateParams{
+				Aggregations: []model.AggregationTerm{{
+					Name:      "device_type",
+					Scope:     model.ScopeInventory,
+					Attribute: "device_type",
+					Limit:     10,
+					Aggregations: []model.AggregationTerm{{
+						Name:      "artifact_name",
+						Scope:     model.ScopeInventory,
+						Attribute: "artifact_name",
+					}},
+				}},
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "online",
+					Type:      "$eq",
+					Value:     true,
+				}},
+				TenantID: tenantID,
+			}).Return([]model.DeviceAggregation{{
+				Name: "device_type",
+				Items: []model.DeviceAggregationItem{{
+					Key:   "rpi4",
+					Count: 2,
+					Aggregations: []model.DeviceAggregation{{
+						Name: "artifact_name",
+						Items: []model.DeviceAggregationItem{{
+							Key:   "v1",
+							Count: 2,
+						}},
+					}},
+				}},
+				OtherCount: 3,
+			}}, nil)
+			return app
+		},
+
+		Response: &pb.AggregateDevicesResponse{
+			Aggregations: []*pb.Aggregation{{
+				Name: "device_type",
+				Items: []*pb.AggregationItem{{
+					Key:   "rpi4",
+					Count: 2,
+					Aggregations: []*pb.Aggregation{{
+						Name: "artifact_name",
+						Items: []*pb.AggregationItem{{
+							Key:          "v1",
+							Count:        2,
+							Aggregations: []*pb.Aggregation{},
+						}},
+					}},
+				}},
+				OtherCount: 3,
+			}},
+		},
+	}, {
+		Name: "error, no aggregations",
+
+		Request: &pb.AggregateDevicesRequest{
+			TenantId: tenantID,
+		},
+		App: func(t *testing.T) *mapp.App {
+			return new(mapp.App)
+		},
+
+		Code: codes.InvalidArgument,
+	}, {
+		Name: "error, attribute not numeric",
+
+		Request: &pb.AggregateDevicesRequest{
+			TenantId: tenantID,
+			Aggregations: []*pb.AggregationTerm{{
+				Name:      "device_type",
+				Scope:     model.ScopeInventory,
+				Attribute: "device_type",
+			}},
+		},
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateDevices", contextMatcher,
+				mock.AnythingOfType("*model.AggregateParams")).
+				Return(nil, reporting.ErrAggregationAttributeNotNumeric)
+			return app
+		},
+
+		Code: codes.InvalidArgument,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.App(t)
+			defer app.AssertExpectations(t)
+
+			client := newTestClient(t, app)
+			res, err := client.AggregateDevices(context.Background(), tc.Request)
+			if tc.Code != codes.OK {
+				assert.Equal(t, tc.Code, status.Code(err))
+			} else if assert.NoError(t, err) {
+				assert.True(t, proto.Equal(tc.Response, res), res.String())
+			}
+		})
+	}
+}
+
+func TestReindexDevices(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		Request *pb.ReindexDevicesRequest
+		App     func(*testing.T) *mapp.App
+
+		Code codes.Code
+	}{{
+		Name: "ok",
+
+		Request: &pb.ReindexDevicesRequest{
+			TenantId:  tenantID,
+			DeviceIds: []string{"1", "2"},
+		},
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReindexDevices", mock.Anything, tenantID, []string{"1", "2"}).
+				Return(nil)
+			return app
+		},
+	}, {
+		Name: "error, no devices",
+
+		Request: &pb.ReindexDevicesRequest{
+			TenantId: tenantID,
+		},
+		App: func(t *testing.T) *mapp.App {
+			return new(mapp.App)
+		},
+
+		Code: codes.InvalidArgument,
+	}, {
+		Name: "error, reindexing not available",
+
+		Request: &pb.ReindexDevicesRequest{
+			TenantId:  tenantID,
+			DeviceIds: []string{"1"},
+		},
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReindexDevices", mock.Anything, tenantID, []string{"1"}).
+				Return(reporting.ErrReindexNotAvailable)
+			return app
+		},
+
+		Code: codes.Unavailable,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.App(t)
+			defer app.AssertExpectations(t)
+
+			client := newTestClient(t, app)
+			_, err := client.ReindexDevices(context.Background(), tc.Request)
+			assert.Equal(t, tc.Code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+	"github.com/mendersoftware/go-lib-micro/requestid"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	// maxAuditLogBodySize is the size the request bodies are truncated to
+	// in the audit trail
+	maxAuditLogBodySize = 64 * 1024
+	// auditLogTimeout bounds the recording of an entry of the audit trail,
+	// which outlives the request when the client went away
+	auditLogTimeout = 10 * time.Second
+)
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// auditLog returns a middleware recording the requests to the management
+// API in the audit trail; it is a no-op if the audit trail is disabled.
+// Failing to record an entry is logged, but does not fail the request.
+func (mc *ManagementController) auditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mc.auditLogEnabled {
+			return
+		}
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxAuditLogBodySize))
+			c.Request.Body = readCloser{
+				Reader: io.MultiReader(bytes.NewReader(body), c.Request.Body),
+				Closer: c.Request.Body,
+			}
+		}
+		createdTs := time.Now().UTC().Truncate(time.Millisecond)
+
+		c.Next()
+
+		reqCtx := c.Request.Context()
+		entry := &model.AuditLogEntry{
+			RequestID: requestid.FromContext(reqCtx),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Query:     c.Request.URL.RawQuery,
+			Body:      string(body),
+			Status:    c.Writer.Status(),
+			CreatedTs: createdTs,
+		}
+		if id := identity.FromContext(reqCtx); id != nil {
+			entry.TenantID = id.Tenant
+			entry.Subject = id.Subject
+		}
+		if claims := claimsFromContext(reqCtx); claims != nil {
+			entry.SubjectType = claims.subjectType()
+			entry.TokenID = claims.tokenID()
+		}
+		l := log.FromContext(reqCtx)
+		ctx, cancel := context.WithTimeout(
+			log.WithContext(context.Background(), l), auditLogTimeout)
+		defer cancel()
+		if err := mc.reporting.RecordAuditLogEntry(ctx, entry); err != nil {
+			l.Errorf("failed to record the audit log entry: %s", err)
+		}
+	}
+}
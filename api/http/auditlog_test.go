@@ -0,0 +1,98 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementAuditLog(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "123456789012345678901234"
+		subject  = "851f90b3-cee5-425e-8f6e-b36de1993e7e"
+		body     = `{"filters":[]}`
+	)
+	testCases := map[string]struct {
+		enabled   bool
+		recordErr error
+	}{
+		"ok, disabled": {},
+		"ok, enabled": {
+			enabled: true,
+		},
+		"ok, failed to record the entry": {
+			enabled:   true,
+			recordErr: errors.New("internal error"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			app.On("SearchDevices", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return([]inventory.Device{}, 0, nil)
+			if tc.enabled {
+				app.On("RecordAuditLogEntry", contextMatcher,
+					mock.MatchedBy(func(entry *model.AuditLogEntry) bool {
+						return entry.TenantID == tenantID &&
+							entry.Subject == subject &&
+							entry.Method == http.MethodPost &&
+							entry.Path == URIManagement+URIInventorySearch &&
+							entry.Query == "page=2" &&
+							entry.Body == body &&
+							entry.Status == http.StatusOK &&
+							!entry.CreatedTs.IsZero()
+					})).
+					Return(tc.recordErr)
+			}
+
+			router := NewRouter(app, WithAuditLog(tc.enabled))
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodPost,
+				URIManagement+URIInventorySearch+"?page=2",
+				strings.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: subject,
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+var (
+	ErrTokenExpired = errors.New("the token has expired")
+)
+
+type claimsContextKey struct{}
+
+// claims are the claims of the JWTs of the management API: on top of the
+// identity, the personal access tokens carry their ID and an expiry date,
+// and the service accounts are not users but belong to the tenant
+type claims struct {
+	identity.Identity
+	ID                  string  `json:"jti,omitempty"`
+	ExpiresAt           float64 `json:"exp,omitempty"`
+	PersonalAccessToken bool    `json:"mender.pat,omitempty"`
+	ServiceAccount      bool    `json:"mender.service_account,omitempty"`
+}
+
+// parseClaims decodes the claims of the JWT; the signature is not
+// verified, as the token was already verified by the API gateway
+func parseClaims(token string, now time.Time) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("identity: incorrect token format")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "identity: failed to decode base64 JWT claims")
+	}
+	res := &claims{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, errors.Wrap(err, "identity: failed to decode JSON JWT claims")
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	if res.ExpiresAt > 0 && !now.Before(time.Unix(int64(res.ExpiresAt), 0)) {
+		return nil, ErrTokenExpired
+	}
+	return res, nil
+}
+
+// subjectType returns the type of the subject of the token
+func (c *claims) subjectType() string {
+	switch {
+	case c.ServiceAccount:
+		return model.SubjectTypeServiceAccount
+	case c.PersonalAccessToken:
+		return model.SubjectTypePersonalAccessToken
+	case c.IsUser:
+		return model.SubjectTypeUser
+	}
+	return ""
+}
+
+// tokenID returns the ID of the personal access token, if the token is one
+func (c *claims) tokenID() string {
+	if c.PersonalAccessToken {
+		return c.ID
+	}
+	return ""
+}
+
+func claimsFromContext(ctx context.Context) *claims {
+	res, _ := ctx.Value(claimsContextKey{}).(*claims)
+	return res
+}
+
+// authenticate returns a middleware adding the identity of the JWT of the
+// request to its context, and to the context of its logger; the user
+// sessions, the personal access tokens and the JWTs of the service
+// accounts are accepted, the expired tokens are not
+func authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		token, err := identity.ExtractJWTFromHeader(c.Request)
+		var res *claims
+		if err == nil {
+			res, err = parseClaims(token, time.Now())
+		}
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="ManagementJWT"`)
+			renderError(c, http.StatusUnauthorized, err)
+			c.Abort()
+			return
+		}
+
+		logCtx := log.Ctx{}
+		switch {
+		case res.ServiceAccount:
+			logCtx["service_account_id"] = res.Subject
+		case res.IsDevice:
+			logCtx["device_id"] = res.Subject
+		case res.IsUser:
+			logCtx["user_id"] = res.Subject
+		default:
+			logCtx["sub"] = res.Subject
+		}
+		if res.PersonalAccessToken {
+			logCtx["token_id"] = res.ID
+		}
+		if res.Tenant != "" {
+			logCtx["tenant_id"] = res.Tenant
+		}
+		if res.Plan != "" {
+			logCtx["plan"] = res.Plan
+		}
+		ctx = identity.WithContext(ctx, &res.Identity)
+		ctx = context.WithValue(ctx, claimsContextKey{}, res)
+		ctx = log.WithContext(ctx, log.FromContext(ctx).F(logCtx))
+		c.Request = c.Request.WithContext(ctx)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func generateClaimsJWT(c map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString(
+		[]byte(`{"alg":"RS256","typ":"JWT"}`),
+	)
+	b, _ := json.Marshal(c)
+	return header + "." + base64.RawURLEncoding.EncodeToString(b) + ".signature"
+}
+
+func TestParseClaims(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		token string
+
+		subjectType string
+		tokenID     string
+		tenant      string
+		err         error
+	}{
+		"ok, user": {
+			token: generateClaimsJWT(map[string]interface{}{
+				"sub":           "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				"mender.tenant": "123456789012345678901234",
+				"mender.user":   true,
+				"jti":           "0b3a1d55-ecf6-4d2c-a5f1-4a1ad3d5c1d5",
+				"exp":           now.Add(time.Hour).Unix(),
+			}),
+			subjectType: model.SubjectTypeUser,
+			tenant:      "123456789012345678901234",
+		},
+		"ok, personal access token": {
+			token: generateClaimsJWT(map[string]interface{}{
+				"sub":           "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				"mender.tenant": "123456789012345678901234",
+				"mender.user":   true,
+				"mender.pat":    true,
+				"jti":           "0b3a1d55-ecf6-4d2c-a5f1-4a1ad3d5c1d5",
+				"exp":           now.Add(365 * 24 * time.Hour).Unix(),
+			}),
+			subjectType: model.SubjectTypePersonalAccessToken,
+			tokenID:     "0b3a1d55-ecf6-4d2c-a5f1-4a1ad3d5c1d5",
+			tenant:      "123456789012345678901234",
+		},
+		"ok, service account": {
+			token: generateClaimsJWT(map[string]interface{}{
+				"sub":                    "ci-pipeline",
+				"mender.tenant":          "123456789012345678901234",
+				"mender.service_account": true,
+			}),
+			subjectType: model.SubjectTypeServiceAccount,
+			tenant:      "123456789012345678901234",
+		},
+		"error, expired": {
+			token: generateClaimsJWT(map[string]interface{}{
+				"sub":         "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				"mender.user": true,
+				"mender.pat":  true,
+				"exp":         now.Add(-time.Second).Unix(),
+			}),
+			err: ErrTokenExpired,
+		},
+		"error, missing subject": {
+			token: generateClaimsJWT(map[string]interface{}{
+				"mender.tenant":          "123456789012345678901234",
+				"mender.service_account": true,
+			}),
+			err: errors.New(`identity: claim "sub" is required`),
+		},
+		"error, malformed token": {
+			token: "token",
+			err:   errors.New("identity: incorrect token format"),
+		},
+		"error, malformed claims": {
+			token: "header.e30=.signature",
+			err: errors.New("identity: failed to decode base64 JWT claims: " +
+				"illegal base64 data at input byte 3"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := parseClaims(tc.token, now)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.subjectType, res.subjectType())
+			assert.Equal(t, tc.tokenID, res.tokenID())
+			assert.Equal(t, tc.tenant, res.Tenant)
+		})
+	}
+}
+
+func TestManagementAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	testCases := map[string]struct {
+		claims map[string]interface{}
+
+		code        int
+		subjectType string
+		tokenID     string
+	}{
+		"ok, personal access token": {
+			claims: map[string]interface{}{
+				"sub":           "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				"mender.tenant": tenantID,
+				"mender.user":   true,
+				"mender.pat":    true,
+				"jti":           "0b3a1d55-ecf6-4d2c-a5f1-4a1ad3d5c1d5",
+				"exp":           time.Now().Add(time.Hour).Unix(),
+			},
+			code:        http.StatusOK,
+			subjectType: model.SubjectTypePersonalAccessToken,
+			tokenID:     "0b3a1d55-ecf6-4d2c-a5f1-4a1ad3d5c1d5",
+		},
+		"ok, service account": {
+			claims: map[string]interface{}{
+				"sub":                    "ci-pipeline",
+				"mender.tenant":          tenantID,
+				"mender.service_account": true,
+			},
+			code:        http.StatusOK,
+			subjectType: model.SubjectTypeServiceAccount,
+		},
+		"error, expired": {
+			claims: map[string]interface{}{
+				"sub":           "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				"mender.tenant": tenantID,
+				"mender.user":   true,
+				"mender.pat":    true,
+				"exp":           time.Now().Add(-time.Minute).Unix(),
+			},
+			code: http.StatusUnauthorized,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			if tc.code == http.StatusOK {
+				app.On("SearchDevices",
+					mock.MatchedBy(func(ctx context.Context) bool {
+						id := identity.FromContext(ctx)
+						return id != nil && id.Tenant == tenantID &&
+							id.Subject == tc.claims["sub"]
+					}),
+					mock.AnythingOfType("*model.SearchParams")).
+					Return([]inventory.Device{}, 0, nil)
+				app.On("RecordAuditLogEntry", contextMatcher,
+					mock.MatchedBy(func(entry *model.AuditLogEntry) bool {
+						return entry.TenantID == tenantID &&
+							entry.Subject == tc.claims["sub"] &&
+							entry.SubjectType == tc.subjectType &&
+							entry.TokenID == tc.tokenID
+					})).
+					Return(nil)
+			}
+
+			router := NewRouter(app, WithAuditLog(true))
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIManagement+URIInventorySearch,
+				strings.NewReader(`{}`),
+			)
+			req.Header.Set("Authorization", "Bearer "+generateClaimsJWT(tc.claims))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.code == http.StatusUnauthorized {
+				var res Error
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, ErrCodeUnauthorized, res.Code)
+				assert.Equal(t, ErrTokenExpired.Error(), res.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	hdrAcceptEncoding  = "Accept-Encoding"
+	hdrContentEncoding = "Content-Encoding"
+	hdrContentLength   = "Content-Length"
+	hdrVary            = "Vary"
+
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+
+	defaultCompressionMinSize = 1024
+)
+
+// encoder is a compressing writer which can be reused
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	encodingGzip: {New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+	encodingDeflate: {New: func() interface{} {
+		return zlib.NewWriter(nil)
+	}},
+}
+
+// negotiateEncoding returns the content coding of the response preferred by
+// the Accept-Encoding header of the request, among gzip and deflate; gzip
+// wins the ties. The "*" wildcard only applies to the codings the header
+// does not list explicitly. It returns an empty string if neither is
+// acceptable.
+func negotiateEncoding(header string) string {
+	qvalues := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding != encodingGzip && coding != encodingDeflate && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		qvalues[coding] = q
+	}
+	var (
+		best  string
+		bestQ float64
+	)
+	// gzip comes first, to win the ties
+	for _, coding := range []string{encodingGzip, encodingDeflate} {
+		q, ok := qvalues[coding]
+		if !ok {
+			q = qvalues["*"]
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the body of the response until it reaches the
+// minimum size, or until it is flushed, and then compresses the rest of it;
+// the smaller bodies are written uncompressed
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf         []byte
+	encoder     encoder
+	passthrough bool
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	switch {
+	case w.encoder != nil:
+		return w.encoder.Write(b)
+	case w.passthrough:
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if w.encoder == nil && !w.passthrough {
+		_ = w.start(true)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.encoder == nil && !w.passthrough {
+		_ = w.start(true)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// start sets the headers of the response and writes the buffered body,
+// compressed if compress and the response can be compressed
+func (w *compressWriter) start(compress bool) error {
+	header := w.Header()
+	status := w.Status()
+	if compress && header.Get(hdrContentEncoding) == "" &&
+		status >= http.StatusOK &&
+		status != http.StatusNoContent &&
+		status != http.StatusNotModified {
+		header.Set(hdrContentEncoding, w.encoding)
+		header.Del(hdrContentLength)
+		w.encoder = encoderPools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	} else {
+		w.passthrough = true
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close writes the rest of the response, uncompressed if it is smaller
+// than the minimum size
+func (w *compressWriter) close() error {
+	if w.encoder == nil && !w.passthrough {
+		return w.start(false)
+	} else if w.encoder == nil {
+		return nil
+	}
+	err := w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	encoderPools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+	return err
+}
+
+// compress returns a middleware compressing the responses with the content
+// coding negotiated with the Accept-Encoding header of the request; it is a
+// no-op if compression is disabled
+func (mc *ManagementController) compress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mc.compression {
+			return
+		}
+		c.Writer.Header().Add(hdrVary, hdrAcceptEncoding)
+		encoding := negotiateEncoding(c.GetHeader(hdrAcceptEncoding))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			return
+		}
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        mc.compressionMinSize,
+		}
+		c.Writer = w
+		defer func() {
+			if err := w.close(); err != nil {
+				_ = c.Error(err)
+			}
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"":                              "",
+		"identity":                      "",
+		"gzip":                          encodingGzip,
+		"deflate":                       encodingDeflate,
+		"deflate, gzip":                 encodingGzip,
+		"gzip;q=0.5, deflate":           encodingDeflate,
+		"GZIP;q=0.8, br":                encodingGzip,
+		"gzip;q=0, deflate;q=0":         "",
+		"*":                             encodingGzip,
+		"br, deflate;q=0.1, *;q=0":      encodingDeflate,
+		"gzip;q=0, *":                   encodingDeflate,
+		"*, gzip;q=0":                   encodingDeflate,
+		"deflate;q=0.5, *;q=0.8":        encodingGzip,
+		"gzip;q=0, deflate;q=0, *":      "",
+		"gzip;q=invalid, deflate":       encodingGzip,
+		" deflate ; q=0.9 , gzip;q=0.8": encodingDeflate,
+	}
+	for header, expected := range testCases {
+		assert.Equal(t, expected, negotiateEncoding(header), header)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat(`{"name":"ip4","value":"10.0.0.2"}`, 100)
+	testCases := map[string]struct {
+		Enabled        bool
+		AcceptEncoding string
+		Body           string
+		Chunks         int
+		Status         int
+
+		Encoding string
+	}{
+		"ok, gzip": {
+			Enabled:        true,
+			AcceptEncoding: "gzip, deflate",
+			Body:           large,
+			Status:         http.StatusOK,
+
+			Encoding: encodingGzip,
+		},
+		"ok, deflate": {
+			Enabled:        true,
+			AcceptEncoding: "deflate",
+			Body:           large,
+			Status:         http.StatusOK,
+
+			Encoding: encodingDeflate,
+		},
+		"ok, streamed": {
+			Enabled:        true,
+			AcceptEncoding: "gzip",
+			Body:           `{"id":"1"}`,
+			Chunks:         3,
+			Status:         http.StatusOK,
+
+			Encoding: encodingGzip,
+		},
+		"ok, small body": {
+			Enabled:        true,
+			AcceptEncoding: "gzip",
+			Body:           `{"id":"1"}`,
+			Status:         http.StatusOK,
+		},
+		"ok, no content": {
+			Enabled:        true,
+			AcceptEncoding: "gzip",
+			Status:         http.StatusNoContent,
+		},
+		"ok, not accepted": {
+			Enabled:        true,
+			AcceptEncoding: "br",
+			Body:           large,
+			Status:         http.StatusOK,
+		},
+		"ok, disabled": {
+			AcceptEncoding: "gzip",
+			Body:           large,
+			Status:         http.StatusOK,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mc := NewManagementController(nil, WithCompression(tc.Enabled, 1024))
+			router := gin.New()
+			router.GET("/", mc.compress(), func(c *gin.Context) {
+				c.Status(tc.Status)
+				if tc.Chunks == 0 {
+					_, _ = c.Writer.WriteString(tc.Body)
+					return
+				}
+				for i := 0; i < tc.Chunks; i++ {
+					_, _ = c.Writer.WriteString(tc.Body)
+					c.Writer.Flush()
+				}
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(hdrAcceptEncoding, tc.AcceptEncoding)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Status, w.Code)
+			assert.Equal(t, tc.Encoding, w.Header().Get(hdrContentEncoding))
+			if tc.Enabled {
+				assert.Equal(t, hdrAcceptEncoding, w.Header().Get(hdrVary))
+			}
+
+			var body io.Reader = w.Body
+			switch tc.Encoding {
+			case encodingGzip:
+				r, err := gzip.NewReader(w.Body)
+				if !assert.NoError(t, err) {
+					return
+				}
+				body = r
+			case encodingDeflate:
+				r, err := zlib.NewReader(w.Body)
+				if !assert.NoError(t, err) {
+					return
+				}
+				body = r
+			}
+			var actual bytes.Buffer
+			_, err := io.Copy(&actual, body)
+			assert.NoError(t, err)
+			expected := tc.Body
+			if tc.Chunks > 0 {
+				expected = strings.Repeat(tc.Body, tc.Chunks)
+			}
+			assert.Equal(t, expected, actual.String())
+		})
+	}
+}
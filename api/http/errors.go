@@ -0,0 +1,143 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/requestid"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/client/breaker"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// tooManyQueriesRetryAfter is the delay, in seconds, after which the
+// clients retry the queries rejected by the store's concurrency limit
+const tooManyQueriesRetryAfter = "1"
+
+// Machine-readable codes of the API errors
+const (
+	ErrCodeInvalidRequest        = "invalid_request"
+	ErrCodeUnauthorized          = "unauthorized"
+	ErrCodeValidationFailed      = "validation_failed"
+	ErrCodeInvalidCursor         = "invalid_cursor"
+	ErrCodeCursorExpired         = "cursor_expired"
+	ErrCodeAttributeNotNumeric   = "attribute_not_numeric"
+	ErrCodeAttributeNotVisible   = "attribute_not_visible"
+	ErrCodeNotFound              = "not_found"
+	ErrCodeConflict              = "conflict"
+	ErrCodeRateLimited           = "rate_limited"
+	ErrCodeInternal              = "internal_error"
+	ErrCodeServiceUnavailable    = "service_unavailable"
+	ErrCodeDependencyUnavailable = "dependency_unavailable"
+)
+
+// Error is the error response of all the API end-points: a human-readable
+// description of the error along with a machine-readable code, for the
+// clients to tell the errors apart, and the details of the error, e.g.
+// the fields which failed the validation
+type Error struct {
+	Code      string      `json:"code"`
+	Err       string      `json:"error"`
+	RequestID string      `json:"request_id,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+func (err Error) Error() string {
+	return err.Err
+}
+
+// errorCodes are the codes of the known errors, taking precedence over
+// the code derived from the status
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{err: model.ErrInvalidCursor, code: ErrCodeInvalidCursor},
+	{err: reporting.ErrCursorExpired, code: ErrCodeCursorExpired},
+	{err: reporting.ErrAggregationAttributeNotNumeric, code: ErrCodeAttributeNotNumeric},
+	{err: reporting.ErrAttributeNotVisible, code: ErrCodeAttributeNotVisible},
+	{err: store.ErrSavedSearchNotFound, code: ErrCodeNotFound},
+	{err: store.ErrDeadLetterNotFound, code: ErrCodeNotFound},
+	{err: store.ErrAlertNotFound, code: ErrCodeNotFound},
+	{err: store.ErrSearchJobNotFound, code: ErrCodeNotFound},
+	{err: reporting.ErrDeviceNotFound, code: ErrCodeNotFound},
+	{err: reporting.ErrReindexStateNotFound, code: ErrCodeNotFound},
+	{err: ErrTooManyRequests, code: ErrCodeRateLimited},
+	{err: breaker.ErrOpen, code: ErrCodeDependencyUnavailable},
+	{err: store.ErrTooManyQueries, code: ErrCodeServiceUnavailable},
+}
+
+// errorCode returns the code of the error rendered with the given status
+func errorCode(status int, err error) string {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+	switch status {
+	case http.StatusBadRequest:
+		var validationErr validation.Errors
+		if errors.As(err, &validationErr) {
+			return ErrCodeValidationFailed
+		}
+		return ErrCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrCodeServiceUnavailable
+	}
+	return ErrCodeInternal
+}
+
+// newError returns the error response for the error rendered with the
+// given status
+func newError(c *gin.Context, status int, err error) Error {
+	res := Error{
+		Code:      errorCode(status, err),
+		Err:       err.Error(),
+		RequestID: requestid.FromContext(c.Request.Context()),
+	}
+	var validationErr validation.Errors
+	if errors.As(err, &validationErr) {
+		res.Details = validationErr
+	}
+	return res
+}
+
+// renderError renders the error response with the given status, and
+// records the error in the context for the access log; the queries
+// rejected by the store's concurrency limit are always rendered as 503,
+// for the clients to retry them
+func renderError(c *gin.Context, status int, err error) {
+	_ = c.Error(err)
+	if errors.Is(err, store.ErrTooManyQueries) {
+		status = http.StatusServiceUnavailable
+		c.Header(hdrRetryAfter, tooManyQueriesRetryAfter)
+	}
+	c.JSON(status, newError(c, status, err))
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/client/breaker"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+func TestRenderError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name string
+
+		Status int
+		Err    error
+
+		Response string
+	}{{
+		Name: "invalid request",
+
+		Status:   http.StatusBadRequest,
+		Err:      errors.New("missing tenant ID from the context"),
+		Response: `{"code": "invalid_request", "error": "missing tenant ID from the context"}`,
+	}, {
+		Name: "unauthorized",
+
+		Status:   http.StatusUnauthorized,
+		Err:      ErrTokenExpired,
+		Response: `{"code": "unauthorized", "error": "the token has expired"}`,
+	}, {
+		Name: "validation failed",
+
+		Status: http.StatusBadRequest,
+		Err: errors.Wrap(model.SavedSearch{}.Validate(),
+			"malformed request body"),
+		Response: `{"code": "validation_failed",
+			"error": "malformed request body: name: cannot be blank.",
+			"details": {"name": "cannot be blank"}}`,
+	}, {
+		Name: "invalid cursor",
+
+		Status: http.StatusBadRequest,
+		Err:    errors.Wrap(model.ErrInvalidCursor, "malformed request body"),
+		Response: `{"code": "invalid_cursor",
+			"error": "malformed request body: invalid cursor"}`,
+	}, {
+		Name: "cursor expired",
+
+		Status: http.StatusBadRequest,
+		Err:    reporting.ErrCursorExpired,
+		Response: `{"code": "cursor_expired",
+			"error": "point in time not found or expired"}`,
+	}, {
+		Name: "not found",
+
+		Status:   http.StatusNotFound,
+		Err:      store.ErrSavedSearchNotFound,
+		Response: `{"code": "not_found", "error": "saved search not found"}`,
+	}, {
+		Name: "conflict",
+
+		Status: http.StatusConflict,
+		Err:    store.ErrSavedSearchNameConflict,
+		Response: `{"code": "conflict",
+			"error": "a saved search with the same name already exists"}`,
+	}, {
+		Name: "rate limited",
+
+		Status: http.StatusTooManyRequests,
+		Err:    ErrTooManyRequests,
+		Response: `{"code": "rate_limited",
+			"error": "too many requests, please retry later"}`,
+	}, {
+		Name: "dependency unavailable",
+
+		Status: http.StatusInternalServerError,
+		Err:    errors.Wrap(breaker.ErrOpen, "failed to get devices from inventory"),
+		Response: `{"code": "dependency_unavailable",
+			"error": "failed to get devices from inventory: circuit breaker is open"}`,
+	}, {
+		Name: "service unavailable",
+
+		Status: http.StatusServiceUnavailable,
+		Err:    reporting.ErrReindexNotAvailable,
+		Response: `{"code": "service_unavailable",
+			"error": "reindexing is not available"}`,
+	}, {
+		Name: "internal error",
+
+		Status:   http.StatusInternalServerError,
+		Err:      errors.New("connection refused"),
+		Response: `{"code": "internal_error", "error": "connection refused"}`,
+	}}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			renderError(c, tc.Status, tc.Err)
+			assert.Equal(t, tc.Status, w.Code)
+			assert.JSONEq(t, tc.Response, w.Body.String())
+			assert.Len(t, c.Errors, 1)
+		})
+	}
+}
+
+func TestRenderErrorTooManyQueries(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	renderError(c, http.StatusInternalServerError,
+		errors.Wrap(store.ErrTooManyQueries, "failed to search devices"))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get(hdrRetryAfter))
+	assert.JSONEq(t, `{"code": "service_unavailable", "error": `+
+		`"failed to search devices: too many concurrent queries, please retry later"}`,
+		w.Body.String())
+}
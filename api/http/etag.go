@@ -0,0 +1,69 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	hdrETag        = "ETag"
+	hdrIfNoneMatch = "If-None-Match"
+)
+
+// renderJSONWithETag renders the JSON body of the response with an ETag
+// hashing the body and the total count of the results; if the ETag matches
+// the If-None-Match header of the request, the response is a 304 Not
+// Modified without body. The ETag is weak: the body may be compressed.
+func renderJSONWithETag(c *gin.Context, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+	hash := sha256.New()
+	_, _ = hash.Write(body)
+	_, _ = hash.Write([]byte(c.Writer.Header().Get(hdrTotalCount)))
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	c.Header(hdrETag, etag)
+	if etagMatches(c.GetHeader(hdrIfNoneMatch), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, gin.MIMEJSON+"; charset=utf-8", body)
+}
+
+// etagMatches compares the ETag with the ones of the If-None-Match header,
+// with the weak comparison
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+)
+
+func TestETagMatches(t *testing.T) {
+	t.Parallel()
+
+	const etag = `W/"0123456789abcdef"`
+	testCases := map[string]bool{
+		"":                     false,
+		`W/"0123456789abcdef"`: true,
+		`"0123456789abcdef"`:   true,
+		`"fedcba9876543210", W/"0123456789abcdef"`: true,
+		`"fedcba9876543210"`:                       false,
+		"*":                                        true,
+	}
+	for ifNoneMatch, expected := range testCases {
+		assert.Equal(t, expected, etagMatches(ifNoneMatch, etag), ifNoneMatch)
+	}
+}
+
+func TestManagementSearchDevicesETag(t *testing.T) {
+	t.Parallel()
+
+	devs := []inventory.Device{{
+		ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+		Attributes: inventory.DeviceAttributes{{
+			Scope: "inventory",
+			Name:  "ip4",
+			Value: "10.0.0.2",
+		}},
+	}}
+	app := new(mapp.App)
+	defer app.AssertExpectations(t)
+	app.On("SearchDevices", contextMatcher, mock.AnythingOfType("*model.SearchParams")).
+		Return(devs, 1, nil).
+		Times(2)
+	app.On("SearchDevices", contextMatcher, mock.AnythingOfType("*model.SearchParams")).
+		Return(devs, 2, nil).
+		Once()
+	router := NewRouter(app)
+
+	search := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(
+			http.MethodPost,
+			URIManagement+URIInventorySearch,
+			bytes.NewReader([]byte("{}")),
+		)
+		req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  "123456789012345678901234",
+		}))
+		if ifNoneMatch != "" {
+			req.Header.Set(hdrIfNoneMatch, ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := search("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get(hdrETag)
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	var res []inventory.Device
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+	// nothing changed: not modified
+	w = search(etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get(hdrETag))
+	assert.Empty(t, w.Body.String())
+
+	// the total count changed
+	w = search(etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get(hdrETag))
+	assert.NotEmpty(t, w.Body.String())
+}
@@ -19,8 +19,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
-
 	"github.com/mendersoftware/reporting/app/reporting"
 )
 
@@ -44,8 +42,19 @@ func (h InternalController) Alive(c *gin.Context) {
 func (h InternalController) Health(c *gin.Context) {
 	err := h.reporting.HealthCheck(c.Request.Context())
 	if err != nil {
-		rest.RenderError(c, http.StatusInternalServerError, err)
+		renderError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// Readiness responds to GET /readiness with the health of each of the
+// service's dependencies
+func (h InternalController) Readiness(c *gin.Context) {
+	report := h.reporting.CheckDependencies(c.Request.Context())
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
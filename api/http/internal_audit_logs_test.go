@@ -0,0 +1,135 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestInternalAuditLogs(t *testing.T) {
+	t.Parallel()
+	entry := model.AuditLogEntry{
+		ID:        "7d9f5b8e-3c41-4f0e-a1c9-0a4c2b1d8e6f",
+		TenantID:  "123456789012345678901234",
+		Subject:   "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+		Method:    http.MethodPost,
+		Path:      URIManagement + URIInventorySearch,
+		Body:      `{"filters":[]}`,
+		Status:    http.StatusOK,
+		CreatedTs: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	type testCase struct {
+		Name string
+
+		Path string
+		App  func(*testing.T, testCase) *mapp.App
+
+		Code       int
+		TotalCount string
+		Response   interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Path: URIAuditLogs + "?tenant_id=123456789012345678901234" +
+			"&subject=851f90b3-cee5-425e-8f6e-b36de1993e7e" +
+			"&from=2023-01-02T00:00:00Z&per_page=10",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ListAuditLogs", contextMatcher, model.AuditLogsParams{
+				TenantID: "123456789012345678901234",
+				Subject:  "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				From:     time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				Page:     1,
+				PerPage:  10,
+			}).Return([]model.AuditLogEntry{entry}, 1, nil)
+			return app
+		},
+
+		Code:       http.StatusOK,
+		TotalCount: "1",
+		Response:   []model.AuditLogEntry{entry},
+	}, {
+		Name: "error, invalid time range",
+
+		Path: URIAuditLogs + "?from=2023-01-02T00:00:00Z&to=2023-01-01T00:00:00Z",
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed query parameters: to: must not be before from.",
+		},
+	}, {
+		Name: "error, internal app error",
+
+		Path: URIAuditLogs,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ListAuditLogs", contextMatcher, model.AuditLogsParams{
+				Page:    1,
+				PerPage: 20,
+			}).Return(nil, 0, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			req, _ := http.NewRequest(http.MethodGet, URIInternal+tc.Path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+			assert.Equal(t, tc.TotalCount, w.Header().Get(hdrTotalCount))
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const paramDeadLetterID = "id"
+
+func (mc *InternalController) ListDeadLetters(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var params model.DeadLettersParams
+	err := c.ShouldBindQuery(&params)
+	if err == nil {
+		err = params.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed query parameters"),
+		)
+		return
+	}
+	params.Normalize()
+
+	res, total, err := mc.reporting.ListDeadLetters(ctx, params)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	pageLinkHdrs(c, params.Page, params.PerPage, total)
+
+	c.Header(hdrTotalCount, strconv.Itoa(total))
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *InternalController) GetDeadLetter(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	res, err := mc.reporting.GetDeadLetter(ctx, c.Param(paramDeadLetterID))
+	if err == reporting.ErrDeadLetterNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *InternalController) ReplayDeadLetter(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	err := mc.reporting.ReplayDeadLetter(ctx, c.Param(paramDeadLetterID))
+	switch err {
+	case nil:
+		c.Status(http.StatusAccepted)
+	case reporting.ErrDeadLetterNotFound:
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+	case reporting.ErrReindexNotAvailable:
+		renderError(c,
+			http.StatusServiceUnavailable,
+			err,
+		)
+	default:
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+}
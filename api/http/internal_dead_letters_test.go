@@ -0,0 +1,224 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestInternalDeadLetters(t *testing.T) {
+	t.Parallel()
+	const letterID = "2e3ad8b6-16d5-4b4e-a5c7-d8fa1ed2f3d2"
+	letter := model.DeadLetter{
+		ID:         letterID,
+		TenantID:   "123456789012345678901234",
+		Subject:    "WORKFLOWS.reporting",
+		Data:       `{"action":"reindex"}`,
+		Reason:     "failed to bulk index the devices",
+		Deliveries: 3,
+		CreatedTs:  time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	type testCase struct {
+		Name string
+
+		Method string
+		Path   string
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code       int
+		TotalCount string
+		Response   interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok, list",
+
+		Method: http.MethodGet,
+		Path:   URIDeadLetters + "?tenant_id=123456789012345678901234&per_page=10",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ListDeadLetters", contextMatcher, model.DeadLettersParams{
+				TenantID: "123456789012345678901234",
+				Page:     1,
+				PerPage:  10,
+			}).Return([]model.DeadLetter{letter}, 1, nil)
+			return app
+		},
+
+		Code:       http.StatusOK,
+		TotalCount: "1",
+		Response:   []model.DeadLetter{letter},
+	}, {
+		Name: "error, list, invalid parameters",
+
+		Method: http.MethodGet,
+		Path:   URIDeadLetters + "?per_page=1000",
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed query parameters: per_page: must be no greater than 500.",
+		},
+	}, {
+		Name: "error, list, internal app error",
+
+		Method: http.MethodGet,
+		Path:   URIDeadLetters,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ListDeadLetters", contextMatcher, model.DeadLettersParams{
+				Page:    1,
+				PerPage: 20,
+			}).Return(nil, 0, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "ok, get",
+
+		Method: http.MethodGet,
+		Path:   URIDeadLetter,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetDeadLetter", contextMatcher, letterID).
+				Return(&letter, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: letter,
+	}, {
+		Name: "error, get, not found",
+
+		Method: http.MethodGet,
+		Path:   URIDeadLetter,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetDeadLetter", contextMatcher, letterID).
+				Return(nil, reporting.ErrDeadLetterNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrDeadLetterNotFound.Error()},
+	}, {
+		Name: "ok, replay",
+
+		Method: http.MethodPost,
+		Path:   URIDeadLetterReplay,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReplayDeadLetter", contextMatcher, letterID).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusAccepted,
+	}, {
+		Name: "error, replay, not found",
+
+		Method: http.MethodPost,
+		Path:   URIDeadLetterReplay,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReplayDeadLetter", contextMatcher, letterID).
+				Return(reporting.ErrDeadLetterNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrDeadLetterNotFound.Error()},
+	}, {
+		Name: "error, replay not available",
+
+		Method: http.MethodPost,
+		Path:   URIDeadLetterReplay,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReplayDeadLetter", contextMatcher, letterID).
+				Return(reporting.ErrReindexNotAvailable)
+			return app
+		},
+
+		Code:     http.StatusServiceUnavailable,
+		Response: Error{Err: reporting.ErrReindexNotAvailable.Error()},
+	}, {
+		Name: "error, replay, internal app error",
+
+		Method: http.MethodPost,
+		Path:   URIDeadLetterReplay,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReplayDeadLetter", contextMatcher, letterID).
+				Return(errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":id", letterID)
+			req, _ := http.NewRequest(tc.Method, URIInternal+repl.Replace(tc.Path), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+			assert.Equal(t, tc.TotalCount, w.Header().Get(hdrTotalCount))
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case nil:
+				assert.Empty(t, w.Body.String())
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
@@ -22,7 +22,9 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/go-lib-micro/identity"
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
 )
 
 func (mc *InternalController) SearchDevices(c *gin.Context) {
@@ -34,7 +36,7 @@ func (mc *InternalController) SearchDevices(c *gin.Context) {
 	params, err := parseSearchDevicesParams(ctx, c)
 
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusBadRequest,
 			errors.Wrap(err, "malformed request body"),
 		)
@@ -43,8 +45,8 @@ func (mc *InternalController) SearchDevices(c *gin.Context) {
 
 	res, total, err := mc.reporting.SearchDevices(ctx, params)
 	if err != nil {
-		rest.RenderError(c,
-			http.StatusInternalServerError,
+		renderError(c,
+			searchErrorStatus(err),
 			err,
 		)
 		return
@@ -55,3 +57,207 @@ func (mc *InternalController) SearchDevices(c *gin.Context) {
 	c.Header(hdrTotalCount, strconv.Itoa(total))
 	c.JSON(http.StatusOK, res)
 }
+
+// GetDevice returns the indexed document of a single device of the tenant
+func (mc *InternalController) GetDevice(c *gin.Context) {
+	tid := c.Param("tenant_id")
+
+	ctx := c.Request.Context()
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: tid})
+
+	res, err := mc.reporting.GetDevice(ctx, tid, c.Param("id"))
+	if err == reporting.ErrDeviceNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// DiffDevice compares the indexed document of a device of the tenant with
+// its live state in inventory and deviceauth
+func (mc *InternalController) DiffDevice(c *gin.Context) {
+	tid := c.Param("tenant_id")
+
+	ctx := c.Request.Context()
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: tid})
+
+	res, err := mc.reporting.DiffDevice(ctx, tid, c.Param("id"))
+	if err == reporting.ErrDeviceNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err == reporting.ErrDeviceDiffNotAvailable {
+		renderError(c,
+			http.StatusServiceUnavailable,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// SearchDevicesAcrossTenants searches the devices of the listed tenants, or
+// of all of them, for the platform-wide analytics of the fleet
+func (mc *InternalController) SearchDevicesAcrossTenants(c *gin.Context) {
+	var params model.TenantsSearchParams
+	err := c.ShouldBindJSON(&params)
+	if err == nil {
+		if params.PerPage <= 0 {
+			params.PerPage = ParamPerPageDefault
+		}
+		if params.Page <= 0 {
+			params.Page = ParamPageDefault
+		}
+		err = params.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	res, total, err := mc.reporting.SearchDevicesAcrossTenants(c.Request.Context(), &params)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Header(hdrTotalCount, strconv.Itoa(total))
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *InternalController) ReindexDevices(c *gin.Context) {
+	tid := c.Param("tenant_id")
+	ctx := c.Request.Context()
+
+	var req model.ReindexDevicesRequest
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	err = mc.reporting.ReindexDevices(ctx, tid, req.DeviceIDs)
+	if err == reporting.ErrReindexNotAvailable {
+		renderError(c,
+			http.StatusServiceUnavailable,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// GetReindexState returns the checkpoint of the rebuild of the tenant's
+// devices index
+func (mc *InternalController) GetReindexState(c *gin.Context) {
+	tid := c.Param("tenant_id")
+	ctx := c.Request.Context()
+
+	state, err := mc.reporting.GetReindexState(ctx, tid)
+	if err == reporting.ErrReindexStateNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// ResetReindexState deletes the checkpoint of the rebuild of the tenant's
+// devices index, for the next rebuild to start from scratch
+func (mc *InternalController) ResetReindexState(c *gin.Context) {
+	tid := c.Param("tenant_id")
+	ctx := c.Request.Context()
+
+	err := mc.reporting.ResetReindexState(ctx, tid)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportDevices exports the tenant's devices matching the search as a
+// Parquet file uploaded to the export storage, for the data warehouses
+func (mc *InternalController) ExportDevices(c *gin.Context) {
+	tid := c.Param("tenant_id")
+
+	ctx := c.Request.Context()
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: tid})
+
+	params, err := parseSearchDevicesParams(ctx, c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	export, err := mc.reporting.ExportDevicesToStorage(ctx, params)
+	if err == reporting.ErrExportNotAvailable {
+		renderError(c,
+			http.StatusServiceUnavailable,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusCreated, export)
+}
@@ -27,8 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
-
+	"github.com/mendersoftware/reporting/app/reporting"
 	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
 	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/model"
@@ -156,7 +155,7 @@ func TestInternalSearchDevices(t *testing.T) {
 			TenantID: "123456789012345678901234",
 		},
 		Code:     http.StatusBadRequest,
-		Response: rest.Error{Err: "malformed request body: type: must be a valid value."},
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
 	}, {
 		Name: "error, internal app error",
 
@@ -188,7 +187,7 @@ func TestInternalSearchDevices(t *testing.T) {
 		},
 
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "internal error"},
+		Response: Error{Err: "internal error"},
 	}}
 	for i := range testCases {
 		tc := testCases[i]
@@ -220,12 +219,12 @@ func TestInternalSearchDevices(t *testing.T) {
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -239,3 +238,734 @@ func TestInternalSearchDevices(t *testing.T) {
 		})
 	}
 }
+
+func TestInternalSearchDevicesAcrossTenants(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		Name string
+
+		App  func(*testing.T, testCase) *mapp.App
+		Body interface{}
+
+		Code       int
+		TotalCount string
+		Response   interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevicesAcrossTenants", contextMatcher,
+				&model.TenantsSearchParams{
+					SearchParams: model.SearchParams{
+						Page:    ParamPageDefault,
+						PerPage: 5,
+						Filters: []model.FilterPredicate{{
+							Scope:     model.ScopeInventory,
+							Attribute: "ip4",
+							Type:      "$exists",
+							Value:     true,
+						}},
+					},
+					TenantIDs: []string{"tenant1", "tenant2"},
+				}).
+				Return(self.Response, 3, nil)
+			return app
+		},
+		Body: map[string]interface{}{
+			"per_page": 5,
+			"filters": []map[string]interface{}{{
+				"scope":     model.ScopeInventory,
+				"attribute": "ip4",
+				"type":      "$exists",
+				"value":     true,
+			}},
+			"tenant_ids": []string{"tenant1", "tenant2"},
+		},
+
+		Code:       http.StatusOK,
+		TotalCount: "3",
+		Response: []reporting.TenantDevices{{
+			TenantID: "tenant1",
+			Count:    3,
+			Devices: []inventory.Device{{
+				ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+			}},
+		}, {
+			TenantID: "tenant2",
+			Count:    0,
+			Devices:  []inventory.Device{},
+		}},
+	}, {
+		Name: "ok, all tenants",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevicesAcrossTenants", contextMatcher,
+				&model.TenantsSearchParams{
+					SearchParams: model.SearchParams{
+						Page:    ParamPageDefault,
+						PerPage: ParamPerPageDefault,
+					},
+				}).
+				Return(self.Response, 0, nil)
+			return app
+		},
+		Body: map[string]interface{}{},
+
+		Code:       http.StatusOK,
+		TotalCount: "0",
+		Response:   []reporting.TenantDevices{},
+	}, {
+		Name: "error, malformed request body",
+
+		Body: map[string]interface{}{
+			"tenant_ids": []string{""},
+		},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: tenant_ids: (0: cannot be blank.)."},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevicesAcrossTenants", contextMatcher,
+				mock.AnythingOfType("*model.TenantsSearchParams")).
+				Return(nil, 0, errors.New("internal error"))
+			return app
+		},
+		Body: map[string]interface{}{},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			b, _ := json.Marshal(tc.Body)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIInternal+URIInventorySearch,
+				bytes.NewReader(b),
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case []reporting.TenantDevices:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+				assert.Equal(t, tc.TotalCount, w.Header().Get(hdrTotalCount))
+
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				panic("[TEST ERR] Dunno what to compare!")
+			}
+		})
+	}
+}
+
+func TestInternalGetDevice(t *testing.T) {
+	t.Parallel()
+	const (
+		tenantID = "123456789012345678901234"
+		deviceID = "194d1060-1717-44dc-a783-00038f4a8013"
+	)
+	device := &inventory.Device{
+		ID: deviceID,
+		Attributes: inventory.DeviceAttributes{{
+			Name:  "hostname",
+			Scope: inventory.AttrScopeInventory,
+			Value: "edge-gw-1",
+		}, {
+			Name:  "latest_deployment_status",
+			Scope: inventory.AttrScopeSystem,
+			Value: "success",
+		}},
+		CreatedTs: time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC),
+		UpdatedTs: time.Date(2023, 3, 7, 6, 0, 0, 0, time.UTC),
+	}
+	type testCase struct {
+		Name string
+
+		App func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetDevice", contextMatcher, tenantID, deviceID).
+				Return(device, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: device,
+	}, {
+		Name: "error, not found",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetDevice", contextMatcher, tenantID, deviceID).
+				Return(nil, reporting.ErrDeviceNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrDeviceNotFound.Error()},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetDevice", contextMatcher, tenantID, deviceID).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.App(t, tc)
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID, ":id", deviceID)
+			req, _ := http.NewRequest(
+				http.MethodGet,
+				URIInternal+repl.Replace(URIInventoryDeviceInternal),
+				nil,
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestInternalDiffDevice(t *testing.T) {
+	t.Parallel()
+	const (
+		tenantID = "123456789012345678901234"
+		deviceID = "194d1060-1717-44dc-a783-00038f4a8013"
+	)
+	diff := &reporting.DeviceDiff{
+		ID: deviceID,
+		Inventory: &inventory.Device{
+			ID: deviceID,
+			Attributes: inventory.DeviceAttributes{{
+				Name:  "hostname",
+				Scope: inventory.AttrScopeInventory,
+				Value: "edge-gw-1",
+			}},
+		},
+		Reason: "the device is not indexed",
+		Attributes: []reporting.AttributeDiff{{
+			Scope:  inventory.AttrScopeInventory,
+			Name:   "hostname",
+			Status: reporting.AttributeMissing,
+			Live:   "edge-gw-1",
+		}},
+	}
+	type testCase struct {
+		Name string
+
+		App func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DiffDevice", contextMatcher, tenantID, deviceID).
+				Return(diff, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: diff,
+	}, {
+		Name: "error, not found",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DiffDevice", contextMatcher, tenantID, deviceID).
+				Return(nil, reporting.ErrDeviceNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrDeviceNotFound.Error()},
+	}, {
+		Name: "error, not available",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DiffDevice", contextMatcher, tenantID, deviceID).
+				Return(nil, reporting.ErrDeviceDiffNotAvailable)
+			return app
+		},
+
+		Code:     http.StatusServiceUnavailable,
+		Response: Error{Err: reporting.ErrDeviceDiffNotAvailable.Error()},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DiffDevice", contextMatcher, tenantID, deviceID).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.App(t, tc)
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID, ":id", deviceID)
+			req, _ := http.NewRequest(
+				http.MethodGet,
+				URIInternal+repl.Replace(URIInventoryDiffInternal),
+				nil,
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestInternalReindexDevices(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+	type testCase struct {
+		Name string
+
+		App  func(*testing.T, testCase) *mapp.App
+		Body interface{}
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReindexDevices", contextMatcher, tenantID,
+				[]string{"1", "2"}).
+				Return(nil)
+			return app
+		},
+		Body: model.ReindexDevicesRequest{
+			DeviceIDs: []string{"1", "2"},
+		},
+
+		Code: http.StatusAccepted,
+	}, {
+		Name: "error, malformed request body",
+
+		Body: map[string]interface{}{
+			"device_ids": "1",
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: json: cannot unmarshal " +
+			"string into Go struct field ReindexDevicesRequest.device_ids of type []string"},
+	}, {
+		Name: "error, no devices",
+
+		Body: model.ReindexDevicesRequest{},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: device_ids: cannot be blank."},
+	}, {
+		Name: "error, reindex not available",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReindexDevices", contextMatcher, tenantID,
+				[]string{"1"}).
+				Return(reporting.ErrReindexNotAvailable)
+			return app
+		},
+		Body: model.ReindexDevicesRequest{
+			DeviceIDs: []string{"1"},
+		},
+
+		Code:     http.StatusServiceUnavailable,
+		Response: Error{Err: reporting.ErrReindexNotAvailable.Error()},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ReindexDevices", contextMatcher, tenantID,
+				[]string{"1"}).
+				Return(errors.New("internal error"))
+			return app
+		},
+		Body: model.ReindexDevicesRequest{
+			DeviceIDs: []string{"1"},
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			b, _ := json.Marshal(tc.Body)
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIInternal+repl.Replace(URIReindexInternal),
+				bytes.NewReader(b),
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case nil:
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestInternalGetReindexState(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+
+	state := &model.ReindexState{
+		TenantID:     tenantID,
+		Index:        "devices-tenant-000002",
+		Page:         3,
+		LastDeviceID: "194d1060-1717-44dc-a783-00038f4a8013",
+		Processed:    300,
+		Status:       model.ReindexStatusInProgress,
+		StartedTs:    time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC),
+		UpdatedTs:    time.Date(2023, 3, 6, 6, 5, 0, 0, time.UTC),
+	}
+	testCases := map[string]struct {
+		appRes *model.ReindexState
+		appErr error
+
+		code     int
+		response *Error
+	}{
+		"ok": {
+			appRes: state,
+			code:   http.StatusOK,
+		},
+		"error, not found": {
+			appErr:   reporting.ErrReindexStateNotFound,
+			code:     http.StatusNotFound,
+			response: &Error{Err: reporting.ErrReindexStateNotFound.Error()},
+		},
+		"error, internal app error": {
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: &Error{Err: "internal error"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			app.On("GetReindexState", contextMatcher, tenantID).
+				Return(tc.appRes, tc.appErr)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			req, _ := http.NewRequest(
+				http.MethodGet,
+				URIInternal+repl.Replace(URIReindexStateInternal),
+				nil,
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.response != nil {
+				var actual Error
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.EqualError(t, tc.response, actual.Error())
+				}
+			} else {
+				var actual model.ReindexState
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.Equal(t, *tc.appRes, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestInternalResetReindexState(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+
+	testCases := map[string]struct {
+		appErr error
+
+		code     int
+		response *Error
+	}{
+		"ok": {
+			code: http.StatusNoContent,
+		},
+		"error, internal app error": {
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: &Error{Err: "internal error"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			app.On("ResetReindexState", contextMatcher, tenantID).Return(tc.appErr)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			req, _ := http.NewRequest(
+				http.MethodDelete,
+				URIInternal+repl.Replace(URIReindexStateInternal),
+				nil,
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.response != nil {
+				var actual Error
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.EqualError(t, tc.response, actual.Error())
+				}
+			} else {
+				assert.Empty(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestInternalExportDevices(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+	createdTs := time.Date(2023, 5, 2, 10, 0, 0, 0, time.UTC)
+	type testCase struct {
+		Name string
+
+		App  func(*testing.T, testCase) *mapp.App
+		Body interface{}
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevicesToStorage", contextMatcher,
+				mock.MatchedBy(func(params *model.SearchParams) bool {
+					return params.TenantID == tenantID &&
+						params.Page == ParamPageDefault &&
+						params.PerPage == ParamPerPageDefault
+				})).
+				Return(self.Response, nil)
+			return app
+		},
+		Body: model.SearchParams{},
+
+		Code: http.StatusCreated,
+		Response: &model.DevicesExport{
+			Key:       "exports/" + tenantID + "/20230502T100000Z.parquet",
+			Devices:   10,
+			CreatedTs: createdTs,
+		},
+	}, {
+		Name: "error, malformed request body",
+
+		Body: map[string]interface{}{
+			"per_page": "10",
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: json: cannot unmarshal " +
+			"string into Go struct field SearchParams.per_page of type int"},
+	}, {
+		Name: "error, export not available",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevicesToStorage", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(nil, reporting.ErrExportNotAvailable)
+			return app
+		},
+		Body: model.SearchParams{},
+
+		Code:     http.StatusServiceUnavailable,
+		Response: Error{Err: reporting.ErrExportNotAvailable.Error()},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevicesToStorage", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+		Body: model.SearchParams{},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			b, _ := json.Marshal(tc.Body)
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIInternal+repl.Replace(URIInventoryExportInternal),
+				bytes.NewReader(b),
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case *model.DevicesExport:
+				var actual model.DevicesExport
+				err := json.Unmarshal(w.Body.Bytes(), &actual)
+				if assert.NoError(t, err) {
+					assert.Equal(t, *res, actual)
+				}
+			}
+		})
+	}
+}
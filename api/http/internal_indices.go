@@ -0,0 +1,70 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+// UpdateIndexSettings updates the dynamic settings of the existing indices,
+// e.g. to disable the refreshes during mass backfills
+func (mc *InternalController) UpdateIndexSettings(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	index := c.Param("index")
+	if err := model.ValidateIndexName(index); err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "invalid index"),
+		)
+		return
+	}
+
+	var settings model.IndexSettings
+	err := c.ShouldBindJSON(&settings)
+	if err == nil {
+		err = settings.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	err = mc.reporting.UpdateIndexSettings(ctx, index, &settings)
+	if err == reporting.ErrIndexSettingsNotSupported {
+		renderError(c,
+			http.StatusNotImplemented,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestInternalUpdateIndexSettings(t *testing.T) {
+	t.Parallel()
+
+	replicas := 2
+	refreshInterval := "-1"
+	testCases := map[string]struct {
+		index string
+		body  string
+
+		settings *model.IndexSettings
+		appErr   error
+
+		code     int
+		response *Error
+	}{
+		"ok": {
+			index: model.IndexDevices,
+			body:  `{"number_of_replicas": 2, "refresh_interval": "-1"}`,
+			settings: &model.IndexSettings{
+				Replicas:        &replicas,
+				RefreshInterval: &refreshInterval,
+			},
+			code: http.StatusNoContent,
+		},
+		"ok, refresh interval only": {
+			index: model.IndexDeployments,
+			body:  `{"refresh_interval": "-1"}`,
+			settings: &model.IndexSettings{
+				RefreshInterval: &refreshInterval,
+			},
+			code: http.StatusNoContent,
+		},
+		"error, unknown index": {
+			index: "alerts",
+			body:  `{"refresh_interval": "-1"}`,
+			code:  http.StatusBadRequest,
+			response: &Error{
+				Err: "invalid index: must be one of devices, deployments or software",
+			},
+		},
+		"error, no settings": {
+			index:    model.IndexSoftware,
+			body:     `{}`,
+			code:     http.StatusBadRequest,
+			response: &Error{Err: "malformed request body: no settings to update"},
+		},
+		"error, invalid refresh interval": {
+			index: model.IndexDevices,
+			body:  `{"refresh_interval": "soon"}`,
+			code:  http.StatusBadRequest,
+			response: &Error{
+				Err: "malformed request body: refresh_interval: " +
+					"must be a time unit, e.g. 30s, or -1.",
+			},
+		},
+		"error, not supported": {
+			index: model.IndexDevices,
+			body:  `{"number_of_replicas": 2}`,
+			settings: &model.IndexSettings{
+				Replicas: &replicas,
+			},
+			appErr:   reporting.ErrIndexSettingsNotSupported,
+			code:     http.StatusNotImplemented,
+			response: &Error{Err: reporting.ErrIndexSettingsNotSupported.Error()},
+		},
+		"error, internal app error": {
+			index: model.IndexDevices,
+			body:  `{"number_of_replicas": 2}`,
+			settings: &model.IndexSettings{
+				Replicas: &replicas,
+			},
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: &Error{Err: "internal error"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			if tc.settings != nil {
+				app.On("UpdateIndexSettings", contextMatcher, tc.index,
+					mock.MatchedBy(func(settings *model.IndexSettings) bool {
+						return assert.ObjectsAreEqual(tc.settings, settings)
+					})).Return(tc.appErr)
+			}
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":index", tc.index)
+			req, _ := http.NewRequest(
+				http.MethodPut,
+				URIInternal+repl.Replace(URIIndexSettingsInternal),
+				strings.NewReader(tc.body),
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.response != nil {
+				var actual Error
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.EqualError(t, tc.response, actual.Error())
+				}
+			} else {
+				assert.Empty(t, w.Body.Bytes())
+			}
+		})
+	}
+}
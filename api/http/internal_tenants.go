@@ -0,0 +1,93 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+const paramMonth = "month"
+
+// DeleteTenant deletes all the data of the tenant, e.g. once the tenant is
+// offboarded
+func (mc *InternalController) DeleteTenant(c *gin.Context) {
+	tid := c.Param("tenant_id")
+	ctx := c.Request.Context()
+
+	err := mc.reporting.DeleteTenant(ctx, tid)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTenantUsage returns the usage of the reporting service by the tenant,
+// with its queries of the given month, by default the current one
+func (mc *InternalController) GetTenantUsage(c *gin.Context) {
+	tid := c.Param("tenant_id")
+	ctx := c.Request.Context()
+
+	month := c.Query(paramMonth)
+	if month == "" {
+		month = model.UsageMonth(time.Now())
+	} else if err := model.ValidateUsageMonth(month); err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "invalid month"),
+		)
+		return
+	}
+
+	usage, err := mc.reporting.GetTenantUsage(ctx, tid, month)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetAttributesUsage returns how many times the tenant's queries used each
+// attribute in their filters, sorts and aggregations, along with the
+// attributes of the tenant's mapping never used, to evict
+func (mc *InternalController) GetAttributesUsage(c *gin.Context) {
+	tid := c.Param("tenant_id")
+	ctx := c.Request.Context()
+
+	usage, err := mc.reporting.GetAttributesUsage(ctx, tid)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestInternalDeleteTenant(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+
+	testCases := map[string]struct {
+		appErr error
+
+		code     int
+		response *Error
+	}{
+		"ok": {
+			code: http.StatusNoContent,
+		},
+		"error, internal app error": {
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: &Error{Err: "internal error"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			app.On("DeleteTenant", contextMatcher, tenantID).Return(tc.appErr)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			req, _ := http.NewRequest(
+				http.MethodDelete,
+				URIInternal+repl.Replace(URITenantInternal),
+				nil,
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.response != nil {
+				var actual Error
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.EqualError(t, tc.response, actual.Error())
+				}
+			} else {
+				assert.Empty(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestInternalGetTenantUsage(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+
+	usage := &model.TenantUsage{
+		TenantID:   tenantID,
+		Documents:  model.UsageDocuments{Devices: 10},
+		IndexBytes: 4096,
+		Month:      "2023-03",
+		Queries:    42,
+	}
+	testCases := map[string]struct {
+		month  string
+		appRes *model.TenantUsage
+		appErr error
+
+		code     int
+		usage    *model.TenantUsage
+		response *Error
+	}{
+		"ok": {
+			month:  "2023-03",
+			appRes: usage,
+			code:   http.StatusOK,
+			usage:  usage,
+		},
+		"ok, current month": {
+			appRes: usage,
+			code:   http.StatusOK,
+			usage:  usage,
+		},
+		"error, invalid month": {
+			month:    "2023-13",
+			code:     http.StatusBadRequest,
+			response: &Error{Err: "invalid month: must be a month, e.g. 2023-03"},
+		},
+		"error, internal app error": {
+			month:    "2023-03",
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: &Error{Err: "internal error"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			if tc.appRes != nil || tc.appErr != nil {
+				month := tc.month
+				if month == "" {
+					month = model.UsageMonth(time.Now())
+				}
+				app.On("GetTenantUsage", contextMatcher, tenantID, month).
+					Return(tc.appRes, tc.appErr)
+			}
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			url := URIInternal + repl.Replace(URITenantUsageInternal)
+			if tc.month != "" {
+				url += "?month=" + tc.month
+			}
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.response != nil {
+				var actual Error
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.EqualError(t, tc.response, actual.Error())
+				}
+			} else {
+				var actual model.TenantUsage
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.Equal(t, tc.usage, &actual)
+				}
+			}
+		})
+	}
+}
+
+func TestInternalGetAttributesUsage(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+
+	lastUsed := time.Date(2023, 3, 6, 7, 0, 0, 0, time.UTC)
+	usage := &model.AttributesUsage{
+		TenantID: tenantID,
+		Attributes: []model.AttributeUsage{{
+			Scope:      model.ScopeInventory,
+			Name:       "mac",
+			Mapped:     true,
+			Filters:    3,
+			Sorts:      1,
+			LastUsedTs: &lastUsed,
+		}, {
+			Scope:  model.ScopeInventory,
+			Name:   "legacy_serial",
+			Mapped: true,
+		}},
+	}
+	testCases := map[string]struct {
+		appRes *model.AttributesUsage
+		appErr error
+
+		code     int
+		response *Error
+	}{
+		"ok": {
+			appRes: usage,
+			code:   http.StatusOK,
+		},
+		"error, internal app error": {
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: &Error{Err: "internal error"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			app.On("GetAttributesUsage", contextMatcher, tenantID).
+				Return(tc.appRes, tc.appErr)
+			router := NewRouter(app)
+
+			repl := strings.NewReplacer(":tenant_id", tenantID)
+			req, _ := http.NewRequest(
+				http.MethodGet,
+				URIInternal+repl.Replace(URIAttrsUsageInternal),
+				nil,
+			)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.response != nil {
+				var actual Error
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.EqualError(t, tc.response, actual.Error())
+				}
+			} else {
+				var actual model.AttributesUsage
+				err := json.NewDecoder(w.Body).Decode(&actual)
+				if assert.NoError(t, err) {
+					assert.Equal(t, tc.appRes, &actual)
+				}
+			}
+		})
+	}
+}
@@ -25,9 +25,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
-
 	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
 )
 
 var contextMatcher = mock.MatchedBy(func(_ context.Context) bool { return true })
@@ -43,6 +42,18 @@ func TestStatus(t *testing.T) {
 	assert.Equal(t, http.StatusNoContent, w.Code)
 }
 
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	router := NewRouter(nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, URIInternal+URIMetrics, nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
 func TestHealth(t *testing.T) {
 	t.Parallel()
 
@@ -83,7 +94,8 @@ func TestHealth(t *testing.T) {
 			if tc.Error == nil {
 				assert.Nil(t, w.Body.Bytes())
 			} else {
-				err := rest.Error{
+				err := Error{
+					Code:      ErrCodeInternal,
 					Err:       tc.Error.Error(),
 					RequestID: "test",
 				}
@@ -96,3 +108,52 @@ func TestHealth(t *testing.T) {
 		})
 	}
 }
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name string
+
+		Report     *model.HealthReport
+		StatusCode int
+	}{{
+		Name: "ok",
+
+		Report: model.NewHealthReport([]model.DependencyHealth{{
+			Name:   model.DependencyStore,
+			Status: model.HealthStatusOK,
+		}}),
+		StatusCode: http.StatusOK,
+	}, {
+		Name: "error, unhealthy dependency",
+
+		Report: model.NewHealthReport([]model.DependencyHealth{{
+			Name:   model.DependencyStore,
+			Status: model.HealthStatusOK,
+		}, {
+			Name:   model.ServiceInventory,
+			Status: model.HealthStatusError,
+			Error:  "status 503",
+		}}),
+		StatusCode: http.StatusServiceUnavailable,
+	}}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			app := new(mapp.App)
+			app.On("CheckDependencies", contextMatcher).Return(tc.Report)
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, URIInternal+URIReadiness, nil)
+
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.StatusCode, w.Code)
+			b, _ := json.Marshal(tc.Report)
+			assert.JSONEq(t, string(b), w.Body.String())
+		})
+	}
+}
@@ -15,6 +15,10 @@
 package http
 
 import (
+	"context"
+	"time"
+
+	"github.com/mendersoftware/reporting/api/graphql"
 	"github.com/mendersoftware/reporting/app/reporting"
 )
 
@@ -23,14 +27,108 @@ const (
 	ParamPerPageDefault = 20
 
 	hdrTotalCount = "X-Total-Count"
+	hdrNextCursor = "X-Next-Cursor"
+
+	mimeNDJSON = "application/x-ndjson"
+
+	defaultSearchStreamInterval = 5 * time.Second
 )
 
 type ManagementController struct {
-	reporting reporting.App
+	reporting            reporting.App
+	searchStreamInterval time.Duration
+	streamsContext       context.Context
+	rateLimiter          *rateLimiter
+	graphQL              bool
+	graphQLSchema        *graphql.Schema
+	auditLogEnabled      bool
+	compression          bool
+	compressionMinSize   int
+	requestValidation    bool
+}
+
+// Option configures the management API
+type Option func(*ManagementController)
+
+// WithSearchStreamInterval sets the interval at which the streamed searches
+// are refreshed
+func WithSearchStreamInterval(interval time.Duration) Option {
+	return func(mc *ManagementController) {
+		if interval > 0 {
+			mc.searchStreamInterval = interval
+		}
+	}
 }
 
-func NewManagementController(r reporting.App) *ManagementController {
-	return &ManagementController{
-		reporting: r,
+// WithStreamsContext sets a context which ends all the streamed responses
+// when done, e.g. on server shutdown
+func WithStreamsContext(ctx context.Context) Option {
+	return func(mc *ManagementController) {
+		mc.streamsContext = ctx
+	}
+}
+
+// WithTenantRateLimit limits the search and aggregation requests of each
+// tenant to rate requests per second, with bursts of up to burst requests;
+// a non-positive rate disables the rate limiting
+func WithTenantRateLimit(rate float64, burst int) Option {
+	return func(mc *ManagementController) {
+		if rate > 0 {
+			mc.rateLimiter = newRateLimiter(rate, burst)
+		} else {
+			mc.rateLimiter = nil
+		}
+	}
+}
+
+// WithGraphQL enables the GraphQL API over the device and deployment
+// documents
+func WithGraphQL(enabled bool) Option {
+	return func(mc *ManagementController) {
+		mc.graphQL = enabled
+	}
+}
+
+// WithCompression compresses the responses of the search, aggregation and
+// export endpoints, as negotiated with the Accept-Encoding header of the
+// requests; the responses smaller than minSize bytes are sent uncompressed
+func WithCompression(enabled bool, minSize int) Option {
+	return func(mc *ManagementController) {
+		mc.compression = enabled
+		if minSize >= 0 {
+			mc.compressionMinSize = minSize
+		}
+	}
+}
+
+// WithRequestValidation validates the requests to the management and the
+// internal APIs against their OpenAPI specifications
+func WithRequestValidation(enabled bool) Option {
+	return func(mc *ManagementController) {
+		mc.requestValidation = enabled
+	}
+}
+
+// WithAuditLog records the requests to the management API, with their
+// JWT subject and query, in the audit trail
+func WithAuditLog(enabled bool) Option {
+	return func(mc *ManagementController) {
+		mc.auditLogEnabled = enabled
+	}
+}
+
+func NewManagementController(r reporting.App, opts ...Option) *ManagementController {
+	mc := &ManagementController{
+		reporting:            r,
+		searchStreamInterval: defaultSearchStreamInterval,
+		streamsContext:       context.Background(),
+		compressionMinSize:   defaultCompressionMinSize,
+	}
+	for _, opt := range opts {
+		opt(mc)
+	}
+	if mc.graphQL {
+		mc.graphQLSchema = graphql.NewSchema(r)
 	}
+	return mc
 }
@@ -0,0 +1,185 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const paramAlertID = "id"
+
+func (mc *ManagementController) ListAlerts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetAlerts(ctx, id.Tenant)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	for i := range res {
+		redactAlert(&res[i])
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) CreateAlert(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	alert, err := parseAlert(c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	err = mc.reporting.CreateAlert(ctx, alert)
+	if err == reporting.ErrAlertNameConflict {
+		renderError(c,
+			http.StatusConflict,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	redactAlert(alert)
+	c.Header("Location", URIManagement+URIAlerts+"/"+alert.ID)
+	c.JSON(http.StatusCreated, alert)
+}
+
+func (mc *ManagementController) GetAlert(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetAlert(ctx, id.Tenant, c.Param(paramAlertID))
+	if err == reporting.ErrAlertNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	redactAlert(res)
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) UpdateAlert(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	alert, err := parseAlert(c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+	alert.ID = c.Param(paramAlertID)
+
+	err = mc.reporting.UpdateAlert(ctx, alert)
+	switch err {
+	case nil:
+		c.Status(http.StatusNoContent)
+	case reporting.ErrAlertNotFound:
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+	case reporting.ErrAlertNameConflict:
+		renderError(c,
+			http.StatusConflict,
+			err,
+		)
+	default:
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+}
+
+func (mc *ManagementController) DeleteAlert(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	err := mc.reporting.DeleteAlert(ctx, id.Tenant, c.Param(paramAlertID))
+	switch err {
+	case nil:
+		c.Status(http.StatusNoContent)
+	case reporting.ErrAlertNotFound:
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+	default:
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+}
+
+func parseAlert(c *gin.Context) (*model.Alert, error) {
+	var alert model.Alert
+
+	err := c.ShouldBindJSON(&alert)
+	if err != nil {
+		return nil, err
+	}
+
+	if id := identity.FromContext(c.Request.Context()); id != nil {
+		alert.TenantID = id.Tenant
+	} else {
+		return nil, errors.New("missing tenant ID from the context")
+	}
+
+	if err := alert.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
+
+// redactAlert removes the secret of the webhook, which is never returned
+func redactAlert(alert *model.Alert) {
+	alert.Webhook.Secret = ""
+}
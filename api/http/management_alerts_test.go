@@ -0,0 +1,323 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementAlerts(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "123456789012345678901234"
+		alertID  = "0f2b0a36-2d5b-4f5e-9a55-4c7d8e0c1a2b"
+	)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	newAlert := func() *model.Alert {
+		return &model.Alert{
+			ID:       alertID,
+			TenantID: tenantID,
+			Name:     "offline devices",
+			Source:   model.AlertSourceDevices,
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeSystem,
+				Attribute: model.AttrNameGroup,
+				Type:      "$eq",
+				Value:     "production",
+			}},
+			Threshold: model.AlertThreshold{
+				Operator: model.AlertOperatorGreaterThanOrEqual,
+				Value:    10,
+			},
+			Webhook: model.AlertWebhook{
+				URL:    "https://hooks.example.com/alerts",
+				Secret: "secret",
+			},
+			CreatedTs: now,
+			UpdatedTs: now,
+		}
+	}
+	redacted := newAlert()
+	redacted.Webhook.Secret = ""
+	alertPath := URIManagement + URIAlerts + "/" + alertID
+
+	type testCase struct {
+		Name string
+
+		Method string
+		Path   string
+		Body   interface{}
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok, list",
+
+		Method: http.MethodGet,
+		Path:   URIManagement + URIAlerts,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetAlerts", contextMatcher, tenantID).
+				Return([]model.Alert{*newAlert()}, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: []model.Alert{*redacted},
+	}, {
+		Name: "error, list internal error",
+
+		Method: http.MethodGet,
+		Path:   URIManagement + URIAlerts,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetAlerts", contextMatcher, tenantID).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "ok, create",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIAlerts,
+		Body:   newAlert(),
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CreateAlert", contextMatcher,
+				mock.MatchedBy(func(alert *model.Alert) bool {
+					return alert.Name == redacted.Name &&
+						alert.TenantID == tenantID &&
+						alert.Webhook.Secret == "secret"
+				})).
+				Run(func(args mock.Arguments) {
+					alert := args.Get(1).(*model.Alert)
+					*alert = *newAlert()
+				}).
+				Return(nil)
+			return app
+		},
+
+		Code:     http.StatusCreated,
+		Response: redacted,
+	}, {
+		Name: "error, create with invalid webhook",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIAlerts,
+		Body: func() *model.Alert {
+			alert := newAlert()
+			alert.Webhook.URL = "ftp://hooks.example.com"
+			return alert
+		}(),
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed request body: webhook: (url: must be a valid http or https URL.).",
+		},
+	}, {
+		Name: "error, create with invalid threshold",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIAlerts,
+		Body: func() *model.Alert {
+			alert := newAlert()
+			alert.Threshold.Operator = "$eq"
+			return alert
+		}(),
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed request body: threshold: (operator: must be a valid value.).",
+		},
+	}, {
+		Name: "error, create with duplicate name",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIAlerts,
+		Body:   newAlert(),
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CreateAlert", contextMatcher,
+				mock.AnythingOfType("*model.Alert")).
+				Return(reporting.ErrAlertNameConflict)
+			return app
+		},
+
+		Code:     http.StatusConflict,
+		Response: Error{Err: reporting.ErrAlertNameConflict.Error()},
+	}, {
+		Name: "ok, get",
+
+		Method: http.MethodGet,
+		Path:   alertPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetAlert", contextMatcher, tenantID, alertID).
+				Return(newAlert(), nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: redacted,
+	}, {
+		Name: "error, get not found",
+
+		Method: http.MethodGet,
+		Path:   alertPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetAlert", contextMatcher, tenantID, alertID).
+				Return(nil, reporting.ErrAlertNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrAlertNotFound.Error()},
+	}, {
+		Name: "ok, update",
+
+		Method: http.MethodPut,
+		Path:   alertPath,
+		Body:   newAlert(),
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("UpdateAlert", contextMatcher,
+				mock.MatchedBy(func(alert *model.Alert) bool {
+					return alert.ID == alertID &&
+						alert.TenantID == tenantID
+				})).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, update not found",
+
+		Method: http.MethodPut,
+		Path:   alertPath,
+		Body:   newAlert(),
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("UpdateAlert", contextMatcher,
+				mock.AnythingOfType("*model.Alert")).
+				Return(reporting.ErrAlertNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrAlertNotFound.Error()},
+	}, {
+		Name: "ok, delete",
+
+		Method: http.MethodDelete,
+		Path:   alertPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DeleteAlert", contextMatcher, tenantID, alertID).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, delete not found",
+
+		Method: http.MethodDelete,
+		Path:   alertPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DeleteAlert", contextMatcher, tenantID, alertID).
+				Return(reporting.ErrAlertNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrAlertNotFound.Error()},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			var body []byte
+			if tc.Body != nil {
+				body, _ = json.Marshal(tc.Body)
+			}
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				tc.Method,
+				tc.Path,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case nil:
+				assert.Empty(t, w.Body.String())
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
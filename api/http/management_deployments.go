@@ -21,7 +21,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mendersoftware/go-lib-micro/identity"
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/reporting/model"
@@ -32,7 +31,7 @@ func (mc *ManagementController) AggregateDeployments(c *gin.Context) {
 
 	params, err := parseAggregateDeploymentsParams(ctx, c)
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusBadRequest,
 			errors.Wrap(err, "malformed request body"),
 		)
@@ -41,8 +40,8 @@ func (mc *ManagementController) AggregateDeployments(c *gin.Context) {
 
 	res, err := mc.reporting.AggregateDeployments(ctx, params)
 	if err != nil {
-		rest.RenderError(c,
-			http.StatusInternalServerError,
+		renderError(c,
+			searchErrorStatus(err),
 			err,
 		)
 		return
@@ -77,7 +76,7 @@ func (mc *ManagementController) SearchDeployments(c *gin.Context) {
 	ctx := c.Request.Context()
 	params, err := parseDeploymentsSearchParams(ctx, c)
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusBadRequest,
 			errors.Wrap(err, "malformed request body"),
 		)
@@ -86,8 +85,8 @@ func (mc *ManagementController) SearchDeployments(c *gin.Context) {
 
 	res, total, err := mc.reporting.SearchDeployments(ctx, params)
 	if err != nil {
-		rest.RenderError(c,
-			http.StatusInternalServerError,
+		renderError(c,
+			searchErrorStatus(err),
 			err,
 		)
 		return
@@ -96,7 +95,7 @@ func (mc *ManagementController) SearchDeployments(c *gin.Context) {
 	pageLinkHdrs(c, params.Page, params.PerPage, total)
 
 	c.Header(hdrTotalCount, strconv.Itoa(total))
-	c.JSON(http.StatusOK, res)
+	renderJSONWithETag(c, res)
 }
 
 func parseDeploymentsSearchParams(ctx context.Context, c *gin.Context) (
@@ -127,3 +126,36 @@ func parseDeploymentsSearchParams(ctx context.Context, c *gin.Context) (
 
 	return &searchParams, nil
 }
+
+func (mc *ManagementController) GetDeviceDeploymentsTimeline(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var params model.DeploymentsTimelineParams
+	err := c.ShouldBindQuery(&params)
+	if err == nil {
+		err = params.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed query parameters"),
+		)
+		return
+	}
+	params.Normalize()
+	params.DeviceID = c.Param("id")
+	if id := identity.FromContext(ctx); id != nil {
+		params.TenantID = id.Tenant
+	}
+
+	res, err := mc.reporting.GetDeviceDeploymentsTimeline(ctx, params)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
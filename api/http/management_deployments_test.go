@@ -30,7 +30,6 @@ import (
 
 	"github.com/mendersoftware/go-lib-micro/identity"
 	"github.com/mendersoftware/go-lib-micro/rbac"
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
 
 	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
 	"github.com/mendersoftware/reporting/model"
@@ -97,7 +96,7 @@ func TestManagementAggregateDeployments(t *testing.T) {
 		),
 		Params:   &model.AggregateDeploymentsParams{},
 		Code:     http.StatusBadRequest,
-		Response: rest.Error{Err: "malformed request body: aggregations: cannot be blank."},
+		Response: Error{Err: "malformed request body: aggregations: cannot be blank."},
 	}, {
 		Name: "error, internal app error",
 
@@ -135,7 +134,7 @@ func TestManagementAggregateDeployments(t *testing.T) {
 		},
 
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "internal error"},
+		Response: Error{Err: "internal error"},
 	}, {
 		Name: "error, request identity not present",
 
@@ -146,7 +145,7 @@ func TestManagementAggregateDeployments(t *testing.T) {
 		Params: &model.AggregateDeploymentsParams{},
 
 		Code:     http.StatusUnauthorized,
-		Response: rest.Error{Err: "Authorization not present in header"},
+		Response: Error{Err: "Authorization not present in header"},
 	}, {
 		Name: "error, malformed request body",
 
@@ -164,7 +163,7 @@ func TestManagementAggregateDeployments(t *testing.T) {
 		},
 
 		Code: http.StatusBadRequest,
-		Response: rest.Error{
+		Response: Error{
 			Err: "malformed request body: json: " +
 				"cannot unmarshal string into Go struct field " +
 				"AggregateDeploymentsParams.filters of type []model.DeploymentsFilterPredicate",
@@ -205,12 +204,12 @@ func TestManagementAggregateDeployments(t *testing.T) {
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -366,7 +365,7 @@ func TestManagementSearchDeployments(t *testing.T) {
 			TenantID: "123456789012345678901234",
 		},
 		Code:     http.StatusBadRequest,
-		Response: rest.Error{Err: "malformed request body: type: must be a valid value."},
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
 	}, {
 		Name: "error, internal app error",
 
@@ -401,7 +400,7 @@ func TestManagementSearchDeployments(t *testing.T) {
 		},
 
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "internal error"},
+		Response: Error{Err: "internal error"},
 	}, {
 		Name: "error, request identity not present",
 
@@ -412,7 +411,7 @@ func TestManagementSearchDeployments(t *testing.T) {
 		Params: &model.DeploymentsSearchParams{},
 
 		Code:     http.StatusUnauthorized,
-		Response: rest.Error{Err: "Authorization not present in header"},
+		Response: Error{Err: "Authorization not present in header"},
 	}, {
 		Name: "error, malformed request body",
 
@@ -430,7 +429,7 @@ func TestManagementSearchDeployments(t *testing.T) {
 		},
 
 		Code: http.StatusBadRequest,
-		Response: rest.Error{
+		Response: Error{
 			Err: "malformed request body: json: " +
 				"cannot unmarshal string into Go struct field " +
 				"DeploymentsSearchParams.filters of type []model.DeploymentsFilterPredicate",
@@ -471,12 +470,12 @@ func TestManagementSearchDeployments(t *testing.T) {
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -490,3 +489,106 @@ func TestManagementSearchDeployments(t *testing.T) {
 		})
 	}
 }
+
+func TestManagementGetDeviceDeploymentsTimeline(t *testing.T) {
+	t.Parallel()
+
+	const deviceID = "5975e1e6-49a6-4218-a46d-f181154a98cc"
+	events := []model.DeploymentTimelineEvent{{
+		DeploymentID:   "1",
+		DeploymentName: "release-1",
+		Status:         "downloading",
+		Timestamp:      time.Date(2023, 10, 1, 10, 0, 0, 0, time.UTC),
+	}, {
+		DeploymentID:   "1",
+		DeploymentName: "release-1",
+		Status:         "failure",
+		Timestamp:      time.Date(2023, 10, 1, 10, 5, 0, 0, time.UTC),
+	}}
+
+	testCases := map[string]struct {
+		query  string
+		params *model.DeploymentsTimelineParams
+		appErr error
+
+		code     int
+		response interface{}
+	}{
+		"ok": {
+			params: &model.DeploymentsTimelineParams{
+				TenantID: "123456789012345678901234",
+				DeviceID: deviceID,
+				Limit:    20,
+			},
+			code:     http.StatusOK,
+			response: events,
+		},
+		"ok, limit": {
+			query: "?limit=5",
+			params: &model.DeploymentsTimelineParams{
+				TenantID: "123456789012345678901234",
+				DeviceID: deviceID,
+				Limit:    5,
+			},
+			code:     http.StatusOK,
+			response: events,
+		},
+		"ko, limit too large": {
+			query:    "?limit=1000",
+			code:     http.StatusBadRequest,
+			response: Error{Err: "malformed query parameters: limit: must be no greater than 100."},
+		},
+		"ko, app error": {
+			params: &model.DeploymentsTimelineParams{
+				TenantID: "123456789012345678901234",
+				DeviceID: deviceID,
+				Limit:    20,
+			},
+			appErr:   errors.New("internal error"),
+			code:     http.StatusInternalServerError,
+			response: Error{Err: "internal error"},
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			app := new(mapp.App)
+			defer app.AssertExpectations(t)
+			if tc.params != nil {
+				var res []model.DeploymentTimelineEvent
+				if tc.appErr == nil {
+					res = events
+				}
+				app.On("GetDeviceDeploymentsTimeline", contextMatcher, *tc.params).
+					Return(res, tc.appErr)
+			}
+			router := NewRouter(app)
+
+			uri := strings.Replace(URIDeploymentsTimeline, ":id", deviceID, 1)
+			req, _ := http.NewRequest(http.MethodGet,
+				URIManagement+uri+tc.query, nil)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				IsUser:  true,
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			switch res := tc.response.(type) {
+			case []model.DeploymentTimelineEvent:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			case Error:
+				var actual Error
+				if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &actual)) {
+					assert.EqualError(t, res, actual.Error())
+				}
+			}
+		})
+	}
+}
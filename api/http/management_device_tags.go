@@ -0,0 +1,64 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const paramDeviceID = "id"
+
+// SetDeviceTags replaces the tags of a device of the tenant, in inventory
+// and in the indexed document, on behalf of the user
+func (mc *ManagementController) SetDeviceTags(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var tags model.DeviceTags
+	err := c.ShouldBindJSON(&tags)
+	if err == nil {
+		err = tags.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	id := identity.FromContext(ctx)
+	err = mc.reporting.SetDeviceTags(ctx, c.GetHeader("Authorization"),
+		id.Tenant, c.Param(paramDeviceID), tags)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, reporting.ErrDeviceNotFound):
+		renderError(c, http.StatusNotFound, err)
+	case errors.Is(err, reporting.ErrInvalidDeviceTags):
+		renderError(c, http.StatusBadRequest, err)
+	case errors.Is(err, reporting.ErrDeviceTagsNotAvailable):
+		renderError(c, http.StatusServiceUnavailable, err)
+	default:
+		renderError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementSetDeviceTags(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "123456789012345678901234"
+		deviceID = "194d1060-1717-44dc-a783-00038f4a8013"
+	)
+	tags := model.DeviceTags{{Name: "owner", Value: "ops"}}
+	path := URIManagement + "/devices/" + deviceID + "/tags"
+	token := "Bearer " + GenerateJWT(identity.Identity{
+		Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+		Tenant:  tenantID,
+	})
+
+	type testCase struct {
+		Name string
+
+		Body  interface{}
+		AppFn func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response *Error
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Body: tags,
+		AppFn: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetDeviceTags", contextMatcher, token, tenantID, deviceID, tags).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, malformed body",
+
+		Body: map[string]interface{}{"name": "owner"},
+
+		Code: http.StatusBadRequest,
+		Response: &Error{Err: "malformed request body: json: cannot unmarshal " +
+			"object into Go value of type model.DeviceTags"},
+	}, {
+		Name: "error, invalid tags",
+
+		Body: model.DeviceTags{{Name: "owner name", Value: "ops"}},
+
+		Code: http.StatusBadRequest,
+		Response: &Error{Err: "malformed request body: " +
+			"0: (name: must be in a valid format.)."},
+	}, {
+		Name: "error, rejected by inventory",
+
+		Body: tags,
+		AppFn: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetDeviceTags", contextMatcher, token, tenantID, deviceID, tags).
+				Return(errors.Wrap(reporting.ErrInvalidDeviceTags, "too many tags"))
+			return app
+		},
+
+		Code:     http.StatusBadRequest,
+		Response: &Error{Err: "too many tags: invalid tags"},
+	}, {
+		Name: "error, device not found",
+
+		Body: tags,
+		AppFn: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetDeviceTags", contextMatcher, token, tenantID, deviceID, tags).
+				Return(reporting.ErrDeviceNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: &Error{Err: reporting.ErrDeviceNotFound.Error()},
+	}, {
+		Name: "error, not available",
+
+		Body: tags,
+		AppFn: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetDeviceTags", contextMatcher, token, tenantID, deviceID, tags).
+				Return(reporting.ErrDeviceTagsNotAvailable)
+			return app
+		},
+
+		Code:     http.StatusServiceUnavailable,
+		Response: &Error{Err: reporting.ErrDeviceTagsNotAvailable.Error()},
+	}, {
+		Name: "error, internal error",
+
+		Body: tags,
+		AppFn: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetDeviceTags", contextMatcher, token, tenantID, deviceID, tags).
+				Return(errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: &Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.AppFn == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.AppFn(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			body, _ := json.Marshal(tc.Body)
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodPut,
+				path,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+			if tc.Response != nil {
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, tc.Response, actual.Error())
+				}
+			} else {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
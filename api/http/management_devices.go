@@ -16,6 +16,7 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"os"
 	"strconv"
@@ -25,12 +26,19 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
 	"github.com/mendersoftware/go-lib-micro/rbac"
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
 
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/model"
 )
 
+const (
+	eventUpdate = "update"
+	eventError  = "error"
+)
+
 type attributes struct {
 	Limit      int         `json:"limit"`
 	Count      int         `json:"count"`
@@ -47,7 +55,7 @@ func (mc *ManagementController) AggregateDevices(c *gin.Context) {
 
 	params, err := parseAggregateDevicesParams(ctx, c)
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusBadRequest,
 			errors.Wrap(err, "malformed request body"),
 		)
@@ -55,8 +63,22 @@ func (mc *ManagementController) AggregateDevices(c *gin.Context) {
 	}
 
 	res, err := mc.reporting.AggregateDevices(ctx, params)
-	if err != nil {
-		rest.RenderError(c,
+	if errors.Is(err, reporting.ErrAttributeNotVisible) {
+		renderError(c,
+			http.StatusForbidden,
+			err,
+		)
+		return
+	} else if errors.Is(err, reporting.ErrAggregationAttributeNotNumeric) ||
+		errors.Is(err, reporting.ErrFilterValueType) ||
+		errors.Is(err, reporting.ErrQueryNotSupported) {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
 			http.StatusInternalServerError,
 			err,
 		)
@@ -85,6 +107,11 @@ func parseAggregateDevicesParams(ctx context.Context, c *gin.Context) (
 		aggregateParams.Groups = scope.DeviceGroups
 	}
 
+	aggregateParams.VisibleAttributes, err = visibleAttributes(c.Request)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := aggregateParams.Validate(); err != nil {
 		return nil, err
 	}
@@ -99,16 +126,25 @@ func (mc *ManagementController) DeviceAttrs(c *gin.Context) {
 	if id := identity.FromContext(ctx); id != nil {
 		tenantID = id.Tenant
 	} else {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusBadRequest,
 			errors.New("missing tenant ID from the context"),
 		)
 		return
 	}
 
+	visible, err := visibleAttributes(c.Request)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	}
+
 	mapping, err := mc.reporting.GetMapping(ctx, tenantID)
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusInternalServerError,
 			errors.Wrap(err, "failed to retrieve the mapping"),
 		)
@@ -124,7 +160,14 @@ func (mc *ManagementController) DeviceAttrs(c *gin.Context) {
 	attributesList := make([]attribute, 0, n)
 	if mapping.Inventory != nil {
 		for _, attr := range mapping.Inventory[:n] {
+			if attr == "" {
+				// evicted attribute
+				continue
+			}
 			parts := strings.SplitN(attr, string(os.PathSeparator), 2)
+			if !visible.Match(parts[0], parts[1]) {
+				continue
+			}
 			attributesList = append(attributesList, attribute{
 				Name:  parts[1],
 				Scope: parts[0],
@@ -144,17 +187,27 @@ func (mc *ManagementController) SearchDevices(c *gin.Context) {
 	ctx := c.Request.Context()
 	params, err := parseSearchDevicesParams(ctx, c)
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusBadRequest,
 			errors.Wrap(err, "malformed request body"),
 		)
 		return
 	}
 
+	if c.NegotiateFormat(gin.MIMEJSON, mimeNDJSON) == mimeNDJSON {
+		mc.searchDevicesNDJSON(c, params)
+		return
+	}
+
+	if params.Cursor != "" {
+		mc.searchDevicesWithCursor(c, params)
+		return
+	}
+
 	res, total, err := mc.reporting.SearchDevices(ctx, params)
 	if err != nil {
-		rest.RenderError(c,
-			http.StatusInternalServerError,
+		renderError(c,
+			searchErrorStatus(err),
 			err,
 		)
 		return
@@ -162,10 +215,242 @@ func (mc *ManagementController) SearchDevices(c *gin.Context) {
 
 	pageLinkHdrs(c, params.Page, params.PerPage, total)
 
+	c.Header(hdrTotalCount, strconv.Itoa(total))
+	renderJSONWithETag(c, res)
+}
+
+// searchErrorStatus returns the status of the response to a failed search:
+// the filters whose values don't fit the types of their attributes, and the
+// queries the storage backend cannot run, are detected only when executing
+// the search
+func searchErrorStatus(err error) int {
+	if errors.Is(err, reporting.ErrFilterValueType) ||
+		errors.Is(err, reporting.ErrQueryNotSupported) {
+		return http.StatusBadRequest
+	} else if errors.Is(err, reporting.ErrAttributeNotVisible) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// CountDevices returns only the number of devices matching the search
+func (mc *ManagementController) CountDevices(c *gin.Context) {
+	ctx := c.Request.Context()
+	params, err := parseSearchDevicesParams(ctx, c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	count, err := mc.reporting.CountDevices(ctx, params)
+	if err != nil {
+		renderError(c,
+			searchErrorStatus(err),
+			err,
+		)
+		return
+	}
+
+	c.Header(hdrTotalCount, strconv.Itoa(count))
+	c.JSON(http.StatusOK, model.DevicesCount{Count: count})
+}
+
+func (mc *ManagementController) searchDevicesWithCursor(
+	c *gin.Context,
+	params *model.SearchParams,
+) {
+	ctx := c.Request.Context()
+	res, total, cursor, err := mc.reporting.SearchDevicesWithCursor(ctx, params)
+	if err == reporting.ErrCursorExpired {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			searchErrorStatus(err),
+			err,
+		)
+		return
+	}
+
+	if cursor != "" {
+		c.Header(hdrNextCursor, cursor)
+	}
 	c.Header(hdrTotalCount, strconv.Itoa(total))
 	c.JSON(http.StatusOK, res)
 }
 
+// searchDevicesNDJSON streams all the devices matching the search as
+// newline-delimited JSON, one device per line, writing each page of results
+// as soon as it is fetched; the pagination parameters are ignored
+func (mc *ManagementController) searchDevicesNDJSON(
+	c *gin.Context,
+	params *model.SearchParams,
+) {
+	ctx := c.Request.Context()
+	enc := json.NewEncoder(c.Writer)
+	written := false
+	writeHeader := func() {
+		c.Header("Content-Type", mimeNDJSON)
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		written = true
+	}
+	err := mc.reporting.ExportDevices(ctx, params, func(devs []inventory.Device) error {
+		if !written {
+			writeHeader()
+		}
+		for i := range devs {
+			if err := enc.Encode(devs[i]); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !written {
+			renderError(c,
+				searchErrorStatus(err),
+				err,
+			)
+			return
+		}
+		// the response is already on its way: the client gets a truncated stream
+		log.FromContext(ctx).Errorf("failed to stream the search results: %s", err)
+		_ = c.Error(err)
+		return
+	} else if !written {
+		writeHeader()
+	}
+}
+
+func (mc *ManagementController) ExportDevices(c *gin.Context) {
+	ctx := c.Request.Context()
+	params, err := parseSearchDevicesParams(ctx, c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	columns, err := reporting.ExportColumns(ctx, mc.reporting, params.TenantID,
+		params.Attributes)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+	w, err := reporting.NewDevicesWriter(c.Writer, model.ReportFormatCSV, columns)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	written := false
+	writeHeader := func() {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", `attachment; filename="devices.csv"`)
+		c.Status(http.StatusOK)
+		written = true
+	}
+	err = mc.reporting.ExportDevices(ctx, params, func(devs []inventory.Device) error {
+		if !written {
+			writeHeader()
+		}
+		if err := w.Write(devs); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !written {
+			renderError(c,
+				searchErrorStatus(err),
+				err,
+			)
+			return
+		}
+		// the response is already on its way: the client gets a truncated file
+		log.FromContext(ctx).Errorf("failed to export devices: %s", err)
+		_ = c.Error(err)
+		return
+	} else if !written {
+		writeHeader()
+	}
+	_ = w.Close()
+}
+
+// StreamDevices streams the results of a device search as server-sent
+// events: the search is refreshed periodically and an "update" event carries
+// the changes in the results since the previous one
+func (mc *ManagementController) StreamDevices(c *gin.Context) {
+	ctx := c.Request.Context()
+	params, err := parseSearchDevicesParams(ctx, c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-mc.streamsContext.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	written := false
+	err = mc.reporting.StreamDevices(ctx, params, mc.searchStreamInterval,
+		func(update *reporting.SearchUpdate) error {
+			if !written {
+				c.Header("Content-Type", "text/event-stream")
+				c.Header("Cache-Control", "no-cache")
+				c.Header("X-Accel-Buffering", "no")
+				c.Status(http.StatusOK)
+				written = true
+			}
+			if update.Changed {
+				c.SSEvent(eventUpdate, update)
+			} else if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		})
+	if err != nil {
+		if !written {
+			renderError(c,
+				searchErrorStatus(err),
+				err,
+			)
+			return
+		}
+		log.FromContext(ctx).Errorf("failed to stream devices: %s", err)
+		_ = c.Error(err)
+		c.SSEvent(eventError, newError(c, http.StatusInternalServerError, err))
+		c.Writer.Flush()
+	}
+}
+
 func parseSearchDevicesParams(ctx context.Context, c *gin.Context) (*model.SearchParams, error) {
 	var searchParams model.SearchParams
 
@@ -184,6 +469,11 @@ func parseSearchDevicesParams(ctx context.Context, c *gin.Context) (*model.Searc
 		searchParams.Groups = scope.DeviceGroups
 	}
 
+	searchParams.VisibleAttributes, err = visibleAttributes(c.Request)
+	if err != nil {
+		return nil, err
+	}
+
 	if searchParams.PerPage <= 0 {
 		searchParams.PerPage = ParamPerPageDefault
 	}
@@ -201,15 +491,33 @@ func parseSearchDevicesParams(ctx context.Context, c *gin.Context) (*model.Searc
 func (mc *ManagementController) SearchDeviceAttrs(c *gin.Context) {
 	ctx := c.Request.Context()
 
+	visible, err := visibleAttributes(c.Request)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	}
+
 	id := identity.FromContext(ctx)
 	res, err := mc.reporting.GetSearchableInvAttrs(ctx, id.Tenant)
 	if err != nil {
-		rest.RenderError(c,
+		renderError(c,
 			http.StatusInternalServerError,
 			err,
 		)
 		return
 	}
+	if visible != nil {
+		attrs := make([]model.FilterAttribute, 0, len(res))
+		for _, attr := range res {
+			if visible.Match(attr.Scope, attr.Name) {
+				attrs = append(attrs, attr)
+			}
+		}
+		res = attrs
+	}
 
 	c.JSON(http.StatusOK, res)
 }
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -33,8 +34,9 @@ import (
 
 	"github.com/mendersoftware/go-lib-micro/identity"
 	"github.com/mendersoftware/go-lib-micro/rbac"
-	"github.com/mendersoftware/go-lib-micro/rest.utils"
+	"github.com/mendersoftware/go-lib-micro/requestid"
 
+	"github.com/mendersoftware/reporting/app/reporting"
 	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
 	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/model"
@@ -116,7 +118,7 @@ func TestManagementAggregateDevices(t *testing.T) {
 		),
 		Params:   &model.AggregateParams{},
 		Code:     http.StatusBadRequest,
-		Response: rest.Error{Err: "malformed request body: aggregations: cannot be blank."},
+		Response: Error{Err: "malformed request body: aggregations: cannot be blank."},
 	}, {
 		Name: "error, internal app error",
 
@@ -156,7 +158,79 @@ func TestManagementAggregateDevices(t *testing.T) {
 		},
 
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "internal error"},
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "error, metrics aggregation on a non numeric attribute",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("AggregateDevices",
+				contextMatcher,
+				mock.MatchedBy(func(*model.AggregateParams) bool {
+					return true
+				})).
+				Return(nil, errors.Wrap(reporting.ErrAggregationAttributeNotNumeric,
+					"aggregation mac"))
+
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.AggregateParams{
+			Aggregations: []model.AggregationTerm{
+				{
+					Name:      "mac",
+					Scope:     model.ScopeIdentity,
+					Attribute: "mac",
+					Type:      model.AggregationTypeStats,
+				},
+			},
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{Err: "aggregation mac: metrics aggregations " +
+			"support only numeric attributes"},
+	}, {
+		Name: "error, aggregation not supported by the storage backend",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("AggregateDevices",
+				contextMatcher,
+				mock.MatchedBy(func(*model.AggregateParams) bool {
+					return true
+				})).
+				Return(nil, errors.Wrap(reporting.ErrQueryNotSupported,
+					"aggregation mem"))
+
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.AggregateParams{
+			Aggregations: []model.AggregationTerm{
+				{
+					Name:      "mem",
+					Scope:     model.ScopeInventory,
+					Attribute: "mem_total_kB",
+					Type:      model.AggregationTypePercentiles,
+				},
+			},
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{Err: "aggregation mem: query not supported " +
+			"by the storage backend"},
 	}, {
 		Name: "error, request identity not present",
 
@@ -167,7 +241,7 @@ func TestManagementAggregateDevices(t *testing.T) {
 		Params: &model.AggregateParams{},
 
 		Code:     http.StatusUnauthorized,
-		Response: rest.Error{Err: "Authorization not present in header"},
+		Response: Error{Err: "Authorization not present in header"},
 	}, {
 		Name: "error, malformed request body",
 
@@ -185,7 +259,7 @@ func TestManagementAggregateDevices(t *testing.T) {
 		},
 
 		Code: http.StatusBadRequest,
-		Response: rest.Error{
+		Response: Error{
 			Err: "malformed request body: json: " +
 				"cannot unmarshal string into Go struct field " +
 				"AggregateParams.filters of type []model.FilterPredicate",
@@ -226,12 +300,12 @@ func TestManagementAggregateDevices(t *testing.T) {
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -360,7 +434,7 @@ func TestManagementDeviceAttrs(t *testing.T) {
 			},
 		),
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "failed to retrieve the mapping: error"},
+		Response: Error{Err: "failed to retrieve the mapping: error"},
 	}, {
 		Name: "ko, no identity",
 		App: func(t *testing.T) *mapp.App {
@@ -368,7 +442,7 @@ func TestManagementDeviceAttrs(t *testing.T) {
 		},
 		CTX:      context.Background(),
 		Code:     http.StatusUnauthorized,
-		Response: rest.Error{Err: "Authorization not present in header"},
+		Response: Error{Err: "Authorization not present in header"},
 	}}
 	for i := range testCases {
 		tc := testCases[i]
@@ -395,12 +469,12 @@ func TestManagementDeviceAttrs(t *testing.T) {
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -434,8 +508,9 @@ func TestManagementSearchDevices(t *testing.T) {
 		CTX    context.Context
 		Params interface{} // *model.SearchParams
 
-		Code     int
-		Response interface{}
+		Code       int
+		Response   interface{}
+		NextCursor string
 	}
 	testCases := []testCase{{
 		Name: "ok",
@@ -575,7 +650,7 @@ func TestManagementSearchDevices(t *testing.T) {
 			TenantID: "123456789012345678901234",
 		},
 		Code:     http.StatusBadRequest,
-		Response: rest.Error{Err: "malformed request body: type: must be a valid value."},
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
 	}, {
 		Name: "error, internal app error",
 
@@ -612,7 +687,139 @@ func TestManagementSearchDevices(t *testing.T) {
 		},
 
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "internal error"},
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "error, filter value type",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("SearchDevices",
+				contextMatcher,
+				newSearchParamMatcher(self.Params.(*model.SearchParams))).
+				Return(nil, 0, errors.Wrap(reporting.ErrFilterValueType, "filters"))
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "mem_total_kB",
+				Type:      "$gt",
+				Value:     "1024",
+			}},
+			TenantID: "123456789012345678901234",
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "filters: " + reporting.ErrFilterValueType.Error(),
+		},
+	}, {
+		Name: "error, search not supported by the storage backend",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("SearchDevices",
+				contextMatcher,
+				newSearchParamMatcher(self.Params.(*model.SearchParams))).
+				Return(nil, 0, errors.Wrap(reporting.ErrQueryNotSupported,
+					"query clause query_string"))
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.SearchParams{
+			Text:     "raspberry",
+			TenantID: "123456789012345678901234",
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "query clause query_string: " + reporting.ErrQueryNotSupported.Error(),
+		},
+	}, {
+		Name: "ok, cursor",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("SearchDevicesWithCursor",
+				contextMatcher,
+				newSearchParamMatcher(self.Params.(*model.SearchParams))).
+				Return(self.Response, 100, self.NextCursor, nil)
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.SearchParams{
+			PerPage:  1,
+			Cursor:   model.CursorStart,
+			TenantID: "123456789012345678901234",
+		},
+
+		Code: http.StatusOK,
+		Response: []inventory.Device{{
+			ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+		}},
+		NextCursor: (&model.Cursor{
+			PointInTimeID: "pit",
+			SearchAfter:   []interface{}{"5975e1e6-49a6-4218-a46d-f181154a98cc"},
+		}).String(),
+	}, {
+		Name: "error, invalid cursor",
+
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.SearchParams{
+			Cursor: "not-a-cursor",
+		},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: cursor: invalid cursor"},
+	}, {
+		Name: "error, cursor expired",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("SearchDevicesWithCursor",
+				contextMatcher,
+				newSearchParamMatcher(self.Params.(*model.SearchParams))).
+				Return(nil, 0, "", reporting.ErrCursorExpired)
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+		Params: &model.SearchParams{
+			Cursor:   (&model.Cursor{PointInTimeID: "pit"}).String(),
+			TenantID: "123456789012345678901234",
+		},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: reporting.ErrCursorExpired.Error()},
 	}, {
 		Name: "error, request identity not present",
 
@@ -623,7 +830,7 @@ func TestManagementSearchDevices(t *testing.T) {
 		Params: &model.SearchParams{},
 
 		Code:     http.StatusUnauthorized,
-		Response: rest.Error{Err: "Authorization not present in header"},
+		Response: Error{Err: "Authorization not present in header"},
 	}, {
 		Name: "error, malformed request body",
 
@@ -641,7 +848,7 @@ func TestManagementSearchDevices(t *testing.T) {
 		},
 
 		Code: http.StatusBadRequest,
-		Response: rest.Error{
+		Response: Error{
 			Err: "malformed request body: json: " +
 				"cannot unmarshal string into Go struct field " +
 				"SearchParams.filters of type []model.FilterPredicate",
@@ -676,18 +883,19 @@ func TestManagementSearchDevices(t *testing.T) {
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, tc.Code, w.Code)
+			assert.Equal(t, tc.NextCursor, w.Header().Get(hdrNextCursor))
 
 			switch res := tc.Response.(type) {
 			case []inventory.Device:
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -702,13 +910,20 @@ func TestManagementSearchDevices(t *testing.T) {
 	}
 }
 
-func TestSearchDevicesAttrs(t *testing.T) {
+func TestManagementCountDevices(t *testing.T) {
 	t.Parallel()
+	identityCTX := identity.WithContext(context.Background(),
+		&identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  "123456789012345678901234",
+		},
+	)
 	type testCase struct {
 		Name string
 
-		App func(*testing.T, testCase) *mapp.App
-		CTX context.Context
+		App    func(*testing.T, testCase) *mapp.App
+		CTX    context.Context
+		Params interface{} // *model.SearchParams
 
 		Code     int
 		Response interface{}
@@ -718,89 +933,73 @@ func TestSearchDevicesAttrs(t *testing.T) {
 
 		App: func(t *testing.T, self testCase) *mapp.App {
 			app := new(mapp.App)
-
-			app.On("GetSearchableInvAttrs",
+			app.On("CountDevices",
 				contextMatcher,
-				"123456789012345678901234",
-			).Return([]model.FilterAttribute{
-				{
-					Scope: model.ScopeInventory,
-					Name:  "attribute",
-					Count: 1,
-				},
-			}, nil)
-
+				mock.MatchedBy(func(params *model.SearchParams) bool {
+					return params.TenantID == "123456789012345678901234" &&
+						len(params.Filters) == 1
+				})).
+				Return(42, nil)
 			return app
 		},
-		CTX: identity.WithContext(context.Background(),
-			&identity.Identity{
-				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
-				Tenant:  "123456789012345678901234",
-			},
-		),
-
-		Code: http.StatusOK,
-		Response: []model.FilterAttribute{
-			{
-				Scope: model.ScopeInventory,
-				Name:  "attribute",
-				Count: 1,
-			},
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "serial",
+				Type:      "$nexists",
+				Value:     true,
+			}},
 		},
-	}, {
-		Name: "ok, empty result",
-
-		App: func(t *testing.T, self testCase) *mapp.App {
-			app := new(mapp.App)
 
-			app.On("GetSearchableInvAttrs",
-				contextMatcher,
-				"123456789012345678901234",
-			).Return([]model.FilterAttribute{}, nil)
+		Code:     http.StatusOK,
+		Response: model.DevicesCount{Count: 42},
+	}, {
+		Name: "error, malformed request body",
 
-			return app
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Type:      "$nope",
+				Attribute: "serial",
+				Value:     true,
+			}},
 		},
-		CTX: identity.WithContext(context.Background(),
-			&identity.Identity{
-				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
-				Tenant:  "123456789012345678901234",
-			},
-		),
-
-		Code:     http.StatusOK,
-		Response: []model.FilterAttribute{},
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
 	}, {
 		Name: "error, internal app error",
 
 		App: func(t *testing.T, self testCase) *mapp.App {
 			app := new(mapp.App)
-
-			app.On("GetSearchableInvAttrs",
+			app.On("CountDevices",
 				contextMatcher,
-				"123456789012345678901234",
-			).Return(nil, errors.New("internal error"))
-
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(0, errors.New("internal error"))
 			return app
 		},
-		CTX: identity.WithContext(context.Background(),
-			&identity.Identity{
-				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
-				Tenant:  "123456789012345678901234",
-			},
-		),
+		CTX:    identityCTX,
+		Params: &model.SearchParams{},
 
 		Code:     http.StatusInternalServerError,
-		Response: rest.Error{Err: "internal error"},
+		Response: Error{Err: "internal error"},
 	}, {
-		Name: "error, request identity not present",
+		Name: "error, filter value type",
 
 		App: func(t *testing.T, self testCase) *mapp.App {
-			return new(mapp.App)
+			app := new(mapp.App)
+			app.On("CountDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(0, reporting.ErrFilterValueType)
+			return app
 		},
-		CTX: identity.WithContext(context.Background(), nil),
+		CTX:    identityCTX,
+		Params: &model.SearchParams{},
 
-		Code:     http.StatusUnauthorized,
-		Response: rest.Error{Err: "Authorization not present in header"},
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: reporting.ErrFilterValueType.Error()},
 	}}
 	for i := range testCases {
 		tc := testCases[i]
@@ -815,10 +1014,11 @@ func TestSearchDevicesAttrs(t *testing.T) {
 			defer app.AssertExpectations(t)
 			router := NewRouter(app)
 
+			b, _ := json.Marshal(tc.Params)
 			req, _ := http.NewRequest(
-				http.MethodGet,
-				URIManagement+URIInventorySearchAttrs,
-				nil,
+				http.MethodPost,
+				URIManagement+URIInventorySearchCount,
+				bytes.NewReader(b),
 			)
 			if id := identity.FromContext(tc.CTX); id != nil {
 				req.Header.Set("Authorization", "Bearer "+GenerateJWT(*id))
@@ -829,16 +1029,532 @@ func TestSearchDevicesAttrs(t *testing.T) {
 			assert.Equal(t, tc.Code, w.Code)
 
 			switch res := tc.Response.(type) {
-			case []model.FilterAttribute:
+			case model.DevicesCount:
+				var actual model.DevicesCount
+				err := json.Unmarshal(w.Body.Bytes(), &actual)
+				if assert.NoError(t, err) {
+					assert.Equal(t, res, actual)
+				}
+				assert.Equal(t, strconv.Itoa(res.Count), w.Header().Get(hdrTotalCount))
+
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				panic("[TEST ERR] Dunno what to compare!")
+			}
+		})
+	}
+}
+
+func TestManagementExportDevices(t *testing.T) {
+	t.Parallel()
+	devs := []inventory.Device{{
+		ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+		Attributes: inventory.DeviceAttributes{{
+			Scope: model.ScopeInventory,
+			Name:  "ip4",
+			Value: []interface{}{"10.0.0.2", "10.0.0.3"},
+		}, {
+			Scope: model.ScopeInventory,
+			Name:  "mem",
+			Value: float64(1024),
+		}},
+	}, {
+		ID: inventory.DeviceID("83bce0e4-c4c0-4995-b8b7-f056da7fc8f6"),
+		Attributes: inventory.DeviceAttributes{{
+			Scope: model.ScopeInventory,
+			Name:  "ip4",
+			Value: "10.0.0.4",
+		}},
+	}}
+	exportDevices := func(args mock.Arguments) {
+		fn := args.Get(2).(func([]inventory.Device) error)
+		_ = fn(devs[:1])
+		_ = fn(devs[1:])
+	}
+	identityCTX := identity.WithContext(context.Background(),
+		&identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  "123456789012345678901234",
+		},
+	)
+	type testCase struct {
+		Name string
+
+		App    func(*testing.T, testCase) *mapp.App
+		CTX    context.Context
+		Params interface{} // *model.SearchParams
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Run(exportDevices).
+				Return(nil)
+			return app
+		},
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "ip4",
+			}, {
+				Scope:     model.ScopeInventory,
+				Attribute: "mem",
+			}},
+		},
+
+		Code: http.StatusOK,
+		Response: "id,inventory:ip4,inventory:mem\n" +
+			"5975e1e6-49a6-4218-a46d-f181154a98cc,\"10.0.0.2,10.0.0.3\",1024\n" +
+			"83bce0e4-c4c0-4995-b8b7-f056da7fc8f6,10.0.0.4,\n",
+	}, {
+		Name: "ok, all searchable attributes",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSearchableInvAttrs",
+				contextMatcher,
+				"123456789012345678901234").
+				Return([]model.FilterAttribute{{
+					Scope: model.ScopeInventory,
+					Name:  "ip4",
+					Count: 1,
+				}}, nil)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Run(exportDevices).
+				Return(nil)
+			return app
+		},
+		CTX:    identityCTX,
+		Params: &model.SearchParams{},
+
+		Code: http.StatusOK,
+		Response: "id,inventory:ip4\n" +
+			"5975e1e6-49a6-4218-a46d-f181154a98cc,\"10.0.0.2,10.0.0.3\"\n" +
+			"83bce0e4-c4c0-4995-b8b7-f056da7fc8f6,10.0.0.4\n",
+	}, {
+		Name: "ok, empty result",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Return(nil)
+			return app
+		},
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "ip4",
+			}},
+		},
+
+		Code:     http.StatusOK,
+		Response: "id,inventory:ip4\n",
+	}, {
+		Name: "error, malformed request body",
+
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Scope:     "secret-attrs",
+				Type:      "$maybethiswillfindsomethinginterresting",
+				Attribute: "rootpwd",
+				Value:     true,
+			}},
+		},
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Return(errors.New("internal error"))
+			return app
+		},
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "ip4",
+			}},
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			b, _ := json.Marshal(tc.Params)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIManagement+URIInventorySearchExport,
+				bytes.NewReader(b),
+			)
+			if id := identity.FromContext(tc.CTX); id != nil {
+				req.Header.Set("Authorization", "Bearer "+GenerateJWT(*id))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case string:
+				assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+				assert.Equal(t, res, w.Body.String())
+
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				panic("[TEST ERR] Dunno what to compare!")
+			}
+		})
+	}
+}
+
+func TestManagementSearchDevicesNDJSON(t *testing.T) {
+	t.Parallel()
+	devs := []inventory.Device{{
+		ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+		Attributes: inventory.DeviceAttributes{{
+			Scope: model.ScopeInventory,
+			Name:  "ip4",
+			Value: "10.0.0.2",
+		}},
+	}, {
+		ID:         inventory.DeviceID("83bce0e4-c4c0-4995-b8b7-f056da7fc8f6"),
+		Attributes: inventory.DeviceAttributes{},
+	}}
+	identityCTX := identity.WithContext(context.Background(),
+		&identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  "123456789012345678901234",
+		},
+	)
+	type testCase struct {
+		Name string
+
+		Params *model.SearchParams
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Run(func(args mock.Arguments) {
+					fn := args.Get(2).(func([]inventory.Device) error)
+					_ = fn(devs[:1])
+					_ = fn(devs[1:])
+				}).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: func() string {
+			var lines string
+			for _, dev := range devs {
+				b, _ := json.Marshal(dev)
+				lines += string(b) + "\n"
+			}
+			return lines
+		}(),
+	}, {
+		Name: "ok, device IDs",
+
+		Params: &model.SearchParams{
+			DeviceIDs: []string{string(devs[0].ID)},
+		},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.MatchedBy(func(params *model.SearchParams) bool {
+					return assert.Equal(t, self.Params.DeviceIDs, params.DeviceIDs)
+				}),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Run(func(args mock.Arguments) {
+					fn := args.Get(2).(func([]inventory.Device) error)
+					_ = fn(devs[:1])
+				}).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: func() string {
+			b, _ := json.Marshal(devs[0])
+			return string(b) + "\n"
+		}(),
+	}, {
+		Name: "ok, empty result",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Return(nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: "",
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Return(errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.App(t, tc)
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			params := tc.Params
+			if params == nil {
+				params = &model.SearchParams{}
+			}
+			b, _ := json.Marshal(params)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIManagement+URIInventorySearch,
+				bytes.NewReader(b),
+			)
+			req.Header.Set("Accept", mimeNDJSON)
+			req.Header.Set("Authorization",
+				"Bearer "+GenerateJWT(*identity.FromContext(identityCTX)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case string:
+				assert.Equal(t, mimeNDJSON, w.Header().Get("Content-Type"))
+				assert.Equal(t, res, w.Body.String())
+
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				panic("[TEST ERR] Dunno what to compare!")
+			}
+		})
+	}
+}
+
+func TestSearchDevicesAttrs(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		Name string
+
+		App func(*testing.T, testCase) *mapp.App
+		CTX context.Context
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("GetSearchableInvAttrs",
+				contextMatcher,
+				"123456789012345678901234",
+			).Return([]model.FilterAttribute{
+				{
+					Scope: model.ScopeInventory,
+					Name:  "attribute",
+					Count: 1,
+				},
+			}, nil)
+
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+
+		Code: http.StatusOK,
+		Response: []model.FilterAttribute{
+			{
+				Scope: model.ScopeInventory,
+				Name:  "attribute",
+				Count: 1,
+			},
+		},
+	}, {
+		Name: "ok, empty result",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("GetSearchableInvAttrs",
+				contextMatcher,
+				"123456789012345678901234",
+			).Return([]model.FilterAttribute{}, nil)
+
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+
+		Code:     http.StatusOK,
+		Response: []model.FilterAttribute{},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+
+			app.On("GetSearchableInvAttrs",
+				contextMatcher,
+				"123456789012345678901234",
+			).Return(nil, errors.New("internal error"))
+
+			return app
+		},
+		CTX: identity.WithContext(context.Background(),
+			&identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			},
+		),
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "error, request identity not present",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			return new(mapp.App)
+		},
+		CTX: identity.WithContext(context.Background(), nil),
+
+		Code:     http.StatusUnauthorized,
+		Response: Error{Err: "Authorization not present in header"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			req, _ := http.NewRequest(
+				http.MethodGet,
+				URIManagement+URIInventorySearchAttrs,
+				nil,
+			)
+			if id := identity.FromContext(tc.CTX); id != nil {
+				req.Header.Set("Authorization", "Bearer "+GenerateJWT(*id))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case []model.FilterAttribute:
 				b, _ := json.Marshal(res)
 				assert.JSONEq(t, string(b), w.Body.String())
 
-			case rest.Error:
-				var actual rest.Error
+			case Error:
+				var actual Error
 				dec := json.NewDecoder(w.Body)
 				dec.DisallowUnknownFields()
 				err := dec.Decode(&actual)
-				if assert.NoError(t, err, "response schema did not match expected rest.Error") {
+				if assert.NoError(t, err, "response schema did not match expected Error") {
 					assert.EqualError(t, res, actual.Error())
 				}
 
@@ -852,3 +1568,174 @@ func TestSearchDevicesAttrs(t *testing.T) {
 		})
 	}
 }
+
+func TestManagementStreamDevices(t *testing.T) {
+	t.Parallel()
+	update := &reporting.SearchUpdate{
+		Devices: []inventory.Device{{
+			ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+			Attributes: inventory.DeviceAttributes{{
+				Scope: model.ScopeInventory,
+				Name:  "ip4",
+				Value: "10.0.0.2",
+			}},
+		}},
+		Removed: []inventory.DeviceID{"83bce0e4-c4c0-4995-b8b7-f056da7fc8f6"},
+		Total:   1,
+		Changed: true,
+	}
+	streamDevices := func(updates ...*reporting.SearchUpdate) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			fn := args.Get(3).(func(*reporting.SearchUpdate) error)
+			for _, update := range updates {
+				_ = fn(update)
+			}
+		}
+	}
+	identityCTX := identity.WithContext(context.Background(),
+		&identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  "123456789012345678901234",
+		},
+	)
+	type testCase struct {
+		Name string
+
+		App    func(*testing.T, testCase) *mapp.App
+		CTX    context.Context
+		Params interface{} // *model.SearchParams
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("StreamDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				time.Second,
+				mock.AnythingOfType("func(*reporting.SearchUpdate) error")).
+				Run(streamDevices(update, &reporting.SearchUpdate{Total: 1})).
+				Return(nil)
+			return app
+		},
+		CTX:    identityCTX,
+		Params: &model.SearchParams{},
+
+		Code: http.StatusOK,
+		Response: "event:update\n" +
+			`data:{"devices":[{"id":"5975e1e6-49a6-4218-a46d-f181154a98cc",` +
+			`"attributes":[{"name":"ip4","value":"10.0.0.2","scope":"inventory"}],` +
+			`"created_ts":"0001-01-01T00:00:00Z","updated_ts":"0001-01-01T00:00:00Z"}],` +
+			`"removed":["83bce0e4-c4c0-4995-b8b7-f056da7fc8f6"],"total":1}` + "\n\n" +
+			": keep-alive\n\n",
+	}, {
+		Name: "error, malformed request body",
+
+		CTX: identityCTX,
+		Params: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Scope:     "secret-attrs",
+				Type:      "$maybethiswillfindsomethinginterresting",
+				Attribute: "rootpwd",
+				Value:     true,
+			}},
+		},
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("StreamDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				time.Second,
+				mock.AnythingOfType("func(*reporting.SearchUpdate) error")).
+				Return(errors.New("internal error"))
+			return app
+		},
+		CTX:    identityCTX,
+		Params: &model.SearchParams{},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "error, internal app error while streaming",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("StreamDevices",
+				contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				time.Second,
+				mock.AnythingOfType("func(*reporting.SearchUpdate) error")).
+				Run(streamDevices(&reporting.SearchUpdate{
+					Devices: []inventory.Device{},
+					Removed: []inventory.DeviceID{},
+					Changed: true,
+				})).
+				Return(errors.New("internal error"))
+			return app
+		},
+		CTX:    identityCTX,
+		Params: &model.SearchParams{},
+
+		Code: http.StatusOK,
+		Response: "event:update\n" +
+			`data:{"devices":[],"removed":[],"total":0}` + "\n\n" +
+			"event:error\n" +
+			`data:{"code":"internal_error","error":"internal error","request_id":"test"}` + "\n\n",
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app, WithSearchStreamInterval(time.Second))
+
+			b, _ := json.Marshal(tc.Params)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIManagement+URIInventorySearchStream,
+				bytes.NewReader(b),
+			)
+			req.Header.Set(requestid.RequestIdHeader, "test")
+			if id := identity.FromContext(tc.CTX); id != nil {
+				req.Header.Set("Authorization", "Bearer "+GenerateJWT(*id))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case string:
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.Equal(t, res, w.Body.String())
+
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				panic("[TEST ERR] Dunno what to compare!")
+			}
+		})
+	}
+}
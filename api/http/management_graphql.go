@@ -0,0 +1,78 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/rbac"
+
+	"github.com/mendersoftware/reporting/api/graphql"
+)
+
+// graphQLMaxBodySize is the maximum size of the GraphQL request bodies
+const graphQLMaxBodySize = 64 * 1024
+
+// GraphQL executes the GraphQL queries over the device and deployment
+// documents; the arguments of the query fields are the request bodies of
+// the corresponding search and aggregation endpoints
+func (mc *ManagementController) GraphQL(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, graphQLMaxBodySize)
+	var req graphql.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	id := identity.FromContext(ctx)
+	if id == nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.New("missing tenant ID from the context"),
+		)
+		return
+	}
+	visible, err := visibleAttributes(c.Request)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	}
+	scope := &graphql.Scope{
+		TenantID:          id.Tenant,
+		VisibleAttributes: visible,
+	}
+	if rbacScope := rbac.ExtractScopeFromHeader(c.Request); rbacScope != nil {
+		scope.Groups = rbacScope.DeviceGroups
+	}
+
+	res := mc.graphQLSchema.Execute(ctx, scope, &req)
+	if res.Data == nil {
+		c.JSON(http.StatusBadRequest, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,326 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/rbac"
+
+	"github.com/mendersoftware/reporting/api/graphql"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementGraphQL(t *testing.T) {
+	t.Parallel()
+	const tenantID = "123456789012345678901234"
+	ctx := identity.WithContext(context.Background(),
+		&identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  tenantID,
+		},
+	)
+	type testCase struct {
+		Name string
+
+		App      func(*testing.T) *mapp.App
+		CTX      context.Context
+		Disabled bool
+		Body     interface{}
+
+		Code     int
+		Response string
+		Error    string
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher, &model.SearchParams{
+				Page:    1,
+				PerPage: 5,
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+					Type:      "$eq",
+					Value:     "00:11",
+				}},
+				TenantID: tenantID,
+				Groups:   []string{"prod"},
+			}).Return([]inventory.Device{{
+				ID: "1",
+				Attributes: inventory.DeviceAttributes{{
+					Scope: model.ScopeInventory,
+					Name:  "mac",
+					Value: "00:11",
+				}},
+			}}, 1, nil)
+			app.On("AggregateDevices", contextMatcher, &model.AggregateParams{
+				Aggregations: []model.AggregationTerm{{
+					Name:      "types",
+					Scope:     model.ScopeInventory,
+					Attribute: "device_type",
+				}},
+				TenantID: tenantID,
+				Groups:   []string{"prod"},
+			}).Return([]model.DeviceAggregation{{
+				Name: "types",
+				Items: []model.DeviceAggregationItem{{
+					Key:   "rpi4",
+					Count: 3,
+				}},
+				OtherCount: 1,
+			}}, nil)
+			return app
+		},
+		CTX: rbac.WithContext(ctx, &rbac.Scope{DeviceGroups: []string{"prod"}}),
+		Body: map[string]interface{}{
+			"query": `query ($filters: [FilterPredicate!]) {
+				devices(per_page: 5, filters: $filters) {
+					total
+					devices { id attributes { name value } }
+				}
+				aggregate_devices(aggregations: [
+					{name: "types", scope: "inventory", attribute: "device_type"}
+				]) {
+					name
+					items { key count }
+				}
+			}`,
+			"variables": map[string]interface{}{
+				"filters": []interface{}{map[string]interface{}{
+					"scope":     "inventory",
+					"attribute": "mac",
+					"type":      "$eq",
+					"value":     "00:11",
+				}},
+			},
+		},
+
+		Code: http.StatusOK,
+		Response: `{"data": {
+			"devices": {
+				"total": 1,
+				"devices": [{"id": "1", "attributes": [{"name": "mac", "value": "00:11"}]}]
+			},
+			"aggregate_devices": [{"name": "types", "items": [{"key": "rpi4", "count": 3}]}]
+		}}`,
+	}, {
+		Name: "ok, deployments",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDeployments", contextMatcher, &model.DeploymentsSearchParams{
+				Page:     1,
+				PerPage:  ParamPerPageDefault,
+				TenantID: tenantID,
+			}).Return([]model.Deployment{{
+				ID:             "5975e1e6-49a6-4218-a46d-f181154a98cc",
+				DeviceID:       "1",
+				DeploymentName: "release",
+			}}, 1, nil)
+			return app
+		},
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": `{ deployments { total deployments { device_id deployment_name } } }`,
+		},
+
+		Code: http.StatusOK,
+		Response: `{"data": {"deployments": {
+			"total": 1,
+			"deployments": [{"device_id": "1", "deployment_name": "release"}]
+		}}}`,
+	}, {
+		Name: "ok, field errors",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher, mock.AnythingOfType("*model.SearchParams")).
+				Return(nil, 0, errors.New("internal error"))
+			app.On("SearchDeployments", contextMatcher,
+				mock.AnythingOfType("*model.DeploymentsSearchParams")).
+				Return(nil, 0, nil)
+			return app
+		},
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": `{
+				devices { total }
+				deployments { total }
+			}`,
+		},
+
+		Code: http.StatusOK,
+		Response: `{"data": {"devices": null, "deployments": {"total": 0}}, "errors": [{
+			"message": "internal error",
+			"path": ["devices"]
+		}]}`,
+	}, {
+		Name: "ok, invalid arguments",
+
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": `{
+				aggregate_devices(aggregations: [{name: "", scope: "", attribute: ""}]) {
+					name
+				}
+			}`,
+		},
+
+		Code: http.StatusOK,
+		Response: `{"data": {"aggregate_devices": null}, "errors": [{
+			"message": "aggregations: (0: (attribute: cannot be blank; ` +
+			`name: cannot be blank; scope: cannot be blank.).).",
+			"path": ["aggregate_devices"]
+		}]}`,
+	}, {
+		Name: "ok, introspection",
+
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": `{ __type(name: "Devices") { fields { name } } }`,
+		},
+
+		Code:     http.StatusOK,
+		Response: `{"data": {"__type": {"fields": [{"name": "devices"}, {"name": "total"}]}}}`,
+	}, {
+		Name: "error, invalid query",
+
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": `{ inventory { total } }`,
+		},
+
+		Code: http.StatusBadRequest,
+		Response: `{"errors": [{
+			"message": "Cannot query field \"inventory\" on type \"Query\".",
+			"locations": [{"line": 1, "column": 3}]
+		}]}`,
+	}, {
+		Name: "error, malformed request body",
+
+		CTX:  ctx,
+		Body: "foo",
+
+		Code: http.StatusBadRequest,
+		Error: "malformed request body: json: cannot unmarshal string " +
+			"into Go value of type graphql.Request",
+	}, {
+		Name: "error, request body too large",
+
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": "{ devices { total } }" + strings.Repeat(" ", graphQLMaxBodySize),
+		},
+
+		Code:  http.StatusBadRequest,
+		Error: "malformed request body: http: request body too large",
+	}, {
+		Name: "error, too many fields",
+
+		App: func(t *testing.T) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher, mock.AnythingOfType("*model.SearchParams")).
+				Return(nil, 0, nil).
+				Times(graphql.MaxRootFields)
+			return app
+		},
+		CTX: ctx,
+		Body: map[string]interface{}{
+			"query": func() string {
+				query := "{"
+				for i := 0; i <= graphql.MaxRootFields; i++ {
+					query += fmt.Sprintf(" d%d: devices { total }", i)
+				}
+				return query + " }"
+			}(),
+		},
+
+		Code: http.StatusOK,
+	}, {
+		Name: "error, request identity not present",
+
+		CTX: context.Background(),
+		Body: map[string]interface{}{
+			"query": `{ devices { total } }`,
+		},
+
+		Code:  http.StatusUnauthorized,
+		Error: "Authorization not present in header",
+	}, {
+		Name: "error, disabled",
+
+		CTX:      ctx,
+		Disabled: true,
+		Body: map[string]interface{}{
+			"query": `{ devices { total } }`,
+		},
+
+		Code: http.StatusNotFound,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			app := new(mapp.App)
+			if tc.App != nil {
+				app = tc.App(t)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app, WithGraphQL(!tc.Disabled))
+
+			b, _ := json.Marshal(tc.Body)
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIManagement+URIGraphQL,
+				bytes.NewReader(b),
+			)
+			if id := identity.FromContext(tc.CTX); id != nil {
+				req.Header.Set("Authorization", "Bearer "+GenerateJWT(*id))
+			}
+			if scope := rbac.FromContext(tc.CTX); scope != nil {
+				req.Header.Set(rbac.ScopeHeader, strings.Join(scope.DeviceGroups, ","))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+			if tc.Error != "" {
+				var actual Error
+				if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &actual)) {
+					assert.EqualError(t, actual, tc.Error)
+				}
+			} else if tc.Response != "" {
+				assert.JSONEq(t, tc.Response, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementListGroups(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	type testCase struct {
+		Name string
+
+		Query string
+		App   func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Query: "limit=5",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ListGroups", contextMatcher,
+				&model.GroupsParams{
+					Limit:    5,
+					TenantID: tenantID,
+				}).
+				Return(self.Response, nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: []model.DeviceGroup{{
+			Name:  "prod",
+			Count: 12,
+		}},
+	}, {
+		Name: "error, invalid limit",
+
+		Query: "limit=5000",
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed query parameters: limit: must be no greater than 1000."},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ListGroups", contextMatcher,
+				mock.AnythingOfType("*model.GroupsParams")).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodGet,
+				URIManagement+URIInventoryGroups+"?"+tc.Query,
+				nil,
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestManagementAggregateDevicesByGroup(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	type testCase struct {
+		Name string
+
+		Body interface{}
+		App  func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	params := model.GroupsAggregateParams{
+		InGroups: []string{"prod"},
+		Aggregations: []model.AggregationTerm{{
+			Name:      "artifacts",
+			Scope:     model.ScopeInventory,
+			Attribute: "artifact_name",
+		}},
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Body: params,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			expected := self.Body.(model.GroupsAggregateParams)
+			expected.TenantID = tenantID
+			aggregateParams := expected.AggregateParams()
+			app.On("AggregateDevices", contextMatcher, &aggregateParams).
+				Return(self.Response, nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: []model.DeviceAggregation{{
+			Name: model.GroupsAggregation,
+			Items: []model.DeviceAggregationItem{{
+				Key:   "prod",
+				Count: 12,
+				Aggregations: []model.DeviceAggregation{{
+					Name: "artifacts",
+					Items: []model.DeviceAggregationItem{{
+						Key:   "release-1",
+						Count: 12,
+					}},
+				}},
+			}},
+		}},
+	}, {
+		Name: "error, malformed body",
+
+		Body: "foo",
+
+		Code: http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: json: cannot unmarshal " +
+			"string into Go value of type model.GroupsAggregateParams"},
+	}, {
+		Name: "error, not numeric attribute",
+
+		Body: params,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateDevices", contextMatcher,
+				mock.AnythingOfType("*model.AggregateParams")).
+				Return(nil, reporting.ErrAggregationAttributeNotNumeric)
+			return app
+		},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: reporting.ErrAggregationAttributeNotNumeric.Error()},
+	}, {
+		Name: "error, internal app error",
+
+		Body: params,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateDevices", contextMatcher,
+				mock.AnythingOfType("*model.AggregateParams")).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			body, _ := json.Marshal(tc.Body)
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodPost,
+				URIManagement+URIInventoryGroupsAggr,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
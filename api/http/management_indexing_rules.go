@@ -0,0 +1,71 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func (mc *ManagementController) GetIndexingRules(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetIndexingRules(ctx, id.Tenant)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) SetIndexingRules(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var rules model.IndexingRules
+	err := c.ShouldBindJSON(&rules)
+	if err == nil {
+		err = rules.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+	rules.TenantID = identity.FromContext(ctx).Tenant
+
+	err = mc.reporting.SetIndexingRules(ctx, &rules)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementIndexingRules(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	rules := &model.IndexingRules{
+		TenantID: tenantID,
+		Filters: []model.FilterPredicate{{
+			Scope:     model.ScopeSystem,
+			Attribute: model.AttrNameGroup,
+			Type:      "$eq",
+			Value:     "production",
+		}},
+		UpdatedTs: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	type testCase struct {
+		Name string
+
+		Method string
+		Body   interface{}
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok, get",
+
+		Method: http.MethodGet,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetIndexingRules", contextMatcher, tenantID).
+				Return(rules, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: rules,
+	}, {
+		Name: "error, get",
+
+		Method: http.MethodGet,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetIndexingRules", contextMatcher, tenantID).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "ok, set",
+
+		Method: http.MethodPut,
+		Body: model.IndexingRules{
+			Filters: rules.Filters,
+		},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetIndexingRules", contextMatcher,
+				mock.MatchedBy(func(r *model.IndexingRules) bool {
+					return r.TenantID == tenantID && len(r.Filters) == 1
+				})).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, set with invalid filters",
+
+		Method: http.MethodPut,
+		Body: model.IndexingRules{
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "foo",
+				Type:      "$nope",
+				Value:     "bar",
+			}},
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed request body: filters: (0: (type: must be a valid value.).).",
+		},
+	}, {
+		Name: "error, set with invalid attributes",
+
+		Method: http.MethodPut,
+		Body: model.IndexingRules{
+			Attributes: &model.AttributesFilter{
+				Excluded: []model.AttributeSelector{{Name: "network_*"}},
+			},
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed request body: attributes: (excluded: (0: (scope: cannot be blank.).).).",
+		},
+	}, {
+		Name: "error, set",
+
+		Method: http.MethodPut,
+		Body:   model.IndexingRules{},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetIndexingRules", contextMatcher,
+				mock.AnythingOfType("*model.IndexingRules")).
+				Return(errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			var body []byte
+			if tc.Body != nil {
+				body, _ = json.Marshal(tc.Body)
+			}
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				tc.Method,
+				URIManagement+URIInventoryIndexingRules,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case nil:
+				assert.Empty(t, w.Body.String())
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func (mc *ManagementController) GetAttributesMapping(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetMappingUsage(ctx, id.Tenant)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			errors.Wrap(err, "failed to retrieve the mapping"),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) EvictAttributesMapping(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req model.EvictMappingAttributes
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.EvictMappingAttributes(ctx, id.Tenant, req.Attributes)
+	if errors.Is(err, reporting.ErrMappingAttributeInUse) {
+		renderError(c,
+			http.StatusConflict,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			errors.Wrap(err, "failed to evict the attributes from the mapping"),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) GetAttributesTypes(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetMappingTypes(ctx, id.Tenant)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			errors.Wrap(err, "failed to retrieve the attributes types"),
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) SetAttributesTypes(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req model.MappingTypes
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	id := identity.FromContext(ctx)
+	err = mc.reporting.SetMappingTypes(ctx, id.Tenant, req.Types)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			errors.Wrap(err, "failed to set the attributes types"),
+		)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,279 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementAttributesMapping(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	usage := &model.MappingUsage{
+		Limit:     model.MaxMappingInventoryAttributes,
+		Count:     1,
+		Remaining: model.MaxMappingInventoryAttributes - 1,
+		Attributes: []model.MappingAttribute{
+			{Scope: model.ScopeInventory, Name: "mac", Devices: 10},
+		},
+	}
+	evict := model.EvictMappingAttributes{
+		Attributes: []model.MappingAttribute{
+			{Scope: model.ScopeInventory, Name: "serial"},
+		},
+	}
+	types := model.MappingTypes{
+		Types: []model.MappingAttributeType{
+			{Scope: model.ScopeInventory, Name: "last_boot", Type: model.AttributeTypeDate},
+		},
+	}
+
+	type testCase struct {
+		Name string
+
+		Method string
+		URI    string
+		Body   interface{}
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok, get",
+
+		Method: http.MethodGet,
+		URI:    URIInventoryAttrsMapping,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetMappingUsage", contextMatcher, tenantID).
+				Return(usage, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: usage,
+	}, {
+		Name: "error, get",
+
+		Method: http.MethodGet,
+		URI:    URIInventoryAttrsMapping,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetMappingUsage", contextMatcher, tenantID).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "failed to retrieve the mapping: internal error"},
+	}, {
+		Name: "ok, evict",
+
+		Method: http.MethodPost,
+		URI:    URIInventoryAttrsEvict,
+		Body:   evict,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("EvictMappingAttributes", contextMatcher, tenantID, evict.Attributes).
+				Return(usage, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: usage,
+	}, {
+		Name: "error, evict without attributes",
+
+		Method: http.MethodPost,
+		URI:    URIInventoryAttrsEvict,
+		Body:   model.EvictMappingAttributes{},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: attributes: cannot be blank."},
+	}, {
+		Name: "error, evict attribute in use",
+
+		Method: http.MethodPost,
+		URI:    URIInventoryAttrsEvict,
+		Body:   evict,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("EvictMappingAttributes", contextMatcher, tenantID, evict.Attributes).
+				Return(nil, errors.Wrap(reporting.ErrMappingAttributeInUse,
+					"inventory/serial (3 devices)"))
+			return app
+		},
+
+		Code: http.StatusConflict,
+		Response: Error{
+			Err: "inventory/serial (3 devices): the attribute is set for some devices",
+		},
+	}, {
+		Name: "error, evict",
+
+		Method: http.MethodPost,
+		URI:    URIInventoryAttrsEvict,
+		Body:   evict,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("EvictMappingAttributes", contextMatcher, tenantID, evict.Attributes).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code: http.StatusInternalServerError,
+		Response: Error{
+			Err: "failed to evict the attributes from the mapping: internal error",
+		},
+	}, {
+		Name: "ok, get types",
+
+		Method: http.MethodGet,
+		URI:    URIInventoryAttrsTypes,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetMappingTypes", contextMatcher, tenantID).
+				Return(&types, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: types,
+	}, {
+		Name: "error, get types",
+
+		Method: http.MethodGet,
+		URI:    URIInventoryAttrsTypes,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetMappingTypes", contextMatcher, tenantID).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "failed to retrieve the attributes types: internal error"},
+	}, {
+		Name: "ok, set types",
+
+		Method: http.MethodPut,
+		URI:    URIInventoryAttrsTypes,
+		Body:   types,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetMappingTypes", contextMatcher, tenantID, types.Types).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, set types with unknown type",
+
+		Method: http.MethodPut,
+		URI:    URIInventoryAttrsTypes,
+		Body: model.MappingTypes{
+			Types: []model.MappingAttributeType{
+				{Scope: model.ScopeInventory, Name: "last_boot", Type: "datetime"},
+			},
+		},
+
+		Code: http.StatusBadRequest,
+	}, {
+		Name: "error, set types",
+
+		Method: http.MethodPut,
+		URI:    URIInventoryAttrsTypes,
+		Body:   types,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SetMappingTypes", contextMatcher, tenantID, types.Types).
+				Return(errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "failed to set the attributes types: internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			var body []byte
+			if tc.Body != nil {
+				body, _ = json.Marshal(tc.Body)
+			}
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				tc.Method,
+				URIManagement+tc.URI,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case nil:
+				if tc.Code == http.StatusNoContent {
+					assert.Empty(t, w.Body.Bytes())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/rbac"
+	"github.com/mendersoftware/go-lib-micro/rest.utils"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const paramSavedSearchID = "id"
+
+func (mc *ManagementController) ListSavedSearches(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetSavedSearches(ctx, id.Tenant)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) CreateSavedSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	search, err := parseSavedSearch(c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	err = mc.reporting.CreateSavedSearch(ctx, search)
+	if err == reporting.ErrSavedSearchNameConflict {
+		renderError(c,
+			http.StatusConflict,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Header("Location", URIManagement+URISavedSearches+"/"+search.ID)
+	c.JSON(http.StatusCreated, search)
+}
+
+func (mc *ManagementController) GetSavedSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	res, err := mc.reporting.GetSavedSearch(ctx, id.Tenant, c.Param(paramSavedSearchID))
+	if err == reporting.ErrSavedSearchNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (mc *ManagementController) UpdateSavedSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	search, err := parseSavedSearch(c)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+	search.ID = c.Param(paramSavedSearchID)
+
+	err = mc.reporting.UpdateSavedSearch(ctx, search)
+	switch err {
+	case nil:
+		c.Status(http.StatusNoContent)
+	case reporting.ErrSavedSearchNotFound:
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+	case reporting.ErrSavedSearchNameConflict:
+		renderError(c,
+			http.StatusConflict,
+			err,
+		)
+	default:
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+}
+
+func (mc *ManagementController) DeleteSavedSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	err := mc.reporting.DeleteSavedSearch(ctx, id.Tenant, c.Param(paramSavedSearchID))
+	switch err {
+	case nil:
+		c.Status(http.StatusNoContent)
+	case reporting.ErrSavedSearchNotFound:
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+	default:
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+	}
+}
+
+func (mc *ManagementController) ExecuteSavedSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	page, perPage, err := rest.ParsePagingParameters(c.Request)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	}
+
+	id := identity.FromContext(ctx)
+	search, err := mc.reporting.GetSavedSearch(ctx, id.Tenant, c.Param(paramSavedSearchID))
+	if err == reporting.ErrSavedSearchNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	params := search.SearchParams()
+	params.Page = int(page)
+	params.PerPage = int(perPage)
+	if scope := rbac.ExtractScopeFromHeader(c.Request); scope != nil {
+		params.Groups = scope.DeviceGroups
+	}
+	params.VisibleAttributes, err = visibleAttributes(c.Request)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	}
+
+	res, total, err := mc.reporting.SearchDevices(ctx, params)
+	if err != nil {
+		renderError(c,
+			searchErrorStatus(err),
+			err,
+		)
+		return
+	}
+
+	pageLinkHdrs(c, params.Page, params.PerPage, total)
+
+	c.Header(hdrTotalCount, strconv.Itoa(total))
+	renderJSONWithETag(c, res)
+}
+
+func parseSavedSearch(c *gin.Context) (*model.SavedSearch, error) {
+	var search model.SavedSearch
+
+	err := c.ShouldBindJSON(&search)
+	if err != nil {
+		return nil, err
+	}
+
+	if id := identity.FromContext(c.Request.Context()); id != nil {
+		search.TenantID = id.Tenant
+	} else {
+		return nil, errors.New("missing tenant ID from the context")
+	}
+
+	if err := search.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
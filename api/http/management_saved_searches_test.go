@@ -0,0 +1,340 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementSavedSearches(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "123456789012345678901234"
+		searchID = "a3c6f3e4-5c4a-4d2e-8d4b-2e2c0c6d8a3b"
+	)
+	savedSearch := &model.SavedSearch{
+		ID:       searchID,
+		TenantID: tenantID,
+		Name:     "production",
+		Filters: []model.FilterPredicate{{
+			Scope:     model.ScopeSystem,
+			Attribute: model.AttrNameGroup,
+			Type:      "$in",
+			Value:     []interface{}{"prod-eu", "prod-us"},
+		}},
+		CreatedTs: time.Now().UTC().Truncate(time.Millisecond),
+		UpdatedTs: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	savedSearchPath := URIManagement + URISavedSearches + "/" + searchID
+
+	type testCase struct {
+		Name string
+
+		Method string
+		Path   string
+		Body   interface{}
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok, list",
+
+		Method: http.MethodGet,
+		Path:   URIManagement + URISavedSearches,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSavedSearches", contextMatcher, tenantID).
+				Return([]model.SavedSearch{*savedSearch}, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: []model.SavedSearch{*savedSearch},
+	}, {
+		Name: "ok, create",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URISavedSearches,
+		Body: model.SavedSearch{
+			Name:    savedSearch.Name,
+			Filters: savedSearch.Filters,
+		},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CreateSavedSearch", contextMatcher,
+				mock.MatchedBy(func(search *model.SavedSearch) bool {
+					return search.Name == savedSearch.Name &&
+						search.TenantID == tenantID
+				})).
+				Run(func(args mock.Arguments) {
+					search := args.Get(1).(*model.SavedSearch)
+					*search = *savedSearch
+				}).
+				Return(nil)
+			return app
+		},
+
+		Code:     http.StatusCreated,
+		Response: savedSearch,
+	}, {
+		Name: "error, create with invalid filters",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URISavedSearches,
+		Body: model.SavedSearch{
+			Name: savedSearch.Name,
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "foo",
+				Type:      "$nope",
+				Value:     "bar",
+			}},
+		},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: type: must be a valid value."},
+	}, {
+		Name: "error, create without name",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URISavedSearches,
+		Body:   model.SavedSearch{},
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed request body: name: cannot be blank."},
+	}, {
+		Name: "error, create with duplicate name",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URISavedSearches,
+		Body:   model.SavedSearch{Name: savedSearch.Name},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CreateSavedSearch", contextMatcher,
+				mock.AnythingOfType("*model.SavedSearch")).
+				Return(reporting.ErrSavedSearchNameConflict)
+			return app
+		},
+
+		Code:     http.StatusConflict,
+		Response: Error{Err: reporting.ErrSavedSearchNameConflict.Error()},
+	}, {
+		Name: "ok, get",
+
+		Method: http.MethodGet,
+		Path:   savedSearchPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSavedSearch", contextMatcher, tenantID, searchID).
+				Return(savedSearch, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: savedSearch,
+	}, {
+		Name: "error, get not found",
+
+		Method: http.MethodGet,
+		Path:   savedSearchPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSavedSearch", contextMatcher, tenantID, searchID).
+				Return(nil, reporting.ErrSavedSearchNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrSavedSearchNotFound.Error()},
+	}, {
+		Name: "ok, update",
+
+		Method: http.MethodPut,
+		Path:   savedSearchPath,
+		Body:   model.SavedSearch{Name: "staging"},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("UpdateSavedSearch", contextMatcher,
+				mock.MatchedBy(func(search *model.SavedSearch) bool {
+					return search.ID == searchID &&
+						search.Name == "staging" &&
+						search.TenantID == tenantID
+				})).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, update not found",
+
+		Method: http.MethodPut,
+		Path:   savedSearchPath,
+		Body:   model.SavedSearch{Name: "staging"},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("UpdateSavedSearch", contextMatcher,
+				mock.AnythingOfType("*model.SavedSearch")).
+				Return(reporting.ErrSavedSearchNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrSavedSearchNotFound.Error()},
+	}, {
+		Name: "ok, delete",
+
+		Method: http.MethodDelete,
+		Path:   savedSearchPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DeleteSavedSearch", contextMatcher, tenantID, searchID).
+				Return(nil)
+			return app
+		},
+
+		Code: http.StatusNoContent,
+	}, {
+		Name: "error, delete internal error",
+
+		Method: http.MethodDelete,
+		Path:   savedSearchPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("DeleteSavedSearch", contextMatcher, tenantID, searchID).
+				Return(errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "ok, execute",
+
+		Method: http.MethodGet,
+		Path:   savedSearchPath + "/search?page=2&per_page=10",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSavedSearch", contextMatcher, tenantID, searchID).
+				Return(savedSearch, nil)
+			params := savedSearch.SearchParams()
+			params.Page = 2
+			params.PerPage = 10
+			app.On("SearchDevices", contextMatcher, params).
+				Return([]inventory.Device{{
+					ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+				}}, 11, nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: []inventory.Device{{
+			ID: inventory.DeviceID("5975e1e6-49a6-4218-a46d-f181154a98cc"),
+		}},
+	}, {
+		Name: "error, execute not found",
+
+		Method: http.MethodGet,
+		Path:   savedSearchPath + "/search",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSavedSearch", contextMatcher, tenantID, searchID).
+				Return(nil, reporting.ErrSavedSearchNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrSavedSearchNotFound.Error()},
+	}, {
+		Name: "error, execute with bad paging parameters",
+
+		Method: http.MethodGet,
+		Path:   savedSearchPath + "/search?page=foo",
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: `invalid page query: "foo"`},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			var body []byte
+			if tc.Body != nil {
+				body, _ = json.Marshal(tc.Body)
+			}
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				tc.Method,
+				tc.Path,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			case nil:
+				assert.Empty(t, w.Body.String())
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+)
+
+const paramSearchJobID = "id"
+
+func (mc *ManagementController) CreateSearchJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	params, err := parseSearchDevicesParams(ctx, c)
+	if err == nil && params.Cursor != "" {
+		err = errors.New("cursor: the search jobs don't support cursors")
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed request body"),
+		)
+		return
+	}
+
+	job, err := mc.reporting.CreateSearchJob(ctx, params)
+	if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.Header("Location", URIManagement+URIInventorySearchJobs+"/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (mc *ManagementController) GetSearchJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := identity.FromContext(ctx)
+	job, err := mc.reporting.GetSearchJob(ctx, id.Tenant, c.Param(paramSearchJobID))
+	if err == reporting.ErrSearchJobNotFound {
+		renderError(c,
+			http.StatusNotFound,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
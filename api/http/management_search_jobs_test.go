@@ -0,0 +1,216 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementSearchJobs(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "123456789012345678901234"
+		jobID    = "8c1c5a6e-7d36-4bd6-9d2b-1f1e3c4a5b6c"
+	)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	total := 1
+	pendingJob := &model.SearchJob{
+		ID:        jobID,
+		TenantID:  tenantID,
+		Status:    model.SearchJobStatusPending,
+		CreatedTs: now,
+		ExpireTs:  now.Add(time.Hour),
+	}
+	doneJob := &model.SearchJob{
+		ID:       jobID,
+		TenantID: tenantID,
+		Status:   model.SearchJobStatusDone,
+		Devices: []inventory.Device{{
+			ID: "194d1060-1717-44dc-a783-00038f4a8013",
+		}},
+		Total:      &total,
+		CreatedTs:  now,
+		FinishedTs: &now,
+		ExpireTs:   now.Add(time.Hour),
+	}
+	jobPath := URIManagement + URIInventorySearchJobs + "/" + jobID
+
+	type testCase struct {
+		Name string
+
+		Method string
+		Path   string
+		Body   interface{}
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Location string
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok, create",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIInventorySearchJobs,
+		Body: model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "device_type",
+				Type:      "$eq",
+				Value:     "raspberrypi4",
+			}},
+		},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CreateSearchJob", contextMatcher,
+				mock.MatchedBy(func(params *model.SearchParams) bool {
+					return params.TenantID == tenantID &&
+						len(params.Filters) == 1 &&
+						params.Page == ParamPageDefault &&
+						params.PerPage == ParamPerPageDefault
+				})).
+				Return(pendingJob, nil)
+			return app
+		},
+
+		Code:     http.StatusAccepted,
+		Location: jobPath,
+		Response: pendingJob,
+	}, {
+		Name: "error, create with cursor",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIInventorySearchJobs,
+		Body: model.SearchParams{
+			Cursor: model.CursorStart,
+		},
+
+		Code: http.StatusBadRequest,
+		Response: Error{
+			Err: "malformed request body: cursor: the search jobs don't support cursors",
+		},
+	}, {
+		Name: "error, create internal error",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIInventorySearchJobs,
+		Body:   model.SearchParams{},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CreateSearchJob", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}, {
+		Name: "ok, get",
+
+		Method: http.MethodGet,
+		Path:   jobPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSearchJob", contextMatcher, tenantID, jobID).
+				Return(doneJob, nil)
+			return app
+		},
+
+		Code:     http.StatusOK,
+		Response: doneJob,
+	}, {
+		Name: "error, get not found",
+
+		Method: http.MethodGet,
+		Path:   jobPath,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("GetSearchJob", contextMatcher, tenantID, jobID).
+				Return(nil, reporting.ErrSearchJobNotFound)
+			return app
+		},
+
+		Code:     http.StatusNotFound,
+		Response: Error{Err: reporting.ErrSearchJobNotFound.Error()},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			var body []byte
+			if tc.Body != nil {
+				body, _ = json.Marshal(tc.Body)
+			}
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				tc.Method,
+				tc.Path,
+				bytes.NewReader(body),
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+			assert.Equal(t, tc.Location, w.Header().Get("Location"))
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementAggregateSoftware(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	type testCase struct {
+		Name string
+
+		Query string
+		App   func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Query: "name=rootfs-image&limit=5",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateSoftware", contextMatcher,
+				&model.SoftwareParams{
+					Name:     "rootfs-image",
+					Limit:    5,
+					TenantID: tenantID,
+				}).
+				Return(self.Response, nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: []model.SoftwareSummary{{
+			Name:    "rootfs-image",
+			Version: "release-1",
+			Count:   12,
+		}},
+	}, {
+		Name: "error, invalid limit",
+
+		Query: "limit=1000",
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed query parameters: limit: must be no greater than 500."},
+	}, {
+		Name: "error, internal app error",
+
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("AggregateSoftware", contextMatcher,
+				mock.AnythingOfType("*model.SoftwareParams")).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodGet,
+				URIManagement+URIInventorySoftware+"?"+tc.Query,
+				nil,
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestManagementSearchSoftwareDevices(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	type testCase struct {
+		Name string
+
+		Query string
+		App   func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Total    string
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Query: "name=rootfs-image&version=release-1&page=2&per_page=10",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchSoftwareDevices", contextMatcher,
+				&model.SoftwareDevicesParams{
+					Name:     "rootfs-image",
+					Version:  "release-1",
+					Page:     2,
+					PerPage:  10,
+					TenantID: tenantID,
+				}).
+				Return(self.Response, 11, nil)
+			return app
+		},
+
+		Code:  http.StatusOK,
+		Total: "11",
+		Response: []model.DeviceSoftware{{
+			ID:               "1",
+			TenantID:         tenantID,
+			Software:         []string{"rootfs-image"},
+			SoftwareVersions: []string{"rootfs-image=release-1"},
+		}},
+	}, {
+		Name: "error, missing name",
+
+		Query: "version=release-1",
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed query parameters: name: cannot be blank."},
+	}, {
+		Name: "error, internal app error",
+
+		Query: "name=rootfs-image",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchSoftwareDevices", contextMatcher,
+				mock.AnythingOfType("*model.SoftwareDevicesParams")).
+				Return(nil, 0, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodGet,
+				URIManagement+URIInventorySoftwareSearch+"?"+tc.Query,
+				nil,
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				assert.Equal(t, tc.Total, w.Header().Get(hdrTotalCount))
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
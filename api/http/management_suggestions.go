@@ -0,0 +1,76 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/rbac"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func (mc *ManagementController) SuggestDeviceAttributeValues(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var params model.SuggestParams
+	err := c.ShouldBindQuery(&params)
+	if err == nil {
+		err = params.Validate()
+	}
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			errors.Wrap(err, "malformed query parameters"),
+		)
+		return
+	}
+
+	id := identity.FromContext(ctx)
+	params.TenantID = id.Tenant
+	if scope := rbac.ExtractScopeFromHeader(c.Request); scope != nil {
+		params.Groups = scope.DeviceGroups
+	}
+	params.VisibleAttributes, err = visibleAttributes(c.Request)
+	if err != nil {
+		renderError(c,
+			http.StatusBadRequest,
+			err,
+		)
+		return
+	}
+
+	res, err := mc.reporting.SuggestDeviceAttributeValues(ctx, &params)
+	if errors.Is(err, reporting.ErrAttributeNotVisible) {
+		renderError(c,
+			http.StatusForbidden,
+			err,
+		)
+		return
+	} else if err != nil {
+		renderError(c,
+			http.StatusInternalServerError,
+			err,
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
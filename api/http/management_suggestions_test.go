@@ -0,0 +1,144 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestManagementSuggestDeviceAttributeValues(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	type testCase struct {
+		Name string
+
+		Query string
+		App   func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response interface{}
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Query: "scope=inventory&attribute=hostname&prefix=rasp&limit=5",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SuggestDeviceAttributeValues", contextMatcher,
+				&model.SuggestParams{
+					Scope:     model.ScopeInventory,
+					Attribute: "hostname",
+					Prefix:    "rasp",
+					Limit:     5,
+					TenantID:  tenantID,
+				}).
+				Return(self.Response, nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+		Response: []model.AttributeSuggestion{{
+			Value: "raspberrypi4",
+			Count: 12,
+		}},
+	}, {
+		Name: "error, missing attribute",
+
+		Query: "scope=inventory",
+
+		Code:     http.StatusBadRequest,
+		Response: Error{Err: "malformed query parameters: attribute: cannot be blank."},
+	}, {
+		Name: "error, invalid limit",
+
+		Query: "scope=inventory&attribute=hostname&limit=ten",
+
+		Code: http.StatusBadRequest,
+		Response: Error{Err: "malformed query parameters: " +
+			"strconv.ParseInt: parsing \"ten\": invalid syntax"},
+	}, {
+		Name: "error, internal app error",
+
+		Query: "scope=inventory&attribute=hostname",
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SuggestDeviceAttributeValues", contextMatcher,
+				mock.AnythingOfType("*model.SuggestParams")).
+				Return(nil, errors.New("internal error"))
+			return app
+		},
+
+		Code:     http.StatusInternalServerError,
+		Response: Error{Err: "internal error"},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			req, _ := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodGet,
+				URIManagement+URIInventoryAttrSuggest+"?"+tc.Query,
+				nil,
+			)
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  tenantID,
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+
+			switch res := tc.Response.(type) {
+			case Error:
+				var actual Error
+				dec := json.NewDecoder(w.Body)
+				dec.DisallowUnknownFields()
+				err := dec.Decode(&actual)
+				if assert.NoError(t, err, "response schema did not match expected Error") {
+					assert.EqualError(t, res, actual.Error())
+				}
+
+			default:
+				b, _ := json.Marshal(res)
+				assert.JSONEq(t, string(b), w.Body.String())
+			}
+		})
+	}
+}
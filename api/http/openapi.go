@@ -0,0 +1,508 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const mimeYAML = "application/yaml"
+
+var openAPIPathParam = regexp.MustCompile(`{([^}/]+)}`)
+
+// openAPISpec is an OpenAPI specification of one of the APIs, with its
+// operations indexed by method and route
+type openAPISpec struct {
+	raw        []byte
+	doc        map[string]interface{}
+	basePath   string
+	operations map[string]*openAPIOperation
+	patterns   sync.Map
+}
+
+// openAPIOperation holds the parts of an operation of the specification
+// the requests are validated against
+type openAPIOperation struct {
+	parameters   []map[string]interface{}
+	body         map[string]interface{}
+	bodyRequired bool
+}
+
+// newOpenAPISpec parses the OpenAPI specification of the API served under
+// basePath
+func newOpenAPISpec(raw []byte, basePath string) (*openAPISpec, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the OpenAPI specification")
+	}
+	root, ok := normalizeYAML(doc).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid OpenAPI specification")
+	}
+	spec := &openAPISpec{
+		raw:        raw,
+		doc:        root,
+		basePath:   basePath,
+		operations: make(map[string]*openAPIOperation),
+	}
+	paths, _ := root["paths"].(map[string]interface{})
+	for path, item := range paths {
+		item, _ := item.(map[string]interface{})
+		route := openAPIPathParam.ReplaceAllString(path, ":$1")
+		common := objects(item["parameters"])
+		for method, op := range item {
+			op, ok := op.(map[string]interface{})
+			if !ok || method == "parameters" {
+				continue
+			}
+			operation := &openAPIOperation{
+				parameters: append(objects(op["parameters"]), common...),
+			}
+			if body, ok := spec.resolve(op["requestBody"]); ok {
+				operation.bodyRequired, _ = body["required"].(bool)
+				content, _ := body["content"].(map[string]interface{})
+				if media, ok := content[gin.MIMEJSON].(map[string]interface{}); ok {
+					operation.body, _ = media["schema"].(map[string]interface{})
+				}
+			}
+			for i, param := range operation.parameters {
+				if param, ok := spec.resolve(param); ok {
+					operation.parameters[i] = param
+				}
+			}
+			spec.operations[strings.ToUpper(method)+" "+route] = operation
+		}
+	}
+	return spec, nil
+}
+
+func mustOpenAPISpec(raw []byte, basePath string) *openAPISpec {
+	spec, err := newOpenAPISpec(raw, basePath)
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// normalizeYAML converts the maps decoded from YAML, whose keys may not be
+// strings (e.g. the status codes of the responses), to JSON objects
+func normalizeYAML(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = normalizeYAML(v)
+		}
+		return value
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			res[fmt.Sprint(k)] = normalizeYAML(v)
+		}
+		return res
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalizeYAML(v)
+		}
+		return value
+	}
+	return value
+}
+
+func objects(value interface{}) []map[string]interface{} {
+	items, _ := value.([]interface{})
+	res := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if item, ok := item.(map[string]interface{}); ok {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// resolve returns the object, following its $ref to the components of the
+// specification, if any
+func (spec *openAPISpec) resolve(value interface{}) (map[string]interface{}, bool) {
+	obj, ok := value.(map[string]interface{})
+	for i := 0; ok && i < 32; i++ {
+		ref, isRef := obj["$ref"].(string)
+		if !isRef {
+			return obj, true
+		}
+		var node interface{} = spec.doc
+		for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			parent, _ := node.(map[string]interface{})
+			node = parent[key]
+		}
+		obj, ok = node.(map[string]interface{})
+	}
+	return nil, false
+}
+
+// serve returns a handler serving the specification
+func (spec *openAPISpec) serve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, mimeYAML, spec.raw)
+	}
+}
+
+// validate returns a middleware rejecting with 400 the requests which do
+// not match the parameters and the JSON request body of their operation in
+// the specification; the details of the error list the invalid fields. The
+// requests to the routes which are not in the specification pass through.
+func (spec *openAPISpec) validate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := strings.TrimPrefix(c.FullPath(), spec.basePath)
+		operation, ok := spec.operations[c.Request.Method+" "+route]
+		if !ok {
+			return
+		}
+		errs := validation.Errors{}
+		query := c.Request.URL.Query()
+		for _, param := range operation.parameters {
+			name, _ := param["name"].(string)
+			schema, _ := param["schema"].(map[string]interface{})
+			required, _ := param["required"].(bool)
+			var values []string
+			switch param["in"] {
+			case "query":
+				values = query[name]
+			case "path":
+				values = []string{c.Param(name)}
+			default:
+				continue
+			}
+			key := fmt.Sprint(param["in"], ".", name)
+			if len(values) == 0 {
+				if required {
+					errs[key] = errors.New("is required")
+				}
+				continue
+			}
+			spec.validateParameter(values, schema, key, errs)
+		}
+
+		if operation.body != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				renderError(c, http.StatusBadRequest, errors.Wrap(err,
+					"failed to read the request body"))
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			if len(bytes.TrimSpace(body)) > 0 {
+				var value interface{}
+				if err := json.Unmarshal(body, &value); err != nil {
+					renderError(c, http.StatusBadRequest, errors.Wrap(err,
+						"malformed request body"))
+					c.Abort()
+					return
+				}
+				spec.validateSchema(value, operation.body, "body", errs)
+			} else if operation.bodyRequired {
+				errs["body"] = errors.New("is required")
+			}
+		}
+
+		if len(errs) > 0 {
+			renderError(c, http.StatusBadRequest, errors.Wrap(errs,
+				"request validation failed"))
+			c.Abort()
+		}
+	}
+}
+
+// validateParameter validates the values of a query or path parameter,
+// converted to the type of its schema
+func (spec *openAPISpec) validateParameter(
+	values []string,
+	schema map[string]interface{},
+	key string,
+	errs validation.Errors,
+) {
+	schema, _ = spec.resolve(schema)
+	if schema["type"] == "array" {
+		items, _ := spec.resolve(schema["items"])
+		converted := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			for _, value := range strings.Split(value, ",") {
+				converted = append(converted, parseParameter(value, items))
+			}
+		}
+		spec.validateSchema(converted, schema, key, errs)
+		return
+	}
+	spec.validateSchema(parseParameter(values[0], schema), schema, key, errs)
+}
+
+// parseParameter converts the value of a parameter to the type of its
+// schema; the values which do not convert are left as strings, and fail
+// the validation of the type
+func parseParameter(value string, schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateSchema validates the value, decoded from JSON, against the
+// schema, adding the errors of the invalid fields to errs
+func (spec *openAPISpec) validateSchema(
+	value interface{},
+	schema map[string]interface{},
+	key string,
+	errs validation.Errors,
+) {
+	schema, ok := spec.resolve(schema)
+	if !ok || value == nil {
+		// the null values decode to the zero values in the handlers
+		return
+	}
+	if !spec.validateComposition(value, schema, key, errs) {
+		return
+	}
+	if err := validateEnum(value, schema); err != nil {
+		errs[key] = err
+		return
+	}
+	spec.validateType(value, schema, key, errs)
+}
+
+// validateEnum validates the value against the values of the enum of the
+// schema, if any
+func validateEnum(value interface{}, schema map[string]interface{}) error {
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(value, enum) {
+		return errors.New("must be a valid value")
+	}
+	return nil
+}
+
+// validateType validates the value with the validator of the type of the
+// schema
+func (spec *openAPISpec) validateType(
+	value interface{},
+	schema map[string]interface{},
+	key string,
+	errs validation.Errors,
+) {
+	var err error
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			err = errors.New("must be an object")
+			break
+		}
+		spec.validateObject(obj, schema, key, errs)
+	case "array":
+		err = spec.validateArray(value, schema, key, errs)
+	case "string":
+		err = spec.validateString(value, schema)
+	case "integer", "number":
+		err = validateNumber(value, schema)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			err = errors.New("must be a boolean")
+		}
+	default:
+		if obj, ok := value.(map[string]interface{}); ok && schema["properties"] != nil {
+			spec.validateObject(obj, schema, key, errs)
+		}
+	}
+	if err != nil {
+		errs[key] = err
+	}
+}
+
+// validateComposition validates the value against the allOf and oneOf
+// schemas, returning false if the value matches none or several of the
+// oneOf schemas
+func (spec *openAPISpec) validateComposition(
+	value interface{},
+	schema map[string]interface{},
+	key string,
+	errs validation.Errors,
+) bool {
+	for _, sub := range objects(schema["allOf"]) {
+		spec.validateSchema(value, sub, key, errs)
+	}
+	alternatives := objects(schema["oneOf"])
+	if len(alternatives) == 0 {
+		return true
+	}
+	matches := 0
+	for _, sub := range alternatives {
+		subErrs := validation.Errors{}
+		spec.validateSchema(value, sub, key, subErrs)
+		if len(subErrs) == 0 {
+			matches++
+		}
+	}
+	if matches != 1 {
+		errs[key] = errors.New("must match exactly one of the schemas")
+		return false
+	}
+	return true
+}
+
+// validateArray validates the value against an array schema and its
+// items against the schema of the items, adding their errors to errs
+func (spec *openAPISpec) validateArray(
+	value interface{},
+	schema map[string]interface{},
+	key string,
+	errs validation.Errors,
+) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return errors.New("must be an array")
+	}
+	itemSchema, _ := schema["items"].(map[string]interface{})
+	for i, item := range items {
+		spec.validateSchema(item, itemSchema, key+"."+strconv.Itoa(i), errs)
+	}
+	if min, ok := number(schema["minItems"]); ok && float64(len(items)) < min {
+		return errors.Errorf("must have at least %v items", min)
+	} else if max, ok := number(schema["maxItems"]); ok && float64(len(items)) > max {
+		return errors.Errorf("must have at most %v items", max)
+	}
+	return nil
+}
+
+// validateString validates the value against a string schema
+func (spec *openAPISpec) validateString(
+	value interface{},
+	schema map[string]interface{},
+) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("must be a string")
+	}
+	length := float64(utf8.RuneCountInString(s))
+	if min, ok := number(schema["minLength"]); ok && length < min {
+		return errors.Errorf("the length must be at least %v", min)
+	} else if max, ok := number(schema["maxLength"]); ok && length > max {
+		return errors.Errorf("the length must be no more than %v", max)
+	} else if pattern, ok := schema["pattern"].(string); ok && !spec.match(pattern, s) {
+		return errors.New("must be in a valid format")
+	}
+	return nil
+}
+
+// validateNumber validates the value against a number or integer schema
+func validateNumber(value interface{}, schema map[string]interface{}) error {
+	f, ok := value.(float64)
+	if !ok {
+		return errors.New("must be a number")
+	} else if schema["type"] == "integer" && f != math.Trunc(f) {
+		return errors.New("must be an integer")
+	}
+	if min, ok := number(schema["minimum"]); ok && f < min {
+		return errors.Errorf("must be no less than %v", min)
+	} else if max, ok := number(schema["maximum"]); ok && f > max {
+		return errors.Errorf("must be no greater than %v", max)
+	}
+	return nil
+}
+
+func (spec *openAPISpec) validateObject(
+	obj map[string]interface{},
+	schema map[string]interface{},
+	key string,
+	errs validation.Errors,
+) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required, _ := schema["required"].([]interface{})
+	for _, name := range required {
+		name, _ := name.(string)
+		if _, ok := obj[name]; !ok {
+			errs[key+"."+name] = errors.New("is required")
+		}
+	}
+	for name, value := range obj {
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			spec.validateSchema(value, property, key+"."+name, errs)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				errs[key+"."+name] = errors.New("is not allowed")
+			}
+		case map[string]interface{}:
+			spec.validateSchema(value, additional, key+"."+name, errs)
+		}
+	}
+}
+
+// match matches the string against the pattern of a schema; the patterns
+// which do not compile match any string
+func (spec *openAPISpec) match(pattern, s string) bool {
+	re, ok := spec.patterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return true
+		}
+		re, _ = spec.patterns.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(s)
+}
+
+// number returns the numeric keyword of a schema, decoded from YAML
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if n, ok := number(allowed); ok {
+			allowed = n
+		}
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
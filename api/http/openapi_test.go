@@ -0,0 +1,227 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/docs"
+)
+
+func TestOpenAPISpecs(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(new(mapp.App), WithGraphQL(true))
+	routes := map[string]bool{}
+	for _, route := range router.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for _, spec := range []*openAPISpec{internalOpenAPISpec, mgmtOpenAPISpec} {
+		// all the references resolve
+		var walk func(value interface{})
+		walk = func(value interface{}) {
+			switch value := value.(type) {
+			case map[string]interface{}:
+				if ref, ok := value["$ref"].(string); ok {
+					_, resolved := spec.resolve(value)
+					assert.True(t, resolved, "unresolved reference %s", ref)
+				}
+				for _, v := range value {
+					walk(v)
+				}
+			case []interface{}:
+				for _, v := range value {
+					walk(v)
+				}
+			}
+		}
+		walk(spec.doc)
+
+		// all the operations are served
+		assert.NotEmpty(t, spec.operations)
+		for key := range spec.operations {
+			parts := strings.SplitN(key, " ", 2)
+			assert.True(t, routes[parts[0]+" "+spec.basePath+parts[1]],
+				"operation %s is not served", key)
+		}
+	}
+}
+
+func TestServeOpenAPISpecs(t *testing.T) {
+	t.Parallel()
+
+	router := NewRouter(new(mapp.App))
+
+	req, _ := http.NewRequest(http.MethodGet, URIInternal+URIOpenAPI, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, mimeYAML, w.Header().Get("Content-Type"))
+	assert.Equal(t, docs.InternalAPI, w.Body.Bytes())
+
+	req, _ = http.NewRequest(http.MethodGet, URIManagement+URIOpenAPI, nil)
+	req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+		Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+		Tenant:  "123456789012345678901234",
+	}))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, docs.ManagementAPI, w.Body.Bytes())
+}
+
+func TestValidateRequests(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Name string
+
+		Method string
+		Path   string
+		Body   string
+		App    func(*testing.T, testCase) *mapp.App
+
+		Code     int
+		Response *Error
+	}
+	testCases := []testCase{{
+		Name: "ok, search",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIInventorySearch,
+		Body: `{"page": 1, "per_page": 20, "filters": [{"scope": "inventory",
+			"attribute": "SN", "type": "$eq", "value": "1234567890"}]}`,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDevices", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return([]inventory.Device{}, 0, nil)
+			return app
+		},
+
+		Code: http.StatusOK,
+	}, {
+		Name: "ok, not in the specification",
+
+		Method: http.MethodGet,
+		Path:   URIManagement + URIOpenAPI,
+
+		Code: http.StatusOK,
+	}, {
+		Name: "error, invalid body",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIInventorySearch,
+		Body: `{"page": 1.5, "filters": [{"scope": "inventory",
+			"attribute": "SN", "type": "$magic"}]}`,
+
+		Code: http.StatusBadRequest,
+		Response: &Error{
+			Code: ErrCodeValidationFailed,
+			Err: "request validation failed: body.filters.0.type: must be a valid " +
+				"value; body.filters.0.value: is required; body.page: must be an " +
+				"integer.",
+			Details: map[string]interface{}{
+				"body.filters.0.type":  "must be a valid value",
+				"body.filters.0.value": "is required",
+				"body.page":            "must be an integer",
+			},
+		},
+	}, {
+		Name: "error, invalid query parameter",
+
+		Method: http.MethodGet,
+		Path:   URIManagement + URIInventorySummary + "?offline_hours=0",
+
+		Code: http.StatusBadRequest,
+		Response: &Error{
+			Code: ErrCodeValidationFailed,
+			Err:  "request validation failed: query.offline_hours: must be no less than 1.",
+			Details: map[string]interface{}{
+				"query.offline_hours": "must be no less than 1",
+			},
+		},
+	}, {
+		Name: "error, malformed body",
+
+		Method: http.MethodPost,
+		Path:   URIManagement + URIInventorySearch,
+		Body:   `{"page": `,
+
+		Code: http.StatusBadRequest,
+		Response: &Error{
+			Code: ErrCodeInvalidRequest,
+			Err:  "malformed request body: unexpected end of JSON input",
+		},
+	}, {
+		Name: "error, internal API",
+
+		Method: http.MethodGet,
+		Path:   URIInternal + URIDeadLetters + "?per_page=1000",
+
+		Code: http.StatusBadRequest,
+		Response: &Error{
+			Code: ErrCodeValidationFailed,
+			Err:  "request validation failed: query.per_page: must be no greater than 500.",
+			Details: map[string]interface{}{
+				"query.per_page": "must be no greater than 500",
+			},
+		},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			var app *mapp.App
+			if tc.App == nil {
+				app = new(mapp.App)
+			} else {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			router := NewRouter(app, WithRequestValidation(true))
+
+			req, _ := http.NewRequest(tc.Method, tc.Path, bytes.NewReader([]byte(tc.Body)))
+			req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+				Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+				Tenant:  "123456789012345678901234",
+			}))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.Code, w.Code)
+			if tc.Response != nil {
+				var actual Error
+				err := json.Unmarshal(w.Body.Bytes(), &actual)
+				if assert.NoError(t, err) {
+					actual.RequestID = ""
+					assert.Equal(t, *tc.Response, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+)
+
+const (
+	hdrRetryAfter = "Retry-After"
+
+	// rateLimitSweepInterval is the interval at which the buckets of the
+	// idle tenants are dropped
+	rateLimitSweepInterval = time.Minute
+)
+
+var (
+	ErrTooManyRequests = errors.New("too many requests, please retry later")
+)
+
+// tokenBucket holds the tokens left to a tenant as of the last request
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket rate limiter keyed by tenant ID: each
+// tenant gets a bucket of burst tokens, refilled at rate tokens per second,
+// and every request takes a token from its tenant's bucket
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the tenant's bucket; if the bucket is empty, it
+// returns false and the time until the next token is available
+func (rl *rateLimiter) Allow(tenantID string) (bool, time.Duration) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	now := rl.now()
+	rl.sweep(now)
+
+	bucket, ok := rl.buckets[tenantID]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[tenantID] = bucket
+	} else if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens = math.Min(rl.burst, bucket.tokens+elapsed.Seconds()*rl.rate)
+		bucket.last = now
+	}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops the buckets which are full again: a new bucket would be the
+// same, and this keeps the map bounded by the number of active tenants
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+	refill := time.Duration(rl.burst / rl.rate * float64(time.Second))
+	for tenantID, bucket := range rl.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(rl.buckets, tenantID)
+		}
+	}
+}
+
+// rateLimit returns a middleware rejecting with 429 the requests of the
+// tenants exceeding their rate limit; it is a no-op if rate limiting is
+// disabled
+func (mc *ManagementController) rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if mc.rateLimiter == nil {
+			return
+		}
+		var tenantID string
+		if id := identity.FromContext(c.Request.Context()); id != nil {
+			tenantID = id.Tenant
+		}
+		if ok, wait := mc.rateLimiter.Allow(tenantID); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			c.Header(hdrRetryAfter, strconv.Itoa(retryAfter))
+			renderError(c, http.StatusTooManyRequests, ErrTooManyRequests)
+			c.Abort()
+		}
+	}
+}
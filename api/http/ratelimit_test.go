@@ -0,0 +1,106 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(2, 3)
+	rl.now = func() time.Time { return now }
+
+	// the burst is allowed straight away
+	for i := 0; i < 3; i++ {
+		ok, _ := rl.Allow("tenant1")
+		assert.True(t, ok)
+	}
+	ok, wait := rl.Allow("tenant1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// the other tenants have their own bucket
+	ok, _ = rl.Allow("tenant2")
+	assert.True(t, ok)
+
+	// the bucket is refilled at rate tokens per second
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = rl.Allow("tenant1")
+	assert.True(t, ok)
+	ok, _ = rl.Allow("tenant1")
+	assert.False(t, ok)
+
+	// the buckets of the idle tenants are dropped
+	now = now.Add(rateLimitSweepInterval)
+	ok, _ = rl.Allow("tenant1")
+	assert.True(t, ok)
+	assert.Len(t, rl.buckets, 1)
+	assert.NotContains(t, rl.buckets, "tenant2")
+}
+
+func TestManagementRateLimit(t *testing.T) {
+	t.Parallel()
+
+	app := new(mapp.App)
+	defer app.AssertExpectations(t)
+	app.On("SearchDevices", contextMatcher,
+		mock.AnythingOfType("*model.SearchParams")).
+		Return([]inventory.Device{}, 0, nil).
+		Twice()
+
+	router := NewRouter(app, WithTenantRateLimit(0.001, 1))
+	search := func(tenantID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequestWithContext(
+			context.Background(),
+			http.MethodPost,
+			URIManagement+URIInventorySearch,
+			strings.NewReader("{}"),
+		)
+		req.Header.Set("Authorization", "Bearer "+GenerateJWT(identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  tenantID,
+		}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := search("tenant1")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = search("tenant1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1000", w.Header().Get(hdrRetryAfter))
+	assert.Contains(t, w.Body.String(), ErrTooManyRequests.Error())
+
+	w = search("tenant2")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
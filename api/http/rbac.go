@@ -0,0 +1,48 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// hdrRBACAttributes is the header, set by the API gateway like the RBAC
+// device groups, listing the attributes visible to the role of the user as
+// comma-separated scope/name selectors, e.g. inventory/cpu_*; all the
+// attributes are visible without it
+const hdrRBACAttributes = "X-MEN-RBAC-Inventory-Attributes"
+
+// visibleAttributes returns the attributes visible to the role of the
+// user, or nil if all the attributes are visible
+func visibleAttributes(r *http.Request) (*model.AttributesFilter, error) {
+	value := r.Header.Get(hdrRBACAttributes)
+	if value == "" {
+		return nil, nil
+	}
+	filter := &model.AttributesFilter{}
+	for _, s := range strings.Split(value, ",") {
+		selector, err := model.ParseAttributeSelector(strings.TrimSpace(s))
+		if err != nil {
+			return nil, errors.Wrap(err, hdrRBACAttributes)
+		}
+		filter.Included = append(filter.Included, selector)
+	}
+	return filter, nil
+}
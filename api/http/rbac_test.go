@@ -0,0 +1,161 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestVisibleAttributes(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		header string
+
+		filter *model.AttributesFilter
+		err    error
+	}{
+		"ok, no restrictions": {},
+		"ok": {
+			header: "inventory/device_*, tags/owner",
+			filter: &model.AttributesFilter{
+				Included: []model.AttributeSelector{
+					{Scope: model.ScopeInventory, Name: "device_*"},
+					{Scope: model.ScopeTags, Name: "owner"},
+				},
+			},
+		},
+		"error, invalid selector": {
+			header: "inventory/device_*,mac",
+			err: errors.New("X-MEN-RBAC-Inventory-Attributes: " +
+				`invalid attribute selector "mac": expected scope/name`),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+			if tc.header != "" {
+				req.Header.Set(hdrRBACAttributes, tc.header)
+			}
+			filter, err := visibleAttributes(req)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.filter, filter)
+		})
+	}
+}
+
+func TestManagementSearchDevicesVisibleAttributes(t *testing.T) {
+	t.Parallel()
+
+	identityCTX := identity.WithContext(context.Background(),
+		&identity.Identity{
+			Subject: "851f90b3-cee5-425e-8f6e-b36de1993e7e",
+			Tenant:  "123456789012345678901234",
+		},
+	)
+	visibleMatcher := mock.MatchedBy(func(params *model.SearchParams) bool {
+		return assert.Equal(t, &model.AttributesFilter{
+			Included: []model.AttributeSelector{
+				{Scope: model.ScopeInventory, Name: "device_*"},
+			},
+		}, params.VisibleAttributes)
+	})
+	testCases := map[string]struct {
+		header string
+		app    func() *mapp.App
+
+		code    int
+		errCode string
+	}{
+		"ok": {
+			header: "inventory/device_*",
+			app: func() *mapp.App {
+				app := new(mapp.App)
+				app.On("SearchDevices", contextMatcher, visibleMatcher).
+					Return([]inventory.Device{}, 0, nil)
+				return app
+			},
+			code: http.StatusOK,
+		},
+		"error, attribute not visible": {
+			header: "inventory/device_*",
+			app: func() *mapp.App {
+				app := new(mapp.App)
+				app.On("SearchDevices", contextMatcher, visibleMatcher).
+					Return(nil, 0, errors.Wrap(reporting.ErrAttributeNotVisible,
+						"inventory/mac"))
+				return app
+			},
+			code:    http.StatusForbidden,
+			errCode: ErrCodeAttributeNotVisible,
+		},
+		"error, invalid header": {
+			header:  "device_*",
+			app:     func() *mapp.App { return new(mapp.App) },
+			code:    http.StatusBadRequest,
+			errCode: ErrCodeInvalidRequest,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := tc.app()
+			defer app.AssertExpectations(t)
+			router := NewRouter(app)
+
+			b, _ := json.Marshal(&model.SearchParams{})
+			req, _ := http.NewRequest(
+				http.MethodPost,
+				URIManagement+URIInventorySearch,
+				bytes.NewReader(b),
+			)
+			req.Header.Set(hdrRBACAttributes, tc.header)
+			req.Header.Set("Authorization",
+				"Bearer "+GenerateJWT(*identity.FromContext(identityCTX)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			if tc.errCode != "" {
+				var res Error
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, tc.errCode, res.Code)
+			}
+		})
+	}
+}
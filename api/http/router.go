@@ -16,13 +16,16 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"github.com/mendersoftware/go-lib-micro/accesslog"
-	"github.com/mendersoftware/go-lib-micro/identity"
 	"github.com/mendersoftware/go-lib-micro/rbac"
 	"github.com/mendersoftware/go-lib-micro/requestid"
 
 	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/docs"
+	"github.com/mendersoftware/reporting/tracing"
 )
 
 // API URL used by the HTTP router
@@ -30,45 +33,159 @@ const (
 	URIInternal   = "/api/internal/v1/reporting"
 	URIManagement = "/api/management/v1/reporting"
 
+	URIAlerts                  = "/alerts"
+	URIAlert                   = "/alerts/:id"
 	URIAlive                   = "/alive"
+	URIAuditLogs               = "/audit-logs"
+	URIDeadLetters             = "/dead-letters"
+	URIDeadLetter              = "/dead-letters/:id"
+	URIDeadLetterReplay        = "/dead-letters/:id/replay"
 	URIHealth                  = "/health"
+	URIReadiness               = "/readiness"
 	URIDeploymentsAggregate    = "/deployments/devices/aggregate"
 	URIDeploymentsSearch       = "/deployments/devices/search"
+	URIDeploymentsTimeline     = "/deployments/devices/:id/timeline"
+	URIGraphQL                 = "/graphql"
 	URIInventoryAggregate      = "/devices/aggregate"
 	URIInventoryAttrs          = "/devices/attributes"
+	URIInventoryAttrSuggest    = "/devices/attributes/suggestions"
+	URIInventoryAttrsMapping   = "/devices/attributes/mapping"
+	URIInventoryAttrsEvict     = "/devices/attributes/mapping/evict"
+	URIInventoryAttrsTypes     = "/devices/attributes/types"
+	URIInventoryDeviceTags     = "/devices/:id/tags"
+	URIInventoryGroups         = "/devices/groups"
+	URIInventoryGroupsAggr     = "/devices/groups/aggregate"
 	URIInventorySearch         = "/devices/search"
+	URIInventorySearchCount    = "/devices/search/count"
+	URIInventorySearchExport   = "/devices/search/export"
+	URIInventorySearchStream   = "/devices/search/stream"
+	URIInventorySearchJobs     = "/devices/search/jobs"
+	URIInventorySearchJob      = "/devices/search/jobs/:id"
+	URIInventoryIndexingRules  = "/devices/indexing-rules"
+	URIIndexSettingsInternal   = "/indices/:index/settings"
 	URIInventorySearchAttrs    = "/devices/search/attributes"
+	URIInventorySoftware       = "/devices/software"
+	URIInventorySoftwareSearch = "/devices/software/devices"
+	URIInventorySummary        = "/devices/summary"
 	URIInventorySearchInternal = "/tenants/:tenant_id/devices/search"
+	URIInventoryDeviceInternal = "/tenants/:tenant_id/devices/:id"
+	URIMetrics                 = "/metrics"
+	URIOpenAPI                 = "/openapi.yml"
+	URIInventoryDiffInternal   = "/tenants/:tenant_id/devices/:id/diff"
+	URIInventoryExportInternal = "/tenants/:tenant_id/devices/search/export"
+	URIReindexInternal         = "/tenants/:tenant_id/devices/reindex"
+	URIReindexStateInternal    = "/tenants/:tenant_id/devices/reindex/checkpoint"
+	URITenantInternal          = "/tenants/:tenant_id"
+	URITenantUsageInternal     = "/tenants/:tenant_id/usage"
+	URIAttrsUsageInternal      = "/tenants/:tenant_id/usage/attributes"
+	URISavedSearches           = "/devices/saved-searches"
+	URISavedSearch             = "/devices/saved-searches/:id"
+	URISavedSearchExecute      = "/devices/saved-searches/:id/search"
+)
+
+// the OpenAPI specifications of the APIs, embedded in the binary
+var (
+	internalOpenAPISpec = mustOpenAPISpec(docs.InternalAPI, URIInternal)
+	mgmtOpenAPISpec     = mustOpenAPISpec(docs.ManagementAPI, URIManagement)
 )
 
 // NewRouter returns the gin router
-func NewRouter(reporting reporting.App) *gin.Engine {
+func NewRouter(reporting reporting.App, opts ...Option) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	gin.DisableConsoleColor()
 
 	router := gin.New()
+	router.Use(otelgin.Middleware(tracing.ServiceName))
 	router.Use(accesslog.Middleware())
 	router.Use(gin.Recovery())
 	router.Use(requestid.Middleware())
 
+	mgmt := NewManagementController(reporting, opts...)
+
 	internal := NewInternalController(reporting)
 	internalAPI := router.Group(URIInternal)
+	if mgmt.requestValidation {
+		internalAPI.Use(internalOpenAPISpec.validate())
+	}
+	internalAPI.GET(URIOpenAPI, internalOpenAPISpec.serve())
 	internalAPI.GET(URIAlive, internal.Alive)
 	internalAPI.GET(URIHealth, internal.Health)
+	internalAPI.GET(URIReadiness, internal.Readiness)
+	internalAPI.POST(URIInventorySearch, internal.SearchDevicesAcrossTenants)
 	internalAPI.POST(URIInventorySearchInternal, internal.SearchDevices)
+	internalAPI.GET(URIInventoryDeviceInternal, internal.GetDevice)
+	internalAPI.GET(URIInventoryDiffInternal, internal.DiffDevice)
+	internalAPI.POST(URIInventoryExportInternal, internal.ExportDevices)
+	internalAPI.POST(URIReindexInternal, internal.ReindexDevices)
+	internalAPI.GET(URIReindexStateInternal, internal.GetReindexState)
+	internalAPI.DELETE(URIReindexStateInternal, internal.ResetReindexState)
+	internalAPI.DELETE(URITenantInternal, internal.DeleteTenant)
+	internalAPI.GET(URITenantUsageInternal, internal.GetTenantUsage)
+	internalAPI.GET(URIAttrsUsageInternal, internal.GetAttributesUsage)
+	internalAPI.GET(URIMetrics, gin.WrapH(promhttp.Handler()))
+	internalAPI.GET(URIDeadLetters, internal.ListDeadLetters)
+	internalAPI.GET(URIDeadLetter, internal.GetDeadLetter)
+	internalAPI.POST(URIDeadLetterReplay, internal.ReplayDeadLetter)
+	internalAPI.GET(URIAuditLogs, internal.ListAuditLogs)
+	internalAPI.PUT(URIIndexSettingsInternal, internal.UpdateIndexSettings)
 
-	mgmt := NewManagementController(reporting)
 	mgmtAPI := router.Group(URIManagement)
-	mgmtAPI.Use(identity.Middleware())
+	mgmtAPI.Use(authenticate())
 	mgmtAPI.Use(rbac.Middleware())
+	if mgmt.requestValidation {
+		mgmtAPI.Use(mgmtOpenAPISpec.validate())
+	}
+	mgmtAPI.Use(mgmt.auditLog())
+	// the search and aggregation endpoints are rate limited per tenant
+	rateLimit := mgmt.rateLimit()
+	// the search, aggregation and export responses are compressed
+	compress := mgmt.compress()
 	// devices
-	mgmtAPI.POST(URIInventoryAggregate, mgmt.AggregateDevices)
+	mgmtAPI.POST(URIInventoryAggregate, rateLimit, compress, mgmt.AggregateDevices)
 	mgmtAPI.GET(URIInventoryAttrs, mgmt.DeviceAttrs)
-	mgmtAPI.POST(URIInventorySearch, mgmt.SearchDevices)
+	mgmtAPI.GET(URIInventoryAttrSuggest, rateLimit, mgmt.SuggestDeviceAttributeValues)
+	mgmtAPI.GET(URIInventoryAttrsMapping, mgmt.GetAttributesMapping)
+	mgmtAPI.POST(URIInventoryAttrsEvict, mgmt.EvictAttributesMapping)
+	mgmtAPI.GET(URIInventoryAttrsTypes, mgmt.GetAttributesTypes)
+	mgmtAPI.PUT(URIInventoryAttrsTypes, mgmt.SetAttributesTypes)
+	mgmtAPI.GET(URIInventoryGroups, rateLimit, mgmt.ListGroups)
+	mgmtAPI.POST(URIInventoryGroupsAggr, rateLimit, compress, mgmt.AggregateDevicesByGroup)
+	mgmtAPI.POST(URIInventorySearch, rateLimit, compress, mgmt.SearchDevices)
+	mgmtAPI.POST(URIInventorySearchCount, rateLimit, mgmt.CountDevices)
+	mgmtAPI.POST(URIInventorySearchExport, rateLimit, compress, mgmt.ExportDevices)
+	mgmtAPI.POST(URIInventorySearchStream, rateLimit, mgmt.StreamDevices)
+	mgmtAPI.POST(URIInventorySearchJobs, rateLimit, mgmt.CreateSearchJob)
+	mgmtAPI.GET(URIInventorySearchJob, mgmt.GetSearchJob)
 	mgmtAPI.GET(URIInventorySearchAttrs, mgmt.SearchDeviceAttrs)
+	mgmtAPI.GET(URIInventorySoftware, rateLimit, mgmt.AggregateSoftware)
+	mgmtAPI.GET(URIInventorySoftwareSearch, rateLimit, compress, mgmt.SearchSoftwareDevices)
+	mgmtAPI.GET(URIInventorySummary, rateLimit, mgmt.GetFleetSummary)
+	mgmtAPI.PUT(URIInventoryDeviceTags, mgmt.SetDeviceTags)
+	mgmtAPI.GET(URIOpenAPI, mgmtOpenAPISpec.serve())
+	// saved searches
+	mgmtAPI.GET(URISavedSearches, mgmt.ListSavedSearches)
+	mgmtAPI.POST(URISavedSearches, mgmt.CreateSavedSearch)
+	mgmtAPI.GET(URISavedSearch, mgmt.GetSavedSearch)
+	mgmtAPI.PUT(URISavedSearch, mgmt.UpdateSavedSearch)
+	mgmtAPI.DELETE(URISavedSearch, mgmt.DeleteSavedSearch)
+	mgmtAPI.GET(URISavedSearchExecute, rateLimit, compress, mgmt.ExecuteSavedSearch)
+	// indexing rules
+	mgmtAPI.GET(URIInventoryIndexingRules, mgmt.GetIndexingRules)
+	mgmtAPI.PUT(URIInventoryIndexingRules, mgmt.SetIndexingRules)
+	// alerts
+	mgmtAPI.GET(URIAlerts, mgmt.ListAlerts)
+	mgmtAPI.POST(URIAlerts, mgmt.CreateAlert)
+	mgmtAPI.GET(URIAlert, mgmt.GetAlert)
+	mgmtAPI.PUT(URIAlert, mgmt.UpdateAlert)
+	mgmtAPI.DELETE(URIAlert, mgmt.DeleteAlert)
 	// deployments
-	mgmtAPI.POST(URIDeploymentsAggregate, mgmt.AggregateDeployments)
-	mgmtAPI.POST(URIDeploymentsSearch, mgmt.SearchDeployments)
+	mgmtAPI.POST(URIDeploymentsAggregate, rateLimit, compress, mgmt.AggregateDeployments)
+	mgmtAPI.POST(URIDeploymentsSearch, rateLimit, compress, mgmt.SearchDeployments)
+	mgmtAPI.GET(URIDeploymentsTimeline, mgmt.GetDeviceDeploymentsTimeline)
+	// graphql
+	if mgmt.graphQL {
+		mgmtAPI.POST(URIGraphQL, rateLimit, mgmt.GraphQL)
+	}
 
 	return router
 }
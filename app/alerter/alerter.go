@@ -0,0 +1,221 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package alerter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	StatusTriggered = "triggered"
+	StatusResolved  = "resolved"
+
+	hdrSignature = "X-Reporting-Signature"
+
+	webhookTimeout = 30 * time.Second
+)
+
+// Notification is the body POSTed to the webhook of an alert when the
+// count crosses its threshold, in either direction
+type Notification struct {
+	AlertID   string               `json:"alert_id"`
+	AlertName string               `json:"alert_name"`
+	TenantID  string               `json:"tenant_id"`
+	Status    string               `json:"status"`
+	Source    string               `json:"source"`
+	Count     int                  `json:"count"`
+	Threshold model.AlertThreshold `json:"threshold"`
+	Evaluated time.Time            `json:"evaluated_ts"`
+}
+
+// Alerter evaluates the alerts and notifies their webhooks
+type Alerter struct {
+	reporting reporting.App
+	ds        store.DataStore
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewAlerter returns a new Alerter
+func NewAlerter(reporting reporting.App, ds store.DataStore) *Alerter {
+	return &Alerter{
+		reporting: reporting,
+		ds:        ds,
+		client:    &http.Client{},
+		now:       time.Now,
+	}
+}
+
+// Run evaluates the alerts every interval, until the context is canceled
+func (a *Alerter) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		a.evaluateAll(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluateAll evaluates all the alerts; every evaluation is claimed in
+// the data store first, so that multiple alerters can run concurrently
+func (a *Alerter) evaluateAll(ctx context.Context) {
+	l := log.FromContext(ctx)
+
+	alerts, err := a.ds.GetAllAlerts(ctx)
+	if err != nil {
+		l.Errorf("failed to get the alerts: %s", err)
+		return
+	}
+
+	now := a.now().UTC().Truncate(time.Second)
+	for i := range alerts {
+		alert := &alerts[i]
+		l := l.F(log.Ctx{
+			"tenant_id": alert.TenantID,
+			"alert_id":  alert.ID,
+		})
+		claimed, err := a.ds.ClaimAlertEvaluation(ctx, alert, now)
+		if err != nil {
+			l.Errorf("failed to claim the alert evaluation: %s", err)
+			continue
+		} else if !claimed {
+			// another alerter is evaluating it
+			continue
+		}
+		if err := a.evaluate(ctx, alert, now); err != nil {
+			l.Errorf("failed to evaluate the alert: %s", err)
+		}
+	}
+}
+
+// evaluate counts the devices or deployments matching the alert and
+// notifies the webhook if the threshold was crossed since the last
+// evaluation; the state is saved only once the webhook was notified, so
+// that a failed notification is retried at the next evaluation
+func (a *Alerter) evaluate(ctx context.Context, alert *model.Alert, now time.Time) error {
+	count, err := a.count(ctx, alert)
+	if err != nil {
+		return err
+	}
+
+	state := model.AlertState{
+		Count:       count,
+		EvaluatedTs: &now,
+	}
+	if alert.State != nil {
+		state.Triggered = alert.State.Triggered
+		state.TriggeredTs = alert.State.TriggeredTs
+	}
+	if triggered := alert.Threshold.Exceeded(count); triggered != state.Triggered {
+		status := StatusResolved
+		if triggered {
+			status = StatusTriggered
+		}
+		err := a.notify(ctx, alert, Notification{
+			AlertID:   alert.ID,
+			AlertName: alert.Name,
+			TenantID:  alert.TenantID,
+			Status:    status,
+			Source:    alert.Source,
+			Count:     count,
+			Threshold: alert.Threshold,
+			Evaluated: now,
+		})
+		if err != nil {
+			return err
+		}
+		log.FromContext(ctx).Infof("alert %s: %s, count %d", alert.ID, status, count)
+		state.Triggered = triggered
+		if triggered {
+			state.TriggeredTs = &now
+		}
+	}
+	return a.ds.SetAlertState(ctx, alert, &state)
+}
+
+// count counts the devices, or the deployments, of the alert, on behalf of
+// the tenant of the alert
+func (a *Alerter) count(ctx context.Context, alert *model.Alert) (int, error) {
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: alert.TenantID})
+	if alert.Source == model.AlertSourceDeployments {
+		params := alert.DeploymentsSearchParams()
+		params.Page = 1
+		params.PerPage = 1
+		_, total, err := a.reporting.SearchDeployments(ctx, params)
+		return total, errors.Wrap(err, "failed to count the deployments")
+	}
+	count, err := a.reporting.CountDevices(ctx, alert.SearchParams())
+	return count, errors.Wrap(err, "failed to count the devices")
+}
+
+// notify POSTs the notification to the webhook of the alert; if the
+// webhook has a secret, the request carries the HMAC-SHA256 signature of
+// the body in the X-Reporting-Signature header
+func (a *Alerter) notify(
+	ctx context.Context,
+	alert *model.Alert,
+	notification Notification,
+) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the notification")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alert.Webhook.URL,
+		bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if alert.Webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(alert.Webhook.Secret))
+		_, _ = mac.Write(body)
+		req.Header.Set(hdrSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	rsp, err := a.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to notify the webhook")
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode/100 != 2 {
+		return errors.Errorf("failed to notify the webhook, status %d", rsp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package alerter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+var contextMatcher = mock.MatchedBy(func(_ context.Context) bool { return true })
+
+// tenantMatcher matches the contexts with the identity of the tenant
+var tenantMatcher = mock.MatchedBy(func(ctx context.Context) bool {
+	id := identity.FromContext(ctx)
+	return id != nil && id.Tenant == "tenant"
+})
+
+func TestEvaluateAll(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 3, 6, 6, 0, 30, 0, time.UTC)
+	evaluated := now.Truncate(time.Second)
+	previous := time.Date(2023, 3, 6, 5, 59, 30, 0, time.UTC)
+	newAlert := func(source string, state *model.AlertState) model.Alert {
+		return model.Alert{
+			ID:       "alert",
+			TenantID: "tenant",
+			Name:     "too many failures",
+			Source:   source,
+			Threshold: model.AlertThreshold{
+				Operator: model.AlertOperatorGreaterThan,
+				Value:    10,
+			},
+			Webhook: model.AlertWebhook{
+				Secret: "secret",
+			},
+			State: state,
+		}
+	}
+
+	type testCase struct {
+		Name string
+
+		Alert  model.Alert
+		Claim  bool
+		App    func(*testing.T, testCase) *mapp.App
+		Status int
+
+		Notification *Notification
+		State        *model.AlertState
+	}
+	testCases := []testCase{{
+		Name: "ok, devices count triggers the alert",
+
+		Alert: newAlert(model.AlertSourceDevices, nil),
+		Claim: true,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			// the alerter runs without identity: the devices are counted
+			// on behalf of the tenant of the alert
+			app.On("CountDevices", tenantMatcher,
+				mock.MatchedBy(func(params *model.SearchParams) bool {
+					return params.TenantID == "tenant"
+				})).
+				Return(11, nil)
+			return app
+		},
+		Status: http.StatusNoContent,
+
+		Notification: &Notification{
+			AlertID:   "alert",
+			AlertName: "too many failures",
+			TenantID:  "tenant",
+			Status:    StatusTriggered,
+			Source:    model.AlertSourceDevices,
+			Count:     11,
+			Threshold: model.AlertThreshold{
+				Operator: model.AlertOperatorGreaterThan,
+				Value:    10,
+			},
+			Evaluated: evaluated,
+		},
+		State: &model.AlertState{
+			Triggered:   true,
+			Count:       11,
+			EvaluatedTs: &evaluated,
+			TriggeredTs: &evaluated,
+		},
+	}, {
+		Name: "ok, deployments count resolves the alert",
+
+		Alert: newAlert(model.AlertSourceDeployments, &model.AlertState{
+			Triggered:   true,
+			Count:       12,
+			EvaluatedTs: &previous,
+			TriggeredTs: &previous,
+		}),
+		Claim: true,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("SearchDeployments", tenantMatcher,
+				mock.MatchedBy(func(params *model.DeploymentsSearchParams) bool {
+					return params.TenantID == "tenant" && params.PerPage == 1
+				})).
+				Return([]model.Deployment{}, 3, nil)
+			return app
+		},
+		Status: http.StatusOK,
+
+		Notification: &Notification{
+			AlertID:   "alert",
+			AlertName: "too many failures",
+			TenantID:  "tenant",
+			Status:    StatusResolved,
+			Source:    model.AlertSourceDeployments,
+			Count:     3,
+			Threshold: model.AlertThreshold{
+				Operator: model.AlertOperatorGreaterThan,
+				Value:    10,
+			},
+			Evaluated: evaluated,
+		},
+		State: &model.AlertState{
+			Count:       3,
+			EvaluatedTs: &evaluated,
+			TriggeredTs: &previous,
+		},
+	}, {
+		Name: "ok, no crossing",
+
+		Alert: newAlert(model.AlertSourceDevices, &model.AlertState{
+			Triggered:   true,
+			Count:       12,
+			EvaluatedTs: &previous,
+			TriggeredTs: &previous,
+		}),
+		Claim: true,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CountDevices", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(15, nil)
+			return app
+		},
+
+		State: &model.AlertState{
+			Triggered:   true,
+			Count:       15,
+			EvaluatedTs: &evaluated,
+			TriggeredTs: &previous,
+		},
+	}, {
+		Name: "ok, evaluation claimed by another alerter",
+
+		Alert: newAlert(model.AlertSourceDevices, nil),
+	}, {
+		Name: "error, webhook failed",
+
+		Alert: newAlert(model.AlertSourceDevices, nil),
+		Claim: true,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CountDevices", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(11, nil)
+			return app
+		},
+		Status: http.StatusInternalServerError,
+
+		Notification: &Notification{
+			AlertID:   "alert",
+			AlertName: "too many failures",
+			TenantID:  "tenant",
+			Status:    StatusTriggered,
+			Source:    model.AlertSourceDevices,
+			Count:     11,
+			Threshold: model.AlertThreshold{
+				Operator: model.AlertOperatorGreaterThan,
+				Value:    10,
+			},
+			Evaluated: evaluated,
+		},
+	}, {
+		Name: "error, count failed",
+
+		Alert: newAlert(model.AlertSourceDevices, nil),
+		Claim: true,
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("CountDevices", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams")).
+				Return(0, errors.New("internal error"))
+			return app
+		},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			var notified bool
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					notified = true
+					body, _ := io.ReadAll(r.Body)
+					mac := hmac.New(sha256.New, []byte("secret"))
+					_, _ = mac.Write(body)
+					assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)),
+						r.Header.Get(hdrSignature))
+					var notification Notification
+					if assert.NoError(t, json.Unmarshal(body, &notification)) {
+						assert.Equal(t, tc.Notification, &notification)
+					}
+					w.WriteHeader(tc.Status)
+				}))
+			defer srv.Close()
+
+			alert := tc.Alert
+			alert.Webhook.URL = srv.URL
+			ds := new(mstore.DataStore)
+			defer ds.AssertExpectations(t)
+			ds.On("GetAllAlerts", contextMatcher).
+				Return([]model.Alert{alert}, nil)
+			ds.On("ClaimAlertEvaluation", contextMatcher,
+				mock.AnythingOfType("*model.Alert"), evaluated).
+				Return(tc.Claim, nil)
+			if tc.State != nil {
+				ds.On("SetAlertState", contextMatcher,
+					mock.AnythingOfType("*model.Alert"), tc.State).
+					Return(nil)
+			}
+			app := new(mapp.App)
+			if tc.App != nil {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+
+			alerter := NewAlerter(app, ds)
+			alerter.now = func() time.Time { return now }
+			alerter.evaluateAll(context.Background())
+
+			assert.Equal(t, tc.Notification != nil, notified)
+		})
+	}
+}
+
+func TestEvaluateAllError(t *testing.T) {
+	t.Parallel()
+
+	ds := new(mstore.DataStore)
+	defer ds.AssertExpectations(t)
+	ds.On("GetAllAlerts", contextMatcher).
+		Return(nil, errors.New("internal error"))
+
+	NewAlerter(new(mapp.App), ds).evaluateAll(context.Background())
+}
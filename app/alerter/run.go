@@ -0,0 +1,76 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package alerter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	rconfig "github.com/mendersoftware/reporting/config"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// InitAndRun initializes the alerter and runs it
+func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := time.Duration(conf.GetInt(rconfig.SettingAlerterIntervalMsec)) *
+		time.Millisecond
+	if interval <= 0 {
+		return fmt.Errorf(
+			"%s: must be a positive integer",
+			rconfig.SettingAlerterIntervalMsec,
+		)
+	}
+
+	if window := conf.GetInt(rconfig.SettingConnectivityWindowMinutes); window > 0 {
+		model.SetConnectivityWindow(time.Duration(window) * time.Minute)
+	}
+
+	redaction, err := rconfig.IndexingAttributesRedaction(conf)
+	if err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, unix.SIGINT, unix.SIGTERM)
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.FromContext(ctx).Infof("alerter: evaluating the alerts every %s", interval)
+	app := reporting.NewApp(store, ds, reporting.WithAttributesRedaction(redaction))
+	alerter := NewAlerter(app, ds)
+	err = alerter.Run(ctx, interval)
+	if err == context.Canceled {
+		err = nil
+	}
+	return err
+}
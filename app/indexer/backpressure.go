@@ -0,0 +1,92 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// backpressureSmoothing is the weight of the latest observation in the
+// moving average of the latency
+const backpressureSmoothing = 0.2
+
+var (
+	metricBackpressureDelay = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "indexer",
+		Name:      "backpressure_delay_seconds",
+		Help:      "Delay applied to the consumption of the jobs by the backpressure.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(metricBackpressureDelay)
+}
+
+// backpressure slows down the consumption of the jobs when the latency of
+// their processing, mostly bound to the latency of OpenSearch, grows above
+// a threshold: while the jobs are not consumed, the queue fills up and the
+// indexer stops pulling messages from the JetStream consumer
+type backpressure struct {
+	threshold time.Duration
+	maxDelay  time.Duration
+
+	mu      sync.Mutex
+	latency time.Duration
+}
+
+// newBackpressure returns the backpressure for the given latency
+// threshold, zero disabling it, and maximum delay
+func newBackpressure(threshold, maxDelay time.Duration) *backpressure {
+	return &backpressure{
+		threshold: threshold,
+		maxDelay:  maxDelay,
+	}
+}
+
+// Observe records the latency of the processing of a batch of jobs in the
+// exponentially weighted moving average of the latency
+func (b *backpressure) Observe(latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.latency == 0 {
+		b.latency = latency
+	} else {
+		b.latency += time.Duration(
+			backpressureSmoothing * float64(latency-b.latency))
+	}
+}
+
+// Delay returns how long to wait before consuming the next job: the
+// excess of the average latency over the threshold, up to the maximum
+// delay
+func (b *backpressure) Delay() time.Duration {
+	if b.threshold <= 0 {
+		return 0
+	}
+	b.mu.Lock()
+	delay := b.latency - b.threshold
+	b.mu.Unlock()
+	if delay < 0 {
+		delay = 0
+	} else if delay > b.maxDelay {
+		delay = b.maxDelay
+	}
+	metricBackpressureDelay.Set(delay.Seconds())
+	return delay
+}
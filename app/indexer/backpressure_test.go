@@ -0,0 +1,79 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackpressure(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		threshold time.Duration
+		maxDelay  time.Duration
+		latencies []time.Duration
+		delay     time.Duration
+	}{
+		"disabled": {
+			maxDelay:  time.Second,
+			latencies: []time.Duration{time.Minute},
+		},
+		"no observations": {
+			threshold: 100 * time.Millisecond,
+			maxDelay:  time.Second,
+		},
+		"below the threshold": {
+			threshold: 100 * time.Millisecond,
+			maxDelay:  time.Second,
+			latencies: []time.Duration{50 * time.Millisecond},
+		},
+		"above the threshold": {
+			threshold: 100 * time.Millisecond,
+			maxDelay:  time.Second,
+			latencies: []time.Duration{300 * time.Millisecond},
+			delay:     200 * time.Millisecond,
+		},
+		"above the threshold, moving average": {
+			threshold: 100 * time.Millisecond,
+			maxDelay:  time.Second,
+			latencies: []time.Duration{
+				100 * time.Millisecond,
+				600 * time.Millisecond,
+			},
+			delay: 100 * time.Millisecond,
+		},
+		"above the maximum delay": {
+			threshold: 100 * time.Millisecond,
+			maxDelay:  time.Second,
+			latencies: []time.Duration{time.Minute},
+			delay:     time.Second,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			bp := newBackpressure(tc.threshold, tc.maxDelay)
+			for _, latency := range tc.latencies {
+				bp.Observe(latency)
+			}
+			assert.Equal(t, tc.delay, bp.Delay())
+		})
+	}
+}
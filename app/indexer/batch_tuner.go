@@ -0,0 +1,140 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// minBulkBatchSize is the smallest batch of documents the tuner shrinks
+// the bulk requests to
+const minBulkBatchSize = 10
+
+var (
+	metricReindexBatchSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "indexer",
+		Name:      "reindex_batch_size",
+		Help:      "Number of documents of the bulk requests sent while reindexing.",
+	})
+	metricReindexConcurrency = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "indexer",
+		Name:      "reindex_concurrency",
+		Help:      "Number of the bulk requests sent at the same time while reindexing.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(metricReindexBatchSize, metricReindexConcurrency)
+}
+
+// batchTuner tunes the size of the bulk requests sent while reindexing,
+// and how many of them are sent at the same time, to the latency of the
+// cluster: it starts with one request of the largest batch, sends more
+// requests at the same time while the latency is well below the target,
+// shrinks the batches while the latency is above the target, and halves
+// both when the cluster rejects the requests (additive increase,
+// multiplicative decrease)
+type batchTuner struct {
+	targetLatency  time.Duration
+	minBatchSize   int
+	maxBatchSize   int
+	maxConcurrency int
+
+	mu          sync.Mutex
+	batchSize   int
+	concurrency int
+}
+
+// newBatchTuner returns the tuner of batches of up to maxBatchSize
+// documents, sent up to maxConcurrency at the same time
+func newBatchTuner(
+	targetLatency time.Duration,
+	maxBatchSize, maxConcurrency int,
+) *batchTuner {
+	minBatchSize := minBulkBatchSize
+	if minBatchSize > maxBatchSize {
+		minBatchSize = maxBatchSize
+	}
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	t := &batchTuner{
+		targetLatency:  targetLatency,
+		minBatchSize:   minBatchSize,
+		maxBatchSize:   maxBatchSize,
+		maxConcurrency: maxConcurrency,
+		batchSize:      maxBatchSize,
+		concurrency:    1,
+	}
+	t.report()
+	return t
+}
+
+// Size returns the size of the batches to send next, and how many of them
+// to send at the same time
+func (t *batchTuner) Size() (batchSize, concurrency int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.batchSize, t.concurrency
+}
+
+// Observe tunes the batches to the slowest latency of the requests sent
+// at the same time, and whether any of them was rejected
+func (t *batchTuner) Observe(latency time.Duration, rejected bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case rejected:
+		t.batchSize /= 2
+		t.concurrency /= 2
+	case latency > t.targetLatency:
+		t.batchSize -= t.batchSize / 4
+	case latency < t.targetLatency/2:
+		if t.batchSize < t.maxBatchSize {
+			t.batchSize += t.maxBatchSize/4 + 1
+		} else {
+			t.concurrency++
+		}
+	}
+	if t.batchSize < t.minBatchSize {
+		t.batchSize = t.minBatchSize
+	} else if t.batchSize > t.maxBatchSize {
+		t.batchSize = t.maxBatchSize
+	}
+	if t.concurrency < 1 {
+		t.concurrency = 1
+	} else if t.concurrency > t.maxConcurrency {
+		t.concurrency = t.maxConcurrency
+	}
+	t.report()
+}
+
+// AtMinimum returns true if the batches can't shrink any further: the
+// requests rejected then are not retried
+func (t *batchTuner) AtMinimum() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.batchSize == t.minBatchSize && t.concurrency == 1
+}
+
+func (t *batchTuner) report() {
+	metricReindexBatchSize.Set(float64(t.batchSize))
+	metricReindexConcurrency.Set(float64(t.concurrency))
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchTuner(t *testing.T) {
+	t.Parallel()
+
+	type observation struct {
+		latency  time.Duration
+		rejected bool
+	}
+	testCases := map[string]struct {
+		maxBatchSize   int
+		maxConcurrency int
+		observations   []observation
+
+		batchSize   int
+		concurrency int
+		atMinimum   bool
+	}{
+		"no observations": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			batchSize:      100,
+			concurrency:    1,
+		},
+		"fast, more requests": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			observations: []observation{
+				{latency: 10 * time.Millisecond},
+				{latency: 10 * time.Millisecond},
+			},
+			batchSize:   100,
+			concurrency: 3,
+		},
+		"fast, up to the maximum concurrency": {
+			maxBatchSize:   100,
+			maxConcurrency: 2,
+			observations: []observation{
+				{latency: 10 * time.Millisecond},
+				{latency: 10 * time.Millisecond},
+			},
+			batchSize:   100,
+			concurrency: 2,
+		},
+		"on target": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			observations: []observation{
+				{latency: 80 * time.Millisecond},
+			},
+			batchSize:   100,
+			concurrency: 1,
+		},
+		"slow, smaller batches": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			observations: []observation{
+				{latency: 200 * time.Millisecond},
+			},
+			batchSize:   75,
+			concurrency: 1,
+		},
+		"slow then fast, larger batches": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			observations: []observation{
+				{latency: 200 * time.Millisecond},
+				{latency: 200 * time.Millisecond},
+				{latency: 10 * time.Millisecond},
+			},
+			batchSize:   83,
+			concurrency: 1,
+		},
+		"rejected": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			observations: []observation{
+				{latency: 10 * time.Millisecond},
+				{latency: 10 * time.Millisecond},
+				{latency: 10 * time.Millisecond},
+				{rejected: true},
+			},
+			batchSize:   50,
+			concurrency: 2,
+		},
+		"rejected, down to the minimum": {
+			maxBatchSize:   100,
+			maxConcurrency: 4,
+			observations: []observation{
+				{rejected: true},
+				{rejected: true},
+				{rejected: true},
+				{rejected: true},
+			},
+			batchSize:   minBulkBatchSize,
+			concurrency: 1,
+			atMinimum:   true,
+		},
+		"batches smaller than the minimum": {
+			maxBatchSize:   5,
+			maxConcurrency: 4,
+			observations: []observation{
+				{rejected: true},
+			},
+			batchSize:   5,
+			concurrency: 1,
+			atMinimum:   true,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tuner := newBatchTuner(100*time.Millisecond,
+				tc.maxBatchSize, tc.maxConcurrency)
+			for _, o := range tc.observations {
+				tuner.Observe(o.latency, o.rejected)
+			}
+			batchSize, concurrency := tuner.Size()
+			assert.Equal(t, tc.batchSize, batchSize)
+			assert.Equal(t, tc.concurrency, concurrency)
+			assert.Equal(t, tc.atMinimum, tuner.AtMinimum())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+var (
+	metricDeduplicatedJobs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "reporting",
+		Subsystem: "indexer",
+		Name:      "deduplicated_jobs_total",
+		Help:      "Number of reindex jobs merged into an identical pending job.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(metricDeduplicatedJobs)
+}
+
+// mergedAcknowledger acknowledges the jobs merged into a single job
+type mergedAcknowledger []model.JobAcknowledger
+
+func (acks mergedAcknowledger) Ack() error {
+	var err error
+	for _, ack := range acks {
+		if ackErr := ack.Ack(); ackErr != nil && err == nil {
+			err = ackErr
+		}
+	}
+	return err
+}
+
+func (acks mergedAcknowledger) Nak(reason error) error {
+	var err error
+	for _, ack := range acks {
+		if nakErr := ack.Nak(reason); nakErr != nil && err == nil {
+			err = nakErr
+		}
+	}
+	return err
+}
+
+// dedupKey identifies the identical reindex jobs
+type dedupKey struct {
+	tenantID string
+	deviceID string
+	service  string
+}
+
+type pendingJob struct {
+	job      model.Job
+	acks     mergedAcknowledger
+	deadline time.Time
+}
+
+// merge merges the identical job into the pending one: both are
+// acknowledged once the pending one is processed
+func (p *pendingJob) merge(job model.Job) {
+	if job.Acknowledger != nil {
+		p.acks = append(p.acks, job.Acknowledger)
+	}
+}
+
+// release returns the pending job, acknowledging all the jobs merged into it
+func (p *pendingJob) release() model.Job {
+	job := p.job
+	if len(p.acks) > 1 {
+		job.Acknowledger = p.acks
+	}
+	return job
+}
+
+// dedupJobs forwards the jobs received from in to out, holding the reindex
+// jobs for the window: the identical reindex jobs of the same device and
+// service received meanwhile, e.g. in a burst of events, are merged into
+// the pending one, for the device to be built and indexed once. The other
+// jobs are forwarded right away. Up to capacity jobs are held; out is
+// closed once in is closed and the held jobs are forwarded, while the held
+// jobs are requeued if the context is done first.
+func dedupJobs(
+	ctx context.Context,
+	in <-chan model.Job,
+	out chan<- model.Job,
+	window time.Duration,
+	capacity int,
+) {
+	pending := map[dedupKey]*pendingJob{}
+	// the pending jobs in the order they were received, which is the
+	// order of their deadlines, and the jobs ready to be forwarded
+	var waiting []dedupKey
+	var ready []model.Job
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+	done := ctx.Done()
+	for in != nil || len(waiting) > 0 || len(ready) > 0 {
+		recv := in
+		if len(waiting)+len(ready) >= capacity {
+			recv = nil
+		}
+		var send chan<- model.Job
+		var next model.Job
+		if len(ready) > 0 {
+			send = out
+			next = ready[0]
+		}
+		var expired <-chan time.Time
+		if len(waiting) > 0 {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(time.Until(pending[waiting[0]].deadline))
+			expired = timer.C
+		}
+		select {
+		case job, open := <-recv:
+			if !open {
+				in = nil
+				// the jobs are no longer held
+				for _, key := range waiting {
+					ready = append(ready, pending[key].release())
+					delete(pending, key)
+				}
+				waiting = nil
+				continue
+			}
+			if job.Action != model.ActionReindex {
+				ready = append(ready, job)
+				continue
+			}
+			key := dedupKey{
+				tenantID: job.TenantID,
+				deviceID: job.DeviceID,
+				service:  job.Service,
+			}
+			if p, ok := pending[key]; ok {
+				p.merge(job)
+				metricDeduplicatedJobs.Inc()
+				continue
+			}
+			p := &pendingJob{
+				job:      job,
+				deadline: time.Now().Add(window),
+			}
+			p.merge(job)
+			pending[key] = p
+			waiting = append(waiting, key)
+
+		case send <- next:
+			ready[0] = model.Job{}
+			ready = ready[1:]
+
+		case <-expired:
+			now := time.Now()
+			for len(waiting) > 0 && !pending[waiting[0]].deadline.After(now) {
+				ready = append(ready, pending[waiting[0]].release())
+				delete(pending, waiting[0])
+				waiting = waiting[1:]
+			}
+
+		case <-done:
+			// requeue the held jobs
+			for _, key := range waiting {
+				ready = append(ready, pending[key].release())
+			}
+			nakJobs(ctx, ready...)
+			return
+		}
+	}
+	close(out)
+}
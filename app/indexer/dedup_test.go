@@ -0,0 +1,116 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+type countingAcknowledger struct {
+	acks, naks int
+	err        error
+}
+
+func (a *countingAcknowledger) Ack() error {
+	a.acks++
+	return a.err
+}
+
+func (a *countingAcknowledger) Nak(reason error) error {
+	a.naks++
+	return a.err
+}
+
+func TestDedupJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan model.Job, 10)
+	out := make(chan model.Job)
+	go dedupJobs(ctx, in, out, 100*time.Millisecond, 10)
+
+	acks := make([]*countingAcknowledger, 3)
+	for i := range acks {
+		acks[i] = &countingAcknowledger{}
+		in <- model.Job{
+			Action:       model.ActionReindex,
+			TenantID:     "tenant",
+			DeviceID:     "1",
+			Service:      model.ServiceInventory,
+			Acknowledger: acks[i],
+		}
+	}
+	in <- model.Job{
+		Action:   model.ActionReindex,
+		TenantID: "tenant",
+		DeviceID: "1",
+		Service:  model.ServiceDeviceauth,
+	}
+	in <- model.Job{
+		Action:   model.ActionUpdateDeviceStatus,
+		TenantID: "tenant",
+		DeviceID: "2",
+		Status:   "accepted",
+	}
+
+	start := time.Now()
+	// the jobs other than the reindex ones are not held
+	job := <-out
+	assert.Equal(t, model.ActionUpdateDeviceStatus, job.Action)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	job = <-out
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, model.ServiceInventory, job.Service)
+	assert.NoError(t, job.Ack())
+	for _, ack := range acks {
+		assert.Equal(t, 1, ack.acks)
+	}
+	job = <-out
+	assert.Equal(t, model.ServiceDeviceauth, job.Service)
+
+	// the held jobs are forwarded once in is closed
+	in <- model.Job{
+		Action:   model.ActionReindex,
+		TenantID: "tenant",
+		DeviceID: "3",
+	}
+	close(in)
+	job = <-out
+	assert.Equal(t, "3", job.DeviceID)
+	_, open := <-out
+	assert.False(t, open)
+}
+
+func TestMergedAcknowledger(t *testing.T) {
+	t.Parallel()
+
+	failing := &countingAcknowledger{err: errors.New("connection closed")}
+	ok := &countingAcknowledger{}
+	acks := mergedAcknowledger{failing, ok}
+
+	assert.EqualError(t, acks.Ack(), "connection closed")
+	assert.EqualError(t, acks.Nak(errors.New("failed")), "connection closed")
+	assert.Equal(t, 1, ok.acks)
+	assert.Equal(t, 1, ok.naks)
+}
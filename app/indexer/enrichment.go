@@ -0,0 +1,414 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"path"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	"github.com/mendersoftware/reporting/client/enrichment"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/mapping"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	// EnrichmentStageInventory adds the inventory attributes of the device
+	EnrichmentStageInventory = "inventory"
+	// EnrichmentStageDeviceAuth adds the status and the identity data of
+	// the device from deviceauth
+	EnrichmentStageDeviceAuth = "deviceauth"
+	// EnrichmentStageDeployments adds the status of the latest finished
+	// deployment of the device
+	EnrichmentStageDeployments = "deployments"
+	// EnrichmentStageLocation adds the location of the device, from its
+	// latitude and longitude inventory attributes
+	EnrichmentStageLocation = "location"
+	// EnrichmentStageWebhook adds the attributes returned by the external
+	// enrichment webhook, if configured
+	EnrichmentStageWebhook = "webhook"
+)
+
+// EnrichmentStages are the names of the built-in enrichment stages, in the
+// order they run by default; the webhook stage runs only if configured
+var EnrichmentStages = []string{
+	EnrichmentStageInventory,
+	EnrichmentStageDeviceAuth,
+	EnrichmentStageDeployments,
+	EnrichmentStageLocation,
+	EnrichmentStageWebhook,
+}
+
+func isEnrichmentStage(name string) bool {
+	for _, stage := range EnrichmentStages {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceSources is the data the document of a device is built from
+type DeviceSources struct {
+	TenantID   string
+	Rules      *model.IndexingRules
+	DeviceAuth *deviceauth.DeviceAuthDevice
+	Inventory  *inventory.Device
+}
+
+// Enricher is a stage of the enrichment pipeline building the documents
+// of the devices: it adds the data of a source to the document
+type Enricher interface {
+	// Name is the name of the stage, as configured
+	Name() string
+	// Enrich adds the data of the source to the document of the device;
+	// the device is not indexed if it returns an error
+	Enrich(ctx context.Context, device *model.Device, src *DeviceSources) error
+}
+
+// WithEnrichers adds custom stages to the enrichment pipeline, to index
+// the data of other sources; they run after the built-in stages, unless
+// the stages are set with WithEnrichmentStages
+func WithEnrichers(enrichers ...Enricher) Option {
+	return func(i *indexer) {
+		i.customEnrichers = append(i.customEnrichers, enrichers...)
+	}
+}
+
+// WithEnrichmentStages sets the names of the stages of the enrichment
+// pipeline, built-in or custom, in the order they run; the stages not
+// listed don't run, and the unknown names are ignored; no names keep the
+// default stages
+func WithEnrichmentStages(names ...string) Option {
+	return func(i *indexer) {
+		if len(names) > 0 {
+			i.enrichmentStages = names
+		}
+	}
+}
+
+// enrichmentPipeline returns the stages of the enrichment pipeline
+func (i *indexer) enrichmentPipeline() []Enricher {
+	builtin := []Enricher{
+		inventoryEnricher{i},
+		deviceAuthEnricher{i},
+		deploymentsEnricher{i},
+		locationEnricher{i},
+	}
+	if i.enrichmentClient != nil {
+		builtin = append(builtin, webhookEnricher{i})
+	}
+	if i.enrichmentStages == nil {
+		return append(builtin, i.customEnrichers...)
+	}
+	byName := make(map[string]Enricher, len(builtin)+len(i.customEnrichers))
+	for _, enricher := range append(builtin, i.customEnrichers...) {
+		byName[enricher.Name()] = enricher
+	}
+	pipeline := make([]Enricher, 0, len(i.enrichmentStages))
+	for _, name := range i.enrichmentStages {
+		if enricher, ok := byName[name]; ok {
+			pipeline = append(pipeline, enricher)
+		}
+	}
+	return pipeline
+}
+
+// enrich runs the enrichment pipeline on the document of the device
+func (i *indexer) enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	for _, enricher := range i.enrichers {
+		if err := enricher.Enrich(ctx, device, src); err != nil {
+			return errors.Wrapf(err, "enrichment stage %s failed", enricher.Name())
+		}
+	}
+	return nil
+}
+
+type inventoryEnricher struct {
+	*indexer
+}
+
+func (inventoryEnricher) Name() string {
+	return EnrichmentStageInventory
+}
+
+// Enrich maps and adds the inventory attributes selected by the indexing
+// rules
+func (e inventoryEnricher) Enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	e.appendAttributes(ctx, device, src, src.Inventory.Attributes)
+	return nil
+}
+
+// appendAttributes redacts, maps and adds the attributes selected by the
+// indexing rules to the document of the device, converted to the types they
+// are declared to be indexed as; the attributes failing to map are logged
+// and skipped
+func (i *indexer) appendAttributes(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+	attrs inventory.DeviceAttributes,
+) {
+	l := log.FromContext(ctx)
+	indexed := make(inventory.DeviceAttributes, 0, len(attrs))
+	for _, attr := range attrs {
+		if i.indexesAttribute(src.Rules, attr.Scope, attr.Name) {
+			attr.Value = i.redaction.Redact(attr.Scope, attr.Name, attr.Value)
+			indexed = append(indexed, attr)
+		}
+	}
+	attributes, err := i.mapper.MapInventoryAttributes(ctx, src.TenantID,
+		indexed, true, false)
+	if err != nil {
+		l.Warn(errors.Wrapf(err,
+			"failed to map device data for tenant %s, "+
+				"device %s", src.TenantID, device.GetID()))
+		return
+	}
+	types, err := i.mapper.AttributeTypes(ctx, src.TenantID)
+	if err != nil {
+		l.Warn(errors.Wrapf(err,
+			"failed to get the attribute types for tenant %s, "+
+				"device %s", src.TenantID, device.GetID()))
+		return
+	}
+	for _, invattr := range attributes {
+		attr := model.NewInventoryAttribute(invattr.Scope).
+			SetName(invattr.Name).
+			SetVal(invattr.Value)
+		if typ, ok := types[path.Join(invattr.Scope, invattr.Name)]; ok {
+			if !attr.ConvertTo(typ).HasValues() {
+				continue
+			}
+		}
+		if err := device.AppendAttr(attr); err != nil {
+			l.Warn(errors.Wrapf(err,
+				"failed to convert device data for tenant %s, "+
+					"device %s", src.TenantID, device.GetID()))
+		}
+	}
+}
+
+type deviceAuthEnricher struct {
+	*indexer
+}
+
+func (deviceAuthEnricher) Name() string {
+	return EnrichmentStageDeviceAuth
+}
+
+// Enrich adds the status of the device and its identity data selected by
+// the indexing rules
+func (e deviceAuthEnricher) Enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	_ = device.AppendAttr(&model.InventoryAttribute{
+		Scope:  model.ScopeIdentity,
+		Name:   model.AttrNameStatus,
+		String: []string{src.DeviceAuth.Status},
+	})
+	for name, value := range src.DeviceAuth.IdDataStruct {
+		if !e.indexesAttribute(src.Rules, model.ScopeIdentity, name) {
+			continue
+		}
+		attr := model.NewInventoryAttribute(model.ScopeIdentity).
+			SetName(name).
+			SetVal(e.redaction.Redact(model.ScopeIdentity, name, value))
+		if err := device.AppendAttr(attr); err != nil {
+			log.FromContext(ctx).Warn(errors.Wrapf(err,
+				"failed to convert identity data for tenant %s, "+
+					"device %s", src.TenantID, src.Inventory.ID))
+		}
+	}
+	return nil
+}
+
+type deploymentsEnricher struct {
+	*indexer
+}
+
+func (deploymentsEnricher) Name() string {
+	return EnrichmentStageDeployments
+}
+
+// Enrich adds the status of the latest finished deployment of the device
+func (e deploymentsEnricher) Enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	deviceDeployment, err := e.deplClient.GetLatestFinishedDeployment(ctx,
+		src.TenantID, device.GetID())
+	if err != nil {
+		return errors.Wrap(err, "failed to get device deployments from deployments")
+	} else if deviceDeployment != nil {
+		_ = device.AppendAttr(&model.InventoryAttribute{
+			Scope:  model.ScopeSystem,
+			Name:   model.AttrNameLatestDeploymentStatus,
+			String: []string{deviceDeployment.Device.Status},
+		})
+	}
+	return nil
+}
+
+type locationEnricher struct {
+	*indexer
+}
+
+func (locationEnricher) Name() string {
+	return EnrichmentStageLocation
+}
+
+// Enrich sets the location of the device, if it has valid coordinates
+func (e locationEnricher) Enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	device.Location = e.deviceLocation(src.Inventory)
+	return nil
+}
+
+// WithEnrichmentWebhook enables the webhook enrichment stage, which sends
+// the draft documents of the devices to an external webhook, e.g. of a
+// CMDB, and adds the attributes it returns
+func WithEnrichmentWebhook(client enrichment.Client) Option {
+	return func(i *indexer) {
+		i.enrichmentClient = client
+	}
+}
+
+type webhookEnricher struct {
+	*indexer
+}
+
+func (webhookEnricher) Name() string {
+	return EnrichmentStageWebhook
+}
+
+// Enrich sends the draft document of the device, with the attributes
+// added by the previous stages, to the webhook, and adds the attributes it
+// returns; only the inventory and tags attributes are added, selected by
+// the indexing rules and redacted like the others
+func (e webhookEnricher) Enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	// only the mapped attributes are reversed, the others are kept as is
+	var mapped, draft inventory.DeviceAttributes
+	for _, attr := range draftAttributes(device) {
+		if mapping.IsFieldName(attr.Name) {
+			mapped = append(mapped, attr)
+		} else {
+			draft = append(draft, attr)
+		}
+	}
+	if len(mapped) > 0 {
+		reversed, err := e.mapper.ReverseInventoryAttributes(ctx, src.TenantID, mapped)
+		if err != nil {
+			return errors.Wrap(err, "failed to map the draft document")
+		}
+		draft = append(draft, reversed...)
+	}
+	req := &enrichment.Device{
+		ID:         device.GetID(),
+		TenantID:   src.TenantID,
+		Attributes: make([]enrichment.Attribute, 0, len(draft)),
+	}
+	for _, attr := range draft {
+		req.Attributes = append(req.Attributes, enrichment.Attribute{
+			Scope: attr.Scope,
+			Name:  attr.Name,
+			Value: attr.Value,
+		})
+	}
+	res, err := e.enrichmentClient.Enrich(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call the enrichment webhook")
+	}
+	attrs := make(inventory.DeviceAttributes, 0, len(res))
+	for _, attr := range res {
+		if attr.Scope != model.ScopeInventory && attr.Scope != model.ScopeTags {
+			log.FromContext(ctx).Warnf("ignoring the attribute %s/%s "+
+				"returned by the enrichment webhook: unsupported scope",
+				attr.Scope, attr.Name)
+			continue
+		}
+		attrs = append(attrs, inventory.DeviceAttribute{
+			Scope: attr.Scope,
+			Name:  attr.Name,
+			Value: attr.Value,
+		})
+	}
+	e.appendAttributes(ctx, device, src, attrs)
+	return nil
+}
+
+// draftAttributes returns the attributes of the document of the device,
+// with the names of the mapped attributes still to reverse; the single
+// values are unwrapped
+func draftAttributes(device *model.Device) inventory.DeviceAttributes {
+	var attrs inventory.DeviceAttributes
+	for _, scoped := range []model.InventoryAttributes{
+		device.IdentityAttributes,
+		device.InventoryAttributes,
+		device.MonitorAttributes,
+		device.SystemAttributes,
+		device.TagsAttributes,
+	} {
+		for _, attr := range scoped {
+			_, value := attr.Map()
+			attrs = append(attrs, inventory.DeviceAttribute{
+				Scope: attr.Scope,
+				Name:  attr.Name,
+				Value: singleValue(value),
+			})
+		}
+	}
+	return attrs
+}
+
+func singleValue(value interface{}) interface{} {
+	switch values := value.(type) {
+	case []string:
+		if len(values) == 1 {
+			return values[0]
+		}
+	case []float64:
+		if len(values) == 1 {
+			return values[0]
+		}
+	case []bool:
+		if len(values) == 1 {
+			return values[0]
+		}
+	}
+	return value
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	"github.com/mendersoftware/reporting/client/enrichment"
+	enrichment_mocks "github.com/mendersoftware/reporting/client/enrichment/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	store_mocks "github.com/mendersoftware/reporting/store/mocks"
+)
+
+type funcEnricher struct {
+	name   string
+	enrich func(device *model.Device, src *DeviceSources) error
+}
+
+func (e funcEnricher) Name() string {
+	return e.name
+}
+
+func (e funcEnricher) Enrich(
+	ctx context.Context,
+	device *model.Device,
+	src *DeviceSources,
+) error {
+	return e.enrich(device, src)
+}
+
+func TestEnrichmentPipeline(t *testing.T) {
+	t.Parallel()
+
+	custom := funcEnricher{name: "custom"}
+	builtin := []string{
+		EnrichmentStageInventory,
+		EnrichmentStageDeviceAuth,
+		EnrichmentStageDeployments,
+		EnrichmentStageLocation,
+	}
+	testCases := map[string]struct {
+		opts   []Option
+		stages []string
+	}{
+		"default": {
+			stages: builtin,
+		},
+		"default, with webhook": {
+			opts:   []Option{WithEnrichmentWebhook(&enrichment_mocks.Client{})},
+			stages: EnrichmentStages,
+		},
+		"default, with custom stages": {
+			opts: []Option{WithEnrichers(custom)},
+			stages: []string{
+				EnrichmentStageInventory,
+				EnrichmentStageDeviceAuth,
+				EnrichmentStageDeployments,
+				EnrichmentStageLocation,
+				"custom",
+			},
+		},
+		"configured stages": {
+			opts: []Option{
+				WithEnrichers(custom),
+				WithEnrichmentStages("custom", EnrichmentStageDeviceAuth, "unknown"),
+			},
+			stages: []string{"custom", EnrichmentStageDeviceAuth},
+		},
+		"no stages configured": {
+			opts:   []Option{WithEnrichmentStages()},
+			stages: builtin,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			i := NewIndexer(nil, nil, nil, nil, nil, nil, tc.opts...).(*indexer)
+			stages := make([]string, 0, len(i.enrichers))
+			for _, enricher := range i.enrichers {
+				stages = append(stages, enricher.Name())
+			}
+			assert.Equal(t, tc.stages, stages)
+		})
+	}
+}
+
+func TestProcessJobDeviceEnrichers(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	deviceAuthDevice := &deviceauth.DeviceAuthDevice{
+		ID:     "1",
+		Status: "accepted",
+	}
+	inventoryDevice := &inventory.Device{
+		ID: "1",
+		Attributes: inventory.DeviceAttributes{
+			{Scope: model.ScopeInventory, Name: "owner", Value: "ops"},
+		},
+	}
+
+	owner := funcEnricher{
+		name: "owner",
+		enrich: func(device *model.Device, src *DeviceSources) error {
+			assert.Equal(t, tenantID, src.TenantID)
+			assert.Equal(t, inventoryDevice, src.Inventory)
+			return device.AppendAttr(&model.InventoryAttribute{
+				Scope:  model.ScopeTags,
+				Name:   "owner",
+				String: []string{"ops"},
+			})
+		},
+	}
+	i := NewIndexer(nil, nil, nil, nil, nil, nil,
+		WithEnrichers(owner),
+		WithEnrichmentStages(EnrichmentStageDeviceAuth, "owner"),
+	).(*indexer)
+	device := i.processJobDevice(context.Background(), tenantID, nil,
+		deviceAuthDevice, inventoryDevice)
+	if assert.NotNil(t, device) {
+		assert.Empty(t, device.InventoryAttributes)
+		assert.Equal(t, model.InventoryAttributes{
+			{Scope: model.ScopeIdentity, Name: model.AttrNameStatus, String: []string{"accepted"}},
+		}, device.IdentityAttributes)
+		assert.Equal(t, model.InventoryAttributes{
+			{Scope: model.ScopeTags, Name: "owner", String: []string{"ops"}},
+		}, device.TagsAttributes)
+	}
+
+	failing := funcEnricher{
+		name: "owner",
+		enrich: func(device *model.Device, src *DeviceSources) error {
+			return errors.New("owner not found")
+		},
+	}
+	i = NewIndexer(nil, nil, nil, nil, nil, nil,
+		WithEnrichers(failing),
+		WithEnrichmentStages(EnrichmentStageDeviceAuth, "owner"),
+	).(*indexer)
+	device = i.processJobDevice(context.Background(), tenantID, nil,
+		deviceAuthDevice, inventoryDevice)
+	assert.Nil(t, device)
+}
+
+func TestWebhookEnricher(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	ds := &store_mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("UpdateAndGetMapping", contextMatcher, tenantID,
+		[]string{"inventory/artifact_name"}).
+		Return(&model.Mapping{
+			TenantID:  tenantID,
+			Inventory: []string{"inventory/artifact_name"},
+		}, nil).
+		Once()
+	ds.On("UpdateAndGetMapping", contextMatcher, tenantID,
+		[]string{"tags/owner"}).
+		Return(&model.Mapping{
+			TenantID:  tenantID,
+			Inventory: []string{"inventory/artifact_name", "tags/owner"},
+		}, nil).
+		Once()
+
+	client := &enrichment_mocks.Client{}
+	defer client.AssertExpectations(t)
+	client.On("Enrich", contextMatcher, &enrichment.Device{
+		ID:       "1",
+		TenantID: tenantID,
+		Attributes: []enrichment.Attribute{
+			{Scope: model.ScopeIdentity, Name: model.AttrNameStatus, Value: "accepted"},
+			{Scope: model.ScopeInventory, Name: "artifact_name", Value: "v1"},
+		},
+	}).Return([]enrichment.Attribute{
+		{Scope: model.ScopeTags, Name: "owner", Value: "ops"},
+		{Scope: model.ScopeSystem, Name: "group", Value: "production"},
+	}, nil).Once()
+
+	i := NewIndexer(nil, ds, nil, nil, nil, nil,
+		WithEnrichmentWebhook(client),
+		WithEnrichmentStages(
+			EnrichmentStageInventory,
+			EnrichmentStageDeviceAuth,
+			EnrichmentStageWebhook,
+		),
+	).(*indexer)
+	device := i.processJobDevice(context.Background(), tenantID, nil,
+		&deviceauth.DeviceAuthDevice{
+			ID:     "1",
+			Status: "accepted",
+		},
+		&inventory.Device{
+			ID: "1",
+			Attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "artifact_name", Value: "v1"},
+			},
+		})
+	if assert.NotNil(t, device) {
+		assert.Equal(t, model.InventoryAttributes{
+			{Scope: model.ScopeInventory, Name: "attribute1", String: []string{"v1"}},
+		}, device.InventoryAttributes)
+		assert.Equal(t, model.InventoryAttributes{
+			{Scope: model.ScopeTags, Name: "attribute2", String: []string{"ops"}},
+		}, device.TagsAttributes)
+		assert.Empty(t, device.SystemAttributes)
+	}
+
+	// the device is not indexed if the webhook fails
+	client.On("Enrich", contextMatcher, mock.AnythingOfType("*enrichment.Device")).
+		Return(nil, errors.New("connection refused")).
+		Once()
+	device = i.processJobDevice(context.Background(), tenantID, nil,
+		&deviceauth.DeviceAuthDevice{
+			ID:     "2",
+			Status: "accepted",
+		},
+		&inventory.Device{ID: "2"})
+	assert.Nil(t, device)
+}
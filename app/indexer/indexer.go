@@ -16,9 +16,11 @@ package indexer
 
 import (
 	"context"
+	"time"
 
 	"github.com/mendersoftware/reporting/client/deployments"
 	"github.com/mendersoftware/reporting/client/deviceauth"
+	"github.com/mendersoftware/reporting/client/enrichment"
 	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/client/nats"
 	"github.com/mendersoftware/reporting/mapping"
@@ -28,17 +30,107 @@ import (
 
 //go:generate ../../x/mockgen.sh
 type Indexer interface {
-	GetJobs(ctx context.Context, jobs chan model.Job) error
+	GetJobs(ctx context.Context, jobs, priorityJobs chan model.Job) error
 	ProcessJobs(ctx context.Context, jobs []model.Job)
+	ReindexTenant(ctx context.Context, tenantID string, batchSize int, restart bool) error
+	CatchUpTenant(ctx context.Context, tenantID string, since time.Time, batchSize int) error
+	SweepOrphanDevices(ctx context.Context, tenantID string, batchSize int) (int, error)
+	ReconcileDevices(ctx context.Context, tenantID string, sampleSize int) (int, error)
 }
 
 type indexer struct {
 	store      store.Store
+	ds         store.DataStore
 	mapper     mapping.Mapper
 	nats       nats.Client
 	devClient  deviceauth.Client
 	invClient  inventory.Client
 	deplClient deployments.Client
+
+	latitudeAttribute  string
+	longitudeAttribute string
+	reindexTimeout     time.Duration
+	attributesFilter   *model.AttributesFilter
+	redaction          *model.AttributesRedaction
+	softwareInventory  bool
+
+	decommissionGracePeriod time.Duration
+
+	reindexTargetLatency  time.Duration
+	reindexMaxConcurrency int
+
+	enrichmentClient enrichment.Client
+	enrichmentStages []string
+	customEnrichers  []Enricher
+	enrichers        []Enricher
+}
+
+// Option configures the indexer
+type Option func(*indexer)
+
+// WithLocationAttributes sets the inventory attributes the latitude and
+// the longitude of the devices' location are derived from; empty names
+// disable the indexing of the location
+func WithLocationAttributes(latitude, longitude string) Option {
+	return func(i *indexer) {
+		i.latitudeAttribute = latitude
+		i.longitudeAttribute = longitude
+	}
+}
+
+// WithReindexTimeout sets the timeout of the requests to the deployments
+// service made while reindexing the tenants, which fetch the deployments
+// of whole pages of devices at once; zero keeps the client's timeout
+func WithReindexTimeout(timeout time.Duration) Option {
+	return func(i *indexer) {
+		i.reindexTimeout = timeout
+	}
+}
+
+// WithAttributesFilter sets the attributes of the devices of all the
+// tenants to index, on top of the tenants' own indexing rules
+func WithAttributesFilter(filter *model.AttributesFilter) Option {
+	return func(i *indexer) {
+		i.attributesFilter = filter
+	}
+}
+
+// WithAttributesRedaction sets the attributes of the devices whose values
+// are hashed or masked before indexing
+func WithAttributesRedaction(redaction *model.AttributesRedaction) Option {
+	return func(i *indexer) {
+		i.redaction = redaction
+	}
+}
+
+// WithSoftwareInventory enables the indexing of the devices' software
+// inventory, parsed from their inventory attributes
+func WithSoftwareInventory(enabled bool) Option {
+	return func(i *indexer) {
+		i.softwareInventory = enabled
+	}
+}
+
+// WithDecommissionGracePeriod sets how long the deployments history of the
+// decommissioned devices is kept before being purged; zero deletes it
+// right away, with the devices
+func WithDecommissionGracePeriod(grace time.Duration) Option {
+	return func(i *indexer) {
+		i.decommissionGracePeriod = grace
+	}
+}
+
+// WithAdaptiveReindex tunes the bulk requests sent while reindexing the
+// tenants to the latency of the cluster: their batch size, up to the
+// reindex batch size, and how many of them are sent at the same time, up
+// to maxConcurrency, are adjusted to keep their latency below the target,
+// and are reduced when the cluster rejects them; zero disables the tuning,
+// sending each page of devices in a single request
+func WithAdaptiveReindex(targetLatency time.Duration, maxConcurrency int) Option {
+	return func(i *indexer) {
+		i.reindexTargetLatency = targetLatency
+		i.reindexMaxConcurrency = maxConcurrency
+	}
 }
 
 func NewIndexer(
@@ -48,14 +140,21 @@ func NewIndexer(
 	devClient deviceauth.Client,
 	invClient inventory.Client,
 	deplClient deployments.Client,
+	opts ...Option,
 ) Indexer {
 	mapper := mapping.NewMapper(ds)
-	return &indexer{
+	i := &indexer{
 		store:      store,
+		ds:         ds,
 		mapper:     mapper,
 		nats:       nats,
 		devClient:  devClient,
 		invClient:  invClient,
 		deplClient: deplClient,
 	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	i.enrichers = i.enrichmentPipeline()
+	return i
 }
@@ -16,8 +16,14 @@ package indexer
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/mendersoftware/go-lib-micro/config"
 	"github.com/mendersoftware/go-lib-micro/log"
@@ -27,13 +33,17 @@ import (
 	"github.com/mendersoftware/reporting/client/inventory"
 	rconfig "github.com/mendersoftware/reporting/config"
 	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/tracing"
 )
 
 type IDs map[string]bool
 type ActionIDs map[string]IDs
 type TenantActionIDs map[string]ActionIDs
 
-func (i *indexer) GetJobs(ctx context.Context, jobs chan model.Job) error {
+// GetJobs subscribes to the jobs: the jobs of the subscriber topic are
+// sent to jobs, while the jobs of the priority topic, if any, and the
+// deviceauth status events are sent to priorityJobs, processed first
+func (i *indexer) GetJobs(ctx context.Context, jobs, priorityJobs chan model.Job) error {
 	streamName := config.Config.GetString(rconfig.SettingNatsStreamName)
 
 	topic := config.Config.GetString(rconfig.SettingNatsSubscriberTopic)
@@ -45,22 +55,114 @@ func (i *indexer) GetJobs(ctx context.Context, jobs chan model.Job) error {
 		return errors.Wrap(err, "failed to subscribe to the nats JetStream")
 	}
 
+	priorityTopic := config.Config.GetString(rconfig.SettingNatsSubscriberPriorityTopic)
+	if priorityTopic != "" {
+		err = i.nats.JetStreamSubscribe(ctx, streamName+"."+priorityTopic,
+			config.Config.GetString(rconfig.SettingNatsSubscriberPriorityDurable),
+			priorityJobs)
+		if err != nil {
+			return errors.Wrap(err,
+				"failed to subscribe to the priority jobs on the nats JetStream")
+		}
+	}
+
+	statusTopic := config.Config.GetString(rconfig.SettingNatsDeviceauthStatusTopic)
+	if statusTopic != "" {
+		err = i.subscribeDeviceStatus(ctx, streamName+"."+statusTopic,
+			config.Config.GetString(rconfig.SettingNatsDeviceauthStatusDurable),
+			priorityJobs)
+	}
+	return err
+}
+
+// subscribeDeviceStatus subscribes to the deviceauth status change events,
+// forwarding them to the jobs channel as ActionUpdateDeviceStatus jobs, or
+// ActionDecommissionDevice jobs for the decommissioned devices
+func (i *indexer) subscribeDeviceStatus(
+	ctx context.Context,
+	subject, durableName string,
+	jobs chan model.Job,
+) error {
+	events := make(chan model.Job, cap(jobs))
+	err := i.nats.JetStreamSubscribe(ctx, subject, durableName, events)
+	if err != nil {
+		return errors.Wrap(err,
+			"failed to subscribe to the deviceauth status events on the nats JetStream")
+	}
+	go func() {
+		for job := range events {
+			job.Action = model.ActionUpdateDeviceStatus
+			if job.Status == model.DeviceStatusDecommissioned {
+				job.Action = model.ActionDecommissionDevice
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				nakJobs(ctx, job)
+				return
+			}
+		}
+		if ctx.Err() == nil {
+			log.FromContext(ctx).Error(
+				"the subscription to the deviceauth status events was closed")
+		}
+	}()
 	return nil
 }
 
 func (i *indexer) ProcessJobs(ctx context.Context, jobs []model.Job) {
+	// the jobs are processed in batch: link the span to the
+	// traces of all the messages the jobs were received with
+	links := make([]trace.Link, 0, len(jobs))
+	for _, job := range jobs {
+		if job.TraceContext != nil {
+			links = append(links, trace.LinkFromContext(
+				tracing.Extract(ctx, propagation.MapCarrier(job.TraceContext))))
+		}
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "indexer process jobs",
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("reporting.jobs", len(jobs))),
+	)
+	defer span.End()
+
 	l := log.FromContext(ctx)
 	l.Debugf("Processing %d jobs", len(jobs))
 	tenantsActionIDs := groupJobsIntoTenantActionIDs(jobs)
+	statuses := groupDeviceStatuses(jobs)
+	errs := make(map[string]map[string]error, len(tenantsActionIDs))
 	for tenant, actionIDs := range tenantsActionIDs {
+		errs[tenant] = make(map[string]error, len(actionIDs))
 		for action, IDs := range actionIDs {
+			var err error
 			if action == model.ActionReindex {
-				i.processJobDevices(ctx, tenant, IDs)
+				err = i.processJobDevices(ctx, tenant, IDs)
 			} else if action == model.ActionReindexDeployment {
-				i.processJobDeployments(ctx, tenant, IDs)
+				err = i.processJobDeployments(ctx, tenant, IDs)
+			} else if action == model.ActionUpdateDeviceStatus {
+				err = i.processJobDevicesStatus(ctx, tenant, statuses[tenant])
+			} else if action == model.ActionDecommissionDevice {
+				err = i.processJobDecommission(ctx, tenant, IDs)
 			} else {
 				l.Warnf("ignoring unknown job action: %v", action)
 			}
+			if err != nil {
+				l.Error(err)
+				errs[tenant][action] = err
+			}
+		}
+	}
+	// acknowledge the jobs processed, requesting the redelivery of the
+	// failed ones
+	for _, job := range jobs {
+		var err error
+		if jobErr := errs[job.TenantID][job.Action]; jobErr != nil {
+			err = job.Nak(jobErr)
+		} else {
+			err = job.Ack()
+		}
+		if err != nil {
+			l.Error(errors.Wrap(err, "failed to acknowledge the job"))
 		}
 	}
 }
@@ -69,11 +171,7 @@ func (i *indexer) processJobDevices(
 	ctx context.Context,
 	tenant string,
 	IDs IDs,
-) {
-	l := log.FromContext(ctx)
-	devices := make([]*model.Device, 0, len(IDs))
-	removedDevices := make([]*model.Device, 0, len(IDs))
-
+) error {
 	deviceIDs := make([]string, 0, len(IDs))
 	for deviceID := range IDs {
 		deviceIDs = append(deviceIDs, deviceID)
@@ -81,19 +179,141 @@ func (i *indexer) processJobDevices(
 	// get devices from deviceauth
 	deviceAuthDevices, err := i.devClient.GetDevices(ctx, tenant, deviceIDs)
 	if err != nil {
-		l.Error(errors.Wrap(err, "failed to get devices from deviceauth"))
-		return
+		return errors.Wrap(err, "failed to get devices from deviceauth")
 	}
 	// get devices from inventory
 	inventoryDevices, err := i.invClient.GetDevices(ctx, tenant, deviceIDs)
 	if err != nil {
-		l.Error(errors.Wrap(err, "failed to get devices from inventory"))
-		return
+		return errors.Wrap(err, "failed to get devices from inventory")
+	}
+	devices, removedDevices, err := i.buildDevices(ctx, tenant, deviceIDs,
+		deviceAuthDevices, inventoryDevices)
+	if err != nil {
+		return err
+	}
+	// bulk index the device
+	if len(devices) > 0 || len(removedDevices) > 0 {
+		err = i.store.BulkIndexDevices(ctx, devices, removedDevices)
+		if err != nil {
+			return errors.Wrap(err, "failed to bulk index the devices")
+		}
+	}
+	return i.indexSoftware(ctx, tenant, inventoryDevices, devices, removedDevices)
+}
+
+// processJobDevicesStatus updates the status of the indexed devices from
+// the deviceauth status change events, without waiting for the next
+// reindex; if the indexing rules of the tenant depend on the status, the
+// devices are reindexed instead, as they may start or stop matching them
+func (i *indexer) processJobDevicesStatus(
+	ctx context.Context,
+	tenant string,
+	statuses map[string]string,
+) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+	rules, err := i.ds.GetIndexingRules(ctx, tenant)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the indexing rules")
+	}
+	if rules.DependsOn(model.ScopeIdentity, model.AttrNameStatus) {
+		IDs := make(IDs, len(statuses))
+		for deviceID := range statuses {
+			IDs[deviceID] = true
+		}
+		return i.processJobDevices(ctx, tenant, IDs)
+	}
+
+	devices := make([]*model.Device, 0, len(statuses))
+	for deviceID, status := range statuses {
+		device := model.NewDevice(tenant, deviceID)
+		_ = device.AppendAttr(&model.InventoryAttribute{
+			Scope:  model.ScopeIdentity,
+			Name:   model.AttrNameStatus,
+			String: []string{status},
+		})
+		devices = append(devices, device)
+	}
+	if err := i.store.UpdateDevices(ctx, devices); err != nil {
+		return errors.Wrap(err, "failed to update the status of the devices")
+	}
+	return nil
+}
+
+// processJobDecommission deletes the documents of the decommissioned
+// devices and their deployments history
+func (i *indexer) processJobDecommission(
+	ctx context.Context,
+	tenant string,
+	IDs IDs,
+) error {
+	deviceIDs := make([]string, 0, len(IDs))
+	for deviceID := range IDs {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	if err := i.deleteDevices(ctx, tenant, deviceIDs); err != nil {
+		return errors.Wrap(err, "failed to delete the decommissioned devices")
+	}
+	return nil
+}
+
+// deleteDevices deletes the documents of the tenant's devices; their
+// deployments history is deleted too, unless kept for the decommission
+// grace period, in which case the devices are recorded to purge it later
+func (i *indexer) deleteDevices(
+	ctx context.Context,
+	tenant string,
+	deviceIDs []string,
+) error {
+	if i.decommissionGracePeriod <= 0 {
+		return i.store.DeleteDevicesData(ctx, tenant, deviceIDs)
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	decommissioned := make([]model.DecommissionedDevice, 0, len(deviceIDs))
+	removedDevices := make([]*model.Device, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		decommissioned = append(decommissioned, model.DecommissionedDevice{
+			TenantID:         tenant,
+			DeviceID:         deviceID,
+			DecommissionedTs: now,
+			PurgeTs:          now.Add(i.decommissionGracePeriod),
+		})
+		removedDevices = append(removedDevices, model.NewDevice(tenant, deviceID))
+	}
+	// record the devices first: their deployments are never left behind
+	err := i.ds.InsertDecommissionedDevices(ctx, decommissioned)
+	if err != nil {
+		return err
+	}
+	err = i.store.BulkIndexDevices(ctx, nil, removedDevices)
+	if err != nil {
+		return err
+	}
+	return i.indexSoftware(ctx, tenant, nil, nil, removedDevices)
+}
+
+// buildDevices builds the documents of the devices from their deviceauth
+// and inventory data; the devices which do not exist or do not match the
+// indexing rules of the tenant are returned as removed
+func (i *indexer) buildDevices(
+	ctx context.Context,
+	tenant string,
+	deviceIDs []string,
+	deviceAuthDevices []deviceauth.DeviceAuthDevice,
+	inventoryDevices []inventory.Device,
+) (devices, removedDevices []*model.Device, err error) {
+	devices = make([]*model.Device, 0, len(deviceIDs))
+	removedDevices = make([]*model.Device, 0, len(deviceIDs))
+
+	// get the indexing rules of the tenant
+	rules, err := i.ds.GetIndexingRules(ctx, tenant)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get the indexing rules")
 	}
 	// process the results
-	devices = devices[:0]
-	removedDevices = removedDevices[:0]
 	for _, deviceID := range deviceIDs {
+		deviceID := deviceID
 		var deviceAuthDevice *deviceauth.DeviceAuthDevice
 		var inventoryDevice *inventory.Device
 		for _, d := range deviceAuthDevices {
@@ -108,99 +328,167 @@ func (i *indexer) processJobDevices(
 				break
 			}
 		}
-		if deviceAuthDevice == nil || inventoryDevice == nil {
+		if deviceAuthDevice == nil || inventoryDevice == nil ||
+			!rules.Match(indexingAttributes(deviceAuthDevice, inventoryDevice)) {
 			removedDevices = append(removedDevices, &model.Device{
 				ID:       &deviceID,
 				TenantID: &tenant,
 			})
 			continue
 		}
-		device := i.processJobDevice(ctx, tenant, deviceAuthDevice, inventoryDevice)
+		device := i.processJobDevice(ctx, tenant, rules, deviceAuthDevice, inventoryDevice)
 		if device != nil {
 			devices = append(devices, device)
 		}
 	}
-	// bulk index the device
-	if len(devices) > 0 || len(removedDevices) > 0 {
-		err = i.store.BulkIndexDevices(ctx, devices, removedDevices)
-		if err != nil {
-			err = errors.Wrap(err, "failed to bulk index the devices")
-			l.Error(err)
+	return devices, removedDevices, nil
+}
+
+// indexSoftware indexes the software inventory of the indexed devices,
+// if enabled, and deletes the one of the removed devices; the attributes
+// not selected by the global attributes filter are ignored, and the
+// redacted ones are indexed redacted
+func (i *indexer) indexSoftware(
+	ctx context.Context,
+	tenant string,
+	inventoryDevices []inventory.Device,
+	devices, removedDevices []*model.Device,
+) error {
+	if !i.softwareInventory || (len(devices) == 0 && len(removedDevices) == 0) {
+		return nil
+	}
+	indexed := make(map[string]*model.Device, len(devices))
+	for _, device := range devices {
+		indexed[device.GetID()] = device
+	}
+	software := make([]*model.DeviceSoftware, 0, len(devices))
+	for _, inventoryDevice := range inventoryDevices {
+		device, ok := indexed[string(inventoryDevice.ID)]
+		if !ok {
+			continue
 		}
+		item := model.NewDeviceSoftware(tenant, device.GetID())
+		item.UpdatedAt = device.UpdatedAt
+		for _, attr := range inventoryDevice.Attributes {
+			if !i.attributesFilter.Match(attr.Scope, attr.Name) {
+				continue
+			}
+			value := i.redaction.Redact(attr.Scope, attr.Name, attr.Value)
+			switch {
+			case attr.Scope == model.ScopeSystem && attr.Name == model.AttrNameGroup:
+				item.Group, _ = value.(string)
+			case attr.Scope == model.ScopeInventory:
+				item.AddAttribute(attr.Name, value)
+			}
+		}
+		software = append(software, item)
+	}
+	err := i.store.BulkIndexSoftware(ctx, software, removedDevices)
+	if err != nil {
+		return errors.Wrap(err, "failed to bulk index the software inventory")
 	}
+	return nil
 }
 
+// processJobDevice builds the document of the device from its deviceauth
+// and inventory data, through the stages of the enrichment pipeline; it
+// returns nil if any stage fails
 func (i *indexer) processJobDevice(
 	ctx context.Context,
 	tenant string,
+	rules *model.IndexingRules,
 	deviceAuthDevice *deviceauth.DeviceAuthDevice,
 	inventoryDevice *inventory.Device,
 ) *model.Device {
-	l := log.FromContext(ctx)
-	//
 	device := model.NewDevice(tenant, string(inventoryDevice.ID))
-	// data from inventory
 	device.SetUpdatedAt(inventoryDevice.UpdatedTs)
-	attributes, err := i.mapper.MapInventoryAttributes(ctx, tenant,
-		inventoryDevice.Attributes, true, false)
+	device.SetCheckInTime(deviceCheckInTime(inventoryDevice))
+	err := i.enrich(ctx, device, &DeviceSources{
+		TenantID:   tenant,
+		Rules:      rules,
+		DeviceAuth: deviceAuthDevice,
+		Inventory:  inventoryDevice,
+	})
 	if err != nil {
-		err = errors.Wrapf(err,
-			"failed to map device data for tenant %s, "+
-				"device %s", tenant, inventoryDevice.ID)
-		l.Warn(err)
-	} else {
-		for _, invattr := range attributes {
-			attr := model.NewInventoryAttribute(invattr.Scope).
-				SetName(invattr.Name).
-				SetVal(invattr.Value)
-			if err := device.AppendAttr(attr); err != nil {
-				err = errors.Wrapf(err,
-					"failed to convert device data for tenant %s, "+
-						"device %s", tenant, inventoryDevice.ID)
-				l.Warn(err)
-			}
-		}
+		log.FromContext(ctx).Error(err)
+		return nil
 	}
-	// data from device auth
-	_ = device.AppendAttr(&model.InventoryAttribute{
-		Scope:  model.ScopeIdentity,
-		Name:   model.AttrNameStatus,
-		String: []string{deviceAuthDevice.Status},
-	})
-	for name, value := range deviceAuthDevice.IdDataStruct {
-		attr := model.NewInventoryAttribute(model.ScopeIdentity).
-			SetName(name).
-			SetVal(value)
-		if err := device.AppendAttr(attr); err != nil {
-			err = errors.Wrapf(err,
-				"failed to convert identity data for tenant %s, "+
-					"device %s", tenant, inventoryDevice.ID)
-			l.Warn(err)
+	return device
+}
+
+// indexesAttribute returns true if the attribute of the tenant's devices
+// is selected for indexing by both the global attributes filter and the
+// tenant's indexing rules; the attributes not indexed don't take slots
+// of the tenant's mapping
+func (i *indexer) indexesAttribute(rules *model.IndexingRules, scope, name string) bool {
+	return i.attributesFilter.Match(scope, name) && rules.IndexesAttribute(scope, name)
+}
+
+// deviceLocation returns the location of the device from its latitude
+// and longitude inventory attributes, or nil if it has none or they are
+// not valid coordinates
+func (i *indexer) deviceLocation(inventoryDevice *inventory.Device) *model.GeoPoint {
+	if i.latitudeAttribute == "" || i.longitudeAttribute == "" {
+		return nil
+	}
+	var lat, lon *float64
+	for _, attr := range inventoryDevice.Attributes {
+		if attr.Scope != model.ScopeInventory {
+			continue
+		}
+		switch attr.Name {
+		case i.latitudeAttribute:
+			lat = parseCoordinate(attr.Value)
+		case i.longitudeAttribute:
+			lon = parseCoordinate(attr.Value)
 		}
 	}
-	// latest deployment
-	deviceDeployment, err := i.deplClient.GetLatestFinishedDeployment(ctx, tenant,
-		string(inventoryDevice.ID))
-	if err != nil {
-		l.Error(errors.Wrap(err, "failed to get device deployments from deployments"))
+	if lat == nil || lon == nil {
 		return nil
-	} else if deviceDeployment != nil {
-		_ = device.AppendAttr(&model.InventoryAttribute{
-			Scope:  model.ScopeSystem,
-			Name:   model.AttrNameLatestDeploymentStatus,
-			String: []string{deviceDeployment.Device.Status},
-		})
 	}
-	// return the device
-	return device
+	location := &model.GeoPoint{Lat: *lat, Lon: *lon}
+	if location.Validate() != nil {
+		return nil
+	}
+	return location
+}
+
+// deviceCheckInTime returns the time the device last checked in, from the
+// system check-in time attribute, falling back to the time of the last
+// inventory update for the devices without it
+func deviceCheckInTime(inventoryDevice *inventory.Device) time.Time {
+	for _, attr := range inventoryDevice.Attributes {
+		if attr.Scope != model.ScopeSystem || attr.Name != model.AttrNameCheckInTime {
+			continue
+		}
+		if value, ok := attr.Value.(string); ok {
+			if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				return ts
+			}
+		}
+	}
+	return inventoryDevice.UpdatedTs
+}
+
+// parseCoordinate parses a coordinate reported by the device either as a
+// number or as a string
+func parseCoordinate(value interface{}) *float64 {
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return &f
+		}
+	}
+	return nil
 }
 
 func (i *indexer) processJobDeployments(
 	ctx context.Context,
 	tenant string,
 	IDs IDs,
-) {
-	l := log.FromContext(ctx)
+) error {
 	depls := make([]*model.Deployment, 0, len(IDs))
 	deploymentIDs := make([]string, 0, len(IDs))
 	for deploymentID := range IDs {
@@ -209,14 +497,17 @@ func (i *indexer) processJobDeployments(
 	// get device deployments from deployments
 	deviceDeployments, err := i.deplClient.GetDeployments(ctx, tenant, deploymentIDs)
 	if err != nil {
-		l.Error(errors.Wrap(err, "failed to get device deployments from device deployments"))
-		return
+		return errors.Wrap(err, "failed to get device deployments from device deployments")
+	}
+	artifacts, err := i.getDeploymentsArtifacts(ctx, tenant, deviceDeployments)
+	if err != nil {
+		return errors.Wrap(err, "failed to get artifacts from deployments")
 	}
 	// process the results
 	for deploymentID := range IDs {
 		for _, d := range deviceDeployments {
 			if d.ID == deploymentID {
-				depl := i.processJobDeployment(ctx, tenant, d)
+				depl := i.processJobDeployment(ctx, tenant, d, artifacts)
 				if depl != nil {
 					depls = append(depls, depl)
 				}
@@ -228,16 +519,51 @@ func (i *indexer) processJobDeployments(
 	if len(depls) > 0 {
 		err = i.store.BulkIndexDeployments(ctx, depls)
 		if err != nil {
-			err = errors.Wrap(err, "failed to bulk index the deployments")
-			l.Error(err)
+			return errors.Wrap(err, "failed to bulk index the deployments")
+		}
+	}
+	return nil
+}
+
+// getDeploymentsArtifacts retrieves the artifacts of the deployments,
+// returning them by ID
+func (i *indexer) getDeploymentsArtifacts(
+	ctx context.Context,
+	tenant string,
+	deviceDeployments []*deployments.DeviceDeployment,
+) (map[string]*deployments.Image, error) {
+	var artifactIDs []string
+	seen := make(map[string]struct{})
+	for _, d := range deviceDeployments {
+		if d.Deployment == nil {
+			continue
 		}
+		for _, id := range d.Deployment.Artifacts {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				artifactIDs = append(artifactIDs, id)
+			}
+		}
+	}
+	if len(artifactIDs) == 0 {
+		return nil, nil
 	}
+	res, err := i.deplClient.GetArtifacts(ctx, tenant, artifactIDs)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := make(map[string]*deployments.Image, len(res))
+	for _, artifact := range res {
+		artifacts[artifact.Id] = artifact
+	}
+	return artifacts, nil
 }
 
 func (i *indexer) processJobDeployment(
 	ctx context.Context,
 	tenant string,
 	deployment *deployments.DeviceDeployment,
+	artifacts map[string]*deployments.Image,
 ) *model.Deployment {
 	deviceElapsedSeconds := uint(0)
 	if deployment.Device == nil ||
@@ -288,6 +614,53 @@ func (i *indexer) processJobDeployment(
 			res.ImageArtifactInfoFormat = deployment.Device.Image.Info.Format
 			res.ImageArtifactInfoVersion = deployment.Device.Image.Info.Version
 		}
+		// the image of the device deployment may lack part of the metadata
+		if artifact, ok := artifacts[deployment.Device.Image.Id]; ok {
+			if len(res.ImageDeviceTypes) == 0 {
+				res.ImageDeviceTypes = artifact.DeviceTypesCompatible
+			}
+			if res.ImageSize == 0 {
+				res.ImageSize = artifact.Size
+			}
+		}
+	}
+	// the store appends the transition to the indexed history if the
+	// status changed; the finished deployments are timestamped with the
+	// time the device finished them
+	transitionTs := time.Now().UTC()
+	if deployment.Device.Finished != nil {
+		transitionTs = *deployment.Device.Finished
 	}
+	res.DeviceStatusHistory = []model.DeviceStatusTransition{{
+		Status:    deployment.Device.Status,
+		Timestamp: transitionTs,
+	}}
+	addDeploymentArtifacts(res, deployment.Deployment.Artifacts, artifacts)
 	return res
 }
+
+// addDeploymentArtifacts adds the metadata of the deployment's artifacts to
+// the document, so that the deployments can be searched by the properties
+// of their artifacts, whatever the artifact installed on the device
+func addDeploymentArtifacts(
+	res *model.Deployment,
+	artifactIDs []string,
+	artifacts map[string]*deployments.Image,
+) {
+	res.DeploymentArtifactIDs = artifactIDs
+	deviceTypes := make(map[string]struct{})
+	for _, id := range artifactIDs {
+		artifact, ok := artifacts[id]
+		if !ok {
+			continue
+		}
+		res.DeploymentArtifactSizes = append(res.DeploymentArtifactSizes, artifact.Size)
+		for _, deviceType := range artifact.DeviceTypesCompatible {
+			if _, ok := deviceTypes[deviceType]; !ok {
+				deviceTypes[deviceType] = struct{}{}
+				res.DeploymentArtifactDeviceTypes = append(
+					res.DeploymentArtifactDeviceTypes, deviceType)
+			}
+		}
+	}
+}
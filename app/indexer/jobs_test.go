@@ -21,6 +21,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mendersoftware/go-lib-micro/config"
+
 	"github.com/mendersoftware/reporting/client/deployments"
 	deployments_mocks "github.com/mendersoftware/reporting/client/deployments/mocks"
 	"github.com/mendersoftware/reporting/client/deviceauth"
@@ -28,12 +30,15 @@ import (
 	"github.com/mendersoftware/reporting/client/inventory"
 	inventory_mocks "github.com/mendersoftware/reporting/client/inventory/mocks"
 	nats_mocks "github.com/mendersoftware/reporting/client/nats/mocks"
+	rconfig "github.com/mendersoftware/reporting/config"
 	"github.com/mendersoftware/reporting/model"
 	store_mocks "github.com/mendersoftware/reporting/store/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var contextMatcher = mock.MatchedBy(func(_ context.Context) bool { return true })
+
 func TestGetJobsSubscriptionError(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -52,7 +57,7 @@ func TestGetJobsSubscriptionError(t *testing.T) {
 	defer nats.AssertExpectations(t)
 
 	indexer := NewIndexer(nil, nil, nats, nil, nil, nil)
-	err := indexer.GetJobs(ctx, jobs)
+	err := indexer.GetJobs(ctx, jobs, make(chan model.Job, 1))
 	assert.Equal(t, "failed to subscribe to the nats JetStream: subscription error", err.Error())
 
 	cancel()
@@ -77,7 +82,7 @@ func TestGetJobs(t *testing.T) {
 	defer nats.AssertExpectations(t)
 
 	indexer := NewIndexer(nil, nil, nats, nil, nil, nil)
-	err := indexer.GetJobs(ctx, jobs)
+	err := indexer.GetJobs(ctx, jobs, make(chan model.Job, 1))
 	assert.Nil(t, err)
 
 	time.Sleep(500 * time.Millisecond)
@@ -108,10 +113,245 @@ func TestGetJobsError(t *testing.T) {
 	defer nats.AssertExpectations(t)
 
 	indexer := NewIndexer(nil, nil, nats, nil, nil, nil)
-	err := indexer.GetJobs(ctx, jobs)
+	err := indexer.GetJobs(ctx, jobs, make(chan model.Job, 1))
 	assert.ErrorIs(t, err, testErr)
 }
 
+func TestGetJobsDeviceStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config.Config.Set(rconfig.SettingNatsStreamName, "WORKFLOWS")
+	config.Config.Set(rconfig.SettingNatsDeviceauthStatusTopic, "deviceauth-status")
+	config.Config.Set(rconfig.SettingNatsDeviceauthStatusDurable,
+		rconfig.SettingNatsDeviceauthStatusDurableDefault)
+	defer config.Config.Set(rconfig.SettingNatsDeviceauthStatusTopic,
+		rconfig.SettingNatsDeviceauthStatusTopicDefault)
+
+	jobs := make(chan model.Job, 2)
+	priorityJobs := make(chan model.Job, 2)
+
+	nats := &nats_mocks.Client{}
+	nats.On("JetStreamSubscribe",
+		ctx,
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		jobs,
+	).Return(nil)
+	nats.On("JetStreamSubscribe",
+		ctx,
+		"WORKFLOWS.deviceauth-status",
+		"reporting-deviceauth-status",
+		mock.AnythingOfType("chan model.Job"),
+	).Run(func(args mock.Arguments) {
+		msgs := args.Get(3).(chan model.Job)
+		msgs <- model.Job{TenantID: "tenant", DeviceID: "1", Status: "rejected"}
+		msgs <- model.Job{TenantID: "tenant", DeviceID: "2", Status: "decommissioned"}
+	}).Return(nil)
+
+	defer nats.AssertExpectations(t)
+
+	indexer := NewIndexer(nil, nil, nats, nil, nil, nil)
+	err := indexer.GetJobs(ctx, jobs, priorityJobs)
+	assert.NoError(t, err)
+
+	expected := []model.Job{{
+		Action:   model.ActionUpdateDeviceStatus,
+		TenantID: "tenant",
+		DeviceID: "1",
+		Status:   "rejected",
+	}, {
+		Action:   model.ActionDecommissionDevice,
+		TenantID: "tenant",
+		DeviceID: "2",
+		Status:   "decommissioned",
+	}}
+	for _, expectedJob := range expected {
+		select {
+		case job := <-priorityJobs:
+			assert.Equal(t, expectedJob, job)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the device status job")
+		}
+	}
+}
+
+func TestGetJobsPriority(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config.Config.Set(rconfig.SettingNatsStreamName, "WORKFLOWS")
+	config.Config.Set(rconfig.SettingNatsSubscriberPriorityTopic, "reporting-priority")
+	config.Config.Set(rconfig.SettingNatsSubscriberPriorityDurable,
+		rconfig.SettingNatsSubscriberPriorityDurableDefault)
+	defer config.Config.Set(rconfig.SettingNatsSubscriberPriorityTopic,
+		rconfig.SettingNatsSubscriberPriorityTopicDefault)
+
+	jobs := make(chan model.Job, 1)
+	priorityJobs := make(chan model.Job, 1)
+
+	nats := &nats_mocks.Client{}
+	defer nats.AssertExpectations(t)
+	nats.On("JetStreamSubscribe",
+		ctx,
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+		jobs,
+	).Return(nil)
+	nats.On("JetStreamSubscribe",
+		ctx,
+		"WORKFLOWS.reporting-priority",
+		rconfig.SettingNatsSubscriberPriorityDurableDefault,
+		priorityJobs,
+	).Return(errors.New("subscription error"))
+
+	indexer := NewIndexer(nil, nil, nats, nil, nil, nil)
+	err := indexer.GetJobs(ctx, jobs, priorityJobs)
+	assert.EqualError(t, err, "failed to subscribe to the priority jobs on "+
+		"the nats JetStream: subscription error")
+}
+
+func TestProcessJobsDeviceStatus(t *testing.T) {
+	const tenantID = "tenant"
+	ctx := context.Background()
+
+	jobs := []model.Job{{
+		Action:   model.ActionUpdateDeviceStatus,
+		TenantID: tenantID,
+		DeviceID: "1",
+		Status:   "pending",
+	}, {
+		Action:   model.ActionUpdateDeviceStatus,
+		TenantID: tenantID,
+		DeviceID: "1",
+		Status:   "accepted",
+	}}
+
+	t.Run("ok, status updated", func(t *testing.T) {
+		ds := &store_mocks.DataStore{}
+		defer ds.AssertExpectations(t)
+		ds.On("GetIndexingRules", contextMatcher, tenantID).
+			Return(&model.IndexingRules{}, nil)
+
+		store := &store_mocks.Store{}
+		defer store.AssertExpectations(t)
+		store.On("UpdateDevices", contextMatcher,
+			mock.MatchedBy(func(devices []*model.Device) bool {
+				return assert.Len(t, devices, 1) &&
+					assert.Equal(t, "1", devices[0].GetID()) &&
+					assert.Equal(t, model.InventoryAttributes{{
+						Scope:  model.ScopeIdentity,
+						Name:   model.AttrNameStatus,
+						String: []string{"accepted"},
+					}}, devices[0].IdentityAttributes)
+			})).
+			Return(nil)
+
+		indexer := NewIndexer(store, ds, nil, nil, nil, nil)
+		indexer.ProcessJobs(ctx, jobs)
+	})
+
+	t.Run("ok, indexing rules on the status", func(t *testing.T) {
+		ds := &store_mocks.DataStore{}
+		defer ds.AssertExpectations(t)
+		ds.On("GetIndexingRules", contextMatcher, tenantID).
+			Return(&model.IndexingRules{Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeIdentity,
+				Attribute: model.AttrNameStatus,
+				Type:      "$eq",
+				Value:     "accepted",
+			}}}, nil)
+
+		// the devices are reindexed
+		devClient := &deviceauth_mocks.Client{}
+		defer devClient.AssertExpectations(t)
+		devClient.On("GetDevices", contextMatcher, tenantID, []string{"1"}).
+			Return(nil, errors.New("deviceauth error"))
+
+		indexer := NewIndexer(nil, ds, nil, devClient, nil, nil)
+		indexer.ProcessJobs(ctx, jobs)
+	})
+}
+
+func TestProcessJobsDecommission(t *testing.T) {
+	const tenantID = "tenant"
+	ctx := context.Background()
+
+	jobs := []model.Job{{
+		Action:   model.ActionDecommissionDevice,
+		TenantID: tenantID,
+		DeviceID: "1",
+	}}
+
+	testCases := map[string]struct {
+		err error
+	}{
+		"ok": {},
+		"error": {
+			err: errors.New("delete error"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			store := &store_mocks.Store{}
+			defer store.AssertExpectations(t)
+			store.On("DeleteDevicesData", contextMatcher, tenantID, []string{"1"}).
+				Return(tc.err)
+
+			acker := &jobAcknowledger{}
+			jobs := append([]model.Job(nil), jobs...)
+			jobs[0].Acknowledger = acker
+
+			indexer := NewIndexer(store, nil, nil, nil, nil, nil)
+			indexer.ProcessJobs(ctx, jobs)
+			if tc.err != nil {
+				assert.False(t, acker.acked)
+				assert.ErrorIs(t, acker.nakReason, tc.err)
+			} else {
+				assert.True(t, acker.acked)
+			}
+		})
+	}
+}
+
+func TestProcessJobsDecommissionGracePeriod(t *testing.T) {
+	const tenantID = "tenant"
+	const grace = 30 * 24 * time.Hour
+	ctx := context.Background()
+
+	acker := &jobAcknowledger{}
+	jobs := []model.Job{{
+		Action:       model.ActionDecommissionDevice,
+		TenantID:     tenantID,
+		DeviceID:     "1",
+		Acknowledger: acker,
+	}}
+
+	ds := &store_mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("InsertDecommissionedDevices", contextMatcher,
+		mock.MatchedBy(func(devices []model.DecommissionedDevice) bool {
+			return len(devices) == 1 &&
+				devices[0].TenantID == tenantID &&
+				devices[0].DeviceID == "1" &&
+				devices[0].PurgeTs.Sub(devices[0].DecommissionedTs) == grace
+		})).
+		Return(nil)
+
+	// the devices are removed, their deployments are kept
+	store := &store_mocks.Store{}
+	defer store.AssertExpectations(t)
+	store.On("BulkIndexDevices", contextMatcher, []*model.Device(nil),
+		[]*model.Device{model.NewDevice(tenantID, "1")}).
+		Return(nil)
+
+	indexer := NewIndexer(store, ds, nil, nil, nil, nil,
+		WithDecommissionGracePeriod(grace))
+	indexer.ProcessJobs(ctx, jobs)
+	assert.True(t, acker.acked)
+}
+
 func strptr(s string) *string {
 	return &s
 }
@@ -133,6 +373,8 @@ func TestProcessJobs(t *testing.T) {
 		deploymentsDevice *deployments.DeviceDeployment
 		deploymentsErr    error
 
+		indexingRules *model.IndexingRules
+
 		updateMapping       []string
 		updateMappingResult []string
 
@@ -248,6 +490,97 @@ func TestProcessJobs(t *testing.T) {
 				},
 			},
 		},
+		"ok, device excluded by the indexing rules": {
+			jobs: []model.Job{
+				{
+					Action:   model.ActionReindex,
+					TenantID: tenantID,
+					DeviceID: "1",
+					Service:  model.ServiceInventory,
+				},
+				{
+					Action:   model.ActionReindex,
+					TenantID: tenantID,
+					DeviceID: "2",
+					Service:  model.ServiceInventory,
+				},
+			},
+
+			deviceauthDeviceIDs: []string{"1", "2"},
+			deviceauthDevices: []deviceauth.DeviceAuthDevice{
+				{
+					ID:     "1",
+					Status: "active",
+				},
+				{
+					ID:     "2",
+					Status: "active",
+				},
+			},
+
+			inventoryDeviceIDs: []string{"1", "2"},
+			inventoryDevices: []inventory.Device{
+				{
+					ID: "1",
+					Attributes: inventory.DeviceAttributes{
+						{
+							Scope: model.ScopeSystem,
+							Name:  model.AttrNameGroup,
+							Value: "production",
+						},
+					},
+				},
+				{
+					ID: "2",
+					Attributes: inventory.DeviceAttributes{
+						{
+							Scope: model.ScopeSystem,
+							Name:  model.AttrNameGroup,
+							Value: "test",
+						},
+					},
+				},
+			},
+
+			indexingRules: &model.IndexingRules{
+				TenantID: tenantID,
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeSystem,
+					Attribute: model.AttrNameGroup,
+					Type:      "$eq",
+					Value:     "production",
+				}},
+			},
+
+			updateMapping: []string{},
+
+			bulkIndexDevices: []*model.Device{
+				{
+					ID:       strptr("1"),
+					TenantID: strptr(tenantID),
+					IdentityAttributes: model.InventoryAttributes{
+						{
+							Scope:  model.ScopeIdentity,
+							Name:   model.AttrNameStatus,
+							String: []string{"active"},
+						},
+					},
+					SystemAttributes: model.InventoryAttributes{
+						{
+							Scope:  model.ScopeSystem,
+							Name:   model.AttrNameGroup,
+							String: []string{"production"},
+						},
+					},
+				},
+			},
+			bulkIndexRemoveDevices: []*model.Device{
+				{
+					ID:       strptr("2"),
+					TenantID: strptr(tenantID),
+				},
+			},
+		},
 		"ok with latest deployment": {
 			jobs: []model.Job{
 				{
@@ -537,7 +870,7 @@ func TestProcessJobs(t *testing.T) {
 
 			if len(tc.bulkIndexDevices) > 0 || len(tc.bulkIndexRemoveDevices) > 0 {
 				store.On("BulkIndexDevices",
-					ctx,
+					contextMatcher,
 					tc.bulkIndexDevices,
 					tc.bulkIndexRemoveDevices,
 				).Return(tc.bulkIndexErr)
@@ -547,7 +880,7 @@ func TestProcessJobs(t *testing.T) {
 			defer devClient.AssertExpectations(t)
 
 			devClient.On("GetDevices",
-				ctx,
+				contextMatcher,
 				tenantID,
 				mock.MatchedBy(func(ids []string) bool {
 					sort.Strings(ids)
@@ -562,7 +895,7 @@ func TestProcessJobs(t *testing.T) {
 
 			if tc.deviceauthErr == nil {
 				invClient.On("GetDevices",
-					ctx,
+					contextMatcher,
 					tenantID,
 					mock.MatchedBy(func(ids []string) bool {
 						sort.Strings(ids)
@@ -577,15 +910,19 @@ func TestProcessJobs(t *testing.T) {
 			defer deplClient.AssertExpectations(t)
 			if tc.deviceauthErr == nil && tc.inventoryErr == nil {
 				deplClient.On("GetLatestFinishedDeployment",
-					ctx,
+					contextMatcher,
 					tenantID,
 					mock.AnythingOfType("string"),
 				).Return(tc.deploymentsDevice, tc.deploymentsErr)
 			}
 
 			ds := &store_mocks.DataStore{}
+			ds.On("GetIndexingRules",
+				contextMatcher,
+				tenantID,
+			).Return(tc.indexingRules, nil).Maybe()
 			ds.On("UpdateAndGetMapping",
-				ctx,
+				contextMatcher,
 				tenantID,
 				tc.updateMapping,
 			).Return(&model.Mapping{
@@ -711,8 +1048,9 @@ func TestProcessJobsDeployments(t *testing.T) {
 
 			if tc.getDeploymentsErr == nil {
 				store.On("BulkIndexDeployments",
-					ctx,
+					contextMatcher,
 					mock.MatchedBy(func(deployments []*model.Deployment) bool {
+						deployments = withoutStatusHistory(t, deployments)
 						for _, i := range deployments {
 							found := false
 							for _, j := range tc.bulkIndexDeployments {
@@ -735,7 +1073,7 @@ func TestProcessJobsDeployments(t *testing.T) {
 			defer deplClient.AssertExpectations(t)
 
 			deplClient.On("GetDeployments",
-				ctx,
+				contextMatcher,
 				tenantID,
 				mock.AnythingOfType("[]string"),
 			).Return(tc.getDeployments, tc.getDeploymentsErr)
@@ -745,3 +1083,440 @@ func TestProcessJobsDeployments(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessJobsDeploymentsArtifacts(t *testing.T) {
+	const tenantID = "tenant"
+	ctx := context.Background()
+
+	jobs := []model.Job{{
+		Action:   model.ActionReindexDeployment,
+		TenantID: tenantID,
+		ID:       "1",
+	}, {
+		Action:   model.ActionReindexDeployment,
+		TenantID: tenantID,
+		ID:       "2",
+	}}
+	deplClient := &deployments_mocks.Client{}
+	defer deplClient.AssertExpectations(t)
+	deplClient.On("GetDeployments", contextMatcher, tenantID,
+		mock.AnythingOfType("[]string")).
+		Return([]*deployments.DeviceDeployment{{
+			ID: "1",
+			Deployment: &deployments.Deployment{
+				Artifacts: []string{"artifact1", "artifact2"},
+			},
+			Device: &deployments.Device{
+				Status: "pending",
+			},
+		}, {
+			ID: "2",
+			Deployment: &deployments.Deployment{
+				Artifacts: []string{"artifact2"},
+			},
+			Device: &deployments.Device{
+				Status: "downloading",
+				Image: &deployments.Image{
+					Id:   "artifact2",
+					Name: "release-1",
+				},
+			},
+		}}, nil)
+	deplClient.On("GetArtifacts", contextMatcher, tenantID,
+		mock.MatchedBy(func(IDs []string) bool {
+			return assert.ElementsMatch(t, []string{"artifact1", "artifact2"}, IDs)
+		})).
+		Return([]*deployments.Image{{
+			Id:                    "artifact1",
+			Name:                  "release-1",
+			DeviceTypesCompatible: []string{"raspberrypi3", "raspberrypi4"},
+			Size:                  1024,
+		}, {
+			Id:                    "artifact2",
+			Name:                  "release-1",
+			DeviceTypesCompatible: []string{"raspberrypi4", "beaglebone"},
+			Size:                  2048,
+		}}, nil)
+
+	expected := []*model.Deployment{{
+		ID:                            "1",
+		TenantID:                      tenantID,
+		DeviceStatus:                  "pending",
+		DeploymentArtifactIDs:         []string{"artifact1", "artifact2"},
+		DeploymentArtifactDeviceTypes: []string{"raspberrypi3", "raspberrypi4", "beaglebone"},
+		DeploymentArtifactSizes:       []int64{1024, 2048},
+	}, {
+		ID:                            "2",
+		TenantID:                      tenantID,
+		DeviceStatus:                  "downloading",
+		ImageID:                       "artifact2",
+		ImageArtifactName:             "release-1",
+		ImageDeviceTypes:              []string{"raspberrypi4", "beaglebone"},
+		ImageSize:                     2048,
+		DeploymentArtifactIDs:         []string{"artifact2"},
+		DeploymentArtifactDeviceTypes: []string{"raspberrypi4", "beaglebone"},
+		DeploymentArtifactSizes:       []int64{2048},
+	}}
+	store := &store_mocks.Store{}
+	defer store.AssertExpectations(t)
+	store.On("BulkIndexDeployments", contextMatcher,
+		mock.MatchedBy(func(depls []*model.Deployment) bool {
+			return assert.ElementsMatch(t, expected, withoutStatusHistory(t, depls))
+		})).
+		Return(nil)
+
+	indexer := NewIndexer(store, nil, nil, nil, nil, deplClient)
+	indexer.ProcessJobs(ctx, jobs)
+}
+
+// withoutStatusHistory checks the deployments carry the transition to
+// their current status, returning copies of them without the history
+func withoutStatusHistory(t *testing.T, depls []*model.Deployment) []*model.Deployment {
+	res := make([]*model.Deployment, 0, len(depls))
+	for _, depl := range depls {
+		if assert.Len(t, depl.DeviceStatusHistory, 1) {
+			transition := depl.DeviceStatusHistory[0]
+			assert.Equal(t, depl.DeviceStatus, transition.Status)
+			if depl.DeviceFinished != nil {
+				assert.Equal(t, *depl.DeviceFinished, transition.Timestamp)
+			} else {
+				assert.WithinDuration(t, time.Now(), transition.Timestamp, time.Minute)
+			}
+		}
+		d := *depl
+		d.DeviceStatusHistory = nil
+		res = append(res, &d)
+	}
+	return res
+}
+
+type jobAcknowledger struct {
+	acked     bool
+	nakReason error
+}
+
+func (a *jobAcknowledger) Ack() error {
+	a.acked = true
+	return nil
+}
+
+func (a *jobAcknowledger) Nak(reason error) error {
+	a.nakReason = reason
+	return nil
+}
+
+func TestProcessJobsAcknowledgement(t *testing.T) {
+	const tenantID = "tenant"
+	ctx := context.Background()
+
+	deviceJob := &jobAcknowledger{}
+	deploymentJob := &jobAcknowledger{}
+	unknownJob := &jobAcknowledger{}
+	jobs := []model.Job{{
+		Action:       model.ActionReindex,
+		TenantID:     tenantID,
+		DeviceID:     "1",
+		Acknowledger: deviceJob,
+	}, {
+		Action:       model.ActionReindexDeployment,
+		TenantID:     tenantID,
+		ID:           "2",
+		Acknowledger: deploymentJob,
+	}, {
+		Action:       "unknown",
+		TenantID:     tenantID,
+		Acknowledger: unknownJob,
+	}}
+
+	devClient := &deviceauth_mocks.Client{}
+	defer devClient.AssertExpectations(t)
+	devClient.On("GetDevices", contextMatcher, tenantID, []string{"1"}).
+		Return(nil, errors.New("deviceauth error"))
+
+	deplClient := &deployments_mocks.Client{}
+	defer deplClient.AssertExpectations(t)
+	deplClient.On("GetDeployments", contextMatcher, tenantID, []string{"2"}).
+		Return([]*deployments.DeviceDeployment{}, nil)
+
+	indexer := NewIndexer(nil, nil, nil, devClient, nil, deplClient)
+	indexer.ProcessJobs(ctx, jobs)
+
+	assert.False(t, deviceJob.acked)
+	assert.EqualError(t, deviceJob.nakReason,
+		"failed to get devices from deviceauth: deviceauth error")
+	assert.True(t, deploymentJob.acked)
+	assert.NoError(t, deploymentJob.nakReason)
+	assert.True(t, unknownJob.acked)
+}
+
+func TestDeviceLocation(t *testing.T) {
+	testCases := map[string]struct {
+		attributes inventory.DeviceAttributes
+		disabled   bool
+
+		location *model.GeoPoint
+	}{
+		"ok, numbers": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "latitude", Value: 59.91},
+				{Scope: model.ScopeInventory, Name: "longitude", Value: 10.75},
+			},
+			location: &model.GeoPoint{Lat: 59.91, Lon: 10.75},
+		},
+		"ok, strings": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "latitude", Value: "59.91"},
+				{Scope: model.ScopeInventory, Name: "longitude", Value: " 10.75"},
+			},
+			location: &model.GeoPoint{Lat: 59.91, Lon: 10.75},
+		},
+		"no location, disabled": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "latitude", Value: 59.91},
+				{Scope: model.ScopeInventory, Name: "longitude", Value: 10.75},
+			},
+			disabled: true,
+		},
+		"no location, missing longitude": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "latitude", Value: 59.91},
+				{Scope: model.ScopeIdentity, Name: "longitude", Value: 10.75},
+			},
+		},
+		"no location, not a number": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "latitude", Value: "north"},
+				{Scope: model.ScopeInventory, Name: "longitude", Value: 10.75},
+			},
+		},
+		"no location, out of range": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "latitude", Value: 100.0},
+				{Scope: model.ScopeInventory, Name: "longitude", Value: 10.75},
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var opts []Option
+			if !tc.disabled {
+				opts = append(opts, WithLocationAttributes("latitude", "longitude"))
+			}
+			i := NewIndexer(nil, nil, nil, nil, nil, nil, opts...).(*indexer)
+			location := i.deviceLocation(&inventory.Device{Attributes: tc.attributes})
+			assert.Equal(t, tc.location, location)
+		})
+	}
+}
+
+func TestDeviceCheckInTime(t *testing.T) {
+	updatedTs := time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		attributes inventory.DeviceAttributes
+
+		checkInTime time.Time
+	}{
+		"ok, check-in time": {
+			attributes: inventory.DeviceAttributes{
+				{
+					Scope: model.ScopeSystem,
+					Name:  model.AttrNameCheckInTime,
+					Value: "2023-05-10T14:30:00.5Z",
+				},
+			},
+			checkInTime: time.Date(2023, 5, 10, 14, 30, 0, 5e8, time.UTC),
+		},
+		"ok, no check-in time": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "device_type", Value: "rpi4"},
+			},
+			checkInTime: updatedTs,
+		},
+		"ok, malformed check-in time": {
+			attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeSystem, Name: model.AttrNameCheckInTime, Value: "now"},
+			},
+			checkInTime: updatedTs,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			checkInTime := deviceCheckInTime(&inventory.Device{
+				Attributes: tc.attributes,
+				UpdatedTs:  updatedTs,
+			})
+			assert.True(t, tc.checkInTime.Equal(checkInTime))
+		})
+	}
+}
+
+func TestIndexesAttribute(t *testing.T) {
+	global := &model.AttributesFilter{
+		Excluded: []model.AttributeSelector{
+			{Scope: model.ScopeInventory, Name: "network_*"},
+		},
+	}
+	rules := &model.IndexingRules{
+		Attributes: &model.AttributesFilter{
+			Included: []model.AttributeSelector{
+				{Scope: model.ScopeInventory, Name: "*"},
+			},
+		},
+	}
+	testCases := map[string]struct {
+		global *model.AttributesFilter
+		rules  *model.IndexingRules
+		scope  string
+		name   string
+
+		indexed bool
+	}{
+		"indexed, no filters": {
+			scope:   model.ScopeInventory,
+			name:    "network_interfaces",
+			indexed: true,
+		},
+		"indexed, included by the tenant": {
+			global:  global,
+			rules:   rules,
+			scope:   model.ScopeInventory,
+			name:    "cpu_model",
+			indexed: true,
+		},
+		"not indexed, excluded globally": {
+			global: global,
+			rules:  rules,
+			scope:  model.ScopeInventory,
+			name:   "network_interfaces",
+		},
+		"not indexed, not included by the tenant": {
+			global: global,
+			rules:  rules,
+			scope:  model.ScopeIdentity,
+			name:   "mac",
+		},
+		"indexed, status": {
+			global:  global,
+			rules:   rules,
+			scope:   model.ScopeIdentity,
+			name:    model.AttrNameStatus,
+			indexed: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			i := NewIndexer(nil, nil, nil, nil, nil, nil,
+				WithAttributesFilter(tc.global)).(*indexer)
+			assert.Equal(t, tc.indexed, i.indexesAttribute(tc.rules, tc.scope, tc.name))
+		})
+	}
+}
+
+func TestProcessJobDeviceRedaction(t *testing.T) {
+	const tenantID = "tenant"
+	redaction := &model.AttributesRedaction{
+		Hashed: []model.AttributeSelector{
+			{Scope: model.ScopeIdentity, Name: "serial"},
+		},
+		Masked: []model.AttributeSelector{
+			{Scope: model.ScopeInventory, Name: "ipv4_*"},
+		},
+		HashKey: []byte("secret"),
+	}
+
+	ds := &store_mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("UpdateAndGetMapping", contextMatcher, tenantID,
+		[]string{"inventory/ipv4_eth0", "inventory/artifact_name"}).
+		Return(&model.Mapping{
+			TenantID:  tenantID,
+			Inventory: []string{"inventory/ipv4_eth0", "inventory/artifact_name"},
+		}, nil)
+
+	deplClient := &deployments_mocks.Client{}
+	defer deplClient.AssertExpectations(t)
+	deplClient.On("GetLatestFinishedDeployment", contextMatcher, tenantID, "1").
+		Return(nil, nil)
+
+	i := NewIndexer(nil, ds, nil, nil, nil, deplClient,
+		WithAttributesRedaction(redaction)).(*indexer)
+	device := i.processJobDevice(context.Background(), tenantID, nil,
+		&deviceauth.DeviceAuthDevice{
+			ID:           "1",
+			Status:       "accepted",
+			IdDataStruct: map[string]string{"serial": "SN-1234"},
+		},
+		&inventory.Device{
+			ID: "1",
+			Attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "ipv4_eth0", Value: "192.168.2.10"},
+				{Scope: model.ScopeInventory, Name: "artifact_name", Value: "v1"},
+			},
+		})
+
+	if assert.NotNil(t, device) {
+		assert.Equal(t, model.InventoryAttributes{
+			{Scope: model.ScopeInventory, Name: "attribute1", String: []string{"********2.10"}},
+			{Scope: model.ScopeInventory, Name: "attribute2", String: []string{"v1"}},
+		}, device.InventoryAttributes)
+		assert.Equal(t, model.InventoryAttributes{
+			{Scope: model.ScopeIdentity, Name: model.AttrNameStatus, String: []string{"accepted"}},
+			{Scope: model.ScopeIdentity, Name: "serial",
+				String: []string{redaction.HashValue("SN-1234").(string)}},
+		}, device.IdentityAttributes)
+	}
+}
+
+func TestIndexSoftware(t *testing.T) {
+	const tenantID = "tenant"
+	updatedAt := time.Now().UTC()
+	device := model.NewDevice(tenantID, "1")
+	device.SetUpdatedAt(updatedAt)
+	removed := model.NewDevice(tenantID, "2")
+	inventoryDevices := []inventory.Device{
+		{
+			ID: "1",
+			Attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeSystem, Name: model.AttrNameGroup, Value: "prod"},
+				{Scope: model.ScopeInventory, Name: "rootfs-image.version", Value: "v1"},
+				{Scope: model.ScopeInventory, Name: "app.nginx.version", Value: "1.23"},
+				{Scope: model.ScopeInventory, Name: "app.secret.version", Value: "s3"},
+				{Scope: model.ScopeInventory, Name: "artifact_name", Value: "v1"},
+			},
+		},
+		{
+			ID: "2",
+			Attributes: inventory.DeviceAttributes{
+				{Scope: model.ScopeInventory, Name: "rootfs-image.version", Value: "v1"},
+			},
+		},
+	}
+
+	store := &store_mocks.Store{}
+	defer store.AssertExpectations(t)
+	store.On("BulkIndexSoftware", contextMatcher, []*model.DeviceSoftware{{
+		ID:               "1",
+		TenantID:         tenantID,
+		Group:            "prod",
+		Software:         []string{"rootfs-image", "app.nginx"},
+		SoftwareVersions: []string{"rootfs-image=v1", "app.nginx=1.23"},
+		UpdatedAt:        &updatedAt,
+	}}, []*model.Device{removed}).Return(nil).Once()
+
+	i := NewIndexer(store, nil, nil, nil, nil, nil,
+		WithSoftwareInventory(true),
+		WithAttributesFilter(&model.AttributesFilter{
+			Excluded: []model.AttributeSelector{
+				{Scope: model.ScopeInventory, Name: "app.secret.*"},
+			},
+		})).(*indexer)
+	err := i.indexSoftware(context.Background(), tenantID, inventoryDevices,
+		[]*model.Device{device}, []*model.Device{removed})
+	assert.NoError(t, err)
+
+	// disabled, nothing is indexed
+	i = NewIndexer(store, nil, nil, nil, nil, nil).(*indexer)
+	err = i.indexSoftware(context.Background(), tenantID, inventoryDevices,
+		[]*model.Device{device}, []*model.Device{removed})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/client/nats"
+)
+
+// leaderLockName is the name of the lock held by the leader of the
+// indexers, which runs the scheduled scans
+const leaderLockName = "indexer-leader"
+
+var metricLeader = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "reporting",
+	Subsystem: "indexer",
+	Name:      "leader",
+	Help:      "Whether the indexer is the leader running the scheduled scans.",
+})
+
+func init() {
+	prometheus.MustRegister(metricLeader)
+}
+
+// leaderRoutine campaigns for the leadership of the indexers on behalf of
+// the holder, renewing the lock every third of its TTL: lead is called with
+// a context cancelled when the leadership is lost, and the lock is released
+// when the routine returns, so that another indexer takes over right away
+func leaderRoutine(
+	ctx context.Context,
+	client nats.Client,
+	holder string,
+	ttl time.Duration,
+	lead func(ctx context.Context),
+) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	var cancelLead context.CancelFunc
+	stepDown := func() {
+		if cancelLead != nil {
+			cancelLead()
+			cancelLead = nil
+			metricLeader.Set(0)
+		}
+	}
+	defer func() {
+		if cancelLead != nil {
+			stepDown()
+			if err := client.ReleaseLock(leaderLockName, holder); err != nil {
+				l.Error(errors.Wrap(err, "failed to release the leadership"))
+			}
+		}
+	}()
+	for {
+		leader, err := client.AcquireLock(leaderLockName, holder, ttl)
+		if err != nil {
+			// the lock may expire before the next attempt
+			l.Error(errors.Wrap(err, "failed to acquire the leadership"))
+		}
+		if leader && cancelLead == nil {
+			l.Infof("elected leader of the indexers as %s", holder)
+			leadCtx, cancel := context.WithCancel(ctx)
+			cancelLead = cancel
+			metricLeader.Set(1)
+			go lead(leadCtx)
+		} else if !leader && cancelLead != nil {
+			l.Warnf("lost the leadership of the indexers")
+			stepDown()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaderHolder returns the name the indexer holds the leadership with:
+// its host name, unique among the replicas, and a random suffix
+func leaderHolder() string {
+	hostname, _ := os.Hostname()
+	return hostname + "-" + uuid.NewString()[:8]
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	nats_mocks "github.com/mendersoftware/reporting/client/nats/mocks"
+)
+
+func TestLeaderRoutine(t *testing.T) {
+	const (
+		holder = "indexer-1"
+		ttl    = 30 * time.Millisecond
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &nats_mocks.Client{}
+	defer client.AssertExpectations(t)
+	// elected, lost the leadership on error, elected again
+	client.On("AcquireLock", leaderLockName, holder, ttl).
+		Return(true, nil).Once()
+	client.On("AcquireLock", leaderLockName, holder, ttl).
+		Return(false, errors.New("connection closed")).Once()
+	client.On("AcquireLock", leaderLockName, holder, ttl).
+		Return(true, nil)
+	client.On("ReleaseLock", leaderLockName, holder).
+		Return(nil).Once()
+
+	leads := make(chan context.Context, 2)
+	done := make(chan struct{})
+	go func() {
+		leaderRoutine(ctx, client, holder, ttl, func(ctx context.Context) {
+			leads <- ctx
+		})
+		close(done)
+	}()
+
+	var first context.Context
+	select {
+	case first = <-leads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the leadership")
+	}
+	select {
+	case second := <-leads:
+		assert.Error(t, first.Err(), "the first leadership was not cancelled")
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the routine to return")
+		}
+		assert.Error(t, second.Err())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the leadership to be taken again")
+	}
+}
+
+func TestLeaderRoutineFollower(t *testing.T) {
+	const ttl = 30 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := make(chan struct{}, 10)
+	client := &nats_mocks.Client{}
+	defer client.AssertExpectations(t)
+	client.On("AcquireLock", leaderLockName, "indexer-2", ttl).
+		Run(func(_ mock.Arguments) {
+			select {
+			case attempts <- struct{}{}:
+			default:
+			}
+		}).
+		Return(false, nil)
+
+	done := make(chan struct{})
+	go func() {
+		leaderRoutine(ctx, client, "indexer-2", ttl, func(ctx context.Context) {
+			assert.Fail(t, "not elected")
+		})
+		close(done)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-attempts:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the campaign")
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the routine to return")
+	}
+}
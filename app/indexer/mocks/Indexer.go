@@ -22,6 +22,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	model "github.com/mendersoftware/reporting/model"
+
+	time "time"
 )
 
 // Indexer is an autogenerated mock type for the Indexer type
@@ -29,13 +31,27 @@ type Indexer struct {
 	mock.Mock
 }
 
-// GetJobs provides a mock function with given fields: ctx, jobs
-func (_m *Indexer) GetJobs(ctx context.Context, jobs chan model.Job) error {
-	ret := _m.Called(ctx, jobs)
+// CatchUpTenant provides a mock function with given fields: ctx, tenantID, since, batchSize
+func (_m *Indexer) CatchUpTenant(ctx context.Context, tenantID string, since time.Time, batchSize int) error {
+	ret := _m.Called(ctx, tenantID, since, batchSize)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) error); ok {
+		r0 = rf(ctx, tenantID, since, batchSize)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetJobs provides a mock function with given fields: ctx, jobs, priorityJobs
+func (_m *Indexer) GetJobs(ctx context.Context, jobs chan model.Job, priorityJobs chan model.Job) error {
+	ret := _m.Called(ctx, jobs, priorityJobs)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, chan model.Job) error); ok {
-		r0 = rf(ctx, jobs)
+	if rf, ok := ret.Get(0).(func(context.Context, chan model.Job, chan model.Job) error); ok {
+		r0 = rf(ctx, jobs, priorityJobs)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -47,3 +63,59 @@ func (_m *Indexer) GetJobs(ctx context.Context, jobs chan model.Job) error {
 func (_m *Indexer) ProcessJobs(ctx context.Context, jobs []model.Job) {
 	_m.Called(ctx, jobs)
 }
+
+// ReconcileDevices provides a mock function with given fields: ctx, tenantID, sampleSize
+func (_m *Indexer) ReconcileDevices(ctx context.Context, tenantID string, sampleSize int) (int, error) {
+	ret := _m.Called(ctx, tenantID, sampleSize)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) int); ok {
+		r0 = rf(ctx, tenantID, sampleSize)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, tenantID, sampleSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReindexTenant provides a mock function with given fields: ctx, tenantID, batchSize, restart
+func (_m *Indexer) ReindexTenant(ctx context.Context, tenantID string, batchSize int, restart bool) error {
+	ret := _m.Called(ctx, tenantID, batchSize, restart)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, bool) error); ok {
+		r0 = rf(ctx, tenantID, batchSize, restart)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SweepOrphanDevices provides a mock function with given fields: ctx, tenantID, batchSize
+func (_m *Indexer) SweepOrphanDevices(ctx context.Context, tenantID string, batchSize int) (int, error) {
+	ret := _m.Called(ctx, tenantID, batchSize)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) int); ok {
+		r0 = rf(ctx, tenantID, batchSize)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, tenantID, batchSize)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
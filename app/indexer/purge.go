@@ -0,0 +1,94 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+// purgeRoutine periodically purges the deployments history of the devices
+// decommissioned longer than the grace period ago, starting right away
+func purgeRoutine(
+	ctx context.Context,
+	store store.Store,
+	ds store.DataStore,
+	interval time.Duration,
+	batchSize int,
+) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := purgeDecommissionedDevices(ctx, store, ds, batchSize)
+		if err != nil {
+			l.Error(errors.Wrap(err, "failed to purge the decommissioned devices"))
+		} else if purged > 0 {
+			l.Infof("purged the deployments of %d decommissioned devices", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDecommissionedDevices deletes the deployments history of the
+// decommissioned devices whose grace period is over, batchSize devices at
+// a time; it returns the number of devices purged
+func purgeDecommissionedDevices(
+	ctx context.Context,
+	store store.Store,
+	ds store.DataStore,
+	batchSize int,
+) (int, error) {
+	var purged int
+	for {
+		devices, err := ds.GetDecommissionedDevicesToPurge(ctx, time.Now(), batchSize)
+		if err != nil {
+			return purged, err
+		}
+		tenantIDs := []string{}
+		deviceIDs := map[string][]string{}
+		for _, device := range devices {
+			if _, ok := deviceIDs[device.TenantID]; !ok {
+				tenantIDs = append(tenantIDs, device.TenantID)
+			}
+			deviceIDs[device.TenantID] = append(deviceIDs[device.TenantID], device.DeviceID)
+		}
+		for _, tenantID := range tenantIDs {
+			err := store.DeleteDevicesData(ctx, tenantID, deviceIDs[tenantID])
+			if err != nil {
+				return purged, errors.Wrapf(err,
+					"failed to purge the decommissioned devices of the tenant %q", tenantID)
+			}
+			err = ds.DeleteDecommissionedDevices(ctx, tenantID, deviceIDs[tenantID])
+			if err != nil {
+				return purged, err
+			}
+			purged += len(deviceIDs[tenantID])
+		}
+		if len(devices) < batchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	store_mocks "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestPurgeDecommissionedDevices(t *testing.T) {
+	t.Parallel()
+
+	const batchSize = 3
+	batch := []model.DecommissionedDevice{
+		{TenantID: "tenant1", DeviceID: "1"},
+		{TenantID: "tenant2", DeviceID: "2"},
+		{TenantID: "tenant1", DeviceID: "3"},
+	}
+	testCases := map[string]struct {
+		batches   [][]model.DecommissionedDevice
+		getErr    error
+		deleteErr error
+
+		purged int
+		err    string
+	}{
+		"ok": {
+			batches: [][]model.DecommissionedDevice{
+				batch,
+				{{TenantID: "tenant1", DeviceID: "4"}},
+			},
+			purged: 4,
+		},
+		"ok, nothing to purge": {
+			batches: [][]model.DecommissionedDevice{{}},
+		},
+		"error, get failed": {
+			getErr: errors.New("mongo error"),
+			err:    "mongo error",
+		},
+		"error, delete failed": {
+			batches:   [][]model.DecommissionedDevice{batch},
+			deleteErr: errors.New("opensearch error"),
+			err: `failed to purge the decommissioned devices of the tenant "tenant1": ` +
+				"opensearch error",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			store := &store_mocks.Store{}
+			defer store.AssertExpectations(t)
+			ds := &store_mocks.DataStore{}
+			defer ds.AssertExpectations(t)
+
+			if tc.getErr != nil {
+				ds.On("GetDecommissionedDevicesToPurge", ctx,
+					mock.AnythingOfType("time.Time"), batchSize).
+					Return(nil, tc.getErr).Once()
+			}
+			for _, devices := range tc.batches {
+				ds.On("GetDecommissionedDevicesToPurge", ctx,
+					mock.AnythingOfType("time.Time"), batchSize).
+					Return(devices, nil).Once()
+				if tc.deleteErr != nil {
+					// the devices are purged by tenant, in order
+					store.On("DeleteDevicesData", ctx, "tenant1", []string{"1", "3"}).
+						Return(tc.deleteErr).Once()
+					continue
+				}
+				deviceIDs := map[string][]string{}
+				for _, device := range devices {
+					deviceIDs[device.TenantID] = append(
+						deviceIDs[device.TenantID], device.DeviceID)
+				}
+				for tenantID, ids := range deviceIDs {
+					store.On("DeleteDevicesData", ctx, tenantID, ids).
+						Return(nil).Once()
+					ds.On("DeleteDecommissionedDevices", ctx, tenantID, ids).
+						Return(nil).Once()
+				}
+			}
+
+			purged, err := purgeDecommissionedDevices(ctx, store, ds, batchSize)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.purged, purged)
+		})
+	}
+}
+
+func TestPurgeRoutine(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := make(chan time.Time, 2)
+	store := &store_mocks.Store{}
+	defer store.AssertExpectations(t)
+	ds := &store_mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetDecommissionedDevicesToPurge", mock.Anything,
+		mock.AnythingOfType("time.Time"), 10).
+		Run(func(args mock.Arguments) {
+			calls <- args.Get(1).(time.Time)
+		}).
+		Return(nil, errors.New("mongo error")).Once()
+	ds.On("GetDecommissionedDevicesToPurge", mock.Anything,
+		mock.AnythingOfType("time.Time"), 10).
+		Run(func(args mock.Arguments) {
+			calls <- args.Get(1).(time.Time)
+			cancel()
+		}).
+		Return([]model.DecommissionedDevice{}, nil).Once()
+
+	done := make(chan struct{})
+	go func() {
+		purgeRoutine(ctx, store, ds, 10*time.Millisecond, 10)
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case before := <-calls:
+			assert.WithinDuration(t, time.Now(), before, time.Minute)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the decommissioned devices to be purged")
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the routine to return")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	metricReconcileChecked = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "reporting",
+		Subsystem: "indexer",
+		Name:      "reconcile_checked_devices_total",
+		Help:      "Number of indexed devices compared to inventory by the reconciliation.",
+	})
+	metricReconcileDrifted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "reporting",
+		Subsystem: "indexer",
+		Name:      "reconcile_drifted_devices_total",
+		Help: "Number of indexed devices found out of date with inventory " +
+			"by the reconciliation, and reindexed.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(metricReconcileChecked, metricReconcileDrifted)
+}
+
+// ReconcileDevices compares a random sample of the tenant's indexed devices
+// to inventory and reindexes the devices which drifted, e.g. because their
+// update events were lost: the devices updated in inventory after they were
+// indexed, and the devices which do not exist in inventory anymore; it
+// returns the number of drifted devices
+func (i *indexer) ReconcileDevices(
+	ctx context.Context,
+	tenantID string,
+	sampleSize int,
+) (int, error) {
+	// the device IDs are UUIDs: the sample starts from a random one and,
+	// if it reaches the end of the devices, continues from the first ones
+	sample, err := i.store.ListDevicesUpdatedAt(ctx, tenantID, uuid.NewString(), sampleSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(sample) < sampleSize {
+		first, err := i.store.ListDevicesUpdatedAt(ctx, tenantID, "",
+			sampleSize-len(sample))
+		if err != nil {
+			return 0, err
+		}
+		for deviceID, updatedAt := range first {
+			sample[deviceID] = updatedAt
+		}
+	}
+	if len(sample) == 0 {
+		return 0, nil
+	}
+
+	deviceIDs := make([]string, 0, len(sample))
+	for deviceID := range sample {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	inventoryDevices, err := i.invClient.GetDevices(ctx, tenantID, deviceIDs)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get devices from inventory")
+	}
+	metricReconcileChecked.Add(float64(len(sample)))
+
+	drifted := make(IDs)
+	for deviceID := range sample {
+		drifted[deviceID] = true
+	}
+	for _, device := range inventoryDevices {
+		deviceID := string(device.ID)
+		if updatedAt, ok := sample[deviceID]; ok && !device.UpdatedTs.After(updatedAt) {
+			delete(drifted, deviceID)
+		}
+	}
+	if len(drifted) == 0 {
+		return 0, nil
+	}
+	metricReconcileDrifted.Add(float64(len(drifted)))
+	if err := i.processJobDevices(ctx, tenantID, drifted); err != nil {
+		return len(drifted), errors.Wrap(err, "failed to reindex the drifted devices")
+	}
+	return len(drifted), nil
+}
+
+// reconcileRoutine periodically reconciles a sample of the devices of all
+// the tenants with inventory
+func reconcileRoutine(
+	ctx context.Context,
+	indexer Indexer,
+	ds store.DataStore,
+	interval time.Duration,
+	sampleSize int,
+) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		tenantIDs, err := ds.GetTenantIDs(ctx)
+		if err != nil {
+			l.Error(errors.Wrap(err, "failed to reconcile the devices"))
+			continue
+		}
+		for _, tenantID := range tenantIDs {
+			drifted, err := indexer.ReconcileDevices(ctx, tenantID, sampleSize)
+			if err != nil {
+				l.Error(errors.Wrapf(err,
+					"failed to reconcile the devices of the tenant %q", tenantID))
+			} else if drifted > 0 {
+				l.Warnf("reindexed %d devices of the tenant %q which drifted from inventory",
+					drifted, tenantID)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
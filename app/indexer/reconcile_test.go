@@ -0,0 +1,179 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	deviceauth_mocks "github.com/mendersoftware/reporting/client/deviceauth/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	inventory_mocks "github.com/mendersoftware/reporting/client/inventory/mocks"
+	"github.com/mendersoftware/reporting/model"
+	store_mocks "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestReconcileDevices(t *testing.T) {
+	const (
+		tenantID   = "tenant"
+		sampleSize = 3
+	)
+	indexedTs := time.Date(2023, 5, 2, 10, 0, 0, 0, time.UTC)
+	elementsMatch := func(expected []string) interface{} {
+		return mock.MatchedBy(func(actual []string) bool {
+			expected := append([]string{}, expected...)
+			actual = append([]string{}, actual...)
+			sort.Strings(expected)
+			sort.Strings(actual)
+			return assert.ObjectsAreEqual(expected, actual)
+		})
+	}
+
+	testCases := map[string]struct {
+		// devices sampled from a random device, and from the first one
+		sample      map[string]time.Time
+		sampleFirst map[string]time.Time
+		// devices in inventory, with their update time
+		inventory map[string]time.Time
+
+		listErr error
+		getErr  error
+
+		drifted []string
+		err     string
+	}{
+		"ok, no devices": {
+			sample:      map[string]time.Time{},
+			sampleFirst: map[string]time.Time{},
+		},
+		"ok, no drift": {
+			sample: map[string]time.Time{
+				"1": indexedTs,
+				"2": indexedTs,
+				"3": indexedTs,
+			},
+			inventory: map[string]time.Time{
+				"1": indexedTs,
+				"2": indexedTs.Add(-time.Hour),
+				"3": indexedTs,
+			},
+		},
+		"ok, drifted devices": {
+			sample: map[string]time.Time{
+				"3": indexedTs,
+			},
+			sampleFirst: map[string]time.Time{
+				"1": indexedTs,
+				"2": indexedTs,
+			},
+			inventory: map[string]time.Time{
+				"1": indexedTs,
+				"2": indexedTs.Add(time.Minute),
+			},
+			drifted: []string{"2", "3"},
+		},
+		"error, list devices": {
+			sample:  map[string]time.Time{},
+			listErr: errors.New("list error"),
+			err:     "list error",
+		},
+		"error, inventory": {
+			sample: map[string]time.Time{
+				"1": indexedTs,
+				"2": indexedTs,
+				"3": indexedTs,
+			},
+			getErr: errors.New("inventory error"),
+			err:    "failed to get devices from inventory: inventory error",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := &store_mocks.Store{}
+			defer store.AssertExpectations(t)
+			ds := &store_mocks.DataStore{}
+			defer ds.AssertExpectations(t)
+			invClient := &inventory_mocks.Client{}
+			defer invClient.AssertExpectations(t)
+			devClient := &deviceauth_mocks.Client{}
+			defer devClient.AssertExpectations(t)
+
+			store.On("ListDevicesUpdatedAt", ctx, tenantID,
+				mock.AnythingOfType("string"), sampleSize).
+				Return(tc.sample, tc.listErr).Once()
+			if tc.sampleFirst != nil {
+				store.On("ListDevicesUpdatedAt", ctx, tenantID, "",
+					sampleSize-len(tc.sample)).
+					Return(tc.sampleFirst, nil).Once()
+			}
+			var sampled []string
+			for deviceID := range tc.sample {
+				sampled = append(sampled, deviceID)
+			}
+			for deviceID := range tc.sampleFirst {
+				sampled = append(sampled, deviceID)
+			}
+			if len(sampled) > 0 && tc.listErr == nil {
+				devices := []inventory.Device{}
+				for deviceID, updatedTs := range tc.inventory {
+					devices = append(devices, inventory.Device{
+						ID:        inventory.DeviceID(deviceID),
+						UpdatedTs: updatedTs,
+					})
+				}
+				invClient.On("GetDevices", ctx, tenantID, elementsMatch(sampled)).
+					Return(devices, tc.getErr).Once()
+			}
+			if len(tc.drifted) > 0 {
+				// the drifted devices were decommissioned: they are removed
+				devClient.On("GetDevices", ctx, tenantID, elementsMatch(tc.drifted)).
+					Return([]deviceauth.DeviceAuthDevice{}, nil).Once()
+				invClient.On("GetDevices", ctx, tenantID, elementsMatch(tc.drifted)).
+					Return([]inventory.Device{}, nil).Once()
+				ds.On("GetIndexingRules", ctx, tenantID).
+					Return(nil, nil).Once()
+				store.On("BulkIndexDevices", ctx, []*model.Device{},
+					mock.MatchedBy(func(removed []*model.Device) bool {
+						ids := make([]string, len(removed))
+						for i, device := range removed {
+							ids[i] = device.GetID()
+						}
+						sort.Strings(ids)
+						return assert.ObjectsAreEqual(tc.drifted, ids)
+					})).
+					Return(nil).Once()
+			}
+
+			indexer := NewIndexer(store, ds, nil, devClient, invClient, nil)
+			drifted, err := indexer.ReconcileDevices(ctx, tenantID, sampleSize)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, len(tc.drifted), drifted)
+		})
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/client/deployments"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	// ErrNoSyncCheckpoint is returned when catching up the devices of a
+	// tenant whose index was never rebuilt or caught up before
+	ErrNoSyncCheckpoint = errors.New(
+		"the tenant's index was never synchronized: specify the time to " +
+			"catch up from or rebuild the index")
+)
+
+// ReindexTenant rebuilds the tenant's devices index from scratch: the
+// devices are listed from inventory and indexed in a new index, which
+// replaces the current one once all the devices are indexed. The progress
+// is saved after each batch of devices, and an interrupted rebuild resumes
+// from the last batch, unless restart is true. The devices updated while
+// rebuilding are indexed again after swapping the index; the devices
+// removed in the meantime are not removed from the new index.
+func (i *indexer) ReindexTenant(
+	ctx context.Context,
+	tenantID string,
+	batchSize int,
+	restart bool,
+) error {
+	l := log.FromContext(ctx)
+	ctx = deployments.WithRequestTimeout(ctx, i.reindexTimeout)
+
+	state, err := i.ds.GetReindexState(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if state == nil || state.Status != model.ReindexStatusInProgress || restart {
+		index, err := i.store.CreateDevicesIndex(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		state = &model.ReindexState{
+			TenantID:  tenantID,
+			Index:     index,
+			Status:    model.ReindexStatusInProgress,
+			StartedTs: now,
+			UpdatedTs: now,
+		}
+		if err := i.ds.SaveReindexState(ctx, state); err != nil {
+			return err
+		}
+	} else {
+		l.Infof("resuming the reindex of the tenant %s in the index %s "+
+			"from the page %d", tenantID, state.Index, state.Page+1)
+	}
+
+	tuner := i.newBatchTuner(batchSize)
+	for page := state.Page + 1; ; page++ {
+		invDevices, err := i.invClient.ListDevices(ctx, tenantID, time.Time{},
+			page, batchSize)
+		if err != nil {
+			return errors.Wrap(err, "failed to list devices from inventory")
+		}
+		if len(invDevices) > 0 {
+			devices, removedDevices, err := i.buildPage(ctx, tenantID, invDevices)
+			if err != nil {
+				return err
+			}
+			err = i.bulkIndexBatches(ctx, tuner, devices,
+				func(ctx context.Context, batch []*model.Device) error {
+					return i.store.BulkIndexDevicesInto(ctx, state.Index, batch)
+				})
+			if err != nil {
+				return errors.Wrap(err, "failed to bulk index the devices")
+			}
+			err = i.indexSoftware(ctx, tenantID, invDevices, devices, removedDevices)
+			if err != nil {
+				return err
+			}
+			state.Page = page
+			state.LastDeviceID = string(invDevices[len(invDevices)-1].ID)
+			state.Processed += len(invDevices)
+			state.UpdatedTs = time.Now().UTC()
+			if err := i.ds.SaveReindexState(ctx, state); err != nil {
+				return err
+			}
+			l.Infof("reindexed %d devices of the tenant %s", state.Processed, tenantID)
+		}
+		if len(invDevices) < batchSize {
+			break
+		}
+	}
+
+	if err := i.store.SwapDevicesIndex(ctx, tenantID, state.Index); err != nil {
+		return err
+	}
+
+	// index again the devices updated while rebuilding the index
+	syncedTs := time.Now().UTC()
+	if _, err := i.indexUpdatedDevices(ctx, tenantID, state.StartedTs,
+		batchSize, tuner); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	state.Status = model.ReindexStatusCompleted
+	state.UpdatedTs = now
+	state.CompletedTs = &now
+	state.SyncedTs = &syncedTs
+	return i.ds.SaveReindexState(ctx, state)
+}
+
+// CatchUpTenant indexes the tenant's devices updated in inventory since the
+// given time, e.g. to catch up after a downtime of the indexer without
+// rebuilding the whole index; if since is zero, it catches up from the
+// last time the tenant's index was in sync, as recorded by the previous
+// rebuild or catch-up. The devices removed in the meantime are not
+// removed from the index.
+func (i *indexer) CatchUpTenant(
+	ctx context.Context,
+	tenantID string,
+	since time.Time,
+	batchSize int,
+) error {
+	l := log.FromContext(ctx)
+	ctx = deployments.WithRequestTimeout(ctx, i.reindexTimeout)
+
+	state, err := i.ds.GetReindexState(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if since.IsZero() {
+		if state == nil || state.SyncedTs == nil {
+			return ErrNoSyncCheckpoint
+		}
+		since = *state.SyncedTs
+	}
+	if state == nil {
+		state = &model.ReindexState{
+			TenantID: tenantID,
+			Status:   model.ReindexStatusCompleted,
+		}
+	}
+
+	syncedTs := time.Now().UTC()
+	processed, err := i.indexUpdatedDevices(ctx, tenantID, since, batchSize,
+		i.newBatchTuner(batchSize))
+	if err != nil {
+		return err
+	}
+	l.Infof("reindexed %d devices of the tenant %s updated since %s",
+		processed, tenantID, since.Format(time.RFC3339))
+
+	state.UpdatedTs = syncedTs
+	state.SyncedTs = &syncedTs
+	return i.ds.SaveReindexState(ctx, state)
+}
+
+// indexUpdatedDevices indexes in the tenant's devices index the devices
+// updated in inventory since the given time, returning their number
+func (i *indexer) indexUpdatedDevices(
+	ctx context.Context,
+	tenantID string,
+	since time.Time,
+	batchSize int,
+	tuner *batchTuner,
+) (int, error) {
+	var processed int
+	for page := 1; ; page++ {
+		invDevices, err := i.invClient.ListDevices(ctx, tenantID, since,
+			page, batchSize)
+		if err != nil {
+			return processed, errors.Wrap(err, "failed to list devices from inventory")
+		}
+		if len(invDevices) > 0 {
+			devices, removedDevices, err := i.buildPage(ctx, tenantID, invDevices)
+			if err != nil {
+				return processed, err
+			}
+			if tuner == nil {
+				err = i.store.BulkIndexDevices(ctx, devices, removedDevices)
+			} else {
+				err = i.bulkIndexBatches(ctx, tuner, devices,
+					func(ctx context.Context, batch []*model.Device) error {
+						return i.store.BulkIndexDevices(ctx, batch, nil)
+					})
+				if err == nil && len(removedDevices) > 0 {
+					err = i.store.BulkIndexDevices(ctx, nil, removedDevices)
+				}
+			}
+			if err != nil {
+				return processed, errors.Wrap(err, "failed to bulk index the devices")
+			}
+			err = i.indexSoftware(ctx, tenantID, invDevices, devices, removedDevices)
+			if err != nil {
+				return processed, err
+			}
+			processed += len(invDevices)
+		}
+		if len(invDevices) < batchSize {
+			return processed, nil
+		}
+	}
+}
+
+// newBatchTuner returns the tuner of the bulk requests of up to batchSize
+// devices, or nil if the adaptive reindex is disabled
+func (i *indexer) newBatchTuner(batchSize int) *batchTuner {
+	if i.reindexTargetLatency <= 0 {
+		return nil
+	}
+	return newBatchTuner(i.reindexTargetLatency, batchSize, i.reindexMaxConcurrency)
+}
+
+// bulkIndexBatches indexes the devices with the bulk function: at once if
+// the tuner is nil, or else in batches sent at the same time, as tuned by
+// the tuner; the batches rejected by the cluster are sent again, smaller,
+// until the tuner can't shrink them any further
+func (i *indexer) bulkIndexBatches(
+	ctx context.Context,
+	tuner *batchTuner,
+	devices []*model.Device,
+	bulk func(ctx context.Context, batch []*model.Device) error,
+) error {
+	if tuner == nil {
+		return bulk(ctx, devices)
+	}
+	for len(devices) > 0 {
+		batchSize, concurrency := tuner.Size()
+		var batches [][]*model.Device
+		for len(devices) > 0 && len(batches) < concurrency {
+			n := batchSize
+			if n > len(devices) {
+				n = len(devices)
+			}
+			batches = append(batches, devices[:n])
+			devices = devices[n:]
+		}
+
+		errs := make([]error, len(batches))
+		latencies := make([]time.Duration, len(batches))
+		var wg sync.WaitGroup
+		for j := range batches {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				start := time.Now()
+				errs[j] = bulk(ctx, batches[j])
+				latencies[j] = time.Since(start)
+			}(j)
+		}
+		wg.Wait()
+
+		var latency time.Duration
+		var rejected []*model.Device
+		var rejectedErr error
+		for j, err := range errs {
+			if latencies[j] > latency {
+				latency = latencies[j]
+			}
+			if errors.Is(err, store.ErrBulkRejected) {
+				rejected = append(rejected, batches[j]...)
+				rejectedErr = err
+			} else if err != nil {
+				return err
+			}
+		}
+		if rejectedErr != nil {
+			if tuner.AtMinimum() {
+				return rejectedErr
+			}
+			log.FromContext(ctx).Warnf("the cluster rejected %d devices, "+
+				"sending them again in smaller batches", len(rejected))
+		}
+		tuner.Observe(latency, rejectedErr != nil)
+		devices = append(rejected, devices...)
+	}
+	return nil
+}
+
+// buildPage builds the documents of a page of inventory devices
+func (i *indexer) buildPage(
+	ctx context.Context,
+	tenantID string,
+	invDevices []inventory.Device,
+) (devices, removedDevices []*model.Device, err error) {
+	deviceIDs := make([]string, len(invDevices))
+	for j, d := range invDevices {
+		deviceIDs[j] = string(d.ID)
+	}
+	deviceAuthDevices, err := i.devClient.GetDevices(ctx, tenantID, deviceIDs)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get devices from deviceauth")
+	}
+	return i.buildDevices(ctx, tenantID, deviceIDs, deviceAuthDevices, invDevices)
+}
@@ -0,0 +1,429 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	deployments_mocks "github.com/mendersoftware/reporting/client/deployments/mocks"
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	deviceauth_mocks "github.com/mendersoftware/reporting/client/deviceauth/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	inventory_mocks "github.com/mendersoftware/reporting/client/inventory/mocks"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+	store_mocks "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestReindexTenant(t *testing.T) {
+	const (
+		tenantID  = "tenant"
+		index     = "devices-tenant-000002"
+		batchSize = 2
+	)
+	startedTs := time.Now().Add(-time.Hour).UTC()
+
+	invDevices := []inventory.Device{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	devAuthDevices := []deviceauth.DeviceAuthDevice{
+		{ID: "1", Status: "accepted"},
+		{ID: "2", Status: "accepted"},
+		{ID: "3", Status: "accepted"},
+	}
+	indexedDevice := func(id string) *model.Device {
+		device := model.NewDevice(tenantID, id)
+		_ = device.AppendAttr(&model.InventoryAttribute{
+			Scope:  model.ScopeIdentity,
+			Name:   model.AttrNameStatus,
+			String: []string{"accepted"},
+		})
+		return device
+	}
+
+	testCases := map[string]struct {
+		state   *model.ReindexState
+		restart bool
+
+		createIndexErr error
+		listErr        error
+
+		// pages of devices indexed in the new index
+		pages [][]*model.Device
+		// the devices updated while rebuilding the index
+		updated []inventory.Device
+
+		err error
+	}{
+		"ok": {
+			pages: [][]*model.Device{
+				{indexedDevice("1"), indexedDevice("2")},
+				{indexedDevice("3")},
+			},
+		},
+		"ok, devices updated while rebuilding": {
+			pages: [][]*model.Device{
+				{indexedDevice("1"), indexedDevice("2")},
+				{indexedDevice("3")},
+			},
+			updated: []inventory.Device{{ID: "2"}},
+		},
+		"ok, resume": {
+			state: &model.ReindexState{
+				TenantID:  tenantID,
+				Index:     index,
+				Page:      1,
+				Processed: 2,
+				Status:    model.ReindexStatusInProgress,
+				StartedTs: startedTs,
+			},
+			pages: [][]*model.Device{
+				nil,
+				{indexedDevice("3")},
+			},
+		},
+		"ok, restart": {
+			state: &model.ReindexState{
+				TenantID:  tenantID,
+				Index:     "devices-tenant-000001",
+				Page:      1,
+				Processed: 2,
+				Status:    model.ReindexStatusInProgress,
+				StartedTs: startedTs,
+			},
+			restart: true,
+			pages: [][]*model.Device{
+				{indexedDevice("1"), indexedDevice("2")},
+				{indexedDevice("3")},
+			},
+		},
+		"error, not supported": {
+			createIndexErr: store.ErrIndexRebuildNotSupported,
+			err:            store.ErrIndexRebuildNotSupported,
+		},
+		"error, list devices": {
+			listErr: errors.New("inventory error"),
+			err:     errors.New("failed to list devices from inventory: inventory error"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ds := &store_mocks.DataStore{}
+			defer ds.AssertExpectations(t)
+			st := &store_mocks.Store{}
+			defer st.AssertExpectations(t)
+			invClient := &inventory_mocks.Client{}
+			defer invClient.AssertExpectations(t)
+			devClient := &deviceauth_mocks.Client{}
+			deplClient := &deployments_mocks.Client{}
+
+			ds.On("GetReindexState", contextMatcher, tenantID).
+				Return(tc.state, nil)
+			resume := tc.state != nil && !tc.restart
+			if !resume {
+				st.On("CreateDevicesIndex", contextMatcher, tenantID).
+					Return(index, tc.createIndexErr)
+			}
+			var saved *model.ReindexState
+			if tc.createIndexErr == nil {
+				ds.On("SaveReindexState", contextMatcher,
+					mock.AnythingOfType("*model.ReindexState")).
+					Run(func(args mock.Arguments) {
+						saved = args.Get(1).(*model.ReindexState)
+					}).
+					Return(nil)
+			}
+			ds.On("GetIndexingRules", contextMatcher, tenantID).
+				Return(nil, nil).Maybe()
+			ds.On("UpdateAndGetMapping", contextMatcher, tenantID, mock.Anything).
+				Return(&model.Mapping{TenantID: tenantID}, nil).Maybe()
+			deplClient.On("GetLatestFinishedDeployment",
+				contextMatcher, tenantID, mock.AnythingOfType("string")).
+				Return(nil, nil).Maybe()
+
+			for page, devices := range tc.pages {
+				if devices == nil {
+					continue
+				}
+				from := page * batchSize
+				to := from + batchSize
+				if to > len(invDevices) {
+					to = len(invDevices)
+				}
+				ids := make([]string, 0, to-from)
+				for _, d := range invDevices[from:to] {
+					ids = append(ids, string(d.ID))
+				}
+				invClient.On("ListDevices", contextMatcher, tenantID,
+					time.Time{}, page+1, batchSize).
+					Return(invDevices[from:to], nil).Once()
+				devClient.On("GetDevices", contextMatcher, tenantID, ids).
+					Return(devAuthDevices[from:to], nil).Once()
+				st.On("BulkIndexDevicesInto", contextMatcher, index, devices).
+					Return(nil).Once()
+			}
+			if tc.listErr != nil {
+				invClient.On("ListDevices", contextMatcher, tenantID,
+					time.Time{}, 1, batchSize).
+					Return(nil, tc.listErr).Once()
+			}
+			if tc.pages != nil {
+				st.On("SwapDevicesIndex", contextMatcher, tenantID, index).
+					Return(nil)
+				invClient.On("ListDevices", contextMatcher, tenantID,
+					mock.MatchedBy(func(ts time.Time) bool {
+						return !ts.IsZero()
+					}), 1, batchSize).
+					Return(tc.updated, nil).Once()
+				if len(tc.updated) > 0 {
+					devClient.On("GetDevices", contextMatcher, tenantID,
+						[]string{"2"}).
+						Return(devAuthDevices[1:2], nil).Once()
+					st.On("BulkIndexDevices", contextMatcher,
+						[]*model.Device{indexedDevice("2")},
+						[]*model.Device{}).
+						Return(nil).Once()
+				}
+			}
+
+			indexer := NewIndexer(st, ds, nil, devClient, invClient, deplClient)
+			err := indexer.ReindexTenant(ctx, tenantID, batchSize, tc.restart)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, model.ReindexStatusCompleted, saved.Status)
+				assert.Equal(t, "3", saved.LastDeviceID)
+			}
+		})
+	}
+}
+
+func TestCatchUpTenant(t *testing.T) {
+	const (
+		tenantID  = "tenant"
+		batchSize = 2
+	)
+	syncedTs := time.Now().Add(-time.Hour).UTC()
+	since := time.Now().Add(-2 * time.Hour).UTC()
+
+	invDevices := []inventory.Device{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	devAuthDevices := []deviceauth.DeviceAuthDevice{
+		{ID: "1", Status: "accepted"},
+		{ID: "2", Status: "accepted"},
+		{ID: "3", Status: "accepted"},
+	}
+
+	testCases := map[string]struct {
+		state *model.ReindexState
+		since time.Time
+
+		listErr error
+
+		// the time the devices are listed from
+		from time.Time
+		err  error
+	}{
+		"ok, from the checkpoint": {
+			state: &model.ReindexState{
+				TenantID: tenantID,
+				Status:   model.ReindexStatusCompleted,
+				SyncedTs: &syncedTs,
+			},
+			from: syncedTs,
+		},
+		"ok, since the given time": {
+			state: &model.ReindexState{
+				TenantID: tenantID,
+				Status:   model.ReindexStatusCompleted,
+				SyncedTs: &syncedTs,
+			},
+			since: since,
+			from:  since,
+		},
+		"ok, since the given time without checkpoint": {
+			since: since,
+			from:  since,
+		},
+		"error, no checkpoint": {
+			state: &model.ReindexState{
+				TenantID: tenantID,
+				Status:   model.ReindexStatusInProgress,
+			},
+			err: ErrNoSyncCheckpoint,
+		},
+		"error, list devices": {
+			since:   since,
+			from:    since,
+			listErr: errors.New("inventory error"),
+			err:     errors.New("failed to list devices from inventory: inventory error"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ds := &store_mocks.DataStore{}
+			defer ds.AssertExpectations(t)
+			st := &store_mocks.Store{}
+			defer st.AssertExpectations(t)
+			invClient := &inventory_mocks.Client{}
+			defer invClient.AssertExpectations(t)
+			devClient := &deviceauth_mocks.Client{}
+			deplClient := &deployments_mocks.Client{}
+
+			ds.On("GetReindexState", contextMatcher, tenantID).
+				Return(tc.state, nil)
+			ds.On("GetIndexingRules", contextMatcher, tenantID).
+				Return(nil, nil).Maybe()
+			ds.On("UpdateAndGetMapping", contextMatcher, tenantID, mock.Anything).
+				Return(&model.Mapping{TenantID: tenantID}, nil).Maybe()
+			deplClient.On("GetLatestFinishedDeployment",
+				contextMatcher, tenantID, mock.AnythingOfType("string")).
+				Return(nil, nil).Maybe()
+
+			if tc.listErr != nil {
+				invClient.On("ListDevices", contextMatcher, tenantID,
+					tc.from, 1, batchSize).
+					Return(nil, tc.listErr).Once()
+			} else if tc.err == nil {
+				for page := 1; (page-1)*batchSize < len(invDevices); page++ {
+					from := (page - 1) * batchSize
+					to := from + batchSize
+					if to > len(invDevices) {
+						to = len(invDevices)
+					}
+					ids := make([]string, 0, to-from)
+					for _, d := range invDevices[from:to] {
+						ids = append(ids, string(d.ID))
+					}
+					invClient.On("ListDevices", contextMatcher, tenantID,
+						tc.from, page, batchSize).
+						Return(invDevices[from:to], nil).Once()
+					devClient.On("GetDevices", contextMatcher, tenantID, ids).
+						Return(devAuthDevices[from:to], nil).Once()
+					st.On("BulkIndexDevices", contextMatcher,
+						mock.AnythingOfType("[]*model.Device"),
+						[]*model.Device{}).
+						Return(nil).Once()
+				}
+				ds.On("SaveReindexState", contextMatcher,
+					mock.MatchedBy(func(state *model.ReindexState) bool {
+						return state.TenantID == tenantID &&
+							state.SyncedTs != nil &&
+							state.SyncedTs.After(syncedTs)
+					})).
+					Return(nil)
+			}
+
+			indexer := NewIndexer(st, ds, nil, devClient, invClient, deplClient)
+			err := indexer.CatchUpTenant(ctx, tenantID, tc.since, batchSize)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBulkIndexBatches(t *testing.T) {
+	t.Parallel()
+
+	devices := make([]*model.Device, 25)
+	for j := range devices {
+		devices[j] = model.NewDevice("tenant", strconv.Itoa(j))
+	}
+	rejectedErr := errors.New("failed to bulk index: rejected")
+
+	testCases := map[string]struct {
+		tuner *batchTuner
+		// the bulk requests rejected by the cluster, in order
+		rejected []bool
+
+		batches []int
+		err     error
+	}{
+		"ok, not tuned": {
+			batches: []int{25},
+		},
+		"ok, tuned": {
+			tuner:   newBatchTuner(time.Hour, 20, 4),
+			batches: []int{20, 5},
+		},
+		"ok, rejected": {
+			tuner:    newBatchTuner(time.Hour, 20, 1),
+			rejected: []bool{true},
+			batches:  []int{20, 10, 15},
+		},
+		"error, rejected at the minimum": {
+			tuner:    newBatchTuner(time.Hour, minBulkBatchSize, 1),
+			rejected: []bool{true},
+			batches:  []int{minBulkBatchSize},
+			err:      rejectedErr,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var batches []int
+			indexed := map[string]bool{}
+			bulk := func(ctx context.Context, batch []*model.Device) error {
+				mu.Lock()
+				defer mu.Unlock()
+				n := len(batches)
+				batches = append(batches, len(batch))
+				if n < len(tc.rejected) && tc.rejected[n] {
+					return bulkRejected{rejectedErr}
+				}
+				for _, device := range batch {
+					indexed[device.GetID()] = true
+				}
+				return nil
+			}
+			i := &indexer{}
+			err := i.bulkIndexBatches(context.Background(), tc.tuner, devices, bulk)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, indexed, len(devices))
+			}
+			assert.Equal(t, tc.batches, batches)
+		})
+	}
+}
+
+// bulkRejected is the error of the bulk requests rejected by the cluster
+type bulkRejected struct {
+	error
+}
+
+func (err bulkRejected) Is(target error) bool {
+	return target == store.ErrBulkRejected
+}
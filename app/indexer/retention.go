@@ -0,0 +1,58 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+// retentionRoutine periodically deletes the deployments which finished
+// more than retention ago, starting right away
+func retentionRoutine(
+	ctx context.Context,
+	store store.Store,
+	retention time.Duration,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		expireDeployments(ctx, store, retention)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expireDeployments deletes the deployments which finished more than
+// retention ago
+func expireDeployments(ctx context.Context, store store.Store, retention time.Duration) {
+	l := log.FromContext(ctx)
+	cutoff := time.Now().Add(-retention)
+	if err := store.DeleteDeploymentsBefore(ctx, cutoff); err != nil {
+		l.Error(errors.Wrap(err, "failed to delete the expired deployments"))
+		return
+	}
+	l.Infof("deleted the deployments finished before %s", cutoff.Format(time.RFC3339))
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	store_mocks "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestRetentionRoutine(t *testing.T) {
+	const retention = 180 * 24 * time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := make(chan time.Time, 2)
+	store := &store_mocks.Store{}
+	defer store.AssertExpectations(t)
+	store.On("DeleteDeploymentsBefore", mock.Anything, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			calls <- args.Get(1).(time.Time)
+		}).
+		Return(errors.New("store error")).Once()
+	store.On("DeleteDeploymentsBefore", mock.Anything, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			calls <- args.Get(1).(time.Time)
+			cancel()
+		}).
+		Return(nil).Once()
+
+	done := make(chan struct{})
+	go func() {
+		retentionRoutine(ctx, store, retention, 10*time.Millisecond)
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case cutoff := <-calls:
+			assert.WithinDuration(t, time.Now().Add(-retention), cutoff, time.Minute)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the expired deployments to be deleted")
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the routine to return")
+	}
+}
@@ -18,19 +18,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/sys/unix"
 
 	"github.com/mendersoftware/go-lib-micro/config"
 	"github.com/mendersoftware/go-lib-micro/log"
 
-	"github.com/mendersoftware/reporting/client/deployments"
-	"github.com/mendersoftware/reporting/client/deviceauth"
-	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/client/enrichment"
 	"github.com/mendersoftware/reporting/client/nats"
 	rconfig "github.com/mendersoftware/reporting/config"
 	"github.com/mendersoftware/reporting/model"
@@ -38,34 +39,84 @@ import (
 )
 
 const (
-	jobsChanSize = 1000
+	metricsPath = "/metrics"
 )
 
 // InitAndRun initializes the indexer and runs it
 func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore, nats nats.Client) error {
+	// the jobs are received until the shutdown, and processed until they
+	// are drained: the in-flight writes are not cancelled by the shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
 
-	invClient := inventory.NewClient(
-		conf.GetString(rconfig.SettingInventoryAddr),
-	)
+	batchSize := conf.GetInt(rconfig.SettingReindexBatchSize)
+	if batchSize <= 0 {
+		return fmt.Errorf(
+			"%s: must be a positive integer",
+			rconfig.SettingReindexBatchSize,
+		)
+	}
+	workerConcurrency := conf.GetInt(rconfig.SettingWorkerConcurrency)
+	if workerConcurrency <= 0 {
+		return fmt.Errorf(
+			"%s: must be a positive integer",
+			rconfig.SettingWorkerConcurrency,
+		)
+	}
 
-	devClient := deviceauth.NewClient(
-		conf.GetString(rconfig.SettingDeviceAuthAddr),
-	)
+	devClient, invClient, deplClient, err := rconfig.ServiceClients(conf)
+	if err != nil {
+		return err
+	}
 
-	deplClient := deployments.NewClient(
-		conf.GetString(rconfig.SettingDeploymentsAddr),
-	)
+	if listen := conf.GetString(rconfig.SettingMetricsListen); listen != "" {
+		go serveMetrics(workCtx, listen)
+	}
 
-	indexer := NewIndexer(store, ds, nats, devClient, invClient, deplClient)
-	jobs := make(chan model.Job, jobsChanSize)
+	opts, err := indexerOptions(conf)
+	if err != nil {
+		return err
+	}
+	indexer := NewIndexer(store, ds, nats, devClient, invClient, deplClient, opts...)
+	pipeline, err := newJobsPipeline(ctx, conf, indexer)
+	if err != nil {
+		return err
+	}
+	cancelOnSignal(ctx, cancel)
 
-	err := indexer.GetJobs(ctx, jobs)
+	scheduled, err := scheduledRoutines(conf, store, ds, indexer, batchSize)
 	if err != nil {
 		return err
 	}
+	runScheduledRoutines(ctx, conf, nats, scheduled)
+
+	bp := newBackpressure(
+		time.Duration(conf.GetInt(rconfig.SettingBackpressureLatencyMsec))*time.Millisecond,
+		time.Duration(conf.GetInt(rconfig.SettingBackpressureMaxDelayMsec))*time.Millisecond,
+	)
+	pool := startWorkers(workCtx, indexer, bp, workerConcurrency, batchSize)
+
+	maxTime := time.Duration(conf.GetInt(rconfig.SettingReindexMaxTimeMsec)) *
+		time.Millisecond
+	jobsList, err := dispatchLoop(ctx, workCtx, pipeline.queued, pool, bp, maxTime)
+	cancel()
+	drainTimeout := time.Duration(conf.GetInt(rconfig.SettingShutdownDrainTimeoutMsec)) *
+		time.Millisecond
+	if drainErr := drainJobs(workCtx, drainTimeout, jobsList, pool.dispatch, &pool.workers,
+		pipeline.jobs, pipeline.priorityJobs); drainErr != nil {
+		return drainErr
+	}
+	if err == context.Canceled {
+		// shut down
+		return nil
+	}
+	return err
+}
 
+// cancelOnSignal cancels the context on SIGINT or SIGTERM
+func cancelOnSignal(ctx context.Context, cancel context.CancelFunc) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, unix.SIGINT, unix.SIGTERM)
 	go func() {
@@ -75,56 +126,360 @@ func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore, nats
 		case <-ctx.Done():
 		}
 	}()
+}
 
-	batchSize := conf.GetInt(rconfig.SettingReindexBatchSize)
-	if batchSize <= 0 {
-		return fmt.Errorf(
+// jobsPipeline are the channels of the jobs received by the indexer:
+// jobs and priorityJobs receive the jobs from the subscriptions, queued
+// the jobs to process, deduplicated, scheduled and prioritized
+type jobsPipeline struct {
+	jobs         chan model.Job
+	priorityJobs chan model.Job
+	queued       <-chan model.Job
+}
+
+// newJobsPipeline subscribes to the jobs and sets up the deduplication,
+// the scheduling and the priority lane of the jobs
+func newJobsPipeline(
+	ctx context.Context,
+	conf config.Reader,
+	indexer Indexer,
+) (*jobsPipeline, error) {
+	jobsQueueSize := conf.GetInt(rconfig.SettingJobsQueueSize)
+	if jobsQueueSize <= 0 {
+		return nil, fmt.Errorf(
 			"%s: must be a positive integer",
-			rconfig.SettingReindexBatchSize,
+			rconfig.SettingJobsQueueSize,
 		)
 	}
-	workerConcurrency := conf.GetInt(rconfig.SettingWorkerConcurrency)
-	if workerConcurrency <= 0 {
-		return fmt.Errorf(
-			"%s: must be a positive integer",
-			rconfig.SettingWorkerConcurrency,
+	jobsScheduler := conf.GetString(rconfig.SettingJobsScheduler)
+	if jobsScheduler != JobsSchedulerFIFO && jobsScheduler != JobsSchedulerRoundRobin {
+		return nil, fmt.Errorf(
+			"%s: must be one of %q or %q",
+			rconfig.SettingJobsScheduler,
+			JobsSchedulerFIFO, JobsSchedulerRoundRobin,
 		)
 	}
-	dispatch := make(chan []model.Job)
-	jobPool := make(chan []model.Job, workerConcurrency)
-	for i := 0; i < workerConcurrency; i++ {
-		jobPool <- make([]model.Job, batchSize)
-		go workerRoutine(ctx, strconv.Itoa(i+1), indexer, dispatch, jobPool)
+	pipeline := &jobsPipeline{
+		jobs:         make(chan model.Job, jobsQueueSize),
+		priorityJobs: make(chan model.Job, jobsQueueSize),
+	}
+	err := indexer.GetJobs(ctx, pipeline.jobs, pipeline.priorityJobs)
+	if err != nil {
+		return nil, err
+	}
+	bulkJobs := (<-chan model.Job)(pipeline.jobs)
+	if window := conf.GetInt(rconfig.SettingJobsDedupWindowMsec); window > 0 {
+		deduplicated := make(chan model.Job)
+		go dedupJobs(ctx, bulkJobs, deduplicated,
+			time.Duration(window)*time.Millisecond, jobsQueueSize)
+		bulkJobs = deduplicated
+	}
+	if jobsScheduler == JobsSchedulerRoundRobin {
+		scheduled := make(chan model.Job)
+		go scheduleJobs(ctx, bulkJobs, scheduled, jobsQueueSize)
+		bulkJobs = scheduled
+	}
+	// the priority jobs are processed before the bulk ones
+	queued := make(chan model.Job)
+	go prioritizeJobs(ctx, pipeline.priorityJobs, bulkJobs, queued)
+	pipeline.queued = queued
+	return pipeline, nil
+}
+
+// scheduledRoutines returns the scheduled scans enabled in the
+// configuration
+func scheduledRoutines(
+	conf config.Reader,
+	store store.Store,
+	ds store.DataStore,
+	indexer Indexer,
+	batchSize int,
+) ([]func(ctx context.Context), error) {
+	var scheduled []func(ctx context.Context)
+	sweepInterval := conf.GetInt(rconfig.SettingOrphanSweepIntervalMsec)
+	if sweepInterval > 0 {
+		scheduled = append(scheduled, func(ctx context.Context) {
+			sweepRoutine(ctx, indexer, ds,
+				time.Duration(sweepInterval)*time.Millisecond, batchSize)
+		})
+	}
+
+	reconcileInterval := conf.GetInt(rconfig.SettingReconcileIntervalMsec)
+	if reconcileInterval > 0 {
+		sampleSize := conf.GetInt(rconfig.SettingReconcileSampleSize)
+		if sampleSize <= 0 {
+			return nil, fmt.Errorf(
+				"%s: must be a positive integer",
+				rconfig.SettingReconcileSampleSize,
+			)
+		}
+		scheduled = append(scheduled, func(ctx context.Context) {
+			reconcileRoutine(ctx, indexer, ds,
+				time.Duration(reconcileInterval)*time.Millisecond, sampleSize)
+		})
+	}
+
+	retentionDays := conf.GetInt(rconfig.SettingDeploymentsRetentionDays)
+	if retentionDays > 0 {
+		retentionInterval := conf.GetInt(rconfig.SettingDeploymentsRetentionIntervalMsec)
+		if retentionInterval <= 0 {
+			return nil, fmt.Errorf(
+				"%s: must be a positive integer",
+				rconfig.SettingDeploymentsRetentionIntervalMsec,
+			)
+		}
+		scheduled = append(scheduled, func(ctx context.Context) {
+			retentionRoutine(ctx, store,
+				time.Duration(retentionDays)*24*time.Hour,
+				time.Duration(retentionInterval)*time.Millisecond)
+		})
 	}
 
-	maxTimeMs := conf.GetInt(rconfig.SettingReindexMaxTimeMsec)
-	tickerTimeout := time.Duration(maxTimeMs) * time.Millisecond
-	ticker := time.NewTimer(tickerTimeout)
-	jobsList := <-jobPool
+	purgeInterval := conf.GetInt(rconfig.SettingDecommissionedDevicesPurgeIntervalMsec)
+	if purgeInterval > 0 {
+		scheduled = append(scheduled, func(ctx context.Context) {
+			purgeRoutine(ctx, store, ds,
+				time.Duration(purgeInterval)*time.Millisecond, batchSize)
+		})
+	}
+	return scheduled, nil
+}
+
+// runScheduledRoutines runs the scheduled scans on the leader only, if
+// the leader election is enabled, or right away
+func runScheduledRoutines(
+	ctx context.Context,
+	conf config.Reader,
+	nats nats.Client,
+	scheduled []func(ctx context.Context),
+) {
+	runScheduled := func(ctx context.Context) {
+		for _, routine := range scheduled {
+			go routine(ctx)
+		}
+	}
+	leaderTTL := conf.GetInt(rconfig.SettingLeaderElectionTTLMsec)
+	if leaderTTL > 0 && len(scheduled) > 0 {
+		go leaderRoutine(ctx, nats, leaderHolder(),
+			time.Duration(leaderTTL)*time.Millisecond, runScheduled)
+	} else {
+		runScheduled(ctx)
+	}
+}
+
+// workerPool are the workers processing the batches of jobs sent on
+// dispatch; the batches are recycled through jobPool
+type workerPool struct {
+	dispatch chan []model.Job
+	jobPool  chan []model.Job
+	workers  sync.WaitGroup
+}
+
+// startWorkers starts the workers, each with a batch of up to batchSize
+// jobs
+func startWorkers(
+	ctx context.Context,
+	indexer Indexer,
+	bp *backpressure,
+	concurrency, batchSize int,
+) *workerPool {
+	pool := &workerPool{
+		dispatch: make(chan []model.Job),
+		jobPool:  make(chan []model.Job, concurrency),
+	}
+	for i := 0; i < concurrency; i++ {
+		pool.jobPool <- make([]model.Job, batchSize)
+		pool.workers.Add(1)
+		go func(name string) {
+			defer pool.workers.Done()
+			workerRoutine(ctx, name, indexer, bp, pool.dispatch, pool.jobPool)
+		}(strconv.Itoa(i + 1))
+	}
+	return pool
+}
+
+// dispatchLoop batches the queued jobs, dispatching the batches to the
+// workers once full or after maxTime, until ctx is done or the jobs are
+// closed; it returns the jobs not dispatched yet
+func dispatchLoop(
+	ctx, workCtx context.Context,
+	queued <-chan model.Job,
+	pool *workerPool,
+	bp *backpressure,
+	maxTime time.Duration,
+) ([]model.Job, error) {
+	ticker := time.NewTimer(maxTime)
+	jobsList := <-pool.jobPool
 	done := ctx.Done()
+	// after dispatching a batch, the consumption of the jobs is paused
+	// for the delay required by the backpressure, if any
+	jobsIn := queued
+	var resume <-chan time.Time
+	pause := func() {
+		if delay := bp.Delay(); delay > 0 {
+			jobsIn = nil
+			resume = time.After(delay)
+		}
+	}
+	var err error
 	for err == nil {
 		select {
 		case <-ticker.C:
-			ticker.Reset(tickerTimeout)
+			ticker.Reset(maxTime)
 			if len(jobsList) > 0 {
-				jobsList, err = dispatchJobs(ctx, jobsList, dispatch, jobPool)
+				jobsList, err = dispatchJobs(workCtx, jobsList, pool.dispatch, pool.jobPool)
+				pause()
 			}
 
-		case job, open := <-jobs:
+		case <-resume:
+			jobsIn = queued
+			resume = nil
+
+		case job, open := <-jobsIn:
 			if !open {
-				return errors.New("Jetstream closed")
+				// the subscriptions are closed on shutdown too
+				err = ctx.Err()
+				if err == nil {
+					err = errors.New("Jetstream closed")
+				}
+				break
 			}
 			jobsList = append(jobsList, job)
 			if len(jobsList) >= cap(jobsList) {
-				ticker.Reset(tickerTimeout)
-				jobsList, err = dispatchJobs(ctx, jobsList, dispatch, jobPool)
+				ticker.Reset(maxTime)
+				jobsList, err = dispatchJobs(workCtx, jobsList, pool.dispatch, pool.jobPool)
+				pause()
 			}
 
 		case <-done:
 			err = ctx.Err()
 		}
 	}
-	return err
+	return jobsList, err
+}
+
+// indexerOptions returns the options of the indexer from the configuration
+func indexerOptions(conf config.Reader) ([]Option, error) {
+	attributesFilter, err := rconfig.IndexingAttributesFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	redaction, err := rconfig.IndexingAttributesRedaction(conf)
+	if err != nil {
+		return nil, err
+	}
+	webhookURL := conf.GetString(rconfig.SettingEnrichmentWebhookURL)
+	stages := conf.GetStringSlice(rconfig.SettingIndexingEnrichmentStages)
+	webhookStage := false
+	for _, stage := range stages {
+		if !isEnrichmentStage(stage) {
+			return nil, fmt.Errorf("%s: unknown stage %q",
+				rconfig.SettingIndexingEnrichmentStages, stage)
+		}
+		webhookStage = webhookStage || stage == EnrichmentStageWebhook
+	}
+	var opts []Option
+	if webhookURL != "" {
+		tlsConfig, err := rconfig.ClientsTLSConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithEnrichmentWebhook(enrichment.NewClient(
+			webhookURL,
+			enrichment.WithTLSConfig(tlsConfig),
+			enrichment.WithToken(conf.GetString(rconfig.SettingEnrichmentWebhookToken)),
+			enrichment.WithTimeout(time.Duration(
+				conf.GetInt(rconfig.SettingEnrichmentWebhookTimeoutMsec))*time.Millisecond),
+		)))
+		if len(stages) > 0 && !webhookStage {
+			stages = append(stages, EnrichmentStageWebhook)
+		}
+	} else if webhookStage {
+		return nil, fmt.Errorf("%s: the %q stage requires %s",
+			rconfig.SettingIndexingEnrichmentStages, EnrichmentStageWebhook,
+			rconfig.SettingEnrichmentWebhookURL)
+	}
+	return append(opts,
+		WithEnrichmentStages(stages...),
+		WithLocationAttributes(
+			conf.GetString(rconfig.SettingLocationLatitudeAttribute),
+			conf.GetString(rconfig.SettingLocationLongitudeAttribute),
+		),
+		WithReindexTimeout(time.Duration(
+			conf.GetInt(rconfig.SettingReindexClientsTimeoutMsec))*time.Millisecond),
+		WithAttributesFilter(attributesFilter),
+		WithAttributesRedaction(redaction),
+		WithSoftwareInventory(conf.GetBool(rconfig.SettingIndexingSoftwareInventory)),
+		WithDecommissionGracePeriod(time.Duration(
+			conf.GetInt(rconfig.SettingDecommissionedDevicesGraceDays))*24*time.Hour),
+		WithAdaptiveReindex(time.Duration(
+			conf.GetInt(rconfig.SettingReindexBulkTargetLatencyMsec))*time.Millisecond,
+			conf.GetInt(rconfig.SettingReindexMaxConcurrency)),
+	), nil
+}
+
+// Reindex rebuilds the devices index of the tenant
+// ReindexOptions are the options of the reindex command
+type ReindexOptions struct {
+	// Restart restarts the rebuild from scratch instead of resuming it
+	Restart bool
+	// CatchUp indexes only the devices updated since the last time the
+	// index was in sync, or since CatchUpSince if set, instead of
+	// rebuilding the whole index
+	CatchUp      bool
+	CatchUpSince time.Time
+}
+
+func Reindex(
+	conf config.Reader,
+	store store.Store,
+	ds store.DataStore,
+	tenantID string,
+	opts ReindexOptions,
+) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelOnSignal(ctx, cancel)
+
+	batchSize := conf.GetInt(rconfig.SettingReindexBatchSize)
+	if batchSize <= 0 {
+		return fmt.Errorf(
+			"%s: must be a positive integer",
+			rconfig.SettingReindexBatchSize,
+		)
+	}
+
+	devClient, invClient, deplClient, err := rconfig.ServiceClients(conf)
+	if err != nil {
+		return err
+	}
+	indexerOpts, err := indexerOptions(conf)
+	if err != nil {
+		return err
+	}
+	indexer := NewIndexer(store, ds, nil, devClient, invClient, deplClient, indexerOpts...)
+	if opts.CatchUp {
+		return indexer.CatchUpTenant(ctx, tenantID, opts.CatchUpSince, batchSize)
+	}
+	return indexer.ReindexTenant(ctx, tenantID, batchSize, opts.Restart)
+}
+
+func serveMetrics(ctx context.Context, listen string) {
+	l := log.FromContext(ctx)
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.Handler())
+	srv := &http.Server{
+		Addr:    listen,
+		Handler: mux,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	l.Infof("serving metrics on %s%s", listen, metricsPath)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		l.Errorf("metrics: %s", err)
+	}
 }
 
 func dispatchJobs(ctx context.Context,
@@ -150,6 +505,7 @@ func workerRoutine(
 	ctx context.Context,
 	workerName string,
 	indexer Indexer,
+	bp *backpressure,
 	jobQ <-chan []model.Job,
 	jobPool chan<- []model.Job) {
 	l := log.FromContext(ctx)
@@ -158,7 +514,9 @@ func workerRoutine(
 	ctx = log.WithContext(ctx, l)
 	for jobs := range jobQ {
 		l.Infof("processing %d jobs", len(jobs))
+		start := time.Now()
 		indexer.ProcessJobs(ctx, jobs)
+		bp.Observe(time.Since(start))
 		jobPool <- jobs
 	}
 }
@@ -0,0 +1,166 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	// JobsSchedulerFIFO processes the jobs in the order they are received
+	JobsSchedulerFIFO = "fifo"
+	// JobsSchedulerRoundRobin processes the buffered jobs of the tenants
+	// in turns, one job of each tenant at a time
+	JobsSchedulerRoundRobin = "round_robin"
+)
+
+// fairQueue is the queue of the jobs of the tenants served in turns: the
+// jobs of each tenant are served in the order they were pushed, and the
+// tenants with pending jobs are served one job at a time, in the order
+// they were first pushed
+type fairQueue struct {
+	tenants []string
+	jobs    map[string][]model.Job
+	next    int
+	size    int
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{
+		jobs: map[string][]model.Job{},
+	}
+}
+
+// Len returns the number of jobs in the queue
+func (q *fairQueue) Len() int {
+	return q.size
+}
+
+// Push adds the job to the queue of its tenant
+func (q *fairQueue) Push(job model.Job) {
+	if len(q.jobs[job.TenantID]) == 0 {
+		q.tenants = append(q.tenants, job.TenantID)
+	}
+	q.jobs[job.TenantID] = append(q.jobs[job.TenantID], job)
+	q.size++
+}
+
+// Peek returns the next job to serve; the queue must not be empty
+func (q *fairQueue) Peek() model.Job {
+	return q.jobs[q.tenants[q.next]][0]
+}
+
+// Pop removes the next job to serve, and moves on to the next tenant
+func (q *fairQueue) Pop() {
+	tenant := q.tenants[q.next]
+	jobs := q.jobs[tenant]
+	jobs[0] = model.Job{}
+	q.size--
+	if len(jobs) == 1 {
+		delete(q.jobs, tenant)
+		q.tenants = append(q.tenants[:q.next], q.tenants[q.next+1:]...)
+	} else {
+		q.jobs[tenant] = jobs[1:]
+		q.next++
+	}
+	if q.next >= len(q.tenants) {
+		q.next = 0
+	}
+}
+
+// prioritizeJobs forwards the jobs of the priority lane and of the bulk
+// lane to out: the jobs of the bulk lane are forwarded only while no job
+// of the priority lane is waiting; out is closed once any lane is closed
+func prioritizeJobs(
+	ctx context.Context,
+	priority, bulk <-chan model.Job,
+	out chan<- model.Job,
+) {
+	done := ctx.Done()
+	for {
+		var job model.Job
+		var open bool
+		select {
+		case job, open = <-priority:
+		default:
+			select {
+			case job, open = <-priority:
+			case job, open = <-bulk:
+			case <-done:
+				return
+			}
+		}
+		if !open {
+			close(out)
+			return
+		}
+		select {
+		case out <- job:
+		case <-done:
+			nakJobs(ctx, job)
+			return
+		}
+	}
+}
+
+// scheduleJobs forwards the jobs received from in to out, reordered by
+// the fair queue: while the jobs are not consumed from out, e.g. because
+// all the workers are busy, up to capacity jobs are buffered, and the
+// tenants with a large backlog, e.g. backfilling their devices, take
+// turns with the others instead of delaying their jobs; out is closed
+// once in is closed and the buffered jobs are forwarded
+func scheduleJobs(
+	ctx context.Context,
+	in <-chan model.Job,
+	out chan<- model.Job,
+	capacity int,
+) {
+	queue := newFairQueue()
+	done := ctx.Done()
+	for in != nil || queue.Len() > 0 {
+		recv := in
+		if queue.Len() >= capacity {
+			recv = nil
+		}
+		var send chan<- model.Job
+		var next model.Job
+		if queue.Len() > 0 {
+			send = out
+			next = queue.Peek()
+		}
+		select {
+		case job, open := <-recv:
+			if !open {
+				in = nil
+				continue
+			}
+			queue.Push(job)
+
+		case send <- next:
+			queue.Pop()
+
+		case <-done:
+			// requeue the buffered jobs
+			for queue.Len() > 0 {
+				nakJobs(ctx, queue.Peek())
+				queue.Pop()
+			}
+			return
+		}
+	}
+	close(out)
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestFairQueue(t *testing.T) {
+	t.Parallel()
+
+	job := func(tenantID, deviceID string) model.Job {
+		return model.Job{TenantID: tenantID, DeviceID: deviceID}
+	}
+	testCases := map[string]struct {
+		jobs []model.Job
+		// the jobs pushed after popping the first one
+		late []model.Job
+
+		order []string
+	}{
+		"empty": {},
+		"one tenant": {
+			jobs:  []model.Job{job("t1", "1"), job("t1", "2"), job("t1", "3")},
+			order: []string{"1", "2", "3"},
+		},
+		"round robin": {
+			jobs: []model.Job{
+				job("t1", "1"), job("t1", "2"), job("t1", "3"), job("t1", "4"),
+				job("t2", "5"), job("t3", "6"), job("t2", "7"),
+			},
+			order: []string{"1", "5", "6", "2", "7", "3", "4"},
+		},
+		"jobs pushed while serving": {
+			jobs: []model.Job{
+				job("t1", "1"), job("t2", "2"), job("t1", "3"),
+			},
+			late:  []model.Job{job("t2", "4"), job("t3", "5")},
+			order: []string{"1", "2", "5", "3", "4"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := newFairQueue()
+			for _, job := range tc.jobs {
+				q.Push(job)
+			}
+			order := []string{}
+			for i := 0; q.Len() > 0; i++ {
+				order = append(order, q.Peek().DeviceID)
+				q.Pop()
+				if i == 0 {
+					for _, job := range tc.late {
+						q.Push(job)
+					}
+				}
+			}
+			assert.Equal(t, len(tc.order), len(order))
+			if len(tc.order) > 0 {
+				assert.Equal(t, tc.order, order)
+			}
+		})
+	}
+}
+
+func TestScheduleJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan model.Job, 10)
+	out := make(chan model.Job)
+	for i, tenantID := range []string{"t1", "t1", "t1", "t2", "t2", "t3"} {
+		in <- model.Job{TenantID: tenantID, DeviceID: string(rune('a' + i))}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		scheduleJobs(ctx, in, out, 10)
+		close(done)
+	}()
+	// let the scheduler buffer the backlog
+	time.Sleep(50 * time.Millisecond)
+	close(in)
+
+	var tenants []string
+	for job := range out {
+		tenants = append(tenants, job.TenantID)
+	}
+	<-done
+	assert.Equal(t, []string{"t1", "t2", "t3", "t1", "t2", "t1"}, tenants)
+}
+
+func TestPrioritizeJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	priority := make(chan model.Job, 10)
+	bulk := make(chan model.Job, 10)
+	out := make(chan model.Job)
+	for _, deviceID := range []string{"1", "2", "3"} {
+		bulk <- model.Job{DeviceID: deviceID}
+	}
+	for _, deviceID := range []string{"4", "5"} {
+		priority <- model.Job{DeviceID: deviceID}
+	}
+	go prioritizeJobs(ctx, priority, bulk, out)
+
+	var order []string
+	for i := 0; i < 5; i++ {
+		order = append(order, (<-out).DeviceID)
+	}
+	assert.Equal(t, []string{"4", "5", "1", "2", "3"}, order)
+
+	close(bulk)
+	_, open := <-out
+	assert.False(t, open)
+}
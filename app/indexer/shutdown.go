@@ -0,0 +1,108 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+var (
+	// errShuttingDown is the reason of the redelivery of the jobs not
+	// processed before the indexer shut down
+	errShuttingDown = errors.New("the indexer is shutting down")
+	// ErrDrainTimeout is returned when the in-flight jobs are not processed
+	// within the drain timeout
+	ErrDrainTimeout = errors.New("timeout draining the in-flight jobs")
+)
+
+// drainJobs dispatches the batch being filled to the workers, stops them
+// and waits, up to the timeout, for them to process the in-flight batches;
+// the jobs left in the queues are then requeued for redelivery
+func drainJobs(
+	ctx context.Context,
+	timeout time.Duration,
+	batch []model.Job,
+	dispatch chan<- []model.Job,
+	workers *sync.WaitGroup,
+	queues ...<-chan model.Job,
+) error {
+	l := log.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	l.Infof("draining %d jobs and the in-flight batches", len(batch))
+	if len(batch) > 0 {
+		select {
+		case dispatch <- batch:
+		case <-ctx.Done():
+			nakJobs(ctx, batch...)
+		}
+	}
+	close(dispatch)
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ErrDrainTimeout
+	}
+	requeued := 0
+	for _, queue := range queues {
+		requeued += nakQueue(ctx, queue)
+	}
+	if requeued > 0 {
+		l.Infof("requeued %d jobs for redelivery", requeued)
+	}
+	return err
+}
+
+// nakJobs requests the redelivery of the jobs not processed before the
+// shutdown
+func nakJobs(ctx context.Context, jobs ...model.Job) {
+	for _, job := range jobs {
+		if err := job.Nak(errShuttingDown); err != nil {
+			log.FromContext(ctx).Error(errors.Wrap(err, "failed to requeue the job"))
+		}
+	}
+}
+
+// nakQueue requests the redelivery of the jobs buffered in the queue,
+// without waiting for more, and returns their number
+func nakQueue(ctx context.Context, queue <-chan model.Job) int {
+	for n := 0; ; n++ {
+		select {
+		case job, open := <-queue:
+			if !open {
+				return n
+			}
+			nakJobs(ctx, job)
+		default:
+			return n
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestDrainJobs(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		workDuration time.Duration
+
+		err error
+	}{
+		"ok": {},
+		"error, timeout": {
+			workDuration: time.Minute,
+			err:          ErrDrainTimeout,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			batchJob := &jobAcknowledger{}
+			queuedJob := &jobAcknowledger{}
+			queue := make(chan model.Job, 2)
+			queue <- model.Job{Acknowledger: queuedJob}
+
+			dispatch := make(chan []model.Job)
+			processed := make(chan []model.Job, 1)
+			var workers sync.WaitGroup
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				for jobs := range dispatch {
+					processed <- jobs
+					select {
+					case <-time.After(tc.workDuration):
+					case <-ctx.Done():
+					}
+				}
+			}()
+
+			err := drainJobs(ctx, 100*time.Millisecond,
+				[]model.Job{{Acknowledger: batchJob}}, dispatch, &workers, queue)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			select {
+			case jobs := <-processed:
+				assert.Len(t, jobs, 1)
+			default:
+				assert.Fail(t, "the batch was not dispatched")
+			}
+			assert.Nil(t, batchJob.nakReason)
+			assert.Equal(t, errShuttingDown, queuedJob.nakReason)
+			assert.Len(t, queue, 0)
+		})
+	}
+}
+
+func TestScheduleJobsShutdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan model.Job, 2)
+	out := make(chan model.Job)
+	ack := &countingAcknowledger{}
+	in <- model.Job{TenantID: "tenant", Acknowledger: ack}
+
+	done := make(chan struct{})
+	go func() {
+		scheduleJobs(ctx, in, out, 10)
+		close(done)
+	}()
+	// wait for the job to be buffered
+	assert.Eventually(t, func() bool {
+		return len(in) == 0
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the scheduler to return")
+	}
+	assert.Equal(t, 1, ack.naks)
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+// SweepOrphanDevices deletes the tenant's indexed devices, and their
+// deployments history, which do not exist in deviceauth anymore, e.g.
+// because their decommissioning event was lost; it returns the number of
+// devices deleted
+func (i *indexer) SweepOrphanDevices(
+	ctx context.Context,
+	tenantID string,
+	batchSize int,
+) (int, error) {
+	var removed int
+	afterID := ""
+	for {
+		deviceIDs, err := i.store.ListDevicesIDs(ctx, tenantID, afterID, batchSize)
+		if err != nil {
+			return removed, err
+		}
+		if len(deviceIDs) == 0 {
+			return removed, nil
+		}
+		deviceAuthDevices, err := i.devClient.GetDevices(ctx, tenantID, deviceIDs)
+		if err != nil {
+			return removed, errors.Wrap(err, "failed to get devices from deviceauth")
+		}
+		existing := make(map[string]bool, len(deviceAuthDevices))
+		for _, d := range deviceAuthDevices {
+			existing[d.ID] = true
+		}
+		orphanIDs := make([]string, 0, len(deviceIDs))
+		for _, deviceID := range deviceIDs {
+			if !existing[deviceID] {
+				orphanIDs = append(orphanIDs, deviceID)
+			}
+		}
+		if len(orphanIDs) > 0 {
+			if err := i.deleteDevices(ctx, tenantID, orphanIDs); err != nil {
+				return removed, errors.Wrap(err, "failed to delete the orphan devices")
+			}
+			removed += len(orphanIDs)
+		}
+		if len(deviceIDs) < batchSize {
+			return removed, nil
+		}
+		afterID = deviceIDs[len(deviceIDs)-1]
+	}
+}
+
+// sweepRoutine periodically sweeps the orphan devices of all the tenants
+func sweepRoutine(
+	ctx context.Context,
+	indexer Indexer,
+	ds store.DataStore,
+	interval time.Duration,
+	batchSize int,
+) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		tenantIDs, err := ds.GetTenantIDs(ctx)
+		if err != nil {
+			l.Error(errors.Wrap(err, "failed to sweep the orphan devices"))
+			continue
+		}
+		for _, tenantID := range tenantIDs {
+			removed, err := indexer.SweepOrphanDevices(ctx, tenantID, batchSize)
+			if err != nil {
+				l.Error(errors.Wrapf(err,
+					"failed to sweep the orphan devices of the tenant %q", tenantID))
+			} else if removed > 0 {
+				l.Infof("removed %d orphan devices of the tenant %q", removed, tenantID)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	deviceauth_mocks "github.com/mendersoftware/reporting/client/deviceauth/mocks"
+	store_mocks "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestSweepOrphanDevices(t *testing.T) {
+	const (
+		tenantID  = "tenant"
+		batchSize = 2
+	)
+
+	testCases := map[string]struct {
+		// pages of the indexed devices
+		pages [][]string
+		// devices existing in deviceauth, by page
+		existing [][]string
+
+		listErr   error
+		getErr    error
+		deleteErr error
+
+		// devices deleted, by page
+		deleted [][]string
+		removed int
+		err     string
+	}{
+		"ok, no devices": {
+			pages: [][]string{{}},
+		},
+		"ok, no orphans": {
+			pages:    [][]string{{"1", "2"}, {"3"}},
+			existing: [][]string{{"1", "2"}, {"3"}},
+		},
+		"ok, orphans": {
+			pages:    [][]string{{"1", "2"}, {"3", "4"}, {}},
+			existing: [][]string{{"2"}, {}, nil},
+			deleted:  [][]string{{"1"}, {"3", "4"}},
+			removed:  3,
+		},
+		"error, list devices": {
+			pages:   [][]string{nil},
+			listErr: errors.New("list error"),
+			err:     "list error",
+		},
+		"error, deviceauth": {
+			pages:    [][]string{{"1"}},
+			existing: [][]string{nil},
+			getErr:   errors.New("deviceauth error"),
+			err:      "failed to get devices from deviceauth: deviceauth error",
+		},
+		"error, delete": {
+			pages:     [][]string{{"1"}},
+			existing:  [][]string{{}},
+			deleted:   [][]string{{"1"}},
+			deleteErr: errors.New("delete error"),
+			err:       "failed to delete the orphan devices: delete error",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := &store_mocks.Store{}
+			defer store.AssertExpectations(t)
+			devClient := &deviceauth_mocks.Client{}
+			defer devClient.AssertExpectations(t)
+
+			afterID := ""
+			for i, page := range tc.pages {
+				store.On("ListDevicesIDs", ctx, tenantID, afterID, batchSize).
+					Return(page, tc.listErr).Once()
+				if len(page) == 0 {
+					break
+				}
+				afterID = page[len(page)-1]
+				devices := []deviceauth.DeviceAuthDevice{}
+				for _, deviceID := range tc.existing[i] {
+					devices = append(devices, deviceauth.DeviceAuthDevice{ID: deviceID})
+				}
+				devClient.On("GetDevices", ctx, tenantID, page).
+					Return(devices, tc.getErr).Once()
+				if i < len(tc.deleted) {
+					store.On("DeleteDevicesData", ctx, tenantID, tc.deleted[i]).
+						Return(tc.deleteErr).Once()
+				}
+			}
+
+			indexer := NewIndexer(store, nil, nil, devClient, nil, nil)
+			removed, err := indexer.SweepOrphanDevices(ctx, tenantID, batchSize)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.removed, removed)
+		})
+	}
+}
@@ -14,7 +14,11 @@
 
 package indexer
 
-import "github.com/mendersoftware/reporting/model"
+import (
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
 
 func groupJobsIntoTenantActionIDs(jobs []model.Job) TenantActionIDs {
 	tenantsActionIDs := make(TenantActionIDs)
@@ -26,7 +30,9 @@ func groupJobsIntoTenantActionIDs(jobs []model.Job) TenantActionIDs {
 			tenantsActionIDs[job.TenantID][job.Action] = make(IDs)
 		}
 		var ID string
-		if job.Action == model.ActionReindex {
+		if job.Action == model.ActionReindex ||
+			job.Action == model.ActionUpdateDeviceStatus ||
+			job.Action == model.ActionDecommissionDevice {
 			ID = job.DeviceID
 		} else if job.Action == model.ActionReindexDeployment {
 			ID = job.ID
@@ -37,3 +43,37 @@ func groupJobsIntoTenantActionIDs(jobs []model.Job) TenantActionIDs {
 	}
 	return tenantsActionIDs
 }
+
+// groupDeviceStatuses returns the new status of the devices, by tenant and
+// device ID, from the deviceauth status change jobs; the latest job wins
+func groupDeviceStatuses(jobs []model.Job) map[string]map[string]string {
+	statuses := make(map[string]map[string]string)
+	for _, job := range jobs {
+		if job.Action != model.ActionUpdateDeviceStatus ||
+			job.DeviceID == "" || job.Status == "" {
+			continue
+		}
+		if _, ok := statuses[job.TenantID]; !ok {
+			statuses[job.TenantID] = make(map[string]string)
+		}
+		statuses[job.TenantID][job.DeviceID] = job.Status
+	}
+	return statuses
+}
+
+// indexingAttributes returns the values of the attributes of the device
+// the indexing rules are evaluated against
+func indexingAttributes(
+	deviceAuthDevice *deviceauth.DeviceAuthDevice,
+	inventoryDevice *inventory.Device,
+) model.AttributeValues {
+	attrs := model.AttributeValues{}
+	for _, attr := range inventoryDevice.Attributes {
+		attrs.Set(attr.Scope, attr.Name, attr.Value)
+	}
+	for name, value := range deviceAuthDevice.IdDataStruct {
+		attrs.Set(model.ScopeIdentity, name, value)
+	}
+	attrs.Set(model.ScopeIdentity, model.AttrNameStatus, deviceAuthDevice.Status)
+	return attrs
+}
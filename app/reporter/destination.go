@@ -0,0 +1,151 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/s3"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	DestinationS3      = "s3"
+	DestinationWebhook = "webhook"
+
+	hdrTenantID        = "X-Reporting-Tenant-ID"
+	hdrSavedSearchID   = "X-Reporting-Saved-Search-ID"
+	hdrSavedSearchName = "X-Reporting-Saved-Search-Name"
+	hdrGeneratedAt     = "X-Reporting-Generated-At"
+	hdrSignature       = "X-Reporting-Signature"
+
+	webhookTimeout = 5 * time.Minute
+)
+
+// Report describes a generated report
+type Report struct {
+	TenantID        string
+	SavedSearchID   string
+	SavedSearchName string
+	Format          string
+	GeneratedAt     time.Time
+}
+
+// ContentType returns the media type of the report
+func (r Report) ContentType() string {
+	if r.Format == model.ReportFormatJSON {
+		return "application/json"
+	}
+	return "text/csv"
+}
+
+// Filename returns the file name of the report, based on the generation time
+func (r Report) Filename() string {
+	return r.GeneratedAt.UTC().Format("20060102T150405Z") + "." + r.Format
+}
+
+// Destination delivers the generated reports
+type Destination interface {
+	Deliver(ctx context.Context, report Report, body io.ReadSeeker) error
+}
+
+type s3Destination struct {
+	client s3.Client
+	prefix string
+}
+
+// NewS3Destination returns a destination which uploads the reports to an
+// S3-compatible bucket, as <prefix><tenant ID>/<saved search ID>/<time>.<format>
+func NewS3Destination(client s3.Client, prefix string) Destination {
+	return &s3Destination{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (d *s3Destination) Deliver(ctx context.Context, report Report, body io.ReadSeeker) error {
+	key := d.prefix + report.TenantID + "/" + report.SavedSearchID + "/" + report.Filename()
+	return d.client.PutObject(ctx, key, body, report.ContentType())
+}
+
+type webhookDestination struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+// NewWebhookDestination returns a destination which POSTs the reports to
+// a webhook; if secret is not empty, the request carries the HMAC-SHA256
+// signature of the body in the X-Reporting-Signature header
+func NewWebhookDestination(url, secret string) Destination {
+	return &webhookDestination{
+		client: &http.Client{},
+		url:    url,
+		secret: secret,
+	}
+}
+
+func (d *webhookDestination) Deliver(
+	ctx context.Context,
+	report Report,
+	body io.ReadSeeker,
+) error {
+	var signature string
+	if d.secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.secret))
+		if _, err := io.Copy(mac, body); err != nil {
+			return errors.Wrap(err, "failed to read the report")
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return errors.Wrap(err, "failed to read the report")
+		}
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, io.NopCloser(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", report.ContentType())
+	req.Header.Set(hdrTenantID, report.TenantID)
+	req.Header.Set(hdrSavedSearchID, report.SavedSearchID)
+	req.Header.Set(hdrSavedSearchName, report.SavedSearchName)
+	req.Header.Set(hdrGeneratedAt, report.GeneratedAt.UTC().Format(time.RFC3339))
+	if signature != "" {
+		req.Header.Set(hdrSignature, signature)
+	}
+
+	rsp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to deliver the report")
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode/100 != 2 {
+		return errors.Errorf("failed to deliver the report, status %d", rsp.StatusCode)
+	}
+	return nil
+}
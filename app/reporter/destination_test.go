@@ -0,0 +1,119 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	ms3 "github.com/mendersoftware/reporting/client/s3/mocks"
+	"github.com/mendersoftware/reporting/model"
+)
+
+var testReport = Report{
+	TenantID:        "tenant",
+	SavedSearchID:   "a3c6f3e4-5c4a-4d2e-8d4b-2e2c0c6d8a3b",
+	SavedSearchName: "production",
+	Format:          model.ReportFormatCSV,
+	GeneratedAt:     time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC),
+}
+
+func TestS3Destination(t *testing.T) {
+	t.Parallel()
+
+	body := strings.NewReader("id\n")
+	client := new(ms3.Client)
+	defer client.AssertExpectations(t)
+	client.On("PutObject", contextMatcher,
+		"reports/tenant/a3c6f3e4-5c4a-4d2e-8d4b-2e2c0c6d8a3b/20230306T060000Z.csv",
+		mock.Anything,
+		"text/csv").
+		Return(nil)
+
+	destination := NewS3Destination(client, "reports/")
+	err := destination.Deliver(context.Background(), testReport, body)
+	assert.NoError(t, err)
+}
+
+func TestWebhookDestination(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		secret string
+		status int
+		err    string
+	}{
+		"ok": {
+			status: http.StatusOK,
+		},
+		"ok, signed": {
+			secret: "secret",
+			status: http.StatusAccepted,
+		},
+		"ko, webhook error": {
+			status: http.StatusInternalServerError,
+			err:    "failed to deliver the report, status 500",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					assert.Equal(t, "id\n", string(body))
+					assert.Equal(t, "text/csv", r.Header.Get("Content-Type"))
+					assert.Equal(t, testReport.TenantID, r.Header.Get(hdrTenantID))
+					assert.Equal(t, testReport.SavedSearchID,
+						r.Header.Get(hdrSavedSearchID))
+					assert.Equal(t, "2023-03-06T06:00:00Z", r.Header.Get(hdrGeneratedAt))
+					if tc.secret != "" {
+						mac := hmac.New(sha256.New, []byte(tc.secret))
+						mac.Write(body)
+						assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)),
+							r.Header.Get(hdrSignature))
+					} else {
+						assert.Empty(t, r.Header.Get(hdrSignature))
+					}
+					w.WriteHeader(tc.status)
+				},
+			))
+			defer srv.Close()
+
+			destination := NewWebhookDestination(srv.URL, tc.secret)
+			err := destination.Deliver(context.Background(), testReport,
+				strings.NewReader("id\n"))
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
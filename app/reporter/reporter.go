@@ -0,0 +1,158 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporter
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// Reporter generates the scheduled reports of the saved searches
+type Reporter struct {
+	reporting   reporting.App
+	ds          store.DataStore
+	destination Destination
+	now         func() time.Time
+}
+
+// NewReporter returns a new Reporter
+func NewReporter(
+	reporting reporting.App,
+	ds store.DataStore,
+	destination Destination,
+) *Reporter {
+	return &Reporter{
+		reporting:   reporting,
+		ds:          ds,
+		destination: destination,
+		now:         time.Now,
+	}
+}
+
+// Run checks for due reports every interval, until the context is canceled
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue generates the reports which are due; every run is claimed in
+// the data store first, so that multiple reporters can run concurrently
+func (r *Reporter) runDue(ctx context.Context) {
+	l := log.FromContext(ctx)
+
+	searches, err := r.ds.GetScheduledSavedSearches(ctx)
+	if err != nil {
+		l.Errorf("failed to get the scheduled saved searches: %s", err)
+		return
+	}
+
+	now := r.now().UTC().Truncate(time.Second)
+	for i := range searches {
+		search := &searches[i]
+		l := l.F(log.Ctx{
+			"tenant_id":       search.TenantID,
+			"saved_search_id": search.ID,
+		})
+		next, err := search.NextRun()
+		if err != nil {
+			l.Errorf("failed to schedule the report: %s", err)
+			continue
+		} else if next.After(now) {
+			continue
+		}
+		claimed, err := r.ds.ClaimSavedSearchReport(ctx, search, now)
+		if err != nil {
+			l.Errorf("failed to claim the report: %s", err)
+			continue
+		} else if !claimed {
+			// another reporter is generating it
+			continue
+		}
+		if err := r.generate(ctx, search, now); err != nil {
+			l.Errorf("failed to generate the report: %s", err)
+			continue
+		}
+		l.Infof("generated the report scheduled at %s", next.Format(time.RFC3339))
+	}
+}
+
+// generate renders the results of the saved search to a temporary file
+// and delivers it to the destination; the search runs on behalf of the
+// tenant of the saved search
+func (r *Reporter) generate(
+	ctx context.Context,
+	search *model.SavedSearch,
+	generatedAt time.Time,
+) error {
+	ctx = identity.WithContext(ctx, &identity.Identity{Tenant: search.TenantID})
+	f, err := os.CreateTemp("", "report-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the report file")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	columns, err := reporting.ExportColumns(ctx, r.reporting, search.TenantID,
+		search.Attributes)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(f)
+	w, err := reporting.NewDevicesWriter(buf, search.Report.Format, columns)
+	if err != nil {
+		return err
+	}
+	err = r.reporting.ExportDevices(ctx, search.SearchParams(), w.Write)
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the report file")
+	}
+	if err := buf.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write the report file")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to read the report file")
+	}
+
+	return r.destination.Deliver(ctx, Report{
+		TenantID:        search.TenantID,
+		SavedSearchID:   search.ID,
+		SavedSearchName: search.Name,
+		Format:          search.Report.Format,
+		GeneratedAt:     generatedAt,
+	}, f)
+}
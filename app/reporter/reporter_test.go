@@ -0,0 +1,217 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	mapp "github.com/mendersoftware/reporting/app/reporting/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+var contextMatcher = mock.MatchedBy(func(_ context.Context) bool { return true })
+
+// tenantMatcher matches the contexts with the identity of the tenant
+var tenantMatcher = mock.MatchedBy(func(ctx context.Context) bool {
+	id := identity.FromContext(ctx)
+	return id != nil && id.Tenant == "tenant"
+})
+
+type mockDestination struct {
+	mock.Mock
+}
+
+func (m *mockDestination) Deliver(ctx context.Context, report Report, body io.ReadSeeker) error {
+	ret := m.Called(ctx, report, body)
+	return ret.Error(0)
+}
+
+func TestRunDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 3, 6, 6, 0, 30, 0, time.UTC)
+	updated := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+	lastRun := time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC)
+	newSearch := func(id, format string, lastRun *time.Time) model.SavedSearch {
+		return model.SavedSearch{
+			ID:       id,
+			TenantID: "tenant",
+			Name:     "search " + id,
+			Attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "ip4",
+			}},
+			Report: &model.SavedSearchReport{
+				Schedule:  "0 6 * * 1",
+				Format:    format,
+				LastRunTs: lastRun,
+			},
+			UpdatedTs: updated,
+		}
+	}
+	exportDevices := func(args mock.Arguments) {
+		fn := args.Get(2).(func([]inventory.Device) error)
+		_ = fn([]inventory.Device{{
+			ID: "5975e1e6-49a6-4218-a46d-f181154a98cc",
+			Attributes: inventory.DeviceAttributes{{
+				Scope: model.ScopeInventory,
+				Name:  "ip4",
+				Value: "10.0.0.2",
+			}},
+		}})
+	}
+
+	type testCase struct {
+		Name string
+
+		DataStore   func(*testing.T, testCase) *mstore.DataStore
+		App         func(*testing.T, testCase) *mapp.App
+		Destination func(*testing.T, testCase) *mockDestination
+	}
+	testCases := []testCase{{
+		Name: "ok, due report generated",
+
+		DataStore: func(t *testing.T, self testCase) *mstore.DataStore {
+			ds := new(mstore.DataStore)
+			ds.On("GetScheduledSavedSearches", contextMatcher).
+				Return([]model.SavedSearch{
+					newSearch("due", model.ReportFormatCSV, nil),
+					newSearch("not-due", model.ReportFormatCSV, &lastRun),
+				}, nil)
+			ds.On("ClaimSavedSearchReport", contextMatcher,
+				mock.MatchedBy(func(s *model.SavedSearch) bool {
+					return s.ID == "due"
+				}),
+				now.Truncate(time.Second)).
+				Return(true, nil)
+			return ds
+		},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			// the reporter runs without identity: the searches run on
+			// behalf of the tenants of the saved searches
+			app.On("ExportDevices", tenantMatcher,
+				mock.MatchedBy(func(params *model.SearchParams) bool {
+					return params.TenantID == "tenant"
+				}),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Run(exportDevices).
+				Return(nil)
+			return app
+		},
+		Destination: func(t *testing.T, self testCase) *mockDestination {
+			destination := new(mockDestination)
+			destination.On("Deliver", contextMatcher,
+				Report{
+					TenantID:        "tenant",
+					SavedSearchID:   "due",
+					SavedSearchName: "search due",
+					Format:          model.ReportFormatCSV,
+					GeneratedAt:     now.Truncate(time.Second),
+				},
+				mock.Anything).
+				Run(func(args mock.Arguments) {
+					b, _ := io.ReadAll(args.Get(2).(io.ReadSeeker))
+					assert.Equal(t,
+						"id,inventory:ip4\n"+
+							"5975e1e6-49a6-4218-a46d-f181154a98cc,10.0.0.2\n",
+						string(b))
+				}).
+				Return(nil)
+			return destination
+		},
+	}, {
+		Name: "ok, report claimed by another reporter",
+
+		DataStore: func(t *testing.T, self testCase) *mstore.DataStore {
+			ds := new(mstore.DataStore)
+			ds.On("GetScheduledSavedSearches", contextMatcher).
+				Return([]model.SavedSearch{
+					newSearch("due", model.ReportFormatJSON, nil),
+				}, nil)
+			ds.On("ClaimSavedSearchReport", contextMatcher,
+				mock.AnythingOfType("*model.SavedSearch"),
+				now.Truncate(time.Second)).
+				Return(false, nil)
+			return ds
+		},
+	}, {
+		Name: "error, export failed",
+
+		DataStore: func(t *testing.T, self testCase) *mstore.DataStore {
+			ds := new(mstore.DataStore)
+			ds.On("GetScheduledSavedSearches", contextMatcher).
+				Return([]model.SavedSearch{
+					newSearch("due", model.ReportFormatJSON, nil),
+				}, nil)
+			ds.On("ClaimSavedSearchReport", contextMatcher,
+				mock.AnythingOfType("*model.SavedSearch"),
+				now.Truncate(time.Second)).
+				Return(true, nil)
+			return ds
+		},
+		App: func(t *testing.T, self testCase) *mapp.App {
+			app := new(mapp.App)
+			app.On("ExportDevices", contextMatcher,
+				mock.AnythingOfType("*model.SearchParams"),
+				mock.AnythingOfType("func([]inventory.Device) error")).
+				Return(errors.New("internal error"))
+			return app
+		},
+	}, {
+		Name: "error, failed to get the saved searches",
+
+		DataStore: func(t *testing.T, self testCase) *mstore.DataStore {
+			ds := new(mstore.DataStore)
+			ds.On("GetScheduledSavedSearches", contextMatcher).
+				Return(nil, errors.New("internal error"))
+			return ds
+		},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ds := tc.DataStore(t, tc)
+			defer ds.AssertExpectations(t)
+			app := new(mapp.App)
+			if tc.App != nil {
+				app = tc.App(t, tc)
+			}
+			defer app.AssertExpectations(t)
+			destination := new(mockDestination)
+			if tc.Destination != nil {
+				destination = tc.Destination(t, tc)
+			}
+			defer destination.AssertExpectations(t)
+
+			reporter := NewReporter(app, ds, destination)
+			reporter.now = func() time.Time { return now }
+			reporter.runDue(context.Background())
+		})
+	}
+}
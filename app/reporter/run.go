@@ -0,0 +1,113 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/client/s3"
+	"github.com/mendersoftware/reporting/client/sigv4"
+	rconfig "github.com/mendersoftware/reporting/config"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// InitAndRun initializes the reporter and runs it
+func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	destination, err := newDestination(conf)
+	if err != nil {
+		return err
+	}
+
+	interval := time.Duration(conf.GetInt(rconfig.SettingReporterIntervalMsec)) *
+		time.Millisecond
+	if interval <= 0 {
+		return fmt.Errorf(
+			"%s: must be a positive integer",
+			rconfig.SettingReporterIntervalMsec,
+		)
+	}
+
+	redaction, err := rconfig.IndexingAttributesRedaction(conf)
+	if err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, unix.SIGINT, unix.SIGTERM)
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.FromContext(ctx).Infof("reporter: checking for due reports every %s", interval)
+	app := reporting.NewApp(store, ds, reporting.WithAttributesRedaction(redaction))
+	reporter := NewReporter(app, ds, destination)
+	err = reporter.Run(ctx, interval)
+	if err == context.Canceled {
+		err = nil
+	}
+	return err
+}
+
+func newDestination(conf config.Reader) (Destination, error) {
+	switch destination := conf.GetString(rconfig.SettingReportDestination); destination {
+	case DestinationS3:
+		bucket := conf.GetString(rconfig.SettingReportS3Bucket)
+		if bucket == "" {
+			return nil, fmt.Errorf("%s: must not be empty", rconfig.SettingReportS3Bucket)
+		}
+		client := s3.NewClient(
+			conf.GetString(rconfig.SettingReportS3Endpoint),
+			conf.GetString(rconfig.SettingReportS3Region),
+			bucket,
+			sigv4.Credentials{
+				AccessKeyID:     conf.GetString(rconfig.SettingReportS3AccessKeyID),
+				SecretAccessKey: conf.GetString(rconfig.SettingReportS3SecretAccessKey),
+			},
+		)
+		return NewS3Destination(client, conf.GetString(rconfig.SettingReportS3Prefix)), nil
+
+	case DestinationWebhook:
+		url := conf.GetString(rconfig.SettingReportWebhookURL)
+		if url == "" {
+			return nil, fmt.Errorf("%s: must not be empty", rconfig.SettingReportWebhookURL)
+		}
+		return NewWebhookDestination(url,
+			conf.GetString(rconfig.SettingReportWebhookSecret)), nil
+
+	default:
+		return nil, fmt.Errorf(
+			"%s: unsupported destination %q, must be %q or %q",
+			rconfig.SettingReportDestination, destination,
+			DestinationS3, DestinationWebhook,
+		)
+	}
+}
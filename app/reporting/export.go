@@ -0,0 +1,288 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/utils/parquet"
+)
+
+// DevicesWriter renders pages of devices, as returned by ExportDevices,
+// to an output stream
+type DevicesWriter interface {
+	// Write renders a page of devices
+	Write(devs []inventory.Device) error
+	// Close completes the output; it doesn't close the underlying writer
+	Close() error
+}
+
+// NewDevicesWriter returns a DevicesWriter for the given format (csv or
+// json); the CSV output has a column with the device ID followed by a
+// column per attribute in columns
+func NewDevicesWriter(
+	w io.Writer,
+	format string,
+	columns []model.SelectAttribute,
+) (DevicesWriter, error) {
+	switch format {
+	case model.ReportFormatCSV:
+		return &csvDevicesWriter{
+			w:       csv.NewWriter(w),
+			columns: columns,
+		}, nil
+	case model.ReportFormatJSON:
+		return &jsonDevicesWriter{
+			w: w,
+		}, nil
+	}
+	return nil, errors.Errorf("unsupported export format: %s", format)
+}
+
+type csvDevicesWriter struct {
+	w             *csv.Writer
+	columns       []model.SelectAttribute
+	headerWritten bool
+}
+
+func (cw *csvDevicesWriter) writeHeader() error {
+	if cw.headerWritten {
+		return nil
+	}
+	cw.headerWritten = true
+	header := make([]string, 0, len(cw.columns)+1)
+	header = append(header, model.FieldNameID)
+	for _, col := range cw.columns {
+		header = append(header, col.Scope+":"+col.Attribute)
+	}
+	return cw.w.Write(header)
+}
+
+func (cw *csvDevicesWriter) Write(devs []inventory.Device) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	for _, dev := range devs {
+		record := make([]string, 0, len(cw.columns)+1)
+		record = append(record, string(dev.ID))
+		for _, col := range cw.columns {
+			value := ""
+			for _, attr := range dev.Attributes {
+				if attr.Scope == col.Scope && attr.Name == col.Attribute {
+					value = csvValue(attr.Value)
+					break
+				}
+			}
+			record = append(record, value)
+		}
+		if err := cw.w.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvDevicesWriter) Close() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func csvValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []interface{}:
+		values := make([]string, len(value))
+		for i, item := range value {
+			values[i] = csvValue(item)
+		}
+		return strings.Join(values, ",")
+	case []string:
+		return strings.Join(value, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+type jsonDevicesWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (jw *jsonDevicesWriter) Write(devs []inventory.Device) error {
+	for _, dev := range devs {
+		sep := ","
+		if jw.count == 0 {
+			sep = "["
+		}
+		b, err := json.Marshal(dev)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(jw.w, sep); err != nil {
+			return err
+		}
+		if _, err := jw.w.Write(b); err != nil {
+			return err
+		}
+		jw.count++
+	}
+	return nil
+}
+
+func (jw *jsonDevicesWriter) Close() error {
+	end := "]"
+	if jw.count == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(jw.w, end)
+	return err
+}
+
+type parquetDevicesWriter struct {
+	w       *parquet.Writer
+	columns []model.SelectAttribute
+	types   []parquet.ColumnType
+}
+
+// NewParquetDevicesWriter returns a DevicesWriter rendering the devices as
+// a Parquet file, with a column with the device ID followed by a column
+// per attribute in columns, typed by types: the multiple values of the
+// string attributes are comma-separated, the numeric and boolean columns
+// keep the first value
+func NewParquetDevicesWriter(
+	w io.Writer,
+	columns []model.SelectAttribute,
+	types []model.Type,
+) DevicesWriter {
+	parquetColumns := make([]parquet.Column, 0, len(columns)+1)
+	parquetColumns = append(parquetColumns, parquet.Column{
+		Name: model.FieldNameID,
+		Type: parquet.String,
+	})
+	parquetTypes := make([]parquet.ColumnType, len(columns))
+	for i, col := range columns {
+		switch types[i] {
+		case model.TypeNum:
+			parquetTypes[i] = parquet.Double
+		case model.TypeBool:
+			parquetTypes[i] = parquet.Boolean
+		default:
+			parquetTypes[i] = parquet.String
+		}
+		parquetColumns = append(parquetColumns, parquet.Column{
+			Name: col.Scope + ":" + col.Attribute,
+			Type: parquetTypes[i],
+		})
+	}
+	return &parquetDevicesWriter{
+		w:       parquet.NewWriter(w, parquetColumns),
+		columns: columns,
+		types:   parquetTypes,
+	}
+}
+
+func (pw *parquetDevicesWriter) Write(devs []inventory.Device) error {
+	for _, dev := range devs {
+		row := make([]interface{}, 0, len(pw.columns)+1)
+		row = append(row, string(dev.ID))
+		for i, col := range pw.columns {
+			var value interface{}
+			for _, attr := range dev.Attributes {
+				if attr.Scope == col.Scope && attr.Name == col.Attribute {
+					value = parquetValue(attr.Value, pw.types[i])
+					break
+				}
+			}
+			row = append(row, value)
+		}
+		if err := pw.w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pw *parquetDevicesWriter) Close() error {
+	return pw.w.Close()
+}
+
+// parquetValue converts the value of an attribute to the type of the
+// column, returning nil if it can't
+func parquetValue(v interface{}, typ parquet.ColumnType) interface{} {
+	if v == nil {
+		return nil
+	}
+	if typ == parquet.String {
+		return csvValue(v)
+	}
+	if values, ok := v.([]interface{}); ok {
+		if len(values) == 0 {
+			return nil
+		}
+		v = values[0]
+	}
+	switch value := v.(type) {
+	case float64:
+		if typ == parquet.Double {
+			return value
+		}
+	case bool:
+		if typ == parquet.Boolean {
+			return value
+		}
+	}
+	return nil
+}
+
+// ExportColumns returns the columns of an export: a copy of the selected
+// attributes or, if none is selected, all the searchable attributes
+func ExportColumns(
+	ctx context.Context,
+	app App,
+	tenantID string,
+	selected []model.SelectAttribute,
+) ([]model.SelectAttribute, error) {
+	columns := make([]model.SelectAttribute, len(selected))
+	copy(columns, selected)
+	if len(columns) > 0 {
+		return columns, nil
+	}
+	attrs, err := app.GetSearchableInvAttrs(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range attrs {
+		columns = append(columns, model.SelectAttribute{
+			Scope:     attr.Scope,
+			Attribute: attr.Name,
+		})
+	}
+	return columns, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestDevicesWriter(t *testing.T) {
+	t.Parallel()
+
+	columns := []model.SelectAttribute{{
+		Scope:     model.ScopeInventory,
+		Attribute: "ip4",
+	}, {
+		Scope:     model.ScopeInventory,
+		Attribute: "mem",
+	}}
+	pages := [][]inventory.Device{{{
+		ID: "5975e1e6-49a6-4218-a46d-f181154a98cc",
+		Attributes: inventory.DeviceAttributes{{
+			Scope: model.ScopeInventory,
+			Name:  "ip4",
+			Value: []interface{}{"10.0.0.2", "10.0.0.3"},
+		}, {
+			Scope: model.ScopeInventory,
+			Name:  "mem",
+			Value: float64(1024),
+		}},
+	}}, {{
+		ID: "83bce0e4-c4c0-4995-b8b7-f056da7fc8f6",
+		Attributes: inventory.DeviceAttributes{{
+			Scope: model.ScopeInventory,
+			Name:  "ip4",
+			Value: "10.0.0.4",
+		}},
+	}}}
+
+	testCases := map[string]struct {
+		format string
+		pages  [][]inventory.Device
+
+		output string
+		err    string
+	}{
+		"ok, csv": {
+			format: model.ReportFormatCSV,
+			pages:  pages,
+
+			output: "id,inventory:ip4,inventory:mem\n" +
+				"5975e1e6-49a6-4218-a46d-f181154a98cc,\"10.0.0.2,10.0.0.3\",1024\n" +
+				"83bce0e4-c4c0-4995-b8b7-f056da7fc8f6,10.0.0.4,\n",
+		},
+		"ok, csv, empty": {
+			format: model.ReportFormatCSV,
+
+			output: "id,inventory:ip4,inventory:mem\n",
+		},
+		"ok, json": {
+			format: model.ReportFormatJSON,
+			pages:  pages,
+
+			output: `[{"id":"5975e1e6-49a6-4218-a46d-f181154a98cc",` +
+				`"attributes":[{"name":"ip4","value":["10.0.0.2","10.0.0.3"],` +
+				`"scope":"inventory"},{"name":"mem","value":1024,"scope":"inventory"}],` +
+				`"created_ts":"0001-01-01T00:00:00Z","updated_ts":"0001-01-01T00:00:00Z"},` +
+				`{"id":"83bce0e4-c4c0-4995-b8b7-f056da7fc8f6",` +
+				`"attributes":[{"name":"ip4","value":"10.0.0.4","scope":"inventory"}],` +
+				`"created_ts":"0001-01-01T00:00:00Z","updated_ts":"0001-01-01T00:00:00Z"}]`,
+		},
+		"ok, json, empty": {
+			format: model.ReportFormatJSON,
+
+			output: "[]",
+		},
+		"ko, unsupported format": {
+			format: "xlsx",
+
+			err: "unsupported export format: xlsx",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			w, err := NewDevicesWriter(&buf, tc.format, columns)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, page := range tc.pages {
+				assert.NoError(t, w.Write(page))
+			}
+			assert.NoError(t, w.Close())
+			if tc.format == model.ReportFormatJSON {
+				assert.JSONEq(t, tc.output, buf.String())
+			} else {
+				assert.Equal(t, tc.output, buf.String())
+			}
+		})
+	}
+}
@@ -23,6 +23,10 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	model "github.com/mendersoftware/reporting/model"
+
+	reporting "github.com/mendersoftware/reporting/app/reporting"
+
+	time "time"
 )
 
 // App is an autogenerated mock type for the App type
@@ -76,22 +80,22 @@ func (_m *App) AggregateDevices(ctx context.Context, aggregateParams *model.Aggr
 	return r0, r1
 }
 
-// GetMapping provides a mock function with given fields: ctx, tid
-func (_m *App) GetMapping(ctx context.Context, tid string) (*model.Mapping, error) {
-	ret := _m.Called(ctx, tid)
+// AggregateSoftware provides a mock function with given fields: ctx, params
+func (_m *App) AggregateSoftware(ctx context.Context, params *model.SoftwareParams) ([]model.SoftwareSummary, error) {
+	ret := _m.Called(ctx, params)
 
-	var r0 *model.Mapping
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Mapping); ok {
-		r0 = rf(ctx, tid)
+	var r0 []model.SoftwareSummary
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SoftwareParams) []model.SoftwareSummary); ok {
+		r0 = rf(ctx, params)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Mapping)
+			r0 = ret.Get(0).([]model.SoftwareSummary)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, tid)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SoftwareParams) error); ok {
+		r1 = rf(ctx, params)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -99,22 +103,36 @@ func (_m *App) GetMapping(ctx context.Context, tid string) (*model.Mapping, erro
 	return r0, r1
 }
 
-// GetSearchableInvAttrs provides a mock function with given fields: ctx, tid
-func (_m *App) GetSearchableInvAttrs(ctx context.Context, tid string) ([]model.FilterAttribute, error) {
-	ret := _m.Called(ctx, tid)
+// CheckDependencies provides a mock function with given fields: ctx
+func (_m *App) CheckDependencies(ctx context.Context) *model.HealthReport {
+	ret := _m.Called(ctx)
 
-	var r0 []model.FilterAttribute
-	if rf, ok := ret.Get(0).(func(context.Context, string) []model.FilterAttribute); ok {
-		r0 = rf(ctx, tid)
+	var r0 *model.HealthReport
+	if rf, ok := ret.Get(0).(func(context.Context) *model.HealthReport); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.FilterAttribute)
+			r0 = ret.Get(0).(*model.HealthReport)
 		}
 	}
 
+	return r0
+}
+
+// CountDevices provides a mock function with given fields: ctx, searchParams
+func (_m *App) CountDevices(ctx context.Context, searchParams *model.SearchParams) (int, error) {
+	ret := _m.Called(ctx, searchParams)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams) int); ok {
+		r0 = rf(ctx, searchParams)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, tid)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SearchParams) error); ok {
+		r1 = rf(ctx, searchParams)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -122,13 +140,13 @@ func (_m *App) GetSearchableInvAttrs(ctx context.Context, tid string) ([]model.F
 	return r0, r1
 }
 
-// HealthCheck provides a mock function with given fields: ctx
-func (_m *App) HealthCheck(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// CreateAlert provides a mock function with given fields: ctx, alert
+func (_m *App) CreateAlert(ctx context.Context, alert *model.Alert) error {
+	ret := _m.Called(ctx, alert)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Alert) error); ok {
+		r0 = rf(ctx, alert)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -136,62 +154,1000 @@ func (_m *App) HealthCheck(ctx context.Context) error {
 	return r0
 }
 
-// SearchDeployments provides a mock function with given fields: ctx, searchParams
-func (_m *App) SearchDeployments(ctx context.Context, searchParams *model.DeploymentsSearchParams) ([]model.Deployment, int, error) {
+// CreateSavedSearch provides a mock function with given fields: ctx, search
+func (_m *App) CreateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	ret := _m.Called(ctx, search)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SavedSearch) error); ok {
+		r0 = rf(ctx, search)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateSearchJob provides a mock function with given fields: ctx, searchParams
+func (_m *App) CreateSearchJob(ctx context.Context, searchParams *model.SearchParams) (*model.SearchJob, error) {
 	ret := _m.Called(ctx, searchParams)
 
-	var r0 []model.Deployment
-	if rf, ok := ret.Get(0).(func(context.Context, *model.DeploymentsSearchParams) []model.Deployment); ok {
+	var r0 *model.SearchJob
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams) *model.SearchJob); ok {
 		r0 = rf(ctx, searchParams)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Deployment)
+			r0 = ret.Get(0).(*model.SearchJob)
 		}
 	}
 
-	var r1 int
-	if rf, ok := ret.Get(1).(func(context.Context, *model.DeploymentsSearchParams) int); ok {
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SearchParams) error); ok {
 		r1 = rf(ctx, searchParams)
 	} else {
-		r1 = ret.Get(1).(int)
+		r1 = ret.Error(1)
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, *model.DeploymentsSearchParams) error); ok {
-		r2 = rf(ctx, searchParams)
+	return r0, r1
+}
+
+// DeleteAlert provides a mock function with given fields: ctx, tenantID, id
+func (_m *App) DeleteAlert(ctx context.Context, tenantID string, id string) error {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tenantID, id)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1, r2
+	return r0
 }
 
-// SearchDevices provides a mock function with given fields: ctx, searchParams
-func (_m *App) SearchDevices(ctx context.Context, searchParams *model.SearchParams) ([]inventory.Device, int, error) {
+// DeleteSavedSearch provides a mock function with given fields: ctx, tenantID, id
+func (_m *App) DeleteSavedSearch(ctx context.Context, tenantID string, id string) error {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTenant provides a mock function with given fields: ctx, tenantID
+func (_m *App) DeleteTenant(ctx context.Context, tenantID string) error {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DiffDevice provides a mock function with given fields: ctx, tenantID, deviceID
+func (_m *App) DiffDevice(ctx context.Context, tenantID string, deviceID string) (*reporting.DeviceDiff, error) {
+	ret := _m.Called(ctx, tenantID, deviceID)
+
+	var r0 *reporting.DeviceDiff
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *reporting.DeviceDiff); ok {
+		r0 = rf(ctx, tenantID, deviceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*reporting.DeviceDiff)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, deviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EvictMappingAttributes provides a mock function with given fields: ctx, tid, attributes
+func (_m *App) EvictMappingAttributes(ctx context.Context, tid string, attributes []model.MappingAttribute) (*model.MappingUsage, error) {
+	ret := _m.Called(ctx, tid, attributes)
+
+	var r0 *model.MappingUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string, []model.MappingAttribute) *model.MappingUsage); ok {
+		r0 = rf(ctx, tid, attributes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.MappingUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []model.MappingAttribute) error); ok {
+		r1 = rf(ctx, tid, attributes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportDevices provides a mock function with given fields: ctx, searchParams, fn
+func (_m *App) ExportDevices(ctx context.Context, searchParams *model.SearchParams, fn func([]inventory.Device) error) error {
+	ret := _m.Called(ctx, searchParams, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams, func([]inventory.Device) error) error); ok {
+		r0 = rf(ctx, searchParams, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportDevicesToStorage provides a mock function with given fields: ctx, searchParams
+func (_m *App) ExportDevicesToStorage(ctx context.Context, searchParams *model.SearchParams) (*model.DevicesExport, error) {
 	ret := _m.Called(ctx, searchParams)
 
-	var r0 []inventory.Device
-	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams) []inventory.Device); ok {
+	var r0 *model.DevicesExport
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams) *model.DevicesExport); ok {
 		r0 = rf(ctx, searchParams)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]inventory.Device)
+			r0 = ret.Get(0).(*model.DevicesExport)
 		}
 	}
 
-	var r1 int
-	if rf, ok := ret.Get(1).(func(context.Context, *model.SearchParams) int); ok {
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SearchParams) error); ok {
 		r1 = rf(ctx, searchParams)
 	} else {
-		r1 = ret.Get(1).(int)
+		r1 = ret.Error(1)
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, *model.SearchParams) error); ok {
-		r2 = rf(ctx, searchParams)
+	return r0, r1
+}
+
+// GetAlert provides a mock function with given fields: ctx, tenantID, id
+func (_m *App) GetAlert(ctx context.Context, tenantID string, id string) (*model.Alert, error) {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 *model.Alert
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Alert); ok {
+		r0 = rf(ctx, tenantID, id)
 	} else {
-		r2 = ret.Error(2)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Alert)
+		}
 	}
 
-	return r0, r1, r2
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAlerts provides a mock function with given fields: ctx, tenantID
+func (_m *App) GetAlerts(ctx context.Context, tenantID string) ([]model.Alert, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 []model.Alert
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Alert); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Alert)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAttributesUsage provides a mock function with given fields: ctx, tenantID
+func (_m *App) GetAttributesUsage(ctx context.Context, tenantID string) (*model.AttributesUsage, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 *model.AttributesUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.AttributesUsage); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.AttributesUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeadLetter provides a mock function with given fields: ctx, id
+func (_m *App) GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *model.DeadLetter
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.DeadLetter); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.DeadLetter)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDevice provides a mock function with given fields: ctx, tenantID, deviceID
+func (_m *App) GetDevice(ctx context.Context, tenantID string, deviceID string) (*inventory.Device, error) {
+	ret := _m.Called(ctx, tenantID, deviceID)
+
+	var r0 *inventory.Device
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *inventory.Device); ok {
+		r0 = rf(ctx, tenantID, deviceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*inventory.Device)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, deviceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeviceDeploymentsTimeline provides a mock function with given fields: ctx, params
+func (_m *App) GetDeviceDeploymentsTimeline(ctx context.Context, params model.DeploymentsTimelineParams) ([]model.DeploymentTimelineEvent, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.DeploymentTimelineEvent
+	if rf, ok := ret.Get(0).(func(context.Context, model.DeploymentsTimelineParams) []model.DeploymentTimelineEvent); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeploymentTimelineEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.DeploymentsTimelineParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFleetSummary provides a mock function with given fields: ctx, params
+func (_m *App) GetFleetSummary(ctx context.Context, params *model.FleetSummaryParams) (*model.FleetSummary, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 *model.FleetSummary
+	if rf, ok := ret.Get(0).(func(context.Context, *model.FleetSummaryParams) *model.FleetSummary); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FleetSummary)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.FleetSummaryParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIndexingRules provides a mock function with given fields: ctx, tenantID
+func (_m *App) GetIndexingRules(ctx context.Context, tenantID string) (*model.IndexingRules, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 *model.IndexingRules
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.IndexingRules); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.IndexingRules)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMapping provides a mock function with given fields: ctx, tid
+func (_m *App) GetMapping(ctx context.Context, tid string) (*model.Mapping, error) {
+	ret := _m.Called(ctx, tid)
+
+	var r0 *model.Mapping
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Mapping); ok {
+		r0 = rf(ctx, tid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Mapping)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMappingTypes provides a mock function with given fields: ctx, tid
+func (_m *App) GetMappingTypes(ctx context.Context, tid string) (*model.MappingTypes, error) {
+	ret := _m.Called(ctx, tid)
+
+	var r0 *model.MappingTypes
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.MappingTypes); ok {
+		r0 = rf(ctx, tid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.MappingTypes)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMappingUsage provides a mock function with given fields: ctx, tid
+func (_m *App) GetMappingUsage(ctx context.Context, tid string) (*model.MappingUsage, error) {
+	ret := _m.Called(ctx, tid)
+
+	var r0 *model.MappingUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.MappingUsage); ok {
+		r0 = rf(ctx, tid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.MappingUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReindexState provides a mock function with given fields: ctx, tenantID
+func (_m *App) GetReindexState(ctx context.Context, tenantID string) (*model.ReindexState, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 *model.ReindexState
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.ReindexState); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ReindexState)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSavedSearch provides a mock function with given fields: ctx, tenantID, id
+func (_m *App) GetSavedSearch(ctx context.Context, tenantID string, id string) (*model.SavedSearch, error) {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 *model.SavedSearch
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.SavedSearch); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SavedSearch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSavedSearches provides a mock function with given fields: ctx, tenantID
+func (_m *App) GetSavedSearches(ctx context.Context, tenantID string) ([]model.SavedSearch, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 []model.SavedSearch
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.SavedSearch); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SavedSearch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSearchJob provides a mock function with given fields: ctx, tenantID, id
+func (_m *App) GetSearchJob(ctx context.Context, tenantID string, id string) (*model.SearchJob, error) {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 *model.SearchJob
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.SearchJob); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SearchJob)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSearchableInvAttrs provides a mock function with given fields: ctx, tid
+func (_m *App) GetSearchableInvAttrs(ctx context.Context, tid string) ([]model.FilterAttribute, error) {
+	ret := _m.Called(ctx, tid)
+
+	var r0 []model.FilterAttribute
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.FilterAttribute); ok {
+		r0 = rf(ctx, tid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.FilterAttribute)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenantUsage provides a mock function with given fields: ctx, tenantID, month
+func (_m *App) GetTenantUsage(ctx context.Context, tenantID string, month string) (*model.TenantUsage, error) {
+	ret := _m.Called(ctx, tenantID, month)
+
+	var r0 *model.TenantUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.TenantUsage); ok {
+		r0 = rf(ctx, tenantID, month)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TenantUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, month)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HealthCheck provides a mock function with given fields: ctx
+func (_m *App) HealthCheck(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListAuditLogs provides a mock function with given fields: ctx, params
+func (_m *App) ListAuditLogs(ctx context.Context, params model.AuditLogsParams) ([]model.AuditLogEntry, int, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.AuditLogEntry
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditLogsParams) []model.AuditLogEntry); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AuditLogEntry)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, model.AuditLogsParams) int); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, model.AuditLogsParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListDeadLetters provides a mock function with given fields: ctx, params
+func (_m *App) ListDeadLetters(ctx context.Context, params model.DeadLettersParams) ([]model.DeadLetter, int, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.DeadLetter
+	if rf, ok := ret.Get(0).(func(context.Context, model.DeadLettersParams) []model.DeadLetter); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeadLetter)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, model.DeadLettersParams) int); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, model.DeadLettersParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListGroups provides a mock function with given fields: ctx, params
+func (_m *App) ListGroups(ctx context.Context, params *model.GroupsParams) ([]model.DeviceGroup, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.DeviceGroup
+	if rf, ok := ret.Get(0).(func(context.Context, *model.GroupsParams) []model.DeviceGroup); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeviceGroup)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.GroupsParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordAuditLogEntry provides a mock function with given fields: ctx, entry
+func (_m *App) RecordAuditLogEntry(ctx context.Context, entry *model.AuditLogEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.AuditLogEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReindexDevices provides a mock function with given fields: ctx, tenantID, deviceIDs
+func (_m *App) ReindexDevices(ctx context.Context, tenantID string, deviceIDs []string) error {
+	ret := _m.Called(ctx, tenantID, deviceIDs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, tenantID, deviceIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplayDeadLetter provides a mock function with given fields: ctx, id
+func (_m *App) ReplayDeadLetter(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetReindexState provides a mock function with given fields: ctx, tenantID
+func (_m *App) ResetReindexState(ctx context.Context, tenantID string) error {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchDeployments provides a mock function with given fields: ctx, searchParams
+func (_m *App) SearchDeployments(ctx context.Context, searchParams *model.DeploymentsSearchParams) ([]model.Deployment, int, error) {
+	ret := _m.Called(ctx, searchParams)
+
+	var r0 []model.Deployment
+	if rf, ok := ret.Get(0).(func(context.Context, *model.DeploymentsSearchParams) []model.Deployment); ok {
+		r0 = rf(ctx, searchParams)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Deployment)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, *model.DeploymentsSearchParams) int); ok {
+		r1 = rf(ctx, searchParams)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *model.DeploymentsSearchParams) error); ok {
+		r2 = rf(ctx, searchParams)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SearchDevices provides a mock function with given fields: ctx, searchParams
+func (_m *App) SearchDevices(ctx context.Context, searchParams *model.SearchParams) ([]inventory.Device, int, error) {
+	ret := _m.Called(ctx, searchParams)
+
+	var r0 []inventory.Device
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams) []inventory.Device); ok {
+		r0 = rf(ctx, searchParams)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]inventory.Device)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SearchParams) int); ok {
+		r1 = rf(ctx, searchParams)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *model.SearchParams) error); ok {
+		r2 = rf(ctx, searchParams)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SearchDevicesAcrossTenants provides a mock function with given fields: ctx, params
+func (_m *App) SearchDevicesAcrossTenants(ctx context.Context, params *model.TenantsSearchParams) ([]reporting.TenantDevices, int, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []reporting.TenantDevices
+	if rf, ok := ret.Get(0).(func(context.Context, *model.TenantsSearchParams) []reporting.TenantDevices); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]reporting.TenantDevices)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, *model.TenantsSearchParams) int); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *model.TenantsSearchParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SearchDevicesWithCursor provides a mock function with given fields: ctx, searchParams
+func (_m *App) SearchDevicesWithCursor(ctx context.Context, searchParams *model.SearchParams) ([]inventory.Device, int, string, error) {
+	ret := _m.Called(ctx, searchParams)
+
+	var r0 []inventory.Device
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams) []inventory.Device); ok {
+		r0 = rf(ctx, searchParams)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]inventory.Device)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SearchParams) int); ok {
+		r1 = rf(ctx, searchParams)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 string
+	if rf, ok := ret.Get(2).(func(context.Context, *model.SearchParams) string); ok {
+		r2 = rf(ctx, searchParams)
+	} else {
+		r2 = ret.Get(2).(string)
+	}
+
+	var r3 error
+	if rf, ok := ret.Get(3).(func(context.Context, *model.SearchParams) error); ok {
+		r3 = rf(ctx, searchParams)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// SearchSoftwareDevices provides a mock function with given fields: ctx, params
+func (_m *App) SearchSoftwareDevices(ctx context.Context, params *model.SoftwareDevicesParams) ([]model.DeviceSoftware, int, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.DeviceSoftware
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SoftwareDevicesParams) []model.DeviceSoftware); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeviceSoftware)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SoftwareDevicesParams) int); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *model.SoftwareDevicesParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SetDeviceTags provides a mock function with given fields: ctx, authorization, tenantID, deviceID, tags
+func (_m *App) SetDeviceTags(ctx context.Context, authorization string, tenantID string, deviceID string, tags model.DeviceTags) error {
+	ret := _m.Called(ctx, authorization, tenantID, deviceID, tags)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, model.DeviceTags) error); ok {
+		r0 = rf(ctx, authorization, tenantID, deviceID, tags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetIndexingRules provides a mock function with given fields: ctx, rules
+func (_m *App) SetIndexingRules(ctx context.Context, rules *model.IndexingRules) error {
+	ret := _m.Called(ctx, rules)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.IndexingRules) error); ok {
+		r0 = rf(ctx, rules)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMappingTypes provides a mock function with given fields: ctx, tid, types
+func (_m *App) SetMappingTypes(ctx context.Context, tid string, types []model.MappingAttributeType) error {
+	ret := _m.Called(ctx, tid, types)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []model.MappingAttributeType) error); ok {
+		r0 = rf(ctx, tid, types)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StreamDevices provides a mock function with given fields: ctx, searchParams, interval, fn
+func (_m *App) StreamDevices(ctx context.Context, searchParams *model.SearchParams, interval time.Duration, fn func(*reporting.SearchUpdate) error) error {
+	ret := _m.Called(ctx, searchParams, interval, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchParams, time.Duration, func(*reporting.SearchUpdate) error) error); ok {
+		r0 = rf(ctx, searchParams, interval, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SuggestDeviceAttributeValues provides a mock function with given fields: ctx, params
+func (_m *App) SuggestDeviceAttributeValues(ctx context.Context, params *model.SuggestParams) ([]model.AttributeSuggestion, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.AttributeSuggestion
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SuggestParams) []model.AttributeSuggestion); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AttributeSuggestion)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SuggestParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAlert provides a mock function with given fields: ctx, alert
+func (_m *App) UpdateAlert(ctx context.Context, alert *model.Alert) error {
+	ret := _m.Called(ctx, alert)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Alert) error); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateIndexSettings provides a mock function with given fields: ctx, index, settings
+func (_m *App) UpdateIndexSettings(ctx context.Context, index string, settings *model.IndexSettings) error {
+	ret := _m.Called(ctx, index, settings)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *model.IndexSettings) error); ok {
+		r0 = rf(ctx, index, settings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateSavedSearch provides a mock function with given fields: ctx, search
+func (_m *App) UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	ret := _m.Called(ctx, search)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SavedSearch) error); ok {
+		r0 = rf(ctx, search)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WaitSearchJobs provides a mock function with given fields: ctx
+func (_m *App) WaitSearchJobs(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
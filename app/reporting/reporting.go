@@ -17,12 +17,21 @@ package reporting
 import (
 	"context"
 	"errors"
+	"fmt"
+	"path"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
 	"github.com/mendersoftware/go-lib-micro/log"
 
+	"github.com/mendersoftware/reporting/client/deviceauth"
 	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/client/nats"
+	"github.com/mendersoftware/reporting/client/s3"
 	"github.com/mendersoftware/reporting/mapping"
 	"github.com/mendersoftware/reporting/model"
 	"github.com/mendersoftware/reporting/store"
@@ -31,30 +40,188 @@ import (
 //go:generate ../../x/mockgen.sh
 type App interface {
 	HealthCheck(ctx context.Context) error
+	CheckDependencies(ctx context.Context) *model.HealthReport
 	GetMapping(ctx context.Context, tid string) (*model.Mapping, error)
+	GetMappingUsage(ctx context.Context, tid string) (*model.MappingUsage, error)
+	EvictMappingAttributes(ctx context.Context, tid string,
+		attributes []model.MappingAttribute) (*model.MappingUsage, error)
+	GetMappingTypes(ctx context.Context, tid string) (*model.MappingTypes, error)
+	SetMappingTypes(ctx context.Context, tid string, types []model.MappingAttributeType) error
 	GetSearchableInvAttrs(ctx context.Context, tid string) ([]model.FilterAttribute, error)
 	AggregateDevices(ctx context.Context, aggregateParams *model.AggregateParams) (
 		[]model.DeviceAggregation, error)
+	SuggestDeviceAttributeValues(ctx context.Context, params *model.SuggestParams) (
+		[]model.AttributeSuggestion, error)
 	SearchDevices(ctx context.Context, searchParams *model.SearchParams) (
 		[]inventory.Device, int, error)
+	// GetDevice returns the indexed document of the tenant's device
+	GetDevice(ctx context.Context, tenantID, deviceID string) (*inventory.Device, error)
+	// DiffDevice compares the indexed document of the tenant's device with
+	// its live state
+	DiffDevice(ctx context.Context, tenantID, deviceID string) (*DeviceDiff, error)
+	// SetDeviceTags replaces the tags of the tenant's device in inventory,
+	// on behalf of the user, and in the indexed document
+	SetDeviceTags(ctx context.Context, authorization, tenantID, deviceID string,
+		tags model.DeviceTags) error
+	SearchDevicesWithCursor(ctx context.Context, searchParams *model.SearchParams) (
+		[]inventory.Device, int, string, error)
+	CountDevices(ctx context.Context, searchParams *model.SearchParams) (int, error)
+	ExportDevices(ctx context.Context, searchParams *model.SearchParams,
+		fn func([]inventory.Device) error) error
+	StreamDevices(ctx context.Context, searchParams *model.SearchParams,
+		interval time.Duration, fn func(*SearchUpdate) error) error
 	AggregateDeployments(ctx context.Context, aggregateParams *model.AggregateDeploymentsParams) (
 		[]model.DeviceAggregation, error)
 	SearchDeployments(ctx context.Context, searchParams *model.DeploymentsSearchParams) (
 		[]model.Deployment, int, error)
+	GetDeviceDeploymentsTimeline(ctx context.Context, params model.DeploymentsTimelineParams) (
+		[]model.DeploymentTimelineEvent, error)
+	CreateSavedSearch(ctx context.Context, search *model.SavedSearch) error
+	GetSavedSearches(ctx context.Context, tenantID string) ([]model.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, tenantID, id string) (*model.SavedSearch, error)
+	UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error
+	DeleteSavedSearch(ctx context.Context, tenantID, id string) error
+	CreateAlert(ctx context.Context, alert *model.Alert) error
+	GetAlerts(ctx context.Context, tenantID string) ([]model.Alert, error)
+	GetAlert(ctx context.Context, tenantID, id string) (*model.Alert, error)
+	UpdateAlert(ctx context.Context, alert *model.Alert) error
+	DeleteAlert(ctx context.Context, tenantID, id string) error
+	GetIndexingRules(ctx context.Context, tenantID string) (*model.IndexingRules, error)
+	SetIndexingRules(ctx context.Context, rules *model.IndexingRules) error
+	ReindexDevices(ctx context.Context, tenantID string, deviceIDs []string) error
+	GetReindexState(ctx context.Context, tenantID string) (*model.ReindexState, error)
+	ResetReindexState(ctx context.Context, tenantID string) error
+	ListDeadLetters(ctx context.Context, params model.DeadLettersParams) (
+		[]model.DeadLetter, int, error)
+	GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id string) error
+	RecordAuditLogEntry(ctx context.Context, entry *model.AuditLogEntry) error
+	ListAuditLogs(ctx context.Context, params model.AuditLogsParams) (
+		[]model.AuditLogEntry, int, error)
+	DeleteTenant(ctx context.Context, tenantID string) error
+	SearchDevicesAcrossTenants(ctx context.Context, params *model.TenantsSearchParams) (
+		[]TenantDevices, int, error)
+	AggregateSoftware(ctx context.Context, params *model.SoftwareParams) (
+		[]model.SoftwareSummary, error)
+	SearchSoftwareDevices(ctx context.Context, params *model.SoftwareDevicesParams) (
+		[]model.DeviceSoftware, int, error)
+	ListGroups(ctx context.Context, params *model.GroupsParams) ([]model.DeviceGroup, error)
+	GetFleetSummary(ctx context.Context, params *model.FleetSummaryParams) (
+		*model.FleetSummary, error)
+	ExportDevicesToStorage(ctx context.Context, searchParams *model.SearchParams) (
+		*model.DevicesExport, error)
+	UpdateIndexSettings(ctx context.Context, index string, settings *model.IndexSettings) error
+	CreateSearchJob(ctx context.Context, searchParams *model.SearchParams) (
+		*model.SearchJob, error)
+	GetSearchJob(ctx context.Context, tenantID, id string) (*model.SearchJob, error)
+	// WaitSearchJobs waits for the search jobs running in the background
+	// to finish, or for the context to be done
+	WaitSearchJobs(ctx context.Context) error
+	// GetTenantUsage returns the usage of the reporting service by the
+	// tenant, with its queries of the month
+	GetTenantUsage(ctx context.Context, tenantID, month string) (*model.TenantUsage, error)
+	// GetAttributesUsage returns the usage of the tenant's attributes by
+	// its queries, with the attributes of its mapping never used
+	GetAttributesUsage(ctx context.Context, tenantID string) (*model.AttributesUsage, error)
+}
+
+const (
+	exportPageSize = 500
+
+	// cursorKeepAlive is how long the point in time of a cursor-based
+	// pagination is kept alive after every page
+	cursorKeepAlive = time.Minute
+)
+
+var (
+	ErrCursorExpired = store.ErrPointInTimeNotFound
+
+	// ErrQueryNotSupported is returned when the storage backend cannot
+	// run the search or the aggregation
+	ErrQueryNotSupported = store.ErrQueryNotSupported
+
+	// ErrAggregationAttributeNotNumeric is returned when a metrics
+	// aggregation targets an attribute which has no numeric values
+	ErrAggregationAttributeNotNumeric = errors.New(
+		"metrics aggregations support only numeric attributes")
+
+	// ErrDeviceNotFound is returned when the device is not indexed
+	ErrDeviceNotFound = errors.New("device not found")
+
+	// ErrFilterValueType is returned when the value of a filter doesn't
+	// fit the types its attribute is indexed with
+	ErrFilterValueType = errors.New(
+		"filter values don't match the types of the attributes")
+)
+
+// filterTypesError are the errors of the filters whose value doesn't fit the
+// types of their attribute, keyed by the position of the filters in the
+// search parameters
+type filterTypesError struct {
+	validation.Errors
+}
+
+func (err filterTypesError) Is(target error) bool {
+	return target == ErrFilterValueType
+}
+
+func (err filterTypesError) Unwrap() error {
+	return err.Errors
 }
 
 type app struct {
 	store  store.Store
 	mapper mapping.Mapper
 	ds     store.DataStore
+
+	nats        nats.Client
+	jobsSubject string
+
+	dependencies []dependency
+
+	auditLogRetention time.Duration
+	redaction         *model.AttributesRedaction
+
+	exportStorage s3.Client
+	exportPrefix  string
+
+	searchJobsTTL   time.Duration
+	searchJobsSlots chan struct{}
+	searchJobsCtx   context.Context
+	searchJobs      sync.WaitGroup
+
+	invClient inventory.Client
+	devClient deviceauth.Client
+
+	attributesUsage *AttributesUsageTracker
 }
 
-func NewApp(store store.Store, ds store.DataStore) App {
+// Option configures the reporting app
+type Option func(*app)
+
+func NewApp(store store.Store, ds store.DataStore, opts ...Option) App {
 	mapper := mapping.NewMapper(ds)
-	return &app{
+	app := &app{
 		store:  store,
 		mapper: mapper,
 		ds:     ds,
+
+		searchJobsTTL:   defaultSearchJobsTTL,
+		searchJobsSlots: make(chan struct{}, defaultSearchJobsConcurrency),
+		searchJobsCtx:   context.Background(),
+	}
+	for _, opt := range opts {
+		opt(app)
+	}
+	return app
+}
+
+// WithAttributesRedaction sets the attributes of the devices whose values
+// are indexed hashed or masked, for the filters to search for the hashed
+// values
+func WithAttributesRedaction(redaction *model.AttributesRedaction) Option {
+	return func(app *app) {
+		app.redaction = redaction
 	}
 }
 
@@ -78,9 +245,14 @@ func (app *app) AggregateDevices(
 	aggregateParams *model.AggregateParams,
 ) ([]model.DeviceAggregation, error) {
 	searchParams := &model.SearchParams{
-		Filters:  aggregateParams.Filters,
-		Groups:   aggregateParams.Groups,
-		TenantID: aggregateParams.TenantID,
+		Filters:           aggregateParams.Filters,
+		Groups:            aggregateParams.Groups,
+		VisibleAttributes: aggregateParams.VisibleAttributes,
+		TenantID:          aggregateParams.TenantID,
+	}
+	if err := checkAggregationsVisibility(aggregateParams.VisibleAttributes,
+		aggregateParams.Aggregations); err != nil {
+		return nil, err
 	}
 	if err := app.mapSearchParams(ctx, searchParams); err != nil {
 		return nil, err
@@ -97,10 +269,15 @@ func (app *app) AggregateDevices(
 		})
 	}
 
+	app.trackAggregations(searchParams.TenantID, aggregateParams.Aggregations)
 	if err := app.mapAggregations(ctx, searchParams.TenantID,
 		aggregateParams.Aggregations); err != nil {
 		return nil, err
 	}
+	if err := app.checkNumericAggregations(ctx, searchParams.TenantID,
+		aggregateParams.Aggregations); err != nil {
+		return nil, err
+	}
 	aggregations, err := model.BuildAggregations(aggregateParams.Aggregations)
 	if err != nil {
 		return nil, err
@@ -132,10 +309,15 @@ func (a *app) storeToDeviceAggregations(
 ) ([]model.DeviceAggregation, error) {
 	aggs := []model.DeviceAggregation{}
 	for name, aggregationS := range aggregationsS {
-		if _, ok := aggregationS.(map[string]interface{}); !ok {
+		aggregationM, ok := aggregationS.(map[string]interface{})
+		if !ok {
 			continue
 		}
-		bucketsS, ok := aggregationS.(map[string]interface{})["buckets"].([]interface{})
+		if agg, ok := storeToMetricsAggregation(name, aggregationM); ok {
+			aggs = append(aggs, agg)
+			continue
+		}
+		bucketsS, ok := aggregationM["buckets"].([]interface{})
 		if !ok {
 			continue
 		}
@@ -145,7 +327,12 @@ func (a *app) storeToDeviceAggregations(
 			if !ok {
 				return nil, errors.New("can't process store bucket item")
 			}
-			key, ok := bucketMap["key"].(string)
+			// the keys of the date histogram buckets are timestamps,
+			// formatted in key_as_string
+			key, ok := bucketMap["key_as_string"].(string)
+			if !ok {
+				key, ok = bucketMap["key"].(string)
+			}
 			if !ok {
 				return nil, errors.New("can't process store key attribute")
 			}
@@ -178,18 +365,112 @@ func (a *app) storeToDeviceAggregations(
 	return aggs, nil
 }
 
+// storeToMetricsAggregation translates the ES results of the stats and
+// percentiles aggregations, returning false for the bucket aggregations
+func storeToMetricsAggregation(
+	name string, aggregationM map[string]interface{},
+) (model.DeviceAggregation, bool) {
+	agg := model.DeviceAggregation{
+		Name:  name,
+		Items: []model.DeviceAggregationItem{},
+	}
+	if values, ok := aggregationM["values"].(map[string]interface{}); ok {
+		agg.Percentiles = make(map[string]float64, len(values))
+		for percent, value := range values {
+			// the percentiles of empty data sets are null
+			if v, ok := value.(float64); ok {
+				agg.Percentiles[percent] = v
+			}
+		}
+		return agg, true
+	}
+	count, ok := aggregationM["count"].(float64)
+	if _, isStats := aggregationM["avg"]; !ok || !isStats {
+		return agg, false
+	}
+	stats := &model.DeviceAggregationStats{Count: int(count)}
+	stats.Sum, _ = aggregationM["sum"].(float64)
+	for field, dst := range map[string]**float64{
+		"min": &stats.Min,
+		"max": &stats.Max,
+		"avg": &stats.Avg,
+	} {
+		if v, ok := aggregationM[field].(float64); ok {
+			*dst = &v
+		}
+	}
+	agg.Stats = stats
+	return agg, true
+}
+
 // SearchDevices searches device data
 func (app *app) SearchDevices(
 	ctx context.Context,
 	searchParams *model.SearchParams,
 ) ([]inventory.Device, int, error) {
-	if err := app.mapSearchParams(ctx, searchParams); err != nil {
+	query, err := app.buildDevicesQuery(ctx, searchParams)
+	if err != nil {
 		return nil, 0, err
 	}
-	query, err := model.BuildQuery(*searchParams)
+
+	esRes, err := app.store.SearchDevices(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, total, err := app.storeToInventoryDevs(ctx, searchParams.TenantID, esRes)
 	if err != nil {
 		return nil, 0, err
 	}
+	hideAttributes(res, searchParams.VisibleAttributes)
+
+	return res, total, err
+}
+
+// GetDevice returns the indexed document of the tenant's device, with all
+// its attributes
+func (app *app) GetDevice(
+	ctx context.Context,
+	tenantID, deviceID string,
+) (*inventory.Device, error) {
+	devices, _, err := app.SearchDevices(ctx, &model.SearchParams{
+		TenantID:  tenantID,
+		DeviceIDs: []string{deviceID},
+		Page:      1,
+		PerPage:   1,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(devices) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+	return &devices[0], nil
+}
+
+// CountDevices returns the number of devices matching the search, without
+// retrieving them; pagination, sorting and projection are ignored
+func (app *app) CountDevices(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) (int, error) {
+	query, err := app.buildDevicesQuery(ctx, searchParams)
+	if err != nil {
+		return 0, err
+	}
+	return app.store.CountDevices(ctx, query)
+}
+
+func (app *app) buildDevicesQuery(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) (model.Query, error) {
+	if err := app.mapSearchParams(ctx, searchParams); err != nil {
+		return nil, err
+	}
+	query, err := model.BuildQuery(*searchParams)
+	if err != nil {
+		return nil, err
+	}
 
 	if searchParams.TenantID != "" {
 		query = query.Must(model.M{
@@ -206,18 +487,167 @@ func (app *app) SearchDevices(
 			},
 		})
 	}
+	return query, nil
+}
 
-	esRes, err := app.store.SearchDevices(ctx, query)
+// SearchDevicesWithCursor searches device data paginating through the
+// results with a cursor: the search runs on a point in time of the index,
+// so the results stay consistent across the pages, and the pages are
+// retrieved with search_after, sorting by the requested criteria and then
+// by device ID; the page in the search parameters is ignored. It returns
+// the cursor to retrieve the next page, or an empty string if there are no
+// more results
+func (app *app) SearchDevicesWithCursor(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) (devs []inventory.Device, total int, next string, err error) {
+	var cursor *model.Cursor
+	if searchParams.Cursor != model.CursorStart {
+		cursor, err = model.ParseCursor(searchParams.Cursor)
+		if err != nil {
+			return nil, 0, "", err
+		}
+	}
+	searchParams.Page = 1
+	query, err := app.buildDevicesQuery(ctx, searchParams)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
+	if cursor == nil {
+		pitID, err := app.store.OpenDevicesPointInTime(ctx, cursorKeepAlive)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		cursor = &model.Cursor{PointInTimeID: pitID}
+	}
+	defer func() {
+		// the client gets no cursor to page any further: release the
+		// point in time, unless it is already gone
+		if err != nil && err != ErrCursorExpired {
+			app.closePointInTime(ctx, cursor.PointInTimeID)
+		}
+	}()
 
-	res, total, err := app.storeToInventoryDevs(ctx, searchParams.TenantID, esRes)
+	query = query.WithSort(model.M{
+		model.FieldNameID: model.M{
+			"order": model.SortOrderAsc,
+		},
+	}).With(map[string]interface{}{
+		"pit": model.M{
+			"id":         cursor.PointInTimeID,
+			"keep_alive": fmt.Sprintf("%dms", cursorKeepAlive.Milliseconds()),
+		},
+	})
+	if cursor.SearchAfter != nil {
+		query = query.With(map[string]interface{}{
+			"search_after": cursor.SearchAfter,
+		})
+	}
+
+	esRes, err := app.store.SearchPointInTime(ctx, query)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
+	}
+	// the point in time ID may change from a search to the next
+	if pitID, ok := esRes["pit_id"].(string); ok && pitID != "" {
+		cursor.PointInTimeID = pitID
+	}
+	devs, total, err = app.storeToInventoryDevs(ctx, searchParams.TenantID, esRes)
+	if err != nil {
+		return nil, 0, "", err
 	}
+	hideAttributes(devs, searchParams.VisibleAttributes)
 
-	return res, total, err
+	if len(devs) < searchParams.PerPage {
+		// last page: release the point in time right away
+		app.closePointInTime(ctx, cursor.PointInTimeID)
+		return devs, total, "", nil
+	}
+	cursor.SearchAfter, err = lastHitSortValues(esRes)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return devs, total, cursor.String(), nil
+}
+
+// closePointInTime releases a point in time of the devices index; failures
+// are only logged, the point in time expires anyway after its keep alive
+func (app *app) closePointInTime(ctx context.Context, pitID string) {
+	if err := app.store.ClosePointInTime(ctx, pitID); err != nil {
+		log.FromContext(ctx).Warnf("failed to close the point in time: %s", err)
+	}
+}
+
+// ExportDevices pages through all the devices matching the search parameters,
+// calling fn for every page of results; the devices are filtered as by
+// SearchDevices, and pagination uses search_after, sorting by the requested
+// criteria and then by device ID, so page and per_page in the search
+// parameters are ignored
+func (app *app) ExportDevices(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+	fn func([]inventory.Device) error,
+) error {
+	searchParams.Page = 1
+	searchParams.PerPage = exportPageSize
+	query, err := app.buildDevicesQuery(ctx, searchParams)
+	if err != nil {
+		return err
+	}
+	query = query.WithSort(model.M{
+		model.FieldNameID: model.M{
+			"order": model.SortOrderAsc,
+		},
+	})
+
+	for {
+		esRes, err := app.store.SearchDevices(ctx, query)
+		if err != nil {
+			return err
+		}
+		devs, _, err := app.storeToInventoryDevs(ctx, searchParams.TenantID, esRes)
+		if err != nil {
+			return err
+		}
+		hideAttributes(devs, searchParams.VisibleAttributes)
+		if len(devs) > 0 {
+			if err := fn(devs); err != nil {
+				return err
+			}
+		}
+		if len(devs) < exportPageSize {
+			return nil
+		}
+		searchAfter, err := lastHitSortValues(esRes)
+		if err != nil {
+			return err
+		}
+		query = query.With(map[string]interface{}{
+			"search_after": searchAfter,
+		})
+	}
+}
+
+// lastHitSortValues returns the sort values of the last hit of a search
+// result, to be used as search_after parameter to retrieve the next page
+func lastHitSortValues(storeRes map[string]interface{}) ([]interface{}, error) {
+	hitsM, ok := storeRes["hits"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("can't process store hits map")
+	}
+	hitsS, ok := hitsM["hits"].([]interface{})
+	if !ok || len(hitsS) == 0 {
+		return nil, errors.New("can't process store hits slice")
+	}
+	hitM, ok := hitsS[len(hitsS)-1].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("can't process individual hit")
+	}
+	sortS, ok := hitM["sort"].([]interface{})
+	if !ok {
+		return nil, errors.New("can't process hit's sort values")
+	}
+	return sortS, nil
 }
 
 func (app *app) mapAggregations(ctx context.Context, tenantID string,
@@ -246,77 +676,282 @@ func (app *app) mapAggregations(ctx context.Context, tenantID string,
 	return err
 }
 
-func (app *app) mapSearchParams(ctx context.Context, searchParams *model.SearchParams) error {
-	if len(searchParams.Filters) > 0 {
-		attributes := make(inventory.DeviceAttributes, 0, len(searchParams.Attributes))
-		for i := 0; i < len(searchParams.Filters); i++ {
-			attributes = append(attributes, inventory.DeviceAttribute{
-				Name:        searchParams.Filters[i].Attribute,
-				Scope:       searchParams.Filters[i].Scope,
-				Value:       searchParams.Filters[i].Value,
-				Description: &searchParams.Filters[i].Type,
-			})
-		}
-		attributes, err := app.mapper.MapInventoryAttributes(ctx, searchParams.TenantID,
-			attributes, false, true)
-		if err != nil {
-			return err
+// checkNumericAggregations verifies that the (mapped) attributes of the
+// metrics aggregations are indexed as numeric values
+func (app *app) checkNumericAggregations(ctx context.Context, tenantID string,
+	aggregations []model.AggregationTerm) error {
+	var fields map[string]interface{}
+	var check func([]model.AggregationTerm) error
+	check = func(aggregations []model.AggregationTerm) error {
+		for _, agg := range aggregations {
+			if !agg.IsMetric() {
+				if err := check(agg.Aggregations); err != nil {
+					return err
+				}
+				continue
+			}
+			if fields == nil {
+				var err error
+				fields, err = app.getDevicesIndexFields(ctx, tenantID)
+				if err != nil {
+					return err
+				}
+			}
+			field := model.ToAttr(agg.Scope, agg.Attribute, model.TypeNum)
+			if _, ok := fields[field]; !ok {
+				return fmt.Errorf("aggregation %s: %w",
+					agg.Name, ErrAggregationAttributeNotNumeric)
+			}
 		}
-		searchParams.Filters = make([]model.FilterPredicate, 0, len(searchParams.Filters))
-		for _, attribute := range attributes {
-			searchParams.Filters = append(searchParams.Filters, model.FilterPredicate{
-				Attribute: attribute.Name,
-				Scope:     attribute.Scope,
-				Value:     attribute.Value,
-				Type:      *attribute.Description,
-			})
+		return nil
+	}
+	return check(aggregations)
+}
+
+// checkFilterTypes verifies that the values of the (mapped) filters fit the
+// types their attributes are indexed with; the index mapping is retrieved
+// only if there are filters to check
+func (app *app) checkFilterTypes(ctx context.Context,
+	searchParams *model.SearchParams) error {
+	var fields map[string]interface{}
+	var err error
+	checkFilters := func(filters []model.FilterPredicate) validation.Errors {
+		errs := validation.Errors{}
+		for i, filter := range filters {
+			if !filter.ComparesValue() {
+				continue
+			}
+			if fields == nil && err == nil {
+				fields, err = app.getDevicesIndexFields(ctx, searchParams.TenantID)
+			}
+			if err != nil {
+				return nil
+			}
+			if ferr := filter.CheckValueType(fields); ferr != nil {
+				errs[strconv.Itoa(i)] = validation.Errors{"value": ferr}
+			}
 		}
+		return errs
 	}
-	if len(searchParams.Attributes) > 0 {
-		attributes := make(inventory.DeviceAttributes, 0, len(searchParams.Attributes))
-		for i := 0; i < len(searchParams.Attributes); i++ {
-			attributes = append(attributes, inventory.DeviceAttribute{
-				Name:  searchParams.Attributes[i].Attribute,
-				Scope: searchParams.Attributes[i].Scope,
-			})
+	var checkGroups func(groups []model.FilterGroup) validation.Errors
+	checkGroups = func(groups []model.FilterGroup) validation.Errors {
+		errs := validation.Errors{}
+		for i, group := range groups {
+			groupErrs := validation.Errors{}
+			if ferrs := checkFilters(group.Filters); len(ferrs) > 0 {
+				groupErrs["filters"] = ferrs
+			}
+			if gerrs := checkGroups(group.Groups); len(gerrs) > 0 {
+				groupErrs["groups"] = gerrs
+			}
+			if len(groupErrs) > 0 {
+				errs[strconv.Itoa(i)] = groupErrs
+			}
 		}
-		attributes, err := app.mapper.MapInventoryAttributes(ctx, searchParams.TenantID,
-			attributes, false, false)
-		if err != nil {
-			return err
+		return errs
+	}
+
+	errs := validation.Errors{}
+	if ferrs := checkFilters(searchParams.Filters); len(ferrs) > 0 {
+		errs["filters"] = ferrs
+	}
+	if gerrs := checkGroups(searchParams.FilterGroups); len(gerrs) > 0 {
+		errs["filter_groups"] = gerrs
+	}
+	if err != nil {
+		return err
+	} else if len(errs) > 0 {
+		return filterTypesError{Errors: errs}
+	}
+	return nil
+}
+
+func (app *app) mapSearchParams(ctx context.Context, searchParams *model.SearchParams) error {
+	if err := app.restrictSearchParams(searchParams); err != nil {
+		return err
+	}
+	app.hashSearchFilters(searchParams)
+	if err := app.mapSearchFilters(ctx, searchParams); err != nil {
+		return err
+	}
+	if err := app.mapSearchFilterGroups(ctx, searchParams); err != nil {
+		return err
+	}
+	if err := app.mapSearchAttributes(ctx, searchParams); err != nil {
+		return err
+	}
+	if err := app.mapSearchSort(ctx, searchParams); err != nil {
+		return err
+	}
+	return app.checkFilterTypes(ctx, searchParams)
+}
+
+// restrictSearchParams checks the visibility of the attributes the search
+// refers to, and tracks their usage; it runs before the mapping, as both
+// refer to the attributes by the names the user knows them by
+func (app *app) restrictSearchParams(searchParams *model.SearchParams) error {
+	if err := checkSearchVisibility(searchParams); err != nil {
+		return err
+	}
+	app.trackSearchParams(searchParams)
+	return nil
+}
+
+// hashSearchFilters hashes the values of the filters on the redacted
+// attributes; the filters are hashed, and mapped, on copies: the caller's
+// parameters may be reused
+func (app *app) hashSearchFilters(searchParams *model.SearchParams) {
+	if searchParams.Filters != nil {
+		filters := make([]model.FilterPredicate, len(searchParams.Filters))
+		for i := range searchParams.Filters {
+			filters[i] = app.redaction.HashFilter(searchParams.Filters[i])
 		}
-		searchParams.Attributes = make([]model.SelectAttribute, 0, len(searchParams.Attributes))
-		for _, attribute := range attributes {
-			searchParams.Attributes = append(searchParams.Attributes, model.SelectAttribute{
-				Attribute: attribute.Name,
-				Scope:     attribute.Scope,
-			})
+		searchParams.Filters = filters
+	}
+	if searchParams.FilterGroups != nil {
+		groups := make([]model.FilterGroup, len(searchParams.FilterGroups))
+		for i := range searchParams.FilterGroups {
+			groups[i] = searchParams.FilterGroups[i].Clone()
+			for _, p := range groups[i].Predicates() {
+				*p = app.redaction.HashFilter(*p)
+			}
 		}
+		searchParams.FilterGroups = groups
+	}
+}
+
+func (app *app) mapSearchFilters(ctx context.Context, searchParams *model.SearchParams) error {
+	if len(searchParams.Filters) == 0 {
+		return nil
+	}
+	attributes := make(inventory.DeviceAttributes, 0, len(searchParams.Filters))
+	for i := 0; i < len(searchParams.Filters); i++ {
+		attributes = append(attributes, inventory.DeviceAttribute{
+			Name:        searchParams.Filters[i].Attribute,
+			Scope:       searchParams.Filters[i].Scope,
+			Value:       searchParams.Filters[i].Value,
+			Description: &searchParams.Filters[i].Type,
+		})
+	}
+	attributes, err := app.mapper.MapInventoryAttributes(ctx, searchParams.TenantID,
+		attributes, false, true)
+	if err != nil {
+		return err
+	}
+	types, err := app.mapper.AttributeTypes(ctx, searchParams.TenantID)
+	if err != nil {
+		return err
+	}
+	searchParams.Filters = make([]model.FilterPredicate, 0, len(searchParams.Filters))
+	for _, attribute := range attributes {
+		searchParams.Filters = append(searchParams.Filters, model.FilterPredicate{
+			Attribute: attribute.Name,
+			Scope:     attribute.Scope,
+			Value:     attribute.Value,
+			Type:      *attribute.Description,
+			IndexedAs: types[path.Join(attribute.Scope, attribute.Name)],
+		})
 	}
-	if len(searchParams.Sort) > 0 {
-		attributes := make(inventory.DeviceAttributes, 0, len(searchParams.Sort))
-		for i := 0; i < len(searchParams.Sort); i++ {
+	return nil
+}
+
+func (app *app) mapSearchFilterGroups(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) error {
+	for i := range searchParams.FilterGroups {
+		// the attributes pass through the mapping, so they can be
+		// updated in place
+		predicates := searchParams.FilterGroups[i].Predicates()
+		attributes := make(inventory.DeviceAttributes, 0, len(predicates))
+		for _, p := range predicates {
 			attributes = append(attributes, inventory.DeviceAttribute{
-				Name:        searchParams.Sort[i].Attribute,
-				Scope:       searchParams.Sort[i].Scope,
-				Description: &searchParams.Sort[i].Order,
+				Name:  p.Attribute,
+				Scope: p.Scope,
 			})
 		}
 		attributes, err := app.mapper.MapInventoryAttributes(ctx, searchParams.TenantID,
-			attributes, false, false)
+			attributes, false, true)
 		if err != nil {
 			return err
 		}
-		searchParams.Sort = make([]model.SortCriteria, 0, len(searchParams.Attributes))
-		for _, attribute := range attributes {
-			searchParams.Sort = append(searchParams.Sort, model.SortCriteria{
-				Attribute: attribute.Name,
-				Scope:     attribute.Scope,
-				Order:     *attribute.Description,
-			})
+		types, err := app.mapper.AttributeTypes(ctx, searchParams.TenantID)
+		if err != nil {
+			return err
 		}
+		for j, attribute := range attributes {
+			predicates[j].Attribute = attribute.Name
+			predicates[j].IndexedAs = types[path.Join(attribute.Scope, attribute.Name)]
+		}
+	}
+	return nil
+}
+
+func (app *app) mapSearchAttributes(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) error {
+	if len(searchParams.Attributes) == 0 {
+		return nil
+	}
+	attributes := make(inventory.DeviceAttributes, 0, len(searchParams.Attributes))
+	for i := 0; i < len(searchParams.Attributes); i++ {
+		attributes = append(attributes, inventory.DeviceAttribute{
+			Name:  searchParams.Attributes[i].Attribute,
+			Scope: searchParams.Attributes[i].Scope,
+		})
+	}
+	attributes, err := app.mapper.MapInventoryAttributes(ctx, searchParams.TenantID,
+		attributes, false, false)
+	if err != nil {
+		return err
+	}
+	types, err := app.mapper.AttributeTypes(ctx, searchParams.TenantID)
+	if err != nil {
+		return err
+	}
+	searchParams.Attributes = make([]model.SelectAttribute, 0, len(searchParams.Attributes))
+	for _, attribute := range attributes {
+		searchParams.Attributes = append(searchParams.Attributes, model.SelectAttribute{
+			Attribute: attribute.Name,
+			Scope:     attribute.Scope,
+			IndexedAs: types[path.Join(attribute.Scope, attribute.Name)],
+		})
 	}
+	return nil
+}
 
+func (app *app) mapSearchSort(ctx context.Context, searchParams *model.SearchParams) error {
+	if len(searchParams.Sort) == 0 {
+		return nil
+	}
+	// the value is the index of the sort criteria, as the criteria
+	// on attributes missing from the mapping are dropped
+	attributes := make(inventory.DeviceAttributes, 0, len(searchParams.Sort))
+	for i := 0; i < len(searchParams.Sort); i++ {
+		attributes = append(attributes, inventory.DeviceAttribute{
+			Name:  searchParams.Sort[i].Attribute,
+			Scope: searchParams.Sort[i].Scope,
+			Value: i,
+		})
+	}
+	attributes, err := app.mapper.MapInventoryAttributes(ctx, searchParams.TenantID,
+		attributes, false, false)
+	if err != nil {
+		return err
+	}
+	types, err := app.mapper.AttributeTypes(ctx, searchParams.TenantID)
+	if err != nil {
+		return err
+	}
+	sortCriteria := make([]model.SortCriteria, 0, len(attributes))
+	for _, attribute := range attributes {
+		criteria := searchParams.Sort[attribute.Value.(int)]
+		criteria.Attribute = attribute.Name
+		criteria.IndexedAs = types[path.Join(attribute.Scope, attribute.Name)]
+		sortCriteria = append(sortCriteria, criteria)
+	}
+	searchParams.Sort = sortCriteria
 	return nil
 }
 
@@ -433,9 +1068,58 @@ func (a *app) storeToInventoryDev(ctx context.Context, tenantID string,
 	}
 	ret.Attributes = attributes
 
+	if highlightM, ok := resM["highlight"].(map[string]interface{}); ok {
+		ret.Highlights, err = a.storeToHighlights(ctx, tenantID, highlightM)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return ret, nil
 }
 
+// storeToHighlights translates the highlighted fields of a hit to the
+// attributes they belong to, with the matching fragments as value
+func (a *app) storeToHighlights(ctx context.Context, tenantID string,
+	highlightM map[string]interface{}) (inventory.DeviceAttributes, error) {
+	attrs := make(inventory.DeviceAttributes, 0, len(highlightM))
+	for k, v := range highlightM {
+		fragments, ok := v.([]interface{})
+		if !ok {
+			return nil, errors.New("can't process hit's 'highlight'")
+		}
+		if k == model.FieldNameID {
+			attrs = append(attrs, inventory.DeviceAttribute{
+				Name:  model.FieldNameID,
+				Scope: model.ScopeIdentity,
+				Value: fragments,
+			})
+			continue
+		}
+		s, n, err := model.MaybeParseAttr(k)
+		if err != nil {
+			return nil, err
+		}
+		if n != "" {
+			attrs = append(attrs, inventory.DeviceAttribute{
+				Name:  model.Redot(n),
+				Scope: s,
+				Value: fragments,
+			})
+		}
+	}
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].Scope != attrs[j].Scope {
+			return attrs[i].Scope < attrs[j].Scope
+		}
+		return attrs[i].Name < attrs[j].Name
+	})
+	return a.mapper.ReverseInventoryAttributes(ctx, tenantID, attrs)
+}
+
 func parseTime(v interface{}) time.Time {
 	val, _ := v.(string)
 	if t, err := time.Parse(time.RFC3339, val); err == nil {
@@ -444,12 +1128,11 @@ func parseTime(v interface{}) time.Time {
 	return time.Time{}
 }
 
-func (app *app) GetSearchableInvAttrs(
+// getDevicesIndexFields returns the fields of the devices index mapping
+func (app *app) getDevicesIndexFields(
 	ctx context.Context,
 	tid string,
-) ([]model.FilterAttribute, error) {
-	l := log.FromContext(ctx)
-
+) (map[string]interface{}, error) {
 	index, err := app.store.GetDevicesIndexMapping(ctx, tid)
 	if err != nil {
 		return nil, err
@@ -475,6 +1158,19 @@ func (app *app) GetSearchableInvAttrs(
 	if !ok {
 		return nil, errors.New("can't parse index properties")
 	}
+	return propsM, nil
+}
+
+func (app *app) GetSearchableInvAttrs(
+	ctx context.Context,
+	tid string,
+) ([]model.FilterAttribute, error) {
+	l := log.FromContext(ctx)
+
+	propsM, err := app.getDevicesIndexFields(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
 
 	attrs := []inventory.DeviceAttribute{}
 	for k := range propsM {
@@ -0,0 +1,63 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	ErrAlertNotFound     = store.ErrAlertNotFound
+	ErrAlertNameConflict = store.ErrAlertNameConflict
+)
+
+// CreateAlert stores a new alert, assigning it a new ID
+func (app *app) CreateAlert(ctx context.Context, alert *model.Alert) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	alert.ID = uuid.NewString()
+	alert.CreatedTs = now
+	alert.UpdatedTs = now
+	alert.State = nil
+	return app.ds.InsertAlert(ctx, alert)
+}
+
+// GetAlerts returns the alerts of the tenant
+func (app *app) GetAlerts(ctx context.Context, tenantID string) ([]model.Alert, error) {
+	return app.ds.GetAlerts(ctx, tenantID)
+}
+
+// GetAlert returns the alert of the tenant with the given ID
+func (app *app) GetAlert(ctx context.Context, tenantID, id string) (*model.Alert, error) {
+	return app.ds.GetAlert(ctx, tenantID, id)
+}
+
+// UpdateAlert replaces the definition of an existing alert; the alert is
+// evaluated from scratch, without notifying its current state
+func (app *app) UpdateAlert(ctx context.Context, alert *model.Alert) error {
+	alert.UpdatedTs = time.Now().UTC().Truncate(time.Millisecond)
+	alert.State = nil
+	return app.ds.UpdateAlert(ctx, alert)
+}
+
+// DeleteAlert removes the alert of the tenant with the given ID
+func (app *app) DeleteAlert(ctx context.Context, tenantID, id string) error {
+	return app.ds.DeleteAlert(ctx, tenantID, id)
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestCreateAlert(t *testing.T) {
+	t.Parallel()
+
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("InsertAlert", contextMatcher,
+		mock.MatchedBy(func(alert *model.Alert) bool {
+			return alert.ID != "" &&
+				alert.State == nil &&
+				!alert.CreatedTs.IsZero() &&
+				alert.CreatedTs.Equal(alert.UpdatedTs)
+		})).
+		Return(nil)
+
+	app := NewApp(nil, ds)
+	evaluatedTs := time.Now()
+	alert := &model.Alert{
+		TenantID: "tenant",
+		Name:     "offline devices",
+		State:    &model.AlertState{EvaluatedTs: &evaluatedTs},
+	}
+	err := app.CreateAlert(context.Background(), alert)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, alert.ID)
+}
+
+func TestUpdateAlert(t *testing.T) {
+	t.Parallel()
+
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("UpdateAlert", contextMatcher,
+		mock.AnythingOfType("*model.Alert")).
+		Return(ErrAlertNotFound)
+
+	app := NewApp(nil, ds)
+	alert := &model.Alert{
+		ID:       "0f1f5a0e-61a9-4d6c-9c1d-5f0c0b7e3a41",
+		TenantID: "tenant",
+		Name:     "offline devices",
+	}
+	err := app.UpdateAlert(context.Background(), alert)
+	assert.Equal(t, ErrAlertNotFound, err)
+	assert.False(t, alert.UpdatedTs.IsZero())
+}
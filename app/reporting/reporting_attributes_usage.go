@@ -0,0 +1,226 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// AttributesUsageTracker counts the uses of the attributes by the queries
+// of the tenants, which are flushed to the data store
+type AttributesUsageTracker struct {
+	ds store.DataStore
+
+	mutex sync.Mutex
+	// usage are the uses of the attributes since the last flush, by
+	// tenant ID and key of the attribute
+	usage map[string]map[string]*model.AttributeUsage
+	now   func() time.Time
+}
+
+// NewAttributesUsageTracker returns a tracker of the uses of the attributes,
+// whose counts are flushed to the data store
+func NewAttributesUsageTracker(ds store.DataStore) *AttributesUsageTracker {
+	return &AttributesUsageTracker{
+		ds:    ds,
+		usage: map[string]map[string]*model.AttributeUsage{},
+		now:   time.Now,
+	}
+}
+
+// WithAttributesUsageTracker sets the tracker counting the uses of the
+// attributes in the filters, sorts and aggregations of the queries
+func WithAttributesUsageTracker(tracker *AttributesUsageTracker) Option {
+	return func(app *app) {
+		app.attributesUsage = tracker
+	}
+}
+
+// Track counts a use of the tenant's attribute; the queries across all the
+// tenants are not tracked
+func (t *AttributesUsageTracker) Track(tenantID, scope, name string, use model.AttributeUse) {
+	if t == nil || tenantID == "" || name == "" {
+		return
+	}
+	now := t.now().UTC().Truncate(time.Millisecond)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.add(tenantID, model.AttributeUsage{Scope: scope, Name: name}).Add(use, now)
+}
+
+func (t *AttributesUsageTracker) add(tenantID string,
+	attr model.AttributeUsage) *model.AttributeUsage {
+	attrs, ok := t.usage[tenantID]
+	if !ok {
+		attrs = map[string]*model.AttributeUsage{}
+		t.usage[tenantID] = attrs
+	}
+	usage, ok := attrs[attr.Key()]
+	if !ok {
+		usage = &model.AttributeUsage{Scope: attr.Scope, Name: attr.Name}
+		attrs[attr.Key()] = usage
+	}
+	return usage
+}
+
+// Flush adds the uses of the attributes counted since the last flush to
+// their counts in the data store; the uses are kept for the next flush if
+// it fails
+func (t *AttributesUsageTracker) Flush(ctx context.Context) error {
+	t.mutex.Lock()
+	pending := t.usage
+	t.usage = map[string]map[string]*model.AttributeUsage{}
+	t.mutex.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	usage := make(map[string][]model.AttributeUsage, len(pending))
+	for tenantID, attrs := range pending {
+		for _, attr := range attrs {
+			usage[tenantID] = append(usage[tenantID], *attr)
+		}
+	}
+	if err := t.ds.IncrementAttributesUsage(ctx, usage); err != nil {
+		t.mutex.Lock()
+		for tenantID, attrs := range pending {
+			for _, attr := range attrs {
+				current := t.add(tenantID, *attr)
+				current.Filters += attr.Filters
+				current.Sorts += attr.Sorts
+				current.Aggregations += attr.Aggregations
+				if current.LastUsedTs == nil || attr.LastUsedTs.After(*current.LastUsedTs) {
+					current.LastUsedTs = attr.LastUsedTs
+				}
+			}
+		}
+		t.mutex.Unlock()
+		return errors.Wrap(err, "failed to flush the usage of the attributes")
+	}
+	return nil
+}
+
+// Run flushes the uses of the attributes every interval, until the context
+// is done
+func (t *AttributesUsageTracker) Run(ctx context.Context, interval time.Duration) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := t.Flush(ctx); err != nil {
+			l.Error(err)
+		}
+	}
+}
+
+// trackSearchParams counts the uses of the attributes in the filters and
+// sorts of the search, before they are mapped
+func (app *app) trackSearchParams(searchParams *model.SearchParams) {
+	tenantID := searchParams.TenantID
+	for _, filter := range searchParams.Filters {
+		app.attributesUsage.Track(tenantID, filter.Scope, filter.Attribute,
+			model.AttributeUseFilter)
+	}
+	for i := range searchParams.FilterGroups {
+		for _, p := range searchParams.FilterGroups[i].Predicates() {
+			app.attributesUsage.Track(tenantID, p.Scope, p.Attribute,
+				model.AttributeUseFilter)
+		}
+	}
+	for _, criteria := range searchParams.Sort {
+		app.attributesUsage.Track(tenantID, criteria.Scope, criteria.Attribute,
+			model.AttributeUseSort)
+	}
+}
+
+// trackAggregations counts the uses of the attributes in the (nested)
+// aggregations, before they are mapped
+func (app *app) trackAggregations(tenantID string, aggregations []model.AggregationTerm) {
+	for _, agg := range aggregations {
+		app.attributesUsage.Track(tenantID, agg.Scope, agg.Attribute,
+			model.AttributeUseAggregation)
+		app.trackAggregations(tenantID, agg.Aggregations)
+	}
+}
+
+// GetAttributesUsage returns the usage of the tenant's attributes by its
+// queries, along with the attributes of its mapping never used, sorted by
+// scope and name; the uses not flushed yet by the servers are not counted
+func (app *app) GetAttributesUsage(
+	ctx context.Context,
+	tenantID string,
+) (*model.AttributesUsage, error) {
+	usage, err := app.ds.GetAttributesUsage(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	tenantMapping, err := app.ds.GetMapping(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	mapped := map[string]bool{}
+	if tenantMapping != nil {
+		for i, attr := range tenantMapping.Inventory {
+			if i >= model.MaxMappingInventoryAttributes {
+				break
+			}
+			if attr != "" {
+				mapped[attr] = true
+			}
+		}
+	}
+	res := &model.AttributesUsage{
+		TenantID:   tenantID,
+		Attributes: make([]model.AttributeUsage, 0, len(usage)+len(mapped)),
+	}
+	used := make(map[string]bool, len(usage))
+	for _, attr := range usage {
+		used[attr.Key()] = true
+		attr.Mapped = mapped[attr.Key()]
+		res.Attributes = append(res.Attributes, attr)
+	}
+	for key := range mapped {
+		if used[key] {
+			continue
+		}
+		attr := model.ParseMappingAttribute(key)
+		res.Attributes = append(res.Attributes, model.AttributeUsage{
+			Scope:  attr.Scope,
+			Name:   attr.Name,
+			Mapped: true,
+		})
+	}
+	sort.Slice(res.Attributes, func(i, j int) bool {
+		if res.Attributes[i].Scope != res.Attributes[j].Scope {
+			return res.Attributes[i].Scope < res.Attributes[j].Scope
+		}
+		return res.Attributes[i].Name < res.Attributes[j].Name
+	})
+	return res, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestAttributesUsageTracker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 3, 6, 7, 0, 0, 0, time.UTC)
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	tracker := NewAttributesUsageTracker(ds)
+	tracker.now = func() time.Time { return now }
+
+	app := NewApp(nil, ds, WithAttributesUsageTracker(tracker)).(*app)
+	app.trackSearchParams(&model.SearchParams{
+		TenantID: "tenant",
+		Filters: []model.FilterPredicate{
+			{Scope: model.ScopeInventory, Attribute: "mac"},
+		},
+		FilterGroups: []model.FilterGroup{{
+			Filters: []model.FilterPredicate{
+				{Scope: model.ScopeInventory, Attribute: "mac"},
+			},
+		}},
+		Sort: []model.SortCriteria{
+			{Scope: model.ScopeIdentity, Attribute: "serial"},
+		},
+	})
+	app.trackAggregations("tenant", []model.AggregationTerm{{
+		Scope:     model.ScopeInventory,
+		Attribute: "mac",
+		Aggregations: []model.AggregationTerm{
+			{Scope: model.ScopeSystem, Attribute: "group"},
+		},
+	}})
+	// the queries across all the tenants are not tracked
+	app.trackSearchParams(&model.SearchParams{
+		Filters: []model.FilterPredicate{
+			{Scope: model.ScopeInventory, Attribute: "mac"},
+		},
+	})
+
+	usageMatcher := func(usage map[string][]model.AttributeUsage) bool {
+		return assert.ElementsMatch(t, []model.AttributeUsage{
+			{Scope: model.ScopeIdentity, Name: "serial", Sorts: 1, LastUsedTs: &now},
+			{Scope: model.ScopeInventory, Name: "mac", Filters: 2, Aggregations: 1,
+				LastUsedTs: &now},
+			{Scope: model.ScopeSystem, Name: "group", Aggregations: 1, LastUsedTs: &now},
+		}, usage["tenant"]) && len(usage) == 1
+	}
+	// the counts are kept for the next flush if it fails
+	ds.On("IncrementAttributesUsage", contextMatcher, mock.MatchedBy(usageMatcher)).
+		Return(errors.New("connection reset")).
+		Once()
+	err := tracker.Flush(context.Background())
+	assert.EqualError(t, err, "failed to flush the usage of the attributes: connection reset")
+
+	ds.On("IncrementAttributesUsage", contextMatcher, mock.MatchedBy(usageMatcher)).
+		Return(nil).
+		Once()
+	err = tracker.Flush(context.Background())
+	assert.NoError(t, err)
+
+	// nothing left to flush
+	err = tracker.Flush(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestGetAttributesUsage(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	lastUsed := time.Date(2023, 3, 6, 7, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		usage    []model.AttributeUsage
+		usageErr error
+		mapping  *model.Mapping
+
+		res *model.AttributesUsage
+		err error
+	}{
+		"ok": {
+			usage: []model.AttributeUsage{
+				{Scope: model.ScopeIdentity, Name: "serial", Sorts: 1, LastUsedTs: &lastUsed},
+				{Scope: model.ScopeInventory, Name: "mac", Filters: 3, LastUsedTs: &lastUsed},
+			},
+			mapping: &model.Mapping{
+				TenantID:  tenantID,
+				Inventory: []string{"inventory/mac", "", "inventory/legacy_serial"},
+			},
+			res: &model.AttributesUsage{
+				TenantID: tenantID,
+				Attributes: []model.AttributeUsage{
+					{Scope: model.ScopeIdentity, Name: "serial", Sorts: 1,
+						LastUsedTs: &lastUsed},
+					{Scope: model.ScopeInventory, Name: "legacy_serial", Mapped: true},
+					{Scope: model.ScopeInventory, Name: "mac", Mapped: true, Filters: 3,
+						LastUsedTs: &lastUsed},
+				},
+			},
+		},
+		"ok, no usage": {
+			usage:   []model.AttributeUsage{},
+			mapping: &model.Mapping{TenantID: tenantID, Inventory: []string{}},
+			res: &model.AttributesUsage{
+				TenantID:   tenantID,
+				Attributes: []model.AttributeUsage{},
+			},
+		},
+		"error": {
+			usageErr: errors.New("connection reset"),
+			err:      errors.New("connection reset"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("GetAttributesUsage", ctx, tenantID).Return(tc.usage, tc.usageErr)
+			if tc.mapping != nil {
+				ds.On("GetMapping", ctx, tenantID).Return(tc.mapping, nil)
+			}
+
+			app := NewApp(nil, ds)
+			res, err := app.GetAttributesUsage(ctx, tenantID)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.res, res)
+			}
+		})
+	}
+}
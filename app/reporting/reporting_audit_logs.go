@@ -0,0 +1,54 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// WithAuditLogRetention deletes the entries of the audit trail once older
+// than retention; a non-positive retention keeps them forever
+func WithAuditLogRetention(retention time.Duration) Option {
+	return func(app *app) {
+		app.auditLogRetention = retention
+	}
+}
+
+// RecordAuditLogEntry appends an entry to the audit trail, assigning it a
+// new ID
+func (app *app) RecordAuditLogEntry(ctx context.Context, entry *model.AuditLogEntry) error {
+	entry.ID = uuid.NewString()
+	if entry.CreatedTs.IsZero() {
+		entry.CreatedTs = time.Now().UTC().Truncate(time.Millisecond)
+	}
+	if app.auditLogRetention > 0 {
+		expireTs := entry.CreatedTs.Add(app.auditLogRetention)
+		entry.ExpireTs = &expireTs
+	}
+	return app.ds.InsertAuditLogEntry(ctx, entry)
+}
+
+// ListAuditLogs returns a page of the audit trail and its total count
+func (app *app) ListAuditLogs(
+	ctx context.Context,
+	params model.AuditLogsParams,
+) ([]model.AuditLogEntry, int, error) {
+	return app.ds.GetAuditLogs(ctx, params)
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestRecordAuditLogEntry(t *testing.T) {
+	t.Parallel()
+
+	createdTs := time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		retention time.Duration
+		expireTs  *time.Time
+	}{
+		"ok, kept forever": {},
+		"ok, with retention": {
+			retention: 24 * time.Hour,
+			expireTs: func() *time.Time {
+				ts := createdTs.Add(24 * time.Hour)
+				return &ts
+			}(),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("InsertAuditLogEntry", contextMatcher,
+				mock.MatchedBy(func(entry *model.AuditLogEntry) bool {
+					return entry.ID != "" &&
+						entry.CreatedTs.Equal(createdTs) &&
+						assert.Equal(t, tc.expireTs, entry.ExpireTs)
+				})).
+				Return(nil)
+
+			app := NewApp(nil, ds, WithAuditLogRetention(tc.retention))
+			err := app.RecordAuditLogEntry(context.Background(), &model.AuditLogEntry{
+				TenantID:  "tenant",
+				Subject:   "user",
+				Method:    "POST",
+				Path:      "/api/management/v1/reporting/devices/search",
+				CreatedTs: createdTs,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
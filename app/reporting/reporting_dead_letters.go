@@ -0,0 +1,58 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	ErrDeadLetterNotFound = store.ErrDeadLetterNotFound
+)
+
+// ListDeadLetters returns a page of the messages the indexer failed to
+// process, and their total count
+func (app *app) ListDeadLetters(
+	ctx context.Context,
+	params model.DeadLettersParams,
+) ([]model.DeadLetter, int, error) {
+	return app.ds.GetDeadLetters(ctx, params)
+}
+
+// GetDeadLetter returns the dead letter with the given ID
+func (app *app) GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error) {
+	return app.ds.GetDeadLetter(ctx, id)
+}
+
+// ReplayDeadLetter publishes the message of the dead letter again, to the
+// subject it was originally published to, and deletes the dead letter
+func (app *app) ReplayDeadLetter(ctx context.Context, id string) error {
+	if app.nats == nil {
+		return ErrReindexNotAvailable
+	}
+	letter, err := app.ds.GetDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = app.nats.JetStreamPublish(letter.Subject, []byte(letter.Data))
+	if err != nil {
+		return fmt.Errorf("failed to replay the dead letter %s: %w", id, err)
+	}
+	return app.ds.DeleteDeadLetter(ctx, id)
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mnats "github.com/mendersoftware/reporting/client/nats/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestReplayDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	const (
+		letterID = "2e3ad8b6-16d5-4b4e-a5c7-d8fa1ed2f3d2"
+		subject  = "WORKFLOWS.reporting"
+		data     = `{"action":"reindex","tenant_id":"tenant","device_id":"1"}`
+	)
+	letter := &model.DeadLetter{
+		ID:      letterID,
+		Subject: subject,
+		Data:    data,
+	}
+
+	testCases := map[string]struct {
+		ds   func() *mstore.DataStore
+		nats func() *mnats.Client
+
+		err error
+	}{
+		"ok": {
+			ds: func() *mstore.DataStore {
+				ds := &mstore.DataStore{}
+				ds.On("GetDeadLetter", contextMatcher, letterID).Return(letter, nil)
+				ds.On("DeleteDeadLetter", contextMatcher, letterID).Return(nil)
+				return ds
+			},
+			nats: func() *mnats.Client {
+				nats := &mnats.Client{}
+				nats.On("JetStreamPublish", subject, []byte(data)).Return(nil)
+				return nats
+			},
+		},
+		"ko, not found": {
+			ds: func() *mstore.DataStore {
+				ds := &mstore.DataStore{}
+				ds.On("GetDeadLetter", contextMatcher, letterID).
+					Return(nil, ErrDeadLetterNotFound)
+				return ds
+			},
+			nats: func() *mnats.Client {
+				return &mnats.Client{}
+			},
+			err: ErrDeadLetterNotFound,
+		},
+		"ko, publish error": {
+			ds: func() *mstore.DataStore {
+				ds := &mstore.DataStore{}
+				ds.On("GetDeadLetter", contextMatcher, letterID).Return(letter, nil)
+				return ds
+			},
+			nats: func() *mnats.Client {
+				nats := &mnats.Client{}
+				nats.On("JetStreamPublish", subject, []byte(data)).
+					Return(errors.New("nats error"))
+				return nats
+			},
+			err: errors.New("failed to replay the dead letter " + letterID + ": nats error"),
+		},
+		"ko, no publisher": {
+			ds: func() *mstore.DataStore {
+				return &mstore.DataStore{}
+			},
+			err: ErrReindexNotAvailable,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ds := tc.ds()
+			defer ds.AssertExpectations(t)
+			var opts []Option
+			if tc.nats != nil {
+				nats := tc.nats()
+				defer nats.AssertExpectations(t)
+				opts = append(opts, WithJobsPublisher(nats, subject))
+			}
+			app := NewApp(nil, ds, opts...)
+
+			err := app.ReplayDeadLetter(context.Background(), letterID)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package reporting
 import (
 	"context"
 	"encoding/json"
+	"sort"
 
 	"github.com/pkg/errors"
 
@@ -116,6 +117,35 @@ func (app *app) SearchDeployments(
 	return res, total, err
 }
 
+// GetDeviceDeploymentsTimeline returns the transitions of the device's
+// status in its latest deployments, from the oldest
+func (app *app) GetDeviceDeploymentsTimeline(
+	ctx context.Context,
+	params model.DeploymentsTimelineParams,
+) ([]model.DeploymentTimelineEvent, error) {
+	deployments, _, err := app.SearchDeployments(ctx, &model.DeploymentsSearchParams{
+		Page:    1,
+		PerPage: params.Limit,
+		Sort: []model.DeploymentsSortCriteria{{
+			Attribute: "device_created",
+			Order:     model.SortOrderDesc,
+		}},
+		DeviceIDs: []string{params.DeviceID},
+		TenantID:  params.TenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := []model.DeploymentTimelineEvent{}
+	for i := range deployments {
+		events = append(events, deployments[i].Timeline()...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
 // storeToInventoryDevs translates ES results directly to inventory devices
 func (a *app) storeToDeployments(
 	ctx context.Context, tenantID string, storeRes map[string]interface{},
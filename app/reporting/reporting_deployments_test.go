@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -115,6 +116,69 @@ func TestAggregateDeployments(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		Name: "ok, date histogram",
+
+		Params: &model.AggregateDeploymentsParams{
+			Aggregations: []model.DeploymentsAggregationTerm{
+				{
+					Name:      "created",
+					Attribute: "deployment_created",
+					Type:      model.AggregationTypeDateHistogram,
+					Interval:  "day",
+				},
+			},
+			TenantID: tenantID,
+		},
+		SearchParams: &model.DeploymentsSearchParams{},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			q, _ := model.BuildDeploymentsQuery(*self.SearchParams)
+			q.Must(model.M{
+				"term": model.M{
+					model.FieldNameTenantID: tenantID,
+				},
+			})
+			aggrs, _ := model.BuildDeploymentsAggregations(self.Params.Aggregations)
+			q = q.WithSize(0).With(map[string]interface{}{
+				"aggs": aggrs,
+			})
+			store.On("AggregateDeployments", contextMatcher, q).
+				Return(model.M{
+					"aggregations": map[string]interface{}{
+						"created": map[string]interface{}{
+							"buckets": []interface{}{
+								map[string]interface{}{
+									"key_as_string": "2023-01-01T00:00:00Z",
+									"key":           float64(1672531200000),
+									"doc_count":     float64(3),
+								},
+								map[string]interface{}{
+									"key_as_string": "2023-01-02T00:00:00Z",
+									"key":           float64(1672617600000),
+									"doc_count":     float64(0),
+								},
+							},
+						},
+					},
+				}, nil)
+			return store
+		},
+		Result: []model.DeviceAggregation{
+			{
+				Name: "created",
+				Items: []model.DeviceAggregationItem{
+					{
+						Key:   "2023-01-01T00:00:00Z",
+						Count: 3,
+					},
+					{
+						Key:   "2023-01-02T00:00:00Z",
+						Count: 0,
+					},
+				},
+			},
+		},
 	}, {
 		Name: "ok, subaggregations",
 
@@ -565,3 +629,115 @@ func TestSearchDeployments(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDeviceDeploymentsTimeline(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "tenant"
+		deviceID = "194d1060-1717-44dc-a783-00038f4a8013"
+	)
+	query, _ := model.BuildDeploymentsQuery(model.DeploymentsSearchParams{
+		Page:    1,
+		PerPage: 10,
+		Sort: []model.DeploymentsSortCriteria{{
+			Attribute: "device_created",
+			Order:     model.SortOrderDesc,
+		}},
+	})
+	query = query.
+		Must(model.M{"term": model.M{model.FieldNameTenantID: tenantID}}).
+		Must(model.M{"terms": model.M{model.FieldNameDeviceID: []string{deviceID}}})
+
+	testCases := map[string]struct {
+		hits     []interface{}
+		storeErr error
+
+		res []model.DeploymentTimelineEvent
+		err string
+	}{
+		"ok": {
+			hits: []interface{}{
+				map[string]interface{}{"_source": map[string]interface{}{
+					"deployment_id":   "2",
+					"deployment_name": "second",
+					"device_status":   "failure",
+					"device_status_history": []interface{}{
+						map[string]interface{}{
+							"status":    "downloading",
+							"timestamp": "2023-10-02T10:00:00Z",
+						},
+						map[string]interface{}{
+							"status":    "failure",
+							"timestamp": "2023-10-02T10:05:00Z",
+						},
+					},
+				}},
+				// indexed before the history was recorded
+				map[string]interface{}{"_source": map[string]interface{}{
+					"deployment_id":   "1",
+					"deployment_name": "first",
+					"device_status":   "success",
+					"device_created":  "2023-10-01T10:00:00Z",
+					"device_finished": "2023-10-01T10:10:00Z",
+				}},
+			},
+			res: []model.DeploymentTimelineEvent{{
+				DeploymentID:   "1",
+				DeploymentName: "first",
+				Status:         "success",
+				Timestamp:      time.Date(2023, 10, 1, 10, 10, 0, 0, time.UTC),
+			}, {
+				DeploymentID:   "2",
+				DeploymentName: "second",
+				Status:         "downloading",
+				Timestamp:      time.Date(2023, 10, 2, 10, 0, 0, 0, time.UTC),
+			}, {
+				DeploymentID:   "2",
+				DeploymentName: "second",
+				Status:         "failure",
+				Timestamp:      time.Date(2023, 10, 2, 10, 5, 0, 0, time.UTC),
+			}},
+		},
+		"ok, no deployments": {
+			hits: []interface{}{},
+			res:  []model.DeploymentTimelineEvent{},
+		},
+		"ko, store error": {
+			storeErr: errors.New("store error"),
+			err:      "store error",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			var res model.M
+			if tc.storeErr == nil {
+				res = model.M{"hits": map[string]interface{}{
+					"hits":  tc.hits,
+					"total": map[string]interface{}{"value": float64(len(tc.hits))},
+				}}
+			}
+			store.On("SearchDeployments", contextMatcher, query).Return(res, tc.storeErr)
+
+			app := NewApp(store, nil)
+			events, err := app.GetDeviceDeploymentsTimeline(context.Background(),
+				model.DeploymentsTimelineParams{
+					TenantID: tenantID,
+					DeviceID: deviceID,
+					Limit:    10,
+				})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.res, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/mapping"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	// AttributeMissing is the status of the attributes of the live state
+	// of the device which are not indexed
+	AttributeMissing = "missing"
+	// AttributeStale is the status of the attributes indexed with a value
+	// different from the one of the live state of the device
+	AttributeStale = "stale"
+	// AttributeUnexpected is the status of the indexed attributes which
+	// are not in the live state of the device
+	AttributeUnexpected = "unexpected"
+
+	reasonDeviceNotIndexed      = "the device is not indexed"
+	reasonDeviceExcluded        = "the device is excluded by the indexing rules"
+	reasonAttributeExcluded     = "the attribute is excluded by the indexing rules"
+	reasonAttributeNotInMapping = "the attribute is not in the attributes mapping"
+)
+
+var (
+	// ErrDeviceDiffNotAvailable is returned when the app has no clients
+	// to fetch the live state of the devices from
+	ErrDeviceDiffNotAvailable = errors.New("comparing the devices with their live state " +
+		"is not available")
+)
+
+// DeviceDiff compares the indexed document of a device with its live state
+// in inventory and deviceauth
+type DeviceDiff struct {
+	ID string `json:"id"`
+	// Indexed is the indexed document of the device, if indexed
+	Indexed *inventory.Device `json:"indexed"`
+	// Inventory and DeviceAuth are the live state of the device, if the
+	// device exists
+	Inventory  *inventory.Device            `json:"inventory"`
+	DeviceAuth *deviceauth.DeviceAuthDevice `json:"deviceauth"`
+	// Reason explains why the device is not indexed
+	Reason string `json:"reason,omitempty"`
+	// Attributes are the attributes which differ, sorted by scope and name
+	Attributes []AttributeDiff `json:"attributes"`
+}
+
+// AttributeDiff is an attribute whose indexed value differs from its live
+// value
+type AttributeDiff struct {
+	Scope   string      `json:"scope"`
+	Name    string      `json:"name"`
+	Status  string      `json:"status"`
+	Indexed interface{} `json:"indexed,omitempty"`
+	Live    interface{} `json:"live,omitempty"`
+	// Reason explains why the attribute is missing, if known
+	Reason string `json:"reason,omitempty"`
+}
+
+// WithDeviceSources sets the clients of the services the live state of
+// the devices is fetched from, to compare it with the indexed documents
+func WithDeviceSources(inv inventory.Client, devauth deviceauth.Client) Option {
+	return func(app *app) {
+		app.invClient = inv
+		app.devClient = devauth
+	}
+}
+
+// DiffDevice compares the indexed document of the tenant's device with its
+// live state, as the indexer would index it: the values are redacted, and
+// the system attributes computed by the indexer are ignored
+func (app *app) DiffDevice(
+	ctx context.Context,
+	tenantID, deviceID string,
+) (*DeviceDiff, error) {
+	if app.invClient == nil || app.devClient == nil {
+		return nil, ErrDeviceDiffNotAvailable
+	}
+	diff := &DeviceDiff{
+		ID:         deviceID,
+		Attributes: []AttributeDiff{},
+	}
+	if err := app.fetchDeviceStates(ctx, tenantID, deviceID, diff); err != nil {
+		return nil, err
+	}
+
+	rules, err := app.ds.GetIndexingRules(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	tenantMapping, err := app.ds.GetMapping(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := app.liveAttributes(diff.Inventory, diff.DeviceAuth)
+	if diff.Indexed == nil {
+		diff.Reason = reasonDeviceNotIndexed
+		if !rules.Match(live) {
+			diff.Reason = reasonDeviceExcluded
+		}
+	}
+	indexed := indexedAttributes(diff.Indexed)
+	diff.Attributes = append(diff.Attributes,
+		liveAttributesDiff(live, indexed, rules, mappedAttributes(tenantMapping))...)
+	diff.Attributes = append(diff.Attributes, unexpectedAttributesDiff(live, indexed)...)
+	sort.Slice(diff.Attributes, func(i, j int) bool {
+		if diff.Attributes[i].Scope != diff.Attributes[j].Scope {
+			return diff.Attributes[i].Scope < diff.Attributes[j].Scope
+		}
+		return diff.Attributes[i].Name < diff.Attributes[j].Name
+	})
+	return diff, nil
+}
+
+// fetchDeviceStates sets the indexed document and the live state of the
+// device in inventory and deviceauth on the diff, returning
+// ErrDeviceNotFound if the device is found nowhere
+func (app *app) fetchDeviceStates(
+	ctx context.Context,
+	tenantID, deviceID string,
+	diff *DeviceDiff,
+) error {
+	indexed, err := app.GetDevice(ctx, tenantID, deviceID)
+	if err == nil {
+		diff.Indexed = indexed
+	} else if err != ErrDeviceNotFound {
+		return err
+	}
+	invDevices, err := app.invClient.GetDevices(ctx, tenantID, []string{deviceID})
+	if err != nil {
+		return err
+	}
+	for i := range invDevices {
+		if invDevices[i].ID == inventory.DeviceID(deviceID) {
+			diff.Inventory = &invDevices[i]
+		}
+	}
+	authDevices, err := app.devClient.GetDevices(ctx, tenantID, []string{deviceID})
+	if err != nil {
+		return err
+	}
+	for i := range authDevices {
+		if authDevices[i].ID == deviceID {
+			diff.DeviceAuth = &authDevices[i]
+		}
+	}
+	if diff.Indexed == nil && diff.Inventory == nil && diff.DeviceAuth == nil {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+// mappedAttributes returns the inventory attributes of the tenant's
+// mapping, as scope/name
+func mappedAttributes(tenantMapping *model.Mapping) map[string]bool {
+	mapped := map[string]bool{}
+	if tenantMapping != nil {
+		for i, attr := range tenantMapping.Inventory {
+			if i >= model.MaxMappingInventoryAttributes {
+				break
+			}
+			mapped[attr] = true
+		}
+	}
+	return mapped
+}
+
+// indexedAttributes returns the attributes of the indexed document of the
+// device, but the ones not from its live state
+func indexedAttributes(indexed *inventory.Device) model.AttributeValues {
+	attrs := model.AttributeValues{}
+	if indexed == nil {
+		return attrs
+	}
+	for _, attr := range indexed.Attributes {
+		if attr.Scope == model.ScopeSystem &&
+			attr.Name == model.AttrNameLatestDeploymentStatus {
+			// from the deployments, not from the live state
+			continue
+		}
+		attrs.Set(attr.Scope, attr.Name, attr.Value)
+	}
+	return attrs
+}
+
+// liveAttributesDiff returns the attributes of the live state of the
+// device which are missing from the indexed document, with the reason if
+// known, or indexed with a stale value
+func liveAttributesDiff(
+	live, indexed model.AttributeValues,
+	rules *model.IndexingRules,
+	mapped map[string]bool,
+) []AttributeDiff {
+	var diffs []AttributeDiff
+	for scope, attrs := range live {
+		for name, value := range attrs {
+			indexedValue, ok := indexed.Get(scope, name)
+			if !ok {
+				attr := AttributeDiff{
+					Scope:  scope,
+					Name:   name,
+					Status: AttributeMissing,
+					Live:   value,
+				}
+				if !rules.IndexesAttribute(scope, name) {
+					attr.Reason = reasonAttributeExcluded
+				} else if mapping.MapsAttribute(scope, name) &&
+					!mapped[path.Join(scope, name)] {
+					attr.Reason = reasonAttributeNotInMapping
+				}
+				diffs = append(diffs, attr)
+			} else if !equalValues(indexedValue, value) {
+				diffs = append(diffs, AttributeDiff{
+					Scope:   scope,
+					Name:    name,
+					Status:  AttributeStale,
+					Indexed: indexedValue,
+					Live:    value,
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+// unexpectedAttributesDiff returns the indexed attributes which are not in
+// the live state of the device
+func unexpectedAttributesDiff(live, indexed model.AttributeValues) []AttributeDiff {
+	var diffs []AttributeDiff
+	for scope, attrs := range indexed {
+		for name, value := range attrs {
+			if _, ok := live.Get(scope, name); !ok {
+				diffs = append(diffs, AttributeDiff{
+					Scope:   scope,
+					Name:    name,
+					Status:  AttributeUnexpected,
+					Indexed: value,
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+// liveAttributes returns the attributes of the live state of the device,
+// redacted as indexed: the identity data and the status from deviceauth
+// take precedence over the ones from inventory
+func (app *app) liveAttributes(
+	inventoryDevice *inventory.Device,
+	deviceAuthDevice *deviceauth.DeviceAuthDevice,
+) model.AttributeValues {
+	attrs := model.AttributeValues{}
+	set := func(scope, name string, value interface{}) {
+		attrs.Set(scope, name, app.redaction.Redact(scope, name, value))
+	}
+	if inventoryDevice != nil {
+		for _, attr := range inventoryDevice.Attributes {
+			set(attr.Scope, attr.Name, attr.Value)
+		}
+	}
+	if deviceAuthDevice != nil {
+		for name, value := range deviceAuthDevice.IdDataStruct {
+			set(model.ScopeIdentity, name, value)
+		}
+		set(model.ScopeIdentity, model.AttrNameStatus, deviceAuthDevice.Status)
+	}
+	return attrs
+}
+
+// equalValues compares the indexed value of an attribute with its live
+// value: the single values equal the arrays of one value, and the times
+// are compared regardless of their format
+func equalValues(indexed, live interface{}) bool {
+	indexed, live = singleValue(indexed), singleValue(live)
+	if a, ok := indexed.(string); ok {
+		if b, ok := live.(string); ok {
+			ta, errA := time.Parse(time.RFC3339Nano, a)
+			tb, errB := time.Parse(time.RFC3339Nano, b)
+			if errA == nil && errB == nil {
+				return ta.Equal(tb)
+			}
+		}
+	}
+	a, errA := json.Marshal(indexed)
+	b, errB := json.Marshal(live)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+func singleValue(value interface{}) interface{} {
+	if values, ok := value.([]interface{}); ok && len(values) == 1 {
+		return values[0]
+	}
+	return value
+}
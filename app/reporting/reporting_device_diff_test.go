@@ -0,0 +1,237 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	dmocks "github.com/mendersoftware/reporting/client/deviceauth/mocks"
+	"github.com/mendersoftware/reporting/client/inventory"
+	imocks "github.com/mendersoftware/reporting/client/inventory/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestDiffDevice(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "tenant"
+		deviceID = "194d1060-1717-44dc-a783-00038f4a8013"
+	)
+	testCases := map[string]struct {
+		hits       []interface{}
+		invDevices []inventory.Device
+		invErr     error
+		devDevices []deviceauth.DeviceAuthDevice
+		rules      *model.IndexingRules
+
+		reason     string
+		attributes []AttributeDiff
+		err        error
+	}{
+		"ok, indexed": {
+			hits: []interface{}{
+				map[string]interface{}{"_source": map[string]interface{}{
+					"id":        deviceID,
+					"tenant_id": tenantID,
+					model.ToAttr("inventory", "attribute1", model.TypeStr):            "edge-gw-1",
+					model.ToAttr("inventory", "attribute2", model.TypeStr):            "5.10",
+					model.ToAttr("inventory", "attribute3", model.TypeStr):            "2023-03-06T06:00:00Z",
+					model.ToAttr("system", "latest_deployment_status", model.TypeStr): "success",
+				}},
+			},
+			invDevices: []inventory.Device{{
+				ID: deviceID,
+				Attributes: inventory.DeviceAttributes{{
+					Name:  "hostname",
+					Scope: inventory.AttrScopeInventory,
+					Value: "edge-gw-2",
+				}, {
+					Name:  "booted_at",
+					Scope: inventory.AttrScopeInventory,
+					Value: "2023-03-06T07:00:00+01:00",
+				}, {
+					Name:  "mac",
+					Scope: inventory.AttrScopeInventory,
+					Value: "00:11:22:33:44:55",
+				}},
+			}},
+			devDevices: []deviceauth.DeviceAuthDevice{{
+				ID:           deviceID,
+				IdDataStruct: map[string]string{"serial": "1234"},
+				Status:       "accepted",
+			}},
+			rules: &model.IndexingRules{
+				Attributes: &model.AttributesFilter{
+					Excluded: []model.AttributeSelector{{
+						Scope: model.ScopeIdentity,
+						Name:  "serial",
+					}},
+				},
+			},
+			attributes: []AttributeDiff{{
+				Scope:  model.ScopeIdentity,
+				Name:   "serial",
+				Status: AttributeMissing,
+				Live:   "1234",
+				Reason: reasonAttributeExcluded,
+			}, {
+				Scope:  model.ScopeIdentity,
+				Name:   model.AttrNameStatus,
+				Status: AttributeMissing,
+				Live:   "accepted",
+			}, {
+				Scope:   inventory.AttrScopeInventory,
+				Name:    "hostname",
+				Status:  AttributeStale,
+				Indexed: "edge-gw-1",
+				Live:    "edge-gw-2",
+			}, {
+				Scope:   inventory.AttrScopeInventory,
+				Name:    "kernel",
+				Status:  AttributeUnexpected,
+				Indexed: "5.10",
+			}, {
+				Scope:  inventory.AttrScopeInventory,
+				Name:   "mac",
+				Status: AttributeMissing,
+				Live:   "00:11:22:33:44:55",
+				Reason: reasonAttributeNotInMapping,
+			}},
+		},
+		"ok, not indexed": {
+			hits: []interface{}{},
+			invDevices: []inventory.Device{{
+				ID: deviceID,
+				Attributes: inventory.DeviceAttributes{{
+					Name:  "device_type",
+					Scope: inventory.AttrScopeInventory,
+					Value: "raspberrypi4",
+				}},
+			}},
+			reason: reasonDeviceNotIndexed,
+			attributes: []AttributeDiff{{
+				Scope:  inventory.AttrScopeInventory,
+				Name:   "device_type",
+				Status: AttributeMissing,
+				Live:   "raspberrypi4",
+			}},
+		},
+		"ok, excluded by the indexing rules": {
+			hits: []interface{}{},
+			invDevices: []inventory.Device{{
+				ID: deviceID,
+				Attributes: inventory.DeviceAttributes{{
+					Name:  "device_type",
+					Scope: inventory.AttrScopeInventory,
+					Value: "raspberrypi4",
+				}},
+			}},
+			rules: &model.IndexingRules{
+				Filters: []model.FilterPredicate{{
+					Scope:     inventory.AttrScopeInventory,
+					Attribute: "device_type",
+					Type:      "$eq",
+					Value:     "qemux86-64",
+				}},
+			},
+			reason: reasonDeviceExcluded,
+			attributes: []AttributeDiff{{
+				Scope:  inventory.AttrScopeInventory,
+				Name:   "device_type",
+				Status: AttributeMissing,
+				Live:   "raspberrypi4",
+			}},
+		},
+		"error, not found": {
+			hits: []interface{}{},
+			err:  ErrDeviceNotFound,
+		},
+		"error, inventory": {
+			hits:   []interface{}{},
+			invErr: errors.New("connection refused"),
+			err:    errors.New("connection refused"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			store.On("SearchDevices", contextMatcher, mock.Anything).
+				Return(model.M{"hits": map[string]interface{}{
+					"hits": tc.hits,
+					"total": map[string]interface{}{
+						"value": float64(len(tc.hits)),
+					},
+				}}, nil)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(&model.Mapping{
+					TenantID: tenantID,
+					Inventory: []string{
+						"inventory/hostname",
+						"inventory/kernel",
+						"inventory/booted_at",
+						"inventory/device_type",
+						"identity/serial",
+					},
+				}, nil).
+				Maybe()
+			ds.On("GetIndexingRules", contextMatcher, tenantID).
+				Return(tc.rules, nil).
+				Maybe()
+
+			invClient := &imocks.Client{}
+			defer invClient.AssertExpectations(t)
+			invClient.On("GetDevices", contextMatcher, tenantID, []string{deviceID}).
+				Return(tc.invDevices, tc.invErr)
+			devClient := &dmocks.Client{}
+			defer devClient.AssertExpectations(t)
+			if tc.invErr == nil {
+				devClient.On("GetDevices", contextMatcher, tenantID, []string{deviceID}).
+					Return(tc.devDevices, nil)
+			}
+
+			app := NewApp(store, ds, WithDeviceSources(invClient, devClient))
+			diff, err := app.DiffDevice(context.Background(), tenantID, deviceID)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.reason, diff.Reason)
+			assert.Equal(t, tc.attributes, diff.Attributes)
+		})
+	}
+}
+
+func TestDiffDeviceNotAvailable(t *testing.T) {
+	t.Parallel()
+
+	app := NewApp(nil, nil)
+	_, err := app.DiffDevice(context.Background(), "tenant", "device")
+	assert.Equal(t, ErrDeviceDiffNotAvailable, err)
+}
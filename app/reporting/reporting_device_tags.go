@@ -0,0 +1,102 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+var (
+	// ErrDeviceTagsNotAvailable is returned when the app has no inventory
+	// client to set the tags of the devices with
+	ErrDeviceTagsNotAvailable = errors.New("setting the tags of the devices " +
+		"is not available")
+	// ErrInvalidDeviceTags is returned when inventory rejects the tags
+	ErrInvalidDeviceTags = inventory.ErrInvalidTags
+)
+
+// SetDeviceTags replaces the tags of the tenant's device in inventory, on
+// behalf of the user, and writes them through to the indexed document, so
+// that they are searchable right away; the document is indexed again
+// anyway once inventory notifies the change
+func (app *app) SetDeviceTags(
+	ctx context.Context,
+	authorization, tenantID, deviceID string,
+	tags model.DeviceTags,
+) error {
+	if app.invClient == nil {
+		return ErrDeviceTagsNotAvailable
+	}
+	invTags := make([]inventory.Tag, 0, len(tags))
+	for _, tag := range tags {
+		invTags = append(invTags, inventory.Tag(tag))
+	}
+	err := app.invClient.ReplaceDeviceTags(ctx, authorization, deviceID, invTags)
+	if errors.Is(err, inventory.ErrDeviceNotFound) {
+		return ErrDeviceNotFound
+	} else if err != nil {
+		return err
+	}
+	// the tags are set: failing to write them through only delays them
+	if err := app.indexDeviceTags(ctx, tenantID, deviceID, tags); err != nil {
+		log.FromContext(ctx).Warnf("failed to update the tags of the "+
+			"indexed device %s: %s", deviceID, err)
+	}
+	return nil
+}
+
+// indexDeviceTags replaces the tags of the indexed document of the device,
+// selected by the indexing rules of the tenant and redacted as indexed
+func (app *app) indexDeviceTags(
+	ctx context.Context,
+	tenantID, deviceID string,
+	tags model.DeviceTags,
+) error {
+	rules, err := app.ds.GetIndexingRules(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	attrs := make(inventory.DeviceAttributes, 0, len(tags))
+	for _, tag := range tags {
+		if !rules.IndexesAttribute(model.ScopeTags, tag.Name) {
+			continue
+		}
+		attrs = append(attrs, inventory.DeviceAttribute{
+			Scope: model.ScopeTags,
+			Name:  tag.Name,
+			Value: app.redaction.Redact(model.ScopeTags, tag.Name, tag.Value),
+		})
+	}
+	attrs, err = app.mapper.MapInventoryAttributes(ctx, tenantID, attrs, true, false)
+	if err != nil {
+		return err
+	}
+	device := model.NewDevice(tenantID, deviceID)
+	for _, attr := range attrs {
+		err := device.AppendAttr(model.NewInventoryAttribute(attr.Scope).
+			SetName(attr.Name).
+			SetVal(attr.Value))
+		if err != nil {
+			return err
+		}
+	}
+	return app.store.ReplaceDevicesTags(ctx, []*model.Device{device})
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	imocks "github.com/mendersoftware/reporting/client/inventory/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestSetDeviceTags(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID      = "tenant"
+		deviceID      = "194d1060-1717-44dc-a783-00038f4a8013"
+		authorization = "Bearer token"
+	)
+	tags := model.DeviceTags{
+		{Name: "owner", Value: "ops"},
+		{Name: "secret", Value: "excluded"},
+	}
+	rules := &model.IndexingRules{
+		Attributes: &model.AttributesFilter{
+			Excluded: []model.AttributeSelector{
+				{Scope: model.ScopeTags, Name: "secret"},
+			},
+		},
+	}
+	testCases := map[string]struct {
+		noInventory bool
+		invErr      error
+		storeErr    error
+
+		err error
+	}{
+		"ok": {},
+		"ok, failed to write through": {
+			storeErr: errors.New("connection refused"),
+		},
+		"error, device not found": {
+			invErr: inventory.ErrDeviceNotFound,
+			err:    ErrDeviceNotFound,
+		},
+		"error, invalid tags": {
+			invErr: pkgerrors.Wrap(inventory.ErrInvalidTags, "tag name is too long"),
+			err:    ErrInvalidDeviceTags,
+		},
+		"error, not available": {
+			noInventory: true,
+			err:         ErrDeviceTagsNotAvailable,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			invClient := &imocks.Client{}
+			defer invClient.AssertExpectations(t)
+
+			opts := []Option{}
+			if !tc.noInventory {
+				opts = append(opts, WithDeviceSources(invClient, nil))
+				invClient.On("ReplaceDeviceTags", ctx, authorization, deviceID,
+					[]inventory.Tag{
+						{Name: "owner", Value: "ops"},
+						{Name: "secret", Value: "excluded"},
+					}).
+					Return(tc.invErr).
+					Once()
+			}
+			if !tc.noInventory && tc.invErr == nil {
+				ds.On("GetIndexingRules", ctx, tenantID).
+					Return(rules, nil).
+					Once()
+				ds.On("UpdateAndGetMapping", ctx, tenantID, []string{"tags/owner"}).
+					Return(&model.Mapping{
+						TenantID:  tenantID,
+						Inventory: []string{"inventory/artifact_name", "tags/owner"},
+					}, nil).
+					Once()
+				store.On("ReplaceDevicesTags", ctx,
+					mock.MatchedBy(func(devices []*model.Device) bool {
+						return assert.Len(t, devices, 1) &&
+							assert.Equal(t, deviceID, devices[0].GetID()) &&
+							assert.Equal(t, model.InventoryAttributes{{
+								Scope:  model.ScopeTags,
+								Name:   "attribute2",
+								String: []string{"ops"},
+							}}, devices[0].TagsAttributes)
+					})).
+					Return(tc.storeErr).
+					Once()
+			}
+
+			app := NewApp(store, ds, opts...)
+			err := app.SetDeviceTags(ctx, authorization, tenantID, deviceID, tags)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
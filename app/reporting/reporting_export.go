@@ -0,0 +1,149 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/client/s3"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const parquetContentType = "application/vnd.apache.parquet"
+
+var (
+	// ErrExportNotAvailable is returned when the app has no storage to
+	// upload the exports to
+	ErrExportNotAvailable = errors.New("exporting to the storage is not available")
+)
+
+// WithExportStorage enables the exports of the devices to an S3-compatible
+// bucket, as <prefix><tenant ID>/<time>.parquet
+func WithExportStorage(client s3.Client, prefix string) Option {
+	return func(app *app) {
+		app.exportStorage = client
+		app.exportPrefix = prefix
+	}
+}
+
+// ExportDevicesToStorage exports the devices matching the search parameters
+// as a Parquet file, with a column per selected attribute typed after the
+// attribute mapping, and uploads it to the export storage
+func (app *app) ExportDevicesToStorage(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) (*model.DevicesExport, error) {
+	if app.exportStorage == nil {
+		return nil, ErrExportNotAvailable
+	}
+	columns, err := ExportColumns(ctx, app, searchParams.TenantID, searchParams.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	types, err := app.attributeTypes(ctx, searchParams.TenantID, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	// the file is buffered on disk: the upload needs its size
+	file, err := os.CreateTemp("", "export-*.parquet")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	export := &model.DevicesExport{
+		CreatedTs: time.Now().UTC().Truncate(time.Second),
+	}
+	w := NewParquetDevicesWriter(file, columns, types)
+	err = app.ExportDevices(ctx, searchParams, func(devs []inventory.Device) error {
+		export.Devices += len(devs)
+		return w.Write(devs)
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	export.Key = app.exportPrefix + searchParams.TenantID + "/" +
+		export.CreatedTs.Format("20060102T150405Z") + ".parquet"
+	err = app.exportStorage.PutObject(ctx, export.Key, file, parquetContentType)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// attributeTypes returns the types of the attributes, after the fields they
+// are indexed as: the attributes indexed as strings, or not indexed, are
+// strings
+func (app *app) attributeTypes(
+	ctx context.Context,
+	tenantID string,
+	attrs []model.SelectAttribute,
+) ([]model.Type, error) {
+	types := make([]model.Type, len(attrs))
+	for i := range types {
+		types[i] = model.TypeStr
+	}
+	if len(attrs) == 0 {
+		return types, nil
+	}
+	fields, err := app.getDevicesIndexFields(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	// the value is the index of the attribute, as the attributes missing
+	// from the mapping are dropped
+	attributes := make(inventory.DeviceAttributes, len(attrs))
+	for i, attr := range attrs {
+		attributes[i] = inventory.DeviceAttribute{
+			Name:  attr.Attribute,
+			Scope: attr.Scope,
+			Value: i,
+		}
+	}
+	attributes, err = app.mapper.MapInventoryAttributes(ctx, tenantID,
+		attributes, false, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range attributes {
+		has := func(typ model.Type) bool {
+			_, ok := fields[model.ToAttr(attr.Scope, attr.Name, typ)]
+			return ok
+		}
+		i := attr.Value.(int)
+		if has(model.TypeStr) {
+			continue
+		} else if has(model.TypeNum) {
+			types[i] = model.TypeNum
+		} else if has(model.TypeBool) {
+			types[i] = model.TypeBool
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	ms3 "github.com/mendersoftware/reporting/client/s3/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestExportDevicesToStorage(t *testing.T) {
+	t.Parallel()
+	const tenantID = "tenant"
+
+	hits := model.M{"hits": map[string]interface{}{
+		"hits": []interface{}{
+			map[string]interface{}{
+				"_source": map[string]interface{}{
+					"id":                       "device-1",
+					"inventory_attribute1_num": []interface{}{float64(2)},
+				},
+				"sort": []interface{}{"device-1"},
+			},
+			map[string]interface{}{
+				"_source": map[string]interface{}{
+					"id": "device-2",
+				},
+				"sort": []interface{}{"device-2"},
+			},
+		},
+		"total": map[string]interface{}{
+			"value": float64(2),
+		},
+	}}
+	indexMapping := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"inventory_attribute1_num": 1,
+			},
+		},
+	}
+	params := func() *model.SearchParams {
+		return &model.SearchParams{
+			TenantID: tenantID,
+			Attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "cpus",
+			}},
+		}
+	}
+
+	type testCase struct {
+		Name string
+
+		Storage func(*testing.T, testCase) *ms3.Client
+		Store   func(*testing.T, testCase) *mstore.Store
+
+		Devices int
+		Error   error
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Storage: func(t *testing.T, self testCase) *ms3.Client {
+			client := new(ms3.Client)
+			client.On("PutObject", contextMatcher,
+				mock.MatchedBy(func(key string) bool {
+					return strings.HasPrefix(key, "exports/"+tenantID+"/") &&
+						strings.HasSuffix(key, ".parquet")
+				}),
+				mock.AnythingOfType("*os.File"),
+				parquetContentType).
+				Run(func(args mock.Arguments) {
+					// the file is closed and removed once uploaded: read it now
+					b, err := io.ReadAll(args.Get(2).(io.Reader))
+					if assert.NoError(t, err) && assert.Greater(t, len(b), 8) {
+						assert.Equal(t, "PAR1", string(b[:4]))
+						assert.Equal(t, "PAR1", string(b[len(b)-4:]))
+					}
+				}).
+				Return(nil)
+			return client
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+				Return(indexMapping, nil)
+			store.On("SearchDevices", contextMatcher, mock.Anything).
+				Return(hits, nil).
+				Once()
+			return store
+		},
+
+		Devices: 2,
+	}, {
+		Name: "error, export not available",
+
+		Error: ErrExportNotAvailable,
+	}, {
+		Name: "error, internal storage-layer error",
+
+		Storage: func(t *testing.T, self testCase) *ms3.Client {
+			return new(ms3.Client)
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+				Return(indexMapping, nil)
+			store.On("SearchDevices", contextMatcher, mock.Anything).
+				Return(nil, errors.New("internal error")).
+				Once()
+			return store
+		},
+
+		Error: errors.New("internal error"),
+	}, {
+		Name: "error, failed to upload the export",
+
+		Storage: func(t *testing.T, self testCase) *ms3.Client {
+			client := new(ms3.Client)
+			client.On("PutObject", contextMatcher, mock.Anything,
+				mock.Anything, parquetContentType).
+				Return(errors.New("upload failed"))
+			return client
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+				Return(indexMapping, nil)
+			store.On("SearchDevices", contextMatcher, mock.Anything).
+				Return(hits, nil).
+				Once()
+			return store
+		},
+
+		Error: errors.New("upload failed"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			store := new(mstore.Store)
+			if tc.Store != nil {
+				store = tc.Store(t, tc)
+			}
+			defer store.AssertExpectations(t)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(&model.Mapping{
+					TenantID:  tenantID,
+					Inventory: []string{"inventory/cpus"},
+				}, nil).
+				Maybe()
+
+			var opts []Option
+			if tc.Storage != nil {
+				client := tc.Storage(t, tc)
+				defer client.AssertExpectations(t)
+				opts = append(opts, WithExportStorage(client, "exports/"))
+			}
+			app := NewApp(store, ds, opts...)
+
+			export, err := app.ExportDevicesToStorage(context.Background(), params())
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.Devices, export.Devices)
+				assert.Equal(t, "exports/"+tenantID+"/"+
+					export.CreatedTs.Format("20060102T150405Z")+".parquet", export.Key)
+			}
+		})
+	}
+}
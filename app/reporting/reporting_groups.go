@@ -0,0 +1,47 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// ListGroups returns the groups of devices, sorted by descending number of
+// devices; the devices not in any group are not counted
+func (app *app) ListGroups(
+	ctx context.Context,
+	params *model.GroupsParams,
+) ([]model.DeviceGroup, error) {
+	aggregateParams := params.AggregateParams()
+	aggregations, err := app.AggregateDevices(ctx, &aggregateParams)
+	if err != nil {
+		return nil, err
+	}
+	groups := []model.DeviceGroup{}
+	for _, aggregation := range aggregations {
+		if aggregation.Name != model.GroupsAggregation {
+			continue
+		}
+		for _, item := range aggregation.Items {
+			groups = append(groups, model.DeviceGroup{
+				Name:  item.Key,
+				Count: item.Count,
+			})
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestListGroups(t *testing.T) {
+	const tenantID = "tenant"
+	t.Parallel()
+	type testCase struct {
+		Name string
+
+		Params   *model.GroupsParams
+		StoreRes model.M
+		StoreErr error
+
+		Result []model.DeviceGroup
+		Error  error
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Params: &model.GroupsParams{
+			Limit:    5,
+			Groups:   []string{"prod", "staging"},
+			TenantID: tenantID,
+		},
+		StoreRes: model.M{
+			"aggregations": map[string]interface{}{
+				model.GroupsAggregation: map[string]interface{}{
+					"sum_other_doc_count": float64(0),
+					"buckets": []interface{}{
+						map[string]interface{}{
+							"key":       "prod",
+							"doc_count": float64(12),
+						},
+						map[string]interface{}{
+							"key":       "staging",
+							"doc_count": float64(3),
+						},
+					},
+				},
+			},
+		},
+
+		Result: []model.DeviceGroup{
+			{Name: "prod", Count: 12},
+			{Name: "staging", Count: 3},
+		},
+	}, {
+		Name: "ok, no groups",
+
+		Params: &model.GroupsParams{
+			TenantID: tenantID,
+		},
+		StoreRes: model.M{
+			"aggregations": map[string]interface{}{
+				model.GroupsAggregation: map[string]interface{}{
+					"buckets": []interface{}{},
+				},
+			},
+		},
+
+		Result: []model.DeviceGroup{},
+	}, {
+		Name: "error, store",
+
+		Params: &model.GroupsParams{
+			TenantID: tenantID,
+		},
+		StoreErr: errors.New("internal error"),
+
+		Error: errors.New("internal error"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			aggregateParams := tc.Params.AggregateParams()
+			query, _ := model.BuildQuery(model.SearchParams{
+				Groups: tc.Params.Groups,
+			})
+			query = query.Must(model.M{
+				"term": model.M{
+					model.FieldNameTenantID: tenantID,
+				},
+			})
+			aggregations, _ := model.BuildAggregations(aggregateParams.Aggregations)
+			query = query.WithSize(0).With(map[string]interface{}{
+				"aggs": aggregations,
+			})
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			store.On("AggregateDevices", contextMatcher, query).
+				Return(tc.StoreRes, tc.StoreErr)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(&model.Mapping{TenantID: tenantID}, nil)
+
+			app := NewApp(store, ds)
+			res, err := app.ListGroups(context.Background(), tc.Params)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Result, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// healthCheckTimeout is how long a dependency has to respond to the
+// health check
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheckFunc checks the health of a dependency
+type HealthCheckFunc func(ctx context.Context) error
+
+type dependency struct {
+	name  string
+	check HealthCheckFunc
+}
+
+// WithDependency adds a dependency to the ones checked by CheckDependencies
+func WithDependency(name string, check HealthCheckFunc) Option {
+	return func(a *app) {
+		a.dependencies = append(a.dependencies, dependency{
+			name:  name,
+			check: check,
+		})
+	}
+}
+
+// CheckDependencies checks, concurrently, the health of the datastore,
+// the store, NATS, if the app publishes jobs, and the other dependencies
+// the app was configured with
+func (a *app) CheckDependencies(ctx context.Context) *model.HealthReport {
+	dependencies := []dependency{
+		{name: model.DependencyDatastore, check: a.ds.Ping},
+		{name: model.DependencyStore, check: a.store.Ping},
+	}
+	if a.nats != nil {
+		dependencies = append(dependencies, dependency{
+			name: model.DependencyNats,
+			check: func(context.Context) error {
+				if !a.nats.IsConnected() {
+					return errors.New("not connected")
+				}
+				return nil
+			},
+		})
+	}
+	dependencies = append(dependencies, a.dependencies...)
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	results := make([]model.DependencyHealth, len(dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			results[i] = model.DependencyHealth{
+				Name:   dep.name,
+				Status: model.HealthStatusOK,
+			}
+			if err := dep.check(ctx); err != nil {
+				results[i].Status = model.HealthStatusError
+				results[i].Error = err.Error()
+			}
+		}(i, dep)
+	}
+	wg.Wait()
+	return model.NewHealthReport(results)
+}
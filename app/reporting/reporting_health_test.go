@@ -0,0 +1,130 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mnats "github.com/mendersoftware/reporting/client/nats/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		DatastoreErr  error
+		StoreErr      error
+		NatsConnected bool
+		InventoryErr  error
+
+		Report *model.HealthReport
+	}{{
+		Name: "ok",
+
+		NatsConnected: true,
+		Report: &model.HealthReport{
+			Status: model.HealthStatusOK,
+			Dependencies: []model.DependencyHealth{
+				{Name: model.DependencyDatastore, Status: model.HealthStatusOK},
+				{Name: model.DependencyStore, Status: model.HealthStatusOK},
+				{Name: model.DependencyNats, Status: model.HealthStatusOK},
+				{Name: model.ServiceInventory, Status: model.HealthStatusOK},
+			},
+		},
+	}, {
+		Name: "ko, all the dependencies",
+
+		DatastoreErr: errors.New("connection refused"),
+		StoreErr:     errors.New("opensearch cluster health is red"),
+		InventoryErr: errors.New("status 503"),
+		Report: &model.HealthReport{
+			Status: model.HealthStatusError,
+			Dependencies: []model.DependencyHealth{{
+				Name:   model.DependencyDatastore,
+				Status: model.HealthStatusError,
+				Error:  "connection refused",
+			}, {
+				Name:   model.DependencyStore,
+				Status: model.HealthStatusError,
+				Error:  "opensearch cluster health is red",
+			}, {
+				Name:   model.DependencyNats,
+				Status: model.HealthStatusError,
+				Error:  "not connected",
+			}, {
+				Name:   model.ServiceInventory,
+				Status: model.HealthStatusError,
+				Error:  "status 503",
+			}},
+		},
+	}, {
+		Name: "ko, store",
+
+		StoreErr:      errors.New("opensearch cluster health is red"),
+		NatsConnected: true,
+		Report: &model.HealthReport{
+			Status: model.HealthStatusError,
+			Dependencies: []model.DependencyHealth{{
+				Name:   model.DependencyDatastore,
+				Status: model.HealthStatusOK,
+			}, {
+				Name:   model.DependencyStore,
+				Status: model.HealthStatusError,
+				Error:  "opensearch cluster health is red",
+			}, {
+				Name:   model.DependencyNats,
+				Status: model.HealthStatusOK,
+			}, {
+				Name:   model.ServiceInventory,
+				Status: model.HealthStatusOK,
+			}},
+		},
+	}}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ds := &mstore.DataStore{}
+			ds.On("Ping", contextMatcher).Return(tc.DatastoreErr)
+			defer ds.AssertExpectations(t)
+
+			store := &mstore.Store{}
+			store.On("Ping", contextMatcher).Return(tc.StoreErr)
+			defer store.AssertExpectations(t)
+
+			nats := &mnats.Client{}
+			nats.On("IsConnected").Return(tc.NatsConnected)
+			defer nats.AssertExpectations(t)
+
+			app := NewApp(store, ds,
+				WithJobsPublisher(nats, "jobs"),
+				WithDependency(model.ServiceInventory, func(context.Context) error {
+					return tc.InventoryErr
+				}),
+			)
+			report := app.CheckDependencies(context.Background())
+			assert.Equal(t, tc.Report, report)
+		})
+	}
+}
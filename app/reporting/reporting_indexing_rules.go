@@ -0,0 +1,38 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"time"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// GetIndexingRules returns the indexing rules of the tenant
+func (app *app) GetIndexingRules(
+	ctx context.Context,
+	tenantID string,
+) (*model.IndexingRules, error) {
+	return app.ds.GetIndexingRules(ctx, tenantID)
+}
+
+// SetIndexingRules replaces the indexing rules of the tenant; the rules
+// apply to the devices indexed from now on, the devices already indexed
+// are left untouched until they are reindexed
+func (app *app) SetIndexingRules(ctx context.Context, rules *model.IndexingRules) error {
+	rules.UpdatedTs = time.Now().UTC().Truncate(time.Millisecond)
+	return app.ds.SetIndexingRules(ctx, rules)
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestSetIndexingRules(t *testing.T) {
+	t.Parallel()
+
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("SetIndexingRules", contextMatcher,
+		mock.MatchedBy(func(rules *model.IndexingRules) bool {
+			return rules.TenantID == "tenant" && !rules.UpdatedTs.IsZero()
+		})).
+		Return(nil)
+
+	app := NewApp(nil, ds)
+	err := app.SetIndexingRules(context.Background(), &model.IndexingRules{
+		TenantID: "tenant",
+		Filters: []model.FilterPredicate{{
+			Scope:     model.ScopeSystem,
+			Attribute: model.AttrNameGroup,
+			Type:      "$eq",
+			Value:     "production",
+		}},
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,36 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// ErrIndexSettingsNotSupported is returned when the store has no indices
+// whose settings can be updated
+var ErrIndexSettingsNotSupported = store.ErrIndexSettingsNotSupported
+
+// UpdateIndexSettings updates the dynamic settings of the existing indices
+// of the kind, e.g. to disable the refreshes during mass backfills
+func (app *app) UpdateIndexSettings(
+	ctx context.Context,
+	index string,
+	settings *model.IndexSettings,
+) error {
+	return app.store.UpdateIndexSettings(ctx, index, settings)
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/mapping"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const mappingUsageAggregation = "usage"
+
+var (
+	// ErrMappingAttributeInUse is returned when evicting from the mapping
+	// an attribute which is still set for some indexed devices
+	ErrMappingAttributeInUse = errors.New("the attribute is set for some devices")
+)
+
+// GetMappingUsage returns the attributes in the tenant's mapping, along
+// with the number of indexed devices they are set for, and how many new
+// attributes can still be mapped
+func (app *app) GetMappingUsage(ctx context.Context, tid string) (*model.MappingUsage, error) {
+	m, err := app.ds.GetMapping(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make([]model.MappingAttribute, 0, len(m.Inventory))
+	fields := make([]string, 0, len(m.Inventory))
+	filters := make(model.M, len(m.Inventory))
+	for slot, key := range m.Inventory {
+		if slot == model.MaxMappingInventoryAttributes {
+			break
+		} else if key == "" {
+			continue
+		}
+		attr := model.ParseMappingAttribute(key)
+		field := mapping.FieldName(slot)
+		should := model.S{}
+		for _, typ := range []model.Type{model.TypeStr, model.TypeNum, model.TypeBool,
+			model.TypeDate} {
+			should = append(should, model.M{
+				"exists": model.M{"field": model.ToAttr(attr.Scope, field, typ)},
+			})
+		}
+		filters[field] = model.M{
+			"bool": model.M{
+				"minimum_should_match": 1,
+				"should":               should,
+			},
+		}
+		attributes = append(attributes, attr)
+		fields = append(fields, field)
+	}
+
+	if len(attributes) > 0 {
+		query := model.NewQuery().
+			Must(model.M{
+				"term": model.M{
+					model.FieldNameTenantID: tid,
+				},
+			}).
+			WithSize(0).
+			With(map[string]interface{}{
+				"aggs": model.M{
+					mappingUsageAggregation: model.M{
+						"filters": model.M{
+							"filters": filters,
+						},
+					},
+				},
+			})
+		esRes, err := app.store.AggregateDevices(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		aggregations, _ := esRes["aggregations"].(map[string]interface{})
+		aggregation, _ := aggregations[mappingUsageAggregation].(map[string]interface{})
+		buckets, ok := aggregation["buckets"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("can't process store aggregations")
+		}
+		for i, field := range fields {
+			bucket, _ := buckets[field].(map[string]interface{})
+			if count, ok := bucket["doc_count"].(float64); ok {
+				attributes[i].Devices = int(count)
+			}
+		}
+	}
+
+	return &model.MappingUsage{
+		Limit:      model.MaxMappingInventoryAttributes,
+		Count:      len(attributes),
+		Remaining:  model.MaxMappingInventoryAttributes - len(attributes),
+		Attributes: attributes,
+	}, nil
+}
+
+// EvictMappingAttributes removes the attributes from the tenant's mapping,
+// freeing their slots for new attributes; the attributes not in the
+// mapping are skipped, while the attributes still set for some indexed
+// devices can't be evicted, for their values not to be taken for the
+// values of the attributes reusing their slots
+func (app *app) EvictMappingAttributes(
+	ctx context.Context,
+	tid string,
+	attributes []model.MappingAttribute,
+) (*model.MappingUsage, error) {
+	usage, err := app.GetMappingUsage(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	devices := make(map[string]int, len(usage.Attributes))
+	for _, attr := range usage.Attributes {
+		devices[attr.Key()] = attr.Devices
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for _, attr := range attributes {
+		n, ok := devices[attr.Key()]
+		if !ok {
+			continue
+		} else if n > 0 {
+			return nil, errors.Wrap(ErrMappingAttributeInUse,
+				fmt.Sprintf("%s (%d devices)", attr.Key(), n))
+		}
+		keys = append(keys, attr.Key())
+	}
+	if len(keys) == 0 {
+		return usage, nil
+	}
+
+	if err := app.ds.EvictMappingAttributes(ctx, tid, keys); err != nil {
+		return nil, err
+	}
+	return app.GetMappingUsage(ctx, tid)
+}
+
+// GetMappingTypes returns the types the tenant's attributes are declared
+// to be indexed as
+func (app *app) GetMappingTypes(ctx context.Context, tid string) (*model.MappingTypes, error) {
+	m, err := app.ds.GetMapping(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	types := m.Types
+	if types == nil {
+		types = []model.MappingAttributeType{}
+	}
+	return &model.MappingTypes{Types: types}, nil
+}
+
+// SetMappingTypes replaces the types the tenant's attributes are declared
+// to be indexed as: the devices are indexed with the new types from now
+// on, within the cache TTL of the mappings, while the values of the devices
+// already indexed are converted by the migrations
+func (app *app) SetMappingTypes(ctx context.Context, tid string,
+	types []model.MappingAttributeType) error {
+	m, err := app.ds.GetMapping(ctx, tid)
+	if err != nil {
+		return err
+	}
+	return app.ds.SetMappingTypes(ctx, tid, types, m.ChangedTypes(types))
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestGetMappingUsage(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	mapping := &model.Mapping{
+		TenantID:  tenantID,
+		Inventory: []string{"inventory/mac", "", "identity/serial"},
+	}
+	testCases := []struct {
+		Name string
+
+		Mapping  *model.Mapping
+		StoreRes model.M
+		StoreErr error
+
+		Usage *model.MappingUsage
+		Error error
+	}{{
+		Name: "ok",
+
+		Mapping: mapping,
+		StoreRes: model.M{
+			"aggregations": map[string]interface{}{
+				mappingUsageAggregation: map[string]interface{}{
+					"buckets": map[string]interface{}{
+						"attribute1": map[string]interface{}{"doc_count": float64(10)},
+						"attribute3": map[string]interface{}{"doc_count": float64(0)},
+					},
+				},
+			},
+		},
+		Usage: &model.MappingUsage{
+			Limit:     model.MaxMappingInventoryAttributes,
+			Count:     2,
+			Remaining: model.MaxMappingInventoryAttributes - 2,
+			Attributes: []model.MappingAttribute{
+				{Scope: "inventory", Name: "mac", Devices: 10},
+				{Scope: "identity", Name: "serial"},
+			},
+		},
+	}, {
+		Name: "ok, empty mapping",
+
+		Mapping: &model.Mapping{TenantID: tenantID, Inventory: []string{}},
+		Usage: &model.MappingUsage{
+			Limit:      model.MaxMappingInventoryAttributes,
+			Remaining:  model.MaxMappingInventoryAttributes,
+			Attributes: []model.MappingAttribute{},
+		},
+	}, {
+		Name: "error, store",
+
+		Mapping:  mapping,
+		StoreErr: errors.New("internal error"),
+		Error:    errors.New("internal error"),
+	}, {
+		Name: "error, unexpected store response",
+
+		Mapping:  mapping,
+		StoreRes: model.M{},
+		Error:    errors.New("can't process store aggregations"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("GetMapping", contextMatcher, tenantID).Return(tc.Mapping, nil)
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			if tc.StoreRes != nil || tc.StoreErr != nil {
+				store.On("AggregateDevices", contextMatcher,
+					mock.AnythingOfType("*model.query")).
+					Return(tc.StoreRes, tc.StoreErr)
+			}
+
+			app := NewApp(store, ds)
+			usage, err := app.GetMappingUsage(context.Background(), tenantID)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Usage, usage)
+			}
+		})
+	}
+}
+
+func TestEvictMappingAttributes(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	storeRes := model.M{
+		"aggregations": map[string]interface{}{
+			mappingUsageAggregation: map[string]interface{}{
+				"buckets": map[string]interface{}{
+					"attribute1": map[string]interface{}{"doc_count": float64(10)},
+					"attribute2": map[string]interface{}{"doc_count": float64(0)},
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		Name string
+
+		Attributes []model.MappingAttribute
+		Evicted    []string
+
+		Error error
+	}{{
+		Name: "ok",
+
+		Attributes: []model.MappingAttribute{
+			{Scope: "inventory", Name: "serial"},
+			{Scope: "inventory", Name: "unknown"},
+		},
+		Evicted: []string{"inventory/serial"},
+	}, {
+		Name: "ok, not in the mapping",
+
+		Attributes: []model.MappingAttribute{
+			{Scope: "inventory", Name: "unknown"},
+		},
+	}, {
+		Name: "error, attribute in use",
+
+		Attributes: []model.MappingAttribute{
+			{Scope: "inventory", Name: "serial"},
+			{Scope: "inventory", Name: "mac"},
+		},
+		Error: ErrMappingAttributeInUse,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(&model.Mapping{
+					TenantID:  tenantID,
+					Inventory: []string{"inventory/mac", "inventory/serial"},
+				}, nil).
+				Once()
+			if tc.Evicted != nil {
+				ds.On("EvictMappingAttributes", contextMatcher, tenantID, tc.Evicted).
+					Return(nil)
+				ds.On("GetMapping", contextMatcher, tenantID).
+					Return(&model.Mapping{
+						TenantID:  tenantID,
+						Inventory: []string{"inventory/mac", ""},
+					}, nil).
+					Once()
+			}
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			store.On("AggregateDevices", contextMatcher,
+				mock.AnythingOfType("*model.query")).
+				Return(storeRes, nil)
+
+			app := NewApp(store, ds)
+			usage, err := app.EvictMappingAttributes(context.Background(),
+				tenantID, tc.Attributes)
+			if tc.Error != nil {
+				assert.ErrorIs(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+				expected := 2
+				if tc.Evicted != nil {
+					expected = 1
+				}
+				assert.Equal(t, expected, usage.Count)
+			}
+		})
+	}
+}
+
+func TestSetMappingTypes(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	ctx := context.Background()
+	mapping := &model.Mapping{
+		TenantID:  tenantID,
+		Inventory: []string{"inventory/last_boot"},
+		Types: []model.MappingAttributeType{
+			{Scope: model.ScopeInventory, Name: "last_boot", Type: model.AttributeTypeKeyword},
+		},
+	}
+	types := []model.MappingAttributeType{
+		{Scope: model.ScopeInventory, Name: "last_boot", Type: model.AttributeTypeDate},
+	}
+
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping", ctx, tenantID).Return(mapping, nil)
+	ds.On("SetMappingTypes", ctx, tenantID, types,
+		[]string{"inventory/last_boot"}).
+		Return(nil)
+
+	app := NewApp(nil, ds)
+	err := app.SetMappingTypes(ctx, tenantID, types)
+	assert.NoError(t, err)
+
+	res, err := app.GetMappingTypes(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.MappingTypes{Types: mapping.Types}, res)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mendersoftware/go-lib-micro/requestid"
+
+	"github.com/mendersoftware/reporting/client/nats"
+	"github.com/mendersoftware/reporting/model"
+)
+
+var (
+	// ErrReindexNotAvailable is returned when the app has no publisher
+	// to enqueue the reindex jobs to
+	ErrReindexNotAvailable = errors.New("reindexing is not available")
+	// ErrReindexStateNotFound is returned when the tenant's index was
+	// never rebuilt nor caught up
+	ErrReindexStateNotFound = errors.New("reindex checkpoint not found")
+)
+
+// WithJobsPublisher enables enqueuing reindex jobs, publishing them to
+// the given NATS subject consumed by the indexer
+func WithJobsPublisher(nats nats.Client, subject string) Option {
+	return func(app *app) {
+		app.nats = nats
+		app.jobsSubject = subject
+	}
+}
+
+// ReindexDevices enqueues a reindex job for each of the devices
+func (app *app) ReindexDevices(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	if app.nats == nil {
+		return ErrReindexNotAvailable
+	}
+	reqID := requestid.FromContext(ctx)
+	for _, deviceID := range deviceIDs {
+		data, err := json.Marshal(model.Job{
+			Action:    model.ActionReindex,
+			RequestID: reqID,
+			TenantID:  tenantID,
+			DeviceID:  deviceID,
+			Service:   model.ServiceInventory,
+		})
+		if err != nil {
+			return err
+		}
+		err = app.nats.JetStreamPublish(app.jobsSubject, data)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue the reindex job for device %s: %w",
+				deviceID, err)
+		}
+	}
+	return nil
+}
+
+// GetReindexState returns the checkpoint of the rebuild of the tenant's
+// devices index, which an interrupted rebuild resumes from
+func (app *app) GetReindexState(
+	ctx context.Context,
+	tenantID string,
+) (*model.ReindexState, error) {
+	state, err := app.ds.GetReindexState(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	} else if state == nil {
+		return nil, ErrReindexStateNotFound
+	}
+	return state, nil
+}
+
+// ResetReindexState deletes the checkpoint of the rebuild of the tenant's
+// devices index: the next rebuild starts from scratch, and catching up
+// requires the time to catch up from
+func (app *app) ResetReindexState(ctx context.Context, tenantID string) error {
+	return app.ds.DeleteReindexState(ctx, tenantID)
+}
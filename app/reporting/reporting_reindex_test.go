@@ -0,0 +1,167 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/requestid"
+
+	mnats "github.com/mendersoftware/reporting/client/nats/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestReindexDevices(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "tenant"
+		subject  = "WORKFLOWS.reporting"
+		reqID    = "request"
+	)
+	jobData := func(deviceID string) []byte {
+		data, _ := json.Marshal(model.Job{
+			Action:    model.ActionReindex,
+			RequestID: reqID,
+			TenantID:  tenantID,
+			DeviceID:  deviceID,
+			Service:   model.ServiceInventory,
+		})
+		return data
+	}
+
+	testCases := map[string]struct {
+		deviceIDs []string
+		nats      func() *mnats.Client
+
+		err error
+	}{
+		"ok": {
+			deviceIDs: []string{"1", "2"},
+			nats: func() *mnats.Client {
+				nats := &mnats.Client{}
+				nats.On("JetStreamPublish", subject, jobData("1")).Return(nil)
+				nats.On("JetStreamPublish", subject, jobData("2")).Return(nil)
+				return nats
+			},
+		},
+		"ko, publish error": {
+			deviceIDs: []string{"1", "2"},
+			nats: func() *mnats.Client {
+				nats := &mnats.Client{}
+				nats.On("JetStreamPublish", subject, mock.Anything).
+					Return(errors.New("nats error"))
+				return nats
+			},
+			err: errors.New("failed to enqueue the reindex job for device 1: nats error"),
+		},
+		"ko, no publisher": {
+			deviceIDs: []string{"1"},
+			err:       ErrReindexNotAvailable,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []Option
+			if tc.nats != nil {
+				nats := tc.nats()
+				defer nats.AssertExpectations(t)
+				opts = append(opts, WithJobsPublisher(nats, subject))
+			}
+			app := NewApp(nil, nil, opts...)
+
+			ctx := requestid.WithContext(context.Background(), reqID)
+			err := app.ReindexDevices(ctx, tenantID, tc.deviceIDs)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetReindexState(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	state := &model.ReindexState{
+		TenantID: tenantID,
+		Index:    "devices-tenant-000002",
+		Page:     3,
+		Status:   model.ReindexStatusInProgress,
+	}
+	testCases := map[string]struct {
+		state *model.ReindexState
+		dsErr error
+
+		err error
+	}{
+		"ok": {
+			state: state,
+		},
+		"ko, not found": {
+			err: ErrReindexStateNotFound,
+		},
+		"ko, datastore error": {
+			dsErr: errors.New("connection refused"),
+			err:   errors.New("connection refused"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("GetReindexState", ctx, tenantID).Return(tc.state, tc.dsErr)
+
+			app := NewApp(nil, ds)
+			res, err := app.GetReindexState(ctx, tenantID)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.state, res)
+			}
+		})
+	}
+}
+
+func TestResetReindexState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("DeleteReindexState", ctx, "tenant").Return(nil)
+
+	app := NewApp(nil, ds)
+	err := app.ResetReindexState(ctx, "tenant")
+	assert.NoError(t, err)
+}
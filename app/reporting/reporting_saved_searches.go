@@ -0,0 +1,73 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	ErrSavedSearchNotFound     = store.ErrSavedSearchNotFound
+	ErrSavedSearchNameConflict = store.ErrSavedSearchNameConflict
+)
+
+// CreateSavedSearch stores a new saved search, assigning it a new ID
+func (app *app) CreateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	search.ID = uuid.NewString()
+	search.CreatedTs = now
+	search.UpdatedTs = now
+	if search.Report != nil {
+		search.Report.LastRunTs = nil
+	}
+	return app.ds.InsertSavedSearch(ctx, search)
+}
+
+// GetSavedSearches returns the saved searches of the tenant
+func (app *app) GetSavedSearches(
+	ctx context.Context,
+	tenantID string,
+) ([]model.SavedSearch, error) {
+	return app.ds.GetSavedSearches(ctx, tenantID)
+}
+
+// GetSavedSearch returns the saved search of the tenant with the given ID
+func (app *app) GetSavedSearch(
+	ctx context.Context,
+	tenantID, id string,
+) (*model.SavedSearch, error) {
+	return app.ds.GetSavedSearch(ctx, tenantID, id)
+}
+
+// UpdateSavedSearch replaces the definition of an existing saved search
+func (app *app) UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	search.UpdatedTs = time.Now().UTC().Truncate(time.Millisecond)
+	if search.Report != nil {
+		// changing the search reschedules the report from now on
+		search.Report.LastRunTs = nil
+	}
+	return app.ds.UpdateSavedSearch(ctx, search)
+}
+
+// DeleteSavedSearch removes the saved search of the tenant with the given ID
+func (app *app) DeleteSavedSearch(ctx context.Context, tenantID, id string) error {
+	return app.ds.DeleteSavedSearch(ctx, tenantID, id)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestCreateSavedSearch(t *testing.T) {
+	t.Parallel()
+
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("InsertSavedSearch", contextMatcher,
+		mock.MatchedBy(func(search *model.SavedSearch) bool {
+			return search.ID != "" &&
+				!search.CreatedTs.IsZero() &&
+				search.CreatedTs.Equal(search.UpdatedTs)
+		})).
+		Return(nil)
+
+	app := NewApp(nil, ds)
+	search := &model.SavedSearch{
+		TenantID: "tenant",
+		Name:     "production",
+	}
+	err := app.CreateSavedSearch(context.Background(), search)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, search.ID)
+}
+
+func TestUpdateSavedSearch(t *testing.T) {
+	t.Parallel()
+
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("UpdateSavedSearch", contextMatcher,
+		mock.AnythingOfType("*model.SavedSearch")).
+		Return(ErrSavedSearchNotFound)
+
+	app := NewApp(nil, ds)
+	search := &model.SavedSearch{
+		ID:       "a3c6f3e4-5c4a-4d2e-8d4b-2e2c0c6d8a3b",
+		TenantID: "tenant",
+		Name:     "production",
+	}
+	err := app.UpdateSavedSearch(context.Background(), search)
+	assert.Equal(t, ErrSavedSearchNotFound, err)
+	assert.False(t, search.UpdatedTs.IsZero())
+}
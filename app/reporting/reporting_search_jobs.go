@@ -0,0 +1,194 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+	"github.com/mendersoftware/go-lib-micro/requestid"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	defaultSearchJobsTTL         = time.Hour
+	defaultSearchJobsConcurrency = 4
+)
+
+var (
+	ErrSearchJobNotFound = store.ErrSearchJobNotFound
+)
+
+// WithSearchJobs sets how long the search jobs, and their results, are kept,
+// and how many of them run at the same time; the jobs over the concurrency
+// wait, pending, for a running job to finish. Non-positive values are left
+// to their defaults.
+func WithSearchJobs(ttl time.Duration, concurrency int) Option {
+	return func(app *app) {
+		if ttl > 0 {
+			app.searchJobsTTL = ttl
+		}
+		if concurrency > 0 {
+			app.searchJobsSlots = make(chan struct{}, concurrency)
+		}
+	}
+}
+
+// WithSearchJobsContext sets the context the search jobs run in the
+// background with: cancelling it interrupts the running jobs, which fail
+func WithSearchJobsContext(ctx context.Context) Option {
+	return func(app *app) {
+		app.searchJobsCtx = ctx
+	}
+}
+
+// CreateSearchJob stores a new search job and runs the search in the
+// background; the job is returned pending, and its status and results are
+// polled for with GetSearchJob until it expires. The job collects all the
+// devices matching the search, paging through them with a cursor: page and
+// per_page in the search parameters are ignored
+func (app *app) CreateSearchJob(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) (*model.SearchJob, error) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	job := &model.SearchJob{
+		ID:        uuid.NewString(),
+		TenantID:  searchParams.TenantID,
+		Status:    model.SearchJobStatusPending,
+		CreatedTs: now,
+		ExpireTs:  now.Add(app.searchJobsTTL),
+	}
+	if err := app.ds.InsertSearchJob(ctx, job); err != nil {
+		return nil, err
+	}
+	// the search outlives the request: keep its identity and logger only;
+	// the job is updated, once interrupted, with a context of its own
+	jobCtx := searchJobContext(context.Background(), ctx, job.ID)
+	searchCtx := searchJobContext(app.searchJobsCtx, ctx, job.ID)
+	running := *job
+	app.searchJobs.Add(1)
+	go func() {
+		defer app.searchJobs.Done()
+		app.runSearchJob(jobCtx, searchCtx, &running, searchParams)
+	}()
+	return job, nil
+}
+
+// searchJobContext returns a context derived from parent with the identity,
+// the request ID and the logger of the request of the job
+func searchJobContext(parent, ctx context.Context, jobID string) context.Context {
+	jobCtx := identity.WithContext(parent, identity.FromContext(ctx))
+	jobCtx = requestid.WithContext(jobCtx, requestid.FromContext(ctx))
+	return log.WithContext(jobCtx, log.FromContext(ctx).F(log.Ctx{
+		"search_job_id": jobID,
+	}))
+}
+
+// WaitSearchJobs waits for the search jobs running in the background to
+// finish, or for the context to be done
+func (app *app) WaitSearchJobs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		app.searchJobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runSearchJob runs the search of the job, once a slot is free, and stores
+// its results; the search is cancelled when the job expires or the search
+// jobs context is cancelled
+func (app *app) runSearchJob(
+	ctx, searchCtx context.Context,
+	job *model.SearchJob,
+	searchParams *model.SearchParams,
+) {
+	l := log.FromContext(ctx)
+	searchCtx, cancel := context.WithDeadline(searchCtx, job.ExpireTs)
+	defer cancel()
+
+	var err error
+	select {
+	case app.searchJobsSlots <- struct{}{}:
+		defer func() { <-app.searchJobsSlots }()
+		job.Status = model.SearchJobStatusRunning
+		err = app.ds.UpdateSearchJob(ctx, job)
+	case <-searchCtx.Done():
+		err = searchCtx.Err()
+	}
+	if err == nil {
+		job.Devices, job.Total, err = app.searchJobDevices(searchCtx, searchParams)
+	}
+
+	finishedTs := time.Now().UTC().Truncate(time.Millisecond)
+	job.FinishedTs = &finishedTs
+	if err != nil {
+		l.Errorf("search job failed: %s", err)
+		job.Status = model.SearchJobStatusFailed
+		job.Error = err.Error()
+		job.Devices = nil
+		job.Total = nil
+	} else {
+		job.Status = model.SearchJobStatusDone
+	}
+	if err := app.ds.UpdateSearchJob(ctx, job); err != nil {
+		l.Errorf("failed to update the search job: %s", err)
+	}
+}
+
+// searchJobDevices pages through all the devices matching the search
+// parameters with a cursor, for the results not to be limited by the
+// maximum result window of the store
+func (app *app) searchJobDevices(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+) ([]inventory.Device, *int, error) {
+	params := searchParams.Clone()
+	params.Cursor = model.CursorStart
+	params.PerPage = exportPageSize
+	var devices []inventory.Device
+	for {
+		devs, total, next, err := app.SearchDevicesWithCursor(ctx, &params)
+		if err != nil {
+			return nil, nil, err
+		}
+		devices = append(devices, devs...)
+		if next == "" {
+			return devices, &total, nil
+		}
+		params.Cursor = next
+	}
+}
+
+// GetSearchJob returns the search job of the tenant with the given ID
+func (app *app) GetSearchJob(
+	ctx context.Context,
+	tenantID, id string,
+) (*model.SearchJob, error) {
+	return app.ds.GetSearchJob(ctx, tenantID, id)
+}
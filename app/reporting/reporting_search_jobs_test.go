@@ -0,0 +1,286 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestCreateSearchJob(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	hits := func(n, offset, total int) model.M {
+		hitsS := make([]interface{}, n)
+		for i := range hitsS {
+			id := fmt.Sprintf("device-%04d", offset+i)
+			hitsS[i] = map[string]interface{}{
+				"_source": map[string]interface{}{
+					"id":        id,
+					"tenant_id": tenantID,
+				},
+				"sort": []interface{}{id},
+			}
+		}
+		return model.M{"hits": map[string]interface{}{
+			"hits": hitsS,
+			"total": map[string]interface{}{
+				"value": float64(total),
+			},
+		}}
+	}
+	devices := func(n int) []inventory.Device {
+		devs := make([]inventory.Device, n)
+		for i := range devs {
+			devs[i] = inventory.Device{
+				ID:         inventory.DeviceID(fmt.Sprintf("device-%04d", i)),
+				Attributes: inventory.DeviceAttributes{},
+			}
+		}
+		return devs
+	}
+	intPtr := func(i int) *int {
+		return &i
+	}
+	testCases := map[string]struct {
+		searchRes []model.M
+		searchErr error
+		insertErr error
+
+		status  string
+		devices []inventory.Device
+		total   *int
+		jobErr  string
+		err     error
+	}{
+		"ok": {
+			searchRes: []model.M{hits(1, 0, 1)},
+			status:    model.SearchJobStatusDone,
+			devices:   devices(1),
+			total:     intPtr(1),
+		},
+		"ok, several pages": {
+			searchRes: []model.M{
+				hits(exportPageSize, 0, exportPageSize+1),
+				hits(1, exportPageSize, exportPageSize+1),
+			},
+			status:  model.SearchJobStatusDone,
+			devices: devices(exportPageSize + 1),
+			total:   intPtr(exportPageSize + 1),
+		},
+		"error, search failed": {
+			searchErr: errors.New("internal error"),
+			status:    model.SearchJobStatusFailed,
+			jobErr:    "internal error",
+		},
+		"error, insert failed": {
+			insertErr: errors.New("connection reset"),
+			err:       errors.New("connection reset"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			identityMatcher := mock.MatchedBy(func(ctx context.Context) bool {
+				id := identity.FromContext(ctx)
+				return id != nil && id.Tenant == tenantID
+			})
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(&model.Mapping{}, nil).Maybe()
+
+			ds.On("InsertSearchJob", contextMatcher,
+				mock.MatchedBy(func(job *model.SearchJob) bool {
+					return job.ID != "" &&
+						job.TenantID == tenantID &&
+						job.Status == model.SearchJobStatusPending &&
+						job.ExpireTs.Sub(job.CreatedTs) == 2*time.Hour
+				})).
+				Return(tc.insertErr).
+				Once()
+			finished := make(chan *model.SearchJob, 1)
+			if tc.insertErr == nil {
+				store.On("OpenDevicesPointInTime", identityMatcher, cursorKeepAlive).
+					Return("pit", nil).
+					Once()
+				for i, res := range tc.searchRes {
+					i := i
+					store.On("SearchPointInTime", identityMatcher,
+						mock.MatchedBy(func(q model.Query) bool {
+							b, _ := json.Marshal(q)
+							var body map[string]interface{}
+							_ = json.Unmarshal(b, &body)
+							_, ok := body["search_after"]
+							return body["size"] == float64(exportPageSize) &&
+								ok == (i > 0)
+						})).
+						Return(res, nil).
+						Once()
+				}
+				if tc.searchErr != nil {
+					store.On("SearchPointInTime", identityMatcher, mock.Anything).
+						Return(nil, tc.searchErr).
+						Once()
+				}
+				store.On("ClosePointInTime", identityMatcher, "pit").
+					Return(nil).
+					Once()
+				ds.On("UpdateSearchJob", identityMatcher,
+					mock.MatchedBy(func(job *model.SearchJob) bool {
+						return job.Status == model.SearchJobStatusRunning
+					})).
+					Return(nil).
+					Once()
+				ds.On("UpdateSearchJob", identityMatcher,
+					mock.MatchedBy(func(job *model.SearchJob) bool {
+						return job.Finished()
+					})).
+					Run(func(args mock.Arguments) {
+						finished <- args.Get(1).(*model.SearchJob)
+					}).
+					Return(nil).
+					Once()
+			}
+
+			ctx := identity.WithContext(context.Background(), &identity.Identity{
+				Tenant: tenantID,
+			})
+			app := NewApp(store, ds, WithSearchJobs(2*time.Hour, 1))
+			job, err := app.CreateSearchJob(ctx, &model.SearchParams{
+				TenantID: tenantID,
+			})
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, model.SearchJobStatusPending, job.Status)
+
+			select {
+			case res := <-finished:
+				assert.Equal(t, job.ID, res.ID)
+				assert.Equal(t, tc.status, res.Status)
+				assert.Equal(t, tc.devices, res.Devices)
+				assert.Equal(t, tc.total, res.Total)
+				assert.Equal(t, tc.jobErr, res.Error)
+				assert.NotNil(t, res.FinishedTs)
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "the search job did not finish")
+			}
+		})
+	}
+}
+
+func TestSearchJobsShutdown(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	store := &mstore.Store{}
+	defer store.AssertExpectations(t)
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping", contextMatcher, tenantID).
+		Return(&model.Mapping{}, nil).Maybe()
+	ds.On("InsertSearchJob", contextMatcher, mock.AnythingOfType("*model.SearchJob")).
+		Return(nil).
+		Once()
+	ds.On("UpdateSearchJob", contextMatcher,
+		mock.MatchedBy(func(job *model.SearchJob) bool {
+			return job.Status == model.SearchJobStatusRunning
+		})).
+		Return(nil).
+		Once()
+
+	searching := make(chan struct{})
+	store.On("OpenDevicesPointInTime", contextMatcher, cursorKeepAlive).
+		Return("pit", nil).
+		Once()
+	store.On("SearchPointInTime", contextMatcher, mock.Anything).
+		Run(func(args mock.Arguments) {
+			close(searching)
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(nil, context.Canceled).
+		Once()
+	store.On("ClosePointInTime", contextMatcher, "pit").
+		Return(nil).
+		Once()
+	// the interrupted job is still updated, with a context not cancelled
+	ds.On("UpdateSearchJob",
+		mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Err() == nil
+		}),
+		mock.MatchedBy(func(job *model.SearchJob) bool {
+			return job.Status == model.SearchJobStatusFailed &&
+				job.Error == context.Canceled.Error()
+		})).
+		Return(nil).
+		Once()
+
+	jobsCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app := NewApp(store, ds, WithSearchJobsContext(jobsCtx))
+	ctx := identity.WithContext(context.Background(), &identity.Identity{
+		Tenant: tenantID,
+	})
+	_, err := app.CreateSearchJob(ctx, &model.SearchParams{
+		TenantID: tenantID,
+	})
+	assert.NoError(t, err)
+
+	<-searching
+	cancel()
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+	assert.NoError(t, app.WaitSearchJobs(waitCtx))
+}
+
+func TestGetSearchJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	job := &model.SearchJob{
+		ID:       "1",
+		TenantID: "tenant",
+		Status:   model.SearchJobStatusRunning,
+	}
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetSearchJob", ctx, "tenant", "1").Return(job, nil)
+
+	app := NewApp(nil, ds)
+	res, err := app.GetSearchJob(ctx, "tenant", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, job, res)
+}
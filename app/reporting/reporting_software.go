@@ -0,0 +1,120 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// AggregateSoftware returns the software installed on the most devices, or
+// the most installed versions of the named software
+func (app *app) AggregateSoftware(
+	ctx context.Context,
+	params *model.SoftwareParams,
+) ([]model.SoftwareSummary, error) {
+	esRes, err := app.store.AggregateSoftware(ctx, model.BuildSoftwareQuery(*params))
+	if err != nil {
+		return nil, err
+	}
+
+	aggregationsS, ok := esRes["aggregations"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("can't process store aggregations slice")
+	}
+	aggregationS, ok := aggregationsS[model.SoftwareAggregation].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("can't process store software aggregation")
+	}
+	bucketsS, _ := aggregationS["buckets"].([]interface{})
+	software := make([]model.SoftwareSummary, 0, len(bucketsS))
+	for _, bucket := range bucketsS {
+		bucketMap, ok := bucket.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("can't process store bucket item")
+		}
+		key, ok := bucketMap["key"].(string)
+		if !ok {
+			return nil, errors.New("can't process store key attribute")
+		}
+		count, ok := bucketMap["doc_count"].(float64)
+		if !ok {
+			return nil, errors.New("can't process store doc_count attribute")
+		}
+		summary := model.SoftwareSummary{
+			Name:  key,
+			Count: int(count),
+		}
+		if params.Name != "" {
+			summary.Name = params.Name
+			summary.Version = strings.TrimPrefix(key,
+				params.Name+model.SoftwareVersionSeparator)
+		}
+		software = append(software, summary)
+	}
+	return software, nil
+}
+
+// SearchSoftwareDevices searches the software inventory of the devices the
+// named software, in the given version if set, is installed on
+func (app *app) SearchSoftwareDevices(
+	ctx context.Context,
+	params *model.SoftwareDevicesParams,
+) ([]model.DeviceSoftware, int, error) {
+	esRes, err := app.store.SearchSoftware(ctx, model.BuildSoftwareDevicesQuery(*params))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hitsM, ok := esRes["hits"].(map[string]interface{})
+	if !ok {
+		return nil, 0, errors.New("can't process store hits map")
+	}
+	hitsTotalM, ok := hitsM["total"].(map[string]interface{})
+	if !ok {
+		return nil, 0, errors.New("can't process total hits struct")
+	}
+	total, ok := hitsTotalM["value"].(float64)
+	if !ok {
+		return nil, 0, errors.New("can't process total hits value")
+	}
+	hitsS, ok := hitsM["hits"].([]interface{})
+	if !ok {
+		return nil, 0, errors.New("can't process store hits slice")
+	}
+
+	devices := make([]model.DeviceSoftware, 0, len(hitsS))
+	for _, hit := range hitsS {
+		hitM, ok := hit.(map[string]interface{})
+		if !ok {
+			return nil, 0, errors.New("can't process individual hit")
+		}
+		source, err := json.Marshal(hitM["_source"])
+		if err != nil {
+			return nil, 0, err
+		}
+		var device model.DeviceSoftware
+		if err := json.Unmarshal(source, &device); err != nil {
+			return nil, 0, errors.Wrap(err, "can't process hit's '_source'")
+		}
+		devices = append(devices, device)
+	}
+	return devices, int(total), nil
+}
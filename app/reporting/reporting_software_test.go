@@ -0,0 +1,236 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestAggregateSoftware(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		Name string
+
+		Params *model.SoftwareParams
+		Store  func(*testing.T, testCase) *mstore.Store
+
+		Result []model.SoftwareSummary
+		Error  error
+	}
+	buckets := func(keys ...string) model.M {
+		bucketsS := []interface{}{}
+		for i, key := range keys {
+			bucketsS = append(bucketsS, map[string]interface{}{
+				"key":       key,
+				"doc_count": float64(len(keys) - i),
+			})
+		}
+		return model.M{
+			"aggregations": map[string]interface{}{
+				model.SoftwareAggregation: map[string]interface{}{
+					"buckets": bucketsS,
+				},
+			},
+		}
+	}
+	testCases := []testCase{{
+		Name: "ok, software",
+
+		Params: &model.SoftwareParams{TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("AggregateSoftware", contextMatcher,
+				model.BuildSoftwareQuery(*self.Params)).
+				Return(buckets("rootfs-image", "app.nginx"), nil)
+			return store
+		},
+
+		Result: []model.SoftwareSummary{
+			{Name: "rootfs-image", Count: 2},
+			{Name: "app.nginx", Count: 1},
+		},
+	}, {
+		Name: "ok, versions",
+
+		Params: &model.SoftwareParams{Name: "app.nginx", TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("AggregateSoftware", contextMatcher,
+				model.BuildSoftwareQuery(*self.Params)).
+				Return(buckets("app.nginx=1.24", "app.nginx=1.23"), nil)
+			return store
+		},
+
+		Result: []model.SoftwareSummary{
+			{Name: "app.nginx", Version: "1.24", Count: 2},
+			{Name: "app.nginx", Version: "1.23", Count: 1},
+		},
+	}, {
+		Name: "error, store",
+
+		Params: &model.SoftwareParams{TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("AggregateSoftware", contextMatcher,
+				model.BuildSoftwareQuery(*self.Params)).
+				Return(nil, errors.New("internal error"))
+			return store
+		},
+
+		Error: errors.New("internal error"),
+	}, {
+		Name: "error, malformed store result",
+
+		Params: &model.SoftwareParams{TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("AggregateSoftware", contextMatcher,
+				model.BuildSoftwareQuery(*self.Params)).
+				Return(model.M{}, nil)
+			return store
+		},
+
+		Error: errors.New("can't process store aggregations slice"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			store := tc.Store(t, tc)
+			defer store.AssertExpectations(t)
+
+			app := NewApp(store, nil)
+			res, err := app.AggregateSoftware(context.Background(), tc.Params)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Result, res)
+			}
+		})
+	}
+}
+
+func TestSearchSoftwareDevices(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		Name string
+
+		Params *model.SoftwareDevicesParams
+		Store  func(*testing.T, testCase) *mstore.Store
+
+		Result []model.DeviceSoftware
+		Total  int
+		Error  error
+	}
+	params := &model.SoftwareDevicesParams{
+		Name:     "rootfs-image",
+		Version:  "v1",
+		Page:     1,
+		PerPage:  20,
+		TenantID: "tenant",
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Params: params,
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchSoftware", contextMatcher,
+				model.BuildSoftwareDevicesQuery(*self.Params)).
+				Return(model.M{
+					"hits": map[string]interface{}{
+						"total": map[string]interface{}{
+							"value": float64(12),
+						},
+						"hits": []interface{}{
+							map[string]interface{}{
+								"_source": map[string]interface{}{
+									"id":                "1",
+									"tenant_id":         "tenant",
+									"group":             "prod",
+									"software":          []interface{}{"rootfs-image"},
+									"software_versions": []interface{}{"rootfs-image=v1"},
+								},
+							},
+						},
+					},
+				}, nil)
+			return store
+		},
+
+		Result: []model.DeviceSoftware{{
+			ID:               "1",
+			TenantID:         "tenant",
+			Group:            "prod",
+			Software:         []string{"rootfs-image"},
+			SoftwareVersions: []string{"rootfs-image=v1"},
+		}},
+		Total: 12,
+	}, {
+		Name: "error, store",
+
+		Params: params,
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchSoftware", contextMatcher,
+				model.BuildSoftwareDevicesQuery(*self.Params)).
+				Return(nil, errors.New("internal error"))
+			return store
+		},
+
+		Error: errors.New("internal error"),
+	}, {
+		Name: "error, malformed store result",
+
+		Params: params,
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchSoftware", contextMatcher,
+				model.BuildSoftwareDevicesQuery(*self.Params)).
+				Return(model.M{}, nil)
+			return store
+		},
+
+		Error: errors.New("can't process store hits map"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			store := tc.Store(t, tc)
+			defer store.AssertExpectations(t)
+
+			app := NewApp(store, nil)
+			res, total, err := app.SearchSoftwareDevices(context.Background(), tc.Params)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Result, res)
+				assert.Equal(t, tc.Total, total)
+			}
+		})
+	}
+}
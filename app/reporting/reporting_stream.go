@@ -0,0 +1,103 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+// SearchUpdate describes the changes in the results of a streamed search
+// since the previous update; the first update of a stream lists all the
+// devices in the results
+type SearchUpdate struct {
+	// Devices lists the devices added to or updated in the results
+	Devices []inventory.Device `json:"devices"`
+	// Removed lists the IDs of the devices which left the results
+	Removed []inventory.DeviceID `json:"removed"`
+	// Total is the total number of devices matching the search
+	Total int `json:"total"`
+	// Changed is true if the update carries any change in the results
+	Changed bool `json:"-"`
+}
+
+// StreamDevices runs the device search every interval, until the context is
+// done, calling fn with the changes in the results since the previous run;
+// fn is called after every run, also when the results did not change
+func (app *app) StreamDevices(
+	ctx context.Context,
+	searchParams *model.SearchParams,
+	interval time.Duration,
+	fn func(*SearchUpdate) error,
+) error {
+	var (
+		previous map[inventory.DeviceID]inventory.Device
+		total    = -1
+		ticker   = time.NewTicker(interval)
+	)
+	defer ticker.Stop()
+	for {
+		// the search maps the attribute names in place: work on a deep
+		// copy, for every run to search with the parameters as requested
+		params := searchParams.Clone()
+		devs, n, err := app.SearchDevices(ctx, &params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		update := &SearchUpdate{
+			Devices: []inventory.Device{},
+			Removed: []inventory.DeviceID{},
+			Total:   n,
+			Changed: n != total,
+		}
+		current := make(map[inventory.DeviceID]inventory.Device, len(devs))
+		for _, dev := range devs {
+			current[dev.ID] = dev
+			if prev, ok := previous[dev.ID]; !ok || !reflect.DeepEqual(prev, dev) {
+				update.Devices = append(update.Devices, dev)
+				update.Changed = true
+			}
+		}
+		for id := range previous {
+			if _, ok := current[id]; !ok {
+				update.Removed = append(update.Removed, id)
+				update.Changed = true
+			}
+		}
+		sort.Slice(update.Removed, func(i, j int) bool {
+			return update.Removed[i] < update.Removed[j]
+		})
+		previous, total = current, n
+
+		if err := fn(update); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
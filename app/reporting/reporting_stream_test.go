@@ -0,0 +1,238 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestStreamDevices(t *testing.T) {
+	t.Parallel()
+
+	hits := func(devs ...model.M) model.M {
+		hitsS := make([]interface{}, len(devs))
+		for i, dev := range devs {
+			hitsS[i] = map[string]interface{}{"_source": map[string]interface{}(dev)}
+		}
+		return model.M{"hits": map[string]interface{}{
+			"hits": hitsS,
+			"total": map[string]interface{}{
+				"value": float64(len(devs)),
+			},
+		}}
+	}
+	dev := func(id, ip string) model.M {
+		return model.M{"id": id, "inventory_attribute1_str": ip}
+	}
+	device := func(id, ip string) inventory.Device {
+		return inventory.Device{
+			ID: inventory.DeviceID(id),
+			Attributes: inventory.DeviceAttributes{{
+				Scope: model.ScopeInventory,
+				Name:  "ip4",
+				Value: ip,
+			}},
+		}
+	}
+
+	type testCase struct {
+		Name string
+
+		Results []model.M
+		Error   error
+
+		Updates []SearchUpdate
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Results: []model.M{
+			hits(dev("a", "10.0.0.1"), dev("b", "10.0.0.1")),
+			hits(dev("a", "10.0.0.1"), dev("b", "10.0.0.1")),
+			hits(dev("b", "10.0.0.2"), dev("c", "10.0.0.1")),
+		},
+		Updates: []SearchUpdate{{
+			Devices: []inventory.Device{
+				device("a", "10.0.0.1"),
+				device("b", "10.0.0.1"),
+			},
+			Removed: []inventory.DeviceID{},
+			Total:   2,
+			Changed: true,
+		}, {
+			Devices: []inventory.Device{},
+			Removed: []inventory.DeviceID{},
+			Total:   2,
+		}, {
+			Devices: []inventory.Device{
+				device("b", "10.0.0.2"),
+				device("c", "10.0.0.1"),
+			},
+			Removed: []inventory.DeviceID{"a"},
+			Total:   2,
+			Changed: true,
+		}},
+	}, {
+		Name: "ok, empty result",
+
+		Results: []model.M{hits()},
+		Updates: []SearchUpdate{{
+			Devices: []inventory.Device{},
+			Removed: []inventory.DeviceID{},
+			Total:   0,
+			Changed: true,
+		}},
+	}, {
+		Name: "error, internal storage-layer error",
+
+		Results: []model.M{hits(dev("a", "10.0.0.1"))},
+		Error:   errors.New("internal error"),
+		Updates: []SearchUpdate{{
+			Devices: []inventory.Device{
+				device("a", "10.0.0.1"),
+			},
+			Removed: []inventory.DeviceID{},
+			Total:   1,
+			Changed: true,
+		}},
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			store := new(mstore.Store)
+			defer store.AssertExpectations(t)
+			for _, res := range tc.Results {
+				store.On("SearchDevices", contextMatcher,
+					mock.Anything).
+					Return(res, nil).
+					Once()
+			}
+			if tc.Error != nil {
+				store.On("SearchDevices", contextMatcher,
+					mock.Anything).
+					Return(nil, tc.Error).
+					Once()
+			}
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, "tenant").
+				Return(&model.Mapping{Inventory: []string{"inventory/ip4"}}, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			app := NewApp(store, ds)
+			var updates []SearchUpdate
+			err := app.StreamDevices(ctx, &model.SearchParams{TenantID: "tenant"},
+				time.Millisecond,
+				func(update *SearchUpdate) error {
+					updates = append(updates, *update)
+					if tc.Error == nil && len(updates) == len(tc.Results) {
+						cancel()
+					}
+					return nil
+				})
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.Updates, updates)
+		})
+	}
+}
+
+func TestStreamDevicesSameSearch(t *testing.T) {
+	t.Parallel()
+
+	const runs = 3
+	params := &model.SearchParams{
+		TenantID: "tenant",
+		FilterGroups: []model.FilterGroup{{
+			Type: model.FilterGroupOr,
+			Filters: []model.FilterPredicate{{
+				Scope:     model.ScopeInventory,
+				Attribute: "ip4",
+				Type:      "$eq",
+				Value:     "10.0.0.1",
+			}},
+		}},
+		Sort: []model.SortCriteria{{
+			Scope:     model.ScopeInventory,
+			Attribute: "ip4",
+			Order:     model.SortOrderAsc,
+		}},
+	}
+	original := params.Clone()
+
+	var queries []model.Query
+	store := new(mstore.Store)
+	defer store.AssertExpectations(t)
+	store.On("GetDevicesIndexMapping", contextMatcher, "tenant").
+		Return(emptyIndexMapping, nil).
+		Maybe()
+	store.On("SearchDevices", contextMatcher, mock.Anything).
+		Run(func(args mock.Arguments) {
+			queries = append(queries, args.Get(1).(model.Query))
+		}).
+		Return(model.M{"hits": map[string]interface{}{
+			"hits":  []interface{}{},
+			"total": map[string]interface{}{"value": float64(0)},
+		}}, nil).
+		Times(runs)
+	ds := &mstore.DataStore{}
+	ds.On("GetMapping", contextMatcher, "tenant").
+		Return(&model.Mapping{Inventory: []string{"inventory/ip4"}}, nil)
+	tracker := NewAttributesUsageTracker(ds)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app := NewApp(store, ds, WithAttributesUsageTracker(tracker))
+	n := 0
+	err := app.StreamDevices(ctx, params, time.Millisecond,
+		func(update *SearchUpdate) error {
+			if n++; n == runs {
+				cancel()
+			}
+			return nil
+		})
+	assert.NoError(t, err)
+
+	// every run searches with the parameters as requested
+	assert.Equal(t, original, *params)
+	if assert.Len(t, queries, runs) {
+		for _, query := range queries[1:] {
+			assert.Equal(t, queries[0], query)
+		}
+	}
+	usage := tracker.usage["tenant"]
+	if assert.Contains(t, usage, "inventory/ip4") {
+		assert.Equal(t, int64(runs), usage["inventory/ip4"].Filters)
+		assert.Equal(t, int64(runs), usage["inventory/ip4"].Sorts)
+	}
+	assert.Len(t, usage, 1)
+}
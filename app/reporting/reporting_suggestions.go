@@ -0,0 +1,85 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+// SuggestDeviceAttributeValues returns the most common values of a device
+// attribute which start with the given prefix
+func (app *app) SuggestDeviceAttributeValues(
+	ctx context.Context,
+	params *model.SuggestParams,
+) ([]model.AttributeSuggestion, error) {
+	if params.VisibleAttributes != nil &&
+		!params.VisibleAttributes.Match(params.Scope, params.Attribute) {
+		return nil, attributeNotVisible(params.Scope, params.Attribute)
+	}
+	attributes, err := app.mapper.MapInventoryAttributes(ctx, params.TenantID,
+		inventory.DeviceAttributes{{
+			Name:  params.Attribute,
+			Scope: params.Scope,
+		}}, false, true)
+	if err != nil {
+		return nil, err
+	}
+	mappedParams := *params
+	mappedParams.Attribute = attributes[0].Name
+	mappedParams.Scope = attributes[0].Scope
+
+	query, err := model.BuildSuggestQuery(mappedParams)
+	if err != nil {
+		return nil, err
+	}
+	esRes, err := app.store.AggregateDevices(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregationsS, ok := esRes["aggregations"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("can't process store aggregations slice")
+	}
+	aggregationS, ok := aggregationsS[model.SuggestionsAggregation].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("can't process store suggestions aggregation")
+	}
+	bucketsS, _ := aggregationS["buckets"].([]interface{})
+	suggestions := make([]model.AttributeSuggestion, 0, len(bucketsS))
+	for _, bucket := range bucketsS {
+		bucketMap, ok := bucket.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("can't process store bucket item")
+		}
+		key, ok := bucketMap["key"].(string)
+		if !ok {
+			return nil, errors.New("can't process store key attribute")
+		}
+		count, ok := bucketMap["doc_count"].(float64)
+		if !ok {
+			return nil, errors.New("can't process store doc_count attribute")
+		}
+		suggestions = append(suggestions, model.AttributeSuggestion{
+			Value: key,
+			Count: int(count),
+		})
+	}
+	return suggestions, nil
+}
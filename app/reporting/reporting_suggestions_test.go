@@ -0,0 +1,159 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestSuggestDeviceAttributeValues(t *testing.T) {
+	const tenantID = "tenant_id"
+	t.Parallel()
+	type testCase struct {
+		Name string
+
+		Params  *model.SuggestParams
+		Mapping *model.Mapping
+		Query   model.SuggestParams
+		Store   func(*testing.T, testCase) *mstore.Store
+
+		Result []model.AttributeSuggestion
+		Error  error
+	}
+	testCases := []testCase{{
+		Name: "ok",
+
+		Params: &model.SuggestParams{
+			Scope:     model.ScopeInventory,
+			Attribute: "hostname",
+			Prefix:    "rasp",
+			TenantID:  tenantID,
+		},
+		Mapping: &model.Mapping{
+			Inventory: []string{"inventory/foo", "inventory/hostname"},
+		},
+		Query: model.SuggestParams{
+			Scope:     model.ScopeInventory,
+			Attribute: "attribute2",
+			Prefix:    "rasp",
+			TenantID:  tenantID,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			q, _ := model.BuildSuggestQuery(self.Query)
+			store.On("AggregateDevices", contextMatcher, q).
+				Return(model.M{
+					"aggregations": map[string]interface{}{
+						model.SuggestionsAggregation: map[string]interface{}{
+							"buckets": []interface{}{
+								map[string]interface{}{
+									"key":       "raspberrypi4",
+									"doc_count": float64(12),
+								},
+								map[string]interface{}{
+									"key":       "raspberrypi3",
+									"doc_count": float64(4),
+								},
+							},
+						},
+					},
+				}, nil)
+			return store
+		},
+
+		Result: []model.AttributeSuggestion{{
+			Value: "raspberrypi4",
+			Count: 12,
+		}, {
+			Value: "raspberrypi3",
+			Count: 4,
+		}},
+	}, {
+		Name: "error, store",
+
+		Params: &model.SuggestParams{
+			Scope:     model.ScopeIdentity,
+			Attribute: "mac",
+			TenantID:  tenantID,
+		},
+		Mapping: &model.Mapping{},
+		Query: model.SuggestParams{
+			Scope:     model.ScopeIdentity,
+			Attribute: "mac",
+			TenantID:  tenantID,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			q, _ := model.BuildSuggestQuery(self.Query)
+			store.On("AggregateDevices", contextMatcher, q).
+				Return(nil, errors.New("internal error"))
+			return store
+		},
+
+		Error: errors.New("internal error"),
+	}, {
+		Name: "error, malformed store result",
+
+		Params: &model.SuggestParams{
+			Scope:     model.ScopeIdentity,
+			Attribute: "mac",
+			TenantID:  tenantID,
+		},
+		Mapping: &model.Mapping{},
+		Query: model.SuggestParams{
+			Scope:     model.ScopeIdentity,
+			Attribute: "mac",
+			TenantID:  tenantID,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			q, _ := model.BuildSuggestQuery(self.Query)
+			store.On("AggregateDevices", contextMatcher, q).
+				Return(model.M{}, nil)
+			return store
+		},
+
+		Error: errors.New("can't process store aggregations slice"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			store := tc.Store(t, tc)
+			defer store.AssertExpectations(t)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(tc.Mapping, nil)
+
+			app := NewApp(store, ds)
+			res, err := app.SuggestDeviceAttributeValues(context.Background(), tc.Params)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Result, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// GetFleetSummary returns the counters of the devices of the fleet: the
+// counts by status and by latest deployment status are computed by a
+// single aggregation, the offline devices are counted separately
+func (app *app) GetFleetSummary(
+	ctx context.Context,
+	params *model.FleetSummaryParams,
+) (*model.FleetSummary, error) {
+	aggregateParams := params.AggregateParams()
+	aggregations, err := app.AggregateDevices(ctx, &aggregateParams)
+	if err != nil {
+		return nil, err
+	}
+	summary := &model.FleetSummary{
+		ByStatus:                 map[string]int{},
+		ByLatestDeploymentStatus: map[string]int{},
+	}
+	for _, aggregation := range aggregations {
+		switch aggregation.Name {
+		case model.SummaryStatusAggregation:
+			// every device has an authentication status
+			summary.Total = aggregation.OtherCount
+			for _, item := range aggregation.Items {
+				summary.ByStatus[item.Key] = item.Count
+				summary.Total += item.Count
+			}
+		case model.SummaryLatestDeploymentStatusAggregation:
+			for _, item := range aggregation.Items {
+				summary.ByLatestDeploymentStatus[item.Key] = item.Count
+			}
+		}
+	}
+
+	query, err := model.BuildOfflineDevicesQuery(*params)
+	if err != nil {
+		return nil, err
+	}
+	summary.Offline, err = app.store.CountDevices(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+// TenantDevices are the devices of a tenant matching a search across tenants
+type TenantDevices struct {
+	TenantID string             `json:"tenant_id"`
+	Count    int                `json:"count"`
+	Devices  []inventory.Device `json:"devices"`
+}
+
+// DeleteTenant deletes all the data of the tenant: the indexed devices and
+// deployments first, then the tenant's saved searches, settings and state
+func (app *app) DeleteTenant(ctx context.Context, tenantID string) error {
+	if err := app.store.DeleteTenantData(ctx, tenantID); err != nil {
+		return errors.Wrap(err, "failed to delete the tenant's documents")
+	}
+	if err := app.ds.DeleteTenantData(ctx, tenantID); err != nil {
+		return errors.Wrap(err, "failed to delete the tenant's data")
+	}
+	return nil
+}
+
+// SearchDevicesAcrossTenants runs the search for each of the tenants, or for
+// all the tenants with indexed devices if none is given, and returns the
+// number of matching devices of each tenant with the requested page of them,
+// along with the total number of matching devices; the tenants without
+// matching devices are left out when searching all the tenants
+func (app *app) SearchDevicesAcrossTenants(
+	ctx context.Context,
+	params *model.TenantsSearchParams,
+) ([]TenantDevices, int, error) {
+	tenantIDs := params.TenantIDs
+	allTenants := len(tenantIDs) == 0
+	if allTenants {
+		var err error
+		tenantIDs, err = app.ds.GetTenantIDs(ctx)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to list the tenants")
+		}
+	}
+
+	res := make([]TenantDevices, 0, len(tenantIDs))
+	total := 0
+	for _, tenantID := range tenantIDs {
+		// the searches without tenant match the devices of all the tenants
+		if tenantID == "" {
+			continue
+		}
+		// the filters are mapped to the attributes of each tenant
+		searchParams := params.SearchParams.Clone()
+		searchParams.TenantID = tenantID
+		tenantCtx := identity.WithContext(ctx, &identity.Identity{Tenant: tenantID})
+		devices, count, err := app.SearchDevices(tenantCtx, &searchParams)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err,
+				"failed to search the devices of the tenant %s", tenantID)
+		}
+		if allTenants && count == 0 {
+			continue
+		}
+		total += count
+		res = append(res, TenantDevices{
+			TenantID: tenantID,
+			Count:    count,
+			Devices:  devices,
+		})
+	}
+	return res, total, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestDeleteTenant(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+
+	testCases := map[string]struct {
+		storeErr error
+		dsErr    error
+
+		err string
+	}{
+		"ok": {},
+		"ko, store error": {
+			storeErr: errors.New("store error"),
+			err:      "failed to delete the tenant's documents: store error",
+		},
+		"ko, datastore error": {
+			dsErr: errors.New("datastore error"),
+			err:   "failed to delete the tenant's data: datastore error",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			store.On("DeleteTenantData", ctx, tenantID).Return(tc.storeErr)
+
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			if tc.storeErr == nil {
+				ds.On("DeleteTenantData", ctx, tenantID).Return(tc.dsErr)
+			}
+
+			app := NewApp(store, ds)
+			err := app.DeleteTenant(ctx, tenantID)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSearchDevicesAcrossTenants(t *testing.T) {
+	t.Parallel()
+
+	mappings := map[string]*model.Mapping{
+		"tenant1": {TenantID: "tenant1", Inventory: []string{"inventory/foo"}},
+		"tenant2": {TenantID: "tenant2", Inventory: []string{"inventory/bar", "inventory/foo"}},
+	}
+	// the filter is mapped to the attributes of each tenant
+	queries := map[string]model.Query{}
+	for tenantID, field := range map[string]string{
+		"tenant1": "attribute1",
+		"tenant2": "attribute2",
+	} {
+		q, _ := model.BuildQuery(model.SearchParams{
+			Page:    1,
+			PerPage: 10,
+			Filters: []model.FilterPredicate{{
+				Scope:     "inventory",
+				Attribute: field,
+				Type:      "$eq",
+				Value:     "baz",
+			}},
+		})
+		queries[tenantID] = q.Must(model.M{
+			"term": model.M{model.FieldNameTenantID: tenantID},
+		})
+	}
+	searchResult := func(count int, ids ...string) model.M {
+		hits := []interface{}{}
+		for _, id := range ids {
+			hits = append(hits, map[string]interface{}{
+				"_source": map[string]interface{}{"id": id},
+			})
+		}
+		return model.M{"hits": map[string]interface{}{
+			"hits":  hits,
+			"total": map[string]interface{}{"value": float64(count)},
+		}}
+	}
+	device := func(id string) inventory.Device {
+		return inventory.Device{
+			ID:         inventory.DeviceID(id),
+			Attributes: inventory.DeviceAttributes{},
+		}
+	}
+	tenantCtx := func(tenantID string) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			id := identity.FromContext(ctx)
+			return id != nil && id.Tenant == tenantID
+		})
+	}
+
+	testCases := map[string]struct {
+		tenantIDs    []string
+		allTenants   []string
+		allTenantErr error
+		results      map[string]model.M
+		searchErr    error
+
+		res   []TenantDevices
+		total int
+		err   string
+	}{
+		"ok, tenants": {
+			tenantIDs: []string{"tenant1", "tenant2"},
+			results: map[string]model.M{
+				"tenant1": searchResult(2, "dev1", "dev2"),
+				"tenant2": searchResult(0),
+			},
+			res: []TenantDevices{{
+				TenantID: "tenant1",
+				Count:    2,
+				Devices:  []inventory.Device{device("dev1"), device("dev2")},
+			}, {
+				TenantID: "tenant2",
+				Count:    0,
+				Devices:  []inventory.Device{},
+			}},
+			total: 2,
+		},
+		"ok, all tenants": {
+			allTenants: []string{"", "tenant1", "tenant2"},
+			results: map[string]model.M{
+				"tenant1": searchResult(0),
+				"tenant2": searchResult(11, "dev3"),
+			},
+			res: []TenantDevices{{
+				TenantID: "tenant2",
+				Count:    11,
+				Devices:  []inventory.Device{device("dev3")},
+			}},
+			total: 11,
+		},
+		"ko, tenants error": {
+			allTenantErr: errors.New("datastore error"),
+			err:          "failed to list the tenants: datastore error",
+		},
+		"ko, search error": {
+			tenantIDs: []string{"tenant1"},
+			searchErr: errors.New("store error"),
+			err:       "failed to search the devices of the tenant tenant1: store error",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			if tc.tenantIDs == nil {
+				ds.On("GetTenantIDs", ctx).Return(tc.allTenants, tc.allTenantErr)
+			}
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			for tenantID, mapping := range mappings {
+				ds.On("GetMapping", mock.Anything, tenantID).Return(mapping, nil).Maybe()
+				store.On("GetDevicesIndexMapping", mock.Anything, tenantID).
+					Return(emptyIndexMapping, nil).Maybe()
+				if tc.searchErr != nil {
+					store.On("SearchDevices", tenantCtx(tenantID), queries[tenantID]).
+						Return(nil, tc.searchErr).Maybe()
+				} else if res, ok := tc.results[tenantID]; ok {
+					store.On("SearchDevices", tenantCtx(tenantID), queries[tenantID]).
+						Return(res, nil)
+				}
+			}
+
+			app := NewApp(store, ds)
+			params := &model.TenantsSearchParams{
+				SearchParams: model.SearchParams{
+					Page:    1,
+					PerPage: 10,
+					Filters: []model.FilterPredicate{{
+						Scope:     "inventory",
+						Attribute: "foo",
+						Type:      "$eq",
+						Value:     "baz",
+					}},
+				},
+				TenantIDs: tc.tenantIDs,
+			}
+			res, total, err := app.SearchDevicesAcrossTenants(ctx, params)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.res, res)
+				assert.Equal(t, tc.total, total)
+			}
+			// the search parameters are left untouched
+			assert.Equal(t, "foo", params.Filters[0].Attribute)
+		})
+	}
+}
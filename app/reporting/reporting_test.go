@@ -15,8 +15,11 @@
 package reporting
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,6 +32,14 @@ import (
 
 var contextMatcher = mock.MatchedBy(func(_ context.Context) bool { return true })
 
+// emptyIndexMapping is the mapping of an index without any field, the
+// filters on its attributes are not type-checked
+var emptyIndexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{},
+	},
+}
+
 func TestHealthCheck(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -329,6 +340,237 @@ func TestAggregateDevices(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		Name: "ok, stats",
+
+		Params: &model.AggregateParams{
+			Filters: []model.FilterPredicate{{
+				Attribute: "foo",
+				Value:     "bar",
+				Scope:     "inventory",
+				Type:      "$eq",
+			}},
+			Aggregations: []model.AggregationTerm{
+				{
+					Name:      "aggr",
+					Attribute: "attr",
+					Scope:     "inventory",
+					Type:      model.AggregationTypeStats,
+				},
+			},
+			TenantID: tenantID,
+		},
+		MappedParams: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Attribute: "attribute1",
+				Value:     "bar",
+				Scope:     "inventory",
+				Type:      "$eq",
+			}},
+		},
+		MappedAggregatedParams: []model.AggregationTerm{
+			{
+				Name:      "aggr",
+				Attribute: "attribute2",
+				Scope:     "inventory",
+				Type:      model.AggregationTypeStats,
+			},
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+				Return(map[string]interface{}{
+					"mappings": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"inventory_attribute2_num": map[string]interface{}{
+								"type": "double",
+							},
+						},
+					},
+				}, nil)
+			q, _ := model.BuildQuery(*self.MappedParams)
+			q.Must(model.M{
+				"term": model.M{
+					model.FieldNameTenantID: tenantID,
+				},
+			})
+			aggrs, _ := model.BuildAggregations(self.MappedAggregatedParams)
+			q = q.WithSize(0).With(map[string]interface{}{
+				"aggs": aggrs,
+			})
+			store.On("AggregateDevices", contextMatcher, q).
+				Return(model.M{
+					"aggregations": map[string]interface{}{
+						"aggr": map[string]interface{}{
+							"count": float64(3),
+							"min":   float64(1),
+							"max":   float64(5),
+							"avg":   float64(3),
+							"sum":   float64(9),
+						},
+					},
+				}, nil)
+			return store
+		},
+		Mapping: model.Mapping{
+			TenantID:  "",
+			Inventory: []string{"inventory/foo", "inventory/attr"},
+		},
+		Result: []model.DeviceAggregation{
+			{
+				Name:  "aggr",
+				Items: []model.DeviceAggregationItem{},
+				Stats: &model.DeviceAggregationStats{
+					Count: 3,
+					Min:   float64Ptr(1),
+					Max:   float64Ptr(5),
+					Avg:   float64Ptr(3),
+					Sum:   9,
+				},
+			},
+		},
+	}, {
+		Name: "ok, percentiles",
+
+		Params: &model.AggregateParams{
+			Filters: []model.FilterPredicate{{
+				Attribute: "foo",
+				Value:     "bar",
+				Scope:     "inventory",
+				Type:      "$eq",
+			}},
+			Aggregations: []model.AggregationTerm{
+				{
+					Name:      "aggr",
+					Attribute: "attr",
+					Scope:     "inventory",
+					Type:      model.AggregationTypePercentiles,
+					Percents:  []float64{50, 95},
+				},
+			},
+			TenantID: tenantID,
+		},
+		MappedParams: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Attribute: "attribute1",
+				Value:     "bar",
+				Scope:     "inventory",
+				Type:      "$eq",
+			}},
+		},
+		MappedAggregatedParams: []model.AggregationTerm{
+			{
+				Name:      "aggr",
+				Attribute: "attribute2",
+				Scope:     "inventory",
+				Type:      model.AggregationTypePercentiles,
+				Percents:  []float64{50, 95},
+			},
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+				Return(map[string]interface{}{
+					"mappings": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"inventory_attribute2_num": map[string]interface{}{
+								"type": "double",
+							},
+						},
+					},
+				}, nil)
+			q, _ := model.BuildQuery(*self.MappedParams)
+			q.Must(model.M{
+				"term": model.M{
+					model.FieldNameTenantID: tenantID,
+				},
+			})
+			aggrs, _ := model.BuildAggregations(self.MappedAggregatedParams)
+			q = q.WithSize(0).With(map[string]interface{}{
+				"aggs": aggrs,
+			})
+			store.On("AggregateDevices", contextMatcher, q).
+				Return(model.M{
+					"aggregations": map[string]interface{}{
+						"aggr": map[string]interface{}{
+							"values": map[string]interface{}{
+								"50.0": float64(512),
+								"95.0": float64(2048),
+							},
+						},
+					},
+				}, nil)
+			return store
+		},
+		Mapping: model.Mapping{
+			TenantID:  "",
+			Inventory: []string{"inventory/foo", "inventory/attr"},
+		},
+		Result: []model.DeviceAggregation{
+			{
+				Name:  "aggr",
+				Items: []model.DeviceAggregationItem{},
+				Percentiles: map[string]float64{
+					"50.0": 512,
+					"95.0": 2048,
+				},
+			},
+		},
+	}, {
+		Name: "ko, stats on a non numeric attribute",
+
+		Params: &model.AggregateParams{
+			Filters: []model.FilterPredicate{{
+				Attribute: "foo",
+				Value:     "bar",
+				Scope:     "inventory",
+				Type:      "$eq",
+			}},
+			Aggregations: []model.AggregationTerm{
+				{
+					Name:      "aggr",
+					Attribute: "attr",
+					Scope:     "inventory",
+					Type:      model.AggregationTypeStats,
+				},
+			},
+			TenantID: tenantID,
+		},
+		MappedParams: &model.SearchParams{
+			Filters: []model.FilterPredicate{{
+				Attribute: "attribute1",
+				Value:     "bar",
+				Scope:     "inventory",
+				Type:      "$eq",
+			}},
+		},
+		MappedAggregatedParams: []model.AggregationTerm{
+			{
+				Name:      "aggr",
+				Attribute: "attribute2",
+				Scope:     "inventory",
+				Type:      model.AggregationTypeStats,
+			},
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+				Return(map[string]interface{}{
+					"mappings": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"inventory_attribute2_str": map[string]interface{}{
+								"type": "double",
+							},
+						},
+					},
+				}, nil)
+			return store
+		},
+		Mapping: model.Mapping{
+			TenantID:  "",
+			Inventory: []string{"inventory/foo", "inventory/attr"},
+		},
+		Error: ErrAggregationAttributeNotNumeric,
 	}}
 	for i := range testCases {
 		tc := testCases[i]
@@ -342,6 +584,8 @@ func TestAggregateDevices(t *testing.T) {
 				store = tc.Store(t, tc)
 			}
 			defer store.AssertExpectations(t)
+			store.On("GetDevicesIndexMapping", contextMatcher, mock.Anything).
+				Return(emptyIndexMapping, nil).Maybe()
 
 			ds := &mstore.DataStore{}
 
@@ -518,6 +762,66 @@ func TestSearchDevices(t *testing.T) {
 				Scope: "inventory",
 			}},
 		}},
+	}, {
+		Name: "ok with highlights",
+
+		Params: &model.SearchParams{
+			Text:      "rasp",
+			Highlight: true,
+		},
+		MappedParams: &model.SearchParams{
+			Text:      "rasp",
+			Highlight: true,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			q, _ := model.BuildQuery(*self.MappedParams)
+			store.On("SearchDevices", contextMatcher, q).
+				Return(model.M{"hits": map[string]interface{}{"hits": []interface{}{
+					map[string]interface{}{
+						"_source": map[string]interface{}{
+							"id": "194d1060-1717-44dc-a783-00038f4a8013",
+							model.ToAttr("inventory", "attribute1", model.TypeStr): []string{
+								"raspberrypi4",
+							},
+						},
+						"highlight": map[string]interface{}{
+							model.ToAttr("inventory", "attribute1", model.TypeStr): []interface{}{
+								"<em>raspberrypi4</em>",
+							},
+							model.ToAttr("system", "group", model.TypeStr): []interface{}{
+								"<em>raspberry</em>-fleet",
+							},
+						},
+					}},
+					"total": map[string]interface{}{
+						"value": float64(1),
+					}},
+				}, nil)
+			return store
+		},
+		Mapping: model.Mapping{
+			TenantID:  "",
+			Inventory: []string{"inventory/device_type"},
+		},
+		TotalCount: 1,
+		Result: []inventory.Device{{
+			ID: "194d1060-1717-44dc-a783-00038f4a8013",
+			Attributes: inventory.DeviceAttributes{{
+				Name:  "device_type",
+				Value: []string{"raspberrypi4"},
+				Scope: "inventory",
+			}},
+			Highlights: inventory.DeviceAttributes{{
+				Name:  "device_type",
+				Value: []interface{}{"<em>raspberrypi4</em>"},
+				Scope: "inventory",
+			}, {
+				Name:  "group",
+				Value: []interface{}{"<em>raspberry</em>-fleet"},
+				Scope: "system",
+			}},
+		}},
 	}, {
 		Name: "ok, empty result",
 
@@ -599,6 +903,8 @@ func TestSearchDevices(t *testing.T) {
 				store = tc.Store(t, tc)
 			}
 			defer store.AssertExpectations(t)
+			store.On("GetDevicesIndexMapping", contextMatcher, mock.Anything).
+				Return(emptyIndexMapping, nil).Maybe()
 
 			ds := &mstore.DataStore{}
 
@@ -632,6 +938,567 @@ func TestSearchDevices(t *testing.T) {
 	}
 }
 
+func TestGetDevice(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tenantID = "tenant"
+		deviceID = "194d1060-1717-44dc-a783-00038f4a8013"
+	)
+	testCases := map[string]struct {
+		hits []interface{}
+		err  error
+
+		device *inventory.Device
+	}{
+		"ok": {
+			hits: []interface{}{
+				map[string]interface{}{"_source": map[string]interface{}{
+					"id":        deviceID,
+					"tenant_id": tenantID,
+					model.ToAttr("inventory", "attribute1", model.TypeStr): "edge-gw-1",
+				}},
+			},
+			device: &inventory.Device{
+				ID: deviceID,
+				Attributes: inventory.DeviceAttributes{{
+					Name:  "hostname",
+					Value: "edge-gw-1",
+					Scope: "inventory",
+				}},
+			},
+		},
+		"error, not found": {
+			hits: []interface{}{},
+			err:  ErrDeviceNotFound,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			store.On("SearchDevices", contextMatcher,
+				mock.MatchedBy(func(q model.Query) bool {
+					b, _ := json.Marshal(q)
+					return bytes.Contains(b, []byte(deviceID)) &&
+						bytes.Contains(b, []byte(tenantID))
+				})).
+				Return(model.M{"hits": map[string]interface{}{
+					"hits": tc.hits,
+					"total": map[string]interface{}{
+						"value": float64(len(tc.hits)),
+					},
+				}}, nil)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, tenantID).
+				Return(&model.Mapping{
+					TenantID:  tenantID,
+					Inventory: []string{"inventory/hostname"},
+				}, nil).
+				Maybe()
+
+			app := NewApp(store, ds)
+			device, err := app.GetDevice(context.Background(), tenantID, deviceID)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.device, device)
+			}
+		})
+	}
+}
+
+func TestCountDevices(t *testing.T) {
+	t.Parallel()
+
+	params := &model.SearchParams{
+		Filters: []model.FilterPredicate{{
+			Attribute: "foo",
+			Value:     "bar",
+			Scope:     "inventory",
+			Type:      "$eq",
+		}},
+		TenantID: "123456789012345678901234",
+	}
+	mappedParams := model.SearchParams{
+		Filters: []model.FilterPredicate{{
+			Attribute: "attribute1",
+			Value:     "bar",
+			Scope:     "inventory",
+			Type:      "$eq",
+		}},
+		TenantID: "123456789012345678901234",
+	}
+	q, _ := model.BuildQuery(mappedParams)
+	q = q.Must(model.M{"term": model.M{model.FieldNameTenantID: params.TenantID}})
+
+	store := new(mstore.Store)
+	defer store.AssertExpectations(t)
+	store.On("GetDevicesIndexMapping", contextMatcher, params.TenantID).
+		Return(emptyIndexMapping, nil).Once()
+	store.On("CountDevices", contextMatcher, q).Return(42, nil)
+
+	ds := new(mstore.DataStore)
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping", contextMatcher, params.TenantID).
+		Return(&model.Mapping{
+			TenantID:  params.TenantID,
+			Inventory: []string{"inventory/foo"},
+		}, nil).Once()
+
+	app := NewApp(store, ds)
+	count, err := app.CountDevices(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count)
+}
+
+func TestCountDevicesHashedAttribute(t *testing.T) {
+	t.Parallel()
+
+	redaction := &model.AttributesRedaction{
+		Hashed: []model.AttributeSelector{
+			{Scope: model.ScopeIdentity, Name: "serial"},
+		},
+		HashKey: []byte("secret"),
+	}
+	params := &model.SearchParams{
+		Filters: []model.FilterPredicate{{
+			Attribute: "serial",
+			Value:     "SN-1234",
+			Scope:     model.ScopeIdentity,
+			Type:      "$eq",
+		}},
+		TenantID: "123456789012345678901234",
+	}
+	mappedParams := model.SearchParams{
+		Filters: []model.FilterPredicate{{
+			Attribute: "attribute1",
+			Value:     redaction.HashValue("SN-1234"),
+			Scope:     model.ScopeIdentity,
+			Type:      "$eq",
+		}},
+		TenantID: "123456789012345678901234",
+	}
+	q, _ := model.BuildQuery(mappedParams)
+	q = q.Must(model.M{"term": model.M{model.FieldNameTenantID: params.TenantID}})
+
+	store := new(mstore.Store)
+	defer store.AssertExpectations(t)
+	store.On("GetDevicesIndexMapping", contextMatcher, params.TenantID).
+		Return(emptyIndexMapping, nil)
+	store.On("CountDevices", contextMatcher, q).Return(1, nil).Twice()
+
+	ds := new(mstore.DataStore)
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping", contextMatcher, params.TenantID).
+		Return(&model.Mapping{
+			TenantID:  params.TenantID,
+			Inventory: []string{"identity/serial"},
+		}, nil).Once()
+
+	app := NewApp(store, ds, WithAttributesRedaction(redaction))
+	// the parameters are reused, sharing the filters: hashed only once
+	for i := 0; i < 2; i++ {
+		reused := *params
+		count, err := app.CountDevices(context.Background(), &reused)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	}
+	assert.Equal(t, "SN-1234", params.Filters[0].Value)
+}
+
+func TestSearchDevicesFilterTypes(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "123456789012345678901234"
+	params := &model.SearchParams{
+		Filters: []model.FilterPredicate{{
+			Attribute: "device_type",
+			Value:     "raspberrypi4",
+			Scope:     model.ScopeInventory,
+			Type:      "$eq",
+		}, {
+			Attribute: "mem_total_kB",
+			Value:     "1024",
+			Scope:     model.ScopeInventory,
+			Type:      "$gt",
+		}},
+		FilterGroups: []model.FilterGroup{{
+			Type: model.FilterGroupOr,
+			Groups: []model.FilterGroup{{
+				Type: model.FilterGroupAnd,
+				Filters: []model.FilterPredicate{{
+					Attribute: "device_type",
+					Value:     float64(4),
+					Scope:     model.ScopeInventory,
+					Type:      "$eq",
+				}},
+			}},
+		}},
+		TenantID: tenantID,
+	}
+
+	store := new(mstore.Store)
+	defer store.AssertExpectations(t)
+	store.On("GetDevicesIndexMapping", contextMatcher, tenantID).
+		Return(map[string]interface{}{
+			"mappings": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"inventory_attribute1_str": map[string]interface{}{
+						"type": "keyword",
+					},
+					"inventory_attribute2_num": map[string]interface{}{
+						"type": "double",
+					},
+				},
+			},
+		}, nil).Once()
+
+	ds := new(mstore.DataStore)
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping", contextMatcher, tenantID).
+		Return(&model.Mapping{
+			TenantID:  tenantID,
+			Inventory: []string{"inventory/device_type", "inventory/mem_total_kB"},
+		}, nil).Once()
+
+	app := NewApp(store, ds)
+	_, _, err := app.SearchDevices(context.Background(), params)
+	assert.ErrorIs(t, err, ErrFilterValueType)
+	assert.EqualError(t, err, "filter_groups: (0: (groups: (0: (filters: (0: "+
+		"(value: must be a string, the attribute is not indexed as number.).).).).).); "+
+		"filters: (1: (value: must be a number, the attribute is not indexed as string.).).")
+}
+
+func TestExportDevices(t *testing.T) {
+	t.Parallel()
+
+	hits := func(n, offset int) model.M {
+		hitsS := make([]interface{}, n)
+		for i := range hitsS {
+			id := fmt.Sprintf("device-%04d", offset+i)
+			hitsS[i] = map[string]interface{}{
+				"_source": map[string]interface{}{
+					"id": id,
+				},
+				"sort": []interface{}{id},
+			}
+		}
+		return model.M{"hits": map[string]interface{}{
+			"hits": hitsS,
+			"total": map[string]interface{}{
+				"value": float64(n),
+			},
+		}}
+	}
+	querySearchAfter := func(searchAfter interface{}) interface{} {
+		return mock.MatchedBy(func(q model.Query) bool {
+			b, _ := json.Marshal(q)
+			var body map[string]interface{}
+			_ = json.Unmarshal(b, &body)
+			return assert.ObjectsAreEqual(searchAfter, body["search_after"])
+		})
+	}
+
+	type testCase struct {
+		Name string
+
+		Params *model.SearchParams
+		Store  func(*testing.T, testCase) *mstore.Store
+
+		Pages []int
+		Error error
+	}
+	testCases := []testCase{{
+		Name: "ok, multiple pages",
+
+		Params: &model.SearchParams{TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchDevices", contextMatcher, querySearchAfter(nil)).
+				Return(hits(exportPageSize, 0), nil).
+				Once()
+			store.On("SearchDevices", contextMatcher,
+				querySearchAfter([]interface{}{
+					fmt.Sprintf("device-%04d", exportPageSize-1),
+				})).
+				Return(hits(3, exportPageSize), nil).
+				Once()
+			return store
+		},
+		Pages: []int{exportPageSize, 3},
+	}, {
+		Name: "ok, device IDs",
+
+		Params: &model.SearchParams{
+			TenantID:  "tenant",
+			DeviceIDs: []string{"device-0001", "device-0002"},
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchDevices", contextMatcher,
+				mock.MatchedBy(func(q model.Query) bool {
+					b, _ := json.Marshal(q)
+					return assert.Contains(t, string(b),
+						`{"terms":{"id":["device-0001","device-0002"]}}`)
+				})).
+				Return(hits(2, 1), nil).
+				Once()
+			return store
+		},
+		Pages: []int{2},
+	}, {
+		Name: "ok, empty result",
+
+		Params: &model.SearchParams{TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchDevices", contextMatcher, querySearchAfter(nil)).
+				Return(hits(0, 0), nil).
+				Once()
+			return store
+		},
+	}, {
+		Name: "error, internal storage-layer error",
+
+		Params: &model.SearchParams{TenantID: "tenant"},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchDevices", contextMatcher, querySearchAfter(nil)).
+				Return(hits(exportPageSize, 0), nil).
+				Once()
+			store.On("SearchDevices", contextMatcher, mock.Anything).
+				Return(nil, errors.New("internal error")).
+				Once()
+			return store
+		},
+		Pages: []int{exportPageSize},
+		Error: errors.New("internal error"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			store := tc.Store(t, tc)
+			defer store.AssertExpectations(t)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, "tenant").
+				Return(&model.Mapping{}, nil)
+
+			app := NewApp(store, ds)
+			var pages []int
+			err := app.ExportDevices(context.Background(), tc.Params,
+				func(devs []inventory.Device) error {
+					pages = append(pages, len(devs))
+					return nil
+				})
+			if tc.Error != nil {
+				if assert.Error(t, err) {
+					assert.Regexp(t, tc.Error.Error(), err.Error())
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.Pages, pages)
+		})
+	}
+}
+
+func TestSearchDevicesWithCursor(t *testing.T) {
+	t.Parallel()
+
+	hits := func(n int, pitID string) model.M {
+		hitsS := make([]interface{}, n)
+		for i := range hitsS {
+			id := fmt.Sprintf("device-%04d", i)
+			hitsS[i] = map[string]interface{}{
+				"_source": map[string]interface{}{
+					"id": id,
+				},
+				"sort": []interface{}{id},
+			}
+		}
+		return model.M{
+			"pit_id": pitID,
+			"hits": map[string]interface{}{
+				"hits": hitsS,
+				"total": map[string]interface{}{
+					"value": float64(10),
+				},
+			},
+		}
+	}
+	queryPointInTime := func(pitID string, searchAfter interface{}) interface{} {
+		return mock.MatchedBy(func(q model.Query) bool {
+			b, _ := json.Marshal(q)
+			var body map[string]interface{}
+			_ = json.Unmarshal(b, &body)
+			pit, _ := body["pit"].(map[string]interface{})
+			return pit["id"] == pitID &&
+				assert.ObjectsAreEqual(searchAfter, body["search_after"])
+		})
+	}
+
+	type testCase struct {
+		Name string
+
+		Params *model.SearchParams
+		Store  func(*testing.T, testCase) *mstore.Store
+
+		Devices int
+		Cursor  *model.Cursor
+		Error   error
+	}
+	testCases := []testCase{{
+		Name: "ok, first page",
+
+		Params: &model.SearchParams{
+			TenantID: "tenant",
+			PerPage:  2,
+			Cursor:   model.CursorStart,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("OpenDevicesPointInTime", contextMatcher, cursorKeepAlive).
+				Return("pit", nil)
+			store.On("SearchPointInTime", contextMatcher, queryPointInTime("pit", nil)).
+				Return(hits(2, "pit2"), nil)
+			return store
+		},
+		Devices: 2,
+		Cursor: &model.Cursor{
+			PointInTimeID: "pit2",
+			SearchAfter:   []interface{}{"device-0001"},
+		},
+	}, {
+		Name: "ok, last page",
+
+		Params: &model.SearchParams{
+			TenantID: "tenant",
+			PerPage:  2,
+			Cursor: (&model.Cursor{
+				PointInTimeID: "pit",
+				SearchAfter:   []interface{}{"device-0001"},
+			}).String(),
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchPointInTime", contextMatcher,
+				queryPointInTime("pit", []interface{}{"device-0001"})).
+				Return(hits(1, "pit"), nil)
+			store.On("ClosePointInTime", contextMatcher, "pit").
+				Return(nil)
+			return store
+		},
+		Devices: 1,
+	}, {
+		Name: "error, failed to open the point in time",
+
+		Params: &model.SearchParams{
+			TenantID: "tenant",
+			PerPage:  2,
+			Cursor:   model.CursorStart,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("OpenDevicesPointInTime", contextMatcher, cursorKeepAlive).
+				Return("", errors.New("internal error"))
+			return store
+		},
+		Error: errors.New("internal error"),
+	}, {
+		Name: "error, search failed, the point in time is released",
+
+		Params: &model.SearchParams{
+			TenantID: "tenant",
+			PerPage:  2,
+			Cursor:   model.CursorStart,
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("OpenDevicesPointInTime", contextMatcher, cursorKeepAlive).
+				Return("pit", nil)
+			store.On("SearchPointInTime", contextMatcher, queryPointInTime("pit", nil)).
+				Return(nil, errors.New("internal error"))
+			store.On("ClosePointInTime", contextMatcher, "pit").
+				Return(nil)
+			return store
+		},
+		Error: errors.New("internal error"),
+	}, {
+		Name: "error, malformed results, the point in time is released",
+
+		Params: &model.SearchParams{
+			TenantID: "tenant",
+			PerPage:  2,
+			Cursor: (&model.Cursor{
+				PointInTimeID: "pit",
+				SearchAfter:   []interface{}{"device-0001"},
+			}).String(),
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchPointInTime", contextMatcher,
+				queryPointInTime("pit", []interface{}{"device-0001"})).
+				Return(model.M{"pit_id": "pit2"}, nil)
+			store.On("ClosePointInTime", contextMatcher, "pit2").
+				Return(nil)
+			return store
+		},
+		Error: errors.New("can't process store hits map"),
+	}, {
+		Name: "error, cursor expired",
+
+		Params: &model.SearchParams{
+			TenantID: "tenant",
+			PerPage:  2,
+			Cursor:   (&model.Cursor{PointInTimeID: "pit"}).String(),
+		},
+		Store: func(t *testing.T, self testCase) *mstore.Store {
+			store := new(mstore.Store)
+			store.On("SearchPointInTime", contextMatcher, queryPointInTime("pit", nil)).
+				Return(nil, ErrCursorExpired)
+			return store
+		},
+		Error: ErrCursorExpired,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			store := tc.Store(t, tc)
+			defer store.AssertExpectations(t)
+
+			ds := &mstore.DataStore{}
+			ds.On("GetMapping", contextMatcher, "tenant").
+				Return(&model.Mapping{}, nil)
+
+			app := NewApp(store, ds)
+			devs, _, cursor, err := app.SearchDevicesWithCursor(context.Background(),
+				tc.Params)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, devs, tc.Devices)
+			if tc.Cursor != nil {
+				assert.Equal(t, tc.Cursor.String(), cursor)
+			} else {
+				assert.Empty(t, cursor)
+			}
+		})
+	}
+}
+
 func TestGetSearchableInvAttrs(t *testing.T) {
 	const tenantID = "tenant_id"
 
@@ -724,3 +1591,7 @@ func TestGetSearchableInvAttrs(t *testing.T) {
 		})
 	}
 }
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// GetTenantUsage returns the usage of the reporting service by the tenant:
+// its documents, the space they take, and its queries of the month; the
+// queries not flushed yet by the servers are not counted
+func (app *app) GetTenantUsage(
+	ctx context.Context,
+	tenantID, month string,
+) (*model.TenantUsage, error) {
+	usage, err := app.store.GetTenantUsage(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := app.ds.GetTenantQueries(ctx, tenantID, month)
+	if err != nil {
+		return nil, err
+	}
+	usage.Month = month
+	usage.Queries = queries
+	return usage, nil
+}
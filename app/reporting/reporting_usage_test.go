@@ -0,0 +1,92 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestGetTenantUsage(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	testCases := map[string]struct {
+		usage      *model.TenantUsage
+		usageErr   error
+		queries    int64
+		queriesErr error
+
+		res *model.TenantUsage
+		err error
+	}{
+		"ok": {
+			usage: &model.TenantUsage{
+				TenantID:   tenantID,
+				Documents:  model.UsageDocuments{Devices: 10, Deployments: 20},
+				IndexBytes: 4096,
+			},
+			queries: 42,
+			res: &model.TenantUsage{
+				TenantID:   tenantID,
+				Documents:  model.UsageDocuments{Devices: 10, Deployments: 20},
+				IndexBytes: 4096,
+				Month:      "2023-03",
+				Queries:    42,
+			},
+		},
+		"error, store": {
+			usageErr: errors.New("opensearch error"),
+			err:      errors.New("opensearch error"),
+		},
+		"error, data store": {
+			usage:      &model.TenantUsage{TenantID: tenantID},
+			queriesErr: errors.New("mongo error"),
+			err:        errors.New("mongo error"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			store := &mstore.Store{}
+			defer store.AssertExpectations(t)
+			store.On("GetTenantUsage", ctx, tenantID).Return(tc.usage, tc.usageErr)
+			ds := &mstore.DataStore{}
+			defer ds.AssertExpectations(t)
+			if tc.usageErr == nil {
+				ds.On("GetTenantQueries", ctx, tenantID, "2023-03").
+					Return(tc.queries, tc.queriesErr)
+			}
+
+			app := NewApp(store, ds)
+			res, err := app.GetTenantUsage(ctx, tenantID, "2023-03")
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.res, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+var (
+	// ErrAttributeNotVisible is returned when a search filters, sorts or
+	// aggregates by an attribute which is not visible to the role of the
+	// user
+	ErrAttributeNotVisible = errors.New("the attribute is not visible")
+)
+
+func attributeNotVisible(scope, name string) error {
+	return errors.Wrap(ErrAttributeNotVisible, scope+"/"+name)
+}
+
+// checkSearchVisibility rejects the searches filtering or sorting by the
+// attributes which are not visible, and drops them from the selected
+// attributes; the results are stripped of them by hideAttributes
+func checkSearchVisibility(searchParams *model.SearchParams) error {
+	visible := searchParams.VisibleAttributes
+	if visible == nil {
+		return nil
+	}
+	for _, f := range searchParams.Filters {
+		if !visible.Match(f.Scope, f.Attribute) {
+			return attributeNotVisible(f.Scope, f.Attribute)
+		}
+	}
+	for i := range searchParams.FilterGroups {
+		for _, p := range searchParams.FilterGroups[i].Predicates() {
+			if !visible.Match(p.Scope, p.Attribute) {
+				return attributeNotVisible(p.Scope, p.Attribute)
+			}
+		}
+	}
+	for _, s := range searchParams.Sort {
+		if !visible.Match(s.Scope, s.Attribute) {
+			return attributeNotVisible(s.Scope, s.Attribute)
+		}
+	}
+	if len(searchParams.Attributes) > 0 {
+		attributes := make([]model.SelectAttribute, 0, len(searchParams.Attributes))
+		for _, attr := range searchParams.Attributes {
+			if visible.Match(attr.Scope, attr.Attribute) {
+				attributes = append(attributes, attr)
+			}
+		}
+		if len(attributes) == 0 {
+			// an empty selection selects all the attributes
+			attributes = append(attributes, model.SelectAttribute{
+				Scope:     model.ScopeIdentity,
+				Attribute: model.AttrNameStatus,
+			})
+		}
+		searchParams.Attributes = attributes
+	}
+	return nil
+}
+
+// checkAggregationsVisibility rejects the aggregations, and their
+// sub-aggregations, of the attributes which are not visible
+func checkAggregationsVisibility(
+	visible *model.AttributesFilter,
+	aggregations []model.AggregationTerm,
+) error {
+	if visible == nil {
+		return nil
+	}
+	for _, agg := range aggregations {
+		if !visible.Match(agg.Scope, agg.Attribute) {
+			return attributeNotVisible(agg.Scope, agg.Attribute)
+		}
+		if err := checkAggregationsVisibility(visible, agg.Aggregations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hideAttributes strips the devices of the attributes, and of the
+// highlights, which are not visible
+func hideAttributes(devs []inventory.Device, visible *model.AttributesFilter) {
+	if visible == nil {
+		return
+	}
+	filter := func(attrs inventory.DeviceAttributes) inventory.DeviceAttributes {
+		if attrs == nil {
+			return nil
+		}
+		res := make(inventory.DeviceAttributes, 0, len(attrs))
+		for _, attr := range attrs {
+			if attr.Scope == model.ScopeIdentity && attr.Name == model.FieldNameID ||
+				visible.Match(attr.Scope, attr.Name) {
+				res = append(res, attr)
+			}
+		}
+		return res
+	}
+	for i := range devs {
+		devs[i].Attributes = filter(devs[i].Attributes)
+		devs[i].Highlights = filter(devs[i].Highlights)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package reporting
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+var visibleInventory = &model.AttributesFilter{
+	Included: []model.AttributeSelector{
+		{Scope: model.ScopeInventory, Name: "device_*"},
+	},
+}
+
+func TestCheckSearchVisibility(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		params *model.SearchParams
+
+		attributes []model.SelectAttribute
+		err        error
+	}{
+		"ok, no restrictions": {
+			params: &model.SearchParams{
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+				}},
+			},
+		},
+		"ok, visible attributes": {
+			params: &model.SearchParams{
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "device_type",
+				}, {
+					Scope:     model.ScopeIdentity,
+					Attribute: model.AttrNameStatus,
+				}},
+				Sort: []model.SortCriteria{{
+					Scope:     model.ScopeSystem,
+					Attribute: "updated_ts",
+				}},
+				Attributes: []model.SelectAttribute{{
+					Scope:     model.ScopeInventory,
+					Attribute: "device_type",
+				}, {
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+				}},
+				VisibleAttributes: visibleInventory,
+			},
+			attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeInventory,
+				Attribute: "device_type",
+			}},
+		},
+		"ok, no visible attribute selected": {
+			params: &model.SearchParams{
+				Attributes: []model.SelectAttribute{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+				}},
+				VisibleAttributes: visibleInventory,
+			},
+			attributes: []model.SelectAttribute{{
+				Scope:     model.ScopeIdentity,
+				Attribute: model.AttrNameStatus,
+			}},
+		},
+		"error, filter": {
+			params: &model.SearchParams{
+				Filters: []model.FilterPredicate{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+				}},
+				VisibleAttributes: visibleInventory,
+			},
+			err: errors.New("inventory/mac: the attribute is not visible"),
+		},
+		"error, filter group": {
+			params: &model.SearchParams{
+				FilterGroups: []model.FilterGroup{{
+					Type: model.FilterGroupOr,
+					Filters: []model.FilterPredicate{{
+						Scope:     model.ScopeInventory,
+						Attribute: "device_type",
+					}, {
+						Scope:     model.ScopeTags,
+						Attribute: "owner",
+					}},
+				}},
+				VisibleAttributes: visibleInventory,
+			},
+			err: errors.New("tags/owner: the attribute is not visible"),
+		},
+		"error, sort": {
+			params: &model.SearchParams{
+				Sort: []model.SortCriteria{{
+					Scope:     model.ScopeInventory,
+					Attribute: "mac",
+				}},
+				VisibleAttributes: visibleInventory,
+			},
+			err: errors.New("inventory/mac: the attribute is not visible"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkSearchVisibility(tc.params)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				assert.True(t, errors.Is(err, ErrAttributeNotVisible))
+				return
+			}
+			assert.NoError(t, err)
+			if tc.attributes != nil {
+				assert.Equal(t, tc.attributes, tc.params.Attributes)
+			}
+		})
+	}
+}
+
+func TestCheckAggregationsVisibility(t *testing.T) {
+	t.Parallel()
+
+	aggregations := []model.AggregationTerm{{
+		Name:      "types",
+		Scope:     model.ScopeInventory,
+		Attribute: "device_type",
+		Aggregations: []model.AggregationTerm{{
+			Name:      "macs",
+			Scope:     model.ScopeInventory,
+			Attribute: "mac",
+		}},
+	}}
+	assert.NoError(t, checkAggregationsVisibility(nil, aggregations))
+	assert.NoError(t, checkAggregationsVisibility(visibleInventory, nil))
+	err := checkAggregationsVisibility(visibleInventory, aggregations)
+	assert.EqualError(t, err, "inventory/mac: the attribute is not visible")
+}
+
+func TestHideAttributes(t *testing.T) {
+	t.Parallel()
+
+	devs := []inventory.Device{{
+		ID: "1",
+		Attributes: inventory.DeviceAttributes{
+			{Scope: model.ScopeIdentity, Name: model.FieldNameID, Value: "1"},
+			{Scope: model.ScopeIdentity, Name: "mac", Value: "00:11:22:33:44:55"},
+			{Scope: model.ScopeIdentity, Name: model.AttrNameStatus, Value: "accepted"},
+			{Scope: model.ScopeInventory, Name: "device_type", Value: "rpi4"},
+			{Scope: model.ScopeInventory, Name: "kernel", Value: "6.1"},
+		},
+		Highlights: inventory.DeviceAttributes{
+			{Scope: model.ScopeInventory, Name: "kernel", Value: "<em>6.1</em>"},
+		},
+	}}
+	hideAttributes(devs, visibleInventory)
+	assert.Equal(t, []inventory.Device{{
+		ID: "1",
+		Attributes: inventory.DeviceAttributes{
+			{Scope: model.ScopeIdentity, Name: model.FieldNameID, Value: "1"},
+			{Scope: model.ScopeIdentity, Name: model.AttrNameStatus, Value: "accepted"},
+			{Scope: model.ScopeInventory, Name: "device_type", Value: "rpi4"},
+		},
+		Highlights: inventory.DeviceAttributes{},
+	}}, devs)
+}
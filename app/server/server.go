@@ -16,6 +16,8 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -28,10 +30,16 @@ import (
 	"github.com/mendersoftware/go-lib-micro/config"
 	"github.com/mendersoftware/go-lib-micro/log"
 
+	grpcapi "github.com/mendersoftware/reporting/api/grpc"
 	api "github.com/mendersoftware/reporting/api/http"
 	"github.com/mendersoftware/reporting/app/reporting"
+	"github.com/mendersoftware/reporting/client/nats"
+	"github.com/mendersoftware/reporting/client/s3"
+	"github.com/mendersoftware/reporting/client/sigv4"
 	dconfig "github.com/mendersoftware/reporting/config"
+	"github.com/mendersoftware/reporting/model"
 	"github.com/mendersoftware/reporting/store"
+	"github.com/mendersoftware/reporting/store/metering"
 )
 
 func init() {
@@ -43,19 +51,115 @@ func init() {
 }
 
 // InitAndRun initializes the server and runs it
-func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore) error {
+func InitAndRun(
+	conf config.Reader,
+	store store.Store,
+	ds store.DataStore,
+	nats nats.Client,
+) error {
 	ctx := context.Background()
 
 	l := log.FromContext(ctx)
 
-	reporting := reporting.NewApp(store, ds)
+	if depth := conf.GetInt(dconfig.SettingAggregationsMaxDepth); depth >= 0 {
+		model.SetMaxNestedAggregations(uint(depth))
+	}
+	model.SetQueryLimits(model.QueryLimits{
+		MaxClauses:            conf.GetInt(dconfig.SettingQueryMaxClauses),
+		MaxAggregationBuckets: conf.GetInt(dconfig.SettingQueryMaxAggregationBuckets),
+		MaxPerPage:            conf.GetInt(dconfig.SettingQueryMaxPerPage),
+		MaxPatternLength:      conf.GetInt(dconfig.SettingQueryMaxPatternLength),
+	})
+	if window := conf.GetInt(dconfig.SettingConnectivityWindowMinutes); window > 0 {
+		model.SetConnectivityWindow(time.Duration(window) * time.Minute)
+	}
+
+	redaction, err := dconfig.IndexingAttributesRedaction(conf)
+	if err != nil {
+		return err
+	}
+	jobsSubject := conf.GetString(dconfig.SettingNatsStreamName) + "." +
+		conf.GetString(dconfig.SettingNatsSubscriberTopic)
+	devClient, invClient, deplClient, err := dconfig.ServiceClients(conf)
+	if err != nil {
+		return err
+	}
+	appOpts := []reporting.Option{
+		reporting.WithJobsPublisher(nats, jobsSubject),
+		reporting.WithDependency(model.ServiceInventory, invClient.CheckHealth),
+		reporting.WithDependency(model.ServiceDeviceauth, devClient.CheckHealth),
+		reporting.WithDependency(model.ServiceDeployments, deplClient.CheckHealth),
+		reporting.WithAuditLogRetention(
+			time.Duration(conf.GetInt(dconfig.SettingAuditLogRetentionDays)) * 24 * time.Hour),
+		reporting.WithAttributesRedaction(redaction),
+		reporting.WithSearchJobs(
+			time.Duration(conf.GetInt(dconfig.SettingSearchJobsTTLMinutes))*time.Minute,
+			conf.GetInt(dconfig.SettingSearchJobsConcurrency)),
+		reporting.WithDeviceSources(invClient, devClient),
+	}
+	if bucket := conf.GetString(dconfig.SettingExportS3Bucket); bucket != "" {
+		client := s3.NewClient(
+			conf.GetString(dconfig.SettingReportS3Endpoint),
+			conf.GetString(dconfig.SettingReportS3Region),
+			bucket,
+			sigv4.Credentials{
+				AccessKeyID:     conf.GetString(dconfig.SettingReportS3AccessKeyID),
+				SecretAccessKey: conf.GetString(dconfig.SettingReportS3SecretAccessKey),
+			},
+		)
+		appOpts = append(appOpts, reporting.WithExportStorage(client,
+			conf.GetString(dconfig.SettingExportS3Prefix)))
+	}
+	// meter the queries of the tenants, served from the cache or not
+	meter := metering.NewStore(store, ds)
+	meterCtx, cancelMeter := context.WithCancel(ctx)
+	defer cancelMeter()
+	flushInterval := conf.GetInt(dconfig.SettingUsageFlushIntervalMsec)
+	if flushInterval <= 0 {
+		return fmt.Errorf(
+			"%s: must be a positive integer",
+			dconfig.SettingUsageFlushIntervalMsec,
+		)
+	}
+	go meter.Run(meterCtx, time.Duration(flushInterval)*time.Millisecond)
+	if interval := conf.GetInt(dconfig.SettingUsageRefreshIntervalMsec); interval > 0 {
+		go meter.RunUsageRefresh(meterCtx, time.Duration(interval)*time.Millisecond)
+	}
+	// count the uses of the attributes by the queries, flushed with the
+	// queries of the tenants
+	attributesUsage := reporting.NewAttributesUsageTracker(ds)
+	go attributesUsage.Run(meterCtx, time.Duration(flushInterval)*time.Millisecond)
+	appOpts = append(appOpts, reporting.WithAttributesUsageTracker(attributesUsage))
+	// the search jobs run in the background: interrupt them on shutdown
+	searchJobsCtx, cancelSearchJobs := context.WithCancel(ctx)
+	defer cancelSearchJobs()
+	appOpts = append(appOpts, reporting.WithSearchJobsContext(searchJobsCtx))
+	reporting := reporting.NewApp(meter, ds, appOpts...)
 
 	var listen = conf.GetString(dconfig.SettingListen)
-	var router = api.NewRouter(reporting)
+	// streamed responses never complete by themselves: end them on shutdown
+	streamsCtx, cancelStreams := context.WithCancel(ctx)
+	defer cancelStreams()
+	var router = api.NewRouter(reporting,
+		api.WithSearchStreamInterval(time.Duration(
+			conf.GetInt(dconfig.SettingSearchStreamIntervalMsec))*time.Millisecond),
+		api.WithStreamsContext(streamsCtx),
+		api.WithTenantRateLimit(
+			conf.GetFloat64(dconfig.SettingRateLimitTenantRPS),
+			conf.GetInt(dconfig.SettingRateLimitTenantBurst)),
+		api.WithGraphQL(conf.GetBool(dconfig.SettingGraphQLEnable)),
+		api.WithCompression(
+			conf.GetBool(dconfig.SettingCompressionEnable),
+			conf.GetInt(dconfig.SettingCompressionMinSize)),
+		api.WithRequestValidation(
+			conf.GetBool(dconfig.SettingRequestValidationEnable)),
+		api.WithAuditLog(conf.GetBool(dconfig.SettingAuditLogEnable)),
+	)
 	srv := &http.Server{
 		Addr:    listen,
 		Handler: router,
 	}
+	srv.RegisterOnShutdown(cancelStreams)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -63,6 +167,20 @@ func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore) error
 		}
 	}()
 
+	if grpcListen := conf.GetString(dconfig.SettingGRPCListen); grpcListen != "" {
+		lis, err := net.Listen("tcp", grpcListen)
+		if err != nil {
+			return err
+		}
+		grpcSrv := grpcapi.NewServer(reporting)
+		defer grpcSrv.GracefulStop()
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				l.Fatalf("grpc listen: %s\n", err)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, unix.SIGINT, unix.SIGTERM)
 	<-quit
@@ -74,6 +192,17 @@ func InitAndRun(conf config.Reader, store store.Store, ds store.DataStore) error
 	if err := srv.Shutdown(ctxWithTimeout); err != nil {
 		l.Fatal("Server Shutdown: ", err)
 	}
+	cancelSearchJobs()
+	if err := reporting.WaitSearchJobs(ctxWithTimeout); err != nil {
+		l.Error(err)
+	}
+	cancelMeter()
+	if err := meter.Flush(ctxWithTimeout); err != nil {
+		l.Error(err)
+	}
+	if err := attributesUsage.Flush(ctxWithTimeout); err != nil {
+		l.Error(err)
+	}
 
 	return nil
 }
@@ -0,0 +1,218 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package breaker
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets all the calls through
+	StateClosed State = iota
+	// StateHalfOpen lets a single trial call through
+	StateHalfOpen
+	// StateOpen rejects all the calls
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+var (
+	// ErrOpen is returned when a call is rejected by an open circuit breaker
+	ErrOpen = errors.New("circuit breaker is open")
+)
+
+var (
+	metricState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "circuit_breaker",
+		Name:      "state",
+		Help:      "State of the circuit breaker: 0 closed, 1 half-open, 2 open.",
+	}, []string{"name"})
+	metricTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "reporting",
+		Subsystem: "circuit_breaker",
+		Name:      "transitions_total",
+		Help:      "Number of state transitions of the circuit breaker.",
+	}, []string{"name", "from", "to"})
+)
+
+func init() {
+	prometheus.MustRegister(metricState, metricTransitions)
+}
+
+// StatusError is the error of a call the downstream service answered with
+// an unexpected status code
+type StatusError struct {
+	StatusCode int
+	err        error
+}
+
+// NewStatusError wraps the error of a call the downstream service answered
+// with the given status code
+func NewStatusError(statusCode int, err error) error {
+	return &StatusError{
+		StatusCode: statusCode,
+		err:        err,
+	}
+}
+
+func (err *StatusError) Error() string {
+	return err.err.Error()
+}
+
+func (err *StatusError) Unwrap() error {
+	return err.err
+}
+
+// isFailure returns true if the error is a failure of the downstream
+// service: the calls answered with a status code, but a server error or
+// too many requests, prove the service is up
+func isFailure(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// CircuitBreaker opens after a number of consecutive failed calls, rejecting
+// all the subsequent calls with ErrOpen until the open timeout expires; it
+// then lets a single trial call through, closing again if it succeeds. The
+// calls failed with a StatusError count as failures only for the server
+// errors and too many requests
+type CircuitBreaker struct {
+	name             string
+	failureThreshold int
+	openTimeout      time.Duration
+
+	mutex    sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewCircuitBreaker returns a new circuit breaker; name identifies the
+// breaker in the metrics, failureThreshold is the number of consecutive
+// failures which opens the breaker and openTimeout the time it stays open
+// before trying to close again
+func NewCircuitBreaker(
+	name string,
+	failureThreshold int,
+	openTimeout time.Duration,
+) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	metricState.WithLabelValues(name).Set(float64(StateClosed))
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		state:            StateClosed,
+		now:              time.Now,
+	}
+}
+
+// State returns the current state of the circuit breaker
+func (cb *CircuitBreaker) State() State {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == StateOpen && cb.now().Sub(cb.openedAt) >= cb.openTimeout {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// Do calls fn if the circuit breaker allows it, and records its outcome;
+// if the circuit breaker is open, it returns ErrOpen without calling fn
+func (cb *CircuitBreaker) Do(fn func() error) error {
+	if !cb.allow() {
+		return ErrOpen
+	}
+	err := fn()
+	cb.record(err)
+	return err
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	switch cb.state {
+	case StateOpen:
+		if cb.now().Sub(cb.openedAt) < cb.openTimeout {
+			return false
+		}
+		cb.setState(StateHalfOpen)
+		return true
+	case StateHalfOpen:
+		// a trial call is already in flight
+		return false
+	}
+	return true
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if err == nil || !isFailure(err) {
+		cb.failures = 0
+		if cb.state != StateClosed {
+			cb.setState(StateClosed)
+		}
+		return
+	} else if errors.Is(err, context.Canceled) {
+		// the caller gave up: it says nothing about the downstream service
+		if cb.state == StateHalfOpen {
+			cb.setState(StateOpen)
+		}
+		return
+	}
+	cb.failures++
+	if cb.state == StateHalfOpen || cb.failures >= cb.failureThreshold {
+		cb.openedAt = cb.now()
+		cb.setState(StateOpen)
+	}
+}
+
+func (cb *CircuitBreaker) setState(state State) {
+	if cb.state == state {
+		return
+	}
+	metricTransitions.WithLabelValues(cb.name, cb.state.String(), state.String()).Inc()
+	metricState.WithLabelValues(cb.name).Set(float64(state))
+	cb.state = state
+}
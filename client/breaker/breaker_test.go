@@ -0,0 +1,126 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package breaker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	errFailed := errors.New("failed")
+	now := time.Now()
+
+	cb := NewCircuitBreaker("test", 2, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	calls := 0
+	fail := func() error {
+		calls++
+		return errFailed
+	}
+	succeed := func() error {
+		calls++
+		return nil
+	}
+
+	// consecutive failures open the breaker
+	assert.Equal(t, errFailed, cb.Do(fail))
+	assert.Equal(t, StateClosed, cb.State())
+	assert.Equal(t, errFailed, cb.Do(fail))
+	assert.Equal(t, StateOpen, cb.State())
+
+	// open breaker rejects the calls
+	assert.Equal(t, ErrOpen, cb.Do(succeed))
+	assert.Equal(t, 2, calls)
+
+	// after the timeout, a failed trial call reopens the breaker
+	now = now.Add(time.Minute)
+	assert.Equal(t, StateHalfOpen, cb.State())
+	assert.Equal(t, errFailed, cb.Do(fail))
+	assert.Equal(t, StateOpen, cb.State())
+	assert.Equal(t, ErrOpen, cb.Do(succeed))
+	assert.Equal(t, 3, calls)
+
+	// a successful trial call closes the breaker
+	now = now.Add(time.Minute)
+	assert.NoError(t, cb.Do(succeed))
+	assert.Equal(t, StateClosed, cb.State())
+	assert.Equal(t, 4, calls)
+
+	// a success resets the failure count
+	assert.Equal(t, errFailed, cb.Do(fail))
+	assert.NoError(t, cb.Do(succeed))
+	assert.Equal(t, errFailed, cb.Do(fail))
+	assert.Equal(t, StateClosed, cb.State())
+
+	// canceled calls are not counted as failures
+	canceled := func() error {
+		return context.Canceled
+	}
+	assert.Equal(t, context.Canceled, cb.Do(canceled))
+	assert.Equal(t, context.Canceled, cb.Do(canceled))
+	assert.Equal(t, StateClosed, cb.State())
+}
+
+func TestCircuitBreakerStatusError(t *testing.T) {
+	testCases := map[string]struct {
+		statusCode int
+		state      State
+	}{
+		"bad request": {
+			statusCode: http.StatusBadRequest,
+			state:      StateClosed,
+		},
+		"not found": {
+			statusCode: http.StatusNotFound,
+			state:      StateClosed,
+		},
+		"too many requests": {
+			statusCode: http.StatusTooManyRequests,
+			state:      StateOpen,
+		},
+		"internal server error": {
+			statusCode: http.StatusInternalServerError,
+			state:      StateOpen,
+		},
+		"service unavailable": {
+			statusCode: http.StatusServiceUnavailable,
+			state:      StateOpen,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			cb := NewCircuitBreaker("test-"+name, 2, time.Minute)
+			errStatus := NewStatusError(tc.statusCode,
+				errors.Wrap(errors.New("request failed"), "get devices"))
+			fail := func() error {
+				return errStatus
+			}
+			for i := 0; i < 2; i++ {
+				err := cb.Do(fail)
+				assert.Equal(t, errStatus, err)
+				assert.EqualError(t, err, "get devices: request failed")
+			}
+			assert.Equal(t, tc.state, cb.State())
+		})
+	}
+}
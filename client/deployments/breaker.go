@@ -0,0 +1,92 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"time"
+
+	"github.com/mendersoftware/reporting/client/breaker"
+)
+
+type breakerClient struct {
+	client  Client
+	breaker *breaker.CircuitBreaker
+}
+
+// NewBreakerClient wraps the client with the circuit breaker
+func NewBreakerClient(client Client, cb *breaker.CircuitBreaker) Client {
+	return &breakerClient{
+		client:  client,
+		breaker: cb,
+	}
+}
+
+func (c *breakerClient) GetDeployments(
+	ctx context.Context,
+	tenantID string,
+	IDs []string,
+) (res []*DeviceDeployment, err error) {
+	err = c.breaker.Do(func() error {
+		res, err = c.client.GetDeployments(ctx, tenantID, IDs)
+		return err
+	})
+	return res, err
+}
+
+func (c *breakerClient) GetLatestFinishedDeployment(
+	ctx context.Context,
+	tenantID string,
+	deviceID string,
+) (res *DeviceDeployment, err error) {
+	err = c.breaker.Do(func() error {
+		res, err = c.client.GetLatestFinishedDeployment(ctx, tenantID, deviceID)
+		return err
+	})
+	return res, err
+}
+
+func (c *breakerClient) GetDeploymentsByStatus(
+	ctx context.Context,
+	tenantID string,
+	status string,
+	from, to time.Time,
+	page, perPage int,
+) (res []*DeviceDeployment, err error) {
+	err = c.breaker.Do(func() error {
+		res, err = c.client.GetDeploymentsByStatus(ctx, tenantID, status, from, to,
+			page, perPage)
+		return err
+	})
+	return res, err
+}
+
+func (c *breakerClient) GetArtifacts(
+	ctx context.Context,
+	tenantID string,
+	IDs []string,
+) (res []*Image, err error) {
+	err = c.breaker.Do(func() error {
+		res, err = c.client.GetArtifacts(ctx, tenantID, IDs)
+		return err
+	})
+	return res, err
+}
+
+// CheckHealth checks the health of the service, regardless of the state
+// of the circuit breaker
+func (c *breakerClient) CheckHealth(ctx context.Context) error {
+	return c.client.CheckHealth(ctx)
+}
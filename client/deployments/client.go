@@ -16,28 +16,57 @@ package deployments
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"io"
+	"math/rand"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/go-lib-micro/log"
 
+	"github.com/mendersoftware/reporting/client/breaker"
+	"github.com/mendersoftware/reporting/client/metrics"
+	"github.com/mendersoftware/reporting/tracing"
 	"github.com/mendersoftware/reporting/utils"
 )
 
 const (
+	serviceName = "deployments"
+
 	urlDeviceDeployments   = "/api/internal/v1/deployments/tenants/:tid/deployments/devices"
 	urlDeviceDeploymentsID = urlDeviceDeployments + "/:id"
+	urlArtifacts           = "/api/internal/v1/deployments/tenants/:tid/artifacts"
+	urlHealth              = "/api/internal/v1/deployments/health"
 	defaultTimeout         = 10 * time.Second
 	maxPerPage             = 100
+
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 2 * time.Second
 )
 
+// RetryPolicy defines how requests failing with a transient error are retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, doubled
+	// after each subsequent attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+}
+
 //go:generate ../../x/mockgen.sh
 type Client interface {
+	// CheckHealth checks the health of the service
+	CheckHealth(ctx context.Context) error
 	// GetDeployments retrieves a list of deployments by ID
 	GetDeployments(
 		ctx context.Context,
@@ -50,31 +79,212 @@ type Client interface {
 		tenantID string,
 		deviceID string,
 	) (*DeviceDeployment, error)
+	// GetDeploymentsByStatus retrieves a page of the device deployments
+	// with the given status, created in the [from, to] time range; zero
+	// from or to values leave the range open on that side
+	GetDeploymentsByStatus(
+		ctx context.Context,
+		tenantID string,
+		status string,
+		from, to time.Time,
+		page, perPage int,
+	) ([]*DeviceDeployment, error)
+	// GetArtifacts retrieves the metadata of a list of artifacts by ID
+	GetArtifacts(
+		ctx context.Context,
+		tenantID string,
+		IDs []string,
+	) ([]*Image, error)
 }
 
+type ClientOption func(*client)
+
 type client struct {
-	client  *http.Client
-	urlBase string
+	client    *http.Client
+	urlBase   string
+	retry     RetryPolicy
+	timeout   time.Duration
+	transport utils.TransportOptions
 }
 
-func NewClient(urlBase string) Client {
-	return &client{
-		client:  &http.Client{},
+func NewClient(urlBase string, opts ...ClientOption) Client {
+	c := &client{
 		urlBase: urlBase,
+		timeout: defaultTimeout,
+		retry: RetryPolicy{
+			MaxAttempts:    defaultRetryMaxAttempts,
+			InitialBackoff: defaultRetryInitialBackoff,
+			MaxBackoff:     defaultRetryMaxBackoff,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.client = &http.Client{
+		Transport: tracing.NewTransport(serviceName,
+			metrics.NewTransport(serviceName, utils.NewTransport(c.transport))),
+	}
+	return c
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to the service
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *client) {
+		c.transport.TLSConfig = config
+	}
+}
+
+// WithConnectionPool sets the number of idle connections kept alive to the
+// service, and for how long; zero values keep the defaults
+func WithConnectionPool(maxIdleConnsPerHost int, idleConnTimeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		c.transport.IdleConnTimeout = idleConnTimeout
+	}
+}
+
+// WithTimeout sets the timeout of the requests to the service
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a copy of the context overriding the timeout
+// of the requests to the service made with it, e.g. to give the bulk
+// backfills more time than the API requests; values <= 0 are ignored
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// requestTimeout returns the timeout of the requests made with the
+// context: the one set with WithRequestTimeout, if any, or the client's one
+func (c *client) requestTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return timeout
+	}
+	return c.timeout
+}
+
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *client) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		if policy.MaxBackoff < policy.InitialBackoff {
+			policy.MaxBackoff = policy.InitialBackoff
+		}
+		c.retry = policy
+	}
+}
+
+// do submits the request, retrying it according to the retry policy
+// if it fails with a transient error
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	backoff := c.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		rsp, err := c.client.Do(req)
+		if attempt >= c.retry.MaxAttempts || !isRetryable(rsp, err) {
+			return rsp, err
+		}
+		if rsp != nil {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			rsp.Body.Close()
+		}
+		l := log.FromContext(req.Context())
+		l.Warnf("%s %s attempt %d failed, retrying: %s",
+			req.Method, req.URL, attempt, retryReason(rsp, err))
+
+		timer := time.NewTimer(withJitter(backoff))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
+
+// isRetryable returns true if the request failed because of a
+// connection reset or a temporary unavailability of the service
+func isRetryable(rsp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	switch rsp.StatusCode {
+	case http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func retryReason(rsp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return rsp.Status
+}
+
+// withJitter returns a random duration in the interval [d/2, d)
+func withJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
 	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)))
 }
 
+// GetDeployments retrieves the device deployments by ID; the IDs are split
+// in chunks of maxPerPage elements, each one retrieved with a separate
+// request, and the results are returned in the same order as the IDs
 func (c *client) GetDeployments(
 	ctx context.Context,
 	tenantID string,
 	IDs []string,
+) ([]*DeviceDeployment, error) {
+	var devDevs []*DeviceDeployment
+	for start := 0; start < len(IDs); start += maxPerPage {
+		end := start + maxPerPage
+		if end > len(IDs) {
+			end = len(IDs)
+		}
+		page, err := c.getDeploymentsPage(ctx, tenantID, IDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		devDevs = append(devDevs, page...)
+	}
+	if len(devDevs) == 0 {
+		return nil, nil
+	}
+	sortByIDs(devDevs, IDs)
+	return devDevs, nil
+}
+
+func (c *client) getDeploymentsPage(
+	ctx context.Context,
+	tenantID string,
+	IDs []string,
 ) ([]*DeviceDeployment, error) {
 	l := log.FromContext(ctx)
 
 	url := utils.JoinURL(c.urlBase, urlDeviceDeployments)
 	url = strings.Replace(url, ":tid", tenantID, 1)
 
-	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	ctx, cancel := context.WithTimeout(
+		metrics.WithEndpoint(ctx, urlDeviceDeployments), c.requestTimeout(ctx))
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -82,20 +292,15 @@ func (c *client) GetDeployments(
 		return nil, errors.Wrapf(err, "failed to create request")
 	}
 
-	nIDs := len(IDs)
-	if nIDs > maxPerPage {
-		return nil, errors.New("too many IDs")
-	}
-
 	q := req.URL.Query()
 	q.Add("page", "1")
-	q.Add("per_page", strconv.Itoa(nIDs))
+	q.Add("per_page", strconv.Itoa(len(IDs)))
 	for _, id := range IDs {
 		q.Add("id", id)
 	}
 	req.URL.RawQuery = q.Encode()
 
-	rsp, err := c.client.Do(req)
+	rsp, err := c.do(req)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
 	}
@@ -104,8 +309,9 @@ func (c *client) GetDeployments(
 	if rsp.StatusCode == http.StatusNotFound {
 		return nil, nil
 	} else if rsp.StatusCode != http.StatusOK {
-		err := errors.Errorf("%s %s request failed with status %v",
-			req.Method, req.URL, rsp.Status)
+		err := breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
 		l.Errorf(err.Error())
 		return nil, err
 	}
@@ -114,12 +320,30 @@ func (c *client) GetDeployments(
 	var devDevs []*DeviceDeployment
 	if err = dec.Decode(&devDevs); err != nil {
 		return nil, errors.Wrap(err, "failed to parse request body")
-	} else if len(devDevs) == 0 {
-		return nil, nil
 	}
 	return devDevs, nil
 }
 
+// sortByIDs sorts the device deployments following the order of the IDs;
+// device deployments with unknown IDs are moved to the end of the slice
+func sortByIDs(devDevs []*DeviceDeployment, IDs []string) {
+	positions := make(map[string]int, len(IDs))
+	for i, id := range IDs {
+		if _, ok := positions[id]; !ok {
+			positions[id] = i
+		}
+	}
+	position := func(id string) int {
+		if i, ok := positions[id]; ok {
+			return i
+		}
+		return len(IDs)
+	}
+	sort.SliceStable(devDevs, func(i, j int) bool {
+		return position(devDevs[i].ID) < position(devDevs[j].ID)
+	})
+}
+
 func (c *client) GetLatestFinishedDeployment(
 	ctx context.Context,
 	tenantID string,
@@ -131,7 +355,8 @@ func (c *client) GetLatestFinishedDeployment(
 	url = strings.Replace(url, ":tid", tenantID, 1)
 	url = strings.Replace(url, ":id", deviceID, 1)
 
-	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	ctx, cancel := context.WithTimeout(
+		metrics.WithEndpoint(ctx, urlDeviceDeploymentsID), c.requestTimeout(ctx))
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -144,7 +369,7 @@ func (c *client) GetLatestFinishedDeployment(
 	q.Add("per_page", "1")
 	req.URL.RawQuery = q.Encode()
 
-	rsp, err := c.client.Do(req)
+	rsp, err := c.do(req)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
 	}
@@ -153,8 +378,9 @@ func (c *client) GetLatestFinishedDeployment(
 	if rsp.StatusCode == http.StatusNotFound {
 		return nil, nil
 	} else if rsp.StatusCode != http.StatusOK {
-		err := errors.Errorf("%s %s request failed with status %v",
-			req.Method, req.URL, rsp.Status)
+		err := breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
 		l.Errorf(err.Error())
 		return nil, err
 	}
@@ -168,3 +394,161 @@ func (c *client) GetLatestFinishedDeployment(
 	}
 	return devDevs[0], nil
 }
+
+func (c *client) GetDeploymentsByStatus(
+	ctx context.Context,
+	tenantID string,
+	status string,
+	from, to time.Time,
+	page, perPage int,
+) ([]*DeviceDeployment, error) {
+	l := log.FromContext(ctx)
+
+	url := utils.JoinURL(c.urlBase, urlDeviceDeployments)
+	url = strings.Replace(url, ":tid", tenantID, 1)
+
+	ctx, cancel := context.WithTimeout(
+		metrics.WithEndpoint(ctx, urlDeviceDeployments), c.requestTimeout(ctx))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request")
+	}
+
+	q := req.URL.Query()
+	q.Add("page", strconv.Itoa(page))
+	q.Add("per_page", strconv.Itoa(perPage))
+	if status != "" {
+		q.Add("status", status)
+	}
+	if !from.IsZero() {
+		q.Add("created_after", strconv.FormatInt(from.Unix(), 10))
+	}
+	if !to.IsZero() {
+		q.Add("created_before", strconv.FormatInt(to.Unix(), 10))
+	}
+	req.URL.RawQuery = q.Encode()
+
+	rsp, err := c.do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if rsp.StatusCode != http.StatusOK {
+		err := breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
+		l.Errorf(err.Error())
+		return nil, err
+	}
+
+	dec := json.NewDecoder(rsp.Body)
+	var devDevs []*DeviceDeployment
+	if err = dec.Decode(&devDevs); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+	return devDevs, nil
+}
+
+// GetArtifacts retrieves the artifacts by ID; the IDs are split in chunks
+// of maxPerPage elements, each one retrieved with a separate request
+func (c *client) GetArtifacts(
+	ctx context.Context,
+	tenantID string,
+	IDs []string,
+) ([]*Image, error) {
+	var artifacts []*Image
+	for start := 0; start < len(IDs); start += maxPerPage {
+		end := start + maxPerPage
+		if end > len(IDs) {
+			end = len(IDs)
+		}
+		page, err := c.getArtifactsPage(ctx, tenantID, IDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, page...)
+	}
+	return artifacts, nil
+}
+
+func (c *client) getArtifactsPage(
+	ctx context.Context,
+	tenantID string,
+	IDs []string,
+) ([]*Image, error) {
+	l := log.FromContext(ctx)
+
+	url := utils.JoinURL(c.urlBase, urlArtifacts)
+	url = strings.Replace(url, ":tid", tenantID, 1)
+
+	ctx, cancel := context.WithTimeout(
+		metrics.WithEndpoint(ctx, urlArtifacts), c.requestTimeout(ctx))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request")
+	}
+
+	q := req.URL.Query()
+	q.Add("page", "1")
+	q.Add("per_page", strconv.Itoa(len(IDs)))
+	for _, id := range IDs {
+		q.Add("id", id)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	rsp, err := c.do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if rsp.StatusCode != http.StatusOK {
+		err := breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
+		l.Errorf(err.Error())
+		return nil, err
+	}
+
+	dec := json.NewDecoder(rsp.Body)
+	var artifacts []*Image
+	if err = dec.Decode(&artifacts); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+	return artifacts, nil
+}
+
+// CheckHealth checks the health of the service
+func (c *client) CheckHealth(ctx context.Context) error {
+	url := utils.JoinURL(c.urlBase, urlHealth)
+
+	ctx, cancel := context.WithTimeout(
+		metrics.WithEndpoint(ctx, urlHealth), c.requestTimeout(ctx))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
+	}
+	return nil
+}
@@ -17,11 +17,16 @@ package deployments
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -347,3 +352,532 @@ func TestGetLatestFinishedDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDeploymentsRetry(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		MaxAttempts   int
+		ResponseCodes []int
+
+		Res   []*DeviceDeployment
+		Error error
+	}{{
+		Name: "ok, after retrying",
+
+		MaxAttempts: 3,
+		ResponseCodes: []int{
+			http.StatusServiceUnavailable,
+			http.StatusBadGateway,
+			http.StatusOK,
+		},
+		Res: []*DeviceDeployment{{
+			ID: "c5e37ef5-160e-401a-aec3-9dbef94855c0",
+		}},
+	}, {
+		Name: "error, max attempts reached",
+
+		MaxAttempts: 2,
+		ResponseCodes: []int{
+			http.StatusGatewayTimeout,
+			http.StatusServiceUnavailable,
+		},
+		Error: errors.New(`^GET .+ request failed with status 503`),
+	}, {
+		Name: "error, not retryable",
+
+		MaxAttempts: 3,
+		ResponseCodes: []int{
+			http.StatusInternalServerError,
+		},
+		Error: errors.New(`^GET .+ request failed with status 500`),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, len(tc.ResponseCodes))
+			srv := newTestServer(rspChan, nil)
+			defer srv.Close()
+
+			client := NewClient(srv.URL, WithRetryPolicy(RetryPolicy{
+				MaxAttempts:    tc.MaxAttempts,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     5 * time.Millisecond,
+			}))
+
+			for _, code := range tc.ResponseCodes {
+				rsp := &http.Response{
+					StatusCode: code,
+				}
+				if code == http.StatusOK {
+					b, _ := json.Marshal(tc.Res)
+					rsp.Body = io.NopCloser(bytes.NewReader(b))
+				}
+				rspChan <- rsp
+			}
+			res, err := client.GetDeployments(context.Background(),
+				"123456789012345678901234",
+				[]string{"c5e37ef5-160e-401a-aec3-9dbef94855c0"})
+
+			if tc.Error != nil {
+				if assert.Error(t, err) {
+					assert.Regexp(t,
+						tc.Error.Error(),
+						err.Error(),
+						"error message does not match expected pattern",
+					)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Res, res)
+			}
+			assert.Len(t, rspChan, 0, "not all the responses were consumed")
+		})
+	}
+}
+
+func TestGetDeploymentsPagination(t *testing.T) {
+	t.Parallel()
+
+	const nIDs = 2*maxPerPage + 10
+	IDs := make([]string, nIDs)
+	for i := range IDs {
+		IDs[i] = fmt.Sprintf("%08d-160e-401a-aec3-9dbef94855c0", i)
+	}
+
+	rspChan := make(chan *http.Response, 3)
+	reqChan := make(chan *http.Request, 3)
+	srv := newTestServer(rspChan, reqChan)
+	defer srv.Close()
+
+	for start := 0; start < nIDs; start += maxPerPage {
+		end := start + maxPerPage
+		if end > nIDs {
+			end = nIDs
+		}
+		// the deployments service returns the items in reverse order
+		page := make([]DeviceDeployment, 0, end-start)
+		for i := end - 1; i >= start; i-- {
+			page = append(page, DeviceDeployment{ID: IDs[i]})
+		}
+		b, _ := json.Marshal(page)
+		rspChan <- &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+		}
+	}
+
+	client := NewClient(srv.URL)
+	res, err := client.GetDeployments(context.Background(),
+		"123456789012345678901234", IDs)
+	assert.NoError(t, err)
+	if assert.Len(t, res, nIDs) {
+		for i, devDep := range res {
+			assert.Equal(t, IDs[i], devDep.ID)
+		}
+	}
+
+	assert.Len(t, reqChan, 3)
+	for _, perPage := range []int{maxPerPage, maxPerPage, 10} {
+		req := <-reqChan
+		q := req.URL.Query()
+		assert.Equal(t, strconv.Itoa(perPage), q.Get("per_page"))
+		assert.Len(t, q["id"], perPage)
+	}
+}
+
+func TestGetDeploymentsByStatus(t *testing.T) {
+	t.Parallel()
+	from := time.Unix(1672531200, 0)
+	to := time.Unix(1675209600, 0)
+	testCases := []struct {
+		Name string
+
+		CTX      context.Context
+		TenantID string
+		Status   string
+		From     time.Time
+		To       time.Time
+
+		URLNoise     string
+		ResponseCode int
+		ResponseBody interface{}
+
+		Query map[string][]string
+		Res   []*DeviceDeployment
+		Error error
+	}{{
+		Name: "ok",
+
+		CTX:      context.Background(),
+		TenantID: "123456789012345678901234",
+		Status:   "success",
+		From:     from,
+		To:       to,
+
+		ResponseCode: http.StatusOK,
+		ResponseBody: []DeviceDeployment{{
+			ID: "c5e37ef5-160e-401a-aec3-9dbef94855c0",
+			Device: &Device{
+				Status: "success",
+			},
+		}},
+
+		Query: map[string][]string{
+			"page":           {"2"},
+			"per_page":       {"10"},
+			"status":         {"success"},
+			"created_after":  {"1672531200"},
+			"created_before": {"1675209600"},
+		},
+		Res: []*DeviceDeployment{{
+			ID: "c5e37ef5-160e-401a-aec3-9dbef94855c0",
+			Device: &Device{
+				Status: "success",
+			},
+		}},
+	}, {
+		Name: "ok, open range and any status",
+
+		CTX:      context.Background(),
+		TenantID: "123456789012345678901234",
+
+		ResponseCode: http.StatusOK,
+		ResponseBody: []DeviceDeployment{},
+
+		Query: map[string][]string{
+			"page":     {"2"},
+			"per_page": {"10"},
+		},
+		Res: []*DeviceDeployment{},
+	}, {
+		Name: "ok, not found",
+
+		CTX:      context.Background(),
+		TenantID: "123456789012345678901234",
+		Status:   "success",
+
+		ResponseCode: http.StatusNotFound,
+	}, {
+		Name:     "error, nil context",
+		CTX:      context.Background(),
+		URLNoise: "#%%%",
+
+		Error: errors.New("failed to create request"),
+	}, {
+		Name: "error, invalid response schema",
+
+		CTX:      context.Background(),
+		TenantID: "123456789012345678901234",
+
+		ResponseCode: http.StatusOK,
+		ResponseBody: []byte("bad response"),
+		Error:        errors.New("failed to parse request body"),
+	}, {
+		Name: "error, unexpected status code",
+
+		CTX:      context.Background(),
+		TenantID: "123456789012345678901234",
+
+		ResponseCode: http.StatusInternalServerError,
+		ResponseBody: rest.Error{Err: "something went wrong..."},
+		Error:        errors.New(`^GET .+ request failed with status 500`),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			client := NewClient(srv.URL + tc.URLNoise)
+
+			rsp := &http.Response{
+				StatusCode: tc.ResponseCode,
+			}
+
+			switch typ := tc.ResponseBody.(type) {
+			case []DeviceDeployment:
+				b, _ := json.Marshal(typ)
+				rsp.Body = io.NopCloser(bytes.NewReader(b))
+
+			case rest.Error:
+				b, _ := json.Marshal(typ)
+				rsp.Body = io.NopCloser(bytes.NewReader(b))
+
+			case []byte:
+				rsp.Body = io.NopCloser(bytes.NewReader(typ))
+
+			case nil:
+				// pass
+
+			default:
+				panic("[PROG ERR] invalid ResponseBody type")
+			}
+			rspChan <- rsp
+			res, err := client.GetDeploymentsByStatus(tc.CTX, tc.TenantID, tc.Status,
+				tc.From, tc.To, 2, 10)
+
+			if tc.Error != nil {
+				if assert.Error(t, err) {
+					assert.Regexp(t,
+						tc.Error.Error(),
+						err.Error(),
+						"error message does not match expected pattern",
+					)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Res, res)
+			}
+			if tc.Query != nil {
+				req := <-reqChan
+				assert.Equal(t, tc.Query, map[string][]string(req.URL.Query()))
+			}
+		})
+	}
+}
+
+func TestGetArtifacts(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		IDs []string
+
+		URLNoise     string
+		ResponseCode int
+		ResponseBody interface{}
+
+		Query map[string][]string
+		Res   []*Image
+		Error error
+	}{{
+		Name: "ok",
+
+		IDs: []string{"b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0"},
+
+		ResponseCode: http.StatusOK,
+		ResponseBody: []Image{{
+			Id:                    "b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0",
+			Name:                  "release-1",
+			DeviceTypesCompatible: []string{"raspberrypi4"},
+			Size:                  1024,
+		}},
+
+		Query: map[string][]string{
+			"page":     {"1"},
+			"per_page": {"1"},
+			"id":       {"b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0"},
+		},
+		Res: []*Image{{
+			Id:                    "b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0",
+			Name:                  "release-1",
+			DeviceTypesCompatible: []string{"raspberrypi4"},
+			Size:                  1024,
+		}},
+	}, {
+		Name: "ok, not found",
+
+		IDs: []string{"b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0"},
+
+		ResponseCode: http.StatusNotFound,
+	}, {
+		Name: "ok, no IDs",
+	}, {
+		Name:     "error, invalid URL",
+		IDs:      []string{"b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0"},
+		URLNoise: "#%%%",
+
+		Error: errors.New("failed to create request"),
+	}, {
+		Name: "error, invalid response schema",
+
+		IDs: []string{"b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0"},
+
+		ResponseCode: http.StatusOK,
+		ResponseBody: []byte("bad response"),
+		Error:        errors.New("failed to parse request body"),
+	}, {
+		Name: "error, unexpected status code",
+
+		IDs: []string{"b0f1e3a6-2b25-4d1a-8a5e-c1f9c3b2a1d0"},
+
+		ResponseCode: http.StatusInternalServerError,
+		ResponseBody: rest.Error{Err: "something went wrong..."},
+		Error:        errors.New(`^GET .+ request failed with status 500`),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			client := NewClient(srv.URL + tc.URLNoise)
+
+			rsp := &http.Response{
+				StatusCode: tc.ResponseCode,
+			}
+			switch typ := tc.ResponseBody.(type) {
+			case []Image, rest.Error:
+				b, _ := json.Marshal(typ)
+				rsp.Body = io.NopCloser(bytes.NewReader(b))
+
+			case []byte:
+				rsp.Body = io.NopCloser(bytes.NewReader(typ))
+			}
+			rspChan <- rsp
+			res, err := client.GetArtifacts(context.Background(),
+				"123456789012345678901234", tc.IDs)
+
+			if tc.Error != nil {
+				if assert.Error(t, err) {
+					assert.Regexp(t,
+						tc.Error.Error(),
+						err.Error(),
+						"error message does not match expected pattern",
+					)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.Res, res)
+			}
+			if tc.Query != nil {
+				req := <-reqChan
+				assert.Equal(t, tc.Query, map[string][]string(req.URL.Query()))
+			}
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		ResponseCode int
+
+		Error string
+	}{{
+		Name: "ok",
+
+		ResponseCode: http.StatusNoContent,
+	}, {
+		Name: "error, unhealthy",
+
+		ResponseCode: http.StatusServiceUnavailable,
+		Error:        "request failed with status 503 Service Unavailable",
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			rspChan <- &http.Response{StatusCode: tc.ResponseCode}
+			client := NewClient(srv.URL)
+			err := client.CheckHealth(context.Background())
+			if tc.Error != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			req := <-reqChan
+			assert.Equal(t, http.MethodGet, req.Method)
+			assert.Equal(t, "/api/internal/v1/deployments/health", req.URL.Path)
+		})
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	client := NewClient(srv.URL)
+	err := client.CheckHealth(context.Background())
+	assert.Error(t, err, "the server certificate is not trusted")
+
+	client = NewClient(srv.URL, WithTLSConfig(&tls.Config{
+		RootCAs:      roots,
+		Certificates: srv.TLS.Certificates,
+		MinVersion:   tls.VersionTLS12,
+	}))
+	err = client.CheckHealth(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name string
+
+		Timeout time.Duration
+
+		Error error
+	}{{
+		Name: "ok, timeout overridden",
+
+		Timeout: 5 * time.Second,
+	}, {
+		Name: "error, timeout not overridden",
+
+		Error: context.DeadlineExceeded,
+	}, {
+		Name: "error, shorter timeout",
+
+		Timeout: 10 * time.Millisecond,
+		Error:   context.DeadlineExceeded,
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(100 * time.Millisecond)
+					w.WriteHeader(http.StatusNoContent)
+				}))
+			defer srv.Close()
+
+			client := NewClient(srv.URL, WithTimeout(50*time.Millisecond))
+
+			ctx := context.Background()
+			if tc.Timeout != 0 {
+				ctx = WithRequestTimeout(ctx, tc.Timeout)
+			}
+			err := client.CheckHealth(ctx)
+			if tc.Error != nil {
+				assert.ErrorIs(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2022 Northern.tech AS
+// Copyright 2023 Northern.tech AS
 //
 //	Licensed under the Apache License, Version 2.0 (the "License");
 //	you may not use this file except in compliance with the License.
@@ -21,6 +21,8 @@ import (
 
 	deployments "github.com/mendersoftware/reporting/client/deployments"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Client is an autogenerated mock type for the Client type
@@ -28,6 +30,43 @@ type Client struct {
 	mock.Mock
 }
 
+// CheckHealth provides a mock function with given fields: ctx
+func (_m *Client) CheckHealth(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetArtifacts provides a mock function with given fields: ctx, tenantID, IDs
+func (_m *Client) GetArtifacts(ctx context.Context, tenantID string, IDs []string) ([]*deployments.Image, error) {
+	ret := _m.Called(ctx, tenantID, IDs)
+
+	var r0 []*deployments.Image
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) []*deployments.Image); ok {
+		r0 = rf(ctx, tenantID, IDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*deployments.Image)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, tenantID, IDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetDeployments provides a mock function with given fields: ctx, tenantID, IDs
 func (_m *Client) GetDeployments(ctx context.Context, tenantID string, IDs []string) ([]*deployments.DeviceDeployment, error) {
 	ret := _m.Called(ctx, tenantID, IDs)
@@ -51,6 +90,29 @@ func (_m *Client) GetDeployments(ctx context.Context, tenantID string, IDs []str
 	return r0, r1
 }
 
+// GetDeploymentsByStatus provides a mock function with given fields: ctx, tenantID, status, from, to, page, perPage
+func (_m *Client) GetDeploymentsByStatus(ctx context.Context, tenantID string, status string, from time.Time, to time.Time, page int, perPage int) ([]*deployments.DeviceDeployment, error) {
+	ret := _m.Called(ctx, tenantID, status, from, to, page, perPage)
+
+	var r0 []*deployments.DeviceDeployment
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time, int, int) []*deployments.DeviceDeployment); ok {
+		r0 = rf(ctx, tenantID, status, from, to, page, perPage)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*deployments.DeviceDeployment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, time.Time, int, int) error); ok {
+		r1 = rf(ctx, tenantID, status, from, to, page, perPage)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLatestFinishedDeployment provides a mock function with given fields: ctx, tenantID, deviceID
 func (_m *Client) GetLatestFinishedDeployment(ctx context.Context, tenantID string, deviceID string) (*deployments.DeviceDeployment, error) {
 	ret := _m.Called(ctx, tenantID, deviceID)
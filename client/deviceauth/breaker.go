@@ -0,0 +1,52 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package deviceauth
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/client/breaker"
+)
+
+type breakerClient struct {
+	client  Client
+	breaker *breaker.CircuitBreaker
+}
+
+// NewBreakerClient wraps the client with the circuit breaker
+func NewBreakerClient(client Client, cb *breaker.CircuitBreaker) Client {
+	return &breakerClient{
+		client:  client,
+		breaker: cb,
+	}
+}
+
+func (c *breakerClient) GetDevices(
+	ctx context.Context,
+	tid string,
+	deviceIDs []string,
+) (res []DeviceAuthDevice, err error) {
+	err = c.breaker.Do(func() error {
+		res, err = c.client.GetDevices(ctx, tid, deviceIDs)
+		return err
+	})
+	return res, err
+}
+
+// CheckHealth checks the health of the service, regardless of the state
+// of the circuit breaker
+func (c *breakerClient) CheckHealth(ctx context.Context) error {
+	return c.client.CheckHealth(ctx)
+}
@@ -15,6 +15,7 @@ package deviceauth
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -25,31 +26,76 @@ import (
 
 	"github.com/mendersoftware/go-lib-micro/log"
 
+	"github.com/mendersoftware/reporting/client/breaker"
+	"github.com/mendersoftware/reporting/client/metrics"
 	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/tracing"
 	"github.com/mendersoftware/reporting/utils"
 )
 
 const (
+	serviceName = "deviceauth"
+
 	urlSearch      = "/api/internal/v1/devauth/tenants/:tid/devices"
+	urlHealth      = "/api/internal/v1/devauth/health"
 	defaultPage    = 1
 	defaultTimeout = 10 * time.Second
 )
 
 //go:generate ../../x/mockgen.sh
 type Client interface {
+	// CheckHealth checks the health of the service
+	CheckHealth(ctx context.Context) error
 	//GetDevices uses the search endpoint to get devices just by ids (not filters)
 	GetDevices(ctx context.Context, tid string, deviceIDs []string) ([]DeviceAuthDevice, error)
 }
 
+type ClientOption func(*client)
+
 type client struct {
-	client  *http.Client
-	urlBase string
+	client    *http.Client
+	urlBase   string
+	timeout   time.Duration
+	transport utils.TransportOptions
 }
 
-func NewClient(urlBase string) Client {
-	return &client{
-		client:  &http.Client{},
+func NewClient(urlBase string, opts ...ClientOption) Client {
+	c := &client{
 		urlBase: urlBase,
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.client = &http.Client{
+		Transport: tracing.NewTransport(serviceName,
+			metrics.NewTransport(serviceName, utils.NewTransport(c.transport))),
+	}
+	return c
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to the service
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *client) {
+		c.transport.TLSConfig = config
+	}
+}
+
+// WithConnectionPool sets the number of idle connections kept alive to the
+// service, and for how long; zero values keep the defaults
+func WithConnectionPool(maxIdleConnsPerHost int, idleConnTimeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		c.transport.IdleConnTimeout = idleConnTimeout
+	}
+}
+
+// WithTimeout sets the timeout of the requests to the service
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
 	}
 }
 
@@ -63,7 +109,7 @@ func (c *client) GetDevices(
 	url := utils.JoinURL(c.urlBase, urlSearch)
 	url = strings.Replace(url, ":tid", tid, 1)
 
-	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlSearch), c.timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -88,8 +134,9 @@ func (c *client) GetDevices(
 	defer rsp.Body.Close()
 
 	if rsp.StatusCode != http.StatusOK {
-		err := errors.Errorf("%s %s request failed with status %v",
-			req.Method, req.URL, rsp.Status)
+		err := breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
 		l.Errorf(err.Error())
 		return nil, err
 	}
@@ -102,3 +149,28 @@ func (c *client) GetDevices(
 
 	return devDevs, nil
 }
+
+// CheckHealth checks the health of the service
+func (c *client) CheckHealth(ctx context.Context) error {
+	url := utils.JoinURL(c.urlBase, urlHealth)
+
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlHealth), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
+	}
+	return nil
+}
@@ -201,3 +201,47 @@ func TestGetDevices(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckHealth(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		ResponseCode int
+
+		Error string
+	}{{
+		Name: "ok",
+
+		ResponseCode: http.StatusNoContent,
+	}, {
+		Name: "error, unhealthy",
+
+		ResponseCode: http.StatusServiceUnavailable,
+		Error:        "request failed with status 503 Service Unavailable",
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			rspChan <- &http.Response{StatusCode: tc.ResponseCode}
+			client := NewClient(srv.URL)
+			err := client.CheckHealth(context.Background())
+			if tc.Error != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			req := <-reqChan
+			assert.Equal(t, http.MethodGet, req.Method)
+			assert.Equal(t, "/api/internal/v1/devauth/health", req.URL.Path)
+		})
+	}
+}
@@ -28,6 +28,20 @@ type Client struct {
 	mock.Mock
 }
 
+// CheckHealth provides a mock function with given fields: ctx
+func (_m *Client) CheckHealth(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetDevices provides a mock function with given fields: ctx, tid, deviceIDs
 func (_m *Client) GetDevices(ctx context.Context, tid string, deviceIDs []string) ([]deviceauth.DeviceAuthDevice, error) {
 	ret := _m.Called(ctx, tid, deviceIDs)
@@ -0,0 +1,136 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package enrichment
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/metrics"
+	"github.com/mendersoftware/reporting/tracing"
+	"github.com/mendersoftware/reporting/utils"
+)
+
+const (
+	serviceName = "enrichment"
+	// endpointWebhook labels the metrics of the requests to the webhook
+	endpointWebhook = "webhook"
+
+	defaultTimeout = 5 * time.Second
+)
+
+//go:generate ../../x/mockgen.sh
+type Client interface {
+	// Enrich sends the draft document of the device to the webhook, and
+	// returns the attributes to add to it
+	Enrich(ctx context.Context, device *Device) ([]Attribute, error)
+}
+
+type ClientOption func(*client)
+
+type client struct {
+	client    *http.Client
+	url       string
+	token     string
+	timeout   time.Duration
+	transport utils.TransportOptions
+}
+
+// NewClient returns the client of the enrichment webhook at the given URL
+func NewClient(url string, opts ...ClientOption) Client {
+	c := &client{
+		url:     url,
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.client = &http.Client{
+		Transport: tracing.NewTransport(serviceName,
+			metrics.NewTransport(serviceName, utils.NewTransport(c.transport))),
+	}
+	return c
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to the webhook
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *client) {
+		c.transport.TLSConfig = config
+	}
+}
+
+// WithTimeout sets the timeout of the requests to the webhook
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// WithToken sets the bearer token the requests to the webhook are
+// authenticated with
+func WithToken(token string) ClientOption {
+	return func(c *client) {
+		c.token = token
+	}
+}
+
+func (c *client) Enrich(ctx context.Context, device *Device) ([]Attribute, error) {
+	body, err := json.Marshal(device)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize the device")
+	}
+
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, endpointWebhook), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("%s %s request failed with status %v",
+			req.Method, req.URL, rsp.Status)
+	}
+
+	var res Response
+	if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
+		return nil, errors.Wrap(err, "failed to parse response body")
+	}
+	return res.Attributes, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	device := &Device{
+		ID:       "1",
+		TenantID: "tenant",
+		Attributes: []Attribute{
+			{Scope: "identity", Name: "mac", Value: "00:11:22:33:44:55"},
+		},
+	}
+	testCases := map[string]struct {
+		token  string
+		status int
+		body   string
+
+		attributes []Attribute
+		err        string
+	}{
+		"ok": {
+			token:  "secret",
+			status: http.StatusOK,
+			body: `{"attributes": [` +
+				`{"scope": "tags", "name": "owner", "value": "ops"}]}`,
+			attributes: []Attribute{
+				{Scope: "tags", Name: "owner", Value: "ops"},
+			},
+		},
+		"ok, no content": {
+			status: http.StatusNoContent,
+		},
+		"error, status": {
+			status: http.StatusInternalServerError,
+			err:    "request failed with status 500 Internal Server Error",
+		},
+		"error, malformed response": {
+			status: http.StatusOK,
+			body:   `{"attributes": "owner"}`,
+			err:    "failed to parse response body",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, http.MethodPost, r.Method)
+					if tc.token != "" {
+						assert.Equal(t, "Bearer "+tc.token, r.Header.Get("Authorization"))
+					} else {
+						assert.Empty(t, r.Header.Get("Authorization"))
+					}
+					var req Device
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+					assert.Equal(t, *device, req)
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+			defer srv.Close()
+
+			client := NewClient(srv.URL, WithToken(tc.token))
+			attributes, err := client.Enrich(context.Background(), device)
+			if tc.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.attributes, attributes)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package enrichment
+
+// Device is the draft document of a device sent to the webhook
+type Device struct {
+	ID         string      `json:"id"`
+	TenantID   string      `json:"tenant_id"`
+	Attributes []Attribute `json:"attributes"`
+}
+
+// Attribute is an attribute of a device
+type Attribute struct {
+	Scope string      `json:"scope"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// Response is the response of the webhook, with the attributes to add to
+// the document of the device
+type Response struct {
+	Attributes []Attribute `json:"attributes"`
+}
@@ -16,6 +16,7 @@ package inventory
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -24,31 +25,95 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/go-lib-micro/log"
+	"github.com/mendersoftware/go-lib-micro/rbac"
 
+	"github.com/mendersoftware/reporting/client/breaker"
+	"github.com/mendersoftware/reporting/client/metrics"
+	"github.com/mendersoftware/reporting/tracing"
 	"github.com/mendersoftware/reporting/utils"
 )
 
 const (
+	serviceName = "inventory"
+
 	urlSearch      = "/api/internal/v2/inventory/tenants/:tid/filters/search"
+	urlHealth      = "/api/internal/v1/inventory/health"
+	urlDeviceTags  = "/api/management/v1/inventory/devices/:id/tags"
 	defaultPage    = 1
 	defaultTimeout = 10 * time.Second
 )
 
+var (
+	// ErrDeviceNotFound is returned when the device does not exist
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrInvalidTags is returned when inventory rejects the tags
+	ErrInvalidTags = errors.New("invalid tags")
+)
+
 //go:generate ../../x/mockgen.sh
 type Client interface {
+	// CheckHealth checks the health of the service
+	CheckHealth(ctx context.Context) error
 	//GetDevices uses the search endpoint to get devices just by ids (not filters)
 	GetDevices(ctx context.Context, tid string, deviceIDs []string) ([]Device, error)
+	// ListDevices uses the search endpoint to get a page of the tenant's
+	// devices, sorted by creation time; if updatedSince is not zero, only
+	// the devices updated since then are returned
+	ListDevices(ctx context.Context, tid string, updatedSince time.Time,
+		page, perPage int) ([]Device, error)
+	// ReplaceDeviceTags replaces the tags of the device through the
+	// management API, on behalf of the user authorized by the
+	// authorization header and restricted to the RBAC scope of the context
+	ReplaceDeviceTags(ctx context.Context, authorization, deviceID string,
+		tags []Tag) error
 }
 
+type ClientOption func(*client)
+
 type client struct {
-	client  *http.Client
-	urlBase string
+	client    *http.Client
+	urlBase   string
+	timeout   time.Duration
+	transport utils.TransportOptions
 }
 
-func NewClient(urlBase string) Client {
-	return &client{
-		client:  &http.Client{},
+func NewClient(urlBase string, opts ...ClientOption) Client {
+	c := &client{
 		urlBase: urlBase,
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.client = &http.Client{
+		Transport: tracing.NewTransport(serviceName,
+			metrics.NewTransport(serviceName, utils.NewTransport(c.transport))),
+	}
+	return c
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to the service
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *client) {
+		c.transport.TLSConfig = config
+	}
+}
+
+// WithConnectionPool sets the number of idle connections kept alive to the
+// service, and for how long; zero values keep the defaults
+func WithConnectionPool(maxIdleConnsPerHost int, idleConnTimeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		c.transport.IdleConnTimeout = idleConnTimeout
+	}
+}
+
+// WithTimeout sets the timeout of the requests to the service
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
 	}
 }
 
@@ -57,14 +122,43 @@ func (c *client) GetDevices(
 	tid string,
 	deviceIDs []string,
 ) ([]Device, error) {
-	l := log.FromContext(ctx)
-
 	perPage := uint(len(deviceIDs))
 	getReq := &GetDevsReq{
 		DeviceIDs: deviceIDs,
 		Page:      defaultPage,
 		PerPage:   perPage,
 	}
+	return c.search(ctx, tid, getReq)
+}
+
+func (c *client) ListDevices(
+	ctx context.Context,
+	tid string,
+	updatedSince time.Time,
+	page, perPage int,
+) ([]Device, error) {
+	getReq := &GetDevsReq{
+		Sort: []SortCriteria{{
+			Scope:     AttrScopeSystem,
+			Attribute: "created_ts",
+			Order:     "asc",
+		}},
+		Page:    uint(page),
+		PerPage: uint(perPage),
+	}
+	if !updatedSince.IsZero() {
+		getReq.Filters = []FilterPredicate{{
+			Scope:     AttrScopeSystem,
+			Attribute: "updated_ts",
+			Type:      "$gte",
+			Value:     updatedSince.UTC().Format(time.RFC3339Nano),
+		}}
+	}
+	return c.search(ctx, tid, getReq)
+}
+
+func (c *client) search(ctx context.Context, tid string, getReq *GetDevsReq) ([]Device, error) {
+	l := log.FromContext(ctx)
 
 	body, err := json.Marshal(getReq)
 	if err != nil {
@@ -76,7 +170,7 @@ func (c *client) GetDevices(
 	url := utils.JoinURL(c.urlBase, urlSearch)
 	url = strings.Replace(url, ":tid", tid, 1)
 
-	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlSearch), c.timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, rd)
@@ -96,8 +190,8 @@ func (c *client) GetDevices(
 		l.Errorf("request %s %s failed with status %v, response: %s",
 			req.Method, req.URL, rsp.Status, body)
 
-		return nil, errors.Errorf(
-			"%s %s request failed with status %v", req.Method, req.URL, rsp.Status)
+		return nil, breaker.NewStatusError(rsp.StatusCode, errors.Errorf(
+			"%s %s request failed with status %v", req.Method, req.URL, rsp.Status))
 	}
 
 	dec := json.NewDecoder(rsp.Body)
@@ -108,3 +202,79 @@ func (c *client) GetDevices(
 
 	return invDevs, nil
 }
+
+func (c *client) ReplaceDeviceTags(
+	ctx context.Context,
+	authorization, deviceID string,
+	tags []Tag,
+) error {
+	body, err := json.Marshal(tags)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize the tags")
+	}
+
+	url := utils.JoinURL(c.urlBase, urlDeviceTags)
+	url = strings.Replace(url, ":id", deviceID, 1)
+
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlDeviceTags), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authorization)
+	if scope := rbac.FromContext(ctx); scope != nil {
+		req.Header.Set(rbac.ScopeHeader, strings.Join(scope.DeviceGroups, ","))
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return breaker.NewStatusError(rsp.StatusCode, ErrDeviceNotFound)
+	case http.StatusBadRequest:
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(rsp.Body).Decode(&apiErr)
+		return breaker.NewStatusError(rsp.StatusCode,
+			errors.Wrap(ErrInvalidTags, apiErr.Error))
+	default:
+		return breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
+	}
+}
+
+// CheckHealth checks the health of the service
+func (c *client) CheckHealth(ctx context.Context) error {
+	url := utils.JoinURL(c.urlBase, urlHealth)
+
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlHealth), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return breaker.NewStatusError(rsp.StatusCode,
+			errors.Errorf("%s %s request failed with status %v",
+				req.Method, req.URL, rsp.Status))
+	}
+	return nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/mendersoftware/go-lib-micro/rbac"
 	"github.com/mendersoftware/go-lib-micro/rest.utils"
 )
 
@@ -211,3 +212,251 @@ func TestGetDevices(t *testing.T) {
 		})
 	}
 }
+
+func TestListDevices(t *testing.T) {
+	t.Parallel()
+	updatedSince := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		Name string
+
+		UpdatedSince time.Time
+		ResponseCode int
+		ResponseBody []byte
+
+		Request *GetDevsReq
+		Devices []Device
+		Error   error
+	}{{
+		Name: "ok",
+
+		ResponseCode: http.StatusOK,
+		ResponseBody: []byte(`[{"id":"9acfe595-78ff-456a-843a-0fa08bfd7c7a"}]`),
+
+		Request: &GetDevsReq{
+			Sort: []SortCriteria{{
+				Scope:     AttrScopeSystem,
+				Attribute: "created_ts",
+				Order:     "asc",
+			}},
+			Page:    2,
+			PerPage: 100,
+		},
+		Devices: []Device{{
+			ID: DeviceID("9acfe595-78ff-456a-843a-0fa08bfd7c7a"),
+		}},
+	}, {
+		Name: "ok, updated since",
+
+		UpdatedSince: updatedSince,
+		ResponseCode: http.StatusOK,
+		ResponseBody: []byte(`[]`),
+
+		Request: &GetDevsReq{
+			Filters: []FilterPredicate{{
+				Scope:     AttrScopeSystem,
+				Attribute: "updated_ts",
+				Type:      "$gte",
+				Value:     "2023-01-02T03:04:05Z",
+			}},
+			Sort: []SortCriteria{{
+				Scope:     AttrScopeSystem,
+				Attribute: "created_ts",
+				Order:     "asc",
+			}},
+			Page:    2,
+			PerPage: 100,
+		},
+		Devices: []Device{},
+	}, {
+		Name: "error, unexpected status code",
+
+		ResponseCode: http.StatusInternalServerError,
+		Error:        errors.New(`^POST [A-Za-z:0-9/\.]+ request failed with status 500`),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			client := NewClient(srv.URL)
+
+			rsp := &http.Response{
+				StatusCode: tc.ResponseCode,
+			}
+			if tc.ResponseBody != nil {
+				rsp.Body = io.NopCloser(bytes.NewReader(tc.ResponseBody))
+			}
+			rspChan <- rsp
+			devs, err := client.ListDevices(context.Background(),
+				"123456789012345678901234", tc.UpdatedSince, 2, 100)
+
+			if tc.Error != nil {
+				if assert.Error(t, err) {
+					assert.Regexp(t, tc.Error.Error(), err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Devices, devs)
+
+			req := <-reqChan
+			var getReq GetDevsReq
+			_ = json.NewDecoder(req.Body).Decode(&getReq)
+			assert.Equal(t, tc.Request, &getReq)
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name string
+
+		ResponseCode int
+
+		Error string
+	}{{
+		Name: "ok",
+
+		ResponseCode: http.StatusNoContent,
+	}, {
+		Name: "error, unhealthy",
+
+		ResponseCode: http.StatusServiceUnavailable,
+		Error:        "request failed with status 503 Service Unavailable",
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			rspChan <- &http.Response{StatusCode: tc.ResponseCode}
+			client := NewClient(srv.URL)
+			err := client.CheckHealth(context.Background())
+			if tc.Error != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			req := <-reqChan
+			assert.Equal(t, http.MethodGet, req.Method)
+			assert.Equal(t, "/api/internal/v1/inventory/health", req.URL.Path)
+		})
+	}
+}
+
+func TestReplaceDeviceTags(t *testing.T) {
+	t.Parallel()
+	tags := []Tag{{Name: "owner", Value: "ops"}}
+	testCases := []struct {
+		Name string
+
+		Scope        *rbac.Scope
+		ResponseCode int
+		ResponseBody string
+
+		Error error
+	}{{
+		Name: "ok",
+
+		ResponseCode: http.StatusOK,
+	}, {
+		Name: "ok, with RBAC scope",
+
+		Scope:        &rbac.Scope{DeviceGroups: []string{"dev", "qa"}},
+		ResponseCode: http.StatusOK,
+	}, {
+		Name: "error, device not found",
+
+		ResponseCode: http.StatusNotFound,
+		Error:        ErrDeviceNotFound,
+	}, {
+		Name: "error, invalid tags",
+
+		ResponseCode: http.StatusBadRequest,
+		ResponseBody: `{"error": "tag name is too long"}`,
+		Error:        ErrInvalidTags,
+	}, {
+		Name: "error, internal server error",
+
+		ResponseCode: http.StatusInternalServerError,
+		Error:        errors.New("request failed with status 500 Internal Server Error"),
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			rspChan := make(chan *http.Response, 1)
+			reqChan := make(chan *http.Request, 1)
+			srv := newTestServer(rspChan, reqChan)
+			defer srv.Close()
+
+			rspChan <- &http.Response{
+				StatusCode: tc.ResponseCode,
+				Body:       io.NopCloser(bytes.NewReader([]byte(tc.ResponseBody))),
+			}
+			ctx := context.Background()
+			if tc.Scope != nil {
+				ctx = rbac.WithContext(ctx, tc.Scope)
+			}
+			client := NewClient(srv.URL)
+			err := client.ReplaceDeviceTags(ctx, "Bearer token", "1", tags)
+			switch {
+			case tc.Error == nil:
+				assert.NoError(t, err)
+			case errors.Is(tc.Error, ErrDeviceNotFound),
+				errors.Is(tc.Error, ErrInvalidTags):
+				assert.ErrorIs(t, err, tc.Error)
+			default:
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error.Error())
+				}
+			}
+
+			req := <-reqChan
+			assert.Equal(t, http.MethodPut, req.Method)
+			assert.Equal(t, "/api/management/v1/inventory/devices/1/tags", req.URL.Path)
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+			if tc.Scope != nil {
+				assert.Equal(t, "dev,qa", req.Header.Get(rbac.ScopeHeader))
+			} else {
+				assert.Empty(t, req.Header.Get(rbac.ScopeHeader))
+			}
+			var reqTags []Tag
+			_ = json.NewDecoder(req.Body).Decode(&reqTags)
+			assert.Equal(t, tags, reqTags)
+		})
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	client := NewClient(srv.URL,
+		WithConnectionPool(64, time.Minute),
+		WithTimeout(50*time.Millisecond),
+	)
+	err := client.CheckHealth(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -37,6 +37,13 @@ type DeviceAttribute struct {
 	Scope       string      `json:"scope" bson:",omitempty"`
 }
 
+// Tag is a tag of a device
+type Tag struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
 // Device is a wrapper for inventory devices
 type Device struct {
 	// ID is the system-generated device ID
@@ -56,6 +63,10 @@ type Device struct {
 
 	// Revision is the device object revision
 	Revision uint `json:"-" bson:"revision,omitempty"`
+
+	// Highlights contains the attributes matching the free-text query,
+	// with the matching fragments of their values
+	Highlights DeviceAttributes `json:"highlights,omitempty" bson:"-"`
 }
 
 func (d *DeviceAttributes) UnmarshalJSON(b []byte) error {
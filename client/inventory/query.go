@@ -16,7 +16,24 @@ package inventory
 // GetDevsReq is a stripped down inventory search query
 // default max 20 devices
 type GetDevsReq struct {
-	DeviceIDs []string `json:"device_ids"`
-	Page      uint     `json:"page"`
-	PerPage   uint     `json:"per_page"`
+	DeviceIDs []string          `json:"device_ids,omitempty"`
+	Filters   []FilterPredicate `json:"filters,omitempty"`
+	Sort      []SortCriteria    `json:"sort,omitempty"`
+	Page      uint              `json:"page"`
+	PerPage   uint              `json:"per_page"`
+}
+
+// FilterPredicate is a filter of the inventory search query
+type FilterPredicate struct {
+	Scope     string      `json:"scope"`
+	Attribute string      `json:"attribute"`
+	Type      string      `json:"type"`
+	Value     interface{} `json:"value"`
+}
+
+// SortCriteria is a sorting criteria of the inventory search query
+type SortCriteria struct {
+	Scope     string `json:"scope"`
+	Attribute string `json:"attribute"`
+	Order     string `json:"order"`
 }
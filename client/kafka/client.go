@@ -0,0 +1,319 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/mendersoftware/reporting/client/nats"
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	defaultClientID = "reporting"
+	// Set the default number of deliveries of a message
+	maxRedeliverCount = 3
+	// Set the time the brokers wait for the replicas to acknowledge the
+	// produced records
+	produceTimeout = 10 * time.Second
+	// Set the number of attempts to produce a record, the first attempts
+	// failing while the partition leadership moves
+	produceAttempts = 3
+	// Set the interval between the retries of the failed fetches
+	retryInterval = time.Second
+	// Set the timeout of the connectivity checks
+	pingTimeout = 5 * time.Second
+	// Set the timeout of the connection to the cluster
+	dialTimeout = 10 * time.Second
+
+	// headerDeliveries is the header counting the deliveries of the
+	// records produced again after a failure
+	headerDeliveries = "Reporting-Deliveries"
+)
+
+var (
+	ErrNoBrokers = errors.New("kafka: no brokers")
+)
+
+type ClientOption func(*client)
+
+// WithClientID sets the client ID reported to the brokers
+func WithClientID(clientID string) ClientOption {
+	return func(c *client) {
+		c.clientID = clientID
+	}
+}
+
+func WithMaxDeliver(maxDeliver int) ClientOption {
+	return func(c *client) {
+		c.maxDeliver = maxDeliver
+	}
+}
+
+func WithDeadLetterFunc(f nats.DeadLetterFunc) ClientOption {
+	return func(c *client) {
+		c.deadLetterFunc = f
+	}
+}
+
+type client struct {
+	producer       *kgo.Client
+	brokers        []string
+	clientID       string
+	maxDeliver     int
+	deadLetterFunc nats.DeadLetterFunc
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewClient returns a client consuming and producing the messages from and
+// to the Kafka topics named after the NATS subjects, as an alternative to
+// the NATS client: the durable subscriptions are consumer groups, named
+// after the durable names, which commit the offsets of the acknowledged
+// messages; as Kafka has no negative acknowledgements, the failed messages
+// are produced again to their topic until delivered the maximum number of
+// times, and moved to the dead letters afterwards
+func NewClient(brokers []string, opts ...ClientOption) (nats.Client, error) {
+	if len(brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+	c := &client{
+		brokers:    brokers,
+		clientID:   defaultClientID,
+		maxDeliver: maxRedeliverCount,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	producer, err := c.newKafkaClient(
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.ProduceRequestTimeout(produceTimeout),
+		kgo.RecordRetries(produceAttempts),
+		kgo.RecordPartitioner(kgo.RoundRobinPartitioner()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	if err := producer.Ping(ctx); err != nil {
+		producer.Close()
+		return nil, err
+	}
+	c.producer = producer
+	return c, nil
+}
+
+// newKafkaClient returns a client of the cluster; the consumers use their
+// own clients, so that the fetches don't delay the other requests
+func (c *client) newKafkaClient(opts ...kgo.Opt) (*kgo.Client, error) {
+	return kgo.NewClient(append([]kgo.Opt{
+		kgo.SeedBrokers(c.brokers...),
+		kgo.ClientID(c.clientID),
+	}, opts...)...)
+}
+
+// AcquireLock is not supported: Kafka has no key-value store
+func (c *client) AcquireLock(_, _ string, _ time.Duration) (bool, error) {
+	return false, nats.ErrLocksNotSupported
+}
+
+// ReleaseLock is not supported: Kafka has no key-value store
+func (c *client) ReleaseLock(_, _ string) error {
+	return nats.ErrLocksNotSupported
+}
+
+// Close stops the subscriptions and closes the connections to the brokers
+func (c *client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.wg.Wait()
+		c.producer.Close()
+	})
+}
+
+// IsConnected returns true if a broker answers the requests
+func (c *client) IsConnected() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return c.producer.Ping(ctx) == nil
+}
+
+// Migrate checks the topic exists: the consumer groups are created by
+// the brokers when the first consumer joins them
+func (c *client) Migrate(ctx context.Context, topic, _ string, _ bool) error {
+	req := kmsg.NewPtrMetadataRequest()
+	reqTopic := kmsg.NewMetadataRequestTopic()
+	reqTopic.Topic = kmsg.StringPtr(topic)
+	req.Topics = append(req.Topics, reqTopic)
+	req.AllowAutoTopicCreation = false
+	res, err := req.RequestWith(ctx, c.producer)
+	if err != nil {
+		return err
+	}
+	for _, resTopic := range res.Topics {
+		if err := kerr.ErrorForCode(resTopic.ErrorCode); err != nil {
+			return errors.Wrapf(err, "kafka: failed to check topic %q", topic)
+		}
+	}
+	return nil
+}
+
+// JetStreamSubscribe consumes the messages of the topic as a member of the
+// group; the jobs are sent to q, which is closed when the context is done
+// or the client is closed
+func (c *client) JetStreamSubscribe(
+	ctx context.Context,
+	topic, group string,
+	q chan model.Job,
+) error {
+	if q == nil {
+		return errors.New("kafka: nil subscription channel")
+	}
+	if err := c.Migrate(ctx, topic, group, false); err != nil {
+		return err
+	}
+	consumer := &groupConsumer{
+		client:   c,
+		ctx:      ctx,
+		topic:    topic,
+		group:    group,
+		q:        q,
+		trackers: make(map[int32]*offsetTracker),
+	}
+	var err error
+	consumer.cl, err = c.newKafkaClient(
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(topic),
+		kgo.Balancers(kgo.RangeBalancer()),
+		kgo.SessionTimeout(sessionTimeout),
+		kgo.RebalanceTimeout(rebalanceTimeout),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.DisableAutoCommit(),
+		kgo.OnPartitionsAssigned(consumer.assigned),
+		kgo.OnPartitionsRevoked(consumer.revoked),
+		kgo.OnPartitionsLost(consumer.lost),
+	)
+	if err != nil {
+		return err
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		consumer.run(ctx)
+	}()
+	return nil
+}
+
+// JetStreamPublish produces the message to the topic
+func (c *client) JetStreamPublish(topic string, data []byte) error {
+	return c.produce(context.Background(), &kgo.Record{Topic: topic, Value: data})
+}
+
+// Publish produces the message to the topic; unlike NATS, Kafka persists
+// all the messages
+func (c *client) Publish(topic string, data []byte) error {
+	return c.produce(context.Background(), &kgo.Record{Topic: topic, Value: data})
+}
+
+// Subscribe calls handler with the messages produced to the topic from
+// now on, until unsubscribed; every subscriber receives all the messages
+func (c *client) Subscribe(topic string, handler func(data []byte)) (nats.UnsubscribeFunc, error) {
+	cl, err := c.newKafkaClient(
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer cl.Close()
+		c.broadcast(ctx, cl, topic, handler)
+	}()
+	return func() error {
+		cancel()
+		return nil
+	}, nil
+}
+
+// broadcast calls the handler with the records fetched by the client, until
+// the context is done or the client is closed
+func (c *client) broadcast(
+	ctx context.Context,
+	cl *kgo.Client,
+	topic string,
+	handler func(data []byte),
+) {
+	l := log.FromContext(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		fetches := cl.PollFetches(ctx)
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			return
+		}
+		failed := false
+		fetches.EachError(func(_ string, partition int32, err error) {
+			l.Errorf("kafka: failed to fetch partition %d of topic %q: %s",
+				partition, topic, err)
+			failed = true
+		})
+		fetches.EachRecord(func(record *kgo.Record) {
+			handler(record.Value)
+		})
+		if failed {
+			c.sleep(ctx, retryInterval)
+		}
+	}
+}
+
+// produce produces the record to a partition of the topic, chosen round
+// robin, and waits for all the in-sync replicas to acknowledge it
+func (c *client) produce(ctx context.Context, record *kgo.Record) error {
+	return c.producer.ProduceSync(ctx, record).FirstErr()
+}
+
+// sleep waits for the duration, unless the context is done or the client
+// is closed before
+func (c *client) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	case <-c.done:
+	}
+}
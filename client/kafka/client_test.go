@@ -0,0 +1,44 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package kafka
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientNoBrokers(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClient(nil)
+	assert.Equal(t, ErrNoBrokers, err)
+}
+
+func TestNewClientUnreachable(t *testing.T) {
+	t.Parallel()
+
+	// nothing listens on the address of the closed listener
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = NewClient([]string{addr})
+	assert.Error(t, err)
+}
@@ -0,0 +1,393 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mendersoftware/go-lib-micro/log"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/tracing"
+)
+
+const (
+	// Set the time the coordinator waits for the heartbeats before
+	// removing a member from the group
+	sessionTimeout = 30 * time.Second
+	// Set the time the coordinator waits for the members to join the
+	// group during a rebalance
+	rebalanceTimeout = 60 * time.Second
+	// Set the interval of the commits of the acknowledged offsets
+	commitInterval = 3 * time.Second
+)
+
+// groupConsumer consumes the partitions of a topic assigned to it as a
+// member of the consumer group; the client joins the group, and joins it
+// again after every rebalance, while the consumer tracks the acknowledged
+// offsets of the assigned partitions to commit them
+type groupConsumer struct {
+	client *client
+	cl     *kgo.Client
+	topic  string
+	group  string
+	q      chan model.Job
+
+	// ctx is the context of the subscription, which outlives the
+	// assignments of the partitions
+	ctx context.Context
+
+	mu       sync.Mutex
+	trackers map[int32]*offsetTracker
+}
+
+// run consumes the topic until the context is done or the client is closed
+func (gc *groupConsumer) run(ctx context.Context) {
+	defer close(gc.q)
+	// closing the client leaves the group, committing the offsets of the
+	// revoked partitions
+	defer gc.cl.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the offsets are committed while the queue is full, for the
+	// acknowledgements of the jobs to be recorded
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(commitInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := gc.commit(ctx, gc.partitions()); err != nil {
+					log.FromContext(ctx).Errorf(
+						"kafka: failed to commit the offsets of topic %q: %s", gc.topic, err)
+				}
+			case <-ctx.Done():
+				return
+			case <-gc.client.done:
+				cancel()
+				return
+			}
+		}
+	}()
+	gc.fetchLoop(ctx)
+	cancel()
+	wg.Wait()
+}
+
+func (gc *groupConsumer) fetchLoop(ctx context.Context) {
+	l := log.FromContext(ctx)
+	for {
+		fetches := gc.cl.PollFetches(ctx)
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			return
+		}
+		failed := false
+		fetches.EachError(func(_ string, partition int32, err error) {
+			l.Errorf("kafka: failed to fetch partition %d of topic %q: %s",
+				partition, gc.topic, err)
+			failed = true
+		})
+		for iter := fetches.RecordIter(); !iter.Done(); {
+			if !gc.deliver(ctx, iter.Next()) {
+				return
+			}
+		}
+		if failed {
+			gc.client.sleep(ctx, retryInterval)
+		}
+	}
+}
+
+// deliver sends the job of the record to the queue, returning false if
+// the consumption stopped first
+func (gc *groupConsumer) deliver(ctx context.Context, record *kgo.Record) bool {
+	l := log.FromContext(ctx)
+	tracker := gc.tracker(record.Partition)
+	if tracker == nil {
+		// revoked since fetched: the record is delivered to the new
+		// owner of the partition
+		return true
+	}
+	tracker.deliver(record.Offset)
+	msg := &message{
+		ctx:     gc.ctx,
+		client:  gc.client,
+		topic:   gc.topic,
+		tracker: tracker,
+		record:  record,
+	}
+	var job model.Job
+	if err := json.Unmarshal(record.Value, &job); err != nil {
+		l.Errorf("moving malformed message to the dead letters: %s", err)
+		if err := msg.deadLetter(err); err != nil {
+			l.Error(err)
+		}
+		return true
+	}
+	job.TraceContext = traceRecord(ctx, gc.topic, record)
+	job.Acknowledger = msg
+	select {
+	case gc.q <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (gc *groupConsumer) tracker(partition int32) *offsetTracker {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.trackers[partition]
+}
+
+func (gc *groupConsumer) partitions() []int32 {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	partitions := make([]int32, 0, len(gc.trackers))
+	for partition := range gc.trackers {
+		partitions = append(partitions, partition)
+	}
+	return partitions
+}
+
+// assigned starts tracking the offsets of the partitions assigned to the
+// consumer
+func (gc *groupConsumer) assigned(_ context.Context, _ *kgo.Client, assigned map[string][]int32) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	for _, partition := range assigned[gc.topic] {
+		gc.trackers[partition] = newOffsetTracker()
+	}
+}
+
+// revoked commits the acknowledged offsets of the revoked partitions, for
+// their next owner to resume from them, and stops tracking them
+func (gc *groupConsumer) revoked(ctx context.Context, cl *kgo.Client, revoked map[string][]int32) {
+	if err := gc.commit(ctx, revoked[gc.topic]); err != nil {
+		log.FromContext(ctx).Errorf(
+			"kafka: failed to commit the offsets of topic %q: %s", gc.topic, err)
+	}
+	gc.lost(ctx, cl, revoked)
+}
+
+// lost stops tracking the offsets of the partitions lost by the consumer:
+// they can't be committed anymore
+func (gc *groupConsumer) lost(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	for _, partition := range lost[gc.topic] {
+		delete(gc.trackers, partition)
+	}
+}
+
+// commit commits the offsets of the partitions, up to the first message
+// not acknowledged yet
+func (gc *groupConsumer) commit(ctx context.Context, partitions []int32) error {
+	offsets := make(map[int32]kgo.EpochOffset, len(partitions))
+	gc.mu.Lock()
+	for _, partition := range partitions {
+		tracker, ok := gc.trackers[partition]
+		if !ok {
+			continue
+		}
+		if offset := tracker.committable(); offset >= 0 {
+			offsets[partition] = kgo.EpochOffset{Epoch: -1, Offset: offset}
+		}
+	}
+	gc.mu.Unlock()
+	if len(offsets) == 0 {
+		return nil
+	}
+	var err error
+	gc.cl.CommitOffsetsSync(ctx, map[string]map[int32]kgo.EpochOffset{gc.topic: offsets},
+		func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest,
+			res *kmsg.OffsetCommitResponse, commitErr error) {
+			if commitErr != nil {
+				err = commitErr
+				return
+			}
+			for _, topic := range res.Topics {
+				for _, partition := range topic.Partitions {
+					if perr := kerr.ErrorForCode(partition.ErrorCode); perr != nil {
+						err = perr
+					}
+				}
+			}
+		})
+	return err
+}
+
+// offsetTracker tracks the offsets of the messages delivered from a
+// partition, to commit the offset of the first message not acknowledged
+// yet: the messages are acknowledged in any order
+type offsetTracker struct {
+	mu        sync.Mutex
+	next      int64
+	delivered []int64
+	acked     map[int64]struct{}
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		next:  -1,
+		acked: make(map[int64]struct{}),
+	}
+}
+
+func (t *offsetTracker) deliver(offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.delivered = append(t.delivered, offset)
+	t.next = offset + 1
+}
+
+func (t *offsetTracker) ack(offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.acked[offset] = struct{}{}
+}
+
+// committable returns the offset to commit: the offset of the first
+// message not acknowledged yet or, if all of them are, the next offset;
+// it is negative if no message was delivered yet
+func (t *offsetTracker) committable() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for len(t.delivered) > 0 {
+		offset := t.delivered[0]
+		if _, ok := t.acked[offset]; !ok {
+			return offset
+		}
+		delete(t.acked, offset)
+		t.delivered = t.delivered[1:]
+	}
+	return t.next
+}
+
+// message acknowledges the jobs to the consumer they were received from
+type message struct {
+	ctx     context.Context
+	client  *client
+	topic   string
+	tracker *offsetTracker
+	record  *kgo.Record
+}
+
+func (m *message) Ack() error {
+	m.tracker.ack(m.record.Offset)
+	return nil
+}
+
+// Nak produces the message again to the topic, to be redelivered, unless
+// it was already delivered the maximum number of times: it is moved to
+// the dead letters instead
+func (m *message) Nak(reason error) error {
+	deliveries := m.deliveries()
+	if deliveries >= m.client.maxDeliver {
+		return m.deadLetter(reason)
+	}
+	record := &kgo.Record{
+		Topic: m.topic,
+		Key:   m.record.Key,
+		Value: m.record.Value,
+	}
+	for _, header := range m.record.Headers {
+		if header.Key != headerDeliveries {
+			record.Headers = append(record.Headers, header)
+		}
+	}
+	record.Headers = append(record.Headers, kgo.RecordHeader{
+		Key:   headerDeliveries,
+		Value: []byte(strconv.Itoa(deliveries + 1)),
+	})
+	if err := m.client.produce(m.ctx, record); err != nil {
+		return err
+	}
+	m.tracker.ack(m.record.Offset)
+	return nil
+}
+
+// deliveries returns the number of times the message was delivered
+func (m *message) deliveries() int {
+	for _, header := range m.record.Headers {
+		if header.Key == headerDeliveries {
+			if n, err := strconv.Atoi(string(header.Value)); err == nil {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// deadLetter stores the message, along with the reason it could not be
+// processed, and acknowledges it
+func (m *message) deadLetter(reason error) error {
+	if m.client.deadLetterFunc != nil {
+		letter := &model.DeadLetter{
+			ID:         uuid.NewString(),
+			Subject:    m.topic,
+			Data:       string(m.record.Value),
+			Reason:     reason.Error(),
+			Deliveries: m.deliveries(),
+			CreatedTs:  time.Now().UTC().Truncate(time.Millisecond),
+		}
+		var job model.Job
+		if err := json.Unmarshal(m.record.Value, &job); err == nil {
+			letter.TenantID = job.TenantID
+		}
+		if err := m.client.deadLetterFunc(m.ctx, letter); err != nil {
+			return err
+		}
+	}
+	m.tracker.ack(m.record.Offset)
+	return nil
+}
+
+// traceRecord records the reception of the record, as a child of the
+// trace context found in the record headers, if any, and returns the
+// trace context of the reception span
+func traceRecord(ctx context.Context, topic string, record *kgo.Record) map[string]string {
+	carrier := propagation.MapCarrier{}
+	for _, header := range record.Headers {
+		carrier[header.Key] = string(header.Value)
+	}
+	ctx = tracing.Extract(ctx, carrier)
+	ctx, span := tracing.Tracer().Start(ctx, "kafka receive "+topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", topic),
+		),
+	)
+	defer span.End()
+	traceContext := propagation.MapCarrier{}
+	tracing.Inject(ctx, traceContext)
+	return traceContext
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := newOffsetTracker()
+	assert.Equal(t, int64(-1), tracker.committable())
+
+	tracker.deliver(10)
+	tracker.deliver(11)
+	tracker.deliver(12)
+	assert.Equal(t, int64(10), tracker.committable())
+
+	// acknowledged out of order
+	tracker.ack(11)
+	assert.Equal(t, int64(10), tracker.committable())
+	tracker.ack(10)
+	assert.Equal(t, int64(12), tracker.committable())
+	tracker.ack(12)
+	assert.Equal(t, int64(13), tracker.committable())
+}
+
+func TestMessageNakDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	var letters []*model.DeadLetter
+	c := &client{
+		maxDeliver: 3,
+		deadLetterFunc: func(_ context.Context, letter *model.DeadLetter) error {
+			letters = append(letters, letter)
+			return nil
+		},
+	}
+	tracker := newOffsetTracker()
+	tracker.deliver(7)
+	msg := &message{
+		ctx:     context.Background(),
+		client:  c,
+		topic:   "WORKFLOWS.reporting",
+		tracker: tracker,
+		record: &kgo.Record{
+			Value:  []byte(`{"tenant_id":"tenant"}`),
+			Offset: 7,
+			Headers: []kgo.RecordHeader{{
+				Key:   headerDeliveries,
+				Value: []byte("3"),
+			}},
+		},
+	}
+
+	// delivered the maximum number of times: not produced again
+	err := msg.Nak(errors.New("failed"))
+	assert.NoError(t, err)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, "tenant", letters[0].TenantID)
+		assert.Equal(t, "WORKFLOWS.reporting", letters[0].Subject)
+		assert.Equal(t, "failed", letters[0].Reason)
+		assert.Equal(t, 3, letters[0].Deliveries)
+	}
+	assert.Equal(t, int64(8), tracker.committable())
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// StatusError is the status label of the requests which failed
+	// without a response from the service
+	StatusError = "error"
+
+	endpointUnknown = "unknown"
+)
+
+var (
+	metricRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "reporting",
+		Subsystem: "client",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests to the downstream services.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "endpoint", "method", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(metricRequestDuration)
+}
+
+type endpointKey struct{}
+
+// WithEndpoint returns a copy of ctx carrying the endpoint label of the
+// requests created with it; use the URL template rather than the actual
+// URL, so that the label cardinality stays bounded
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+func endpointFromContext(ctx context.Context) string {
+	if endpoint, ok := ctx.Value(endpointKey{}).(string); ok {
+		return endpoint
+	}
+	return endpointUnknown
+}
+
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// NewTransport wraps the base round tripper, observing the duration of each
+// request in the request duration histogram; if base is nil,
+// http.DefaultTransport is used
+func NewTransport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		service: service,
+		base:    base,
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	rsp, err := t.base.RoundTrip(req)
+	status := StatusError
+	if err == nil {
+		status = strconv.Itoa(rsp.StatusCode)
+	}
+	metricRequestDuration.WithLabelValues(
+		t.service,
+		endpointFromContext(req.Context()),
+		req.Method,
+		status,
+	).Observe(time.Since(start).Seconds())
+	return rsp, err
+}
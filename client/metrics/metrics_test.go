@@ -0,0 +1,58 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	var m dto.Metric
+	err := metricRequestDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(&m)
+	assert.NoError(t, err)
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	url := srv.URL
+
+	client := &http.Client{Transport: NewTransport("test", nil)}
+
+	ctx := WithEndpoint(context.Background(), "/api/:id")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/api/1", nil)
+	rsp, err := client.Do(req)
+	if assert.NoError(t, err) {
+		rsp.Body.Close()
+	}
+	assert.Equal(t, uint64(1), sampleCount(t, "test", "/api/:id", http.MethodGet, "418"))
+
+	// requests failing without a response are labelled as errors
+	srv.Close()
+	req, _ = http.NewRequest(http.MethodPost, url+"/api/1", nil)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1),
+		sampleCount(t, "test", endpointUnknown, http.MethodPost, StatusError))
+}
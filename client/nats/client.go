@@ -18,12 +18,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mendersoftware/go-lib-micro/log"
 	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/tracing"
 )
 
 const (
@@ -31,10 +37,10 @@ const (
 	reconnectBufSize = 10 * 1024 * 1024
 	// Set reconnect interval to 1 second
 	reconnectWaitTimeSeconds = 1 * time.Second
-	// Set the number of redeliveries for a message
+	// Set the default number of deliveries of a message
 	maxRedeliverCount = 3
-	// Set the number of inflight messages; setting it to 1 we explicitly
-	// tell the NATS server that we want to process jobs serially, one by one
+	// Set the default number of inflight messages, delivered and not
+	// acknowledged yet
 	maxAckPending = 10
 	// Set the ACK wait
 	ackWaitSeconds = 30 * time.Second
@@ -46,6 +52,9 @@ var (
 	ErrInconsistentConsumerConfig = errors.New(
 		"consumer configuration is inconsistent: requires migration",
 	)
+	// ErrLocksNotSupported is returned by the clients of the brokers
+	// which have no key-value store to hold the locks in
+	ErrLocksNotSupported = errors.New("the locks are not supported by the message broker")
 )
 
 type UnsubscribeFunc func() error
@@ -54,15 +63,23 @@ type UnsubscribeFunc func() error
 //
 //go:generate ../../x/mockgen.sh
 type Client interface {
+	// AcquireLock acquires, or renews, the lock with the given name on
+	// behalf of the holder, returning false if held by another holder
+	AcquireLock(name, holder string, ttl time.Duration) (bool, error)
 	Close()
 	IsConnected() bool
 	JetStreamSubscribe(ctx context.Context, sub, dur string, q chan model.Job) error
 	JetStreamPublish(string, []byte) error
+	Publish(subj string, data []byte) error
+	ReleaseLock(name, holder string) error
+	Subscribe(subj string, handler func(data []byte)) (UnsubscribeFunc, error)
 	Migrate(ctx context.Context, sub, dur string, recreate bool) error
 }
 
-// NewClient returns a new nats client with default options
-func NewClient(url string) (Client, error) {
+type ClientOption func(*client)
+
+// NewClient returns a new nats client
+func NewClient(url string, opts ...ClientOption) (Client, error) {
 	natsClient, err := nats.Connect(url,
 		nats.ReconnectBufSize(reconnectBufSize),
 		nats.ReconnectWait(reconnectWaitTimeSeconds),
@@ -74,15 +91,53 @@ func NewClient(url string) (Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &client{
-		nats: natsClient,
-		js:   js,
-	}, nil
+	c := &client{
+		nats:          natsClient,
+		js:            js,
+		maxDeliver:    maxRedeliverCount,
+		maxAckPending: maxAckPending,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// WithMaxDeliver sets the maximum number of deliveries of a message
+func WithMaxDeliver(maxDeliver int) ClientOption {
+	return func(c *client) {
+		c.maxDeliver = maxDeliver
+	}
+}
+
+// WithMaxAckPending sets the maximum number of messages delivered and not
+// acknowledged yet
+func WithMaxAckPending(maxAckPending int) ClientOption {
+	return func(c *client) {
+		c.maxAckPending = maxAckPending
+	}
+}
+
+// DeadLetterFunc stores a message which cannot be processed
+type DeadLetterFunc func(ctx context.Context, letter *model.DeadLetter) error
+
+// WithDeadLetterFunc sets the function storing the messages which cannot
+// be processed; without it, they are discarded
+func WithDeadLetterFunc(f DeadLetterFunc) ClientOption {
+	return func(c *client) {
+		c.deadLetterFunc = f
+	}
 }
 
 type client struct {
-	nats *nats.Conn
-	js   nats.JetStreamContext
+	nats           *nats.Conn
+	js             nats.JetStreamContext
+	maxDeliver     int
+	maxAckPending  int
+	deadLetterFunc DeadLetterFunc
+
+	mutex   sync.Mutex
+	locksKV nats.KeyValue
 }
 
 // Close closes the connection to nats
@@ -103,8 +158,8 @@ func (c *client) Migrate(ctx context.Context, sub, dur string, recreate bool) er
 		FilterSubject: sub,
 		AckPolicy:     nats.AckExplicitPolicy,
 		AckWait:       ackWaitSeconds,
-		MaxAckPending: maxAckPending,
-		MaxDeliver:    maxRedeliverCount,
+		MaxAckPending: c.maxAckPending,
+		MaxDeliver:    c.maxDeliver,
 		Replicas:      replicas,
 	}
 	stream, err := c.js.StreamNameBySubject(sub)
@@ -131,6 +186,10 @@ func (c *client) Migrate(ctx context.Context, sub, dur string, recreate bool) er
 		}
 		l.Info("recreating consumer configuration")
 		_, err = c.js.AddConsumer(stream, cfg)
+	} else if info.Config.MaxDeliver != cfg.MaxDeliver ||
+		info.Config.MaxAckPending != cfg.MaxAckPending {
+		log.FromContext(ctx).Info("updating consumer configuration")
+		_, err = c.js.UpdateConsumer(stream, cfg)
 	}
 	return err
 }
@@ -174,24 +233,29 @@ func (c *client) JetStreamSubscribe(
 					continue
 				}
 				close(q)
+				if ctx.Err() != nil {
+					// unsubscribed
+					return nil
+				}
 				return err
 			}
 			for _, msg := range msgs {
 				var job model.Job
-				err = msg.Ack(opt)
-				if err != nil {
-					close(q)
-					return err
-				}
-				err = json.Unmarshal(msg.Data, &job)
-				if err != nil {
-					close(q)
-					return err
+				if err := json.Unmarshal(msg.Data, &job); err != nil {
+					l.Errorf("moving malformed message to the dead letters: %s", err)
+					if err := c.deadLetter(ctx, msg, err); err != nil {
+						l.Error(err)
+					}
+					continue
 				}
+				job.TraceContext = traceMessage(ctx, msg)
+				job.Acknowledger = &jobAcknowledger{ctx: ctx, client: c, msg: msg}
 				select {
 				case q <- job:
 
 				case <-done:
+					// redeliver the message right away
+					_ = msg.Nak()
 					close(q)
 					return nil
 				}
@@ -202,8 +266,114 @@ func (c *client) JetStreamSubscribe(
 	return nil
 }
 
+// jobAcknowledger acknowledges the jobs to the JetStream consumer they
+// were received from
+type jobAcknowledger struct {
+	ctx    context.Context
+	client *client
+	msg    *nats.Msg
+}
+
+func (a *jobAcknowledger) Ack() error {
+	return a.msg.Ack()
+}
+
+// Nak requests the redelivery of the message, unless it was already
+// delivered the maximum number of times: the server would not deliver it
+// again, it is moved to the dead-letter subject instead
+func (a *jobAcknowledger) Nak(reason error) error {
+	meta, err := a.msg.Metadata()
+	if err == nil && meta.NumDelivered >= uint64(a.client.maxDeliver) {
+		return a.client.deadLetter(a.ctx, a.msg, reason)
+	}
+	return a.msg.Nak()
+}
+
+// deadLetter stores the message, along with the reason it could not be
+// processed, and terminates its delivery
+func (c *client) deadLetter(ctx context.Context, msg *nats.Msg, reason error) error {
+	if c.deadLetterFunc != nil {
+		letter := &model.DeadLetter{
+			ID:        uuid.NewString(),
+			Subject:   msg.Subject,
+			Data:      string(msg.Data),
+			Reason:    reason.Error(),
+			CreatedTs: time.Now().UTC().Truncate(time.Millisecond),
+		}
+		var job model.Job
+		if err := json.Unmarshal(msg.Data, &job); err == nil {
+			letter.TenantID = job.TenantID
+		}
+		if meta, err := msg.Metadata(); err == nil {
+			letter.Deliveries = int(meta.NumDelivered)
+		}
+		if err := c.deadLetterFunc(ctx, letter); err != nil {
+			return err
+		}
+	}
+	return msg.Term()
+}
+
+// traceMessage records the reception of the message, as a child of the
+// trace context found in the message headers, if any, and returns the
+// trace context of the reception span
+func traceMessage(ctx context.Context, msg *nats.Msg) map[string]string {
+	ctx = tracing.Extract(ctx, headerCarrier(msg.Header))
+	ctx, span := tracing.Tracer().Start(ctx, "nats receive "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination", msg.Subject),
+		),
+	)
+	defer span.End()
+	carrier := propagation.MapCarrier{}
+	tracing.Inject(ctx, carrier)
+	return carrier
+}
+
+// headerCarrier adapts the NATS message headers to the propagators;
+// unlike the HTTP headers, the NATS headers are case sensitive
+type headerCarrier nats.Header
+
+func (h headerCarrier) Get(key string) string {
+	if values := h[key]; len(values) > 0 {
+		return values[0]
+	}
+	return nats.Header(h).Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h[key] = []string{value}
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 // JetStreamPublish publishes a message to the given subject
 func (c *client) JetStreamPublish(subj string, data []byte) error {
 	_, err := c.js.Publish(subj, data)
 	return err
 }
+
+// Publish publishes a message to the given subject, without persisting it
+func (c *client) Publish(subj string, data []byte) error {
+	return c.nats.Publish(subj, data)
+}
+
+// Subscribe calls handler with the messages published to the given subject
+// until unsubscribed
+func (c *client) Subscribe(subj string, handler func(data []byte)) (UnsubscribeFunc, error) {
+	sub, err := c.nats.Subscribe(subj, func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub.Unsubscribe, nil
+}
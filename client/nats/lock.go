@@ -0,0 +1,99 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package nats
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// locksBucket is the key-value bucket of the locks; its TTL, the TTL of
+// the locks, is set by the first lock acquired
+const locksBucket = "reporting_locks"
+
+// AcquireLock acquires, or renews if already held, the lock with the given
+// name on behalf of the holder; the lock expires if not renewed within the
+// TTL, so that another holder can take it over. It returns false if the
+// lock is held by another holder.
+func (c *client) AcquireLock(name, holder string, ttl time.Duration) (bool, error) {
+	kv, err := c.locks(ttl)
+	if err != nil {
+		return false, err
+	}
+	entry, err := kv.Get(name)
+	if err == nats.ErrKeyNotFound {
+		_, err = kv.Create(name, []byte(holder))
+		if errors.Is(err, nats.ErrKeyExists) {
+			return false, nil
+		}
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	} else if string(entry.Value()) != holder {
+		return false, nil
+	}
+	_, err = kv.Update(name, []byte(holder), entry.Revision())
+	if errors.Is(err, nats.ErrKeyExists) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseLock releases the lock with the given name, if held by the holder
+func (c *client) ReleaseLock(name, holder string) error {
+	kv, err := c.locks(0)
+	if err != nil {
+		return err
+	}
+	entry, err := kv.Get(name)
+	if err == nats.ErrKeyNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	} else if string(entry.Value()) != holder {
+		return nil
+	}
+	err = kv.Delete(name, nats.LastRevision(entry.Revision()))
+	if errors.Is(err, nats.ErrKeyExists) {
+		// renewed or taken over in the meantime
+		return nil
+	}
+	return err
+}
+
+func (c *client) locks(ttl time.Duration) (nats.KeyValue, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.locksKV != nil {
+		return c.locksKV, nil
+	}
+	kv, err := c.js.KeyValue(locksBucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = c.js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      locksBucket,
+			Description: "reporting locks",
+			History:     1,
+			TTL:         ttl,
+			Replicas:    replicas,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.locksKV = kv
+	return kv, nil
+}
@@ -18,9 +18,12 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	model "github.com/mendersoftware/reporting/model"
 	mock "github.com/stretchr/testify/mock"
+
+	nats "github.com/mendersoftware/reporting/client/nats"
 )
 
 // Client is an autogenerated mock type for the Client type
@@ -28,6 +31,27 @@ type Client struct {
 	mock.Mock
 }
 
+// AcquireLock provides a mock function with given fields: name, holder, ttl
+func (_m *Client) AcquireLock(name string, holder string, ttl time.Duration) (bool, error) {
+	ret := _m.Called(name, holder, ttl)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) bool); ok {
+		r0 = rf(name, holder, ttl)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, time.Duration) error); ok {
+		r1 = rf(name, holder, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with given fields:
 func (_m *Client) Close() {
 	_m.Called()
@@ -88,3 +112,54 @@ func (_m *Client) Migrate(ctx context.Context, sub string, dur string, recreate
 
 	return r0
 }
+
+// Publish provides a mock function with given fields: subj, data
+func (_m *Client) Publish(subj string, data []byte) error {
+	ret := _m.Called(subj, data)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(subj, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseLock provides a mock function with given fields: name, holder
+func (_m *Client) ReleaseLock(name string, holder string) error {
+	ret := _m.Called(name, holder)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, holder)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Subscribe provides a mock function with given fields: subj, handler
+func (_m *Client) Subscribe(subj string, handler func([]byte)) (nats.UnsubscribeFunc, error) {
+	ret := _m.Called(subj, handler)
+
+	var r0 nats.UnsubscribeFunc
+	if rf, ok := ret.Get(0).(func(string, func([]byte)) nats.UnsubscribeFunc); ok {
+		r0 = rf(subj, handler)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(nats.UnsubscribeFunc)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, func([]byte)) error); ok {
+		r1 = rf(subj, handler)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/sigv4"
+	"github.com/mendersoftware/reporting/utils"
+)
+
+const (
+	serviceName    = "s3"
+	defaultTimeout = 5 * time.Minute
+)
+
+//go:generate ../../x/mockgen.sh
+type Client interface {
+	// PutObject uploads the content of body to the bucket, as key
+	PutObject(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+}
+
+type client struct {
+	client   *http.Client
+	endpoint string
+	bucket   string
+	signer   *sigv4.Signer
+}
+
+// NewClient returns a client for an S3-compatible storage; objects are
+// addressed path-style (endpoint/bucket/key), which all the S3-compatible
+// implementations support
+func NewClient(endpoint, region, bucket string, credentials sigv4.Credentials) Client {
+	return &client{
+		client:   &http.Client{},
+		endpoint: endpoint,
+		bucket:   bucket,
+		signer:   sigv4.NewSigner(credentials, region, serviceName),
+	}
+}
+
+func (c *client) PutObject(
+	ctx context.Context,
+	key string,
+	body io.ReadSeeker,
+	contentType string,
+) error {
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the object")
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to read the object")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	objectPath := url.PathEscape(c.bucket) + "/" + escapeKey(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
+		utils.JoinURL(c.endpoint, objectPath), io.NopCloser(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	if err := c.signer.Sign(req, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return errors.Wrap(err, "failed to sign the request")
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to upload the object")
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode/100 != 2 {
+		return errors.Errorf("failed to upload the object, status %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
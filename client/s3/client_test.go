@@ -0,0 +1,81 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/client/sigv4"
+)
+
+func TestPutObject(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		status int
+		err    string
+	}{
+		"ok": {
+			status: http.StatusOK,
+		},
+		"ko, upload failed": {
+			status: http.StatusForbidden,
+			err:    "failed to upload the object, status 403",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, http.MethodPut, r.Method)
+					assert.Equal(t, "/bucket/reports/tenant/report%201.csv",
+						r.URL.EscapedPath())
+					assert.Equal(t, "text/csv", r.Header.Get("Content-Type"))
+					assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+						"AWS4-HMAC-SHA256 Credential=access-key/"))
+					assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+					assert.Equal(t, int64(len("id\n")), r.ContentLength)
+					body, _ := io.ReadAll(r.Body)
+					assert.Equal(t, "id\n", string(body))
+					w.WriteHeader(tc.status)
+				},
+			))
+			defer srv.Close()
+
+			client := NewClient(srv.URL, "us-east-1", "bucket", sigv4.Credentials{
+				AccessKeyID:     "access-key",
+				SecretAccessKey: "secret-key",
+			})
+			err := client.PutObject(context.Background(), "reports/tenant/report 1.csv",
+				strings.NewReader("id\n"), "text/csv")
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
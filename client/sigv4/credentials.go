@@ -0,0 +1,58 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package sigv4
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/pkg/errors"
+)
+
+// CredentialsProvider provides the credentials to sign the requests with
+type CredentialsProvider = aws.CredentialsProvider
+
+// Credentials are static AWS credentials
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Retrieve returns the credentials themselves: static credentials are a
+// CredentialsProvider
+func (c Credentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return credentials.NewStaticCredentialsProvider(
+		c.AccessKeyID,
+		c.SecretAccessKey,
+		c.SessionToken,
+	).Retrieve(ctx)
+}
+
+// NewDefaultCredentialsProvider returns the provider of the credentials
+// of the default credential chain of the AWS SDK: the environment, the
+// shared configuration files, the role assumed with a web identity (e.g.
+// IAM roles for Kubernetes service accounts), the role of the container
+// (e.g. ECS tasks, EKS pod identities) and the role of the EC2 instance.
+// The temporary credentials are cached until they are about to expire.
+func NewDefaultCredentialsProvider(ctx context.Context) (CredentialsProvider, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the AWS configuration")
+	}
+	return cfg.Credentials, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package sigv4
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+
+	credentials, err := Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+	}.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "AKIDEXAMPLE", credentials.AccessKeyID)
+	assert.Equal(t, "secret", credentials.SecretAccessKey)
+	assert.Equal(t, "token", credentials.SessionToken)
+	assert.False(t, credentials.CanExpire)
+
+	_, err = Credentials{}.Retrieve(context.Background())
+	assert.Error(t, err)
+}
+
+// setCredentialsEnv clears the AWS configuration of the environment, and
+// sets the given variables
+func setCredentialsEnv(t *testing.T, env map[string]string) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY",
+		"AWS_SESSION_TOKEN",
+		"AWS_PROFILE",
+		"AWS_WEB_IDENTITY_TOKEN_FILE",
+		"AWS_ROLE_ARN",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+}
+
+func TestDefaultCredentialsProviderEnv(t *testing.T) {
+	setCredentialsEnv(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	})
+
+	provider, err := NewDefaultCredentialsProvider(context.Background())
+	if assert.NoError(t, err) {
+		credentials, err := provider.Retrieve(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "AKIDEXAMPLE", credentials.AccessKeyID)
+		assert.Equal(t, "secret", credentials.SecretAccessKey)
+	}
+}
+
+func TestDefaultCredentialsProviderContainer(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "auth-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"AccessKeyId": "ASIAEXAMPLE",
+			"SecretAccessKey": "secret",
+			"Token": "token",
+			"Expiration": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"
+		}`))
+	}))
+	defer srv.Close()
+	setCredentialsEnv(t, map[string]string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": srv.URL + "/v2/credentials",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "auth-token",
+	})
+
+	provider, err := NewDefaultCredentialsProvider(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+	var credentials aws.Credentials
+	for i := 0; i < 2; i++ {
+		credentials, err = provider.Retrieve(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "ASIAEXAMPLE", credentials.AccessKeyID)
+	assert.Equal(t, "token", credentials.SessionToken)
+	assert.True(t, credentials.CanExpire)
+	// the temporary credentials are cached
+	assert.Equal(t, 1, calls)
+}
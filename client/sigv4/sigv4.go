@@ -0,0 +1,120 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package sigv4 signs HTTP requests with the AWS Signature Version 4, with
+// the signer and the credential providers of the AWS SDK
+package sigv4
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	serviceS3 = "s3"
+
+	hdrAmzContentSHA256 = "X-Amz-Content-Sha256"
+	emptyPayloadSHA256  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+const (
+	// ServiceOpenSearch is the service name of Amazon OpenSearch Service
+	ServiceOpenSearch = "es"
+	// ServiceOpenSearchServerless is the service name of Amazon OpenSearch
+	// Serverless
+	ServiceOpenSearchServerless = "aoss"
+)
+
+// UnsignedPayload is the payload hash of S3 requests with a body not signed
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Signer signs HTTP requests for a service in a region
+type Signer struct {
+	signer      *v4.Signer
+	credentials CredentialsProvider
+	region      string
+	service     string
+	now         func() time.Time
+}
+
+// NewSigner returns a new signer for the given service (e.g. s3, es)
+// and region; static Credentials are a CredentialsProvider too
+func NewSigner(credentials CredentialsProvider, region, service string) *Signer {
+	return &Signer{
+		signer: v4.NewSigner(func(opts *v4.SignerOptions) {
+			// the S3 object keys are escaped only once
+			opts.DisableURIPathEscaping = service == serviceS3
+		}),
+		credentials: credentials,
+		region:      region,
+		service:     service,
+		now:         time.Now,
+	}
+}
+
+// PayloadHash returns the hex-encoded SHA-256 of the payload
+func PayloadHash(payload []byte) string {
+	if len(payload) == 0 {
+		return emptyPayloadSHA256
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignRequest adds the signature headers to the request, hashing its body;
+// it implements the request signer of the OpenSearch client
+func (s *Signer) SignRequest(req *http.Request) error {
+	var payload []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body := req.Body
+		if req.GetBody != nil {
+			var err error
+			if body, err = req.GetBody(); err != nil {
+				return err
+			}
+		}
+		var err error
+		payload, err = io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		if req.GetBody == nil {
+			req.Body = io.NopCloser(bytes.NewReader(payload))
+		}
+	}
+	return s.Sign(req, PayloadHash(payload))
+}
+
+// Sign adds the signature headers to the request; payloadHash is the
+// hex-encoded SHA-256 of the request body (see PayloadHash), or
+// UnsignedPayload for S3 requests with a body not signed
+func (s *Signer) Sign(req *http.Request, payloadHash string) error {
+	ctx := req.Context()
+	credentials, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	if s.service == serviceS3 || s.service == ServiceOpenSearchServerless {
+		req.Header.Set(hdrAmzContentSHA256, payloadHash)
+	}
+	return s.signer.SignHTTP(ctx, credentials, req, payloadHash,
+		s.service, s.region, s.now().UTC())
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package sigv4
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	hdrAuthorization    = "Authorization"
+	hdrAmzDate          = "X-Amz-Date"
+	hdrAmzSecurityToken = "X-Amz-Security-Token"
+)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	// test vectors from the AWS Signature Version 4 test suite
+	credentials := Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		method string
+		url    string
+
+		authorization string
+	}{
+		"get-vanilla": {
+			method: http.MethodGet,
+			url:    "https://example.amazonaws.com/",
+
+			authorization: "AWS4-HMAC-SHA256 " +
+				"Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		"get-vanilla-query-order-key-case": {
+			method: http.MethodGet,
+			url:    "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+
+			authorization: "AWS4-HMAC-SHA256 " +
+				"Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			signer := NewSigner(credentials, "us-east-1", "service")
+			signer.now = now
+
+			req, _ := http.NewRequest(tc.method, tc.url, nil)
+			err := signer.Sign(req, PayloadHash(nil))
+			assert.NoError(t, err)
+
+			assert.Equal(t, "20150830T123600Z", req.Header.Get(hdrAmzDate))
+			assert.Equal(t, tc.authorization, req.Header.Get(hdrAuthorization))
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	t.Parallel()
+
+	credentials := Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		SessionToken:    "token",
+	}
+	now := func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	const body = `{"query":{"match_all":{}}}`
+
+	testCases := map[string]struct {
+		service string
+		getBody bool
+
+		contentSHA256 string
+	}{
+		"ok, opensearch": {
+			service: ServiceOpenSearch,
+		},
+		"ok, opensearch serverless": {
+			service: ServiceOpenSearchServerless,
+
+			contentSHA256: PayloadHash([]byte(body)),
+		},
+		"ok, replayable body": {
+			service: ServiceOpenSearch,
+			getBody: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			signer := NewSigner(credentials, "us-east-1", tc.service)
+			signer.now = now
+
+			req, _ := http.NewRequest(http.MethodPost,
+				"https://search.us-east-1.es.amazonaws.com/devices/_search",
+				strings.NewReader(body))
+			if !tc.getBody {
+				req.GetBody = nil
+			}
+			err := signer.SignRequest(req)
+			assert.NoError(t, err)
+
+			expected, _ := http.NewRequest(http.MethodPost, req.URL.String(),
+				strings.NewReader(body))
+			err = signer.Sign(expected, PayloadHash([]byte(body)))
+			assert.NoError(t, err)
+			assert.Equal(t,
+				expected.Header.Get(hdrAuthorization),
+				req.Header.Get(hdrAuthorization))
+			assert.Equal(t, "token", req.Header.Get(hdrAmzSecurityToken))
+			assert.Equal(t, tc.contentSHA256, req.Header.Get(hdrAmzContentSHA256))
+
+			actual, _ := io.ReadAll(req.Body)
+			assert.Equal(t, body, string(actual))
+		})
+	}
+}
+
+func TestSignCredentialsError(t *testing.T) {
+	t.Parallel()
+
+	credentials := aws.CredentialsProviderFunc(
+		func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{}, errors.New("connection refused")
+		})
+	signer := NewSigner(credentials, "us-east-1", ServiceOpenSearch)
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	err := signer.Sign(req, PayloadHash(nil))
+	assert.EqualError(t, err, "connection refused")
+	assert.Empty(t, req.Header.Get(hdrAuthorization))
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package tenantadm
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/metrics"
+	"github.com/mendersoftware/reporting/tracing"
+	"github.com/mendersoftware/reporting/utils"
+)
+
+const (
+	serviceName = "tenantadm"
+
+	urlTenant      = "/api/internal/v1/tenantadm/tenants/:tid"
+	urlHealth      = "/api/internal/v1/tenantadm/health"
+	defaultTimeout = 10 * time.Second
+)
+
+var (
+	// ErrTenantNotFound is returned when the tenant does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+)
+
+//go:generate ../../x/mockgen.sh
+type Client interface {
+	// CheckHealth checks the health of the service
+	CheckHealth(ctx context.Context) error
+	// GetTenant returns the tenant with the given ID
+	GetTenant(ctx context.Context, tid string) (*Tenant, error)
+}
+
+type ClientOption func(*client)
+
+type client struct {
+	client    *http.Client
+	urlBase   string
+	timeout   time.Duration
+	transport utils.TransportOptions
+}
+
+func NewClient(urlBase string, opts ...ClientOption) Client {
+	c := &client{
+		urlBase: urlBase,
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.client = &http.Client{
+		Transport: tracing.NewTransport(serviceName,
+			metrics.NewTransport(serviceName, utils.NewTransport(c.transport))),
+	}
+	return c
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to the service
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *client) {
+		c.transport.TLSConfig = config
+	}
+}
+
+// WithTimeout sets the timeout of the requests to the service
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+func (c *client) GetTenant(ctx context.Context, tid string) (*Tenant, error) {
+	url := utils.JoinURL(c.urlBase, urlTenant)
+	url = strings.Replace(url, ":tid", tid, 1)
+
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlTenant), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request")
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrTenantNotFound
+	default:
+		return nil, errors.Errorf("%s %s request failed with status %v",
+			req.Method, req.URL, rsp.Status)
+	}
+
+	tenant := &Tenant{}
+	if err := json.NewDecoder(rsp.Body).Decode(tenant); err != nil {
+		return nil, errors.Wrap(err, "failed to parse request body")
+	}
+	return tenant, nil
+}
+
+// CheckHealth checks the health of the service
+func (c *client) CheckHealth(ctx context.Context) error {
+	url := utils.JoinURL(c.urlBase, urlHealth)
+
+	ctx, cancel := context.WithTimeout(metrics.WithEndpoint(ctx, urlHealth), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to submit %s %s", req.Method, req.URL)
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("%s %s request failed with status %v",
+			req.Method, req.URL, rsp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package tenantadm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTenant(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		status int
+		body   string
+
+		tenant *Tenant
+		err    string
+	}{
+		"ok": {
+			status: http.StatusOK,
+			body: `{"id":"123456789012345678901234","name":"acme",` +
+				`"status":"active","region":"eu"}`,
+			tenant: &Tenant{
+				ID:     "123456789012345678901234",
+				Name:   "acme",
+				Status: "active",
+				Region: "eu",
+			},
+		},
+		"error, not found": {
+			status: http.StatusNotFound,
+			err:    ErrTenantNotFound.Error(),
+		},
+		"error, internal error": {
+			status: http.StatusInternalServerError,
+			err:    "request failed with status 500 Internal Server Error",
+		},
+		"error, malformed body": {
+			status: http.StatusOK,
+			body:   `[]`,
+			err: "failed to parse request body: json: cannot unmarshal array " +
+				"into Go value of type tenantadm.Tenant",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, http.MethodGet, r.Method)
+					assert.Equal(t,
+						"/api/internal/v1/tenantadm/tenants/123456789012345678901234",
+						r.URL.Path)
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+			defer srv.Close()
+
+			client := NewClient(srv.URL)
+			tenant, err := client.GetTenant(context.Background(),
+				"123456789012345678901234")
+			if tc.err != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.tenant, tenant)
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	t.Parallel()
+
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/internal/v1/tenantadm/health", r.URL.Path)
+			w.WriteHeader(status)
+		}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	assert.NoError(t, client.CheckHealth(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, client.CheckHealth(context.Background()))
+}
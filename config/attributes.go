@@ -0,0 +1,81 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package config
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// IndexingAttributesFilter returns the attributes of the devices of all the
+// tenants to index, or nil to index them all
+func IndexingAttributesFilter(conf config.Reader) (*model.AttributesFilter, error) {
+	included, err := attributeSelectors(conf, SettingIndexingIncludedAttributes)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := attributeSelectors(conf, SettingIndexingExcludedAttributes)
+	if err != nil {
+		return nil, err
+	}
+	if len(included) == 0 && len(excluded) == 0 {
+		return nil, nil
+	}
+	return &model.AttributesFilter{
+		Included: included,
+		Excluded: excluded,
+	}, nil
+}
+
+// IndexingAttributesRedaction returns the attributes of the devices whose
+// values are hashed or masked before indexing, or nil if none
+func IndexingAttributesRedaction(conf config.Reader) (*model.AttributesRedaction, error) {
+	hashed, err := attributeSelectors(conf, SettingIndexingHashedAttributes)
+	if err != nil {
+		return nil, err
+	}
+	masked, err := attributeSelectors(conf, SettingIndexingMaskedAttributes)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashed) == 0 && len(masked) == 0 {
+		return nil, nil
+	}
+	hashKey := conf.GetString(SettingIndexingHashKey)
+	if len(hashed) > 0 && hashKey == "" {
+		return nil, errors.Errorf("%s: required to hash the attributes",
+			SettingIndexingHashKey)
+	}
+	return &model.AttributesRedaction{
+		Hashed:  hashed,
+		Masked:  masked,
+		HashKey: []byte(hashKey),
+	}, nil
+}
+
+func attributeSelectors(conf config.Reader, key string) ([]model.AttributeSelector, error) {
+	var selectors []model.AttributeSelector
+	for _, s := range conf.GetStringSlice(key) {
+		selector, err := model.ParseAttributeSelector(s)
+		if err != nil {
+			return nil, errors.Wrap(err, key)
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+
+	"github.com/mendersoftware/reporting/client/breaker"
+	"github.com/mendersoftware/reporting/client/deployments"
+	"github.com/mendersoftware/reporting/client/deviceauth"
+	"github.com/mendersoftware/reporting/client/inventory"
+	"github.com/mendersoftware/reporting/model"
+)
+
+// ServiceClients returns the clients of the downstream services the
+// devices and deployments data is fetched from, behind circuit breakers;
+// the indexer and the API server share them
+func ServiceClients(conf config.Reader) (
+	deviceauth.Client,
+	inventory.Client,
+	deployments.Client,
+	error,
+) {
+	tlsConfig, err := ClientsTLSConfig(conf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	timeout := time.Duration(conf.GetInt(SettingClientsTimeoutMsec)) *
+		time.Millisecond
+	maxIdleConnsPerHost := conf.GetInt(SettingClientsMaxIdleConnsPerHost)
+	idleConnTimeout := time.Duration(
+		conf.GetInt(SettingClientsIdleConnTimeoutMsec),
+	) * time.Millisecond
+	breakerThreshold := conf.GetInt(SettingCircuitBreakerFailureThreshold)
+	breakerTimeout := time.Duration(
+		conf.GetInt(SettingCircuitBreakerOpenTimeoutMsec),
+	) * time.Millisecond
+
+	invClient := inventory.NewBreakerClient(
+		inventory.NewClient(
+			conf.GetString(SettingInventoryAddr),
+			inventory.WithTLSConfig(tlsConfig),
+			inventory.WithConnectionPool(maxIdleConnsPerHost, idleConnTimeout),
+			inventory.WithTimeout(timeout),
+		),
+		breaker.NewCircuitBreaker(model.ServiceInventory,
+			breakerThreshold, breakerTimeout),
+	)
+
+	devClient := deviceauth.NewBreakerClient(
+		deviceauth.NewClient(
+			conf.GetString(SettingDeviceAuthAddr),
+			deviceauth.WithTLSConfig(tlsConfig),
+			deviceauth.WithConnectionPool(maxIdleConnsPerHost, idleConnTimeout),
+			deviceauth.WithTimeout(timeout),
+		),
+		breaker.NewCircuitBreaker(model.ServiceDeviceauth,
+			breakerThreshold, breakerTimeout),
+	)
+
+	deplClient := deployments.NewBreakerClient(
+		deployments.NewClient(
+			conf.GetString(SettingDeploymentsAddr),
+			deployments.WithTLSConfig(tlsConfig),
+			deployments.WithConnectionPool(maxIdleConnsPerHost, idleConnTimeout),
+			deployments.WithTimeout(timeout),
+			deployments.WithRetryPolicy(deployments.RetryPolicy{
+				MaxAttempts: conf.GetInt(SettingDeploymentsRetryMaxAttempts),
+				InitialBackoff: time.Duration(
+					conf.GetInt(SettingDeploymentsRetryBackoffMsec),
+				) * time.Millisecond,
+				MaxBackoff: time.Duration(
+					conf.GetInt(SettingDeploymentsRetryMaxBackoffMsec),
+				) * time.Millisecond,
+			}),
+		),
+		breaker.NewCircuitBreaker(model.ServiceDeployments,
+			breakerThreshold, breakerTimeout),
+	)
+	return devClient, invClient, deplClient, nil
+}
@@ -24,11 +24,210 @@ const (
 	// SettingListenDefault is the default value for the listen address
 	SettingListenDefault = ":8080"
 
+	// SettingGRPCListen is the config key for the listen address of the
+	// gRPC internal API, empty to disable it
+	SettingGRPCListen = "grpc_listen"
+	// SettingGRPCListenDefault is the default value for the listen address
+	// of the gRPC internal API
+	SettingGRPCListenDefault = ""
+
+	// SettingAggregationsMaxDepth is the config key for the maximum depth of
+	// nested sub-aggregations in the aggregation requests
+	SettingAggregationsMaxDepth = "aggregations_max_depth"
+	// SettingAggregationsMaxDepthDefault is the default value for the maximum
+	// depth of nested sub-aggregations in the aggregation requests
+	SettingAggregationsMaxDepthDefault = 5
+
+	// SettingQueryMaxClauses is the config key for the maximum number of
+	// filters of the search and aggregation requests
+	SettingQueryMaxClauses = "query_max_clauses"
+	// SettingQueryMaxClausesDefault is the default value for the maximum
+	// number of filters of the search and aggregation requests
+	SettingQueryMaxClausesDefault = 100
+
+	// SettingQueryMaxAggregationBuckets is the config key for the maximum
+	// number of buckets the aggregation requests may return
+	SettingQueryMaxAggregationBuckets = "query_max_aggregation_buckets"
+	// SettingQueryMaxAggregationBucketsDefault is the default value for the
+	// maximum number of buckets the aggregation requests may return
+	SettingQueryMaxAggregationBucketsDefault = 10000
+
+	// SettingQueryMaxPerPage is the config key for the maximum page size of
+	// the search requests
+	SettingQueryMaxPerPage = "query_max_per_page"
+	// SettingQueryMaxPerPageDefault is the default value for the maximum
+	// page size of the search requests
+	SettingQueryMaxPerPageDefault = 500
+
+	// SettingQueryMaxPatternLength is the config key for the maximum length
+	// of the $regex and $wildcard patterns of the filters
+	SettingQueryMaxPatternLength = "query_max_pattern_length"
+	// SettingQueryMaxPatternLengthDefault is the default value for the
+	// maximum length of the $regex and $wildcard patterns of the filters
+	SettingQueryMaxPatternLengthDefault = 256
+
+	// SettingConnectivityWindowMinutes is the config key for the staleness
+	// window, in minutes, of the connectivity state of the devices
+	SettingConnectivityWindowMinutes = "connectivity_window_minutes"
+	// SettingConnectivityWindowMinutesDefault is the default value for the
+	// staleness window of the connectivity state of the devices
+	SettingConnectivityWindowMinutesDefault = 1440
+
+	// SettingSearchStreamIntervalMsec is the config key for the interval at
+	// which the streamed searches are refreshed
+	SettingSearchStreamIntervalMsec = "search_stream_interval_msec"
+	// SettingSearchStreamIntervalMsecDefault is the default value for the
+	// interval at which the streamed searches are refreshed
+	SettingSearchStreamIntervalMsecDefault = 5000
+
+	// SettingRateLimitTenantRPS is the config key for the number of search
+	// and aggregation requests per second allowed to each tenant
+	SettingRateLimitTenantRPS = "rate_limit_tenant_rps"
+	// SettingRateLimitTenantRPSDefault is the default value for the number
+	// of search and aggregation requests per second allowed to each tenant;
+	// zero disables the rate limiting
+	SettingRateLimitTenantRPSDefault = 0
+
+	// SettingRateLimitTenantBurst is the config key for the number of search
+	// and aggregation requests a tenant can send in a burst
+	SettingRateLimitTenantBurst = "rate_limit_tenant_burst"
+	// SettingRateLimitTenantBurstDefault is the default value for the number
+	// of search and aggregation requests a tenant can send in a burst
+	SettingRateLimitTenantBurstDefault = 20
+
+	// SettingGraphQLEnable is the config key for enabling the GraphQL API
+	// over the device and deployment documents
+	SettingGraphQLEnable = "graphql_enable"
+	// SettingGraphQLEnableDefault is the default value for enabling the
+	// GraphQL API over the device and deployment documents
+	SettingGraphQLEnableDefault = false
+
+	// SettingCompressionEnable is the config key for compressing the
+	// responses of the search, aggregation and export endpoints
+	SettingCompressionEnable = "compression_enable"
+	// SettingCompressionEnableDefault is the default value for compressing
+	// the responses of the search, aggregation and export endpoints
+	SettingCompressionEnableDefault = true
+
+	// SettingCompressionMinSize is the config key for the minimum size, in
+	// bytes, of the compressed responses
+	SettingCompressionMinSize = "compression_min_size"
+	// SettingCompressionMinSizeDefault is the default value for the minimum
+	// size, in bytes, of the compressed responses
+	SettingCompressionMinSizeDefault = 1024
+
+	// SettingRequestValidationEnable is the config key for validating the
+	// requests against the OpenAPI specifications of the APIs
+	SettingRequestValidationEnable = "request_validation_enable"
+	// SettingRequestValidationEnableDefault is the default value for
+	// validating the requests against the OpenAPI specifications of the APIs
+	SettingRequestValidationEnableDefault = false
+
+	// SettingAuditLogEnable is the config key for recording the requests to
+	// the management API in the audit trail
+	SettingAuditLogEnable = "audit_log_enable"
+	// SettingAuditLogEnableDefault is the default value for recording the
+	// requests to the management API in the audit trail
+	SettingAuditLogEnableDefault = false
+
+	// SettingAuditLogRetentionDays is the config key for the number of days
+	// the entries of the audit trail are kept for
+	SettingAuditLogRetentionDays = "audit_log_retention_days"
+	// SettingAuditLogRetentionDaysDefault is the default value for the number
+	// of days the entries of the audit trail are kept for; zero keeps them
+	// forever
+	SettingAuditLogRetentionDaysDefault = 90
+
+	// SettingSearchJobsTTLMinutes is the config key for the number of
+	// minutes the search jobs, and their results, are kept for
+	SettingSearchJobsTTLMinutes = "search_jobs_ttl_minutes"
+	// SettingSearchJobsTTLMinutesDefault is the default value for the
+	// number of minutes the search jobs, and their results, are kept for
+	SettingSearchJobsTTLMinutesDefault = 60
+
+	// SettingSearchJobsConcurrency is the config key for the number of
+	// search jobs each instance runs at the same time
+	SettingSearchJobsConcurrency = "search_jobs_concurrency"
+	// SettingSearchJobsConcurrencyDefault is the default value for the
+	// number of search jobs each instance runs at the same time
+	SettingSearchJobsConcurrencyDefault = 4
+
+	// SettingUsageFlushIntervalMsec is the config key for the interval at
+	// which the server flushes the counts of the queries of the tenants,
+	// and of the uses of their attributes
+	SettingUsageFlushIntervalMsec = "usage_flush_interval_msec"
+	// SettingUsageFlushIntervalMsecDefault is the default value for the
+	// interval at which the counts of the queries are flushed
+	SettingUsageFlushIntervalMsecDefault = 60000
+
+	// SettingUsageRefreshIntervalMsec is the config key for the interval at
+	// which the server refreshes the usage metrics of all the tenants
+	SettingUsageRefreshIntervalMsec = "usage_refresh_interval_msec"
+	// SettingUsageRefreshIntervalMsecDefault is the default value for the
+	// interval at which the usage metrics are refreshed
+	SettingUsageRefreshIntervalMsecDefault = 3600000
+
+	// SettingCacheTTLMsec is the config key for the time, in milliseconds,
+	// the results of the searches and aggregations are cached for
+	SettingCacheTTLMsec = "cache_ttl_msec"
+	// SettingCacheTTLMsecDefault is the default value for the time the
+	// results of the searches and aggregations are cached for; zero
+	// disables the cache
+	SettingCacheTTLMsecDefault = 0
+
+	// SettingCacheSize is the config key for the maximum number of results
+	// kept in the cache
+	SettingCacheSize = "cache_size"
+	// SettingCacheSizeDefault is the default value for the maximum number
+	// of results kept in the cache
+	SettingCacheSizeDefault = 1000
+
+	// SettingCacheInvalidationSubject is the config key for the nats subject
+	// the indexer publishes the invalidations of the tenants' cache on
+	SettingCacheInvalidationSubject = "cache_invalidation_subject"
+	// SettingCacheInvalidationSubjectDefault is the default value for the
+	// nats subject the invalidations of the tenants' cache are published on
+	SettingCacheInvalidationSubjectDefault = "reporting.cache.invalidations"
+
+	// SettingStorageBackend is the config key for the storage backend of the
+	// devices and deployments: opensearch or mongodb
+	SettingStorageBackend = "storage_backend"
+	// SettingStorageBackendDefault is the default value for the storage
+	// backend of the devices and deployments
+	SettingStorageBackendDefault = StorageBackendOpenSearch
+
+	// StorageBackendOpenSearch stores the devices and deployments in OpenSearch
+	StorageBackendOpenSearch = "opensearch"
+	// StorageBackendMongoDB stores the devices and deployments in MongoDB,
+	// for small installations which do not want to run OpenSearch
+	StorageBackendMongoDB = "mongodb"
+
 	// SettingOpenSearchAddresses is the config key for the opensearch addresses
 	SettingOpenSearchAddresses = "opensearch_addresses"
 	// SettingOpenSearchAddressesDefault is the default value for the opensearch addresses
 	SettingOpenSearchAddressesDefault = "http://localhost:9200"
 
+	// SettingOpenSearchReadAddresses is the config key for the addresses of
+	// the cluster the queries run on, e.g. a read replica, instead of the
+	// opensearch addresses the documents are written to
+	SettingOpenSearchReadAddresses = "opensearch_read_addresses"
+
+	// SettingOpenSearchReadHealthCheckMsec is the config key for the interval
+	// between the health checks of the read cluster
+	SettingOpenSearchReadHealthCheckMsec = "opensearch_read_health_check_msec"
+	// SettingOpenSearchReadHealthCheckMsecDefault is the default value for
+	// the interval between the health checks of the read cluster
+	SettingOpenSearchReadHealthCheckMsecDefault = 10000
+
+	// SettingOpenSearchClusters is the config key for the list of the
+	// additional OpenSearch clusters, as name=address entries, the data of
+	// the tenants with residency requirements resides in
+	SettingOpenSearchClusters = "opensearch_clusters"
+
+	// SettingOpenSearchTenantClusters is the config key for the routing
+	// table of the tenants to the clusters, as tenant=cluster entries
+	SettingOpenSearchTenantClusters = "opensearch_tenant_clusters"
+
 	// SettingOpenSearchDevicesIndexName is the config key for the opensearch devices
 	// index name
 	SettingOpenSearchDevicesIndexName = "opensearch_devices_index_name"
@@ -50,6 +249,13 @@ const (
 	// opensearch devices index replicas
 	SettingOpenSearchDevicesIndexReplicasDefault = 0
 
+	// SettingOpenSearchDevicesIndexRefreshInterval is the config key for the
+	// opensearch devices index refresh interval
+	SettingOpenSearchDevicesIndexRefreshInterval = "opensearch_devices_index_refresh_interval"
+	// SettingOpenSearchDevicesIndexRefreshIntervalDefault is the default value
+	// for the opensearch devices index refresh interval
+	SettingOpenSearchDevicesIndexRefreshIntervalDefault = "1s"
+
 	// SettingOpenSearchDeploymentsIndexName is the config key for the opensearch deployments
 	// index name
 	SettingOpenSearchDeploymentsIndexName = "opensearch_deployments_index_name"
@@ -71,11 +277,274 @@ const (
 	// opensearch deployments index replicas
 	SettingOpenSearchDeploymentsIndexReplicasDefault = 0
 
+	// SettingOpenSearchDeploymentsIndexRefreshInterval is the config key for
+	// the opensearch deployments index refresh interval
+	SettingOpenSearchDeploymentsIndexRefreshInterval = "opensearch_deployments_" +
+		"index_refresh_interval"
+	// SettingOpenSearchDeploymentsIndexRefreshIntervalDefault is the default
+	// value for the opensearch deployments index refresh interval
+	SettingOpenSearchDeploymentsIndexRefreshIntervalDefault = "1s"
+
+	// SettingOpenSearchSoftwareIndexName is the config key for the opensearch
+	// index name of the devices' software inventory
+	SettingOpenSearchSoftwareIndexName = "opensearch_software_index_name"
+	// SettingOpenSearchSoftwareIndexNameDefault is the default value for the
+	// opensearch index name of the devices' software inventory
+	SettingOpenSearchSoftwareIndexNameDefault = "software"
+
+	// SettingOpenSearchSoftwareIndexShards is the config key for the
+	// opensearch software inventory index shards
+	SettingOpenSearchSoftwareIndexShards = "opensearch_software_index_shards"
+	// SettingOpenSearchSoftwareIndexShardsDefault is the default value for
+	// the opensearch software inventory index shards
+	SettingOpenSearchSoftwareIndexShardsDefault = 1
+
+	// SettingOpenSearchSoftwareIndexReplicas is the config key for the
+	// opensearch software inventory index replicas
+	SettingOpenSearchSoftwareIndexReplicas = "opensearch_software_index_replicas"
+	// SettingOpenSearchSoftwareIndexReplicasDefault is the default value for
+	// the opensearch software inventory index replicas
+	SettingOpenSearchSoftwareIndexReplicasDefault = 0
+
+	// SettingOpenSearchSoftwareIndexRefreshInterval is the config key for the
+	// opensearch software inventory index refresh interval
+	SettingOpenSearchSoftwareIndexRefreshInterval = "opensearch_software_index_refresh_interval"
+	// SettingOpenSearchSoftwareIndexRefreshIntervalDefault is the default
+	// value for the opensearch software inventory index refresh interval
+	SettingOpenSearchSoftwareIndexRefreshIntervalDefault = "1s"
+
+	// SettingOpenSearchIndexStrategy is the config key for the strategy
+	// mapping the tenants to the opensearch indices: shared, per_tenant or hashed
+	SettingOpenSearchIndexStrategy = "opensearch_index_strategy"
+	// SettingOpenSearchIndexStrategyDefault is the default value for the
+	// strategy mapping the tenants to the opensearch indices
+	SettingOpenSearchIndexStrategyDefault = "shared"
+
+	// SettingOpenSearchIndexGroups is the config key for the number of index
+	// groups the tenants are spread across with the hashed index strategy
+	SettingOpenSearchIndexGroups = "opensearch_index_groups"
+	// SettingOpenSearchIndexGroupsDefault is the default value for the number
+	// of index groups the tenants are spread across with the hashed index strategy
+	SettingOpenSearchIndexGroupsDefault = 4
+
+	// SettingOpenSearchDeploymentsRollover is the config key for the
+	// rollover of the deployments indices: empty or monthly
+	SettingOpenSearchDeploymentsRollover = "opensearch_deployments_rollover"
+	// SettingOpenSearchDeploymentsRolloverDefault is the default value for
+	// the rollover of the deployments indices: none
+	SettingOpenSearchDeploymentsRolloverDefault = ""
+
+	// SettingOpenSearchBulkMaxRetries is the config key for the number of
+	// times the bulk items failed for a transient reason are retried
+	SettingOpenSearchBulkMaxRetries = "opensearch_bulk_max_retries"
+	// SettingOpenSearchBulkMaxRetriesDefault is the default value for the
+	// number of times the bulk items failed for a transient reason are retried
+	SettingOpenSearchBulkMaxRetriesDefault = 3
+
+	// SettingOpenSearchBulkRetryBackoffMsec is the config key for the backoff
+	// before the first retry of the failed bulk items, doubled at each retry
+	SettingOpenSearchBulkRetryBackoffMsec = "opensearch_bulk_retry_backoff_msec"
+	// SettingOpenSearchBulkRetryBackoffMsecDefault is the default value for
+	// the backoff before the first retry of the failed bulk items
+	SettingOpenSearchBulkRetryBackoffMsecDefault = 100
+
+	// SettingOpenSearchSlowQueryMsec is the config key for the latency above
+	// which the queries are logged as slow queries, 0 to disable the log
+	SettingOpenSearchSlowQueryMsec = "opensearch_slow_query_msec"
+	// SettingOpenSearchSlowQueryMsecDefault is the default value for the
+	// latency above which the queries are logged as slow queries
+	SettingOpenSearchSlowQueryMsecDefault = 1000
+
+	// SettingOpenSearchMaxConcurrentQueries is the config key for the
+	// maximum number of queries run at the same time by the server, 0 for
+	// no limit
+	SettingOpenSearchMaxConcurrentQueries = "opensearch_max_concurrent_queries"
+	// SettingOpenSearchMaxConcurrentQueriesDefault is the default value for
+	// the maximum number of queries run at the same time by the server
+	SettingOpenSearchMaxConcurrentQueriesDefault = 0
+	// SettingOpenSearchQueriesQueueTimeoutMsec is the config key for how
+	// long the queries over the limit wait for a free slot before being
+	// rejected, 0 to reject them right away
+	SettingOpenSearchQueriesQueueTimeoutMsec = "opensearch_queries_queue_timeout_msec"
+	// SettingOpenSearchQueriesQueueTimeoutMsecDefault is the default value
+	// for how long the queries over the limit wait for a free slot
+	SettingOpenSearchQueriesQueueTimeoutMsecDefault = 1000
+
+	// SettingOpenSearchTLSCAFile is the config key for the PEM bundle of the
+	// CAs the OpenSearch certificates are verified with
+	SettingOpenSearchTLSCAFile = "opensearch_tls_ca_file"
+	// SettingOpenSearchTLSCertFile is the config key for the PEM client
+	// certificate presented to OpenSearch
+	SettingOpenSearchTLSCertFile = "opensearch_tls_cert_file"
+	// SettingOpenSearchTLSKeyFile is the config key for the PEM private key
+	// of the client certificate presented to OpenSearch
+	SettingOpenSearchTLSKeyFile = "opensearch_tls_key_file"
+	// SettingOpenSearchTLSMinVersion is the config key for the minimum TLS
+	// version of the connections to OpenSearch
+	SettingOpenSearchTLSMinVersion = "opensearch_tls_min_version"
+
+	// SettingOpenSearchAuth is the config key for the authentication of the
+	// requests to OpenSearch: empty for none, or aws_sigv4 to sign them
+	// with the AWS Signature Version 4, for Amazon OpenSearch Service
+	SettingOpenSearchAuth = "opensearch_auth"
+	// SettingOpenSearchAuthDefault is the default value for the
+	// authentication of the requests to OpenSearch
+	SettingOpenSearchAuthDefault = ""
+	// OpenSearchAuthAWSSigV4 signs the requests to OpenSearch with the AWS
+	// Signature Version 4
+	OpenSearchAuthAWSSigV4 = "aws_sigv4"
+
+	// SettingOpenSearchAWSRegion is the config key for the AWS region of
+	// the OpenSearch domain or collection
+	SettingOpenSearchAWSRegion = "opensearch_aws_region"
+	// SettingOpenSearchAWSService is the config key for the AWS service
+	// the requests are signed for: es for Amazon OpenSearch Service, or
+	// aoss for Amazon OpenSearch Serverless
+	SettingOpenSearchAWSService = "opensearch_aws_service"
+	// SettingOpenSearchAWSServiceDefault is the default value for the AWS
+	// service the requests are signed for
+	SettingOpenSearchAWSServiceDefault = "es"
+	// SettingOpenSearchAWSAccessKeyID is the config key for the access key
+	// ID the requests are signed with; if empty, the credentials are
+	// taken from the environment, e.g. the role of the pod or instance
+	SettingOpenSearchAWSAccessKeyID = "opensearch_aws_access_key_id"
+	// SettingOpenSearchAWSSecretAccessKey is the config key for the secret
+	// access key the requests are signed with
+	SettingOpenSearchAWSSecretAccessKey = "opensearch_aws_secret_access_key"
+	// SettingOpenSearchAWSSessionToken is the config key for the session
+	// token of temporary credentials
+	SettingOpenSearchAWSSessionToken = "opensearch_aws_session_token"
+
+	// SettingOpenSearchDistribution is the config key for the distribution
+	// of the cluster: opensearch, or elasticsearch for Elasticsearch 8
+	SettingOpenSearchDistribution = "opensearch_distribution"
+	// SettingOpenSearchDistributionDefault is the default value for the
+	// distribution of the cluster
+	SettingOpenSearchDistributionDefault = "opensearch"
+
+	// SettingOpenSearchUsername is the config key for the username of the
+	// HTTP basic authentication to the cluster
+	SettingOpenSearchUsername = "opensearch_username"
+	// SettingOpenSearchPassword is the config key for the password of the
+	// HTTP basic authentication to the cluster
+	SettingOpenSearchPassword = "opensearch_password"
+	// SettingOpenSearchAPIKey is the config key for the API key the
+	// requests to the cluster are authenticated with
+	SettingOpenSearchAPIKey = "opensearch_api_key"
+
+	// SettingLocationLatitudeAttribute is the config key for the inventory
+	// attribute the latitude of the devices' location is derived from
+	SettingLocationLatitudeAttribute = "location_latitude_attribute"
+	// SettingLocationLatitudeAttributeDefault is the default value for the
+	// inventory attribute the latitude of the devices' location is derived from
+	SettingLocationLatitudeAttributeDefault = "latitude"
+
+	// SettingLocationLongitudeAttribute is the config key for the inventory
+	// attribute the longitude of the devices' location is derived from
+	SettingLocationLongitudeAttribute = "location_longitude_attribute"
+	// SettingLocationLongitudeAttributeDefault is the default value for the
+	// inventory attribute the longitude of the devices' location is derived from
+	SettingLocationLongitudeAttributeDefault = "longitude"
+
+	// SettingIndexingIncludedAttributes is the config key for the list of
+	// the attributes, as scope/name patterns, of the devices of all the
+	// tenants to index; if empty, all the attributes are indexed
+	SettingIndexingIncludedAttributes = "indexing_included_attributes"
+
+	// SettingIndexingExcludedAttributes is the config key for the list of
+	// the attributes, as scope/name patterns, of the devices of all the
+	// tenants not to index
+	SettingIndexingExcludedAttributes = "indexing_excluded_attributes"
+
+	// SettingIndexingHashedAttributes is the config key for the list of
+	// the attributes, as scope/name patterns, whose values are hashed
+	// before indexing
+	SettingIndexingHashedAttributes = "indexing_hashed_attributes"
+
+	// SettingIndexingMaskedAttributes is the config key for the list of
+	// the attributes, as scope/name patterns, whose values are masked
+	// before indexing
+	SettingIndexingMaskedAttributes = "indexing_masked_attributes"
+
+	// SettingIndexingHashKey is the config key for the secret key the
+	// values of the hashed attributes are hashed with
+	SettingIndexingHashKey = "indexing_hash_key"
+
+	// SettingIndexingSoftwareInventory is the config key for the flag
+	// enabling the indexing of the devices' software inventory
+	SettingIndexingSoftwareInventory = "indexing_software_inventory"
+	// SettingIndexingSoftwareInventoryDefault is the default value for the
+	// flag enabling the indexing of the devices' software inventory
+	SettingIndexingSoftwareInventoryDefault = false
+
+	// SettingIndexingEnrichmentStages is the config key for the list of
+	// the stages of the pipeline building the documents of the devices
+	SettingIndexingEnrichmentStages = "indexing_enrichment_stages"
+	// SettingIndexingEnrichmentStagesDefault is the default value for the
+	// list of the stages of the pipeline building the documents of the devices
+	SettingIndexingEnrichmentStagesDefault = "inventory deviceauth deployments location"
+
+	// SettingEnrichmentWebhookURL is the config key for the URL of the
+	// external webhook enriching the documents of the devices
+	SettingEnrichmentWebhookURL = "enrichment_webhook_url"
+	// SettingEnrichmentWebhookURLDefault is the default value for the URL
+	// of the external webhook enriching the documents of the devices
+	SettingEnrichmentWebhookURLDefault = ""
+
+	// SettingEnrichmentWebhookToken is the config key for the bearer token
+	// the requests to the enrichment webhook are authenticated with
+	SettingEnrichmentWebhookToken = "enrichment_webhook_token"
+	// SettingEnrichmentWebhookTokenDefault is the default value for the
+	// bearer token the requests to the enrichment webhook are authenticated with
+	SettingEnrichmentWebhookTokenDefault = ""
+
+	// SettingEnrichmentWebhookTimeoutMsec is the config key for the
+	// timeout, in milliseconds, of the requests to the enrichment webhook
+	SettingEnrichmentWebhookTimeoutMsec = "enrichment_webhook_timeout_msec"
+	// SettingEnrichmentWebhookTimeoutMsecDefault is the default value for
+	// the timeout of the requests to the enrichment webhook
+	SettingEnrichmentWebhookTimeoutMsecDefault = 5000
+
 	// SettingDeploymentsAddr is the config key for the deviceauth service address
 	SettingDeploymentsAddr = "deployments_addr"
 	// SettingDeploymentsAddrDefault is the default value for the deployments service address
 	SettingDeploymentsAddrDefault = "http://mender-deployments:8080/"
 
+	// SettingDeploymentsRetryMaxAttempts is the config key for the max number of
+	// attempts for requests to the deployments service
+	SettingDeploymentsRetryMaxAttempts = "deployments_retry_max_attempts"
+	// SettingDeploymentsRetryMaxAttemptsDefault is the default value for the max
+	// number of attempts for requests to the deployments service
+	SettingDeploymentsRetryMaxAttemptsDefault = 3
+
+	// SettingDeploymentsRetryBackoffMsec is the config key for the initial backoff
+	// between two attempts of requests to the deployments service
+	SettingDeploymentsRetryBackoffMsec = "deployments_retry_backoff_msec"
+	// SettingDeploymentsRetryBackoffMsecDefault is the default value for the initial
+	// backoff between two attempts of requests to the deployments service
+	SettingDeploymentsRetryBackoffMsecDefault = 100
+
+	// SettingDeploymentsRetryMaxBackoffMsec is the config key for the max backoff
+	// between two attempts of requests to the deployments service
+	SettingDeploymentsRetryMaxBackoffMsec = "deployments_retry_max_backoff_msec"
+	// SettingDeploymentsRetryMaxBackoffMsecDefault is the default value for the max
+	// backoff between two attempts of requests to the deployments service
+	SettingDeploymentsRetryMaxBackoffMsecDefault = 2000
+
+	// SettingCircuitBreakerFailureThreshold is the config key for the number of
+	// consecutive failed requests to a downstream service which open its circuit breaker
+	SettingCircuitBreakerFailureThreshold = "circuit_breaker_failure_threshold"
+	// SettingCircuitBreakerFailureThresholdDefault is the default value for the number
+	// of consecutive failed requests which open the circuit breaker
+	SettingCircuitBreakerFailureThresholdDefault = 5
+
+	// SettingCircuitBreakerOpenTimeoutMsec is the config key for the time the circuit
+	// breaker stays open before letting a trial request through
+	SettingCircuitBreakerOpenTimeoutMsec = "circuit_breaker_open_timeout_msec"
+	// SettingCircuitBreakerOpenTimeoutMsecDefault is the default value for the time
+	// the circuit breaker stays open before letting a trial request through
+	SettingCircuitBreakerOpenTimeoutMsecDefault = 10000
+
 	// SettingDeviceAuthAddr is the config key for the deviceauth service address
 	SettingDeviceAuthAddr = "deviceauth_addr"
 	// SettingDeviceAuthAddrDefault is the default value for the deviceauth service address
@@ -86,6 +555,48 @@ const (
 	// SettingInventoryAddrDefault is the default value for the inventory service address
 	SettingInventoryAddrDefault = "http://mender-inventory:8080/"
 
+	// SettingTenantadmAddr is the config key for the tenantadm service
+	// address, which the clusters of the tenants not in the routing table
+	// are resolved with; if empty, they are routed to the default cluster
+	SettingTenantadmAddr = "tenantadm_addr"
+	// SettingTenantadmAddrDefault is the default value for the tenantadm
+	// service address
+	SettingTenantadmAddrDefault = ""
+
+	// SettingClientsTLSCAFile is the config key for the PEM bundle of the
+	// CAs the certificates of the downstream services are verified with
+	SettingClientsTLSCAFile = "clients_tls_ca_file"
+	// SettingClientsTLSCertFile is the config key for the PEM client
+	// certificate presented to the downstream services
+	SettingClientsTLSCertFile = "clients_tls_cert_file"
+	// SettingClientsTLSKeyFile is the config key for the PEM private key of
+	// the client certificate presented to the downstream services
+	SettingClientsTLSKeyFile = "clients_tls_key_file"
+	// SettingClientsTLSMinVersion is the config key for the minimum TLS
+	// version of the connections to the downstream services
+	SettingClientsTLSMinVersion = "clients_tls_min_version"
+
+	// SettingClientsTimeoutMsec is the config key for the timeout, in
+	// milliseconds, of the requests to the downstream services
+	SettingClientsTimeoutMsec = "clients_timeout_msec"
+	// SettingClientsTimeoutMsecDefault is the default value for the timeout
+	// of the requests to the downstream services
+	SettingClientsTimeoutMsecDefault = 10000
+
+	// SettingClientsMaxIdleConnsPerHost is the config key for the number of
+	// idle connections kept alive to each downstream service
+	SettingClientsMaxIdleConnsPerHost = "clients_max_idle_conns_per_host"
+	// SettingClientsMaxIdleConnsPerHostDefault is the default value for the
+	// number of idle connections kept alive to each downstream service
+	SettingClientsMaxIdleConnsPerHostDefault = 32
+
+	// SettingClientsIdleConnTimeoutMsec is the config key for the time, in
+	// milliseconds, an idle connection to a downstream service is kept alive
+	SettingClientsIdleConnTimeoutMsec = "clients_idle_conn_timeout_msec"
+	// SettingClientsIdleConnTimeoutMsecDefault is the default value for the
+	// time an idle connection to a downstream service is kept alive
+	SettingClientsIdleConnTimeoutMsecDefault = 90000
+
 	// SettingMongo is the config key for the mongo URL
 	SettingMongo = "mongo_url"
 	// SettingMongoDefault is the default value for the mongo URL
@@ -112,6 +623,25 @@ const (
 	// SettingDbPassword is the config key for the mongo password
 	SettingDbPassword = "mongo_password"
 
+	// SettingEventSource is the config key for the source of the events
+	// and jobs the indexer consumes: nats or kafka
+	SettingEventSource = "event_source"
+	// SettingEventSourceDefault is the default value for the source of the
+	// events and jobs
+	SettingEventSourceDefault = EventSourceNats
+
+	// EventSourceNats consumes the events and jobs from NATS JetStream
+	EventSourceNats = "nats"
+	// EventSourceKafka consumes the events and jobs from Kafka, from the
+	// topics named after the NATS subjects
+	EventSourceKafka = "kafka"
+
+	// SettingKafkaBrokers is the config key for the kafka brokers addresses
+	SettingKafkaBrokers = "kafka_brokers"
+	// SettingKafkaBrokersDefault is the default value for the kafka brokers
+	// addresses
+	SettingKafkaBrokersDefault = "kafka:9092"
+
 	// SettingNatsURI is the config key for the nats uri
 	SettingNatsURI = "nats_uri"
 	// SettingNatsURIDefault is the default value for the nats uri
@@ -133,20 +663,282 @@ const (
 	// name
 	SettingNatsSubscriberDurableDefault = "reporting"
 
+	// SettingNatsSubscriberPriorityTopic is the config key for the nats
+	// topic of the priority jobs, processed before the other jobs; empty
+	// disables the priority lane
+	SettingNatsSubscriberPriorityTopic        = "nats_subscriber_priority_topic"
+	SettingNatsSubscriberPriorityTopicDefault = ""
+
+	// SettingNatsSubscriberPriorityDurable is the config key for the nats
+	// durable consumer of the priority jobs
+	SettingNatsSubscriberPriorityDurable        = "nats_subscriber_priority_durable"
+	SettingNatsSubscriberPriorityDurableDefault = "reporting-priority"
+
+	// SettingNatsDeviceauthStatusTopic is the config key for the nats topic
+	// of the deviceauth status change events; empty disables them
+	SettingNatsDeviceauthStatusTopic        = "nats_deviceauth_status_topic"
+	SettingNatsDeviceauthStatusTopicDefault = ""
+
+	// SettingNatsDeviceauthStatusDurable is the config key for the nats
+	// durable name of the deviceauth status change events subscriber
+	SettingNatsDeviceauthStatusDurable        = "nats_deviceauth_status_durable"
+	SettingNatsDeviceauthStatusDurableDefault = "reporting-deviceauth-status"
+
+	// SettingNatsMaxDeliver is the config key for the maximum number of
+	// deliveries of a message, before moving it to the dead letters
+	SettingNatsMaxDeliver        = "nats_max_deliver"
+	SettingNatsMaxDeliverDefault = 3
+
+	// SettingNatsMaxAckPending is the config key for the maximum number
+	// of messages delivered and not acknowledged yet
+	SettingNatsMaxAckPending        = "nats_max_ack_pending"
+	SettingNatsMaxAckPendingDefault = 1000
+
 	// SettingReindexBatchSize is the num of buffered requests processed together
 	SettingReindexBatchSize        = "reindex_batch_size"
 	SettingReindexBatchSizeDefault = 100
 
+	// SettingReindexClientsTimeoutMsec is the timeout of the requests to
+	// the deployments service while reindexing, in milliseconds
+	SettingReindexClientsTimeoutMsec        = "reindex_clients_timeout_msec"
+	SettingReindexClientsTimeoutMsecDefault = 60000
+
+	// SettingReindexBulkTargetLatencyMsec is the latency, in milliseconds,
+	// the bulk requests sent while reindexing are tuned to, 0 to send each
+	// batch of devices in a single request
+	SettingReindexBulkTargetLatencyMsec        = "reindex_bulk_target_latency_msec"
+	SettingReindexBulkTargetLatencyMsecDefault = 0
+
+	// SettingReindexMaxConcurrency is the maximum number of bulk requests
+	// sent at the same time while reindexing, when tuned to their latency
+	SettingReindexMaxConcurrency        = "reindex_max_concurrency"
+	SettingReindexMaxConcurrencyDefault = 4
+
 	// SettingWorkerConcurrency defines the number of concurrent worker
 	// threads that exist at the same time (defaults to 10)
 	SettingWorkerConcurrency        = "worker_concurrency"
 	SettingWorkerConcurrencyDefault = 10
 
+	// SettingShutdownDrainTimeoutMsec is the config key for the time the
+	// indexer waits, on shutdown, for the in-flight jobs to be processed
+	SettingShutdownDrainTimeoutMsec = "shutdown_drain_timeout_msec"
+	// SettingShutdownDrainTimeoutMsecDefault is the default value for the
+	// time the indexer waits for the in-flight jobs on shutdown
+	SettingShutdownDrainTimeoutMsecDefault = 20000
+
+	// SettingJobsQueueSize is the config key for the number of jobs the
+	// indexer buffers before it stops pulling messages from NATS
+	SettingJobsQueueSize = "jobs_queue_size"
+	// SettingJobsQueueSizeDefault is the default value for the number of
+	// jobs the indexer buffers before it stops pulling messages from NATS
+	SettingJobsQueueSizeDefault = 1000
+
+	// SettingJobsScheduler is the config key for the order the indexer
+	// processes the buffered jobs in: "fifo" or "round_robin"
+	SettingJobsScheduler = "jobs_scheduler"
+	// SettingJobsSchedulerDefault is the default value for the order the
+	// indexer processes the buffered jobs in
+	SettingJobsSchedulerDefault = "fifo"
+
+	// SettingJobsDedupWindowMsec is the config key for how long the indexer
+	// holds the reindex jobs to merge the identical ones received meanwhile;
+	// zero disables the deduplication
+	SettingJobsDedupWindowMsec = "jobs_dedup_window_msec"
+	// SettingJobsDedupWindowMsecDefault is the default value for how long
+	// the indexer holds the reindex jobs to merge the identical ones
+	SettingJobsDedupWindowMsecDefault = 0
+
+	// SettingBackpressureLatencyMsec is the config key for the average
+	// latency of the processing of a batch of jobs above which the indexer
+	// slows down the consumption of the jobs; zero disables the backpressure
+	SettingBackpressureLatencyMsec = "backpressure_latency_msec"
+	// SettingBackpressureLatencyMsecDefault is the default value for the
+	// latency above which the indexer slows down: disabled
+	SettingBackpressureLatencyMsecDefault = 0
+
+	// SettingBackpressureMaxDelayMsec is the config key for the maximum
+	// delay the backpressure pauses the consumption of the jobs for
+	SettingBackpressureMaxDelayMsec = "backpressure_max_delay_msec"
+	// SettingBackpressureMaxDelayMsecDefault is the default value for the
+	// maximum delay the backpressure pauses the consumption of the jobs for
+	SettingBackpressureMaxDelayMsecDefault = 5000
+
 	// SettingReindexTimeMsec is the max time after which reindexing is triggered
 	// (even if buffered requests didn't reach reindex_batch_size yet)
 	SettingReindexMaxTimeMsec        = "reindex_max_time_msec"
 	SettingReindexMaxTimeMsecDefault = 1000
 
+	// SettingOrphanSweepIntervalMsec is the config key for the interval at
+	// which the indexer removes the indexed devices which do not exist in
+	// deviceauth anymore; zero disables the sweeper
+	SettingOrphanSweepIntervalMsec = "orphan_sweep_interval_msec"
+	// SettingOrphanSweepIntervalMsecDefault is the default value for the
+	// interval of the orphan devices sweeper: disabled
+	SettingOrphanSweepIntervalMsecDefault = 0
+
+	// SettingReconcileIntervalMsec is the config key for the interval at
+	// which the indexer compares a sample of the indexed devices to
+	// inventory, reindexing the drifted ones; zero disables the reconciliation
+	SettingReconcileIntervalMsec = "reconcile_interval_msec"
+	// SettingReconcileIntervalMsecDefault is the default value for the
+	// interval of the reconciliation: disabled
+	SettingReconcileIntervalMsecDefault = 0
+
+	// SettingReconcileSampleSize is the config key for the number of
+	// devices of each tenant compared to inventory by the reconciliation
+	SettingReconcileSampleSize = "reconcile_sample_size"
+	// SettingReconcileSampleSizeDefault is the default value for the number
+	// of devices compared by the reconciliation
+	SettingReconcileSampleSizeDefault = 100
+
+	// SettingLeaderElectionTTLMsec is the config key for the TTL of the
+	// leadership of the indexers, held by the one indexer running the
+	// scheduled scans; zero disables the leader election
+	SettingLeaderElectionTTLMsec = "leader_election_ttl_msec"
+	// SettingLeaderElectionTTLMsecDefault is the default value for the TTL
+	// of the leadership of the indexers: disabled
+	SettingLeaderElectionTTLMsecDefault = 0
+
+	// SettingIndexerDryRun is the config key for the flag enabling the
+	// dry-run mode of the indexer, which validates and logs the documents
+	// instead of writing them
+	SettingIndexerDryRun = "indexer_dry_run"
+	// SettingIndexerDryRunDefault is the default value for the flag
+	// enabling the dry-run mode of the indexer
+	SettingIndexerDryRunDefault = false
+
+	// SettingDeploymentsRetentionDays is the config key for the number of
+	// days the finished deployments are kept for; zero keeps them forever
+	SettingDeploymentsRetentionDays = "deployments_retention_days"
+	// SettingDeploymentsRetentionDaysDefault is the default value for the
+	// retention of the deployments: forever
+	SettingDeploymentsRetentionDaysDefault = 0
+
+	// SettingDeploymentsRetentionIntervalMsec is the config key for the
+	// interval at which the indexer deletes the expired deployments
+	SettingDeploymentsRetentionIntervalMsec = "deployments_retention_interval_msec"
+	// SettingDeploymentsRetentionIntervalMsecDefault is the default value
+	// for the interval at which the expired deployments are deleted
+	SettingDeploymentsRetentionIntervalMsecDefault = 3600000
+
+	// SettingDecommissionedDevicesGraceDays is the config key for the
+	// number of days the deployments of the decommissioned devices are
+	// kept for; zero deletes them with the devices
+	SettingDecommissionedDevicesGraceDays = "decommissioned_devices_grace_days"
+	// SettingDecommissionedDevicesGraceDaysDefault is the default value for
+	// the grace period of the decommissioned devices: none
+	SettingDecommissionedDevicesGraceDaysDefault = 0
+
+	// SettingDecommissionedDevicesPurgeIntervalMsec is the config key for
+	// the interval at which the indexer purges the deployments of the
+	// decommissioned devices whose grace period is over
+	SettingDecommissionedDevicesPurgeIntervalMsec = "decommissioned_devices_purge_interval_msec"
+	// SettingDecommissionedDevicesPurgeIntervalMsecDefault is the default
+	// value for the interval at which the decommissioned devices are purged
+	SettingDecommissionedDevicesPurgeIntervalMsecDefault = 3600000
+
+	// SettingMetricsListen is the config key for the listen address of the
+	// indexer's metrics endpoint
+	SettingMetricsListen = "metrics_listen"
+	// SettingMetricsListenDefault is the default value for the listen address
+	// of the indexer's metrics endpoint
+	SettingMetricsListenDefault = ":8081"
+
+	// SettingTracingOTLPEndpoint is the config key for the host and port of
+	// the OTLP/HTTP collector the traces are exported to
+	SettingTracingOTLPEndpoint = "tracing_otlp_endpoint"
+	// SettingTracingOTLPEndpointDefault is the default value for the OTLP
+	// collector endpoint: empty, the traces are not exported
+	SettingTracingOTLPEndpointDefault = ""
+
+	// SettingTracingOTLPInsecure is the config key to disable TLS when
+	// connecting to the OTLP collector
+	SettingTracingOTLPInsecure = "tracing_otlp_insecure"
+	// SettingTracingOTLPInsecureDefault is the default value to disable TLS
+	// when connecting to the OTLP collector
+	SettingTracingOTLPInsecureDefault = false
+
+	// SettingTracingSampleRatio is the config key for the fraction of the
+	// traces which are sampled
+	SettingTracingSampleRatio = "tracing_sample_ratio"
+	// SettingTracingSampleRatioDefault is the default value for the fraction
+	// of the traces which are sampled
+	SettingTracingSampleRatioDefault = 1.0
+
+	// SettingReporterIntervalMsec is the config key for the interval at which
+	// the reporter checks for due reports
+	SettingReporterIntervalMsec = "reporter_interval_msec"
+	// SettingReporterIntervalMsecDefault is the default value for the interval
+	// at which the reporter checks for due reports
+	SettingReporterIntervalMsecDefault = 60000
+
+	// SettingReportDestination is the config key for the destination of the
+	// scheduled reports: s3 or webhook
+	SettingReportDestination = "report_destination"
+	// SettingReportDestinationDefault is the default value for the destination
+	// of the scheduled reports
+	SettingReportDestinationDefault = "s3"
+
+	// SettingReportS3Endpoint is the config key for the endpoint of the
+	// S3-compatible storage where the reports are uploaded
+	SettingReportS3Endpoint = "report_s3_endpoint"
+	// SettingReportS3EndpointDefault is the default value for the endpoint of
+	// the S3-compatible storage where the reports are uploaded
+	SettingReportS3EndpointDefault = "https://s3.amazonaws.com"
+
+	// SettingReportS3Region is the config key for the region of the bucket
+	// where the reports are uploaded
+	SettingReportS3Region = "report_s3_region"
+	// SettingReportS3RegionDefault is the default value for the region of the
+	// bucket where the reports are uploaded
+	SettingReportS3RegionDefault = "us-east-1"
+
+	// SettingReportS3Bucket is the config key for the bucket where the
+	// reports are uploaded
+	SettingReportS3Bucket = "report_s3_bucket"
+
+	// SettingReportS3Prefix is the config key for the prefix of the keys of
+	// the uploaded reports
+	SettingReportS3Prefix = "report_s3_prefix"
+	// SettingReportS3PrefixDefault is the default value for the prefix of the
+	// keys of the uploaded reports
+	SettingReportS3PrefixDefault = "reports/"
+
+	// SettingReportS3AccessKeyID is the config key for the access key ID
+	// used to upload the reports
+	SettingReportS3AccessKeyID = "report_s3_access_key_id"
+
+	// SettingReportS3SecretAccessKey is the config key for the secret access
+	// key used to upload the reports
+	SettingReportS3SecretAccessKey = "report_s3_secret_access_key"
+
+	// SettingReportWebhookURL is the config key for the URL of the webhook
+	// the reports are posted to
+	SettingReportWebhookURL = "report_webhook_url"
+
+	// SettingReportWebhookSecret is the config key for the secret used to
+	// sign the reports posted to the webhook
+	SettingReportWebhookSecret = "report_webhook_secret"
+
+	// SettingExportS3Bucket is the config key for the bucket where the
+	// Parquet exports of the devices are uploaded; the exports share the
+	// endpoint, region and credentials of the reports
+	SettingExportS3Bucket = "export_s3_bucket"
+
+	// SettingExportS3Prefix is the config key for the prefix of the keys of
+	// the uploaded exports
+	SettingExportS3Prefix = "export_s3_prefix"
+	// SettingExportS3PrefixDefault is the default value for the prefix of the
+	// keys of the uploaded exports
+	SettingExportS3PrefixDefault = "exports/"
+
+	// SettingAlerterIntervalMsec is the config key for the interval at which
+	// the alerter evaluates the alerts
+	SettingAlerterIntervalMsec = "alerter_interval_msec"
+	// SettingAlerterIntervalMsecDefault is the default value for the interval
+	// at which the alerter evaluates the alerts
+	SettingAlerterIntervalMsecDefault = 60000
+
 	// SettingDebugLog is the config key for the truning on the debug log
 	SettingDebugLog = "debug_log"
 	// SettingDebugLogDefault is the default value for the debug log enabling
@@ -157,31 +949,167 @@ var (
 	// Defaults are the default configuration settings
 	Defaults = []config.Default{
 		{Key: SettingListen, Value: SettingListenDefault},
+		{Key: SettingGRPCListen, Value: SettingGRPCListenDefault},
+		{Key: SettingAggregationsMaxDepth, Value: SettingAggregationsMaxDepthDefault},
+		{Key: SettingQueryMaxClauses, Value: SettingQueryMaxClausesDefault},
+		{Key: SettingQueryMaxAggregationBuckets,
+			Value: SettingQueryMaxAggregationBucketsDefault},
+		{Key: SettingQueryMaxPerPage, Value: SettingQueryMaxPerPageDefault},
+		{Key: SettingQueryMaxPatternLength, Value: SettingQueryMaxPatternLengthDefault},
+		{Key: SettingConnectivityWindowMinutes,
+			Value: SettingConnectivityWindowMinutesDefault},
+		{Key: SettingSearchStreamIntervalMsec,
+			Value: SettingSearchStreamIntervalMsecDefault},
+		{Key: SettingRateLimitTenantRPS, Value: SettingRateLimitTenantRPSDefault},
+		{Key: SettingRateLimitTenantBurst, Value: SettingRateLimitTenantBurstDefault},
+		{Key: SettingGraphQLEnable, Value: SettingGraphQLEnableDefault},
+		{Key: SettingCompressionEnable, Value: SettingCompressionEnableDefault},
+		{Key: SettingCompressionMinSize, Value: SettingCompressionMinSizeDefault},
+		{Key: SettingRequestValidationEnable,
+			Value: SettingRequestValidationEnableDefault},
+		{Key: SettingAuditLogEnable, Value: SettingAuditLogEnableDefault},
+		{Key: SettingAuditLogRetentionDays, Value: SettingAuditLogRetentionDaysDefault},
+		{Key: SettingSearchJobsTTLMinutes, Value: SettingSearchJobsTTLMinutesDefault},
+		{Key: SettingSearchJobsConcurrency, Value: SettingSearchJobsConcurrencyDefault},
+		{Key: SettingUsageFlushIntervalMsec, Value: SettingUsageFlushIntervalMsecDefault},
+		{Key: SettingUsageRefreshIntervalMsec, Value: SettingUsageRefreshIntervalMsecDefault},
+		{Key: SettingCacheTTLMsec, Value: SettingCacheTTLMsecDefault},
+		{Key: SettingCacheSize, Value: SettingCacheSizeDefault},
+		{Key: SettingCacheInvalidationSubject,
+			Value: SettingCacheInvalidationSubjectDefault},
+		{Key: SettingStorageBackend, Value: SettingStorageBackendDefault},
 		{Key: SettingOpenSearchAddresses, Value: SettingOpenSearchAddressesDefault},
+		{Key: SettingOpenSearchReadHealthCheckMsec,
+			Value: SettingOpenSearchReadHealthCheckMsecDefault},
 		{Key: SettingOpenSearchDevicesIndexName,
 			Value: SettingOpenSearchDevicesIndexNameDefault},
 		{Key: SettingOpenSearchDevicesIndexShards,
 			Value: SettingOpenSearchDevicesIndexShardsDefault},
 		{Key: SettingOpenSearchDevicesIndexReplicas,
 			Value: SettingOpenSearchDevicesIndexReplicasDefault},
+		{Key: SettingOpenSearchDevicesIndexRefreshInterval,
+			Value: SettingOpenSearchDevicesIndexRefreshIntervalDefault},
 		{Key: SettingOpenSearchDeploymentsIndexName,
 			Value: SettingOpenSearchDeploymentsIndexNameDefault},
 		{Key: SettingOpenSearchDeploymentsIndexShards,
 			Value: SettingOpenSearchDeploymentsIndexShardsDefault},
 		{Key: SettingOpenSearchDeploymentsIndexReplicas,
 			Value: SettingOpenSearchDeploymentsIndexReplicasDefault},
+		{Key: SettingOpenSearchDeploymentsIndexRefreshInterval,
+			Value: SettingOpenSearchDeploymentsIndexRefreshIntervalDefault},
+		{Key: SettingOpenSearchSoftwareIndexName,
+			Value: SettingOpenSearchSoftwareIndexNameDefault},
+		{Key: SettingOpenSearchSoftwareIndexShards,
+			Value: SettingOpenSearchSoftwareIndexShardsDefault},
+		{Key: SettingOpenSearchSoftwareIndexReplicas,
+			Value: SettingOpenSearchSoftwareIndexReplicasDefault},
+		{Key: SettingOpenSearchSoftwareIndexRefreshInterval,
+			Value: SettingOpenSearchSoftwareIndexRefreshIntervalDefault},
+		{Key: SettingOpenSearchIndexStrategy, Value: SettingOpenSearchIndexStrategyDefault},
+		{Key: SettingOpenSearchIndexGroups, Value: SettingOpenSearchIndexGroupsDefault},
+		{Key: SettingOpenSearchDeploymentsRollover,
+			Value: SettingOpenSearchDeploymentsRolloverDefault},
+		{Key: SettingOpenSearchBulkMaxRetries,
+			Value: SettingOpenSearchBulkMaxRetriesDefault},
+		{Key: SettingOpenSearchBulkRetryBackoffMsec,
+			Value: SettingOpenSearchBulkRetryBackoffMsecDefault},
+		{Key: SettingOpenSearchSlowQueryMsec,
+			Value: SettingOpenSearchSlowQueryMsecDefault},
+		{Key: SettingOpenSearchMaxConcurrentQueries,
+			Value: SettingOpenSearchMaxConcurrentQueriesDefault},
+		{Key: SettingOpenSearchQueriesQueueTimeoutMsec,
+			Value: SettingOpenSearchQueriesQueueTimeoutMsecDefault},
+		{Key: SettingOpenSearchAuth, Value: SettingOpenSearchAuthDefault},
+		{Key: SettingOpenSearchAWSService, Value: SettingOpenSearchAWSServiceDefault},
+		{Key: SettingOpenSearchDistribution, Value: SettingOpenSearchDistributionDefault},
 		{Key: SettingDebugLog, Value: SettingDebugLogDefault},
+		{Key: SettingLocationLatitudeAttribute,
+			Value: SettingLocationLatitudeAttributeDefault},
+		{Key: SettingLocationLongitudeAttribute,
+			Value: SettingLocationLongitudeAttributeDefault},
+		{Key: SettingIndexingSoftwareInventory,
+			Value: SettingIndexingSoftwareInventoryDefault},
+		{Key: SettingIndexingEnrichmentStages,
+			Value: SettingIndexingEnrichmentStagesDefault},
+		{Key: SettingEnrichmentWebhookURL, Value: SettingEnrichmentWebhookURLDefault},
+		{Key: SettingEnrichmentWebhookToken, Value: SettingEnrichmentWebhookTokenDefault},
+		{Key: SettingEnrichmentWebhookTimeoutMsec,
+			Value: SettingEnrichmentWebhookTimeoutMsecDefault},
 		{Key: SettingDeploymentsAddr, Value: SettingDeploymentsAddrDefault},
+		{Key: SettingDeploymentsRetryMaxAttempts,
+			Value: SettingDeploymentsRetryMaxAttemptsDefault},
+		{Key: SettingDeploymentsRetryBackoffMsec,
+			Value: SettingDeploymentsRetryBackoffMsecDefault},
+		{Key: SettingDeploymentsRetryMaxBackoffMsec,
+			Value: SettingDeploymentsRetryMaxBackoffMsecDefault},
+		{Key: SettingCircuitBreakerFailureThreshold,
+			Value: SettingCircuitBreakerFailureThresholdDefault},
+		{Key: SettingCircuitBreakerOpenTimeoutMsec,
+			Value: SettingCircuitBreakerOpenTimeoutMsecDefault},
 		{Key: SettingDeviceAuthAddr, Value: SettingDeviceAuthAddrDefault},
 		{Key: SettingInventoryAddr, Value: SettingInventoryAddrDefault},
+		{Key: SettingTenantadmAddr, Value: SettingTenantadmAddrDefault},
+		{Key: SettingClientsTimeoutMsec, Value: SettingClientsTimeoutMsecDefault},
+		{Key: SettingClientsMaxIdleConnsPerHost,
+			Value: SettingClientsMaxIdleConnsPerHostDefault},
+		{Key: SettingClientsIdleConnTimeoutMsec,
+			Value: SettingClientsIdleConnTimeoutMsecDefault},
 		{Key: SettingMongo, Value: SettingMongoDefault},
 		{Key: SettingDbName, Value: SettingDbNameDefault},
+		{Key: SettingEventSource, Value: SettingEventSourceDefault},
+		{Key: SettingKafkaBrokers, Value: SettingKafkaBrokersDefault},
 		{Key: SettingNatsURI, Value: SettingNatsURIDefault},
 		{Key: SettingNatsStreamName, Value: SettingNatsStreamNameDefault},
 		{Key: SettingNatsSubscriberTopic, Value: SettingNatsSubscriberTopicDefault},
 		{Key: SettingNatsSubscriberDurable, Value: SettingNatsSubscriberDurableDefault},
+		{Key: SettingNatsSubscriberPriorityTopic,
+			Value: SettingNatsSubscriberPriorityTopicDefault},
+		{Key: SettingNatsSubscriberPriorityDurable,
+			Value: SettingNatsSubscriberPriorityDurableDefault},
+		{Key: SettingNatsDeviceauthStatusTopic, Value: SettingNatsDeviceauthStatusTopicDefault},
+		{Key: SettingNatsDeviceauthStatusDurable,
+			Value: SettingNatsDeviceauthStatusDurableDefault},
+		{Key: SettingNatsMaxDeliver, Value: SettingNatsMaxDeliverDefault},
+		{Key: SettingNatsMaxAckPending, Value: SettingNatsMaxAckPendingDefault},
 		{Key: SettingReindexMaxTimeMsec, Value: SettingReindexMaxTimeMsecDefault},
 		{Key: SettingReindexBatchSize, Value: SettingReindexBatchSizeDefault},
+		{Key: SettingReindexClientsTimeoutMsec,
+			Value: SettingReindexClientsTimeoutMsecDefault},
+		{Key: SettingReindexBulkTargetLatencyMsec,
+			Value: SettingReindexBulkTargetLatencyMsecDefault},
+		{Key: SettingReindexMaxConcurrency,
+			Value: SettingReindexMaxConcurrencyDefault},
+		{Key: SettingOrphanSweepIntervalMsec, Value: SettingOrphanSweepIntervalMsecDefault},
+		{Key: SettingReconcileIntervalMsec, Value: SettingReconcileIntervalMsecDefault},
+		{Key: SettingReconcileSampleSize, Value: SettingReconcileSampleSizeDefault},
+		{Key: SettingLeaderElectionTTLMsec, Value: SettingLeaderElectionTTLMsecDefault},
+		{Key: SettingIndexerDryRun, Value: SettingIndexerDryRunDefault},
+		{Key: SettingDeploymentsRetentionDays, Value: SettingDeploymentsRetentionDaysDefault},
+		{Key: SettingDeploymentsRetentionIntervalMsec,
+			Value: SettingDeploymentsRetentionIntervalMsecDefault},
+		{Key: SettingDecommissionedDevicesGraceDays,
+			Value: SettingDecommissionedDevicesGraceDaysDefault},
+		{Key: SettingDecommissionedDevicesPurgeIntervalMsec,
+			Value: SettingDecommissionedDevicesPurgeIntervalMsecDefault},
 		{Key: SettingWorkerConcurrency, Value: SettingWorkerConcurrencyDefault},
+		{Key: SettingShutdownDrainTimeoutMsec, Value: SettingShutdownDrainTimeoutMsecDefault},
+		{Key: SettingJobsQueueSize, Value: SettingJobsQueueSizeDefault},
+		{Key: SettingJobsScheduler, Value: SettingJobsSchedulerDefault},
+		{Key: SettingJobsDedupWindowMsec, Value: SettingJobsDedupWindowMsecDefault},
+		{Key: SettingBackpressureLatencyMsec,
+			Value: SettingBackpressureLatencyMsecDefault},
+		{Key: SettingBackpressureMaxDelayMsec,
+			Value: SettingBackpressureMaxDelayMsecDefault},
+		{Key: SettingMetricsListen, Value: SettingMetricsListenDefault},
+		{Key: SettingTracingOTLPEndpoint, Value: SettingTracingOTLPEndpointDefault},
+		{Key: SettingTracingOTLPInsecure, Value: SettingTracingOTLPInsecureDefault},
+		{Key: SettingTracingSampleRatio, Value: SettingTracingSampleRatioDefault},
+		{Key: SettingReporterIntervalMsec, Value: SettingReporterIntervalMsecDefault},
+		{Key: SettingReportDestination, Value: SettingReportDestinationDefault},
+		{Key: SettingReportS3Endpoint, Value: SettingReportS3EndpointDefault},
+		{Key: SettingReportS3Region, Value: SettingReportS3RegionDefault},
+		{Key: SettingReportS3Prefix, Value: SettingReportS3PrefixDefault},
+		{Key: SettingExportS3Prefix, Value: SettingExportS3PrefixDefault},
+		{Key: SettingAlerterIntervalMsec, Value: SettingAlerterIntervalMsecDefault},
 	}
 )
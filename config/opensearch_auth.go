@@ -0,0 +1,66 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package config
+
+import (
+	"context"
+
+	"github.com/opensearch-project/opensearch-go/signer"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+
+	"github.com/mendersoftware/reporting/client/sigv4"
+)
+
+// OpenSearchSigner returns the signer of the requests to OpenSearch, or nil
+// if the requests are not signed
+func OpenSearchSigner(ctx context.Context, conf config.Reader) (signer.Signer, error) {
+	switch auth := conf.GetString(SettingOpenSearchAuth); auth {
+	case "":
+		return nil, nil
+	case OpenSearchAuthAWSSigV4:
+	default:
+		return nil, errors.Errorf("unknown OpenSearch authentication: %q", auth)
+	}
+
+	region := conf.GetString(SettingOpenSearchAWSRegion)
+	if region == "" {
+		return nil, errors.Errorf("%s is required with the %s authentication",
+			SettingOpenSearchAWSRegion, OpenSearchAuthAWSSigV4)
+	}
+	service := conf.GetString(SettingOpenSearchAWSService)
+	switch service {
+	case sigv4.ServiceOpenSearch, sigv4.ServiceOpenSearchServerless:
+	default:
+		return nil, errors.Errorf("unknown AWS service: %q", service)
+	}
+
+	var credentials sigv4.CredentialsProvider
+	if accessKeyID := conf.GetString(SettingOpenSearchAWSAccessKeyID); accessKeyID != "" {
+		credentials = sigv4.Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: conf.GetString(SettingOpenSearchAWSSecretAccessKey),
+			SessionToken:    conf.GetString(SettingOpenSearchAWSSessionToken),
+		}
+	} else {
+		var err error
+		credentials, err = sigv4.NewDefaultCredentialsProvider(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sigv4.NewSigner(credentials, region, service), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+)
+
+// defaultOpenSearchCluster is the name of the cluster of the
+// opensearch_addresses, which the tenants are routed to by default
+const defaultOpenSearchCluster = "default"
+
+// OpenSearchClusters returns the addresses of the additional OpenSearch
+// clusters, by name, configured as name=address entries; the addresses of
+// the same cluster repeat its name
+func OpenSearchClusters(conf config.Reader) (map[string][]string, error) {
+	clusters := map[string][]string{}
+	for _, entry := range conf.GetStringSlice(SettingOpenSearchClusters) {
+		name, address, err := parseEntry(SettingOpenSearchClusters, entry)
+		if err != nil {
+			return nil, err
+		}
+		if name == defaultOpenSearchCluster {
+			return nil, errors.Errorf("%s: the %q cluster is the one of %s",
+				SettingOpenSearchClusters, defaultOpenSearchCluster,
+				SettingOpenSearchAddresses)
+		}
+		clusters[name] = append(clusters[name], address)
+	}
+	return clusters, nil
+}
+
+// TenantClusters returns the names of the clusters of the tenants, by
+// tenant ID, configured as tenant=cluster entries; the clusters must be
+// configured
+func TenantClusters(conf config.Reader) (map[string]string, error) {
+	clusters, err := OpenSearchClusters(conf)
+	if err != nil {
+		return nil, err
+	}
+	tenantClusters := map[string]string{}
+	for _, entry := range conf.GetStringSlice(SettingOpenSearchTenantClusters) {
+		tenantID, cluster, err := parseEntry(SettingOpenSearchTenantClusters, entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := clusters[cluster]; !ok && cluster != defaultOpenSearchCluster {
+			return nil, errors.Errorf("%s: unknown cluster %q of the tenant %s",
+				SettingOpenSearchTenantClusters, cluster, tenantID)
+		}
+		tenantClusters[tenantID] = cluster
+	}
+	return tenantClusters, nil
+}
+
+func parseEntry(key, entry string) (string, string, error) {
+	parts := strings.SplitN(entry, "=", 2)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Errorf("%s: invalid entry %q: expected key=value", key, entry)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/config"
+
+	"github.com/mendersoftware/reporting/utils"
+)
+
+// OpenSearchTLSConfig returns the TLS configuration of the connections to
+// OpenSearch, or nil if not configured
+func OpenSearchTLSConfig(conf config.Reader) (*tls.Config, error) {
+	tlsConfig, err := utils.TLSOptions{
+		CAFile:     conf.GetString(SettingOpenSearchTLSCAFile),
+		CertFile:   conf.GetString(SettingOpenSearchTLSCertFile),
+		KeyFile:    conf.GetString(SettingOpenSearchTLSKeyFile),
+		MinVersion: conf.GetString(SettingOpenSearchTLSMinVersion),
+	}.TLSConfig()
+	return tlsConfig, errors.Wrap(err, "invalid OpenSearch TLS configuration")
+}
+
+// ClientsTLSConfig returns the TLS configuration of the connections to the
+// downstream services, or nil if not configured
+func ClientsTLSConfig(conf config.Reader) (*tls.Config, error) {
+	tlsConfig, err := utils.TLSOptions{
+		CAFile:     conf.GetString(SettingClientsTLSCAFile),
+		CertFile:   conf.GetString(SettingClientsTLSCertFile),
+		KeyFile:    conf.GetString(SettingClientsTLSKeyFile),
+		MinVersion: conf.GetString(SettingClientsTLSMinVersion),
+	}.TLSConfig()
+	return tlsConfig, errors.Wrap(err, "invalid downstream services TLS configuration")
+}
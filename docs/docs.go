@@ -0,0 +1,31 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package docs embeds the OpenAPI specifications of the APIs of the
+// service, to validate the requests against them and to serve them
+package docs
+
+import (
+	_ "embed"
+)
+
+// ManagementAPI is the OpenAPI specification of the management API
+//
+//go:embed management_api.yml
+var ManagementAPI []byte
+
+// InternalAPI is the OpenAPI specification of the internal API
+//
+//go:embed internal_api.yml
+var InternalAPI []byte
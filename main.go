@@ -17,6 +17,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"strings"
@@ -29,18 +30,30 @@ import (
 	"github.com/mendersoftware/go-lib-micro/log"
 	mlog "github.com/mendersoftware/go-lib-micro/log"
 
+	"github.com/mendersoftware/reporting/app/alerter"
 	"github.com/mendersoftware/reporting/app/indexer"
+	"github.com/mendersoftware/reporting/app/reporter"
 	"github.com/mendersoftware/reporting/app/server"
+	"github.com/mendersoftware/reporting/client/kafka"
 	"github.com/mendersoftware/reporting/client/nats"
+	"github.com/mendersoftware/reporting/client/tenantadm"
 	dconfig "github.com/mendersoftware/reporting/config"
+	"github.com/mendersoftware/reporting/mapping"
+	"github.com/mendersoftware/reporting/model"
 	"github.com/mendersoftware/reporting/store"
+	"github.com/mendersoftware/reporting/store/cache"
+	"github.com/mendersoftware/reporting/store/dryrun"
+	"github.com/mendersoftware/reporting/store/limiter"
 	"github.com/mendersoftware/reporting/store/mongo"
 	"github.com/mendersoftware/reporting/store/opensearch"
+	"github.com/mendersoftware/reporting/store/residency"
+	"github.com/mendersoftware/reporting/tracing"
 )
 
 const (
 	opensearchMaxWaitingTime      = 300
 	opensearchRetryDelayInSeconds = 1
+	tracingShutdownTimeout        = 5 * time.Second
 )
 
 func main() {
@@ -49,6 +62,7 @@ func main() {
 
 func doMain(args []string) int {
 	var configPath string
+	var shutdownTracing tracing.ShutdownFunc
 
 	app := &cli.App{
 		Flags: []cli.Flag{
@@ -70,6 +84,11 @@ func doMain(args []string) int {
 						Name:  "automigrate",
 						Usage: "Run database migrations before starting.",
 					},
+					&cli.BoolFlag{
+						Name: "skip-bootstrap",
+						Usage: "Do not create or verify the index templates, " +
+							"aliases and ISM policies before starting.",
+					},
 				},
 			},
 			{
@@ -81,12 +100,87 @@ func doMain(args []string) int {
 						Name:  "automigrate",
 						Usage: "Run database migrations before starting.",
 					},
+					&cli.BoolFlag{
+						Name: "skip-bootstrap",
+						Usage: "Do not create or verify the index templates, " +
+							"aliases and ISM policies before starting.",
+					},
+				},
+			},
+			{
+				Name:   "reporter",
+				Usage:  "Run the scheduled reports generation process",
+				Action: cmdReporter,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "automigrate",
+						Usage: "Run database migrations before starting.",
+					},
+					&cli.BoolFlag{
+						Name: "skip-bootstrap",
+						Usage: "Do not create or verify the index templates, " +
+							"aliases and ISM policies before starting.",
+					},
+				},
+			},
+			{
+				Name:   "alerter",
+				Usage:  "Run the alerts evaluation process",
+				Action: cmdAlerter,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "automigrate",
+						Usage: "Run database migrations before starting.",
+					},
+					&cli.BoolFlag{
+						Name: "skip-bootstrap",
+						Usage: "Do not create or verify the index templates, " +
+							"aliases and ISM policies before starting.",
+					},
 				},
 			},
 			{
 				Name:   "migrate",
 				Usage:  "Run the migrations",
 				Action: cmdMigrate,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name: "dry-run",
+						Usage: "Show the changes to the index templates, indices, " +
+							"aliases, mappings and ISM policies, without applying them.",
+					},
+					&cli.BoolFlag{
+						Name:  "apply",
+						Usage: "Show the changes, then apply them.",
+					},
+				},
+			},
+			{
+				Name:   "reindex",
+				Usage:  "Rebuild the devices index of a tenant",
+				Action: cmdReindex,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "tenant",
+						Usage:    "ID of the tenant to rebuild the devices index of.",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name: "restart",
+						Usage: "Restart the rebuild from scratch, instead of " +
+							"resuming an interrupted one.",
+					},
+					&cli.BoolFlag{
+						Name: "catch-up",
+						Usage: "Index only the devices updated since the last " +
+							"time the index was in sync, instead of rebuilding it.",
+					},
+					&cli.StringFlag{
+						Name: "since",
+						Usage: "With --catch-up, index the devices updated since " +
+							"the given time (RFC3339).",
+					},
+				},
 			},
 		},
 	}
@@ -109,8 +203,33 @@ func doMain(args []string) int {
 		// setup logging
 		mlog.Setup(config.Config.GetBool(dconfig.SettingDebugLog))
 
+		// setup tracing
+		process := args.Args().First()
+		if process == "" {
+			process = "server"
+		}
+		shutdownTracing, err = tracing.Setup(context.Background(), tracing.Config{
+			Endpoint:    config.Config.GetString(dconfig.SettingTracingOTLPEndpoint),
+			Insecure:    config.Config.GetBool(dconfig.SettingTracingOTLPInsecure),
+			SampleRatio: config.Config.GetFloat64(dconfig.SettingTracingSampleRatio),
+			Process:     process,
+		})
+		if err != nil {
+			return cli.NewExitError(
+				fmt.Sprintf("error setting up tracing: %s", err),
+				1)
+		}
+
 		return nil
 	}
+	app.After = func(args *cli.Context) error {
+		if shutdownTracing == nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		return shutdownTracing(ctx)
+	}
 
 	err := app.Run(args)
 	if err != nil {
@@ -131,28 +250,73 @@ func cmdServer(args *cli.Context) error {
 		return err
 	}
 	defer ds.Close(ctx)
+	nats, err := getEventsClient(nil)
+	if err != nil {
+		return err
+	}
+	defer nats.Close()
 	if args.Bool("automigrate") {
-		nats, err := getNatsClient()
+		err = migrate(ctx, store, ds, nats)
 		if err != nil {
 			return err
 		}
-		ctx := context.Background()
-		err = migrate(ctx, store, ds, nats)
-		nats.Close()
-		if err != nil {
+	} else if !args.Bool("skip-bootstrap") {
+		if err := bootstrap(ctx, store); err != nil {
 			return err
 		}
 	}
-	return server.InitAndRun(config.Config, store, ds)
+	if max := config.Config.GetInt(dconfig.SettingOpenSearchMaxConcurrentQueries); max > 0 {
+		store = limiter.NewStore(store, max, time.Duration(config.Config.GetInt(
+			dconfig.SettingOpenSearchQueriesQueueTimeoutMsec))*time.Millisecond)
+	}
+	if ttl := config.Config.GetInt(dconfig.SettingCacheTTLMsec); ttl > 0 {
+		lru := cache.NewLRU(config.Config.GetInt(dconfig.SettingCacheSize),
+			time.Duration(ttl)*time.Millisecond)
+		unsubscribe, err := cache.SubscribeInvalidations(ctx, nats,
+			config.Config.GetString(dconfig.SettingCacheInvalidationSubject), lru)
+		if err != nil {
+			return errors.Wrap(err, "failed to subscribe to the cache invalidations")
+		}
+		defer unsubscribe() //nolint:errcheck
+		store = cache.NewStore(store, lru)
+	}
+	return server.InitAndRun(config.Config, store, ds, nats)
 }
 
-func getNatsClient() (nats.Client, error) {
-	natsURI := config.Config.GetString(dconfig.SettingNatsURI)
-	nats, err := nats.NewClient(natsURI)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to nats")
+// getEventsClient returns the client of the configured event source; the
+// messages the indexer fails to process are passed to deadLetterFunc, if any
+func getEventsClient(deadLetterFunc nats.DeadLetterFunc) (nats.Client, error) {
+	maxDeliver := config.Config.GetInt(dconfig.SettingNatsMaxDeliver)
+	switch source := config.Config.GetString(dconfig.SettingEventSource); source {
+	case dconfig.EventSourceNats:
+		natsURI := config.Config.GetString(dconfig.SettingNatsURI)
+		opts := []nats.ClientOption{
+			nats.WithMaxDeliver(maxDeliver),
+			nats.WithMaxAckPending(config.Config.GetInt(dconfig.SettingNatsMaxAckPending)),
+		}
+		if deadLetterFunc != nil {
+			opts = append(opts, nats.WithDeadLetterFunc(deadLetterFunc))
+		}
+		nats, err := nats.NewClient(natsURI, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to nats")
+		}
+		return nats, nil
+	case dconfig.EventSourceKafka:
+		brokers := config.Config.GetStringSlice(dconfig.SettingKafkaBrokers)
+		opts := []kafka.ClientOption{kafka.WithMaxDeliver(maxDeliver)}
+		if deadLetterFunc != nil {
+			opts = append(opts, kafka.WithDeadLetterFunc(deadLetterFunc))
+		}
+		log.FromContext(context.Background()).Info("using Kafka as event source")
+		client, err := kafka.NewClient(brokers, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to kafka")
+		}
+		return client, nil
+	default:
+		return nil, errors.Errorf("unknown event source: %q", source)
 	}
-	return nats, nil
 }
 
 func cmdIndexer(args *cli.Context) error {
@@ -160,11 +324,45 @@ func cmdIndexer(args *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	nats, err := getNatsClient()
+	// in dry-run mode nothing is written, not even the index templates
+	dryRun := config.Config.GetBool(dconfig.SettingIndexerDryRun)
+	if dryRun {
+		store = dryrun.NewStore(store)
+	}
+	ctx := context.Background()
+	ds, err := getDatastore(args)
+	if err != nil {
+		return err
+	}
+	defer ds.Close(ctx)
+	// the messages the indexer fails to process are kept in the datastore
+	nats, err := getEventsClient(ds.InsertDeadLetter)
 	if err != nil {
 		return err
 	}
 	defer nats.Close()
+	if args.Bool("automigrate") {
+		err = migrate(ctx, store, ds, nats)
+		if err != nil {
+			return err
+		}
+	} else if !args.Bool("skip-bootstrap") {
+		if err := bootstrap(ctx, store); err != nil {
+			return err
+		}
+	}
+	if !dryRun && config.Config.GetInt(dconfig.SettingCacheTTLMsec) > 0 {
+		store = cache.NewStore(store, cache.NewInvalidationPublisher(nats,
+			config.Config.GetString(dconfig.SettingCacheInvalidationSubject)))
+	}
+	return indexer.InitAndRun(config.Config, store, ds, nats)
+}
+
+func cmdReporter(args *cli.Context) error {
+	store, err := getStore(args)
+	if err != nil {
+		return err
+	}
 	ctx := context.Background()
 	ds, err := getDatastore(args)
 	if err != nil {
@@ -172,17 +370,57 @@ func cmdIndexer(args *cli.Context) error {
 	}
 	defer ds.Close(ctx)
 	if args.Bool("automigrate") {
+		nats, err := getEventsClient(nil)
+		if err != nil {
+			return err
+		}
 		err = migrate(ctx, store, ds, nats)
+		nats.Close()
 		if err != nil {
 			return err
 		}
+	} else if !args.Bool("skip-bootstrap") {
+		if err := bootstrap(ctx, store); err != nil {
+			return err
+		}
+	}
+	return reporter.InitAndRun(config.Config, store, ds)
+}
 
+func cmdAlerter(args *cli.Context) error {
+	store, err := getStore(args)
+	if err != nil {
+		return err
 	}
-	return indexer.InitAndRun(config.Config, store, ds, nats)
+	ctx := context.Background()
+	ds, err := getDatastore(args)
+	if err != nil {
+		return err
+	}
+	defer ds.Close(ctx)
+	if args.Bool("automigrate") {
+		nats, err := getEventsClient(nil)
+		if err != nil {
+			return err
+		}
+		err = migrate(ctx, store, ds, nats)
+		nats.Close()
+		if err != nil {
+			return err
+		}
+	} else if !args.Bool("skip-bootstrap") {
+		if err := bootstrap(ctx, store); err != nil {
+			return err
+		}
+	}
+	return alerter.InitAndRun(config.Config, store, ds)
 }
 
 func cmdMigrate(args *cli.Context) error {
 	ctx := context.Background()
+	if args.Bool("dry-run") && args.Bool("apply") {
+		return errors.New("--dry-run and --apply are mutually exclusive")
+	}
 	store, err := getStore(args)
 	if err != nil {
 		return err
@@ -191,11 +429,109 @@ func cmdMigrate(args *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	nats, err := getNatsClient()
+	if args.Bool("dry-run") || args.Bool("apply") {
+		changes, err := store.PlanMigrations(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to plan the migrations")
+		}
+		remaps, err := mapping.PlanRemaps(ctx, ds)
+		if err != nil {
+			return errors.Wrap(err, "failed to plan the migrations")
+		}
+		printMigrationPlan(os.Stdout, append(changes, remaps...))
+		if args.Bool("dry-run") {
+			return nil
+		}
+	}
+	nats, err := getEventsClient(nil)
+	if err != nil {
+		return err
+	}
+	err = migrate(ctx, store, ds, nats)
+	if err != nil {
+		return err
+	}
+	err = store.MigrateMappings(ctx)
+	if err != nil {
+		return err
+	}
+	// the indices have the fields of the declared types once migrated
+	return mapping.RemapAttributes(ctx, store, ds)
+}
+
+func cmdReindex(args *cli.Context) error {
+	store, err := getStore(args)
+	if err != nil {
+		return err
+	}
+	ds, err := getDatastore(args)
 	if err != nil {
 		return err
 	}
-	return migrate(ctx, store, ds, nats)
+	defer ds.Close(context.Background())
+	if config.Config.GetBool(dconfig.SettingIndexerDryRun) {
+		store = dryrun.NewStore(store)
+	} else if config.Config.GetInt(dconfig.SettingCacheTTLMsec) > 0 {
+		nats, err := getEventsClient(nil)
+		if err != nil {
+			return err
+		}
+		defer nats.Close()
+		store = cache.NewStore(store, cache.NewInvalidationPublisher(nats,
+			config.Config.GetString(dconfig.SettingCacheInvalidationSubject)))
+	}
+	opts := indexer.ReindexOptions{
+		Restart: args.Bool("restart"),
+		CatchUp: args.Bool("catch-up"),
+	}
+	if since := args.String("since"); since != "" {
+		if !opts.CatchUp {
+			return errors.New("--since requires --catch-up")
+		}
+		opts.CatchUpSince, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return errors.Wrap(err, "invalid --since")
+		}
+	}
+	if opts.CatchUp && opts.Restart {
+		return errors.New("--catch-up and --restart are mutually exclusive")
+	}
+	return indexer.Reindex(config.Config, store, ds, args.String("tenant"), opts)
+}
+
+// printMigrationPlan prints the changes of the migrations, one per line,
+// followed by their details
+func printMigrationPlan(w io.Writer, changes []model.MigrationChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes: the store is up to date.")
+		return
+	}
+	breaking := 0
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s: %s", change.Kind, change.Name, change.Action)
+		if change.Cluster != "" {
+			line = "[" + change.Cluster + "] " + line
+		}
+		if change.Breaking {
+			line += " (breaking)"
+			breaking++
+		}
+		fmt.Fprintln(w, line)
+		for _, detail := range change.Details {
+			fmt.Fprintln(w, "    "+detail)
+		}
+	}
+	fmt.Fprintf(w, "%d changes, %d breaking.\n", len(changes), breaking)
+}
+
+// bootstrap creates, or verifies, the index templates, the aliases and
+// the ISM policies of the store, for the fresh environments to come up
+// without preparing the cluster; it is idempotent
+func bootstrap(ctx context.Context, store store.Store) error {
+	if err := store.Migrate(ctx); err != nil {
+		return errors.Wrap(err, "failed to bootstrap the store")
+	}
+	return nil
 }
 
 func migrate(ctx context.Context, store store.Store, ds store.DataStore, nats nats.Client) error {
@@ -215,6 +551,19 @@ func migrate(ctx context.Context, store store.Store, ds store.DataStore, nats na
 }
 
 func getStore(args *cli.Context) (store.Store, error) {
+	switch backend := config.Config.GetString(dconfig.SettingStorageBackend); backend {
+	case dconfig.StorageBackendOpenSearch:
+	case dconfig.StorageBackendMongoDB:
+		db, err := getMongoStore(args)
+		if err != nil {
+			return nil, err
+		}
+		log.FromContext(context.Background()).Info("using MongoDB as storage backend")
+		return mongo.NewSearchStore(db), nil
+	default:
+		return nil, errors.Errorf("unknown storage backend: %q", backend)
+	}
+
 	addresses := config.Config.GetStringSlice(dconfig.SettingOpenSearchAddresses)
 	devicesIndexName := config.Config.GetString(dconfig.SettingOpenSearchDevicesIndexName)
 	devicesIndexShards := config.Config.GetInt(dconfig.SettingOpenSearchDevicesIndexShards)
@@ -224,18 +573,75 @@ func getStore(args *cli.Context) (store.Store, error) {
 	deploymentsIndexShards := config.Config.GetInt(dconfig.SettingOpenSearchDeploymentsIndexShards)
 	deploymentsIndexReplicas := config.Config.GetInt(
 		dconfig.SettingOpenSearchDeploymentsIndexReplicas)
-	store, err := opensearch.NewStore(
-		opensearch.WithServerAddresses(addresses),
+	tlsConfig, err := dconfig.OpenSearchTLSConfig(config.Config)
+	if err != nil {
+		return nil, err
+	}
+	requestSigner, err := dconfig.OpenSearchSigner(context.Background(), config.Config)
+	if err != nil {
+		return nil, err
+	}
+	storeOpts := []opensearch.StoreOption{
+		opensearch.WithTLSConfig(tlsConfig),
+		opensearch.WithRequestSigner(requestSigner),
+		opensearch.WithDistribution(
+			config.Config.GetString(dconfig.SettingOpenSearchDistribution)),
+		opensearch.WithBasicAuth(
+			config.Config.GetString(dconfig.SettingOpenSearchUsername),
+			config.Config.GetString(dconfig.SettingOpenSearchPassword)),
+		opensearch.WithAPIKey(config.Config.GetString(dconfig.SettingOpenSearchAPIKey)),
 		opensearch.WithDevicesIndexName(devicesIndexName),
 		opensearch.WithDevicesIndexShards(devicesIndexShards),
 		opensearch.WithDevicesIndexReplicas(devicesIndexReplicas),
+		opensearch.WithDevicesIndexRefreshInterval(config.Config.GetString(
+			dconfig.SettingOpenSearchDevicesIndexRefreshInterval)),
 		opensearch.WithDeploymentsIndexName(deploymentsIndexName),
 		opensearch.WithDeploymentsIndexShards(deploymentsIndexShards),
 		opensearch.WithDeploymentsIndexReplicas(deploymentsIndexReplicas),
-	)
+		opensearch.WithDeploymentsIndexRefreshInterval(config.Config.GetString(
+			dconfig.SettingOpenSearchDeploymentsIndexRefreshInterval)),
+		opensearch.WithSoftwareIndexName(
+			config.Config.GetString(dconfig.SettingOpenSearchSoftwareIndexName)),
+		opensearch.WithSoftwareIndexShards(
+			config.Config.GetInt(dconfig.SettingOpenSearchSoftwareIndexShards)),
+		opensearch.WithSoftwareIndexReplicas(
+			config.Config.GetInt(dconfig.SettingOpenSearchSoftwareIndexReplicas)),
+		opensearch.WithSoftwareIndexRefreshInterval(config.Config.GetString(
+			dconfig.SettingOpenSearchSoftwareIndexRefreshInterval)),
+		opensearch.WithIndexStrategy(
+			config.Config.GetString(dconfig.SettingOpenSearchIndexStrategy)),
+		opensearch.WithIndexGroups(config.Config.GetInt(dconfig.SettingOpenSearchIndexGroups)),
+		opensearch.WithDeploymentsRollover(
+			config.Config.GetString(dconfig.SettingOpenSearchDeploymentsRollover)),
+		opensearch.WithDeploymentsRetention(
+			config.Config.GetInt(dconfig.SettingDeploymentsRetentionDays)),
+		opensearch.WithBulkRetries(
+			config.Config.GetInt(dconfig.SettingOpenSearchBulkMaxRetries),
+			time.Duration(config.Config.GetInt(
+				dconfig.SettingOpenSearchBulkRetryBackoffMsec))*time.Millisecond),
+		opensearch.WithSlowQueryThreshold(time.Duration(config.Config.GetInt(
+			dconfig.SettingOpenSearchSlowQueryMsec)) * time.Millisecond),
+	}
+	store, err := opensearch.NewStore(append(storeOpts,
+		opensearch.WithServerAddresses(addresses),
+		opensearch.WithReadAddresses(
+			config.Config.GetStringSlice(dconfig.SettingOpenSearchReadAddresses),
+			time.Duration(config.Config.GetInt(
+				dconfig.SettingOpenSearchReadHealthCheckMsec))*time.Millisecond),
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := dconfig.OpenSearchClusters(config.Config)
 	if err != nil {
 		return nil, err
 	}
+	if len(clusters) > 0 {
+		store, err = getResidencyStore(store, clusters, storeOpts)
+		if err != nil {
+			return nil, err
+		}
+	}
 	ctx := context.Background()
 	l := log.FromContext(ctx)
 	for i := 0; i < opensearchMaxWaitingTime; i++ {
@@ -254,7 +660,48 @@ func getStore(args *cli.Context) (store.Store, error) {
 	return store, nil
 }
 
+// getResidencyStore returns the store routing the tenants to the clusters
+// their data resides in: the default store, or the one of the additional
+// clusters, configured as the default one but for their addresses
+func getResidencyStore(
+	defaultStore store.Store,
+	clusters map[string][]string,
+	storeOpts []opensearch.StoreOption,
+) (store.Store, error) {
+	stores := map[string]store.Store{
+		residency.DefaultCluster: defaultStore,
+	}
+	for name, addresses := range clusters {
+		clusterStore, err := opensearch.NewStore(
+			append(storeOpts, opensearch.WithServerAddresses(addresses))...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cluster %s", name)
+		}
+		stores[name] = clusterStore
+	}
+	tenantClusters, err := dconfig.TenantClusters(config.Config)
+	if err != nil {
+		return nil, err
+	}
+	var tenants tenantadm.Client
+	if addr := config.Config.GetString(dconfig.SettingTenantadmAddr); addr != "" {
+		tlsConfig, err := dconfig.ClientsTLSConfig(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		tenants = tenantadm.NewClient(addr,
+			tenantadm.WithTLSConfig(tlsConfig),
+			tenantadm.WithTimeout(time.Duration(config.Config.GetInt(
+				dconfig.SettingClientsTimeoutMsec))*time.Millisecond))
+	}
+	return residency.NewStore(stores, residency.NewResolver(tenantClusters, tenants))
+}
+
 func getDatastore(args *cli.Context) (store.DataStore, error) {
+	return getMongoStore(args)
+}
+
+func getMongoStore(args *cli.Context) (*mongo.MongoStore, error) {
 	mgoURL, err := url.Parse(config.Config.GetString(dconfig.SettingMongo))
 	if err != nil {
 		return nil, err
@@ -20,8 +20,10 @@ import (
 	"math"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/model"
@@ -30,6 +32,10 @@ import (
 
 const (
 	inventoryAttributeTemplate = "attribute%d"
+
+	// cacheTTL is how long the tenants' mappings are cached, for the
+	// attributes evicted by other instances to be eventually dropped
+	cacheTTL = time.Minute
 )
 
 // Mapping is an interface to map and reverse attributes
@@ -39,11 +45,16 @@ type Mapper interface {
 	) (inventory.DeviceAttributes, error)
 	ReverseInventoryAttributes(ctx context.Context, tenantID string,
 		attrs inventory.DeviceAttributes) (inventory.DeviceAttributes, error)
+	// AttributeTypes returns the types the tenant's mapped attributes are
+	// declared to be indexed as, by mapped attribute as "scope/field"
+	AttributeTypes(ctx context.Context, tenantID string) (map[string]model.Type, error)
 }
 
 type tenantMapCache struct {
 	inventory        map[string]string
 	inventoryReverse map[string]string
+	types            map[string]model.Type
+	expireTs         time.Time
 }
 
 type mapper struct {
@@ -101,6 +112,24 @@ func (m *mapper) ReverseInventoryAttributes(ctx context.Context, tenantID string
 	return mapAttributes(attrs, attributesToFieldsMap, true, false), nil
 }
 
+// AttributeTypes returns the types the tenant's mapped attributes are
+// declared to be indexed as; the attributes declared but not mapped yet,
+// or not remapped yet to their declared type, are not returned
+func (m *mapper) AttributeTypes(ctx context.Context, tenantID string) (
+	map[string]model.Type, error) {
+	m.lock.RLock()
+	cache, ok := m.cache[tenantID]
+	m.lock.RUnlock()
+	if ok && time.Now().Before(cache.expireTs) {
+		return cache.types, nil
+	}
+	mapping, err := m.getMapping(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return attributeTypes(mapping), nil
+}
+
 func (m *mapper) getMapping(ctx context.Context, tenantID string) (*model.Mapping, error) {
 	mapping, err := m.ds.GetMapping(ctx, tenantID)
 	if err == nil {
@@ -113,10 +142,15 @@ func (m *mapper) cacheMapping(tenantID string, mapping *model.Mapping) {
 	cache := &tenantMapCache{
 		inventory:        make(map[string]string),
 		inventoryReverse: make(map[string]string),
+		types:            attributeTypes(mapping),
+		expireTs:         time.Now().Add(cacheTTL),
 	}
 	n := int(math.Min(float64(len(mapping.Inventory)), model.MaxMappingInventoryAttributes))
 	for i, attr := range mapping.Inventory[:n] {
-		attrName := fmt.Sprintf(inventoryAttributeTemplate, i+1)
+		if attr == "" {
+			continue
+		}
+		attrName := FieldName(i)
 		cache.inventory[attr] = attrName
 		cache.inventoryReverse[attrName] = attr
 	}
@@ -130,7 +164,7 @@ func (m *mapper) lookupMapping(tenantID string, attrs inventory.DeviceAttributes
 	m.lock.RLock()
 	cache, ok := m.cache[tenantID]
 	m.lock.RUnlock()
-	if ok {
+	if ok && time.Now().Before(cache.expireTs) {
 		var cacheAttributes map[string]string
 		if reverse {
 			cacheAttributes = cache.inventoryReverse
@@ -210,7 +244,9 @@ func mapAttributes(attrs inventory.DeviceAttributes,
 func attributesToFields(attrs []string) map[string]string {
 	var attributesToFields = make(map[string]string, len(attrs))
 	for i := 0; i < len(attrs); i++ {
-		attributesToFields[attrs[i]] = fmt.Sprintf(inventoryAttributeTemplate, i+1)
+		if attrs[i] != "" {
+			attributesToFields[attrs[i]] = FieldName(i)
+		}
 	}
 	return attributesToFields
 }
@@ -218,11 +254,61 @@ func attributesToFields(attrs []string) map[string]string {
 func fieldsToAttributes(attrs []string) map[string]string {
 	var fieldsToAttributes = make(map[string]string, len(attrs))
 	for i := 0; i < len(attrs); i++ {
-		fieldsToAttributes[fmt.Sprintf(inventoryAttributeTemplate, i+1)] = attrs[i]
+		if attrs[i] != "" {
+			fieldsToAttributes[FieldName(i)] = attrs[i]
+		}
 	}
 	return fieldsToAttributes
 }
 
+// attributeTypes returns the declared types of the attributes of the
+// mapping, by mapped attribute; the attributes pending a remap are left
+// out, as their values are still indexed as the previous type
+func attributeTypes(mapping *model.Mapping) map[string]model.Type {
+	types := make(map[string]model.Type, len(mapping.Types))
+	if len(mapping.Types) == 0 {
+		return types
+	}
+	attributesToFieldsMap := attributesToFields(mapping.Inventory[:int(math.Min(
+		float64(len(mapping.Inventory)), model.MaxMappingInventoryAttributes))])
+	pending := make(map[string]struct{}, len(mapping.Remaps))
+	for _, key := range mapping.Remaps {
+		pending[key] = struct{}{}
+	}
+	for _, t := range mapping.Types {
+		if _, ok := pending[t.Key()]; ok {
+			continue
+		}
+		if field, ok := attributesToFieldsMap[t.Key()]; ok {
+			types[path.Join(t.Scope, field)] = t.IndexedType()
+		}
+	}
+	return types
+}
+
+// FieldName returns the name of the field the attribute in the given slot
+// of the mapping, starting from zero, is indexed as
+func FieldName(slot int) string {
+	return fmt.Sprintf(inventoryAttributeTemplate, slot+1)
+}
+
+// IsFieldName returns true if the name is the one of a field the
+// attributes are indexed as, once mapped
+func IsFieldName(name string) bool {
+	prefix := strings.TrimSuffix(inventoryAttributeTemplate, "%d")
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	slot, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+	return err == nil && slot > 0 && FieldName(slot-1) == name
+}
+
+// MapsAttribute returns true if the attribute takes a slot of the tenant's
+// mapping to be indexed
+func MapsAttribute(scope, attribute string) bool {
+	return shouldMapScope(scope, attribute)
+}
+
 func shouldMapScope(scope, attribute string) bool {
 	return scope != model.ScopeSystem &&
 		!(scope == model.ScopeIdentity && attribute == model.AttrNameStatus)
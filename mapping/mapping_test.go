@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/model"
@@ -304,6 +305,13 @@ func TestAttributesToFields(t *testing.T) {
 				"a2": fmt.Sprintf(inventoryAttributeTemplate, 2),
 			},
 		},
+		"evicted attribute": {
+			in: []string{"a1", "", "a3"},
+			out: map[string]string{
+				"a1": fmt.Sprintf(inventoryAttributeTemplate, 1),
+				"a3": fmt.Sprintf(inventoryAttributeTemplate, 3),
+			},
+		},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
@@ -325,6 +333,13 @@ func TestFieldsToAttributes(t *testing.T) {
 				fmt.Sprintf(inventoryAttributeTemplate, 2): "a2",
 			},
 		},
+		"evicted attribute": {
+			in: []string{"a1", "", "a3"},
+			out: map[string]string{
+				fmt.Sprintf(inventoryAttributeTemplate, 1): "a1",
+				fmt.Sprintf(inventoryAttributeTemplate, 3): "a3",
+			},
+		},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
@@ -334,6 +349,21 @@ func TestFieldsToAttributes(t *testing.T) {
 	}
 }
 
+func TestIsFieldName(t *testing.T) {
+	testCases := map[string]bool{
+		FieldName(0):    true,
+		FieldName(99):   true,
+		"attribute0":    false,
+		"attribute01":   false,
+		"attribute1x":   false,
+		"attribute":     false,
+		"artifact_name": false,
+	}
+	for name, isField := range testCases {
+		assert.Equal(t, isField, IsFieldName(name), name)
+	}
+}
+
 func TestCache(t *testing.T) {
 	ctx := context.Background()
 	const tenantID = "tenantID"
@@ -414,3 +444,93 @@ func TestCache(t *testing.T) {
 		{Name: "a3", Value: "v3", Scope: model.ScopeSystem},
 	}, res)
 }
+
+func TestCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	const tenantID = "tenantID"
+
+	ds := &mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping",
+		ctx,
+		tenantID,
+	).Return(&model.Mapping{
+		TenantID: tenantID,
+		Inventory: []string{
+			path.Join(model.ScopeInventory, "a1"),
+			path.Join(model.ScopeInventory, "a2"),
+		},
+	}, nil).Once()
+
+	mapper := newMapper(ds)
+	attrs := inventory.DeviceAttributes{
+		{Name: "a1", Value: "v1", Scope: model.ScopeInventory},
+		{Name: "a2", Value: "v2", Scope: model.ScopeInventory},
+	}
+	res, err := mapper.MapInventoryAttributes(ctx, tenantID, attrs, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, inventory.DeviceAttributes{
+		{Name: fmt.Sprintf(inventoryAttributeTemplate, 1), Value: "v1", Scope: model.ScopeInventory},
+		{Name: fmt.Sprintf(inventoryAttributeTemplate, 2), Value: "v2", Scope: model.ScopeInventory},
+	}, res)
+
+	// once expired, the cached mapping is fetched again, e.g. to drop
+	// the attributes evicted in the meantime
+	mapper.cache[tenantID].expireTs = time.Now().Add(-time.Second)
+	ds.On("GetMapping",
+		ctx,
+		tenantID,
+	).Return(&model.Mapping{
+		TenantID: tenantID,
+		Inventory: []string{
+			"",
+			path.Join(model.ScopeInventory, "a2"),
+		},
+	}, nil).Once()
+
+	res, err = mapper.MapInventoryAttributes(ctx, tenantID, attrs, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, inventory.DeviceAttributes{
+		{Name: fmt.Sprintf(inventoryAttributeTemplate, 2), Value: "v2", Scope: model.ScopeInventory},
+	}, res)
+}
+
+func TestAttributeTypes(t *testing.T) {
+	const tenantID = "tenant"
+
+	ds := &mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetMapping", mock.Anything, tenantID).
+		Return(&model.Mapping{
+			TenantID:  tenantID,
+			Inventory: []string{"inventory/last_boot", "inventory/mac"},
+			Types: []model.MappingAttributeType{{
+				Scope: model.ScopeInventory,
+				Name:  "last_boot",
+				Type:  model.AttributeTypeDate,
+			}, {
+				Scope: model.ScopeInventory,
+				Name:  "mac",
+				Type:  model.AttributeTypeNumber,
+			}, {
+				Scope: model.ScopeInventory,
+				Name:  "not_mapped",
+				Type:  model.AttributeTypeNumber,
+			}},
+			// not remapped yet: still indexed as the previous type
+			Remaps: []string{"inventory/mac"},
+		}, nil).
+		Once()
+
+	mapper := NewMapper(ds)
+	types, err := mapper.AttributeTypes(context.Background(), tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]model.Type{
+		path.Join(model.ScopeInventory, FieldName(0)): model.TypeDate,
+	}, types)
+
+	// cached
+	types, err = mapper.AttributeTypes(context.Background(), tenantID)
+	assert.NoError(t, err)
+	assert.Len(t, types, 1)
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mapping
+
+import (
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// attributeRemap is the pending remap of an attribute of a tenant's
+// mapping
+type attributeRemap struct {
+	tenantID  string
+	key       string
+	attribute model.MappingAttribute
+	// field is the field the attribute is mapped to, empty if the
+	// attribute is not mapped
+	field string
+	typ   model.MappingAttributeType
+}
+
+// pendingRemaps returns the pending remaps of all the tenants
+func pendingRemaps(ctx context.Context, ds store.DataStore) ([]attributeRemap, error) {
+	mappings, err := ds.GetMappingsToRemap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var remaps []attributeRemap
+	for i := range mappings {
+		mapping := &mappings[i]
+		n := len(mapping.Inventory)
+		if n > model.MaxMappingInventoryAttributes {
+			n = model.MaxMappingInventoryAttributes
+		}
+		fields := attributesToFields(mapping.Inventory[:n])
+		types := make(map[string]model.MappingAttributeType, len(mapping.Types))
+		for _, t := range mapping.Types {
+			types[t.Key()] = t
+		}
+		for _, key := range mapping.Remaps {
+			remaps = append(remaps, attributeRemap{
+				tenantID:  mapping.TenantID,
+				key:       key,
+				attribute: model.ParseMappingAttribute(key),
+				field:     fields[key],
+				typ:       types[key],
+			})
+		}
+	}
+	return remaps, nil
+}
+
+// PlanRemaps returns the remaps of the attributes whose declared type
+// changed, which RemapAttributes would apply
+func PlanRemaps(ctx context.Context, ds store.DataStore) ([]model.MigrationChange, error) {
+	remaps, err := pendingRemaps(ctx, ds)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the attributes to remap")
+	}
+	changes := make([]model.MigrationChange, 0, len(remaps))
+	for _, remap := range remaps {
+		change := model.MigrationChange{
+			Kind:   model.MigrationKindAttribute,
+			Name:   path.Join(remap.tenantID, remap.key),
+			Action: model.MigrationActionRemap,
+		}
+		if remap.field == "" {
+			change.Details = []string{"not mapped: nothing to remap"}
+		} else if remap.typ.Type == "" {
+			change.Details = []string{"to the default fields"}
+			change.Breaking = true
+		} else {
+			change.Details = []string{"to " + remap.typ.Type}
+			change.Breaking = true
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// RemapAttributes converts the indexed values of the attributes whose
+// declared type changed to their new type, tenant by tenant, and removes
+// them from the pending remaps; the attributes not mapped have nothing to
+// remap. If the store does not support remapping, the remaps are dropped:
+// the values are converted reindexing the devices.
+func RemapAttributes(ctx context.Context, s store.Store, ds store.DataStore) error {
+	l := log.FromContext(ctx)
+	remaps, err := pendingRemaps(ctx, ds)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the attributes to remap")
+	}
+	for _, remap := range remaps {
+		if remap.field != "" {
+			err := s.RemapDevicesAttribute(ctx, remap.tenantID,
+				remap.attribute.Scope, remap.field, remap.typ.IndexedType())
+			if errors.Is(err, store.ErrAttributeRemapNotSupported) {
+				l.Warnf("the attribute %s of the tenant %s is not remapped: %s; "+
+					"reindex the tenant's devices to convert its values",
+					remap.key, remap.tenantID, err)
+			} else if err != nil {
+				return errors.Wrapf(err, "failed to remap the attribute %s "+
+					"of the tenant %s", remap.key, remap.tenantID)
+			} else {
+				l.Infof("remapped the attribute %s of the tenant %s",
+					remap.key, remap.tenantID)
+			}
+		}
+		err := ds.CompleteMappingRemap(ctx, remap.tenantID, remap.key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
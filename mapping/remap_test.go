@@ -0,0 +1,132 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mapping
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+	"github.com/mendersoftware/reporting/store/mocks"
+)
+
+var remapMappings = []model.Mapping{{
+	TenantID:  "tenant1",
+	Inventory: []string{"inventory/mac", "inventory/last_boot"},
+	Types: []model.MappingAttributeType{{
+		Scope: model.ScopeInventory,
+		Name:  "last_boot",
+		Type:  model.AttributeTypeDate,
+	}},
+	Remaps: []string{"inventory/last_boot", "inventory/mac"},
+}, {
+	TenantID:  "tenant2",
+	Inventory: []string{"inventory/mac"},
+	Remaps:    []string{"inventory/online"},
+}}
+
+func TestPlanRemaps(t *testing.T) {
+	t.Parallel()
+
+	ds := &mocks.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetMappingsToRemap", mock.Anything).Return(remapMappings, nil)
+
+	changes, err := PlanRemaps(context.Background(), ds)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.MigrationChange{{
+		Kind:     model.MigrationKindAttribute,
+		Name:     "tenant1/inventory/last_boot",
+		Action:   model.MigrationActionRemap,
+		Details:  []string{"to date"},
+		Breaking: true,
+	}, {
+		Kind:     model.MigrationKindAttribute,
+		Name:     "tenant1/inventory/mac",
+		Action:   model.MigrationActionRemap,
+		Details:  []string{"to the default fields"},
+		Breaking: true,
+	}, {
+		Kind:    model.MigrationKindAttribute,
+		Name:    "tenant2/inventory/online",
+		Action:  model.MigrationActionRemap,
+		Details: []string{"not mapped: nothing to remap"},
+	}}, changes)
+}
+
+func TestRemapAttributes(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		remapErr error
+
+		err error
+	}{
+		"ok": {},
+		"ok, not supported": {
+			remapErr: store.ErrAttributeRemapNotSupported,
+		},
+		"error, remap failed": {
+			remapErr: errors.New("connection reset"),
+			err: errors.New("failed to remap the attribute inventory/last_boot " +
+				"of the tenant tenant1: connection reset"),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &mocks.Store{}
+			defer s.AssertExpectations(t)
+			ds := &mocks.DataStore{}
+			defer ds.AssertExpectations(t)
+			ds.On("GetMappingsToRemap", mock.Anything).Return(remapMappings, nil)
+
+			s.On("RemapDevicesAttribute", mock.Anything, "tenant1",
+				model.ScopeInventory, FieldName(1), model.TypeDate).
+				Return(tc.remapErr).
+				Once()
+			if tc.err == nil {
+				s.On("RemapDevicesAttribute", mock.Anything, "tenant1",
+					model.ScopeInventory, FieldName(0), model.TypeAny).
+					Return(tc.remapErr).
+					Once()
+				for _, remap := range []struct{ tenantID, key string }{
+					{"tenant1", "inventory/last_boot"},
+					{"tenant1", "inventory/mac"},
+					{"tenant2", "inventory/online"},
+				} {
+					ds.On("CompleteMappingRemap", mock.Anything,
+						remap.tenantID, remap.key).
+						Return(nil).
+						Once()
+				}
+			}
+
+			err := RemapAttributes(context.Background(), s, ds)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -15,31 +15,104 @@
 package model
 
 import (
+	"regexp"
+	"time"
+	// the time zones of the date histograms are validated against the
+	// embedded database, as the container may not provide one
+	_ "time/tzdata"
+
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/pkg/errors"
 )
 
 const (
-	defaultAggregationLimit = 10
-	maxAggregationTerms     = 100
-	maxNestedAggregations   = 5
+	defaultAggregationLimit      = 10
+	maxAggregationTerms          = 100
+	defaultMaxNestedAggregations = 5
+	maxPercents                  = 20
+)
+
+const (
+	AggregationTypeTerms         = "terms"
+	AggregationTypeDateHistogram = "date_histogram"
+	AggregationTypeStats         = "stats"
+	AggregationTypePercentiles   = "percentiles"
 )
 
+var validAggregationTypes = []interface{}{
+	AggregationTypeTerms,
+	AggregationTypeDateHistogram,
+}
+
+// validDeviceAggregationTypes are the aggregation types supported on the
+// devices, which include the metrics aggregations on numeric attributes
+var validDeviceAggregationTypes = append([]interface{}{
+	AggregationTypeStats,
+	AggregationTypePercentiles,
+}, validAggregationTypes...)
+
+var validDateHistogramIntervals = []interface{}{
+	"hour",
+	"day",
+	"week",
+	"month",
+}
+
+const (
+	dateHistogramFormat = "strict_date_time_no_millis"
+
+	// dateHistogramScript parses the device timestamps, which are
+	// indexed as strings, as dates
+	dateHistogramScript = "def v = doc[params.field]; " +
+		"return v.size() == 0 ? null : " +
+		"ZonedDateTime.parse(v.value).toInstant().toEpochMilli();"
+)
+
+var timeZoneOffsetRegexp = regexp.MustCompile(`^[+-]\d{2}:\d{2}$`)
+
+var maxNestedAggregations uint = defaultMaxNestedAggregations
+
+// SetMaxNestedAggregations sets the maximum depth of nested sub-aggregations
+// accepted by the aggregation parameters validation
+func SetMaxNestedAggregations(depth uint) {
+	maxNestedAggregations = depth
+}
+
 type AggregateParams struct {
 	Aggregations []AggregationTerm `json:"aggregations"`
 	Filters      []FilterPredicate `json:"filters"`
 	Groups       []string          `json:"-"`
-	TenantID     string            `json:"-"`
+	// VisibleAttributes are the attributes visible to the role of the
+	// user, if restricted
+	VisibleAttributes *AttributesFilter `json:"-"`
+	TenantID          string            `json:"-"`
 }
 
 type AggregationTerm struct {
-	Name         string            `json:"name"`
-	Attribute    string            `json:"attribute"`
-	Scope        string            `json:"scope"`
-	Limit        int               `json:"limit"`
+	Name      string `json:"name"`
+	Attribute string `json:"attribute"`
+	Scope     string `json:"scope"`
+	Limit     int    `json:"limit"`
+	// Type is the type of the aggregation: terms (default), date_histogram,
+	// stats or percentiles
+	Type string `json:"type,omitempty"`
+	// Interval is the calendar interval of the date_histogram buckets
+	Interval string `json:"interval,omitempty"`
+	// TimeZone is the time zone of the date_histogram buckets, either
+	// an IANA time zone name or an UTC offset, defaults to UTC
+	TimeZone string `json:"time_zone,omitempty"`
+	// Percents are the percentiles to compute, defaults to the
+	// 1, 5, 25, 50, 75, 95 and 99 percentiles
+	Percents     []float64         `json:"percents,omitempty"`
 	Aggregations []AggregationTerm `json:"aggregations"`
 }
 
+// IsMetric returns true if the aggregation computes metrics on the values
+// of a numeric attribute, rather than grouping the devices in buckets
+func (f AggregationTerm) IsMetric() bool {
+	return f.Type == AggregationTypeStats || f.Type == AggregationTypePercentiles
+}
+
 func checkMaxNestedAggregationsWithLimit(value interface{}, limit uint) error {
 	if limit <= 0 {
 		return errors.Errorf("too many nested aggregations, limit is %d", maxNestedAggregations)
@@ -47,7 +120,10 @@ func checkMaxNestedAggregationsWithLimit(value interface{}, limit uint) error {
 	if aggs, ok := value.([]AggregationTerm); ok {
 		for _, agg := range aggs {
 			if len(agg.Aggregations) > 0 {
-				return checkMaxNestedAggregationsWithLimit(agg.Aggregations, limit-1)
+				err := checkMaxNestedAggregationsWithLimit(agg.Aggregations, limit-1)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -65,6 +141,12 @@ func (sp AggregateParams) Validate() error {
 	if err != nil {
 		return err
 	}
+	if err := checkAggregationBuckets(aggregationBuckets(sp.Aggregations)); err != nil {
+		return err
+	}
+	if err := checkClauses(len(sp.Filters)); err != nil {
+		return err
+	}
 
 	for _, f := range sp.Filters {
 		err := f.Validate()
@@ -75,17 +157,44 @@ func (sp AggregateParams) Validate() error {
 	return nil
 }
 
+func checkTimeZone(value interface{}) error {
+	tz, _ := value.(string)
+	if tz == "" || timeZoneOffsetRegexp.MatchString(tz) {
+		return nil
+	} else if _, err := time.LoadLocation(tz); err != nil {
+		return errors.New("must be a valid time zone name or UTC offset")
+	}
+	return nil
+}
+
 func (f AggregationTerm) Validate() error {
+	isDateHistogram := f.Type == AggregationTypeDateHistogram
+	isPercentiles := f.Type == AggregationTypePercentiles
 	return validation.ValidateStruct(&f,
 		validation.Field(&f.Name, validation.Required),
 		validation.Field(&f.Attribute, validation.Required),
 		validation.Field(&f.Scope, validation.Required),
 		validation.Field(&f.Limit, validation.Min(0)),
+		validation.Field(&f.Type, validation.In(validDeviceAggregationTypes...)),
+		validation.Field(&f.Interval,
+			validation.When(isDateHistogram, validation.Required,
+				validation.In(validDateHistogramIntervals...)).
+				Else(validation.Empty)),
+		validation.Field(&f.TimeZone,
+			validation.When(isDateHistogram, validation.By(checkTimeZone)).
+				Else(validation.Empty)),
+		validation.Field(&f.Percents,
+			validation.When(isPercentiles, validation.Length(0, maxPercents),
+				validation.Each(validation.Min(0.0), validation.Max(100.0))).
+				Else(validation.Empty)),
 		validation.Field(&f.Aggregations, validation.When(
+			f.IsMetric(),
+			validation.Empty.Error("metrics aggregations do not support sub-aggregations"),
+		).Else(validation.When(
 			len(f.Aggregations) > 0,
 			validation.Length(0, maxAggregationTerms),
 			validation.By(checkMaxNestedAggregations),
-		)),
+		))),
 	)
 }
 
@@ -94,16 +203,52 @@ type Aggregations map[string]interface{}
 func BuildAggregations(terms []AggregationTerm) (*Aggregations, error) {
 	aggs := Aggregations{}
 	for _, term := range terms {
-		terms := map[string]interface{}{
-			"field": ToAttr(term.Scope, term.Attribute, TypeStr),
-		}
-		limit := term.Limit
-		if limit <= 0 {
-			limit = defaultAggregationLimit
-		}
-		terms["size"] = limit
-		agg := map[string]interface{}{
-			"terms": terms,
+		field := ToAttr(term.Scope, term.Attribute, TypeStr)
+		var agg map[string]interface{}
+		switch term.Type {
+		case AggregationTypeStats:
+			agg = map[string]interface{}{
+				AggregationTypeStats: map[string]interface{}{
+					"field": ToAttr(term.Scope, term.Attribute, TypeNum),
+				},
+			}
+		case AggregationTypePercentiles:
+			percentiles := map[string]interface{}{
+				"field": ToAttr(term.Scope, term.Attribute, TypeNum),
+			}
+			if len(term.Percents) > 0 {
+				percentiles["percents"] = term.Percents
+			}
+			agg = map[string]interface{}{
+				AggregationTypePercentiles: percentiles,
+			}
+		case AggregationTypeDateHistogram:
+			histogram := dateHistogram(term.Interval, term.TimeZone)
+			histogram["script"] = map[string]interface{}{
+				"source": dateHistogramScript,
+				"params": map[string]interface{}{
+					"field": field,
+				},
+			}
+			agg = map[string]interface{}{
+				AggregationTypeDateHistogram: histogram,
+			}
+		default:
+			if IsConnectivityAttribute(term.Scope, term.Attribute) {
+				agg = connectivityAggregation()
+				break
+			}
+			terms := map[string]interface{}{
+				"field": field,
+			}
+			limit := term.Limit
+			if limit <= 0 {
+				limit = defaultAggregationLimit
+			}
+			terms["size"] = limit
+			agg = map[string]interface{}{
+				"terms": terms,
+			}
 		}
 		if len(term.Aggregations) > 0 {
 			subaggs, err := BuildAggregations(term.Aggregations)
@@ -117,10 +262,37 @@ func BuildAggregations(terms []AggregationTerm) (*Aggregations, error) {
 	return &aggs, nil
 }
 
+// dateHistogram returns the definition of a date_histogram aggregation,
+// without the source of the values
+func dateHistogram(interval, timeZone string) map[string]interface{} {
+	histogram := map[string]interface{}{
+		"calendar_interval": interval,
+		"format":            dateHistogramFormat,
+		"min_doc_count":     0,
+	}
+	if timeZone != "" {
+		histogram["time_zone"] = timeZone
+	}
+	return histogram
+}
+
 type DeviceAggregation struct {
 	Name       string                  `json:"name"`
 	Items      []DeviceAggregationItem `json:"items"`
 	OtherCount int                     `json:"other_count"`
+	// Stats are the results of the stats aggregations
+	Stats *DeviceAggregationStats `json:"stats,omitempty"`
+	// Percentiles are the results of the percentiles aggregations, indexed
+	// by percent
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+}
+
+type DeviceAggregationStats struct {
+	Count int      `json:"count"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Avg   *float64 `json:"avg"`
+	Sum   float64  `json:"sum"`
 }
 
 type DeviceAggregationItem struct {
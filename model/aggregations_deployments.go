@@ -26,9 +26,16 @@ type AggregateDeploymentsParams struct {
 }
 
 type DeploymentsAggregationTerm struct {
-	Name         string                       `json:"name"`
-	Attribute    string                       `json:"attribute"`
-	Limit        int                          `json:"limit"`
+	Name      string `json:"name"`
+	Attribute string `json:"attribute"`
+	Limit     int    `json:"limit"`
+	// Type is the type of the aggregation: terms (default) or date_histogram
+	Type string `json:"type,omitempty"`
+	// Interval is the calendar interval of the date_histogram buckets
+	Interval string `json:"interval,omitempty"`
+	// TimeZone is the time zone of the date_histogram buckets, either
+	// an IANA time zone name or an UTC offset, defaults to UTC
+	TimeZone     string                       `json:"time_zone,omitempty"`
 	Aggregations []DeploymentsAggregationTerm `json:"aggregations"`
 }
 
@@ -39,7 +46,10 @@ func checkMaxNestedDeploymentsAggregationsWithLimit(value interface{}, limit uin
 	if aggs, ok := value.([]DeploymentsAggregationTerm); ok {
 		for _, agg := range aggs {
 			if len(agg.Aggregations) > 0 {
-				return checkMaxNestedDeploymentsAggregationsWithLimit(agg.Aggregations, limit-1)
+				err := checkMaxNestedDeploymentsAggregationsWithLimit(agg.Aggregations, limit-1)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -57,6 +67,13 @@ func (sp AggregateDeploymentsParams) Validate() error {
 	if err != nil {
 		return err
 	}
+	err = checkAggregationBuckets(deploymentsAggregationBuckets(sp.Aggregations))
+	if err != nil {
+		return err
+	}
+	if err := checkClauses(len(sp.Filters)); err != nil {
+		return err
+	}
 
 	for _, f := range sp.Filters {
 		err := f.Validate()
@@ -68,10 +85,19 @@ func (sp AggregateDeploymentsParams) Validate() error {
 }
 
 func (f DeploymentsAggregationTerm) Validate() error {
+	isDateHistogram := f.Type == AggregationTypeDateHistogram
 	return validation.ValidateStruct(&f,
 		validation.Field(&f.Name, validation.Required),
 		validation.Field(&f.Attribute, validation.Required),
 		validation.Field(&f.Limit, validation.Min(0)),
+		validation.Field(&f.Type, validation.In(validAggregationTypes...)),
+		validation.Field(&f.Interval,
+			validation.When(isDateHistogram, validation.Required,
+				validation.In(validDateHistogramIntervals...)).
+				Else(validation.Empty)),
+		validation.Field(&f.TimeZone,
+			validation.When(isDateHistogram, validation.By(checkTimeZone)).
+				Else(validation.Empty)),
 		validation.Field(&f.Aggregations, validation.When(
 			len(f.Aggregations) > 0,
 			validation.Length(0, maxAggregationTerms),
@@ -83,16 +109,25 @@ func (f DeploymentsAggregationTerm) Validate() error {
 func BuildDeploymentsAggregations(terms []DeploymentsAggregationTerm) (*Aggregations, error) {
 	aggs := Aggregations{}
 	for _, term := range terms {
-		terms := map[string]interface{}{
-			"field": term.Attribute,
-		}
-		limit := term.Limit
-		if limit <= 0 {
-			limit = defaultAggregationLimit
-		}
-		terms["size"] = limit
-		agg := map[string]interface{}{
-			"terms": terms,
+		var agg map[string]interface{}
+		if term.Type == AggregationTypeDateHistogram {
+			histogram := dateHistogram(term.Interval, term.TimeZone)
+			histogram["field"] = term.Attribute
+			agg = map[string]interface{}{
+				AggregationTypeDateHistogram: histogram,
+			}
+		} else {
+			terms := map[string]interface{}{
+				"field": term.Attribute,
+			}
+			limit := term.Limit
+			if limit <= 0 {
+				limit = defaultAggregationLimit
+			}
+			terms["size"] = limit
+			agg = map[string]interface{}{
+				"terms": terms,
+			}
 		}
 		if len(term.Aggregations) > 0 {
 			subaggs, err := BuildDeploymentsAggregations(term.Aggregations)
@@ -203,6 +203,34 @@ func TestAggregateDeploymentsParamsValidate(t *testing.T) {
 			},
 			err: errors.New("aggregations: (0: (aggregations: too many nested aggregations, limit is 5.).)."),
 		},
+		"ok, date histogram": {
+			params: AggregateDeploymentsParams{
+				Aggregations: []DeploymentsAggregationTerm{
+					{
+						Name:      "created",
+						Attribute: "deployment_created",
+						Type:      AggregationTypeDateHistogram,
+						Interval:  "month",
+						TimeZone:  "+02:00",
+					},
+				},
+			},
+		},
+		"ko, date histogram with invalid time zone": {
+			params: AggregateDeploymentsParams{
+				Aggregations: []DeploymentsAggregationTerm{
+					{
+						Name:      "created",
+						Attribute: "deployment_created",
+						Type:      AggregationTypeDateHistogram,
+						Interval:  "month",
+						TimeZone:  "CEST+2",
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (time_zone: must be a valid " +
+				"time zone name or UTC offset.).)."),
+		},
 	}
 
 	for name, tc := range testCases {
@@ -286,6 +314,26 @@ func TestBuildDeploymentsAggregations(t *testing.T) {
 				},
 			},
 		},
+		"ok, date histogram": {
+			terms: []DeploymentsAggregationTerm{
+				{
+					Name:      "created",
+					Attribute: "deployment_created",
+					Type:      AggregationTypeDateHistogram,
+					Interval:  "week",
+				},
+			},
+			res: &Aggregations{
+				"created": map[string]interface{}{
+					"date_histogram": map[string]interface{}{
+						"field":             "deployment_created",
+						"calendar_interval": "week",
+						"format":            dateHistogramFormat,
+						"min_doc_count":     0,
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range testCases {
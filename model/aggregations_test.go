@@ -68,6 +68,178 @@ func TestAggregateParamsValidate(t *testing.T) {
 				},
 			},
 		},
+		"ok, date histogram": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "check_in",
+						Scope:     ScopeSystem,
+						Attribute: "check_in_time",
+						Type:      AggregationTypeDateHistogram,
+						Interval:  "day",
+						TimeZone:  "Europe/Oslo",
+					},
+				},
+			},
+		},
+		"ok, date histogram with UTC offset": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "check_in",
+						Scope:     ScopeSystem,
+						Attribute: "check_in_time",
+						Type:      AggregationTypeDateHistogram,
+						Interval:  "hour",
+						TimeZone:  "-05:00",
+					},
+				},
+			},
+		},
+		"ok, stats": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mem",
+						Scope:     ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      AggregationTypeStats,
+					},
+				},
+			},
+		},
+		"ok, percentiles": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mem",
+						Scope:     ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      AggregationTypePercentiles,
+						Percents:  []float64{50, 99.9},
+					},
+				},
+			},
+		},
+		"ko, percents out of range": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mem",
+						Scope:     ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      AggregationTypePercentiles,
+						Percents:  []float64{50, 101},
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (percents: (1: must be no greater " +
+				"than 100.).).)."),
+		},
+		"ko, percents on stats aggregation": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mem",
+						Scope:     ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      AggregationTypeStats,
+						Percents:  []float64{50},
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (percents: must be blank.).)."),
+		},
+		"ko, sub-aggregations of metrics aggregation": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mem",
+						Scope:     ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      AggregationTypeStats,
+						Aggregations: []AggregationTerm{
+							{
+								Name:      "mac",
+								Scope:     ScopeIdentity,
+								Attribute: "mac",
+							},
+						},
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (aggregations: metrics aggregations " +
+				"do not support sub-aggregations.).)."),
+		},
+		"ko, unknown aggregation type": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mac",
+						Scope:     ScopeIdentity,
+						Attribute: "mac",
+						Type:      "histogram",
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (type: must be a valid value.).)."),
+		},
+		"ko, date histogram without interval": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "check_in",
+						Scope:     ScopeSystem,
+						Attribute: "check_in_time",
+						Type:      AggregationTypeDateHistogram,
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (interval: cannot be blank.).)."),
+		},
+		"ko, date histogram with invalid interval": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "check_in",
+						Scope:     ScopeSystem,
+						Attribute: "check_in_time",
+						Type:      AggregationTypeDateHistogram,
+						Interval:  "fortnight",
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (interval: must be a valid value.).)."),
+		},
+		"ko, date histogram with invalid time zone": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "check_in",
+						Scope:     ScopeSystem,
+						Attribute: "check_in_time",
+						Type:      AggregationTypeDateHistogram,
+						Interval:  "week",
+						TimeZone:  "Mars/Olympus_Mons",
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (time_zone: must be a valid " +
+				"time zone name or UTC offset.).)."),
+		},
+		"ko, interval on terms aggregation": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "mac",
+						Scope:     ScopeIdentity,
+						Attribute: "mac",
+						Interval:  "day",
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (interval: must be blank.).)."),
+		},
 		"ko, filter fails validation": {
 			params: AggregateParams{
 				Filters: []FilterPredicate{
@@ -308,6 +480,100 @@ func TestBuildAggregations(t *testing.T) {
 				},
 			},
 		},
+		"ok, stats": {
+			terms: []AggregationTerm{
+				{
+					Name:      "mem",
+					Attribute: "mem_total_kB",
+					Scope:     "inventory",
+					Type:      AggregationTypeStats,
+				},
+			},
+			res: &Aggregations{
+				"mem": map[string]interface{}{
+					"stats": map[string]interface{}{
+						"field": "inventory_mem_total_kB_num",
+					},
+				},
+			},
+		},
+		"ok, percentiles nested in terms": {
+			terms: []AggregationTerm{
+				{
+					Name:      "device_type",
+					Attribute: "device_type",
+					Scope:     "inventory",
+					Aggregations: []AggregationTerm{
+						{
+							Name:      "mem",
+							Attribute: "mem_total_kB",
+							Scope:     "inventory",
+							Type:      AggregationTypePercentiles,
+							Percents:  []float64{50, 95},
+						},
+					},
+				},
+			},
+			res: &Aggregations{
+				"device_type": map[string]interface{}{
+					"terms": map[string]interface{}{
+						"field": "inventory_device_type_str",
+						"size":  defaultAggregationLimit,
+					},
+					"aggs": &Aggregations{
+						"mem": map[string]interface{}{
+							"percentiles": map[string]interface{}{
+								"field":    "inventory_mem_total_kB_num",
+								"percents": []float64{50, 95},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ok, date histogram": {
+			terms: []AggregationTerm{
+				{
+					Name:      "check_in",
+					Attribute: "check_in_time",
+					Scope:     "system",
+					Type:      AggregationTypeDateHistogram,
+					Interval:  "day",
+					TimeZone:  "Europe/Oslo",
+					Aggregations: []AggregationTerm{
+						{
+							Name:      "aggregation",
+							Attribute: "attribute",
+							Scope:     "scope",
+						},
+					},
+				},
+			},
+			res: &Aggregations{
+				"check_in": map[string]interface{}{
+					"date_histogram": map[string]interface{}{
+						"calendar_interval": "day",
+						"time_zone":         "Europe/Oslo",
+						"format":            dateHistogramFormat,
+						"min_doc_count":     0,
+						"script": map[string]interface{}{
+							"source": dateHistogramScript,
+							"params": map[string]interface{}{
+								"field": "system_check_in_time_str",
+							},
+						},
+					},
+					"aggs": &Aggregations{
+						"aggregation": map[string]interface{}{
+							"terms": map[string]interface{}{
+								"field": "scope_attribute_str",
+								"size":  defaultAggregationLimit,
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range testCases {
@@ -321,3 +587,64 @@ func TestBuildAggregations(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateParamsValidateMaxNestedAggregations(t *testing.T) {
+	SetMaxNestedAggregations(1)
+	defer SetMaxNestedAggregations(defaultMaxNestedAggregations)
+
+	byGroupAndArtifact := AggregationTerm{
+		Name:      "group",
+		Scope:     ScopeSystem,
+		Attribute: AttrNameGroup,
+		Aggregations: []AggregationTerm{
+			{
+				Name:      "artifact",
+				Scope:     ScopeInventory,
+				Attribute: "artifact_name",
+			},
+			{
+				Name:      "device_type",
+				Scope:     ScopeInventory,
+				Attribute: "device_type",
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		params AggregateParams
+		err    error
+	}{
+		"ok": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{byGroupAndArtifact},
+			},
+		},
+		"ko, too many nested aggregations in a sibling": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{
+					{
+						Name:      "group",
+						Scope:     ScopeSystem,
+						Attribute: AttrNameGroup,
+						Aggregations: []AggregationTerm{
+							byGroupAndArtifact.Aggregations[0],
+							byGroupAndArtifact,
+						},
+					},
+				},
+			},
+			err: errors.New("aggregations: (0: (aggregations: too many nested aggregations, limit is 1.).)."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, tc.err, err.Error())
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
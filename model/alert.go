@@ -0,0 +1,175 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+const maxAlertNameLength = 256
+
+const (
+	// AlertSourceDevices counts the devices matching the filters
+	AlertSourceDevices = "devices"
+	// AlertSourceDeployments counts the device deployments matching the
+	// filters
+	AlertSourceDeployments = "deployments"
+
+	AlertOperatorGreaterThan        = "$gt"
+	AlertOperatorGreaterThanOrEqual = "$gte"
+	AlertOperatorLessThan           = "$lt"
+	AlertOperatorLessThanOrEqual    = "$lte"
+)
+
+var (
+	validAlertSources = []interface{}{
+		AlertSourceDevices,
+		AlertSourceDeployments,
+	}
+	validAlertOperators = []interface{}{
+		AlertOperatorGreaterThan,
+		AlertOperatorGreaterThanOrEqual,
+		AlertOperatorLessThan,
+		AlertOperatorLessThanOrEqual,
+	}
+)
+
+// Alert notifies a webhook when the number of devices, or of deployments,
+// matching its filters crosses a threshold
+type Alert struct {
+	ID       string `json:"id" bson:"_id"`
+	TenantID string `json:"-" bson:"tenant_id"`
+	Name     string `json:"name" bson:"name"`
+	// Source is what the alert counts: devices or deployments; the scope
+	// of the filters is ignored for the deployments
+	Source    string            `json:"source" bson:"source"`
+	Filters   []FilterPredicate `json:"filters" bson:"filters"`
+	Threshold AlertThreshold    `json:"threshold" bson:"threshold"`
+	Webhook   AlertWebhook      `json:"webhook" bson:"webhook"`
+	State     *AlertState       `json:"state,omitempty" bson:"state,omitempty"`
+	CreatedTs time.Time         `json:"created_ts" bson:"created_ts"`
+	UpdatedTs time.Time         `json:"updated_ts" bson:"updated_ts"`
+}
+
+// AlertThreshold is the condition which triggers the alert
+type AlertThreshold struct {
+	// Operator compares the count to the value: $gt, $gte, $lt or $lte
+	Operator string `json:"operator" bson:"operator"`
+	Value    int    `json:"value" bson:"value"`
+}
+
+// Exceeded returns true if the count satisfies the condition
+func (t AlertThreshold) Exceeded(count int) bool {
+	switch t.Operator {
+	case AlertOperatorGreaterThan:
+		return count > t.Value
+	case AlertOperatorGreaterThanOrEqual:
+		return count >= t.Value
+	case AlertOperatorLessThan:
+		return count < t.Value
+	case AlertOperatorLessThanOrEqual:
+		return count <= t.Value
+	}
+	return false
+}
+
+func (t AlertThreshold) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Operator, validation.Required,
+			validation.In(validAlertOperators...)),
+		validation.Field(&t.Value, validation.Min(0)),
+	)
+}
+
+// AlertWebhook is the webhook notified when the alert triggers or resolves
+type AlertWebhook struct {
+	URL string `json:"url" bson:"url"`
+	// Secret, if not empty, signs the notifications with HMAC-SHA256; it
+	// is never returned by the API
+	Secret string `json:"secret,omitempty" bson:"secret,omitempty"`
+}
+
+func (w AlertWebhook) Validate() error {
+	return validation.ValidateStruct(&w,
+		validation.Field(&w.URL, validation.Required, validation.By(checkWebhookURL)),
+	)
+}
+
+func checkWebhookURL(value interface{}) error {
+	s, _ := value.(string)
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be a valid http or https URL")
+	}
+	return nil
+}
+
+// AlertState is the outcome of the last evaluation of the alert
+type AlertState struct {
+	// Triggered is true if the count satisfied the threshold at the last
+	// notified evaluation
+	Triggered bool `json:"triggered" bson:"triggered"`
+	// Count is the count at the last evaluation
+	Count       int        `json:"count" bson:"count"`
+	EvaluatedTs *time.Time `json:"evaluated_ts,omitempty" bson:"evaluated_ts,omitempty"`
+	TriggeredTs *time.Time `json:"triggered_ts,omitempty" bson:"triggered_ts,omitempty"`
+}
+
+func (a Alert) Validate() error {
+	err := validation.ValidateStruct(&a,
+		validation.Field(&a.Name, validation.Required,
+			validation.Length(1, maxAlertNameLength)),
+		validation.Field(&a.Source, validation.Required,
+			validation.In(validAlertSources...)),
+		validation.Field(&a.Threshold),
+		validation.Field(&a.Webhook),
+	)
+	if err != nil {
+		return err
+	}
+	if a.Source == AlertSourceDeployments {
+		return a.DeploymentsSearchParams().Validate()
+	}
+	return a.SearchParams().Validate()
+}
+
+// SearchParams returns the search parameters of the devices counted by
+// the alert
+func (a Alert) SearchParams() *SearchParams {
+	return &SearchParams{
+		Filters:  a.Filters,
+		TenantID: a.TenantID,
+	}
+}
+
+// DeploymentsSearchParams returns the search parameters of the deployments
+// counted by the alert
+func (a Alert) DeploymentsSearchParams() *DeploymentsSearchParams {
+	params := &DeploymentsSearchParams{
+		TenantID: a.TenantID,
+	}
+	for _, f := range a.Filters {
+		params.Filters = append(params.Filters, DeploymentsFilterPredicate{
+			Attribute: f.Attribute,
+			Type:      f.Type,
+			Value:     f.Value,
+		})
+	}
+	return params
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertValidate(t *testing.T) {
+	threshold := AlertThreshold{
+		Operator: AlertOperatorGreaterThan,
+		Value:    100,
+	}
+	webhook := AlertWebhook{
+		URL: "https://hooks.example.com/alerts",
+	}
+	testCases := map[string]struct {
+		alert Alert
+		err   error
+	}{
+		"ok, devices": {
+			alert: Alert{
+				Name:   "production",
+				Source: AlertSourceDevices,
+				Filters: []FilterPredicate{{
+					Scope:     ScopeSystem,
+					Attribute: AttrNameGroup,
+					Type:      "$eq",
+					Value:     "production",
+				}},
+				Threshold: threshold,
+				Webhook:   webhook,
+			},
+		},
+		"ok, deployments": {
+			alert: Alert{
+				Name:   "failures",
+				Source: AlertSourceDeployments,
+				Filters: []FilterPredicate{{
+					Attribute: "status",
+					Type:      "$eq",
+					Value:     "failure",
+				}},
+				Threshold: threshold,
+				Webhook:   webhook,
+			},
+		},
+		"ko, missing name": {
+			alert: Alert{
+				Source:    AlertSourceDevices,
+				Threshold: threshold,
+				Webhook:   webhook,
+			},
+			err: errors.New("name: cannot be blank."),
+		},
+		"ko, invalid source": {
+			alert: Alert{
+				Name:      "production",
+				Source:    "artifacts",
+				Threshold: threshold,
+				Webhook:   webhook,
+			},
+			err: errors.New("source: must be a valid value."),
+		},
+		"ko, invalid threshold": {
+			alert: Alert{
+				Name:   "production",
+				Source: AlertSourceDevices,
+				Threshold: AlertThreshold{
+					Operator: "$eq",
+					Value:    -1,
+				},
+				Webhook: webhook,
+			},
+			err: errors.New("threshold: (operator: must be a valid value; " +
+				"value: must be no less than 0.)."),
+		},
+		"ko, invalid webhook": {
+			alert: Alert{
+				Name:      "production",
+				Source:    AlertSourceDevices,
+				Threshold: threshold,
+				Webhook:   AlertWebhook{URL: "hooks.example.com"},
+			},
+			err: errors.New("webhook: (url: must be a valid http or https URL.)."),
+		},
+		"ko, invalid filter": {
+			alert: Alert{
+				Name:   "production",
+				Source: AlertSourceDevices,
+				Filters: []FilterPredicate{{
+					Scope:     ScopeSystem,
+					Attribute: AttrNameGroup,
+					Type:      "$nope",
+					Value:     "production",
+				}},
+				Threshold: threshold,
+				Webhook:   webhook,
+			},
+			err: errors.New("type: must be a valid value."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.alert.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAlertThresholdExceeded(t *testing.T) {
+	testCases := map[string]struct {
+		operator string
+		count    int
+		exceeded bool
+	}{
+		"$gt, below":     {operator: AlertOperatorGreaterThan, count: 9},
+		"$gt, equal":     {operator: AlertOperatorGreaterThan, count: 10},
+		"$gt, above":     {operator: AlertOperatorGreaterThan, count: 11, exceeded: true},
+		"$gte, equal":    {operator: AlertOperatorGreaterThanOrEqual, count: 10, exceeded: true},
+		"$lt, below":     {operator: AlertOperatorLessThan, count: 9, exceeded: true},
+		"$lt, equal":     {operator: AlertOperatorLessThan, count: 10},
+		"$lte, equal":    {operator: AlertOperatorLessThanOrEqual, count: 10, exceeded: true},
+		"$lte, above":    {operator: AlertOperatorLessThanOrEqual, count: 11},
+		"unknown, above": {operator: "$eq", count: 11},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			threshold := AlertThreshold{Operator: tc.operator, Value: 10}
+			assert.Equal(t, tc.exceeded, threshold.Exceeded(tc.count))
+		})
+	}
+}
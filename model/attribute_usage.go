@@ -0,0 +1,75 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"path"
+	"time"
+)
+
+// AttributeUse is how a query uses an attribute
+type AttributeUse int
+
+const (
+	AttributeUseFilter AttributeUse = iota
+	AttributeUseSort
+	AttributeUseAggregation
+)
+
+// AttributeUsage is the number of times the queries of a tenant used an
+// attribute, by use
+type AttributeUsage struct {
+	Scope string `json:"scope" bson:"scope"`
+	Name  string `json:"name" bson:"name"`
+	// Mapped is true if the attribute takes a slot of the tenant's mapping
+	Mapped       bool  `json:"mapped" bson:"-"`
+	Filters      int64 `json:"filters" bson:"filters"`
+	Sorts        int64 `json:"sorts" bson:"sorts"`
+	Aggregations int64 `json:"aggregations" bson:"aggregations"`
+	// LastUsedTs is when a query last used the attribute; nil if never
+	LastUsedTs *time.Time `json:"last_used_ts" bson:"last_used_ts,omitempty"`
+}
+
+// Key returns the key of the attribute, as in the mapping
+func (u *AttributeUsage) Key() string {
+	return path.Join(u.Scope, u.Name)
+}
+
+// Add counts a use of the attribute at the given time
+func (u *AttributeUsage) Add(use AttributeUse, ts time.Time) {
+	switch use {
+	case AttributeUseFilter:
+		u.Filters++
+	case AttributeUseSort:
+		u.Sorts++
+	case AttributeUseAggregation:
+		u.Aggregations++
+	}
+	if u.LastUsedTs == nil || ts.After(*u.LastUsedTs) {
+		u.LastUsedTs = &ts
+	}
+}
+
+// Total returns the number of uses of the attribute
+func (u *AttributeUsage) Total() int64 {
+	return u.Filters + u.Sorts + u.Aggregations
+}
+
+// AttributesUsage is the usage of the attributes by the queries of a
+// tenant, along with the attributes of the tenant's mapping never used
+type AttributesUsage struct {
+	TenantID   string           `json:"tenant_id"`
+	Attributes []AttributeUsage `json:"attributes"`
+}
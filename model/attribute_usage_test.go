@@ -0,0 +1,42 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeUsage(t *testing.T) {
+	t.Parallel()
+
+	first := time.Date(2023, 3, 6, 7, 0, 0, 0, time.UTC)
+	usage := &AttributeUsage{Scope: ScopeInventory, Name: "dir/name"}
+	assert.Equal(t, "inventory/dir/name", usage.Key())
+
+	usage.Add(AttributeUseFilter, first)
+	usage.Add(AttributeUseFilter, first.Add(time.Minute))
+	usage.Add(AttributeUseSort, first.Add(-time.Minute))
+	usage.Add(AttributeUseAggregation, first)
+	assert.Equal(t, int64(2), usage.Filters)
+	assert.Equal(t, int64(1), usage.Sorts)
+	assert.Equal(t, int64(1), usage.Aggregations)
+	assert.Equal(t, int64(4), usage.Total())
+	if assert.NotNil(t, usage.LastUsedTs) {
+		assert.Equal(t, first.Add(time.Minute), *usage.LastUsedTs)
+	}
+}
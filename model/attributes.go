@@ -22,6 +22,12 @@ const (
 	TypeStr
 	TypeNum
 	TypeBool
+	// TypeVersion is the sortable form of the string attributes which
+	// are versions, see NormalizeVersion
+	TypeVersion
+	// TypeDate is the type of the attributes declared to be indexed as
+	// dates, see MappingAttributeType
+	TypeDate
 )
 
 // scope prefixes
@@ -41,6 +47,11 @@ const (
 	AttrNameCreatedAt              = "created_ts"
 	AttrNameUpdatedAt              = "updated_ts"
 	AttrNameLatestDeploymentStatus = "latest_deployment_status"
+	AttrNameLocation               = "location"
+	AttrNameCheckInTime            = "check_in_time"
+	// AttrNameConnectivity is the derived attribute of the connectivity
+	// state of the devices, see NewFilterConnectivity
+	AttrNameConnectivity = "connectivity"
 )
 
 const (
@@ -48,20 +59,27 @@ const (
 	FieldNameDeploymentID = "deployment_id"
 	FieldNameDeviceID     = "device_id"
 	FieldNameTenantID     = "tenant_id"
+	FieldNameLocation     = "location"
+	FieldNameCheckInTime  = "check_in_time"
+	FieldNameUpdatedAt    = "updated_at"
 )
 
 // type enum/suffixes
 const (
-	typeStr  = "str"
-	typeNum  = "num"
-	typeBool = "bool"
+	typeStr     = "str"
+	typeNum     = "num"
+	typeBool    = "bool"
+	typeVersion = "ver"
+	typeDate    = "date"
 )
 
 var (
 	attrSuffixes = map[Type]string{
-		TypeStr:  typeStr,
-		TypeNum:  typeNum,
-		TypeBool: typeBool,
+		TypeStr:     typeStr,
+		TypeNum:     typeNum,
+		TypeBool:    typeBool,
+		TypeVersion: typeVersion,
+		TypeDate:    typeDate,
 	}
 )
 
@@ -0,0 +1,97 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	defaultAuditLogsPerPage = 20
+	maxAuditLogsPerPage     = 500
+)
+
+const (
+	// SubjectTypeUser is the type of the subjects of the user sessions
+	SubjectTypeUser = "user"
+	// SubjectTypePersonalAccessToken is the type of the subjects of the
+	// personal access tokens, issued to a user for the automation
+	SubjectTypePersonalAccessToken = "personal_access_token"
+	// SubjectTypeServiceAccount is the type of the subjects of the
+	// service accounts, which belong to the tenant rather than to a user
+	SubjectTypeServiceAccount = "service_account"
+)
+
+// AuditLogEntry records a request to the management API: who sent it,
+// when, and the query it executed
+type AuditLogEntry struct {
+	ID       string `json:"id" bson:"_id"`
+	TenantID string `json:"tenant_id" bson:"tenant_id"`
+	// Subject is the subject of the JWT the request was authenticated with
+	Subject string `json:"subject" bson:"subject"`
+	// SubjectType is the type of the subject, e.g. a user or a service
+	// account
+	SubjectType string `json:"subject_type,omitempty" bson:"subject_type,omitempty"`
+	// TokenID is the ID of the personal access token the request was
+	// authenticated with, if any
+	TokenID   string `json:"token_id,omitempty" bson:"token_id,omitempty"`
+	RequestID string `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	Method    string `json:"method" bson:"method"`
+	Path      string `json:"path" bson:"path"`
+	// Query is the raw query string of the request
+	Query string `json:"query,omitempty" bson:"query,omitempty"`
+	// Body is the body of the request, truncated if too long
+	Body      string    `json:"body,omitempty" bson:"body,omitempty"`
+	Status    int       `json:"status" bson:"status"`
+	CreatedTs time.Time `json:"created_ts" bson:"created_ts"`
+	// ExpireTs is the time the entry is deleted at, if set
+	ExpireTs *time.Time `json:"-" bson:"expire_ts,omitempty"`
+}
+
+// AuditLogsParams are the parameters of the audit trail search
+type AuditLogsParams struct {
+	TenantID string    `json:"tenant_id" form:"tenant_id"`
+	Subject  string    `json:"subject" form:"subject"`
+	From     time.Time `json:"from" form:"from"`
+	To       time.Time `json:"to" form:"to"`
+	Page     int       `json:"page" form:"page"`
+	PerPage  int       `json:"per_page" form:"per_page"`
+}
+
+func (p AuditLogsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.To, validation.By(func(interface{}) error {
+			if !p.From.IsZero() && !p.To.IsZero() && p.To.Before(p.From) {
+				return validation.NewError("validation_to_before_from",
+					"must not be before from")
+			}
+			return nil
+		})),
+		validation.Field(&p.Page, validation.Min(0)),
+		validation.Field(&p.PerPage, validation.Min(0), validation.Max(maxAuditLogsPerPage)),
+	)
+}
+
+// Normalize sets the default values of the pagination parameters
+func (p *AuditLogsParams) Normalize() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.PerPage <= 0 {
+		p.PerPage = defaultAuditLogsPerPage
+	}
+}
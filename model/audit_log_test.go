@@ -0,0 +1,76 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogsParamsValidate(t *testing.T) {
+	from := time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		params AuditLogsParams
+		err    error
+	}{
+		"ok": {
+			params: AuditLogsParams{
+				TenantID: "tenant",
+				Subject:  "user",
+				From:     from,
+				To:       from.Add(time.Hour),
+				Page:     2,
+				PerPage:  maxAuditLogsPerPage,
+			},
+		},
+		"ok, defaults": {},
+		"ko, to before from": {
+			params: AuditLogsParams{
+				From: from,
+				To:   from.Add(-time.Hour),
+			},
+			err: errors.New("to: must not be before from."),
+		},
+		"ko, too many per page": {
+			params: AuditLogsParams{
+				PerPage: maxAuditLogsPerPage + 1,
+			},
+			err: errors.New("per_page: must be no greater than 500."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuditLogsParamsNormalize(t *testing.T) {
+	params := AuditLogsParams{}
+	params.Normalize()
+	assert.Equal(t, AuditLogsParams{
+		Page:    1,
+		PerPage: defaultAuditLogsPerPage,
+	}, params)
+}
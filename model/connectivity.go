@@ -0,0 +1,128 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ConnectivityOnline  = "online"
+	ConnectivityOffline = "offline"
+
+	defaultConnectivityWindow = 24 * time.Hour
+)
+
+var ErrConnectivityFilter = errors.New("the connectivity filters support only the " +
+	"$eq and $ne types, with the values " + ConnectivityOnline + " and " +
+	ConnectivityOffline)
+
+var connectivityWindow = defaultConnectivityWindow
+
+// SetConnectivityWindow sets the staleness window of the connectivity
+// state: the devices which did not check in within the window are offline
+func SetConnectivityWindow(window time.Duration) {
+	connectivityWindow = window
+}
+
+// ConnectivityWindow returns the staleness window of the connectivity state
+func ConnectivityWindow() time.Duration {
+	return connectivityWindow
+}
+
+// IsConnectivityAttribute returns true if the attribute is the derived
+// connectivity attribute, computed from the check-in time of the devices
+func IsConnectivityAttribute(scope, attribute string) bool {
+	return scope == ScopeSystem && attribute == AttrNameConnectivity
+}
+
+// checkInSince returns the date math expression of the start of the window
+func checkInSince(window time.Duration) string {
+	return fmt.Sprintf("now-%ds", int64(window/time.Second))
+}
+
+type filterConnectivity struct {
+	online bool
+	window time.Duration
+}
+
+// NewFilterConnectivity returns the query part of a filter on the derived
+// connectivity attribute: the devices are online if they checked in within
+// the connectivity window, offline otherwise, including the devices which
+// never checked in
+func NewFilterConnectivity(fp FilterPredicate) (*filterConnectivity, error) {
+	value, _ := fp.Value.(string)
+	if value != ConnectivityOnline && value != ConnectivityOffline {
+		return nil, ErrConnectivityFilter
+	}
+	online := value == ConnectivityOnline
+	switch fp.Type {
+	case "$eq":
+	case "$ne":
+		online = !online
+	default:
+		return nil, ErrConnectivityFilter
+	}
+	return newFilterConnectivity(online, connectivityWindow), nil
+}
+
+func newFilterConnectivity(online bool, window time.Duration) *filterConnectivity {
+	return &filterConnectivity{
+		online: online,
+		window: window,
+	}
+}
+
+func (f *filterConnectivity) AddTo(q Query) Query {
+	checkedIn := M{
+		"range": M{
+			FieldNameCheckInTime: M{
+				"gte": checkInSince(f.window),
+			},
+		},
+	}
+	if f.online {
+		return q.Must(checkedIn)
+	}
+	return q.Must(M{
+		"bool": M{
+			"must_not": checkedIn,
+		},
+	})
+}
+
+// connectivityAggregation returns the range aggregation counting the
+// devices by connectivity state
+func connectivityAggregation() map[string]interface{} {
+	since := checkInSince(connectivityWindow)
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			"field": FieldNameCheckInTime,
+			"ranges": []interface{}{
+				map[string]interface{}{
+					"key": ConnectivityOffline,
+					"to":  since,
+				},
+				map[string]interface{}{
+					"key":  ConnectivityOnline,
+					"from": since,
+				},
+			},
+		},
+	}
+}
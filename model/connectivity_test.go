@@ -0,0 +1,135 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterConnectivity(t *testing.T) {
+	t.Parallel()
+
+	online := M{
+		"range": M{
+			FieldNameCheckInTime: M{
+				"gte": "now-86400s",
+			},
+		},
+	}
+	offline := M{
+		"bool": M{
+			"must_not": online,
+		},
+	}
+	testCases := map[string]struct {
+		Predicate FilterPredicate
+
+		Query Query
+		Error error
+	}{
+		"ok, online": {
+			Predicate: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameConnectivity,
+				Type:      "$eq",
+				Value:     ConnectivityOnline,
+			},
+			Query: NewQuery().Must(online),
+		},
+		"ok, offline": {
+			Predicate: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameConnectivity,
+				Type:      "$eq",
+				Value:     ConnectivityOffline,
+			},
+			Query: NewQuery().Must(offline),
+		},
+		"ok, not online": {
+			Predicate: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameConnectivity,
+				Type:      "$ne",
+				Value:     ConnectivityOnline,
+			},
+			Query: NewQuery().Must(offline),
+		},
+		"error, unknown state": {
+			Predicate: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameConnectivity,
+				Type:      "$eq",
+				Value:     "sleeping",
+			},
+			Error: ErrConnectivityFilter,
+		},
+		"error, unsupported type": {
+			Predicate: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameConnectivity,
+				Type:      "$in",
+				Value:     []interface{}{ConnectivityOnline},
+			},
+			Error: ErrConnectivityFilter,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.Predicate.Validate()
+			part, partErr := getFilterPart(tc.Predicate)
+			if tc.Error != nil {
+				assert.EqualError(t, err, tc.Error.Error())
+				assert.EqualError(t, partErr, tc.Error.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, partErr)
+				assert.Equal(t, tc.Query, part.AddTo(NewQuery()))
+			}
+		})
+	}
+}
+
+func TestConnectivityAggregation(t *testing.T) {
+	t.Parallel()
+
+	aggs, err := BuildAggregations([]AggregationTerm{{
+		Name:      "connectivity",
+		Scope:     ScopeSystem,
+		Attribute: AttrNameConnectivity,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, &Aggregations{
+		"connectivity": map[string]interface{}{
+			"range": map[string]interface{}{
+				"field": FieldNameCheckInTime,
+				"ranges": []interface{}{
+					map[string]interface{}{
+						"key": ConnectivityOffline,
+						"to":  "now-86400s",
+					},
+					map[string]interface{}{
+						"key":  ConnectivityOnline,
+						"from": "now-86400s",
+					},
+				},
+			},
+		},
+	}, aggs)
+}
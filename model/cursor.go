@@ -0,0 +1,55 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// CursorStart is the cursor value which starts a new cursor-based iteration
+// over the search results
+const CursorStart = "*"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a cursor-based iteration over the search
+// results: a point in time of the index, which keeps the results consistent
+// across the pages, and the sort values of the last result returned
+type Cursor struct {
+	PointInTimeID string        `json:"pit"`
+	SearchAfter   []interface{} `json:"search_after,omitempty"`
+}
+
+// ParseCursor decodes a cursor token
+func ParseCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.PointInTimeID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// String encodes the cursor as an opaque token
+func (c *Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
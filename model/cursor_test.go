@@ -0,0 +1,43 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := &Cursor{
+		PointInTimeID: "pit",
+		SearchAfter:   []interface{}{"value", float64(1)},
+	}
+	parsed, err := ParseCursor(cursor.String())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+
+	testCases := map[string]string{
+		"not base64":     "not a cursor!",
+		"not json":       "bm90IGpzb24",
+		"missing pit id": (&Cursor{}).String(),
+	}
+	for name, token := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseCursor(token)
+			assert.Equal(t, ErrInvalidCursor, err)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	defaultDeadLettersPerPage = 20
+	maxDeadLettersPerPage     = 500
+)
+
+// DeadLetter is a message the indexer failed to process, kept to be
+// inspected and replayed
+type DeadLetter struct {
+	ID       string `json:"id" bson:"_id"`
+	TenantID string `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	// Subject is the subject the message was published to
+	Subject string `json:"subject" bson:"subject"`
+	// Data is the payload of the message
+	Data string `json:"data" bson:"data"`
+	// Reason is the error the last delivery of the message failed with
+	Reason     string    `json:"reason" bson:"reason"`
+	Deliveries int       `json:"deliveries" bson:"deliveries"`
+	CreatedTs  time.Time `json:"created_ts" bson:"created_ts"`
+}
+
+// DeadLettersParams are the parameters of the dead letters listing
+type DeadLettersParams struct {
+	TenantID string `json:"tenant_id" form:"tenant_id"`
+	Page     int    `json:"page" form:"page"`
+	PerPage  int    `json:"per_page" form:"per_page"`
+}
+
+func (p DeadLettersParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Page, validation.Min(0)),
+		validation.Field(&p.PerPage, validation.Min(0), validation.Max(maxDeadLettersPerPage)),
+	)
+}
+
+// Normalize sets the default values of the pagination parameters
+func (p *DeadLettersParams) Normalize() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.PerPage <= 0 {
+		p.PerPage = defaultDeadLettersPerPage
+	}
+}
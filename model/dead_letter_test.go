@@ -0,0 +1,74 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLettersParamsValidate(t *testing.T) {
+	testCases := map[string]struct {
+		params DeadLettersParams
+		err    error
+	}{
+		"ok": {
+			params: DeadLettersParams{
+				TenantID: "tenant",
+				Page:     2,
+				PerPage:  maxDeadLettersPerPage,
+			},
+		},
+		"ok, defaults": {},
+		"ko, negative page": {
+			params: DeadLettersParams{
+				Page: -1,
+			},
+			err: errors.New("page: must be no less than 0."),
+		},
+		"ko, too many per page": {
+			params: DeadLettersParams{
+				PerPage: maxDeadLettersPerPage + 1,
+			},
+			err: errors.New("per_page: must be no greater than 500."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeadLettersParamsNormalize(t *testing.T) {
+	params := DeadLettersParams{}
+	params.Normalize()
+	assert.Equal(t, DeadLettersParams{
+		Page:    1,
+		PerPage: defaultDeadLettersPerPage,
+	}, params)
+
+	params = DeadLettersParams{Page: 3, PerPage: 50}
+	params.Normalize()
+	assert.Equal(t, DeadLettersParams{Page: 3, PerPage: 50}, params)
+}
@@ -0,0 +1,28 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import "time"
+
+// DecommissionedDevice is a decommissioned device whose deployments
+// history is kept for a grace period, and purged afterwards
+type DecommissionedDevice struct {
+	TenantID         string    `json:"tenant_id" bson:"tenant_id"`
+	DeviceID         string    `json:"device_id" bson:"device_id"`
+	DecommissionedTs time.Time `json:"decommissioned_ts" bson:"decommissioned_ts"`
+	// PurgeTs is the time the deployments history of the device is
+	// purged at
+	PurgeTs time.Time `json:"purge_ts" bson:"purge_ts"`
+}
@@ -14,44 +14,130 @@
 
 package model
 
-import "time"
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
 
 //nolint:lll
 type Deployment struct {
-	ID                          string                 `json:"id"`
-	TenantID                    string                 `json:"tenant_id"`
-	DeviceID                    string                 `json:"device_id"`
-	DeploymentID                string                 `json:"deployment_id"`
-	DeploymentName              string                 `json:"deployment_name"`
-	DeploymentArtifactName      string                 `json:"deployment_artifact_name"`
-	DeploymentType              string                 `json:"deployment_type"`
-	DeploymentCreated           *time.Time             `json:"deployment_created"`
-	DeploymentFilterID          string                 `json:"deployment_filter_id,omitempty"`
-	DeploymentAllDevices        bool                   `json:"deployment_all_devices"`
-	DeploymentForceInstallation bool                   `json:"deployment_force_installation"`
-	DeploymentGroup             string                 `json:"deployment_group,omitempty"`
-	DeploymentPhased            bool                   `json:"deployment_phased"`
-	DeploymentPhaseId           string                 `json:"deployment_phase_id,omitempty"`
-	DeploymentRetries           uint                   `json:"deployment_retries"`
-	DeploymentMaxDevices        uint                   `json:"deployment_max_devices"`
-	DeploymentAutogenerateDelta bool                   `json:"deployment_autogenerate_deta"`
-	DeviceCreated               *time.Time             `json:"device_created"`
-	DeviceFinished              *time.Time             `json:"device_finished"`
-	DeviceElapsedSeconds        uint                   `json:"device_elapsed_seconds"`
-	DeviceDeleted               *time.Time             `json:"device_deleted,omitempty"`
-	DeviceStatus                string                 `json:"device_status"`
-	DeviceIsLogAvailable        bool                   `json:"device_is_log_available"`
-	DeviceRetries               uint                   `json:"device_retries"`
-	DeviceAttempts              uint                   `json:"device_attempts"`
-	ImageID                     string                 `json:"image_id,omitempty"`
-	ImageDescription            string                 `json:"image_description,omitempty"`
-	ImageArtifactName           string                 `json:"image_artifact_name"`
-	ImageDeviceTypes            []string               `json:"image_device_types"`
-	ImageSigned                 bool                   `json:"image_signed"`
-	ImageArtifactInfoFormat     string                 `json:"image_artifact_info_format,omitempty"`
-	ImageArtifactInfoVersion    uint                   `json:"image_artifact_info_version,omitempty"`
-	ImageProvides               map[string]string      `json:"image_provides,omitempty"`
-	ImageDepends                map[string]interface{} `json:"image_depends,omitempty"`
-	ImageClearsProvides         []string               `json:"image_clears_provides,omitempty"`
-	ImageSize                   int64                  `json:"image_size,omitempty"`
+	ID                            string                 `json:"id"`
+	TenantID                      string                 `json:"tenant_id"`
+	DeviceID                      string                 `json:"device_id"`
+	DeploymentID                  string                 `json:"deployment_id"`
+	DeploymentName                string                 `json:"deployment_name"`
+	DeploymentArtifactName        string                 `json:"deployment_artifact_name"`
+	DeploymentType                string                 `json:"deployment_type"`
+	DeploymentCreated             *time.Time             `json:"deployment_created"`
+	DeploymentFilterID            string                 `json:"deployment_filter_id,omitempty"`
+	DeploymentAllDevices          bool                   `json:"deployment_all_devices"`
+	DeploymentForceInstallation   bool                   `json:"deployment_force_installation"`
+	DeploymentGroup               string                 `json:"deployment_group,omitempty"`
+	DeploymentPhased              bool                   `json:"deployment_phased"`
+	DeploymentPhaseId             string                 `json:"deployment_phase_id,omitempty"`
+	DeploymentRetries             uint                   `json:"deployment_retries"`
+	DeploymentMaxDevices          uint                   `json:"deployment_max_devices"`
+	DeploymentAutogenerateDelta   bool                   `json:"deployment_autogenerate_deta"`
+	DeploymentArtifactIDs         []string               `json:"deployment_artifact_ids,omitempty"`
+	DeploymentArtifactDeviceTypes []string               `json:"deployment_artifact_device_types,omitempty"`
+	DeploymentArtifactSizes       []int64                `json:"deployment_artifact_sizes,omitempty"`
+	DeviceCreated                 *time.Time             `json:"device_created"`
+	DeviceFinished                *time.Time             `json:"device_finished"`
+	DeviceElapsedSeconds          uint                   `json:"device_elapsed_seconds"`
+	DeviceDeleted                 *time.Time             `json:"device_deleted,omitempty"`
+	DeviceStatus                  string                 `json:"device_status"`
+	DeviceIsLogAvailable          bool                   `json:"device_is_log_available"`
+	DeviceRetries                 uint                   `json:"device_retries"`
+	DeviceAttempts                uint                   `json:"device_attempts"`
+	ImageID                       string                 `json:"image_id,omitempty"`
+	ImageDescription              string                 `json:"image_description,omitempty"`
+	ImageArtifactName             string                 `json:"image_artifact_name"`
+	ImageDeviceTypes              []string               `json:"image_device_types"`
+	ImageSigned                   bool                   `json:"image_signed"`
+	ImageArtifactInfoFormat       string                 `json:"image_artifact_info_format,omitempty"`
+	ImageArtifactInfoVersion      uint                   `json:"image_artifact_info_version,omitempty"`
+	ImageProvides                 map[string]string      `json:"image_provides,omitempty"`
+	ImageDepends                  map[string]interface{} `json:"image_depends,omitempty"`
+	ImageClearsProvides           []string               `json:"image_clears_provides,omitempty"`
+	ImageSize                     int64                  `json:"image_size,omitempty"`
+	// DeviceStatusHistory are the transitions of the device's status,
+	// from the oldest; the indexed documents keep up to
+	// MaxDeviceStatusHistory transitions
+	DeviceStatusHistory []DeviceStatusTransition `json:"device_status_history,omitempty"`
+}
+
+// MaxDeviceStatusHistory is the maximum number of transitions of the
+// device's status kept in the deployment's history
+const MaxDeviceStatusHistory = 50
+
+// DeviceStatusTransition is a transition of the device's status in a deployment
+type DeviceStatusTransition struct {
+	Status    string    `json:"status" bson:"status"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
+// DeploymentTimelineEvent is a transition of the device's status in one of
+// its deployments
+type DeploymentTimelineEvent struct {
+	DeploymentID   string    `json:"deployment_id"`
+	DeploymentName string    `json:"deployment_name"`
+	Status         string    `json:"status"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+const (
+	defaultDeploymentsTimelineLimit = 20
+	maxDeploymentsTimelineLimit     = 100
+)
+
+// DeploymentsTimelineParams are the parameters of the timeline of the
+// device's deployments: the number of latest deployments to include
+type DeploymentsTimelineParams struct {
+	TenantID string `json:"-" form:"-"`
+	DeviceID string `json:"-" form:"-"`
+	Limit    int    `json:"limit" form:"limit"`
+}
+
+func (p DeploymentsTimelineParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(maxDeploymentsTimelineLimit)),
+	)
+}
+
+// Normalize sets the default number of deployments
+func (p *DeploymentsTimelineParams) Normalize() {
+	if p.Limit <= 0 {
+		p.Limit = defaultDeploymentsTimelineLimit
+	}
+}
+
+// Timeline returns the transitions of the device's status in the
+// deployment; the documents indexed before the history was recorded have
+// only the latest status, timestamped with the time the device finished
+// or started the deployment
+func (d *Deployment) Timeline() []DeploymentTimelineEvent {
+	history := d.DeviceStatusHistory
+	if len(history) == 0 {
+		var ts *time.Time
+		if d.DeviceFinished != nil {
+			ts = d.DeviceFinished
+		} else if d.DeviceCreated != nil {
+			ts = d.DeviceCreated
+		}
+		if d.DeviceStatus == "" || ts == nil {
+			return nil
+		}
+		history = []DeviceStatusTransition{{Status: d.DeviceStatus, Timestamp: *ts}}
+	}
+	events := make([]DeploymentTimelineEvent, 0, len(history))
+	for _, transition := range history {
+		events = append(events, DeploymentTimelineEvent{
+			DeploymentID:   d.DeploymentID,
+			DeploymentName: d.DeploymentName,
+			Status:         transition.Status,
+			Timestamp:      transition.Timestamp,
+		})
+	}
+	return events
 }
@@ -17,6 +17,8 @@ package model
 import (
 	"encoding/json"
 	"errors"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -30,6 +32,12 @@ type Device struct {
 	SystemAttributes    InventoryAttributes `json:"system_attributes,omitempty"`
 	TagsAttributes      InventoryAttributes `json:"tags_attributes,omitempty"`
 	UpdatedAt           *time.Time          `json:"updated_at,omitempty"`
+	// Location is the geographical location of the device, derived from
+	// its inventory attributes
+	Location *GeoPoint `json:"location,omitempty"`
+	// CheckInTime is the time the device last checked in, indexed as a
+	// date to detect the offline devices
+	CheckInTime *time.Time `json:"check_in_time,omitempty"`
 }
 
 func NewDevice(tenantID, id string) *Device {
@@ -99,6 +107,20 @@ func (a *Device) SetUpdatedAt(val time.Time) *Device {
 	return a
 }
 
+func (a *Device) GetCheckInTime() time.Time {
+	if a.CheckInTime != nil {
+		return *a.CheckInTime
+	}
+	return time.Time{}
+}
+
+func (a *Device) SetCheckInTime(val time.Time) *Device {
+	if !val.IsZero() {
+		a.CheckInTime = &val
+	}
+	return a
+}
+
 type InventoryAttributes []*InventoryAttribute
 
 type InventoryAttribute struct {
@@ -107,6 +129,9 @@ type InventoryAttribute struct {
 	String  []string
 	Numeric []float64
 	Boolean []bool
+	// Date are the values of the attributes declared to be indexed as
+	// dates, see ConvertTo
+	Date []time.Time
 }
 
 func NewInventoryAttribute(s string) *InventoryAttribute {
@@ -127,6 +152,10 @@ func (a *InventoryAttribute) IsBool() bool {
 	return a.Boolean != nil
 }
 
+func (a *InventoryAttribute) IsDate() bool {
+	return a.Date != nil
+}
+
 func (a *InventoryAttribute) SetName(val string) *InventoryAttribute {
 	a.Name = val
 	return a
@@ -136,6 +165,7 @@ func (a *InventoryAttribute) SetString(val string) *InventoryAttribute {
 	a.String = []string{val}
 	a.Boolean = nil
 	a.Numeric = nil
+	a.Date = nil
 	return a
 }
 
@@ -143,6 +173,7 @@ func (a *InventoryAttribute) SetStrings(val []string) *InventoryAttribute {
 	a.String = val
 	a.Boolean = nil
 	a.Numeric = nil
+	a.Date = nil
 	return a
 }
 
@@ -150,6 +181,7 @@ func (a *InventoryAttribute) SetNumeric(val float64) *InventoryAttribute {
 	a.Numeric = []float64{val}
 	a.Boolean = nil
 	a.String = nil
+	a.Date = nil
 	return a
 }
 
@@ -157,6 +189,7 @@ func (a *InventoryAttribute) SetNumerics(val []float64) *InventoryAttribute {
 	a.Numeric = val
 	a.String = nil
 	a.Boolean = nil
+	a.Date = nil
 	return a
 }
 
@@ -164,6 +197,7 @@ func (a *InventoryAttribute) SetBoolean(val bool) *InventoryAttribute {
 	a.Boolean = []bool{val}
 	a.Numeric = nil
 	a.String = nil
+	a.Date = nil
 	return a
 }
 
@@ -171,6 +205,15 @@ func (a *InventoryAttribute) SetBooleans(val []bool) *InventoryAttribute {
 	a.Boolean = val
 	a.Numeric = nil
 	a.String = nil
+	a.Date = nil
+	return a
+}
+
+func (a *InventoryAttribute) SetDates(val []time.Time) *InventoryAttribute {
+	a.Date = val
+	a.Numeric = nil
+	a.String = nil
+	a.Boolean = nil
 	return a
 }
 
@@ -210,11 +253,132 @@ func (a *InventoryAttribute) SetVal(val interface{}) *InventoryAttribute {
 	return a
 }
 
+// ConvertTo converts the values of the attribute to the type it is declared
+// to be indexed as, see MappingAttributeType: the strings are parsed as
+// numbers, RFC3339 dates or booleans ("true" or "false", in any case), and
+// the other values are formatted as strings to be indexed as keywords. The
+// values which do not convert are dropped: the attribute has no values
+// left if none converts.
+func (a *InventoryAttribute) ConvertTo(typ Type) *InventoryAttribute {
+	values := a.values()
+	switch typ {
+	case TypeStr:
+		a.SetStrings(convertToStrings(values))
+	case TypeNum:
+		a.SetNumerics(convertToNumerics(values))
+	case TypeBool:
+		a.SetBooleans(convertToBooleans(values))
+	case TypeDate:
+		a.SetDates(convertToDates(values))
+	}
+	if len(a.values()) == 0 {
+		a.String, a.Numeric, a.Boolean, a.Date = nil, nil, nil, nil
+	}
+	return a
+}
+
+func convertToStrings(values []interface{}) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			strs = append(strs, value)
+		case float64:
+			strs = append(strs, strconv.FormatFloat(value, 'f', -1, 64))
+		case bool:
+			strs = append(strs, strconv.FormatBool(value))
+		case time.Time:
+			strs = append(strs, value.Format(time.RFC3339Nano))
+		}
+	}
+	return strs
+}
+
+func convertToNumerics(values []interface{}) []float64 {
+	nums := make([]float64, 0, len(values))
+	for _, value := range values {
+		switch value := value.(type) {
+		case float64:
+			nums = append(nums, value)
+		case string:
+			num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err == nil && !math.IsNaN(num) && !math.IsInf(num, 0) {
+				nums = append(nums, num)
+			}
+		}
+	}
+	return nums
+}
+
+func convertToBooleans(values []interface{}) []bool {
+	bools := make([]bool, 0, len(values))
+	for _, value := range values {
+		switch value := value.(type) {
+		case bool:
+			bools = append(bools, value)
+		case string:
+			if strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+				bools = append(bools, strings.EqualFold(value, "true"))
+			}
+		}
+	}
+	return bools
+}
+
+func convertToDates(values []interface{}) []time.Time {
+	dates := make([]time.Time, 0, len(values))
+	for _, value := range values {
+		switch value := value.(type) {
+		case time.Time:
+			dates = append(dates, value.UTC())
+		case string:
+			date, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+			if err == nil {
+				dates = append(dates, date.UTC())
+			}
+		}
+	}
+	return dates
+}
+
+// HasValues returns true if the attribute has any value to index
+func (a *InventoryAttribute) HasValues() bool {
+	return a.IsStr() || a.IsNum() || a.IsBool() || a.IsDate()
+}
+
+func (a *InventoryAttribute) values() []interface{} {
+	values := make([]interface{}, 0,
+		len(a.String)+len(a.Numeric)+len(a.Boolean)+len(a.Date))
+	for _, value := range a.String {
+		values = append(values, value)
+	}
+	for _, value := range a.Numeric {
+		values = append(values, value)
+	}
+	for _, value := range a.Boolean {
+		values = append(values, value)
+	}
+	for _, value := range a.Date {
+		values = append(values, value)
+	}
+	return values
+}
+
 func (d *Device) MarshalJSON() ([]byte, error) {
 	// TODO: smarter encoding, without explicit rewrites?
 	m := make(map[string]interface{})
 	m[FieldNameID] = d.ID
 	m[FieldNameTenantID] = d.TenantID
+	if d.Location != nil {
+		m[FieldNameLocation] = d.Location
+	}
+	if d.CheckInTime != nil {
+		m[FieldNameCheckInTime] = d.CheckInTime.UTC()
+	}
+	// the inventory update time orders the documents built concurrently
+	if d.UpdatedAt != nil {
+		m[FieldNameUpdatedAt] = d.UpdatedAt.UTC()
+	}
 
 	attributes := append(d.IdentityAttributes, d.InventoryAttributes...)
 	attributes = append(attributes, d.MonitorAttributes...)
@@ -224,11 +388,40 @@ func (d *Device) MarshalJSON() ([]byte, error) {
 	for _, a := range attributes {
 		name, val := a.Map()
 		m[name] = val
+		// index the sortable form of the versions for the version filters
+		if a.IsStr() {
+			if versions := normalizeVersions(a.String); len(versions) > 0 {
+				m[ToAttr(a.Scope, a.Name, TypeVersion)] = versions
+			}
+		}
 	}
 
 	return json.Marshal(m)
 }
 
+// TagsFieldsPrefix is the prefix of the fields of the documents the tags
+// attributes of the devices are indexed as
+const TagsFieldsPrefix = ScopeTags + "_"
+
+// TagsFields returns the fields of the document the tags attributes of the
+// device are indexed as
+func (d *Device) TagsFields() (map[string]interface{}, error) {
+	b, err := json.Marshal(&Device{TagsAttributes: d.TagsAttributes})
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for field := range fields {
+		if !strings.HasPrefix(field, TagsFieldsPrefix) {
+			delete(fields, field)
+		}
+	}
+	return fields, nil
+}
+
 func (a *InventoryAttribute) Map() (string, interface{}) {
 	var val interface{}
 	var typ Type
@@ -242,6 +435,9 @@ func (a *InventoryAttribute) Map() (string, interface{}) {
 	} else if a.IsBool() {
 		typ = TypeBool
 		val = a.Boolean
+	} else if a.IsDate() {
+		typ = TypeDate
+		val = a.Date
 	}
 
 	name := ToAttr(a.Scope, a.Name, typ)
@@ -264,7 +460,7 @@ func MaybeParseAttr(field string) (string, string, error) {
 	}
 
 	if scope != "" {
-		for _, s := range []string{typeStr, typeNum} {
+		for _, s := range []string{typeStr, typeNum, typeDate} {
 			if strings.HasSuffix(field, "_"+s) {
 				// strip the prefix/suffix
 				start := strings.Index(field, "_")
@@ -0,0 +1,67 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxDeviceTags is the maximum number of tags of a device
+	MaxDeviceTags = 20
+
+	maxDeviceTagNameLength  = 1024
+	maxDeviceTagValueLength = 1024
+)
+
+var deviceTagNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9-_]+$`)
+
+// DeviceTag is a tag of a device, set in inventory
+type DeviceTag struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// Validate validates the tag of the device
+func (t DeviceTag) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Name, validation.Required,
+			validation.Length(1, maxDeviceTagNameLength),
+			validation.Match(deviceTagNameRegexp)),
+		validation.Field(&t.Value, validation.Length(0, maxDeviceTagValueLength)),
+	)
+}
+
+// DeviceTags are the tags of a device
+type DeviceTags []DeviceTag
+
+// Validate validates the tags of the device: their names are unique
+func (tags DeviceTags) Validate() error {
+	if len(tags) > MaxDeviceTags {
+		return errors.New("too many tags")
+	}
+	names := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if names[tag.Name] {
+			return errors.New("duplicate tag name: " + tag.Name)
+		}
+		names[tag.Name] = true
+	}
+	return validation.Validate([]DeviceTag(tags))
+}
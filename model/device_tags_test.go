@@ -0,0 +1,67 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceTagsValidate(t *testing.T) {
+	testCases := map[string]struct {
+		tags DeviceTags
+		err  string
+	}{
+		"ok": {
+			tags: DeviceTags{
+				{Name: "owner", Value: "ops"},
+				{Name: "site-1", Value: ""},
+			},
+		},
+		"ok, no tags": {
+			tags: DeviceTags{},
+		},
+		"ko, missing name": {
+			tags: DeviceTags{{Value: "ops"}},
+			err:  "0: (name: cannot be blank.).",
+		},
+		"ko, invalid name": {
+			tags: DeviceTags{{Name: "owner name", Value: "ops"}},
+			err:  "0: (name: must be in a valid format.).",
+		},
+		"ko, duplicate name": {
+			tags: DeviceTags{
+				{Name: "owner", Value: "ops"},
+				{Name: "owner", Value: "dev"},
+			},
+			err: "duplicate tag name: owner",
+		},
+		"ko, too many tags": {
+			tags: make(DeviceTags, MaxDeviceTags+1),
+			err:  "too many tags",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.tags.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -46,6 +46,10 @@ func TestDevice(t *testing.T) {
 	assert.Equal(t, newTenant, device.GetTenantID())
 	assert.Equal(t, now, device.GetUpdatedAt())
 
+	assert.Equal(t, time.Time{}, device.GetCheckInTime())
+	device.SetCheckInTime(now)
+	assert.Equal(t, now, device.GetCheckInTime())
+
 	err := device.AppendAttr(NewInventoryAttribute("dummy"))
 	assert.NotNil(t, err)
 	assert.Equal(t, "unknown attribute scope dummy", err.Error())
@@ -77,8 +81,29 @@ func TestDevice(t *testing.T) {
 		SetName("2").SetVal([]interface{}{true, true}))
 	assert.Nil(t, err)
 
-	_, err = json.Marshal(device)
+	b, err := json.Marshal(device)
 	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"check_in_time":"2010-09-22T06:05:00Z"`)
+	assert.Contains(t, string(b), `"updated_at":"2010-09-22T06:05:00Z"`)
+}
+
+func TestDeviceTagsFields(t *testing.T) {
+	device := NewDevice("tenant", "1")
+	_ = device.AppendAttr(NewInventoryAttribute(ScopeInventory).
+		SetName("attribute1").SetVal("ops"))
+	_ = device.AppendAttr(NewInventoryAttribute(ScopeTags).
+		SetName("attribute2").SetVal("1.2.3"))
+
+	fields, err := device.TagsFields()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"tags_attribute2_str": []interface{}{"1.2.3"},
+		"tags_attribute2_ver": []interface{}{"0000000001.0000000002.0000000003#"},
+	}, fields)
+
+	fields, err = NewDevice("tenant", "2").TagsFields()
+	assert.NoError(t, err)
+	assert.Empty(t, fields)
 }
 
 func TestMaybeParseAttr(t *testing.T) {
@@ -87,3 +112,67 @@ func TestMaybeParseAttr(t *testing.T) {
 	assert.Equal(t, "monitor", scope)
 	assert.Equal(t, "a1", name)
 }
+
+func TestInventoryAttributeConvertTo(t *testing.T) {
+	t.Parallel()
+
+	boot := time.Date(2023, 3, 6, 7, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		attr *InventoryAttribute
+		typ  Type
+
+		value interface{}
+	}{
+		"number to string": {
+			attr:  (&InventoryAttribute{}).SetNumeric(1.5),
+			typ:   TypeStr,
+			value: []string{"1.5"},
+		},
+		"strings to numbers, invalid dropped": {
+			attr:  (&InventoryAttribute{}).SetStrings([]string{"42", "NaN", "n/a"}),
+			typ:   TypeNum,
+			value: []float64{42},
+		},
+		"string to boolean": {
+			attr:  (&InventoryAttribute{}).SetString("TRUE"),
+			typ:   TypeBool,
+			value: []bool{true},
+		},
+		"string to date": {
+			attr:  (&InventoryAttribute{}).SetString("2023-03-06T08:00:00+01:00"),
+			typ:   TypeDate,
+			value: []time.Time{boot},
+		},
+		"date back to string": {
+			attr:  (&InventoryAttribute{}).SetDates([]time.Time{boot}),
+			typ:   TypeStr,
+			value: []string{"2023-03-06T07:00:00Z"},
+		},
+		"nothing converts": {
+			attr: (&InventoryAttribute{}).SetString("yes"),
+			typ:  TypeBool,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			attr := tc.attr.ConvertTo(tc.typ)
+			if tc.value == nil {
+				assert.False(t, attr.HasValues())
+				return
+			}
+			assert.True(t, attr.HasValues())
+			switch tc.typ {
+			case TypeStr:
+				assert.Equal(t, tc.value, attr.String)
+			case TypeNum:
+				assert.Equal(t, tc.value, attr.Numeric)
+			case TypeBool:
+				assert.Equal(t, tc.value, attr.Boolean)
+			case TypeDate:
+				assert.Equal(t, tc.value, attr.Date)
+			}
+		})
+	}
+}
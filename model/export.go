@@ -0,0 +1,26 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import "time"
+
+// DevicesExport describes an export of the devices uploaded to the storage
+type DevicesExport struct {
+	// Key is the key of the exported file in the bucket
+	Key string `json:"key"`
+	// Devices is the number of exported devices
+	Devices   int       `json:"devices"`
+	CreatedTs time.Time `json:"created_ts"`
+}
@@ -31,9 +31,23 @@ var validSelectors = []interface{}{
 	"$ne",
 	"$nin",
 	"$exists",
-	"$regex",
+	"$nexists",
+	FilterTypeRegex,
+	FilterTypeWildcard,
 }
 
+// validDeviceSelectors are the filter types supported by the devices
+// searches: the geo filters apply only to the devices' location, the
+// version filters to the string attributes indexed with their sortable form
+var validDeviceSelectors = append([]interface{}{
+	FilterTypeGeoDistance,
+	FilterTypeGeoBoundingBox,
+	FilterTypeVersionGt,
+	FilterTypeVersionGte,
+	FilterTypeVersionLt,
+	FilterTypeVersionLte,
+}, validSelectors...)
+
 const (
 	SortOrderAsc  = "asc"
 	SortOrderDesc = "desc"
@@ -41,15 +55,51 @@ const (
 
 var validSortOrders = []interface{}{SortOrderAsc, SortOrderDesc}
 
+// the placement of the devices missing the sort attribute; by default,
+// they are placed last
+const (
+	SortMissingFirst = "first"
+	SortMissingLast  = "last"
+)
+
+var validSortMissing = []interface{}{SortMissingFirst, SortMissingLast}
+
+const maxSearchTextLength = 256
+
+// maxSearchTenants is the maximum number of tenants listed in a search
+// across tenants
+const maxSearchTenants = 100
+
 type SearchParams struct {
-	Page       int               `json:"page"`
-	PerPage    int               `json:"per_page"`
-	Filters    []FilterPredicate `json:"filters"`
-	Sort       []SortCriteria    `json:"sort"`
-	Attributes []SelectAttribute `json:"attributes"`
-	DeviceIDs  []string          `json:"device_ids"`
-	Groups     []string          `json:"-"`
-	TenantID   string            `json:"-"`
+	Page    int               `json:"page"`
+	PerPage int               `json:"per_page"`
+	Filters []FilterPredicate `json:"filters"`
+	// FilterGroups are combined with the filters with AND
+	FilterGroups []FilterGroup     `json:"filter_groups,omitempty"`
+	Sort         []SortCriteria    `json:"sort"`
+	Attributes   []SelectAttribute `json:"attributes"`
+	DeviceIDs    []string          `json:"device_ids"`
+	// Text is a free-text query, matching the devices whose ID or string
+	// attributes start with all its words
+	Text string `json:"text,omitempty"`
+	// Highlight returns, for every device, the attributes matching the
+	// free-text query and the matching fragments of their values
+	Highlight bool `json:"highlight,omitempty"`
+	// Cursor selects the cursor-based pagination: CursorStart starts a new
+	// iteration, the following pages are retrieved with the returned cursor
+	Cursor string `json:"cursor,omitempty"`
+	// InGroups restricts the search to the devices in any of the groups
+	InGroups []string `json:"groups,omitempty"`
+	Groups   []string `json:"-"`
+	// VisibleAttributes are the attributes visible to the role of the
+	// user, if restricted
+	VisibleAttributes *AttributesFilter `json:"-"`
+	TenantID          string            `json:"-"`
+}
+
+// DevicesCount is the number of devices matching a search
+type DevicesCount struct {
+	Count int `json:"count"`
 }
 
 type FilterPredicate struct {
@@ -57,20 +107,48 @@ type FilterPredicate struct {
 	Attribute string      `json:"attribute" bson:"attribute"`
 	Type      string      `json:"type" bson:"type"`
 	Value     interface{} `json:"value" bson:"value"`
+	// IndexedAs is the type the (mapped) attribute is declared to be
+	// indexed as, if any: the filter applies to the field of the type,
+	// whatever the type of its value
+	IndexedAs Type `json:"-" bson:"-"`
 }
 
 type SortCriteria struct {
 	Scope     string `json:"scope"`
 	Attribute string `json:"attribute"`
 	Order     string `json:"order"`
+	Missing   string `json:"missing,omitempty"`
+	// IndexedAs is the type the (mapped) attribute is declared to be
+	// indexed as, if any, see FilterPredicate
+	IndexedAs Type `json:"-"`
 }
 
 type SelectAttribute struct {
 	Scope     string `json:"scope" bson:"scope"`
 	Attribute string `json:"attribute" bson:"attribute"`
+	// IndexedAs is the type the (mapped) attribute is declared to be
+	// indexed as, if any, see FilterPredicate
+	IndexedAs Type `json:"-" bson:"-"`
 }
 
 func (sp SearchParams) Validate() error {
+	err := validation.ValidateStruct(&sp,
+		validation.Field(&sp.PerPage, validation.By(checkPerPage)),
+		validation.Field(&sp.Text, validation.Length(0, maxSearchTextLength)),
+		validation.Field(&sp.InGroups, validation.Length(0, maxGroupsFilter),
+			validation.Each(validation.Required)))
+	if err != nil {
+		return err
+	}
+
+	clauses := len(sp.Filters)
+	for i := range sp.FilterGroups {
+		clauses += len(sp.FilterGroups[i].Predicates())
+	}
+	if err := checkClauses(clauses); err != nil {
+		return err
+	}
+
 	for _, f := range sp.Filters {
 		err := f.Validate()
 		if err != nil {
@@ -78,6 +156,12 @@ func (sp SearchParams) Validate() error {
 		}
 	}
 
+	for _, g := range sp.FilterGroups {
+		if err := g.Validate(); err != nil {
+			return errors.Wrap(err, "filter_groups")
+		}
+	}
+
 	for _, s := range sp.Sort {
 		err := validation.ValidateStruct(&s,
 			validation.Field(&s.Scope, validation.Required),
@@ -85,6 +169,7 @@ func (sp SearchParams) Validate() error {
 			validation.Field(&s.Order,
 				validation.Required, validation.In(validSortOrders...),
 			),
+			validation.Field(&s.Missing, validation.In(validSortMissing...)),
 		)
 		if err != nil {
 			return err
@@ -99,15 +184,62 @@ func (sp SearchParams) Validate() error {
 			return err
 		}
 	}
+
+	if sp.Cursor != "" && sp.Cursor != CursorStart {
+		if _, err := ParseCursor(sp.Cursor); err != nil {
+			return errors.Wrap(err, "cursor")
+		}
+	}
 	return nil
 }
 
+// Clone returns a copy of the search parameters whose filters, sort
+// criteria and attributes can be mapped without affecting the original
+func (sp SearchParams) Clone() SearchParams {
+	clone := sp
+	clone.Filters = append([]FilterPredicate(nil), sp.Filters...)
+	clone.Sort = append([]SortCriteria(nil), sp.Sort...)
+	clone.Attributes = append([]SelectAttribute(nil), sp.Attributes...)
+	clone.FilterGroups = nil
+	for _, g := range sp.FilterGroups {
+		clone.FilterGroups = append(clone.FilterGroups, g.Clone())
+	}
+	return clone
+}
+
+// TenantsSearchParams are the parameters of a search of the devices across
+// multiple tenants; an empty list of tenants searches all the tenants
+type TenantsSearchParams struct {
+	SearchParams
+	TenantIDs []string `json:"tenant_ids,omitempty"`
+}
+
+func (sp TenantsSearchParams) Validate() error {
+	err := validation.ValidateStruct(&sp,
+		validation.Field(&sp.TenantIDs, validation.Length(0, maxSearchTenants),
+			validation.Each(validation.Required)))
+	if err != nil {
+		return err
+	}
+	if sp.Cursor != "" {
+		return errors.New("cursor: not supported by the search across tenants")
+	}
+	return sp.SearchParams.Validate()
+}
+
 func (f FilterPredicate) Validate() error {
-	return validation.ValidateStruct(&f,
+	err := validation.ValidateStruct(&f,
 		validation.Field(&f.Scope, validation.Required),
 		validation.Field(&f.Attribute, validation.Required),
-		validation.Field(&f.Type, validation.Required, validation.In(validSelectors...)),
+		validation.Field(&f.Type, validation.Required,
+			validation.In(validDeviceSelectors...)),
 		validation.Field(&f.Value, validation.NotNil))
+	if err == nil &&
+		(isGeoFilter(f.Type) || isPatternFilter(f.Type) || isVersionFilter(f.Type) ||
+			IsConnectivityAttribute(f.Scope, f.Attribute)) {
+		_, err = getFilterPart(f)
+	}
+	return err
 }
 
 // ValueType returns actual type info of the value:
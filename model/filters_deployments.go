@@ -48,6 +48,15 @@ type DeploymentsSelectAttribute struct {
 }
 
 func (sp DeploymentsSearchParams) Validate() error {
+	err := validation.ValidateStruct(&sp,
+		validation.Field(&sp.PerPage, validation.By(checkPerPage)))
+	if err != nil {
+		return err
+	}
+	if err := checkClauses(len(sp.Filters)); err != nil {
+		return err
+	}
+
 	for _, f := range sp.Filters {
 		err := f.Validate()
 		if err != nil {
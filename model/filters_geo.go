@@ -0,0 +1,150 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	FilterTypeGeoDistance    = "$geo_distance"
+	FilterTypeGeoBoundingBox = "$geo_bounding_box"
+)
+
+var (
+	ErrGeoFilterAttribute = errors.New(
+		"geo filters support only the system attribute " + AttrNameLocation)
+
+	// distanceRegexp matches the distances with the units supported by
+	// the geo_distance query
+	distanceRegexp = regexp.MustCompile(
+		`^[0-9]+(\.[0-9]+)?(mi|miles|yd|yards|ft|feet|in|inch|km|kilometers|` +
+			`m|meters|cm|centimeters|mm|millimeters|NM|nmi|nauticalmiles)$`)
+)
+
+// GeoPoint is a geographical location
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func (p GeoPoint) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Lat, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&p.Lon, validation.Min(-180.0), validation.Max(180.0)),
+	)
+}
+
+// GeoDistance is the value of the geo distance filters: it matches the
+// devices within the distance from the location
+type GeoDistance struct {
+	GeoPoint
+	Distance string `json:"distance"`
+}
+
+func (d GeoDistance) Validate() error {
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.GeoPoint),
+		validation.Field(&d.Distance, validation.Required,
+			validation.Match(distanceRegexp).Error(
+				"must be a number followed by a distance unit, e.g. 10km")),
+	)
+}
+
+// GeoBoundingBox is the value of the geo bounding box filters: it matches
+// the devices located within the box
+type GeoBoundingBox struct {
+	TopLeft     *GeoPoint `json:"top_left"`
+	BottomRight *GeoPoint `json:"bottom_right"`
+}
+
+func (b GeoBoundingBox) Validate() error {
+	err := validation.ValidateStruct(&b,
+		validation.Field(&b.TopLeft, validation.Required),
+		validation.Field(&b.BottomRight, validation.Required),
+	)
+	if err == nil && b.TopLeft.Lat < b.BottomRight.Lat {
+		err = errors.New("top_left: must not be below bottom_right")
+	}
+	return err
+}
+
+func isGeoFilter(filterType string) bool {
+	return filterType == FilterTypeGeoDistance || filterType == FilterTypeGeoBoundingBox
+}
+
+// decodeGeoFilterValue decodes the value of a geo filter, as unmarshaled
+// from the request, into dst
+func decodeGeoFilterValue(fp FilterPredicate, dst validation.Validatable) error {
+	if fp.Scope != ScopeSystem || fp.Attribute != AttrNameLocation {
+		return ErrGeoFilterAttribute
+	}
+	b, err := json.Marshal(fp.Value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return errors.Wrapf(err, "malformed %s filter value", fp.Type)
+	}
+	return dst.Validate()
+}
+
+type filterGeoDistance struct {
+	value GeoDistance
+}
+
+func NewFilterGeoDistance(fp FilterPredicate) (*filterGeoDistance, error) {
+	f := &filterGeoDistance{}
+	if err := decodeGeoFilterValue(fp, &f.value); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *filterGeoDistance) AddTo(q Query) Query {
+	return q.Must(M{
+		"geo_distance": M{
+			"distance":        f.value.Distance,
+			FieldNameLocation: f.value.GeoPoint,
+		},
+	})
+}
+
+type filterGeoBoundingBox struct {
+	value GeoBoundingBox
+}
+
+func NewFilterGeoBoundingBox(fp FilterPredicate) (*filterGeoBoundingBox, error) {
+	f := &filterGeoBoundingBox{}
+	if err := decodeGeoFilterValue(fp, &f.value); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *filterGeoBoundingBox) AddTo(q Query) Query {
+	return q.Must(M{
+		"geo_bounding_box": M{
+			FieldNameLocation: M{
+				"top_left":     f.value.TopLeft,
+				"bottom_right": f.value.BottomRight,
+			},
+		},
+	})
+}
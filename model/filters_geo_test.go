@@ -0,0 +1,155 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoFilters(t *testing.T) {
+	t.Parallel()
+
+	// the values as unmarshaled from the request body
+	value := func(s string) interface{} {
+		var v interface{}
+		_ = json.Unmarshal([]byte(s), &v)
+		return v
+	}
+	testCases := map[string]struct {
+		filter FilterPredicate
+
+		query string
+		err   string
+	}{
+		"ok, geo distance": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoDistance,
+				Value:     value(`{"lat": 59.91, "lon": 10.75, "distance": "12.5km"}`),
+			},
+			query: `{"geo_distance": {"distance": "12.5km",
+				"location": {"lat": 59.91, "lon": 10.75}}}`,
+		},
+		"ok, geo bounding box": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoBoundingBox,
+				Value: value(`{"top_left": {"lat": 60, "lon": 10},
+					"bottom_right": {"lat": 59, "lon": 11}}`),
+			},
+			query: `{"geo_bounding_box": {"location": {
+				"top_left": {"lat": 60, "lon": 10},
+				"bottom_right": {"lat": 59, "lon": 11}}}}`,
+		},
+		"error, not the location attribute": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "latitude",
+				Type:      FilterTypeGeoDistance,
+				Value:     value(`{"lat": 59.91, "lon": 10.75, "distance": "10km"}`),
+			},
+			err: ErrGeoFilterAttribute.Error(),
+		},
+		"error, malformed value": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoDistance,
+				Value:     "10km",
+			},
+			err: "malformed $geo_distance filter value: " +
+				"json: cannot unmarshal string into Go value of type model.GeoDistance",
+		},
+		"error, invalid latitude": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoDistance,
+				Value:     value(`{"lat": 91, "lon": 10.75, "distance": "10km"}`),
+			},
+			err: "lat: must be no greater than 90.",
+		},
+		"error, invalid distance": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoDistance,
+				Value:     value(`{"lat": 59.91, "lon": 10.75, "distance": "far"}`),
+			},
+			err: "distance: must be a number followed by a distance unit, e.g. 10km.",
+		},
+		"error, missing corner": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoBoundingBox,
+				Value:     value(`{"top_left": {"lat": 60, "lon": 10}}`),
+			},
+			err: "bottom_right: cannot be blank.",
+		},
+		"error, upside-down box": {
+			filter: FilterPredicate{
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLocation,
+				Type:      FilterTypeGeoBoundingBox,
+				Value: value(`{"top_left": {"lat": 59, "lon": 10},
+					"bottom_right": {"lat": 60, "lon": 11}}`),
+			},
+			err: "top_left: must not be below bottom_right",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.filter.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			query, err := BuildQuery(SearchParams{
+				Filters: []FilterPredicate{tc.filter},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			})
+			if assert.NoError(t, err) {
+				b, _ := json.Marshal(query)
+				var actual map[string]interface{}
+				_ = json.Unmarshal(b, &actual)
+				must := actual["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"]
+				b, _ = json.Marshal(must.([]interface{})[0])
+				assert.JSONEq(t, tc.query, string(b))
+			}
+		})
+	}
+}
+
+func TestDeviceMarshalJSONLocation(t *testing.T) {
+	t.Parallel()
+
+	device := NewDevice("tenant", "device")
+	device.Location = &GeoPoint{Lat: 59.91, Lon: 10.75}
+	b, err := json.Marshal(device)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": "device", "tenant_id": "tenant",
+		"location": {"lat": 59.91, "lon": 10.75}}`, string(b))
+}
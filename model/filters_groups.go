@@ -0,0 +1,172 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	FilterGroupAnd = "$and"
+	FilterGroupOr  = "$or"
+	FilterGroupNot = "$not"
+)
+
+const (
+	// maxFilterGroupDepth is the maximum nesting depth of the filter groups
+	maxFilterGroupDepth = 5
+	// maxFilterGroupItems is the maximum number of filters and groups
+	// in a filter group
+	maxFilterGroupItems = 20
+)
+
+var (
+	validFilterGroupTypes = []interface{}{FilterGroupAnd, FilterGroupOr, FilterGroupNot}
+
+	ErrFilterGroupTooDeep = errors.Errorf(
+		"filter groups must not be nested more than %d levels deep", maxFilterGroupDepth)
+	ErrFilterGroupEmpty = errors.New(
+		"filter groups must contain at least a filter or a group")
+	ErrFilterGroupTooLarge = errors.Errorf(
+		"filter groups must not contain more than %d filters and groups",
+		maxFilterGroupItems)
+)
+
+// FilterGroup combines filter predicates and nested groups: $and matches
+// the devices matching all of them, $or any of them and $not none of them
+type FilterGroup struct {
+	Type    string            `json:"type"`
+	Filters []FilterPredicate `json:"filters,omitempty"`
+	Groups  []FilterGroup     `json:"groups,omitempty"`
+}
+
+func (g FilterGroup) Validate() error {
+	return g.validate(1)
+}
+
+func (g FilterGroup) validate(depth int) error {
+	if depth > maxFilterGroupDepth {
+		return ErrFilterGroupTooDeep
+	}
+	err := validation.ValidateStruct(&g,
+		validation.Field(&g.Type, validation.Required,
+			validation.In(validFilterGroupTypes...)))
+	if err != nil {
+		return err
+	}
+	items := len(g.Filters) + len(g.Groups)
+	if items == 0 {
+		return ErrFilterGroupEmpty
+	} else if items > maxFilterGroupItems {
+		return ErrFilterGroupTooLarge
+	}
+	for _, f := range g.Filters {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, sub := range g.Groups {
+		if err := sub.validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Predicates returns pointers to all the filter predicates of the group
+// and of its nested groups, to update them in place
+func (g *FilterGroup) Predicates() []*FilterPredicate {
+	predicates := make([]*FilterPredicate, 0, len(g.Filters))
+	for i := range g.Filters {
+		predicates = append(predicates, &g.Filters[i])
+	}
+	for i := range g.Groups {
+		predicates = append(predicates, g.Groups[i].Predicates()...)
+	}
+	return predicates
+}
+
+// Clone returns a deep copy of the group, whose predicates can be updated
+// without affecting the original
+func (g FilterGroup) Clone() FilterGroup {
+	clone := FilterGroup{Type: g.Type}
+	if g.Filters != nil {
+		clone.Filters = append([]FilterPredicate{}, g.Filters...)
+	}
+	for _, sub := range g.Groups {
+		clone.Groups = append(clone.Groups, sub.Clone())
+	}
+	return clone
+}
+
+// filterGroup translates a filter group to a bool query
+type filterGroup struct {
+	clause M
+}
+
+func NewFilterGroup(g FilterGroup) (*filterGroup, error) {
+	clauses := make(S, 0, len(g.Filters)+len(g.Groups))
+	for _, f := range g.Filters {
+		fpart, err := getFilterPart(f)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, toClause(fpart))
+	}
+	for _, sub := range g.Groups {
+		fpart, err := NewFilterGroup(sub)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, fpart.clause)
+	}
+
+	var clause M
+	switch g.Type {
+	case FilterGroupAnd:
+		clause = M{"must": clauses}
+	case FilterGroupOr:
+		clause = M{"should": clauses, "minimum_should_match": 1}
+	case FilterGroupNot:
+		clause = M{"must_not": clauses}
+	default:
+		return nil, errors.Errorf("filter group type not supported: %s", g.Type)
+	}
+	return &filterGroup{
+		clause: M{"bool": clause},
+	}, nil
+}
+
+func (f *filterGroup) AddTo(q Query) Query {
+	return q.Must(f.clause)
+}
+
+// toClause returns the query part as a single query clause
+func toClause(fpart QueryPart) interface{} {
+	q := &query{}
+	fpart.AddTo(q)
+	if len(q.must) == 1 && len(q.mustNot) == 0 {
+		return q.must[0]
+	}
+	clause := M{}
+	if q.must != nil {
+		clause["must"] = q.must
+	}
+	if q.mustNot != nil {
+		clause["must_not"] = q.mustNot
+	}
+	return M{"bool": clause}
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterGroups(t *testing.T) {
+	t.Parallel()
+
+	eq := func(attr, value string) FilterPredicate {
+		return FilterPredicate{
+			Scope:     ScopeInventory,
+			Attribute: attr,
+			Type:      "$eq",
+			Value:     value,
+		}
+	}
+	nested := func(depth int) FilterGroup {
+		g := FilterGroup{Type: FilterGroupAnd, Filters: []FilterPredicate{eq("a", "b")}}
+		for i := 1; i < depth; i++ {
+			g = FilterGroup{Type: FilterGroupAnd, Groups: []FilterGroup{g}}
+		}
+		return g
+	}
+	testCases := map[string]struct {
+		group FilterGroup
+
+		query string
+		err   string
+	}{
+		"ok, or of and and not": {
+			group: FilterGroup{
+				Type: FilterGroupOr,
+				Filters: []FilterPredicate{
+					eq("device_type", "raspberrypi4"),
+				},
+				Groups: []FilterGroup{{
+					Type:    FilterGroupAnd,
+					Filters: []FilterPredicate{eq("region", "eu"), eq("tier", "gold")},
+				}, {
+					Type: FilterGroupNot,
+					Filters: []FilterPredicate{{
+						Scope:     ScopeInventory,
+						Attribute: "serial",
+						Type:      "$exists",
+						Value:     false,
+					}},
+				}},
+			},
+			query: `{"bool": {"minimum_should_match": 1, "should": [
+				{"match": {"inventory_device_type_str": "raspberrypi4"}},
+				{"bool": {"must": [
+					{"match": {"inventory_region_str": "eu"}},
+					{"match": {"inventory_tier_str": "gold"}}
+				]}},
+				{"bool": {"must_not": [
+					{"bool": {"must_not": [
+						{"exists": {"field": "inventory_serial_str"}},
+						{"exists": {"field": "inventory_serial_num"}},
+						{"exists": {"field": "inventory_serial_bool"}}
+					]}}
+				]}}
+			]}}`,
+		},
+		"ok, max depth": {
+			group: nested(maxFilterGroupDepth),
+			query: `{"bool": {"must": [{"bool": {"must": [{"bool": {"must": [
+				{"bool": {"must": [{"bool": {"must": [
+					{"match": {"inventory_a_str": "b"}}
+				]}}]}}]}}]}}]}}`,
+		},
+		"error, too deep": {
+			group: nested(maxFilterGroupDepth + 1),
+			err:   ErrFilterGroupTooDeep.Error(),
+		},
+		"error, empty": {
+			group: FilterGroup{Type: FilterGroupOr},
+			err:   ErrFilterGroupEmpty.Error(),
+		},
+		"error, too large": {
+			group: FilterGroup{
+				Type:    FilterGroupOr,
+				Filters: make([]FilterPredicate, maxFilterGroupItems+1),
+			},
+			err: ErrFilterGroupTooLarge.Error(),
+		},
+		"error, invalid type": {
+			group: FilterGroup{Type: "$xor", Filters: []FilterPredicate{eq("a", "b")}},
+			err:   "type: must be a valid value.",
+		},
+		"error, invalid filter": {
+			group: FilterGroup{
+				Type:    FilterGroupAnd,
+				Filters: []FilterPredicate{{Scope: ScopeInventory, Type: "$eq", Value: "b"}},
+			},
+			err: "attribute: cannot be blank.",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.group.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			query, err := BuildQuery(SearchParams{
+				FilterGroups: []FilterGroup{tc.group},
+				Page:         defaultPage,
+				PerPage:      defaultPerPage,
+			})
+			if assert.NoError(t, err) {
+				b, _ := json.Marshal(query)
+				var actual map[string]interface{}
+				_ = json.Unmarshal(b, &actual)
+				must := actual["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"]
+				b, _ = json.Marshal(must.([]interface{})[0])
+				assert.JSONEq(t, tc.query, string(b))
+			}
+		})
+	}
+}
+
+func TestFilterGroupPredicates(t *testing.T) {
+	t.Parallel()
+
+	group := FilterGroup{
+		Type:    FilterGroupOr,
+		Filters: []FilterPredicate{{Attribute: "a"}},
+		Groups: []FilterGroup{{
+			Type:    FilterGroupNot,
+			Filters: []FilterPredicate{{Attribute: "b"}, {Attribute: "c"}},
+		}},
+	}
+	predicates := group.Predicates()
+	if assert.Len(t, predicates, 3) {
+		predicates[2].Attribute = "mapped"
+	}
+	assert.Equal(t, "mapped", group.Groups[0].Filters[1].Attribute)
+}
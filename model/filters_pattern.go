@@ -0,0 +1,148 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FilterTypeRegex    = "$regex"
+	FilterTypeWildcard = "$wildcard"
+)
+
+const (
+	// maxPatternRepeats is the maximum number of repetition operators
+	// (*, +, ?, {n,m}) in a $regex pattern
+	maxPatternRepeats = 10
+)
+
+var (
+	ErrPatternTooLong         = errors.New("the pattern is too long")
+	ErrPatternLeadingWildcard = errors.New(
+		"the pattern must not start with a wildcard")
+	ErrPatternTooComplex = errors.Errorf(
+		"the pattern must not contain nested repetitions or more than %d of them",
+		maxPatternRepeats)
+)
+
+func checkPatternLength(pattern string) error {
+	if len(pattern) > queryLimits.MaxPatternLength {
+		return fmt.Errorf("%w, limit is %d characters",
+			ErrPatternTooLong, queryLimits.MaxPatternLength)
+	}
+	return nil
+}
+
+func isPatternFilter(filterType string) bool {
+	return filterType == FilterTypeRegex || filterType == FilterTypeWildcard
+}
+
+// validateRegex checks the guardrails of the $regex patterns: the
+// patterns which start with a wildcard or have many or nested repetitions
+// are too expensive to evaluate on the whole index
+func validateRegex(pattern string) error {
+	if err := checkPatternLength(pattern); err != nil {
+		return err
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return errors.Wrap(err, "invalid regular expression")
+	}
+	if startsWithAnyChar(re) {
+		return ErrPatternLeadingWildcard
+	}
+	repeats := 0
+	if !checkRepeats(re, false, &repeats) || repeats > maxPatternRepeats {
+		return ErrPatternTooComplex
+	}
+	return nil
+}
+
+func startsWithAnyChar(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat,
+		syntax.OpCapture, syntax.OpConcat:
+		return len(re.Sub) > 0 && startsWithAnyChar(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if startsWithAnyChar(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRepeats counts the repetitions in repeats, returning false if
+// any of them is nested in another one
+func checkRepeats(re *syntax.Regexp, inRepeat bool, repeats *int) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if inRepeat {
+			return false
+		}
+		*repeats++
+		inRepeat = true
+	}
+	for _, sub := range re.Sub {
+		if !checkRepeats(sub, inRepeat, repeats) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWildcard checks the guardrails of the $wildcard patterns
+func validateWildcard(pattern string) error {
+	if err := checkPatternLength(pattern); err != nil {
+		return err
+	}
+	if strings.HasPrefix(pattern, "*") || strings.HasPrefix(pattern, "?") {
+		return ErrPatternLeadingWildcard
+	}
+	return nil
+}
+
+// WildcardToRegexp converts a wildcard pattern, where * matches any
+// sequence of characters, ? any single character and \ escapes the
+// following character, to the equivalent regular expression
+func WildcardToRegexp(pattern string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternFilters(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		filterType string
+		pattern    string
+
+		err string
+	}{
+		"ok, regex": {
+			filterType: FilterTypeRegex,
+			pattern:    "edge-gw-[0-9]+-(prod|staging)",
+		},
+		"ok, wildcard": {
+			filterType: FilterTypeWildcard,
+			pattern:    "edge-gw-*-prod",
+		},
+		"error, invalid regex": {
+			filterType: FilterTypeRegex,
+			pattern:    "edge-gw-(prod",
+			err: "invalid regular expression: " +
+				"error parsing regexp: missing closing ): `edge-gw-(prod`",
+		},
+		"error, regex leading wildcard": {
+			filterType: FilterTypeRegex,
+			pattern:    ".*-prod",
+			err:        ErrPatternLeadingWildcard.Error(),
+		},
+		"error, regex leading wildcard in alternation": {
+			filterType: FilterTypeRegex,
+			pattern:    "(edge|.+)-prod",
+			err:        ErrPatternLeadingWildcard.Error(),
+		},
+		"error, regex nested repetitions": {
+			filterType: FilterTypeRegex,
+			pattern:    "edge(-[a-z]+)*",
+			err:        ErrPatternTooComplex.Error(),
+		},
+		"error, regex too many repetitions": {
+			filterType: FilterTypeRegex,
+			pattern:    "e" + strings.Repeat("-[a-z]+", maxPatternRepeats+1),
+			err:        ErrPatternTooComplex.Error(),
+		},
+		"error, regex too long": {
+			filterType: FilterTypeRegex,
+			pattern:    strings.Repeat("a", defaultMaxPatternLength+1),
+			err:        ErrPatternTooLong.Error() + ", limit is 256 characters",
+		},
+		"error, wildcard leading wildcard": {
+			filterType: FilterTypeWildcard,
+			pattern:    "*-prod",
+			err:        ErrPatternLeadingWildcard.Error(),
+		},
+		"error, wildcard leading single character wildcard": {
+			filterType: FilterTypeWildcard,
+			pattern:    "?dge-gw-*",
+			err:        ErrPatternLeadingWildcard.Error(),
+		},
+		"error, wildcard too long": {
+			filterType: FilterTypeWildcard,
+			pattern:    strings.Repeat("a", defaultMaxPatternLength+1),
+			err:        ErrPatternTooLong.Error() + ", limit is 256 characters",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "hostname",
+				Type:      tc.filterType,
+				Value:     tc.pattern,
+			}.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWildcardToRegexp(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		pattern string
+
+		match   []string
+		noMatch []string
+	}{
+		"any sequence": {
+			pattern: "edge-gw-*-prod",
+			match:   []string{"edge-gw-1-prod", "edge-gw--prod", "edge-gw-a-b-prod"},
+			noMatch: []string{"edge-gw-1-staging", "edge-gw-prod"},
+		},
+		"single character": {
+			pattern: "gw-?",
+			match:   []string{"gw-1", "gw-a"},
+			noMatch: []string{"gw-", "gw-10"},
+		},
+		"escaped and special characters": {
+			pattern: `gw.\*(1)`,
+			match:   []string{"gw.*(1)"},
+			noMatch: []string{"gwa*(1)", "gw.1(1)"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			re := regexp.MustCompile("^(?:" + WildcardToRegexp(tc.pattern) + ")$")
+			for _, s := range tc.match {
+				assert.True(t, re.MatchString(s), s)
+			}
+			for _, s := range tc.noMatch {
+				assert.False(t, re.MatchString(s), s)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package model
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,6 +75,19 @@ func TestSearchParamsValidate(t *testing.T) {
 			},
 			err: errors.New("attribute: cannot be blank; order: must be a valid value; scope: cannot be blank."),
 		},
+		"ko, sort missing fails validation": {
+			params: SearchParams{
+				Sort: []SortCriteria{
+					{
+						Scope:     ScopeIdentity,
+						Attribute: "mac",
+						Order:     SortOrderAsc,
+						Missing:   "middle",
+					},
+				},
+			},
+			err: errors.New("missing: must be a valid value."),
+		},
 		"ko, attributes fails validation": {
 			params: SearchParams{
 				Attributes: []SelectAttribute{
@@ -83,6 +97,12 @@ func TestSearchParamsValidate(t *testing.T) {
 				}},
 			err: errors.New("scope: cannot be blank."),
 		},
+		"ko, text too long": {
+			params: SearchParams{
+				Text: strings.Repeat("a", maxSearchTextLength+1),
+			},
+			err: errors.New("text: the length must be no more than 256."),
+		},
 	}
 
 	for name, tc := range testCases {
@@ -97,6 +117,93 @@ func TestSearchParamsValidate(t *testing.T) {
 	}
 }
 
+func TestTenantsSearchParamsValidate(t *testing.T) {
+	testCases := map[string]struct {
+		params TenantsSearchParams
+		err    error
+	}{
+		"ok, all tenants": {
+			params: TenantsSearchParams{},
+		},
+		"ok, tenants": {
+			params: TenantsSearchParams{
+				TenantIDs: []string{"tenant1", "tenant2"},
+			},
+		},
+		"ko, empty tenant": {
+			params: TenantsSearchParams{
+				TenantIDs: []string{"tenant1", ""},
+			},
+			err: errors.New("tenant_ids: (1: cannot be blank.)."),
+		},
+		"ko, too many tenants": {
+			params: TenantsSearchParams{
+				TenantIDs: make([]string, maxSearchTenants+1),
+			},
+			err: errors.New("tenant_ids: the length must be no more than 100."),
+		},
+		"ko, cursor": {
+			params: TenantsSearchParams{
+				SearchParams: SearchParams{Cursor: CursorStart},
+			},
+			err: errors.New("cursor: not supported by the search across tenants"),
+		},
+		"ko, search parameters": {
+			params: TenantsSearchParams{
+				SearchParams: SearchParams{
+					Attributes: []SelectAttribute{{Attribute: "mac"}},
+				},
+			},
+			err: errors.New("scope: cannot be blank."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSearchParamsClone(t *testing.T) {
+	params := SearchParams{
+		Filters: []FilterPredicate{{
+			Scope: ScopeInventory, Attribute: "foo", Type: "$eq", Value: "bar",
+		}},
+		FilterGroups: []FilterGroup{{
+			Type: FilterGroupOr,
+			Groups: []FilterGroup{{
+				Type: FilterGroupAnd,
+				Filters: []FilterPredicate{{
+					Scope: ScopeInventory, Attribute: "foo", Type: "$eq", Value: "baz",
+				}},
+			}},
+		}},
+		Sort:       []SortCriteria{{Scope: ScopeInventory, Attribute: "foo"}},
+		Attributes: []SelectAttribute{{Scope: ScopeInventory, Attribute: "foo"}},
+	}
+
+	clone := params.Clone()
+	assert.Equal(t, params, clone)
+
+	clone.Filters[0].Attribute = "attribute1"
+	for _, p := range clone.FilterGroups[0].Predicates() {
+		p.Attribute = "attribute1"
+	}
+	clone.Sort[0].Attribute = "attribute1"
+	clone.Attributes[0].Attribute = "attribute1"
+
+	assert.Equal(t, "foo", params.Filters[0].Attribute)
+	assert.Equal(t, "foo", params.FilterGroups[0].Groups[0].Filters[0].Attribute)
+	assert.Equal(t, "foo", params.Sort[0].Attribute)
+	assert.Equal(t, "foo", params.Attributes[0].Attribute)
+}
+
 func TestFilterPredicateValueType(t *testing.T) {
 	testCases := map[string]struct {
 		filterPredicate FilterPredicate
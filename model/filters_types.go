@@ -0,0 +1,74 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// typeNames are the names of the types of the values in the error messages
+var typeNames = map[Type]string{
+	TypeStr:  "string",
+	TypeNum:  "number",
+	TypeBool: "boolean",
+}
+
+// ComparesValue returns true if the filter compares its value with the values
+// of the attribute, and the type of the value has to fit the attribute's;
+// it's not the case of the special attributes, the $exists, geo and version
+// filters
+func (f FilterPredicate) ComparesValue() bool {
+	if f.Scope == "" || parseSpecialAttr(f.Attribute) != "" ||
+		IsConnectivityAttribute(f.Scope, f.Attribute) ||
+		f.Type == "$exists" || f.Type == "$nexists" ||
+		isGeoFilter(f.Type) || isVersionFilter(f.Type) {
+		return false
+	}
+	values, ok := f.Value.([]interface{})
+	return !ok || len(values) > 0
+}
+
+// CheckValueType verifies that the type of the value of the filter fits the
+// types the attribute is indexed with, given the fields of the devices index
+// mapping; the attribute name is the mapped one. Attributes not indexed
+// yet, or declared to be indexed as a type, are not checked
+func (f FilterPredicate) CheckValueType(fields map[string]interface{}) error {
+	if !f.ComparesValue() || f.IndexedAs != TypeAny {
+		// the declared type of the attribute converts the value
+		return nil
+	}
+	typ, _, err := f.ValueType()
+	if err != nil {
+		// unsupported values are rejected building the query
+		return nil
+	}
+
+	indexed := make([]string, 0, len(typeNames))
+	for _, t := range []Type{TypeStr, TypeNum, TypeBool} {
+		if _, ok := fields[ToAttr(f.Scope, f.Attribute, t)]; !ok {
+			continue
+		} else if t == typ {
+			return nil
+		}
+		indexed = append(indexed, typeNames[t])
+	}
+	if len(indexed) == 0 {
+		return nil
+	}
+	return errors.Errorf("must be a %s, the attribute is not indexed as %s",
+		strings.Join(indexed, " or "), typeNames[typ])
+}
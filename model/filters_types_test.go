@@ -0,0 +1,126 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckValueType(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]interface{}{
+		"inventory_attribute1_num":  M{"type": "double"},
+		"inventory_attribute2_str":  M{"type": "keyword"},
+		"inventory_attribute3_str":  M{"type": "keyword"},
+		"inventory_attribute3_bool": M{"type": "boolean"},
+	}
+	testCases := map[string]struct {
+		filter FilterPredicate
+
+		err string
+	}{
+		"ok, number": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute1",
+				Type:      "$gt",
+				Value:     float64(512),
+			},
+		},
+		"ok, strings": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute2",
+				Type:      "$in",
+				Value:     []interface{}{"qemux86-64", "raspberrypi4"},
+			},
+		},
+		"ok, attribute indexed with multiple types": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute3",
+				Type:      "$eq",
+				Value:     true,
+			},
+		},
+		"ok, attribute not indexed": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute4",
+				Type:      "$eq",
+				Value:     "value",
+			},
+		},
+		"ok, exists": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute1",
+				Type:      "$exists",
+				Value:     true,
+			},
+		},
+		"ok, device ID": {
+			filter: FilterPredicate{
+				Scope:     ScopeIdentity,
+				Attribute: attrDeviceID,
+				Type:      "$eq",
+				Value:     "194d1060-1717-44dc-a783-00038f4a8013",
+			},
+		},
+		"error, string on numeric attribute": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute1",
+				Type:      "$eq",
+				Value:     "512",
+			},
+			err: "must be a number, the attribute is not indexed as string",
+		},
+		"error, pattern on numeric attribute": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute1",
+				Type:      FilterTypeWildcard,
+				Value:     "5*",
+			},
+			err: "must be a number, the attribute is not indexed as string",
+		},
+		"error, numbers on attribute indexed with multiple types": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "attribute3",
+				Type:      "$in",
+				Value:     []interface{}{float64(1), float64(2)},
+			},
+			err: "must be a string or boolean, the attribute is not indexed as number",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter.CheckValueType(fields)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FilterTypeVersionGt  = "$version_gt"
+	FilterTypeVersionGte = "$version_gte"
+	FilterTypeVersionLt  = "$version_lt"
+	FilterTypeVersionLte = "$version_lte"
+)
+
+const (
+	// versionComponentWidth is the number of digits the numeric components
+	// of the versions are padded to in their sortable form
+	versionComponentWidth = 10
+
+	// the sortable form of a version ends with versionPreRelease followed
+	// by the pre-release identifier, or with versionRelease: both sort
+	// before the components separator, so that 1.2-rc1 < 1.2 < 1.2.1
+	versionPreRelease = "!"
+	versionRelease    = "#"
+)
+
+var (
+	ErrVersionRequired = errors.New(
+		"the value must be a version, e.g. 1.2.10 or 2.0.0-rc1")
+
+	// versionRegexp matches the versions made of two or more numeric
+	// components, optionally prefixed with "v" and followed by a
+	// pre-release identifier and build metadata
+	versionRegexp = regexp.MustCompile(
+		`^v?([0-9]+(?:\.[0-9]+)+)(?:[-~]([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+	versionRangeOps = map[string]string{
+		FilterTypeVersionGt:  "gt",
+		FilterTypeVersionGte: "gte",
+		FilterTypeVersionLt:  "lt",
+		FilterTypeVersionLte: "lte",
+	}
+)
+
+// NormalizeVersion returns the sortable form of a version string, which
+// compares lexicographically in the version order, and whether the string
+// is a version at all
+func NormalizeVersion(s string) (string, bool) {
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	components := strings.Split(m[1], ".")
+	for i, c := range components {
+		if len(c) > versionComponentWidth {
+			return "", false
+		}
+		n, _ := strconv.ParseUint(c, 10, 64)
+		components[i] = fmt.Sprintf("%0*d", versionComponentWidth, n)
+	}
+	normalized := strings.Join(components, ".")
+	if m[2] != "" {
+		return normalized + versionPreRelease + m[2], true
+	}
+	return normalized + versionRelease, true
+}
+
+// normalizeVersions returns the sortable form of the values which are
+// versions, skipping the others
+func normalizeVersions(values []string) []string {
+	var versions []string
+	for _, v := range values {
+		if version, ok := NormalizeVersion(v); ok {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+func isVersionFilter(filterType string) bool {
+	_, ok := versionRangeOps[filterType]
+	return ok
+}
+
+// filterVersionRange compares the attribute with a version, using the
+// sortable form of the string attributes indexed alongside them
+type filterVersionRange struct {
+	*filter
+
+	op string
+}
+
+func NewFilterVersionRange(fp FilterPredicate) (*filterVersionRange, error) {
+	value, ok := fp.Value.(string)
+	if !ok {
+		return nil, ErrVersionRequired
+	}
+	version, ok := NormalizeVersion(value)
+	if !ok {
+		return nil, ErrVersionRequired
+	}
+	return &filterVersionRange{
+		filter: &filter{
+			attr: ToAttr(fp.Scope, fp.Attribute, TypeVersion),
+			val:  version,
+		},
+		op: versionRangeOps[fp.Type],
+	}, nil
+}
+
+func (f *filterVersionRange) AddTo(q Query) Query {
+	return q.Must(M{
+		"range": M{
+			f.attr: M{
+				f.op: f.val,
+			},
+		},
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	t.Parallel()
+
+	// in ascending version order
+	versions := []string{
+		"1.2.0-rc1",
+		"1.2.0-rc2",
+		"v1.2.0",
+		"1.2.0.1",
+		"1.2.9",
+		"1.2.10+build.5",
+		"1.10",
+		"10.0.0",
+	}
+	var previous string
+	for _, v := range versions {
+		normalized, ok := NormalizeVersion(v)
+		assert.True(t, ok, v)
+		assert.Greater(t, normalized, previous, v)
+		previous = normalized
+	}
+
+	for _, v := range []string{"", "1", "1.x", "release-1.2", "1.2.12345678901"} {
+		_, ok := NormalizeVersion(v)
+		assert.False(t, ok, v)
+	}
+}
+
+func TestVersionFilters(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		filter FilterPredicate
+
+		query string
+		err   string
+	}{
+		"ok, gte": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "os_release",
+				Type:      FilterTypeVersionGte,
+				Value:     "1.2.10",
+			},
+			query: `{"range": {"inventory_os_release_ver": {
+				"gte": "0000000001.0000000002.0000000010#"}}}`,
+		},
+		"ok, lt, pre-release": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "kernel",
+				Type:      FilterTypeVersionLt,
+				Value:     "5.10-rc1",
+			},
+			query: `{"range": {"inventory_kernel_ver": {
+				"lt": "0000000005.0000000010!rc1"}}}`,
+		},
+		"error, not a version": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "kernel",
+				Type:      FilterTypeVersionGt,
+				Value:     "latest",
+			},
+			err: ErrVersionRequired.Error(),
+		},
+		"error, not a string": {
+			filter: FilterPredicate{
+				Scope:     ScopeInventory,
+				Attribute: "kernel",
+				Type:      FilterTypeVersionLte,
+				Value:     float64(5),
+			},
+			err: ErrVersionRequired.Error(),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.filter.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			query, err := BuildQuery(SearchParams{
+				Filters: []FilterPredicate{tc.filter},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			})
+			if assert.NoError(t, err) {
+				b, _ := json.Marshal(query)
+				var actual map[string]interface{}
+				_ = json.Unmarshal(b, &actual)
+				must := actual["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"]
+				b, _ = json.Marshal(must.([]interface{})[0])
+				assert.JSONEq(t, tc.query, string(b))
+			}
+		})
+	}
+}
+
+func TestDeviceMarshalJSONVersions(t *testing.T) {
+	t.Parallel()
+
+	device := NewDevice("tenant", "device")
+	device.InventoryAttributes = InventoryAttributes{
+		NewInventoryAttribute(ScopeInventory).SetName("kernel").
+			SetStrings([]string{"5.10.1", "custom"}),
+		NewInventoryAttribute(ScopeInventory).SetName("hostname").
+			SetString("edge-gw-1"),
+	}
+	b, err := json.Marshal(device)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": "device", "tenant_id": "tenant",
+		"inventory_kernel_str": ["5.10.1", "custom"],
+		"inventory_kernel_ver": ["0000000005.0000000010.0000000001#"],
+		"inventory_hostname_str": ["edge-gw-1"]}`, string(b))
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	defaultGroupsLimit = 100
+	maxGroupsLimit     = 1000
+	// maxGroupsFilter is the maximum number of groups the devices are
+	// filtered by
+	maxGroupsFilter = 100
+)
+
+// GroupsAggregation is the name of the aggregation of the devices by group
+const GroupsAggregation = "groups"
+
+type groupsFilter struct {
+	groups []string
+}
+
+// NewGroupsFilter returns the query part matching the devices in any of
+// the groups, with a terms filter on the group attribute
+func NewGroupsFilter(groups []string) *groupsFilter {
+	return &groupsFilter{
+		groups: groups,
+	}
+}
+
+func (f *groupsFilter) AddTo(q Query) Query {
+	return q.Must(M{
+		"terms": M{
+			ToAttr(ScopeSystem, AttrNameGroup, TypeStr): f.groups,
+		},
+	})
+}
+
+// GroupsParams are the parameters of the listing of the groups of devices
+type GroupsParams struct {
+	Limit    int      `json:"limit" form:"limit"`
+	Groups   []string `json:"-" form:"-"`
+	TenantID string   `json:"-" form:"-"`
+}
+
+func (p GroupsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(maxGroupsLimit)),
+	)
+}
+
+// AggregateParams returns the parameters of the aggregation of the devices
+// by group listing the groups
+func (p GroupsParams) AggregateParams() AggregateParams {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = defaultGroupsLimit
+	}
+	return AggregateParams{
+		Aggregations: []AggregationTerm{{
+			Name:      GroupsAggregation,
+			Scope:     ScopeSystem,
+			Attribute: AttrNameGroup,
+			Limit:     limit,
+		}},
+		Groups:   p.Groups,
+		TenantID: p.TenantID,
+	}
+}
+
+// DeviceGroup is a group of devices, with the number of devices in it
+type DeviceGroup struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// GroupsAggregateParams are the parameters of the aggregation of the
+// devices by group: the aggregations are computed for each group
+type GroupsAggregateParams struct {
+	Filters []FilterPredicate `json:"filters"`
+	// InGroups restricts the aggregation to the devices in any of the groups
+	InGroups     []string          `json:"groups,omitempty"`
+	Aggregations []AggregationTerm `json:"aggregations"`
+	// Limit is the maximum number of groups
+	Limit    int      `json:"limit"`
+	Groups   []string `json:"-"`
+	TenantID string   `json:"-"`
+}
+
+func (p GroupsAggregateParams) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.InGroups, validation.Length(0, maxGroupsFilter),
+			validation.Each(validation.Required)),
+		validation.Field(&p.Aggregations, validation.Length(0, maxAggregationTerms)),
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(maxGroupsLimit)),
+	)
+	if err != nil {
+		return err
+	}
+	// the aggregations are nested one level deeper
+	return p.AggregateParams().Validate()
+}
+
+// AggregateParams returns the parameters of the aggregation of the devices
+// nesting the aggregations under a terms aggregation on the group
+func (p GroupsAggregateParams) AggregateParams() AggregateParams {
+	aggregateParams := GroupsParams{
+		Limit:    p.Limit,
+		Groups:   p.Groups,
+		TenantID: p.TenantID,
+	}.AggregateParams()
+	aggregateParams.Aggregations[0].Aggregations = p.Aggregations
+	aggregateParams.Filters = append([]FilterPredicate(nil), p.Filters...)
+	if len(p.InGroups) > 0 {
+		aggregateParams.Filters = append(aggregateParams.Filters, FilterPredicate{
+			Scope:     ScopeSystem,
+			Attribute: AttrNameGroup,
+			Type:      "$in",
+			Value:     p.InGroups,
+		})
+	}
+	return aggregateParams
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupsParamsAggregateParams(t *testing.T) {
+	t.Parallel()
+
+	params := GroupsParams{
+		Groups:   []string{"prod"},
+		TenantID: "tenant",
+	}
+	assert.NoError(t, params.Validate())
+	assert.Equal(t, AggregateParams{
+		Aggregations: []AggregationTerm{{
+			Name:      GroupsAggregation,
+			Scope:     ScopeSystem,
+			Attribute: AttrNameGroup,
+			Limit:     defaultGroupsLimit,
+		}},
+		Groups:   []string{"prod"},
+		TenantID: "tenant",
+	}, params.AggregateParams())
+
+	params.Limit = maxGroupsLimit + 1
+	assert.EqualError(t, params.Validate(), "limit: must be no greater than 1000.")
+}
+
+func TestGroupsAggregateParams(t *testing.T) {
+	t.Parallel()
+
+	filters := []FilterPredicate{{
+		Scope:     ScopeInventory,
+		Attribute: "device_type",
+		Type:      "$eq",
+		Value:     "rpi4",
+	}}
+	aggregations := []AggregationTerm{{
+		Name:      "artifacts",
+		Scope:     ScopeInventory,
+		Attribute: "artifact_name",
+	}}
+	testCases := map[string]struct {
+		params GroupsAggregateParams
+
+		aggregateParams AggregateParams
+		err             error
+	}{
+		"ok": {
+			params: GroupsAggregateParams{
+				Filters:      filters,
+				InGroups:     []string{"prod", "staging"},
+				Aggregations: aggregations,
+				Limit:        10,
+				TenantID:     "tenant",
+			},
+			aggregateParams: AggregateParams{
+				Aggregations: []AggregationTerm{{
+					Name:         GroupsAggregation,
+					Scope:        ScopeSystem,
+					Attribute:    AttrNameGroup,
+					Limit:        10,
+					Aggregations: aggregations,
+				}},
+				Filters: append(filters, FilterPredicate{
+					Scope:     ScopeSystem,
+					Attribute: AttrNameGroup,
+					Type:      "$in",
+					Value:     []string{"prod", "staging"},
+				}),
+				TenantID: "tenant",
+			},
+		},
+		"ok, counts only": {
+			params: GroupsAggregateParams{},
+			aggregateParams: AggregateParams{
+				Aggregations: []AggregationTerm{{
+					Name:      GroupsAggregation,
+					Scope:     ScopeSystem,
+					Attribute: AttrNameGroup,
+					Limit:     defaultGroupsLimit,
+				}},
+			},
+		},
+		"ko, too many groups": {
+			params: GroupsAggregateParams{
+				InGroups: strings.Split(strings.Repeat("g,", maxGroupsFilter), ","),
+			},
+			err: errors.New("groups: the length must be no more than 100."),
+		},
+		"ko, invalid aggregation": {
+			params: GroupsAggregateParams{
+				Aggregations: []AggregationTerm{{Name: "artifacts"}},
+			},
+			err: errors.New("aggregations: (0: (attribute: cannot be blank; " +
+				"scope: cannot be blank.).)."),
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.aggregateParams, tc.params.AggregateParams())
+		})
+	}
+}
+
+func TestBuildQueryInGroups(t *testing.T) {
+	t.Parallel()
+
+	params := SearchParams{
+		InGroups: []string{"prod", "staging"},
+		Groups:   []string{"prod"},
+	}
+	assert.NoError(t, params.Validate())
+	query, err := BuildQuery(params)
+	assert.NoError(t, err)
+	assert.Equal(t, NewQuery().Must(M{
+		"terms": M{
+			"system_group_str": []string{"prod", "staging"},
+		},
+	}).Must(M{
+		"terms": M{
+			"system_group_str": []string{"prod"},
+		},
+	}).WithPage(0, 0), query)
+
+	params.InGroups = []string{""}
+	assert.EqualError(t, params.Validate(), "groups: (0: cannot be blank.).")
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+const (
+	HealthStatusOK    = "ok"
+	HealthStatusError = "error"
+)
+
+const (
+	DependencyDatastore = "datastore"
+	DependencyStore     = "store"
+	DependencyNats      = "nats"
+)
+
+// DependencyHealth is the health of a dependency of the service
+type DependencyHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthReport is the health of the service's dependencies; the service
+// is healthy only if all of them are
+type HealthReport struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+}
+
+// NewHealthReport returns the report of the dependencies' health
+func NewHealthReport(dependencies []DependencyHealth) *HealthReport {
+	report := &HealthReport{
+		Status:       HealthStatusOK,
+		Dependencies: dependencies,
+	}
+	for _, dependency := range dependencies {
+		if dependency.Status != HealthStatusOK {
+			report.Status = HealthStatusError
+		}
+	}
+	return report
+}
+
+// Healthy returns true if all the dependencies are healthy
+func (r *HealthReport) Healthy() bool {
+	return r.Status == HealthStatusOK
+}
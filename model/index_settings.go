@@ -0,0 +1,62 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// The indices whose dynamic settings can be updated
+const (
+	IndexDevices     = "devices"
+	IndexDeployments = "deployments"
+	IndexSoftware    = "software"
+)
+
+// refreshIntervalRegexp matches the refresh intervals: a time unit, or -1
+// to disable the refreshes
+var refreshIntervalRegexp = regexp.MustCompile(`^(-1|\d+(ms|s|m|h|d))$`)
+
+var errIndexSettingsEmpty = errors.New("no settings to update")
+
+// IndexSettings are the dynamic settings of the indices, which can be
+// updated on the existing indices; the settings not set are left as they are
+type IndexSettings struct {
+	Replicas        *int    `json:"number_of_replicas,omitempty"`
+	RefreshInterval *string `json:"refresh_interval,omitempty"`
+}
+
+func (s IndexSettings) Validate() error {
+	if s.Replicas == nil && s.RefreshInterval == nil {
+		return errIndexSettingsEmpty
+	}
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Replicas, validation.Min(0)),
+		validation.Field(&s.RefreshInterval,
+			validation.Match(refreshIntervalRegexp).
+				Error("must be a time unit, e.g. 30s, or -1")),
+	)
+}
+
+// ValidateIndexName validates the name of the index whose settings are
+// updated
+func ValidateIndexName(index string) error {
+	return validation.Validate(index,
+		validation.In(IndexDevices, IndexDeployments, IndexSoftware).
+			Error("must be one of devices, deployments or software"))
+}
@@ -0,0 +1,386 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxIndexingRulesFilters    = 20
+	maxIndexingRulesAttributes = 100
+)
+
+// IndexingRules are the conditions a device of the tenant must satisfy,
+// all of them, to be indexed; without filters, all the devices are indexed.
+// The attributes filter selects which attributes of the devices are indexed.
+type IndexingRules struct {
+	TenantID   string            `json:"-" bson:"_id"`
+	Filters    []FilterPredicate `json:"filters" bson:"filters"`
+	Attributes *AttributesFilter `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	UpdatedTs  time.Time         `json:"updated_ts" bson:"updated_ts"`
+}
+
+func (r IndexingRules) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Filters, validation.Length(0, maxIndexingRulesFilters)),
+		validation.Field(&r.Attributes),
+	)
+}
+
+// IndexesAttribute returns true if the attribute is selected for indexing
+// by the attributes filter
+func (r *IndexingRules) IndexesAttribute(scope, name string) bool {
+	if r == nil {
+		return true
+	}
+	return r.Attributes.Match(scope, name)
+}
+
+// AttributesFilter selects the attributes of the devices to index: if any
+// attribute is included, only the included attributes are indexed, less
+// the excluded ones. The system attributes and the status of the devices
+// are always indexed.
+type AttributesFilter struct {
+	Included []AttributeSelector `json:"included,omitempty" bson:"included,omitempty"`
+	Excluded []AttributeSelector `json:"excluded,omitempty" bson:"excluded,omitempty"`
+}
+
+func (f AttributesFilter) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.Included, validation.Length(0, maxIndexingRulesAttributes)),
+		validation.Field(&f.Excluded, validation.Length(0, maxIndexingRulesAttributes)),
+	)
+}
+
+// Match returns true if the attribute is selected by the filter
+func (f *AttributesFilter) Match(scope, name string) bool {
+	if f == nil || scope == ScopeSystem ||
+		(scope == ScopeIdentity && name == AttrNameStatus) {
+		return true
+	}
+	for _, selector := range f.Excluded {
+		if selector.Match(scope, name) {
+			return false
+		}
+	}
+	if len(f.Included) == 0 {
+		return true
+	}
+	for _, selector := range f.Included {
+		if selector.Match(scope, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AttributeSelector selects the attributes of a scope by name; the name
+// may contain the * and ? wildcards, matching any sequence of characters
+// and any single character, except the slashes
+type AttributeSelector struct {
+	Scope string `json:"scope" bson:"scope"`
+	Name  string `json:"name" bson:"name"`
+}
+
+// ParseAttributeSelector parses an attribute selector in the scope/name
+// form, e.g. inventory/cpu_*
+func ParseAttributeSelector(s string) (AttributeSelector, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) < 2 {
+		return AttributeSelector{}, errors.Errorf(
+			"invalid attribute selector %q: expected scope/name", s)
+	}
+	selector := AttributeSelector{Scope: parts[0], Name: parts[1]}
+	if err := selector.Validate(); err != nil {
+		return AttributeSelector{}, errors.Wrapf(err, "invalid attribute selector %q", s)
+	}
+	return selector, nil
+}
+
+func (s AttributeSelector) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Scope, validation.Required),
+		validation.Field(&s.Name, validation.Required, validation.By(checkNamePattern)),
+	)
+}
+
+func checkNamePattern(value interface{}) error {
+	pattern, _ := value.(string)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.New("must be a valid pattern")
+	}
+	return nil
+}
+
+// Match returns true if the selector selects the attribute
+func (s AttributeSelector) Match(scope, name string) bool {
+	if s.Scope != scope {
+		return false
+	}
+	ok, _ := path.Match(s.Name, name)
+	return ok
+}
+
+// Match returns true if the attributes satisfy all the filters
+func (r *IndexingRules) Match(attrs AttributeValues) bool {
+	if r == nil {
+		return true
+	}
+	for _, filter := range r.Filters {
+		value, exists := attrs.Get(filter.Scope, filter.Attribute)
+		if !filter.Match(value, exists) {
+			return false
+		}
+	}
+	return true
+}
+
+// DependsOn returns true if any of the filters is on the attribute
+func (r *IndexingRules) DependsOn(scope, name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, filter := range r.Filters {
+		if filter.Scope == scope && filter.Attribute == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AttributeValues are the raw values of the attributes of a device,
+// by scope and name
+type AttributeValues map[string]map[string]interface{}
+
+// Set sets the value of the attribute
+func (v AttributeValues) Set(scope, name string, value interface{}) {
+	if v[scope] == nil {
+		v[scope] = make(map[string]interface{})
+	}
+	v[scope][name] = value
+}
+
+// Get returns the value of the attribute, and whether it exists
+func (v AttributeValues) Get(scope, name string) (interface{}, bool) {
+	value, ok := v[scope][name]
+	return value, ok
+}
+
+// Match evaluates the filter against the value of an attribute; if the
+// value is an array, the filter matches if any of its elements matches,
+// except for the negated selectors, which require none of them to match
+func (f FilterPredicate) Match(value interface{}, exists bool) bool {
+	match, ok := filterMatchers[f.Type]
+	if !ok {
+		return false
+	}
+	return match(f, value, exists)
+}
+
+// filterMatcher evaluates a filter of a given type against the value of
+// an attribute
+type filterMatcher func(f FilterPredicate, value interface{}, exists bool) bool
+
+var filterMatchers = map[string]filterMatcher{
+	"$exists":            matchExists,
+	"$nexists":           matchNotExists,
+	"$eq":                matchEqual,
+	"$ne":                matchNotEqual,
+	"$in":                matchIn,
+	"$nin":               matchNotIn,
+	"$gt":                matchCompare,
+	"$gte":               matchCompare,
+	"$lt":                matchCompare,
+	"$lte":               matchCompare,
+	FilterTypeVersionGt:  matchVersion,
+	FilterTypeVersionGte: matchVersion,
+	FilterTypeVersionLt:  matchVersion,
+	FilterTypeVersionLte: matchVersion,
+	FilterTypeRegex:      matchPattern,
+	FilterTypeWildcard:   matchPattern,
+}
+
+// comparisonResults tells whether the result of the comparison of the
+// value with the filter value satisfies the comparison filters
+var comparisonResults = map[string]func(cmp int) bool{
+	"$gt":                func(cmp int) bool { return cmp > 0 },
+	"$gte":               func(cmp int) bool { return cmp >= 0 },
+	"$lt":                func(cmp int) bool { return cmp < 0 },
+	"$lte":               func(cmp int) bool { return cmp <= 0 },
+	FilterTypeVersionGt:  func(cmp int) bool { return cmp > 0 },
+	FilterTypeVersionGte: func(cmp int) bool { return cmp >= 0 },
+	FilterTypeVersionLt:  func(cmp int) bool { return cmp < 0 },
+	FilterTypeVersionLte: func(cmp int) bool { return cmp <= 0 },
+}
+
+func matchExists(f FilterPredicate, _ interface{}, exists bool) bool {
+	want, _ := f.Value.(bool)
+	return exists == want
+}
+
+func matchNotExists(f FilterPredicate, _ interface{}, exists bool) bool {
+	want, _ := f.Value.(bool)
+	return exists != want
+}
+
+func matchEqual(f FilterPredicate, value interface{}, exists bool) bool {
+	return exists && anyMatch(value, func(v interface{}) bool {
+		return equalValues(v, f.Value)
+	})
+}
+
+func matchNotEqual(f FilterPredicate, value interface{}, exists bool) bool {
+	return !matchEqual(f, value, exists)
+}
+
+func matchIn(f FilterPredicate, value interface{}, exists bool) bool {
+	return exists && anyMatch(value, func(v interface{}) bool {
+		return inValues(v, f.Value)
+	})
+}
+
+func matchNotIn(f FilterPredicate, value interface{}, exists bool) bool {
+	return !matchIn(f, value, exists)
+}
+
+func matchCompare(f FilterPredicate, value interface{}, exists bool) bool {
+	satisfies := comparisonResults[f.Type]
+	return exists && anyMatch(value, func(v interface{}) bool {
+		cmp, ok := compareValues(v, f.Value)
+		return ok && satisfies(cmp)
+	})
+}
+
+// matchVersion compares the values as versions, once normalized
+func matchVersion(f FilterPredicate, value interface{}, exists bool) bool {
+	if !exists {
+		return false
+	}
+	bound, _ := f.Value.(string)
+	want, ok := NormalizeVersion(bound)
+	if !ok {
+		return false
+	}
+	satisfies := comparisonResults[f.Type]
+	return anyMatch(value, func(v interface{}) bool {
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		version, ok := NormalizeVersion(s)
+		return ok && satisfies(strings.Compare(version, want))
+	})
+}
+
+// matchPattern matches the values against the regular expression or the
+// wildcard pattern
+func matchPattern(f FilterPredicate, value interface{}, exists bool) bool {
+	pattern, ok := f.Value.(string)
+	if !exists || !ok {
+		return false
+	}
+	if f.Type == FilterTypeWildcard {
+		pattern = WildcardToRegexp(pattern)
+	}
+	// same semantics as the search: the whole value must match
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return false
+	}
+	return anyMatch(value, func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && re.MatchString(s)
+	})
+}
+
+func anyMatch(value interface{}, match func(interface{}) bool) bool {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			if match(rv.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+	return match(value)
+}
+
+func inValues(value, values interface{}) bool {
+	return anyMatch(values, func(v interface{}) bool {
+		return equalValues(value, v)
+	})
+}
+
+func equalValues(a, b interface{}) bool {
+	if a, ok := a.(bool); ok {
+		b, ok := b.(bool)
+		return ok && a == b
+	}
+	cmp, ok := compareValues(a, b)
+	return ok && cmp == 0
+}
+
+// compareValues compares two numbers or two strings, returning false
+// if the values are not comparable
+func compareValues(a, b interface{}) (int, bool) {
+	switch a := normalizeValue(a).(type) {
+	case float64:
+		b, ok := normalizeValue(b).(float64)
+		if !ok {
+			return 0, false
+		}
+		if a < b {
+			return -1, true
+		} else if a > b {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		if a < b {
+			return -1, true
+		} else if a > b {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return v
+}
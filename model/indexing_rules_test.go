@@ -0,0 +1,217 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexingRulesValidate(t *testing.T) {
+	rules := IndexingRules{
+		Filters: []FilterPredicate{{
+			Scope:     ScopeSystem,
+			Attribute: AttrNameGroup,
+			Type:      "$eq",
+			Value:     "production",
+		}},
+	}
+	assert.NoError(t, rules.Validate())
+
+	rules.Filters[0].Type = "$nope"
+	assert.EqualError(t, rules.Validate(),
+		"filters: (0: (type: must be a valid value.).).")
+
+	rules.Filters = make([]FilterPredicate, maxIndexingRulesFilters+1)
+	assert.EqualError(t, rules.Validate(), "filters: the length must be no more than 20.")
+
+	rules.Filters = nil
+	rules.Attributes = &AttributesFilter{
+		Excluded: []AttributeSelector{{Scope: ScopeInventory, Name: "cpu_[a"}},
+	}
+	assert.EqualError(t, rules.Validate(),
+		"attributes: (excluded: (0: (name: must be a valid pattern.).).).")
+}
+
+func TestAttributesFilterMatch(t *testing.T) {
+	var filter *AttributesFilter
+	assert.True(t, filter.Match(ScopeInventory, "cpu_model"))
+
+	filter = &AttributesFilter{
+		Excluded: []AttributeSelector{
+			{Scope: ScopeInventory, Name: "cpu_*"},
+			{Scope: ScopeSystem, Name: "*"},
+		},
+	}
+	assert.False(t, filter.Match(ScopeInventory, "cpu_model"))
+	assert.True(t, filter.Match(ScopeInventory, "mem_total_kB"))
+	assert.True(t, filter.Match(ScopeIdentity, "cpu_model"))
+	assert.True(t, filter.Match(ScopeSystem, AttrNameGroup))
+
+	filter.Included = []AttributeSelector{
+		{Scope: ScopeInventory, Name: "?pu_*"},
+		{Scope: ScopeIdentity, Name: "mac"},
+	}
+	assert.False(t, filter.Match(ScopeInventory, "cpu_model"))
+	assert.True(t, filter.Match(ScopeInventory, "gpu_model"))
+	assert.False(t, filter.Match(ScopeInventory, "mem_total_kB"))
+	assert.True(t, filter.Match(ScopeIdentity, "mac"))
+	assert.True(t, filter.Match(ScopeIdentity, AttrNameStatus))
+}
+
+func TestParseAttributeSelector(t *testing.T) {
+	selector, err := ParseAttributeSelector("inventory/cpu_*")
+	assert.NoError(t, err)
+	assert.Equal(t, AttributeSelector{Scope: ScopeInventory, Name: "cpu_*"}, selector)
+
+	_, err = ParseAttributeSelector("cpu_model")
+	assert.EqualError(t, err,
+		`invalid attribute selector "cpu_model": expected scope/name`)
+
+	_, err = ParseAttributeSelector("inventory/")
+	assert.EqualError(t, err,
+		`invalid attribute selector "inventory/": name: cannot be blank.`)
+}
+
+func TestIndexingRulesMatch(t *testing.T) {
+	attrs := AttributeValues{}
+	attrs.Set(ScopeSystem, AttrNameGroup, "production")
+	attrs.Set(ScopeInventory, "mem", float64(1024))
+	attrs.Set(ScopeInventory, "tags", []interface{}{"a", "b"})
+	attrs.Set(ScopeIdentity, "verified", true)
+	attrs.Set(ScopeInventory, "kernel", "5.10.0-rc1")
+
+	testCases := map[string]struct {
+		filter FilterPredicate
+		match  bool
+	}{
+		"$eq": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$eq", Value: "production"},
+			match: true,
+		},
+		"$eq, no match": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$eq", Value: "test"},
+		},
+		"$eq, array": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "tags",
+				Type: "$eq", Value: "b"},
+			match: true,
+		},
+		"$eq, bool": {
+			filter: FilterPredicate{Scope: ScopeIdentity, Attribute: "verified",
+				Type: "$eq", Value: true},
+			match: true,
+		},
+		"$eq, missing": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "foo",
+				Type: "$eq", Value: "bar"},
+		},
+		"$ne": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "tags",
+				Type: "$ne", Value: "c"},
+			match: true,
+		},
+		"$ne, array": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "tags",
+				Type: "$ne", Value: "a"},
+		},
+		"$ne, missing": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "foo",
+				Type: "$ne", Value: "bar"},
+			match: true,
+		},
+		"$in": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$in", Value: []interface{}{"production", "staging"}},
+			match: true,
+		},
+		"$nin": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$nin", Value: []interface{}{"production", "staging"}},
+		},
+		"$gte": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "mem",
+				Type: "$gte", Value: float64(1024)},
+			match: true,
+		},
+		"$lt": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "mem",
+				Type: "$lt", Value: float64(1024)},
+		},
+		"$gt, mismatched types": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "mem",
+				Type: "$gt", Value: "512"},
+		},
+		"$exists": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "mem",
+				Type: "$exists", Value: true},
+			match: true,
+		},
+		"$exists, false": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "foo",
+				Type: "$exists", Value: false},
+			match: true,
+		},
+		"$nexists": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "foo",
+				Type: "$nexists", Value: true},
+			match: true,
+		},
+		"$nexists, existing attribute": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "mem",
+				Type: "$nexists", Value: true},
+		},
+		"$regex": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$regex", Value: "prod.*"},
+			match: true,
+		},
+		"$wildcard": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$wildcard", Value: "pro?uct*"},
+			match: true,
+		},
+		"$version_gte": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "kernel",
+				Type: "$version_gte", Value: "5.9"},
+			match: true,
+		},
+		"$version_lt, pre-release": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "kernel",
+				Type: "$version_lt", Value: "5.10.0"},
+			match: true,
+		},
+		"$version_lt": {
+			filter: FilterPredicate{Scope: ScopeInventory, Attribute: "kernel",
+				Type: "$version_lt", Value: "5.9"},
+		},
+		"$regex, partial": {
+			filter: FilterPredicate{Scope: ScopeSystem, Attribute: AttrNameGroup,
+				Type: "$regex", Value: "prod"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rules := &IndexingRules{Filters: []FilterPredicate{tc.filter}}
+			assert.Equal(t, tc.match, rules.Match(attrs))
+		})
+	}
+
+	var rules *IndexingRules
+	assert.True(t, rules.Match(attrs))
+}
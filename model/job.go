@@ -22,4 +22,38 @@ type Job struct {
 	DeviceID     string `json:"device_id"`
 	DeploymentID string `json:"deployment_id"`
 	Service      string `json:"service"`
+	// Status is the new status of the device, for the
+	// ActionUpdateDeviceStatus jobs
+	Status string `json:"status,omitempty"`
+
+	// TraceContext carries the trace context of the message the job
+	// was received with
+	TraceContext map[string]string `json:"-"`
+	// Acknowledger acknowledges the job to the queue it was received from
+	Acknowledger JobAcknowledger `json:"-"`
+}
+
+// JobAcknowledger acknowledges the processing of a job
+type JobAcknowledger interface {
+	// Ack acknowledges the job as processed
+	Ack() error
+	// Nak reports the job as failed, requesting its redelivery; the jobs
+	// which failed too many times are moved to the dead-letter queue
+	Nak(reason error) error
+}
+
+// Ack acknowledges the job as processed, if received from a queue
+func (j Job) Ack() error {
+	if j.Acknowledger == nil {
+		return nil
+	}
+	return j.Acknowledger.Ack()
+}
+
+// Nak reports the job as failed, if received from a queue
+func (j Job) Nak(reason error) error {
+	if j.Acknowledger == nil {
+		return nil
+	}
+	return j.Acknowledger.Nak(reason)
 }
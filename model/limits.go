@@ -0,0 +1,158 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxClauses            = 100
+	defaultMaxAggregationBuckets = 10000
+	defaultMaxPerPage            = 500
+	defaultMaxPatternLength      = 256
+
+	// dateHistogramBuckets is the estimate of the number of buckets of a
+	// date_histogram aggregation, whose number of buckets depends on the
+	// data: about three months of daily buckets
+	dateHistogramBuckets = 100
+)
+
+// QueryLimits are the server-side limits of the search and aggregation
+// queries, protecting the cluster from pathological requests
+type QueryLimits struct {
+	// MaxClauses is the maximum number of filters of a query, including
+	// the filters of the filter groups
+	MaxClauses int
+	// MaxAggregationBuckets is the maximum number of buckets the
+	// aggregations of a query may return, including the buckets of the
+	// sub-aggregations
+	MaxAggregationBuckets int
+	// MaxPerPage is the maximum number of results per page
+	MaxPerPage int
+	// MaxPatternLength is the maximum length of the $regex and $wildcard
+	// patterns
+	MaxPatternLength int
+}
+
+var queryLimits = DefaultQueryLimits()
+
+// DefaultQueryLimits returns the default limits of the queries
+func DefaultQueryLimits() QueryLimits {
+	return QueryLimits{
+		MaxClauses:            defaultMaxClauses,
+		MaxAggregationBuckets: defaultMaxAggregationBuckets,
+		MaxPerPage:            defaultMaxPerPage,
+		MaxPatternLength:      defaultMaxPatternLength,
+	}
+}
+
+// SetQueryLimits sets the limits of the queries enforced by the validation
+// of the search and aggregation parameters; the limits which are not
+// positive are left to their default value
+func SetQueryLimits(limits QueryLimits) {
+	defaults := DefaultQueryLimits()
+	if limits.MaxClauses <= 0 {
+		limits.MaxClauses = defaults.MaxClauses
+	}
+	if limits.MaxAggregationBuckets <= 0 {
+		limits.MaxAggregationBuckets = defaults.MaxAggregationBuckets
+	}
+	if limits.MaxPerPage <= 0 {
+		limits.MaxPerPage = defaults.MaxPerPage
+	}
+	if limits.MaxPatternLength <= 0 {
+		limits.MaxPatternLength = defaults.MaxPatternLength
+	}
+	queryLimits = limits
+}
+
+func checkClauses(clauses int) error {
+	if clauses > queryLimits.MaxClauses {
+		return errors.Errorf("too many filters, limit is %d", queryLimits.MaxClauses)
+	}
+	return nil
+}
+
+func checkPerPage(value interface{}) error {
+	perPage, _ := value.(int)
+	if perPage > queryLimits.MaxPerPage {
+		return errors.Errorf("must be no greater than %d", queryLimits.MaxPerPage)
+	}
+	return nil
+}
+
+func checkAggregationBuckets(buckets int) error {
+	if buckets > queryLimits.MaxAggregationBuckets {
+		return errors.Errorf("the aggregations would return too many buckets, limit is %d",
+			queryLimits.MaxAggregationBuckets)
+	}
+	return nil
+}
+
+// aggregationBuckets returns the maximum number of buckets of the
+// aggregations, including the buckets of their sub-aggregations
+func aggregationBuckets(aggregations []AggregationTerm) int {
+	buckets := 0
+	for _, agg := range aggregations {
+		var size int
+		switch {
+		case agg.IsMetric():
+			size = 1
+		case agg.Type == AggregationTypeDateHistogram:
+			size = dateHistogramBuckets
+		case agg.Limit > 0:
+			size = agg.Limit
+		default:
+			size = defaultAggregationLimit
+		}
+		if size > queryLimits.MaxAggregationBuckets {
+			// over the limit anyway, stop before overflowing
+			return size
+		}
+		buckets += size * (1 + aggregationBuckets(agg.Aggregations))
+		if buckets > queryLimits.MaxAggregationBuckets {
+			return buckets
+		}
+	}
+	return buckets
+}
+
+// deploymentsAggregationBuckets returns the maximum number of buckets of
+// the deployments aggregations, including the buckets of their
+// sub-aggregations
+func deploymentsAggregationBuckets(aggregations []DeploymentsAggregationTerm) int {
+	buckets := 0
+	for _, agg := range aggregations {
+		var size int
+		switch {
+		case agg.Type == AggregationTypeDateHistogram:
+			size = dateHistogramBuckets
+		case agg.Limit > 0:
+			size = agg.Limit
+		default:
+			size = defaultAggregationLimit
+		}
+		if size > queryLimits.MaxAggregationBuckets {
+			// over the limit anyway, stop before overflowing
+			return size
+		}
+		buckets += size * (1 + deploymentsAggregationBuckets(agg.Aggregations))
+		if buckets > queryLimits.MaxAggregationBuckets {
+			return buckets
+		}
+	}
+	return buckets
+}
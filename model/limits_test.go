@@ -0,0 +1,187 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLimits(t *testing.T) {
+	SetQueryLimits(QueryLimits{
+		MaxClauses:            2,
+		MaxAggregationBuckets: 100,
+		MaxPerPage:            50,
+		MaxPatternLength:      8,
+	})
+	defer SetQueryLimits(DefaultQueryLimits())
+
+	filter := FilterPredicate{
+		Scope:     ScopeInventory,
+		Attribute: "device_type",
+		Type:      "$eq",
+		Value:     "raspberrypi4",
+	}
+	testCases := map[string]struct {
+		params interface{ Validate() error }
+
+		err string
+	}{
+		"ok, search": {
+			params: SearchParams{
+				PerPage: 50,
+				Filters: []FilterPredicate{filter},
+				FilterGroups: []FilterGroup{{
+					Type:    FilterGroupOr,
+					Filters: []FilterPredicate{filter},
+				}},
+			},
+		},
+		"error, search page size": {
+			params: SearchParams{
+				PerPage: 51,
+			},
+			err: "per_page: must be no greater than 50.",
+		},
+		"error, search clauses": {
+			params: SearchParams{
+				Filters: []FilterPredicate{filter, filter},
+				FilterGroups: []FilterGroup{{
+					Type:    FilterGroupOr,
+					Filters: []FilterPredicate{filter},
+				}},
+			},
+			err: "too many filters, limit is 2",
+		},
+		"error, search pattern length": {
+			params: SearchParams{
+				Filters: []FilterPredicate{{
+					Scope:     ScopeInventory,
+					Attribute: "hostname",
+					Type:      FilterTypeWildcard,
+					Value:     "edge-gw-*-prod",
+				}},
+			},
+			err: "the pattern is too long, limit is 8 characters",
+		},
+		"ok, aggregations": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{{
+					Name:      "device_type",
+					Scope:     ScopeInventory,
+					Attribute: "device_type",
+					Limit:     9,
+					Aggregations: []AggregationTerm{{
+						Name:      "memory",
+						Scope:     ScopeInventory,
+						Attribute: "mem_total_kB",
+						Type:      AggregationTypeStats,
+					}, {
+						Name:      "artifact",
+						Scope:     ScopeInventory,
+						Attribute: "artifact_name",
+						Limit:     8,
+					}},
+				}},
+			},
+		},
+		"error, aggregation buckets": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{{
+					Name:      "device_type",
+					Scope:     ScopeInventory,
+					Attribute: "device_type",
+					Aggregations: []AggregationTerm{{
+						Name:      "artifact",
+						Scope:     ScopeInventory,
+						Attribute: "artifact_name",
+					}},
+				}},
+			},
+			err: "the aggregations would return too many buckets, limit is 100",
+		},
+		"error, aggregation limit": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{{
+					Name:      "device_type",
+					Scope:     ScopeInventory,
+					Attribute: "device_type",
+					Limit:     1 << 62,
+					Aggregations: []AggregationTerm{{
+						Name:      "artifact",
+						Scope:     ScopeInventory,
+						Attribute: "artifact_name",
+						Limit:     1 << 62,
+					}},
+				}},
+			},
+			err: "the aggregations would return too many buckets, limit is 100",
+		},
+		"error, aggregation clauses": {
+			params: AggregateParams{
+				Aggregations: []AggregationTerm{{
+					Name:      "device_type",
+					Scope:     ScopeInventory,
+					Attribute: "device_type",
+				}},
+				Filters: []FilterPredicate{filter, filter, filter},
+			},
+			err: "too many filters, limit is 2",
+		},
+		"error, deployments page size": {
+			params: DeploymentsSearchParams{
+				PerPage: 100,
+			},
+			err: "per_page: must be no greater than 50.",
+		},
+		"error, deployments aggregation buckets": {
+			params: AggregateDeploymentsParams{
+				Aggregations: []DeploymentsAggregationTerm{{
+					Name:      "per_day",
+					Attribute: "created",
+					Type:      AggregationTypeDateHistogram,
+					Interval:  "day",
+					Aggregations: []DeploymentsAggregationTerm{{
+						Name:      "status",
+						Attribute: "status",
+					}},
+				}},
+			},
+			err: "the aggregations would return too many buckets, limit is 100",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetQueryLimitsDefaults(t *testing.T) {
+	SetQueryLimits(QueryLimits{MaxPerPage: 10})
+	defer SetQueryLimits(DefaultQueryLimits())
+
+	expected := DefaultQueryLimits()
+	expected.MaxPerPage = 10
+	assert.Equal(t, expected, queryLimits)
+	assert.NoError(t, checkPatternLength(strings.Repeat("a", defaultMaxPatternLength)))
+}
@@ -14,9 +14,192 @@
 
 package model
 
+import (
+	"os"
+	"path"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
 const MaxMappingInventoryAttributes = 100
 
+// Mapping maps the tenant's attributes, as "scope/name", to the fields they
+// are indexed as: the attribute in the n-th slot is indexed as the n-th
+// field. The evicted attributes leave their slot empty, for the following
+// attributes to keep their fields, until it is reused by a new attribute.
 type Mapping struct {
 	TenantID  string   `json:"tenant_id" bson:"tenant_id"`
 	Inventory []string `json:"inventory" bson:"inventory"`
+	// Types are the types the attributes are declared to be indexed as
+	Types []MappingAttributeType `json:"types,omitempty" bson:"types,omitempty"`
+	// Remaps are the keys of the attributes whose type changed: their
+	// indexed values are converted to the new type by the migrations
+	Remaps []string `json:"remaps,omitempty" bson:"remaps,omitempty"`
+}
+
+// Count returns the number of attributes in the mapping, excluding the
+// empty slots of the evicted ones
+func (m *Mapping) Count() int {
+	n := 0
+	for i, attr := range m.Inventory {
+		if i == MaxMappingInventoryAttributes {
+			break
+		}
+		if attr != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// ChangedTypes returns the keys of the attributes whose type differs
+// between the declared types of the mapping and the given ones, in order
+func (m *Mapping) ChangedTypes(types []MappingAttributeType) []string {
+	current := make(map[string]string, len(m.Types))
+	for _, t := range m.Types {
+		current[t.Key()] = t.Type
+	}
+	changed := []string{}
+	for _, t := range types {
+		if typ, ok := current[t.Key()]; !ok || typ != t.Type {
+			changed = append(changed, t.Key())
+		}
+		delete(current, t.Key())
+	}
+	for _, t := range m.Types {
+		if _, ok := current[t.Key()]; ok {
+			changed = append(changed, t.Key())
+		}
+	}
+	return changed
+}
+
+// MappingAttribute is an attribute of the tenant's mapping
+type MappingAttribute struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name"`
+	// Devices is the number of indexed devices the attribute is set for
+	Devices int `json:"devices"`
+}
+
+// Key returns the key of the attribute in the mapping
+func (a MappingAttribute) Key() string {
+	return path.Join(a.Scope, a.Name)
+}
+
+// ParseMappingAttribute parses the key of an attribute in the mapping
+func ParseMappingAttribute(key string) MappingAttribute {
+	parts := strings.SplitN(key, string(os.PathSeparator), 2)
+	if len(parts) < 2 {
+		return MappingAttribute{Name: key}
+	}
+	return MappingAttribute{Scope: parts[0], Name: parts[1]}
+}
+
+// MappingUsage describes how much of the limit of the tenant's mapping is
+// used, and by which attributes
+type MappingUsage struct {
+	Limit      int                `json:"limit"`
+	Count      int                `json:"count"`
+	Remaining  int                `json:"remaining"`
+	Attributes []MappingAttribute `json:"attributes"`
+}
+
+// EvictMappingAttributes is the request to remove attributes from the
+// tenant's mapping, freeing their slots for new attributes
+type EvictMappingAttributes struct {
+	Attributes []MappingAttribute `json:"attributes"`
+}
+
+func (e EvictMappingAttributes) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Attributes, validation.Required,
+			validation.Length(1, MaxMappingInventoryAttributes),
+			validation.Each(validation.By(checkMappingAttribute))),
+	)
+}
+
+func checkMappingAttribute(value interface{}) error {
+	attr, _ := value.(MappingAttribute)
+	return validation.ValidateStruct(&attr,
+		validation.Field(&attr.Scope, validation.Required),
+		validation.Field(&attr.Name, validation.Required),
+	)
+}
+
+// the types the attributes can be declared to be indexed as, instead of
+// the default indexing of their strings and numbers in fields of their own
+const (
+	AttributeTypeNumber  = "number"
+	AttributeTypeDate    = "date"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeKeyword = "keyword"
+)
+
+var attributeTypes = map[string]Type{
+	AttributeTypeNumber:  TypeNum,
+	AttributeTypeDate:    TypeDate,
+	AttributeTypeBoolean: TypeBool,
+	AttributeTypeKeyword: TypeStr,
+}
+
+// MappingAttributeType is the type an inventory attribute is declared to be
+// indexed as: its values are converted to the type, and indexed in the
+// field of the type only
+type MappingAttributeType struct {
+	Scope string `json:"scope" bson:"scope"`
+	Name  string `json:"name" bson:"name"`
+	Type  string `json:"type" bson:"type"`
+}
+
+// Key returns the key of the attribute in the mapping
+func (t MappingAttributeType) Key() string {
+	return path.Join(t.Scope, t.Name)
+}
+
+// IndexedType returns the type of the field the attribute is indexed in
+func (t MappingAttributeType) IndexedType() Type {
+	return attributeTypes[t.Type]
+}
+
+// MappingTypes are the types the tenant's attributes are declared to be
+// indexed as
+type MappingTypes struct {
+	Types []MappingAttributeType `json:"types"`
+}
+
+func (t MappingTypes) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Types,
+			validation.Length(0, MaxMappingInventoryAttributes),
+			validation.Each(validation.By(checkMappingAttributeType)),
+			validation.By(checkUniqueMappingAttributeTypes)),
+	)
+}
+
+func checkMappingAttributeType(value interface{}) error {
+	t, _ := value.(MappingAttributeType)
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.Scope, validation.Required,
+			validation.In(ScopeInventory)),
+		validation.Field(&t.Name, validation.Required),
+		validation.Field(&t.Type, validation.Required,
+			validation.In(AttributeTypeNumber, AttributeTypeDate,
+				AttributeTypeBoolean, AttributeTypeKeyword)),
+	)
+}
+
+func checkUniqueMappingAttributeTypes(value interface{}) error {
+	types, _ := value.([]MappingAttributeType)
+	seen := make(map[string]bool, len(types))
+	for _, t := range types {
+		if seen[t.Key()] {
+			return errors.Errorf("the type of the attribute %s is declared twice",
+				t.Key())
+		}
+		seen[t.Key()] = true
+	}
+	return nil
 }
@@ -0,0 +1,169 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappingCount(t *testing.T) {
+	t.Parallel()
+
+	mapping := &Mapping{Inventory: []string{"inventory/mac", "", "identity/serial"}}
+	assert.Equal(t, 2, mapping.Count())
+
+	mapping = &Mapping{Inventory: make([]string, MaxMappingInventoryAttributes+1)}
+	for i := range mapping.Inventory {
+		mapping.Inventory[i] = "inventory/attr"
+	}
+	assert.Equal(t, MaxMappingInventoryAttributes, mapping.Count())
+}
+
+func TestParseMappingAttribute(t *testing.T) {
+	t.Parallel()
+
+	attr := ParseMappingAttribute("inventory/dir/name")
+	assert.Equal(t, MappingAttribute{Scope: ScopeInventory, Name: "dir/name"}, attr)
+	assert.Equal(t, "inventory/dir/name", attr.Key())
+
+	attr = ParseMappingAttribute("name")
+	assert.Equal(t, MappingAttribute{Name: "name"}, attr)
+}
+
+func TestEvictMappingAttributesValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name string
+
+		Request EvictMappingAttributes
+
+		Error string
+	}{{
+		Name: "ok",
+
+		Request: EvictMappingAttributes{
+			Attributes: []MappingAttribute{{Scope: ScopeInventory, Name: "mac"}},
+		},
+	}, {
+		Name: "error, no attributes",
+
+		Error: "attributes: cannot be blank.",
+	}, {
+		Name: "error, missing scope",
+
+		Request: EvictMappingAttributes{
+			Attributes: []MappingAttribute{{Name: "mac"}},
+		},
+		Error: "attributes: (0: (scope: cannot be blank.).).",
+	}, {
+		Name: "error, too many attributes",
+
+		Request: EvictMappingAttributes{
+			Attributes: make([]MappingAttribute, MaxMappingInventoryAttributes+1),
+		},
+		Error: "attributes: the length must be between 1 and 100.",
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.Request.Validate()
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMappingChangedTypes(t *testing.T) {
+	t.Parallel()
+
+	mapping := &Mapping{Types: []MappingAttributeType{
+		{Scope: ScopeInventory, Name: "last_boot", Type: AttributeTypeDate},
+		{Scope: ScopeInventory, Name: "mem_total_kB", Type: AttributeTypeNumber},
+	}}
+	changed := mapping.ChangedTypes([]MappingAttributeType{
+		{Scope: ScopeInventory, Name: "last_boot", Type: AttributeTypeDate},
+		{Scope: ScopeInventory, Name: "online", Type: AttributeTypeBoolean},
+	})
+	assert.ElementsMatch(t, []string{"inventory/mem_total_kB", "inventory/online"}, changed)
+}
+
+func TestMappingTypesValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name string
+
+		Request MappingTypes
+
+		Error string
+	}{{
+		Name: "ok",
+
+		Request: MappingTypes{
+			Types: []MappingAttributeType{
+				{Scope: ScopeInventory, Name: "last_boot", Type: AttributeTypeDate},
+			},
+		},
+	}, {
+		Name: "ok, no types",
+	}, {
+		Name: "error, unknown type",
+
+		Request: MappingTypes{
+			Types: []MappingAttributeType{
+				{Scope: ScopeInventory, Name: "last_boot", Type: "datetime"},
+			},
+		},
+		Error: "types: (0: (type: must be a valid value.).).",
+	}, {
+		Name: "error, not an inventory attribute",
+
+		Request: MappingTypes{
+			Types: []MappingAttributeType{
+				{Scope: ScopeIdentity, Name: "mac", Type: AttributeTypeKeyword},
+			},
+		},
+		Error: "types: (0: (scope: must be a valid value.).).",
+	}, {
+		Name: "error, declared twice",
+
+		Request: MappingTypes{
+			Types: []MappingAttributeType{
+				{Scope: ScopeInventory, Name: "last_boot", Type: AttributeTypeDate},
+				{Scope: ScopeInventory, Name: "last_boot", Type: AttributeTypeKeyword},
+			},
+		},
+		Error: "types: the type of the attribute inventory/last_boot is declared twice.",
+	}}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.Request.Validate()
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
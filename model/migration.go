@@ -0,0 +1,58 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+const (
+	// MigrationKindIndexTemplate is the kind of the changes to the index
+	// templates
+	MigrationKindIndexTemplate = "index_template"
+	// MigrationKindIndex is the kind of the changes to the indices
+	MigrationKindIndex = "index"
+	// MigrationKindAlias is the kind of the changes to the aliases
+	MigrationKindAlias = "alias"
+	// MigrationKindMapping is the kind of the changes to the mappings of
+	// the existing indices
+	MigrationKindMapping = "mapping"
+	// MigrationKindPolicy is the kind of the changes to the ISM policies
+	MigrationKindPolicy = "ism_policy"
+	// MigrationKindAttribute is the kind of the changes to the indexed
+	// values of the tenants' attributes
+	MigrationKindAttribute = "attribute"
+
+	// MigrationActionCreate creates the missing resource
+	MigrationActionCreate = "create"
+	// MigrationActionUpdate updates the resource in place
+	MigrationActionUpdate = "update"
+	// MigrationActionReindex copies the documents of the index in a new
+	// index, replacing it
+	MigrationActionReindex = "reindex"
+	// MigrationActionRemap converts the indexed values of the attribute to
+	// the type it is declared to be indexed as
+	MigrationActionRemap = "remap"
+)
+
+// MigrationChange is a change the migrations would apply to the store
+type MigrationChange struct {
+	// Cluster is the name of the cluster of the change, if the store
+	// spans several clusters
+	Cluster string `json:"cluster,omitempty"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	// Details describes the differences with the current resource
+	Details []string `json:"details,omitempty"`
+	// Breaking is true if the change rewrites the existing data
+	Breaking bool `json:"breaking"`
+}
@@ -17,6 +17,7 @@ package model
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 )
 
 const (
@@ -24,8 +25,22 @@ const (
 	defaultPerPage = 20
 
 	attrDeviceID = "id"
+
+	// highlightFragmentSize is the size, in characters, of the fragments
+	// of the attribute values returned as highlights
+	highlightFragmentSize = 100
+	// highlightFragments is the maximum number of fragments returned for
+	// every matching attribute
+	highlightFragments = 3
+
+	// queryStringReservedChars are the characters with a special meaning
+	// in the query_string syntax
+	queryStringReservedChars = `+-=&|><!(){}[]^"~*?:\/`
 )
 
+// freeTextFields are the fields the free-text queries search in
+var freeTextFields = []string{attrDeviceID, "*_" + typeStr}
+
 type ArrayOpts int
 
 const (
@@ -168,6 +183,9 @@ func (q *query) MarshalJSON() ([]byte, error) {
 
 // filter factory
 func getFilterPart(pred FilterPredicate) (QueryPart, error) {
+	if IsConnectivityAttribute(pred.Scope, pred.Attribute) {
+		return NewFilterConnectivity(pred)
+	}
 	switch pred.Type {
 	case "$eq":
 		return NewFilterEq(pred)
@@ -185,10 +203,19 @@ func getFilterPart(pred FilterPredicate) (QueryPart, error) {
 		return NewFilterIn(pred)
 	case "$nin":
 		return NewFilterNin(pred)
-	case "$exists":
+	case "$exists", "$nexists":
 		return NewFilterExists(pred)
-	case "$regex":
+	case FilterTypeRegex:
 		return NewFilterRegex(pred)
+	case FilterTypeWildcard:
+		return NewFilterWildcard(pred)
+	case FilterTypeVersionGt, FilterTypeVersionGte,
+		FilterTypeVersionLt, FilterTypeVersionLte:
+		return NewFilterVersionRange(pred)
+	case FilterTypeGeoDistance:
+		return NewFilterGeoDistance(pred)
+	case FilterTypeGeoBoundingBox:
+		return NewFilterGeoBoundingBox(pred)
 	}
 
 	return nil, errors.New("filter type not supported")
@@ -237,7 +264,9 @@ func NewFilter(fp FilterPredicate, arrOpts ArrayOpts, typeOpts Type) (*filter, e
 
 	// some special attributes translate to non-scoped, predefined fields
 	attr := parseSpecialAttr(fp.Attribute)
-	if attr == "" {
+	if attr == "" && fp.IndexedAs != TypeAny {
+		attr = ToAttr(fp.Scope, fp.Attribute, fp.IndexedAs)
+	} else if attr == "" {
 		attr = ToAttr(fp.Scope, fp.Attribute, typ)
 	}
 
@@ -302,6 +331,9 @@ func NewFilterRegex(fp FilterPredicate) (*filterRegex, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := validateRegex(f.val.(string)); err != nil {
+		return nil, err
+	}
 	return &filterRegex{
 		filter: f,
 	}, nil
@@ -315,6 +347,31 @@ func (f *filterRegex) AddTo(q Query) Query {
 	})
 }
 
+type filterWildcard struct {
+	*filter
+}
+
+func NewFilterWildcard(fp FilterPredicate) (*filterWildcard, error) {
+	f, err := NewFilter(fp, ArrNotAllowed, TypeStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWildcard(f.val.(string)); err != nil {
+		return nil, err
+	}
+	return &filterWildcard{
+		filter: f,
+	}, nil
+}
+
+func (f *filterWildcard) AddTo(q Query) Query {
+	return q.Must(M{
+		"wildcard": M{
+			f.attr: f.val,
+		},
+	})
+}
+
 type filterIn struct {
 	*filter
 }
@@ -377,7 +434,15 @@ func NewFilterExists(fp FilterPredicate) (*filterExists, error) {
 }
 
 func (f *filterExists) AddTo(q Query) Query {
-	exists := f.fp.Value.(bool)
+	// {"$nexists": true} is a shorthand for {"$exists": false}
+	exists := f.fp.Value.(bool) != (f.fp.Type == "$nexists")
+	if f.fp.IndexedAs != TypeAny {
+		field := ToAttr(f.fp.Scope, f.fp.Attribute, f.fp.IndexedAs)
+		if exists {
+			return q.Must(M{"exists": M{"field": field}})
+		}
+		return q.MustNot(M{"exists": M{"field": field}})
+	}
 	astr := ToAttr(f.fp.Scope, f.fp.Attribute, TypeStr)
 	anum := ToAttr(f.fp.Scope, f.fp.Attribute, TypeNum)
 	abool := ToAttr(f.fp.Scope, f.fp.Attribute, TypeBool)
@@ -435,6 +500,20 @@ type sort struct {
 	attrNum  string
 	attrBool string
 	order    string
+	missing  string
+	// attrTyped is the field of the type the attribute is declared to be
+	// indexed as, sorted on alone
+	attrTyped     string
+	unmappedTyped string
+}
+
+// unmappedTypes are the types the sorts assume for the fields of the
+// declared types of the attributes, missing from the index
+var unmappedTypes = map[Type]string{
+	TypeStr:  "keyword",
+	TypeNum:  "double",
+	TypeBool: "boolean",
+	TypeDate: "date",
 }
 
 func NewSort(sc SortCriteria) *sort {
@@ -442,29 +521,51 @@ func NewSort(sc SortCriteria) *sort {
 	if order == "" {
 		order = SortOrderAsc
 	}
-	return &sort{
+	s := &sort{
 		attrStr:  ToAttr(sc.Scope, sc.Attribute, TypeStr),
 		attrNum:  ToAttr(sc.Scope, sc.Attribute, TypeNum),
 		attrBool: ToAttr(sc.Scope, sc.Attribute, TypeBool),
 		order:    order,
+		missing:  sc.Missing,
+	}
+	if sc.IndexedAs != TypeAny {
+		s.attrTyped = ToAttr(sc.Scope, sc.Attribute, sc.IndexedAs)
+		s.unmappedTyped = unmappedTypes[sc.IndexedAs]
 	}
+	return s
 }
 
 func (s *sort) AddTo(q Query) Query {
+	if s.attrTyped != "" {
+		typedSort := M{
+			"order":         s.order,
+			"unmapped_type": s.unmappedTyped,
+		}
+		if s.missing != "" {
+			typedSort["missing"] = "_" + s.missing
+		}
+		return q.WithSort(M{s.attrTyped: typedSort})
+	}
+	strSort := M{
+		"order":         s.order,
+		"unmapped_type": "keyword",
+	}
+	numSort := M{
+		"order":         s.order,
+		"unmapped_type": "double",
+	}
+	if s.missing != "" {
+		strSort["missing"] = "_" + s.missing
+		numSort["missing"] = "_" + s.missing
+	}
 	q = q.
 		WithSort(
 			M{
-				s.attrStr: M{
-					"order":         s.order,
-					"unmapped_type": "keyword",
-				},
+				s.attrStr: strSort,
 			},
 		).WithSort(
 		M{
-			s.attrNum: M{
-				"order":         s.order,
-				"unmapped_type": "double",
-			},
+			s.attrNum: numSort,
 		},
 	)
 
@@ -485,6 +586,10 @@ func (s *sel) AddTo(q Query) Query {
 	fields := []string{}
 
 	for _, a := range s.attrs {
+		if a.IndexedAs != TypeAny {
+			fields = append(fields, ToAttr(a.Scope, a.Attribute, a.IndexedAs))
+			continue
+		}
 		fields = append(fields,
 			ToAttr(a.Scope, a.Attribute, TypeStr),
 			ToAttr(a.Scope, a.Attribute, TypeNum),
@@ -531,6 +636,18 @@ func BuildQuery(params SearchParams) (Query, error) {
 		query = fpart.AddTo(query)
 	}
 
+	for _, g := range params.FilterGroups {
+		fpart, err := NewFilterGroup(g)
+		if err != nil {
+			return nil, err
+		}
+		query = fpart.AddTo(query)
+	}
+
+	if len(params.InGroups) > 0 {
+		query = NewGroupsFilter(params.InGroups).AddTo(query)
+	}
+
 	if len(params.Groups) > 0 {
 		fp := FilterPredicate{
 			Scope:     ScopeSystem,
@@ -562,9 +679,80 @@ func BuildQuery(params SearchParams) (Query, error) {
 		query = devs.AddTo(query)
 	}
 
+	if text := NewFreeText(params.Text); text != nil {
+		query = text.AddTo(query)
+		if params.Highlight {
+			query = text.Highlight(query)
+		}
+	}
+
 	return query, nil
 }
 
+type freeText struct {
+	words []string
+}
+
+// NewFreeText returns the query part matching the devices with, for all
+// the words of the text, a string attribute starting with the word, or nil
+// if text is blank
+func NewFreeText(text string) *freeText {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+	return &freeText{
+		words: words,
+	}
+}
+
+func (f *freeText) AddTo(q Query) Query {
+	terms := make([]string, len(f.words))
+	for i, word := range f.words {
+		// prefix queries only: the leading wildcards would scan all the
+		// terms of the string fields
+		terms[i] = escapeQueryString(word) + "*"
+	}
+	return q.Must(M{
+		"query_string": M{
+			"query":            strings.Join(terms, " "),
+			"fields":           freeTextFields,
+			"default_operator": "AND",
+			"lenient":          true,
+		},
+	})
+}
+
+// Highlight adds to the query the highlighting of the fragments of the
+// attributes matching the free-text query
+func (f *freeText) Highlight(q Query) Query {
+	fields := make(M, len(freeTextFields))
+	for _, field := range freeTextFields {
+		fields[field] = M{}
+	}
+	return q.With(map[string]interface{}{
+		"highlight": M{
+			"fields":              fields,
+			"fragment_size":       highlightFragmentSize,
+			"number_of_fragments": highlightFragments,
+			"require_field_match": true,
+		},
+	})
+}
+
+// escapeQueryString escapes the reserved characters of the query_string
+// syntax, so that the text is matched literally
+func escapeQueryString(text string) string {
+	var b strings.Builder
+	for _, c := range text {
+		if strings.ContainsRune(queryStringReservedChars, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
 // parseSpecialAttr detects attributes like `Device ID`, which
 // translate to plain flat fields (e.g. 'id'), and not
 // scoped attributes
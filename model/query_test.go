@@ -242,6 +242,24 @@ func TestBuildQuery(t *testing.T) {
 				},
 			}),
 		},
+		"filter $nexists": {
+			inParams: SearchParams{
+				Filters: []FilterPredicate{
+					{
+						Scope:     ScopeInventory,
+						Attribute: "serial",
+						Type:      "$nexists",
+						Value:     true,
+					},
+				},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().
+				MustNot(M{"exists": M{"field": "inventory_serial_str"}}).
+				MustNot(M{"exists": M{"field": "inventory_serial_num"}}).
+				MustNot(M{"exists": M{"field": "inventory_serial_bool"}}),
+		},
 		"filter $regex": {
 			inParams: SearchParams{
 				Filters: []FilterPredicate{
@@ -261,6 +279,25 @@ func TestBuildQuery(t *testing.T) {
 				},
 			}),
 		},
+		"filter $wildcard": {
+			inParams: SearchParams{
+				Filters: []FilterPredicate{
+					{
+						Scope:     ScopeInventory,
+						Attribute: "hostname",
+						Type:      "$wildcard",
+						Value:     "edge-gw-*-prod",
+					},
+				},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().Must(M{
+				"wildcard": M{
+					"inventory_hostname_str": "edge-gw-*-prod",
+				},
+			}),
+		},
 		"sort": {
 			inParams: SearchParams{
 				Sort: []SortCriteria{
@@ -284,6 +321,48 @@ func TestBuildQuery(t *testing.T) {
 				},
 			}),
 		},
+		"multiple sort, missing values placement": {
+			inParams: SearchParams{
+				Sort: []SortCriteria{
+					{
+						Scope:     ScopeInventory,
+						Attribute: "region",
+						Order:     SortOrderAsc,
+						Missing:   SortMissingFirst,
+					},
+					{
+						Scope:     ScopeSystem,
+						Attribute: AttrNameUpdatedAt,
+						Order:     SortOrderDesc,
+					},
+				},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().WithSort(M{
+				"inventory_region_str": M{
+					"order":         "asc",
+					"unmapped_type": "keyword",
+					"missing":       "_first",
+				},
+			}).WithSort(M{
+				"inventory_region_num": M{
+					"order":         "asc",
+					"unmapped_type": "double",
+					"missing":       "_first",
+				},
+			}).WithSort(M{
+				"system_updated_ts_str": M{
+					"order":         "desc",
+					"unmapped_type": "keyword",
+				},
+			}).WithSort(M{
+				"system_updated_ts_num": M{
+					"order":         "desc",
+					"unmapped_type": "double",
+				},
+			}),
+		},
 		"attributes": {
 			inParams: SearchParams{
 				Attributes: []SelectAttribute{
@@ -317,6 +396,123 @@ func TestBuildQuery(t *testing.T) {
 				},
 			}),
 		},
+		"free text": {
+			inParams: SearchParams{
+				Text:    " 00:11:22 raspberry(pi) ",
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().Must(M{
+				"query_string": M{
+					"query":            `00\:11\:22* raspberry\(pi\)*`,
+					"fields":           []string{"id", "*_str"},
+					"default_operator": "AND",
+					"lenient":          true,
+				},
+			}),
+		},
+		"free text, highlight": {
+			inParams: SearchParams{
+				Text:      "raspberry",
+				Highlight: true,
+				Page:      defaultPage,
+				PerPage:   defaultPerPage,
+			},
+			outQuery: NewQuery().Must(M{
+				"query_string": M{
+					"query":            `raspberry*`,
+					"fields":           []string{"id", "*_str"},
+					"default_operator": "AND",
+					"lenient":          true,
+				},
+			}).With(map[string]interface{}{
+				"highlight": M{
+					"fields": M{
+						"id":    M{},
+						"*_str": M{},
+					},
+					"fragment_size":       100,
+					"number_of_fragments": 3,
+					"require_field_match": true,
+				},
+			}),
+		},
+		"highlight without free text": {
+			inParams: SearchParams{
+				Highlight: true,
+				Page:      defaultPage,
+				PerPage:   defaultPerPage,
+			},
+			outQuery: NewQuery(),
+		},
+		"free text, blank": {
+			inParams: SearchParams{
+				Text:    "  ",
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery(),
+		},
+		"filter $gt, indexed as date": {
+			inParams: SearchParams{
+				Filters: []FilterPredicate{
+					{
+						Scope:     ScopeInventory,
+						Attribute: "attribute1",
+						Type:      "$gt",
+						Value:     "2023-03-06T07:00:00Z",
+						IndexedAs: TypeDate,
+					},
+				},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().Must(M{
+				"range": M{
+					"inventory_attribute1_date": M{
+						"gt": "2023-03-06T07:00:00Z",
+					},
+				},
+			}),
+		},
+		"filter $exists, indexed as number": {
+			inParams: SearchParams{
+				Filters: []FilterPredicate{
+					{
+						Scope:     ScopeInventory,
+						Attribute: "attribute1",
+						Type:      "$exists",
+						Value:     true,
+						IndexedAs: TypeNum,
+					},
+				},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().Must(M{
+				"exists": M{"field": "inventory_attribute1_num"},
+			}),
+		},
+		"sort, indexed as date": {
+			inParams: SearchParams{
+				Sort: []SortCriteria{
+					{
+						Scope:     ScopeInventory,
+						Attribute: "attribute1",
+						Order:     SortOrderDesc,
+						IndexedAs: TypeDate,
+					},
+				},
+				Page:    defaultPage,
+				PerPage: defaultPerPage,
+			},
+			outQuery: NewQuery().WithSort(M{
+				"inventory_attribute1_date": M{
+					"order":         "desc",
+					"unmapped_type": "date",
+				},
+			}),
+		},
 	}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
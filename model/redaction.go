@@ -0,0 +1,131 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maskedClearSuffixLength is the number of trailing characters kept in
+	// clear by the masked values longer than minPartialMaskLength, the
+	// shorter ones being masked entirely
+	maskedClearSuffixLength = 4
+	minPartialMaskLength    = 8
+)
+
+// AttributesRedaction hashes or masks the values of the selected attributes
+// of the devices before indexing them, e.g. the IP addresses or the serial
+// numbers. The hashed values are keyed SHA-256 digests, which the equality
+// filters can still search for, once hashed the same way; the masked values
+// keep at most their last characters in clear and are not searchable. The
+// system attributes and the status of the devices are never redacted.
+type AttributesRedaction struct {
+	Hashed  []AttributeSelector
+	Masked  []AttributeSelector
+	HashKey []byte
+}
+
+// Hashes returns true if the values of the attribute are hashed
+func (r *AttributesRedaction) Hashes(scope, name string) bool {
+	return r != nil && redacts(r.Hashed, scope, name) && !r.Masks(scope, name)
+}
+
+// Masks returns true if the values of the attribute are masked
+func (r *AttributesRedaction) Masks(scope, name string) bool {
+	return r != nil && redacts(r.Masked, scope, name)
+}
+
+func redacts(selectors []AttributeSelector, scope, name string) bool {
+	if scope == ScopeSystem || (scope == ScopeIdentity && name == AttrNameStatus) {
+		return false
+	}
+	for _, selector := range selectors {
+		if selector.Match(scope, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns the value of the attribute to index: hashed, masked or
+// as is, depending on the attribute
+func (r *AttributesRedaction) Redact(scope, name string, value interface{}) interface{} {
+	if r.Masks(scope, name) {
+		return redactValue(value, maskValue)
+	} else if r.Hashes(scope, name) {
+		return r.HashValue(value)
+	}
+	return value
+}
+
+// HashValue returns the hashed form of the value, or of each of its
+// elements if it is an array
+func (r *AttributesRedaction) HashValue(value interface{}) interface{} {
+	return redactValue(value, func(s string) string {
+		mac := hmac.New(sha256.New, r.HashKey)
+		_, _ = mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))
+	})
+}
+
+// HashFilter returns a copy of the filter whose value is hashed if it is
+// an equality filter on a hashed attribute, for it to match the indexed
+// values; the filter itself is left as is
+func (r *AttributesRedaction) HashFilter(filter FilterPredicate) FilterPredicate {
+	if !r.Hashes(filter.Scope, filter.Attribute) {
+		return filter
+	}
+	switch filter.Type {
+	case "$eq", "$ne", "$in", "$nin":
+		filter.Value = r.HashValue(filter.Value)
+	}
+	return filter
+}
+
+func redactValue(value interface{}, redact func(string) string) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return redact(v)
+	case []string:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = redact(v[i])
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = redactValue(v[i], redact)
+		}
+		return res
+	}
+	return redact(fmt.Sprint(value))
+}
+
+func maskValue(s string) string {
+	runes := []rune(s)
+	if len(runes) <= minPartialMaskLength {
+		return strings.Repeat("*", len(runes))
+	}
+	n := len(runes) - maskedClearSuffixLength
+	return strings.Repeat("*", n) + string(runes[n:])
+}
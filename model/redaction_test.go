@@ -0,0 +1,106 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributesRedaction(t *testing.T) {
+	t.Parallel()
+
+	redaction := &AttributesRedaction{
+		Hashed: []AttributeSelector{
+			{Scope: ScopeIdentity, Name: "*"},
+			{Scope: ScopeSystem, Name: AttrNameGroup},
+		},
+		Masked: []AttributeSelector{
+			{Scope: ScopeInventory, Name: "ipv4_*"},
+			{Scope: ScopeIdentity, Name: "mac"},
+		},
+		HashKey: []byte("secret"),
+	}
+
+	// hex-encoded HMAC-SHA256
+	value := redaction.Redact(ScopeIdentity, "serial", "SN-1234")
+	assert.Len(t, value, 64)
+	assert.Equal(t, redaction.HashValue("SN-1234"), value)
+	assert.NotEqual(t, (&AttributesRedaction{HashKey: []byte("other")}).
+		HashValue("SN-1234"), value)
+
+	assert.Equal(t,
+		[]interface{}{redaction.HashValue("1"), redaction.HashValue("b")},
+		redaction.Redact(ScopeIdentity, "serial", []interface{}{float64(1), "b"}))
+
+	assert.Equal(t, "********2.10",
+		redaction.Redact(ScopeInventory, "ipv4_wlan0", "192.168.2.10"))
+	assert.Equal(t, []interface{}{"****", "********"},
+		redaction.Redact(ScopeInventory, "ipv4_eth0", []interface{}{"10.0", "10.0.0.1"}))
+	// masked takes precedence over hashed
+	assert.Equal(t, "**********3:44",
+		redaction.Redact(ScopeIdentity, "mac", "00:11:22:33:44"))
+
+	assert.Equal(t, "1.2.3",
+		redaction.Redact(ScopeInventory, "artifact_name", "1.2.3"))
+	assert.Equal(t, "production",
+		redaction.Redact(ScopeSystem, AttrNameGroup, "production"))
+	assert.Equal(t, "accepted",
+		redaction.Redact(ScopeIdentity, AttrNameStatus, "accepted"))
+
+	var none *AttributesRedaction
+	assert.Equal(t, "SN-1234", none.Redact(ScopeIdentity, "serial", "SN-1234"))
+}
+
+func TestAttributesRedactionHashFilter(t *testing.T) {
+	t.Parallel()
+
+	redaction := &AttributesRedaction{
+		Hashed: []AttributeSelector{
+			{Scope: ScopeIdentity, Name: "serial"},
+		},
+		HashKey: []byte("secret"),
+	}
+
+	filter := FilterPredicate{
+		Scope:     ScopeIdentity,
+		Attribute: "serial",
+		Type:      "$in",
+		Value:     []interface{}{"SN-1", "SN-2"},
+	}
+	hashed := redaction.HashFilter(filter)
+	assert.Equal(t,
+		[]interface{}{redaction.HashValue("SN-1"), redaction.HashValue("SN-2")},
+		hashed.Value)
+	// the filter itself is left as is
+	assert.Equal(t, []interface{}{"SN-1", "SN-2"}, filter.Value)
+
+	filter = FilterPredicate{
+		Scope:     ScopeIdentity,
+		Attribute: "serial",
+		Type:      "$exists",
+		Value:     true,
+	}
+	assert.Equal(t, true, redaction.HashFilter(filter).Value)
+
+	filter = FilterPredicate{
+		Scope:     ScopeIdentity,
+		Attribute: "mac",
+		Type:      "$eq",
+		Value:     "00:11:22:33:44:55",
+	}
+	assert.Equal(t, "00:11:22:33:44:55", redaction.HashFilter(filter).Value)
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const maxReindexDevices = 1000
+
+// ReindexDevicesRequest is the request to reindex a list of devices
+type ReindexDevicesRequest struct {
+	DeviceIDs []string `json:"device_ids"`
+}
+
+func (r ReindexDevicesRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.DeviceIDs, validation.Required,
+			validation.Length(1, maxReindexDevices),
+			validation.Each(validation.Required)),
+	)
+}
+
+const (
+	ReindexStatusInProgress = "in_progress"
+	ReindexStatusCompleted  = "completed"
+)
+
+// ReindexState is the progress of the rebuild of the tenant's devices
+// index, saved to resume it if interrupted
+type ReindexState struct {
+	TenantID string `bson:"_id" json:"tenant_id"`
+	// Index is the index the devices are rebuilt in
+	Index string `bson:"index" json:"index"`
+	// Page is the last page of inventory devices indexed, and LastDeviceID
+	// the ID of the last device of the page
+	Page         int    `bson:"page" json:"page"`
+	LastDeviceID string `bson:"last_device_id,omitempty" json:"last_device_id,omitempty"`
+	Processed    int    `bson:"processed" json:"processed"`
+	Status       string `bson:"status" json:"status"`
+	// StartedTs is the time the rebuild started at: the devices updated
+	// since then are indexed again once the index is swapped
+	StartedTs   time.Time  `bson:"started_ts" json:"started_ts"`
+	UpdatedTs   time.Time  `bson:"updated_ts" json:"updated_ts"`
+	CompletedTs *time.Time `bson:"completed_ts,omitempty" json:"completed_ts,omitempty"`
+	// SyncedTs is the last time the tenant's index was known to be in
+	// sync with inventory: catching up indexes the devices updated since
+	SyncedTs *time.Time `bson:"synced_ts,omitempty" json:"synced_ts,omitempty"`
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReindexDevicesRequestValidate(t *testing.T) {
+	tooManyDevices := make([]string, maxReindexDevices+1)
+	for i := range tooManyDevices {
+		tooManyDevices[i] = "device"
+	}
+
+	testCases := map[string]struct {
+		req ReindexDevicesRequest
+		err error
+	}{
+		"ok": {
+			req: ReindexDevicesRequest{
+				DeviceIDs: []string{"1", "2"},
+			},
+		},
+		"ko, empty": {
+			err: errors.New("device_ids: cannot be blank."),
+		},
+		"ko, empty device ID": {
+			req: ReindexDevicesRequest{
+				DeviceIDs: []string{"1", ""},
+			},
+			err: errors.New("device_ids: (1: cannot be blank.)."),
+		},
+		"ko, too many devices": {
+			req: ReindexDevicesRequest{
+				DeviceIDs: tooManyDevices,
+			},
+			err: errors.New("device_ids: the length must be between 1 and 1000."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.req.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
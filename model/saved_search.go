@@ -0,0 +1,112 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+const maxSavedSearchNameLength = 256
+
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatJSON = "json"
+)
+
+var validReportFormats = []interface{}{ReportFormatCSV, ReportFormatJSON}
+
+// SavedSearch is a named device search definition, persisted per tenant
+type SavedSearch struct {
+	ID         string             `json:"id" bson:"_id"`
+	TenantID   string             `json:"-" bson:"tenant_id"`
+	Name       string             `json:"name" bson:"name"`
+	Filters    []FilterPredicate  `json:"filters" bson:"filters"`
+	Sort       []SortCriteria     `json:"sort" bson:"sort"`
+	Attributes []SelectAttribute  `json:"attributes" bson:"attributes"`
+	Report     *SavedSearchReport `json:"report,omitempty" bson:"report,omitempty"`
+	CreatedTs  time.Time          `json:"created_ts" bson:"created_ts"`
+	UpdatedTs  time.Time          `json:"updated_ts" bson:"updated_ts"`
+}
+
+// SavedSearchReport schedules the periodic generation of a report
+// with the results of a saved search
+type SavedSearchReport struct {
+	// Schedule is a standard (5 fields) cron expression, in UTC
+	Schedule string `json:"schedule" bson:"schedule"`
+	// Format is the format of the report: csv or json
+	Format string `json:"format" bson:"format"`
+	// LastRunTs is the time the report was last generated
+	LastRunTs *time.Time `json:"last_run_ts,omitempty" bson:"last_run_ts,omitempty"`
+}
+
+func (r SavedSearchReport) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Schedule, validation.Required,
+			validation.By(checkCronSchedule)),
+		validation.Field(&r.Format, validation.Required,
+			validation.In(validReportFormats...)),
+	)
+}
+
+func checkCronSchedule(value interface{}) error {
+	schedule, _ := value.(string)
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return errors.New("must be a valid cron expression")
+	}
+	return nil
+}
+
+// NextRun returns the time the report is due next: the first scheduled time
+// after the last run or, if it never run, after the last update of the search
+func (s SavedSearch) NextRun() (time.Time, error) {
+	if s.Report == nil {
+		return time.Time{}, errors.New("the saved search has no report")
+	}
+	schedule, err := cron.ParseStandard(s.Report.Schedule)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to parse the report schedule")
+	}
+	from := s.UpdatedTs
+	if s.Report.LastRunTs != nil {
+		from = *s.Report.LastRunTs
+	}
+	return schedule.Next(from.UTC()), nil
+}
+
+func (s SavedSearch) Validate() error {
+	err := validation.ValidateStruct(&s,
+		validation.Field(&s.Name, validation.Required,
+			validation.Length(1, maxSavedSearchNameLength)),
+		validation.Field(&s.Report),
+	)
+	if err != nil {
+		return err
+	}
+	return s.SearchParams().Validate()
+}
+
+// SearchParams returns the search parameters of the saved search
+func (s SavedSearch) SearchParams() *SearchParams {
+	return &SearchParams{
+		Filters:    s.Filters,
+		Sort:       s.Sort,
+		Attributes: s.Attributes,
+		TenantID:   s.TenantID,
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedSearchValidate(t *testing.T) {
+	testCases := map[string]struct {
+		search SavedSearch
+		err    error
+	}{
+		"ok": {
+			search: SavedSearch{
+				Name: "production",
+				Filters: []FilterPredicate{{
+					Scope:     ScopeSystem,
+					Attribute: AttrNameGroup,
+					Type:      "$eq",
+					Value:     "production",
+				}},
+			},
+		},
+		"ok, with report": {
+			search: SavedSearch{
+				Name: "production",
+				Report: &SavedSearchReport{
+					Schedule: "0 6 * * 1",
+					Format:   ReportFormatCSV,
+				},
+			},
+		},
+		"ko, missing name": {
+			search: SavedSearch{},
+			err:    errors.New("name: cannot be blank."),
+		},
+		"ko, invalid filter": {
+			search: SavedSearch{
+				Name: "production",
+				Filters: []FilterPredicate{{
+					Scope:     ScopeSystem,
+					Attribute: AttrNameGroup,
+					Type:      "$nope",
+					Value:     "production",
+				}},
+			},
+			err: errors.New("type: must be a valid value."),
+		},
+		"ko, invalid report": {
+			search: SavedSearch{
+				Name: "production",
+				Report: &SavedSearchReport{
+					Schedule: "every monday",
+					Format:   "xlsx",
+				},
+			},
+			err: errors.New("report: (format: must be a valid value; " +
+				"schedule: must be a valid cron expression.)."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.search.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSavedSearchNextRun(t *testing.T) {
+	updated := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC)
+	lastRun := time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		search SavedSearch
+		next   time.Time
+		err    error
+	}{
+		"ok, never run": {
+			search: SavedSearch{
+				UpdatedTs: updated,
+				Report: &SavedSearchReport{
+					Schedule: "0 6 * * 1",
+					Format:   ReportFormatCSV,
+				},
+			},
+			next: time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC),
+		},
+		"ok, after the last run": {
+			search: SavedSearch{
+				UpdatedTs: updated,
+				Report: &SavedSearchReport{
+					Schedule:  "0 6 * * 1",
+					Format:    ReportFormatCSV,
+					LastRunTs: &lastRun,
+				},
+			},
+			next: time.Date(2023, 3, 13, 6, 0, 0, 0, time.UTC),
+		},
+		"ko, no report": {
+			search: SavedSearch{},
+			err:    errors.New("the saved search has no report"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			next, err := tc.search.NextRun()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.next, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	"github.com/mendersoftware/reporting/client/inventory"
+)
+
+const (
+	SearchJobStatusPending = "pending"
+	SearchJobStatusRunning = "running"
+	SearchJobStatusDone    = "done"
+	SearchJobStatusFailed  = "failed"
+)
+
+// SearchJob is a search of the devices executed in the background, whose
+// results are polled for until the job expires
+type SearchJob struct {
+	ID       string `json:"id" bson:"_id"`
+	TenantID string `json:"-" bson:"tenant_id"`
+	Status   string `json:"status" bson:"status"`
+	// Error is the error the search failed with
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+	// Devices are the devices found, once the search is done
+	Devices []inventory.Device `json:"devices,omitempty" bson:"devices,omitempty"`
+	// Total is the total number of devices matching the search, once the
+	// search is done
+	Total      *int       `json:"total,omitempty" bson:"total,omitempty"`
+	CreatedTs  time.Time  `json:"created_ts" bson:"created_ts"`
+	FinishedTs *time.Time `json:"finished_ts,omitempty" bson:"finished_ts,omitempty"`
+	// ExpireTs is the time the job and its results are deleted at
+	ExpireTs time.Time `json:"expire_ts" bson:"expire_ts"`
+}
+
+// Finished returns true if the search is done or failed
+func (job *SearchJob) Finished() bool {
+	return job.Status == SearchJobStatusDone || job.Status == SearchJobStatusFailed
+}
@@ -21,7 +21,17 @@ const (
 	ServiceDeployments = "deployments"
 )
 
+// DeviceStatusDecommissioned is the status of the deviceauth status change
+// events of the decommissioned devices
+const DeviceStatusDecommissioned = "decommissioned"
+
 const (
 	ActionReindex           = "reindex"
 	ActionReindexDeployment = "reindex_deployment"
+	// ActionUpdateDeviceStatus is the action of the deviceauth status
+	// change events
+	ActionUpdateDeviceStatus = "update_device_status"
+	// ActionDecommissionDevice deletes all the data of a decommissioned
+	// device, including its deployments history
+	ActionDecommissionDevice = "decommission_device"
 )
@@ -0,0 +1,261 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	defaultSoftwareLimit          = 20
+	maxSoftwareLimit              = 500
+	defaultSoftwareDevicesPerPage = 20
+	maxSoftwareDevicesPerPage     = 500
+
+	// softwareVersionSuffix is the suffix of the names of the inventory
+	// attributes holding the version of a software
+	softwareVersionSuffix = ".version"
+)
+
+const (
+	FieldNameSoftware         = "software"
+	FieldNameSoftwareVersions = "software_versions"
+	FieldNameGroup            = "group"
+
+	// SoftwareVersionSeparator separates the name of the software from
+	// its version in the software versions of the devices
+	SoftwareVersionSeparator = "="
+
+	// SoftwareAggregation is the name of the aggregation of the software
+	SoftwareAggregation = "software"
+)
+
+// softwarePrefixes are the prefixes of the names of the inventory
+// attributes describing the software installed on the devices: the root
+// filesystem images, e.g. rootfs-image.version, and the applications,
+// e.g. app.nginx.version
+var softwarePrefixes = []string{"rootfs-image.", "app."}
+
+// DeviceSoftware is the normalized software inventory of a device, parsed
+// from its inventory attributes
+type DeviceSoftware struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Group    string `json:"group,omitempty"`
+	// Software are the names of the software installed on the device
+	Software []string `json:"software"`
+	// SoftwareVersions are the installed versions, as name=version
+	SoftwareVersions []string   `json:"software_versions"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+}
+
+func NewDeviceSoftware(tenantID, id string) *DeviceSoftware {
+	return &DeviceSoftware{
+		ID:               id,
+		TenantID:         tenantID,
+		Software:         []string{},
+		SoftwareVersions: []string{},
+	}
+}
+
+// AddAttribute adds the versions of the software held by the inventory
+// attribute, named <software>.version; the other attributes are ignored
+func (s *DeviceSoftware) AddAttribute(name string, value interface{}) {
+	if !IsSoftwareAttribute(name) {
+		return
+	}
+	software := strings.TrimSuffix(name, softwareVersionSuffix)
+	var versions []string
+	switch v := value.(type) {
+	case string:
+		versions = []string{v}
+	case []string:
+		versions = v
+	case []interface{}:
+		for _, item := range v {
+			if version, ok := item.(string); ok {
+				versions = append(versions, version)
+			}
+		}
+	}
+	for _, version := range versions {
+		if version == "" {
+			continue
+		}
+		if !contains(s.Software, software) {
+			s.Software = append(s.Software, software)
+		}
+		version = software + SoftwareVersionSeparator + version
+		if !contains(s.SoftwareVersions, version) {
+			s.SoftwareVersions = append(s.SoftwareVersions, version)
+		}
+	}
+}
+
+// IsSoftwareAttribute returns true if the inventory attribute holds the
+// version of a software
+func IsSoftwareAttribute(name string) bool {
+	if !strings.HasSuffix(name, softwareVersionSuffix) {
+		return false
+	}
+	for _, prefix := range softwarePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SoftwareParams are the parameters of the aggregation of the software
+// installed on the devices: the names of the software, or the versions of
+// the named software
+type SoftwareParams struct {
+	Name     string   `json:"name" form:"name"`
+	Limit    int      `json:"limit" form:"limit"`
+	Groups   []string `json:"-" form:"-"`
+	TenantID string   `json:"-" form:"-"`
+}
+
+func (p SoftwareParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(maxSoftwareLimit)),
+	)
+}
+
+// SoftwareSummary is a software, or a version of it if Version is set,
+// with the number of devices it is installed on
+type SoftwareSummary struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Count   int    `json:"count"`
+}
+
+// SoftwareDevicesParams are the parameters of the search of the devices
+// a version of a software is installed on
+type SoftwareDevicesParams struct {
+	Name     string   `json:"name" form:"name"`
+	Version  string   `json:"version" form:"version"`
+	Page     int      `json:"page" form:"page"`
+	PerPage  int      `json:"per_page" form:"per_page"`
+	Groups   []string `json:"-" form:"-"`
+	TenantID string   `json:"-" form:"-"`
+}
+
+func (p SoftwareDevicesParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required),
+		validation.Field(&p.Page, validation.Min(0)),
+		validation.Field(&p.PerPage, validation.Min(0),
+			validation.Max(maxSoftwareDevicesPerPage)),
+	)
+}
+
+// Normalize sets the default values of the pagination parameters
+func (p *SoftwareDevicesParams) Normalize() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.PerPage <= 0 {
+		p.PerPage = defaultSoftwareDevicesPerPage
+	}
+}
+
+func buildSoftwareFilter(tenantID string, groups []string) Query {
+	query := NewQuery()
+	if tenantID != "" {
+		query = query.Must(M{
+			"term": M{
+				FieldNameTenantID: tenantID,
+			},
+		})
+	}
+	if len(groups) > 0 {
+		query = query.Must(M{
+			"terms": M{
+				FieldNameGroup: groups,
+			},
+		})
+	}
+	return query
+}
+
+// BuildSoftwareQuery returns the query aggregating the names of the
+// software installed on the devices, or the versions of the named software
+func BuildSoftwareQuery(params SoftwareParams) Query {
+	query := buildSoftwareFilter(params.TenantID, params.Groups)
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSoftwareLimit
+	}
+	terms := M{
+		"field": FieldNameSoftware,
+		"size":  limit,
+	}
+	if params.Name != "" {
+		query = query.Must(M{
+			"term": M{
+				FieldNameSoftware: params.Name,
+			},
+		})
+		terms = M{
+			"field": FieldNameSoftwareVersions,
+			"size":  limit,
+			"include": escapeRegexp(params.Name+SoftwareVersionSeparator) +
+				".*",
+		}
+	}
+	return query.WithSize(0).With(map[string]interface{}{
+		"aggs": M{
+			SoftwareAggregation: M{
+				"terms": terms,
+			},
+		},
+	})
+}
+
+// BuildSoftwareDevicesQuery returns the query searching the devices the
+// named software, in the given version if set, is installed on
+func BuildSoftwareDevicesQuery(params SoftwareDevicesParams) Query {
+	query := buildSoftwareFilter(params.TenantID, params.Groups)
+	if params.Version != "" {
+		query = query.Must(M{
+			"term": M{
+				FieldNameSoftwareVersions: params.Name +
+					SoftwareVersionSeparator + params.Version,
+			},
+		})
+	} else {
+		query = query.Must(M{
+			"term": M{
+				FieldNameSoftware: params.Name,
+			},
+		})
+	}
+	return query.
+		WithSort(M{FieldNameID: M{"order": SortOrderAsc}}).
+		WithPage(params.Page, params.PerPage)
+}
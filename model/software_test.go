@@ -0,0 +1,217 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceSoftwareAddAttribute(t *testing.T) {
+	t.Parallel()
+
+	software := NewDeviceSoftware("tenant", "device")
+	software.AddAttribute("rootfs-image.version", "release-1")
+	software.AddAttribute("rootfs-image.docker.version", []interface{}{"20.10", "", 1})
+	software.AddAttribute("app.nginx.version", []string{"1.23", "1.24"})
+	software.AddAttribute("app.nginx.version", "1.23")
+	software.AddAttribute("app.empty.version", "")
+	software.AddAttribute("artifact_name", "release-1")
+	software.AddAttribute("rootfs-image.checksum", "abc")
+	software.AddAttribute("kernel.version", "5.10")
+
+	assert.Equal(t, &DeviceSoftware{
+		ID:       "device",
+		TenantID: "tenant",
+		Software: []string{"rootfs-image", "rootfs-image.docker", "app.nginx"},
+		SoftwareVersions: []string{
+			"rootfs-image=release-1",
+			"rootfs-image.docker=20.10",
+			"app.nginx=1.23",
+			"app.nginx=1.24",
+		},
+	}, software)
+}
+
+func TestSoftwareParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		params SoftwareParams
+		err    error
+	}{
+		"ok": {
+			params: SoftwareParams{Name: "rootfs-image", Limit: 10},
+		},
+		"ok, no name": {
+			params: SoftwareParams{},
+		},
+		"ko, limit too high": {
+			params: SoftwareParams{Limit: maxSoftwareLimit + 1},
+			err:    errors.New("limit: must be no greater than 500."),
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSoftwareDevicesParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		params SoftwareDevicesParams
+		err    error
+	}{
+		"ok": {
+			params: SoftwareDevicesParams{Name: "rootfs-image", Version: "v1"},
+		},
+		"ko, missing name": {
+			params: SoftwareDevicesParams{Version: "v1"},
+			err:    errors.New("name: cannot be blank."),
+		},
+		"ko, per page too high": {
+			params: SoftwareDevicesParams{
+				Name:    "rootfs-image",
+				PerPage: maxSoftwareDevicesPerPage + 1,
+			},
+			err: errors.New("per_page: must be no greater than 500."),
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildSoftwareQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		params SoftwareParams
+		query  Query
+	}{
+		"ok, software names": {
+			params: SoftwareParams{},
+			query: NewQuery().WithSize(0).With(map[string]interface{}{
+				"aggs": M{
+					SoftwareAggregation: M{
+						"terms": M{
+							"field": FieldNameSoftware,
+							"size":  defaultSoftwareLimit,
+						},
+					},
+				},
+			}),
+		},
+		"ok, software versions with tenant and groups": {
+			params: SoftwareParams{
+				Name:     "app.nginx",
+				Limit:    5,
+				Groups:   []string{"prod"},
+				TenantID: "tenant",
+			},
+			query: NewQuery().Must(M{
+				"term": M{
+					FieldNameTenantID: "tenant",
+				},
+			}).Must(M{
+				"terms": M{
+					FieldNameGroup: []string{"prod"},
+				},
+			}).Must(M{
+				"term": M{
+					FieldNameSoftware: "app.nginx",
+				},
+			}).WithSize(0).With(map[string]interface{}{
+				"aggs": M{
+					SoftwareAggregation: M{
+						"terms": M{
+							"field":   FieldNameSoftwareVersions,
+							"size":    5,
+							"include": `app\.nginx=.*`,
+						},
+					},
+				},
+			}),
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.query, BuildSoftwareQuery(tc.params))
+		})
+	}
+}
+
+func TestBuildSoftwareDevicesQuery(t *testing.T) {
+	t.Parallel()
+
+	params := SoftwareDevicesParams{
+		Name:     "rootfs-image",
+		Version:  "release-1",
+		TenantID: "tenant",
+	}
+	params.Normalize()
+	query := BuildSoftwareDevicesQuery(params)
+	assert.Equal(t, NewQuery().Must(M{
+		"term": M{
+			FieldNameTenantID: "tenant",
+		},
+	}).Must(M{
+		"term": M{
+			FieldNameSoftwareVersions: "rootfs-image=release-1",
+		},
+	}).WithSort(M{
+		FieldNameID: M{"order": SortOrderAsc},
+	}).WithPage(1, defaultSoftwareDevicesPerPage), query)
+
+	params.Version = ""
+	query = BuildSoftwareDevicesQuery(params)
+	assert.Equal(t, NewQuery().Must(M{
+		"term": M{
+			FieldNameTenantID: "tenant",
+		},
+	}).Must(M{
+		"term": M{
+			FieldNameSoftware: "rootfs-image",
+		},
+	}).WithSort(M{
+		FieldNameID: M{"order": SortOrderAsc},
+	}).WithPage(1, defaultSoftwareDevicesPerPage), query)
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	defaultSuggestionsLimit = 10
+	maxSuggestionsLimit     = 100
+	maxSuggestionPrefix     = 256
+
+	// regexpReservedChars are the characters with a special meaning in
+	// the OpenSearch regular expressions
+	regexpReservedChars = `.?+*|{}[]()"\#@&<>~^$`
+)
+
+// SuggestionsAggregation is the name of the aggregation of the suggestions
+const SuggestionsAggregation = "suggestions"
+
+// SuggestParams are the parameters of the attribute values suggestions
+type SuggestParams struct {
+	Scope     string   `json:"scope" form:"scope"`
+	Attribute string   `json:"attribute" form:"attribute"`
+	Prefix    string   `json:"prefix" form:"prefix"`
+	Limit     int      `json:"limit" form:"limit"`
+	Groups    []string `json:"-" form:"-"`
+	// VisibleAttributes are the attributes visible to the role of the
+	// user, if restricted
+	VisibleAttributes *AttributesFilter `json:"-" form:"-"`
+	TenantID          string            `json:"-" form:"-"`
+}
+
+func (sp SuggestParams) Validate() error {
+	return validation.ValidateStruct(&sp,
+		validation.Field(&sp.Scope, validation.Required),
+		validation.Field(&sp.Attribute, validation.Required),
+		validation.Field(&sp.Prefix, validation.Length(0, maxSuggestionPrefix)),
+		validation.Field(&sp.Limit, validation.Min(0), validation.Max(maxSuggestionsLimit)),
+	)
+}
+
+// AttributeSuggestion is a value of an attribute, with the number of
+// devices having it
+type AttributeSuggestion struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// BuildSuggestQuery returns the query for the most common values of the
+// (mapped) attribute which start with the prefix
+func BuildSuggestQuery(params SuggestParams) (Query, error) {
+	query, err := BuildQuery(SearchParams{
+		Groups: params.Groups,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if params.TenantID != "" {
+		query = query.Must(M{
+			"term": M{
+				FieldNameTenantID: params.TenantID,
+			},
+		})
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSuggestionsLimit
+	}
+	terms := M{
+		"field": ToAttr(params.Scope, params.Attribute, TypeStr),
+		"size":  limit,
+	}
+	if params.Prefix != "" {
+		terms["include"] = escapeRegexp(params.Prefix) + ".*"
+	}
+	return query.WithSize(0).With(map[string]interface{}{
+		"aggs": M{
+			SuggestionsAggregation: M{
+				"terms": terms,
+			},
+		},
+	}), nil
+}
+
+// escapeRegexp escapes the reserved characters of the regular expressions,
+// so that the text is matched literally
+func escapeRegexp(text string) string {
+	var b strings.Builder
+	for _, c := range text {
+		if strings.ContainsRune(regexpReservedChars, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestParamsValidate(t *testing.T) {
+	testCases := map[string]struct {
+		params SuggestParams
+		err    error
+	}{
+		"ok": {
+			params: SuggestParams{
+				Scope:     ScopeInventory,
+				Attribute: "hostname",
+				Prefix:    "rasp",
+				Limit:     20,
+			},
+		},
+		"ko, missing attribute": {
+			params: SuggestParams{
+				Scope: ScopeInventory,
+			},
+			err: errors.New("attribute: cannot be blank."),
+		},
+		"ko, limit too high": {
+			params: SuggestParams{
+				Scope:     ScopeInventory,
+				Attribute: "hostname",
+				Limit:     maxSuggestionsLimit + 1,
+			},
+			err: errors.New("limit: must be no greater than 100."),
+		},
+		"ko, prefix too long": {
+			params: SuggestParams{
+				Scope:     ScopeInventory,
+				Attribute: "hostname",
+				Prefix:    strings.Repeat("a", maxSuggestionPrefix+1),
+			},
+			err: errors.New("prefix: the length must be no more than 256."),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildSuggestQuery(t *testing.T) {
+	testCases := map[string]struct {
+		params SuggestParams
+		query  Query
+	}{
+		"ok, no prefix": {
+			params: SuggestParams{
+				Scope:     ScopeInventory,
+				Attribute: "hostname",
+			},
+			query: NewQuery().WithPage(0, 0).With(map[string]interface{}{
+				"aggs": M{
+					SuggestionsAggregation: M{
+						"terms": M{
+							"field": "inventory_hostname_str",
+							"size":  defaultSuggestionsLimit,
+						},
+					},
+				},
+			}),
+		},
+		"ok, prefix with tenant and groups": {
+			params: SuggestParams{
+				Scope:     ScopeIdentity,
+				Attribute: "mac",
+				Prefix:    "00:11.2",
+				Limit:     5,
+				Groups:    []string{"prod"},
+				TenantID:  "tenant",
+			},
+			query: NewQuery().Must(M{
+				"terms": M{
+					"system_group_str": []string{"prod"},
+				},
+			}).Must(M{
+				"term": M{
+					FieldNameTenantID: "tenant",
+				},
+			}).WithPage(0, 0).With(map[string]interface{}{
+				"aggs": M{
+					SuggestionsAggregation: M{
+						"terms": M{
+							"field":   "identity_mac_str",
+							"size":    5,
+							"include": `00:11\.2.*`,
+						},
+					},
+				},
+			}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			query, err := BuildSuggestQuery(tc.params)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.query, query)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	// the aggregations of the fleet summary
+	SummaryStatusAggregation                 = "status"
+	SummaryLatestDeploymentStatusAggregation = "latest_deployment_status"
+
+	maxOfflineHours = 24 * 365
+	// summaryAggregationsLimit is the maximum number of values counted
+	// for each of the statuses of the fleet summary
+	summaryAggregationsLimit = 20
+)
+
+// FleetSummaryParams are the parameters of the fleet summary
+type FleetSummaryParams struct {
+	// OfflineHours is the number of hours after which a device which did
+	// not check in is counted as offline; it defaults to the connectivity
+	// window
+	OfflineHours int      `json:"offline_hours" form:"offline_hours"`
+	Groups       []string `json:"-" form:"-"`
+	TenantID     string   `json:"-" form:"-"`
+}
+
+func (p FleetSummaryParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.OfflineHours, validation.Min(0), validation.Max(maxOfflineHours)),
+	)
+}
+
+// AggregateParams returns the parameters of the aggregation of the devices
+// counting them by status and by latest deployment status in a single query
+func (p FleetSummaryParams) AggregateParams() AggregateParams {
+	return AggregateParams{
+		Aggregations: []AggregationTerm{
+			{
+				Name:      SummaryStatusAggregation,
+				Scope:     ScopeIdentity,
+				Attribute: AttrNameStatus,
+				Limit:     summaryAggregationsLimit,
+			},
+			{
+				Name:      SummaryLatestDeploymentStatusAggregation,
+				Scope:     ScopeSystem,
+				Attribute: AttrNameLatestDeploymentStatus,
+				Limit:     summaryAggregationsLimit,
+			},
+		},
+		Groups:   p.Groups,
+		TenantID: p.TenantID,
+	}
+}
+
+// OfflineWindow returns the time after which a device which did not check
+// in is counted as offline
+func (p FleetSummaryParams) OfflineWindow() time.Duration {
+	if p.OfflineHours <= 0 {
+		return connectivityWindow
+	}
+	return time.Duration(p.OfflineHours) * time.Hour
+}
+
+// BuildOfflineDevicesQuery returns the query matching the offline devices
+// of the tenant
+func BuildOfflineDevicesQuery(params FleetSummaryParams) (Query, error) {
+	query, err := BuildQuery(SearchParams{
+		Groups:   params.Groups,
+		TenantID: params.TenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if params.TenantID != "" {
+		query = query.Must(M{
+			"term": M{
+				FieldNameTenantID: params.TenantID,
+			},
+		})
+	}
+	return newFilterConnectivity(false, params.OfflineWindow()).AddTo(query), nil
+}
+
+// FleetSummary are the counters of the devices of the fleet
+type FleetSummary struct {
+	Total int `json:"total"`
+	// ByStatus are the number of devices by authentication status
+	ByStatus map[string]int `json:"by_status"`
+	// ByLatestDeploymentStatus are the number of devices by status of
+	// their latest deployment
+	ByLatestDeploymentStatus map[string]int `json:"by_latest_deployment_status"`
+	// Offline is the number of devices which did not check in within the
+	// last offline hours
+	Offline int `json:"offline"`
+}
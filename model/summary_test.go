@@ -0,0 +1,75 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFleetSummaryParams(t *testing.T) {
+	t.Parallel()
+
+	params := FleetSummaryParams{
+		Groups:   []string{"prod"},
+		TenantID: "tenant",
+	}
+	assert.NoError(t, params.Validate())
+	assert.Equal(t, defaultConnectivityWindow, params.OfflineWindow())
+
+	aggregateParams := params.AggregateParams()
+	assert.Equal(t, []string{"prod"}, aggregateParams.Groups)
+	assert.Equal(t, "tenant", aggregateParams.TenantID)
+	assert.NoError(t, aggregateParams.Validate())
+	if assert.Len(t, aggregateParams.Aggregations, 2) {
+		assert.Equal(t, SummaryStatusAggregation, aggregateParams.Aggregations[0].Name)
+		assert.Equal(t, SummaryLatestDeploymentStatusAggregation,
+			aggregateParams.Aggregations[1].Name)
+	}
+
+	params.OfflineHours = 2
+	assert.Equal(t, 2*time.Hour, params.OfflineWindow())
+
+	params.OfflineHours = maxOfflineHours + 1
+	assert.EqualError(t, params.Validate(), "offline_hours: must be no greater than 8760.")
+}
+
+func TestBuildOfflineDevicesQuery(t *testing.T) {
+	t.Parallel()
+
+	query, err := BuildOfflineDevicesQuery(FleetSummaryParams{
+		OfflineHours: 48,
+		TenantID:     "tenant",
+	})
+	assert.NoError(t, err)
+	expected, _ := BuildQuery(SearchParams{TenantID: "tenant"})
+	assert.Equal(t, expected.Must(M{
+		"term": M{
+			FieldNameTenantID: "tenant",
+		},
+	}).Must(M{
+		"bool": M{
+			"must_not": M{
+				"range": M{
+					FieldNameCheckInTime: M{
+						"gte": "now-172800s",
+					},
+				},
+			},
+		},
+	}), query)
+}
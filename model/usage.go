@@ -0,0 +1,60 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// UsageMonthLayout is the layout of the months the queries of the tenants
+// are metered by
+const UsageMonthLayout = "2006-01"
+
+var errUsageMonth = errors.New("must be a month, e.g. 2023-03")
+
+// TenantUsage is the usage of the reporting service by a tenant: the
+// documents indexed and the space they take, and the queries of the month
+type TenantUsage struct {
+	TenantID  string         `json:"tenant_id"`
+	Documents UsageDocuments `json:"documents"`
+	// IndexBytes is the size of the tenant's documents in the primary
+	// shards; the share of the shared indices is estimated from the
+	// tenant's share of their documents
+	IndexBytes int64  `json:"index_bytes"`
+	Month      string `json:"month"`
+	// Queries is the number of queries of the tenant in the month
+	Queries int64 `json:"queries"`
+}
+
+// UsageDocuments are the numbers of documents of the tenant by index
+type UsageDocuments struct {
+	Devices     int64 `json:"devices"`
+	Deployments int64 `json:"deployments"`
+	Software    int64 `json:"software"`
+}
+
+// UsageMonth returns the month of the time, as the queries are metered by
+func UsageMonth(t time.Time) string {
+	return t.UTC().Format(UsageMonthLayout)
+}
+
+// ValidateUsageMonth validates the month of the usage
+func ValidateUsageMonth(month string) error {
+	if _, err := time.Parse(UsageMonthLayout, month); err != nil {
+		return errUsageMonth
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache caches the results of the queries of the tenants
+type Cache interface {
+	// Get returns the cached value of the key for the tenant, if any
+	Get(ctx context.Context, tenantID, key string) ([]byte, bool)
+	// Generation returns the generation of the tenant's values, read before
+	// computing a value to cache
+	Generation(ctx context.Context, tenantID string) uint64
+	// Set caches the value of the key for the tenant, computed at the given
+	// generation: the value is not cached if the tenant's values were
+	// invalidated since
+	Set(ctx context.Context, tenantID, key string, generation uint64, value []byte)
+	// Invalidate drops all the cached values of the tenant, or of all the
+	// tenants for AllTenants
+	Invalidate(ctx context.Context, tenantID string) error
+}
+
+// AllTenants is the tenant ID invalidating the cached values of all the
+// tenants, e.g. when the documents of all the tenants are deleted
+const AllTenants = "*"
+
+type lruEntry struct {
+	tenantID   string
+	key        string
+	generation uint64
+	value      []byte
+	expiresAt  time.Time
+}
+
+// lru is an in-memory cache evicting the least recently used entries; the
+// tenants' entries are invalidated by bumping their generation, or the
+// epoch for all the tenants, the stale entries are then evicted as any other
+type lru struct {
+	size int
+	ttl  time.Duration
+
+	mutex       sync.Mutex
+	entries     map[string]*list.Element
+	order       *list.List
+	generations map[string]uint64
+	epoch       uint64
+	now         func() time.Time
+}
+
+// NewLRU returns an in-memory cache of up to size entries, each cached for
+// the given TTL
+func NewLRU(size int, ttl time.Duration) Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &lru{
+		size:        size,
+		ttl:         ttl,
+		entries:     make(map[string]*list.Element, size),
+		order:       list.New(),
+		generations: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+func lruKey(tenantID, key string) string {
+	return tenantID + "/" + key
+}
+
+func (c *lru) Get(_ context.Context, tenantID, key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[lruKey(tenantID, key)]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if entry.generation != c.generation(tenantID) || !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *lru) Generation(_ context.Context, tenantID string) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generation(tenantID)
+}
+
+// generation returns the generation of the tenant's values: both the
+// tenant's generation and the epoch only increase, so their sum changes
+// with any of them
+func (c *lru) generation(tenantID string) uint64 {
+	return c.generations[tenantID] + c.epoch
+}
+
+func (c *lru) Set(_ context.Context, tenantID, key string, generation uint64, value []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation != c.generation(tenantID) {
+		// invalidated while the value was computed: the value may be stale
+		return
+	}
+	entry := &lruEntry{
+		tenantID:   tenantID,
+		key:        lruKey(tenantID, key),
+		generation: generation,
+		value:      value,
+		expiresAt:  c.now().Add(c.ttl),
+	}
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lru) Invalidate(_ context.Context, tenantID string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if tenantID == AllTenants {
+		c.epoch++
+	} else {
+		c.generations[tenantID]++
+	}
+	return nil
+}
+
+func (c *lru) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
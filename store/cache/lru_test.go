@@ -0,0 +1,93 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(2, time.Minute).(*lru)
+	c.now = func() time.Time { return now }
+
+	_, ok := c.Get(ctx, "tenant1", "key1")
+	assert.False(t, ok)
+
+	c.Set(ctx, "tenant1", "key1", 0, []byte("value1"))
+	c.Set(ctx, "tenant2", "key1", 0, []byte("value2"))
+	value, ok := c.Get(ctx, "tenant1", "key1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value1"), value)
+	value, ok = c.Get(ctx, "tenant2", "key1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value2"), value)
+
+	// the least recently used entry is evicted
+	c.Set(ctx, "tenant1", "key2", 0, []byte("value3"))
+	_, ok = c.Get(ctx, "tenant1", "key1")
+	assert.False(t, ok)
+	_, ok = c.Get(ctx, "tenant2", "key1")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.order.Len())
+
+	// invalidating a tenant does not affect the other tenants
+	generation := c.Generation(ctx, "tenant1")
+	err := c.Invalidate(ctx, "tenant1")
+	assert.NoError(t, err)
+	_, ok = c.Get(ctx, "tenant1", "key2")
+	assert.False(t, ok)
+	_, ok = c.Get(ctx, "tenant2", "key1")
+	assert.True(t, ok)
+
+	// the values computed before the invalidation are not cached
+	c.Set(ctx, "tenant1", "key2", generation, []byte("value4"))
+	_, ok = c.Get(ctx, "tenant1", "key2")
+	assert.False(t, ok)
+
+	// the values computed after the invalidation are cached
+	c.Set(ctx, "tenant1", "key2", c.Generation(ctx, "tenant1"), []byte("value4"))
+	value, ok = c.Get(ctx, "tenant1", "key2")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value4"), value)
+
+	// invalidating all the tenants affects every tenant
+	generation = c.Generation(ctx, "tenant2")
+	err = c.Invalidate(ctx, AllTenants)
+	assert.NoError(t, err)
+	_, ok = c.Get(ctx, "tenant1", "key2")
+	assert.False(t, ok)
+	_, ok = c.Get(ctx, "tenant2", "key1")
+	assert.False(t, ok)
+	assert.NotEqual(t, generation, c.Generation(ctx, "tenant2"))
+	c.Set(ctx, "tenant1", "key2", c.Generation(ctx, "tenant1"), []byte("value4"))
+	c.Set(ctx, "tenant2", "key1", c.Generation(ctx, "tenant2"), []byte("value2"))
+
+	// the entries expire after the TTL
+	now = now.Add(time.Minute)
+	_, ok = c.Get(ctx, "tenant1", "key2")
+	assert.False(t, ok)
+	_, ok = c.Get(ctx, "tenant2", "key1")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.order.Len())
+	assert.Len(t, c.entries, 0)
+}
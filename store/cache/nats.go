@@ -0,0 +1,66 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package cache
+
+import (
+	"context"
+
+	"github.com/mendersoftware/reporting/client/nats"
+)
+
+// publisher is a cache which caches nothing, but publishes the
+// invalidations to the caches of the other processes
+type publisher struct {
+	nats    nats.Client
+	subject string
+}
+
+// NewInvalidationPublisher returns a cache which does not cache any value,
+// but publishes the invalidations on the given subject, for the processes
+// which write the documents, e.g. the indexer
+func NewInvalidationPublisher(client nats.Client, subject string) Cache {
+	return &publisher{
+		nats:    client,
+		subject: subject,
+	}
+}
+
+func (p *publisher) Get(context.Context, string, string) ([]byte, bool) {
+	return nil, false
+}
+
+func (p *publisher) Generation(context.Context, string) uint64 {
+	return 0
+}
+
+func (p *publisher) Set(context.Context, string, string, uint64, []byte) {
+}
+
+func (p *publisher) Invalidate(_ context.Context, tenantID string) error {
+	return p.nats.Publish(p.subject, []byte(tenantID))
+}
+
+// SubscribeInvalidations invalidates the tenants' values in the cache when
+// their invalidations are published on the given subject
+func SubscribeInvalidations(
+	ctx context.Context,
+	client nats.Client,
+	subject string,
+	cache Cache,
+) (nats.UnsubscribeFunc, error) {
+	return client.Subscribe(subject, func(data []byte) {
+		_ = cache.Invalidate(ctx, string(data))
+	})
+}
@@ -0,0 +1,261 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	opAggregateDevices     = "devices/aggregate"
+	opAggregateDeployments = "deployments/aggregate"
+	opSearchDevices        = "devices/search"
+	opCountDevices         = "devices/count"
+	opSearchDeployments    = "deployments/search"
+)
+
+// cachedStore serves the repeated identical queries of the tenants from
+// the cache, and invalidates the tenants' cached values when it writes
+// their documents
+type cachedStore struct {
+	store.Store
+	cache Cache
+}
+
+// NewStore wraps the store caching the results of the searches and the
+// aggregations, keyed on the tenant and the query
+func NewStore(s store.Store, cache Cache) store.Store {
+	return &cachedStore{
+		Store: s,
+		cache: cache,
+	}
+}
+
+type queryFunc func(ctx context.Context, query model.Query) (model.M, error)
+
+func (s *cachedStore) cached(
+	ctx context.Context,
+	op string,
+	query model.Query,
+	fn queryFunc,
+) (model.M, error) {
+	var tenantID string
+	if id := identity.FromContext(ctx); id != nil {
+		tenantID = id.Tenant
+	}
+	// the maps are marshaled with sorted keys: equal queries have the
+	// same JSON, no matter how they were built
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fn(ctx, query)
+	}
+	hash := sha256.Sum256(body)
+	key := op + "/" + hex.EncodeToString(hash[:])
+
+	if value, ok := s.cache.Get(ctx, tenantID, key); ok {
+		var res model.M
+		if err := json.Unmarshal(value, &res); err == nil {
+			return res, nil
+		}
+	}
+	// the generation is read before running the query: the result is not
+	// cached if the tenant's documents are written in the meantime
+	generation := s.cache.Generation(ctx, tenantID)
+	res, err := fn(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if value, err := json.Marshal(res); err == nil {
+		s.cache.Set(ctx, tenantID, key, generation, value)
+	}
+	return res, nil
+}
+
+func (s *cachedStore) invalidate(ctx context.Context, tenantIDs map[string]struct{}) {
+	for tenantID := range tenantIDs {
+		if err := s.cache.Invalidate(ctx, tenantID); err != nil {
+			log.FromContext(ctx).Errorf(
+				"failed to invalidate the cache of the tenant %q: %s", tenantID, err)
+		}
+	}
+}
+
+func (s *cachedStore) AggregateDevices(ctx context.Context, query model.Query) (model.M, error) {
+	return s.cached(ctx, opAggregateDevices, query, s.Store.AggregateDevices)
+}
+
+func (s *cachedStore) AggregateDeployments(
+	ctx context.Context,
+	query model.Query,
+) (model.M, error) {
+	return s.cached(ctx, opAggregateDeployments, query, s.Store.AggregateDeployments)
+}
+
+func (s *cachedStore) SearchDevices(ctx context.Context, query model.Query) (model.M, error) {
+	return s.cached(ctx, opSearchDevices, query, s.Store.SearchDevices)
+}
+
+func (s *cachedStore) CountDevices(ctx context.Context, query model.Query) (int, error) {
+	res, err := s.cached(ctx, opCountDevices, query,
+		func(ctx context.Context, query model.Query) (model.M, error) {
+			count, err := s.Store.CountDevices(ctx, query)
+			// float64, as the cached values are decoded from JSON
+			return model.M{"count": float64(count)}, err
+		})
+	if err != nil {
+		return 0, err
+	}
+	count, _ := res["count"].(float64)
+	return int(count), nil
+}
+
+func (s *cachedStore) SearchDeployments(ctx context.Context, query model.Query) (model.M, error) {
+	return s.cached(ctx, opSearchDeployments, query, s.Store.SearchDeployments)
+}
+
+// BulkIndexDevices indexes the devices, invalidating the cache of their
+// tenants; the cache is invalidated even if indexing fails, as some of
+// the documents may have been written anyway
+func (s *cachedStore) BulkIndexDevices(
+	ctx context.Context,
+	devices, removedDevices []*model.Device,
+) error {
+	err := s.Store.BulkIndexDevices(ctx, devices, removedDevices)
+	tenantIDs := make(map[string]struct{})
+	for _, device := range devices {
+		tenantIDs[device.GetTenantID()] = struct{}{}
+	}
+	for _, device := range removedDevices {
+		tenantIDs[device.GetTenantID()] = struct{}{}
+	}
+	s.invalidate(ctx, tenantIDs)
+	return err
+}
+
+// UpdateDevices updates the devices, invalidating the cache of their tenants
+func (s *cachedStore) UpdateDevices(ctx context.Context, devices []*model.Device) error {
+	err := s.Store.UpdateDevices(ctx, devices)
+	tenantIDs := make(map[string]struct{})
+	for _, device := range devices {
+		tenantIDs[device.GetTenantID()] = struct{}{}
+	}
+	s.invalidate(ctx, tenantIDs)
+	return err
+}
+
+// ReplaceDevicesTags replaces the tags of the devices, invalidating the
+// cache of their tenants
+func (s *cachedStore) ReplaceDevicesTags(ctx context.Context, devices []*model.Device) error {
+	err := s.Store.ReplaceDevicesTags(ctx, devices)
+	tenantIDs := make(map[string]struct{})
+	for _, device := range devices {
+		tenantIDs[device.GetTenantID()] = struct{}{}
+	}
+	s.invalidate(ctx, tenantIDs)
+	return err
+}
+
+// DeleteDevicesData deletes the devices' data, e.g. when purging the
+// decommissioned devices, invalidating the cache of their tenant
+func (s *cachedStore) DeleteDevicesData(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	err := s.Store.DeleteDevicesData(ctx, tenantID, deviceIDs)
+	s.invalidate(ctx, map[string]struct{}{tenantID: {}})
+	return err
+}
+
+// DeleteTenantData deletes the tenant's data, invalidating its cache
+func (s *cachedStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	err := s.Store.DeleteTenantData(ctx, tenantID)
+	s.invalidate(ctx, map[string]struct{}{tenantID: {}})
+	return err
+}
+
+// BulkIndexDeployments indexes the deployments, invalidating the cache of
+// their tenants
+func (s *cachedStore) BulkIndexDeployments(
+	ctx context.Context,
+	deployments []*model.Deployment,
+) error {
+	err := s.Store.BulkIndexDeployments(ctx, deployments)
+	tenantIDs := make(map[string]struct{})
+	for _, deployment := range deployments {
+		tenantIDs[deployment.TenantID] = struct{}{}
+	}
+	s.invalidate(ctx, tenantIDs)
+	return err
+}
+
+// BulkIndexSoftware indexes the software inventory of the devices,
+// invalidating the cache of their tenants
+func (s *cachedStore) BulkIndexSoftware(
+	ctx context.Context,
+	software []*model.DeviceSoftware,
+	removedDevices []*model.Device,
+) error {
+	err := s.Store.BulkIndexSoftware(ctx, software, removedDevices)
+	tenantIDs := make(map[string]struct{})
+	for _, item := range software {
+		tenantIDs[item.TenantID] = struct{}{}
+	}
+	for _, device := range removedDevices {
+		tenantIDs[device.GetTenantID()] = struct{}{}
+	}
+	s.invalidate(ctx, tenantIDs)
+	return err
+}
+
+// DeleteDeploymentsBefore deletes the old deployments of all the tenants,
+// invalidating the cache of all of them
+func (s *cachedStore) DeleteDeploymentsBefore(ctx context.Context, cutoff time.Time) error {
+	err := s.Store.DeleteDeploymentsBefore(ctx, cutoff)
+	s.invalidate(ctx, map[string]struct{}{AllTenants: {}})
+	return err
+}
+
+// RemapDevicesAttribute remaps the attribute of the tenant's devices,
+// invalidating the tenant's cache
+func (s *cachedStore) RemapDevicesAttribute(
+	ctx context.Context,
+	tenantID, scope, attribute string,
+	typ model.Type,
+) error {
+	err := s.Store.RemapDevicesAttribute(ctx, tenantID, scope, attribute, typ)
+	s.invalidate(ctx, map[string]struct{}{tenantID: {}})
+	return err
+}
+
+// SwapDevicesIndex replaces the tenant's devices index, invalidating the
+// tenant's cache
+func (s *cachedStore) SwapDevicesIndex(ctx context.Context, tid, index string) error {
+	err := s.Store.SwapDevicesIndex(ctx, tid, index)
+	if err == nil {
+		s.invalidate(ctx, map[string]struct{}{tid: {}})
+	}
+	return err
+}
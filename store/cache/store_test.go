@@ -0,0 +1,213 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/client/nats"
+	mnats "github.com/mendersoftware/reporting/client/nats/mocks"
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestCachedStoreSearch(t *testing.T) {
+	t.Parallel()
+
+	ctx := identity.WithContext(context.Background(), &identity.Identity{
+		Tenant: "tenant1",
+	})
+	otherCtx := identity.WithContext(context.Background(), &identity.Identity{
+		Tenant: "tenant2",
+	})
+	query := model.NewQuery().Must(model.M{
+		"term": model.M{model.FieldNameTenantID: "tenant1"},
+	})
+	res := model.M{"hits": map[string]interface{}{"total": float64(1)}}
+
+	st := new(mstore.Store)
+	defer st.AssertExpectations(t)
+	st.On("SearchDevices", ctx, query).Return(res, nil).Once()
+	st.On("SearchDevices", otherCtx, query).Return(res, nil).Once()
+	st.On("AggregateDevices", ctx, query).Return(nil, errors.New("error")).Twice()
+	st.On("CountDevices", ctx, query).Return(42, nil).Once()
+
+	s := NewStore(st, NewLRU(10, time.Minute))
+
+	// the repeated identical queries hit the cache
+	for i := 0; i < 2; i++ {
+		actual, err := s.SearchDevices(ctx, query)
+		assert.NoError(t, err)
+		assert.Equal(t, res, actual)
+	}
+	// the cache is per tenant
+	actual, err := s.SearchDevices(otherCtx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, res, actual)
+
+	// the errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err := s.AggregateDevices(ctx, query)
+		assert.EqualError(t, err, "error")
+	}
+
+	// the counts are cached apart from the searches
+	for i := 0; i < 2; i++ {
+		count, err := s.CountDevices(ctx, query)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+	}
+}
+
+func TestCachedStoreInvalidation(t *testing.T) {
+	t.Parallel()
+
+	ctx := identity.WithContext(context.Background(), &identity.Identity{
+		Tenant: "tenant1",
+	})
+	query := model.NewQuery()
+	res := model.M{"hits": map[string]interface{}{}}
+	device := model.NewDevice("tenant1", "device1")
+	deployment := &model.Deployment{TenantID: "tenant1"}
+	software := model.NewDeviceSoftware("tenant1", "device1")
+	cutoff := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	st := new(mstore.Store)
+	defer st.AssertExpectations(t)
+	st.On("SearchDevices", ctx, query).Return(res, nil).Times(8)
+	st.On("BulkIndexDevices", ctx, []*model.Device{device}, []*model.Device(nil)).
+		Return(errors.New("error"))
+	st.On("BulkIndexDeployments", ctx, []*model.Deployment{deployment}).Return(nil)
+	st.On("SwapDevicesIndex", ctx, "tenant1", "devices-tenant1-000002").Return(nil)
+	st.On("DeleteDevicesData", ctx, "tenant1", []string{"device1"}).Return(nil)
+	st.On("DeleteTenantData", ctx, "tenant1").Return(nil)
+	st.On("BulkIndexSoftware", ctx, []*model.DeviceSoftware{software},
+		[]*model.Device(nil)).Return(nil)
+	st.On("DeleteDeploymentsBefore", ctx, cutoff).Return(nil)
+
+	s := NewStore(st, NewLRU(10, time.Minute))
+
+	_, err := s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+	// indexing invalidates the cache even if it fails
+	err = s.BulkIndexDevices(ctx, []*model.Device{device}, nil)
+	assert.EqualError(t, err, "error")
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+
+	err = s.BulkIndexDeployments(ctx, []*model.Deployment{deployment})
+	assert.NoError(t, err)
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+
+	err = s.SwapDevicesIndex(ctx, "tenant1", "devices-tenant1-000002")
+	assert.NoError(t, err)
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+
+	err = s.DeleteDevicesData(ctx, "tenant1", []string{"device1"})
+	assert.NoError(t, err)
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+
+	err = s.DeleteTenantData(ctx, "tenant1")
+	assert.NoError(t, err)
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+
+	err = s.BulkIndexSoftware(ctx, []*model.DeviceSoftware{software}, nil)
+	assert.NoError(t, err)
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+
+	// the retention of the deployments invalidates all the tenants
+	err = s.DeleteDeploymentsBefore(ctx, cutoff)
+	assert.NoError(t, err)
+	_, err = s.SearchDevices(ctx, query)
+	assert.NoError(t, err)
+}
+
+func TestCachedStoreInvalidatedDuringQuery(t *testing.T) {
+	t.Parallel()
+
+	ctx := identity.WithContext(context.Background(), &identity.Identity{
+		Tenant: "tenant1",
+	})
+	query := model.NewQuery()
+	res := model.M{"hits": map[string]interface{}{}}
+
+	cache := NewLRU(10, time.Minute)
+	st := new(mstore.Store)
+	defer st.AssertExpectations(t)
+	// the tenant's documents are written while the first query runs
+	st.On("SearchDevices", ctx, query).
+		Run(func(mock.Arguments) {
+			_ = cache.Invalidate(ctx, "tenant1")
+		}).
+		Return(res, nil).
+		Once()
+	st.On("SearchDevices", ctx, query).Return(res, nil).Once()
+
+	s := NewStore(st, cache)
+	for i := 0; i < 3; i++ {
+		actual, err := s.SearchDevices(ctx, query)
+		assert.NoError(t, err)
+		assert.Equal(t, res, actual)
+	}
+}
+
+func TestInvalidationPublisher(t *testing.T) {
+	t.Parallel()
+
+	const subject = "reporting.cache.invalidations"
+	ctx := context.Background()
+	lru := NewLRU(10, time.Minute)
+	lru.Set(ctx, "tenant1", "key", 0, []byte("value"))
+
+	var handler func([]byte)
+	client := new(mnats.Client)
+	defer client.AssertExpectations(t)
+	client.On("Subscribe", subject, mock.AnythingOfType("func([]uint8)")).
+		Run(func(args mock.Arguments) {
+			handler = args.Get(1).(func([]byte))
+		}).
+		Return(nats.UnsubscribeFunc(func() error { return nil }), nil)
+	client.On("Publish", subject, []byte("tenant1")).
+		Run(func(args mock.Arguments) {
+			handler(args.Get(1).([]byte))
+		}).
+		Return(nil)
+
+	_, err := SubscribeInvalidations(ctx, client, subject, lru)
+	assert.NoError(t, err)
+
+	publisher := NewInvalidationPublisher(client, subject)
+	publisher.Set(ctx, "tenant1", "key", 0, []byte("value"))
+	_, ok := publisher.Get(ctx, "tenant1", "key")
+	assert.False(t, ok)
+
+	err = publisher.Invalidate(ctx, "tenant1")
+	assert.NoError(t, err)
+	_, ok = lru.Get(ctx, "tenant1", "key")
+	assert.False(t, ok)
+}
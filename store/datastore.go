@@ -16,10 +16,30 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/mendersoftware/reporting/model"
 )
 
+var (
+	// ErrSavedSearchNotFound is returned when the saved search does not exist
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	// ErrSavedSearchNameConflict is returned when a saved search with the
+	// same name already exists for the tenant
+	ErrSavedSearchNameConflict = errors.New("a saved search with the same name already exists")
+	// ErrDeadLetterNotFound is returned when the dead letter does not exist
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrAlertNotFound is returned when the alert does not exist
+	ErrAlertNotFound = errors.New("alert not found")
+	// ErrAlertNameConflict is returned when an alert with the same name
+	// already exists for the tenant
+	ErrAlertNameConflict = errors.New("an alert with the same name already exists")
+	// ErrSearchJobNotFound is returned when the search job does not exist,
+	// or expired
+	ErrSearchJobNotFound = errors.New("search job not found")
+)
+
 // DataStore interface for DataStore services
 //
 //nolint:lll - skip line length check for interface declaration.
@@ -31,6 +51,84 @@ type DataStore interface {
 	Migrate(ctx context.Context, version string, automigrate bool) error
 	MigrateLatest(ctx context.Context) error
 	GetMapping(ctx context.Context, tenantID string) (*model.Mapping, error)
+	// GetTenantIDs returns the IDs of the tenants with a mapping, that is
+	// the tenants whose devices were indexed at least once
+	GetTenantIDs(ctx context.Context) ([]string, error)
 	UpdateAndGetMapping(ctx context.Context, tenantID string, inventory []string) (
 		*model.Mapping, error)
+	// EvictMappingAttributes removes the attributes from the tenant's
+	// mapping, leaving their slots empty for new attributes
+	EvictMappingAttributes(ctx context.Context, tenantID string, attributes []string) error
+	// SetMappingTypes replaces the types the tenant's attributes are
+	// declared to be indexed as, adding the attributes to remap to the
+	// pending remaps of the mapping
+	SetMappingTypes(ctx context.Context, tenantID string, types []model.MappingAttributeType,
+		remaps []string) error
+	// GetMappingsToRemap returns the mappings of all the tenants with
+	// pending remaps
+	GetMappingsToRemap(ctx context.Context) ([]model.Mapping, error)
+	// CompleteMappingRemap removes the attribute from the pending remaps
+	// of the tenant's mapping
+	CompleteMappingRemap(ctx context.Context, tenantID, attribute string) error
+	InsertSavedSearch(ctx context.Context, search *model.SavedSearch) error
+	GetSavedSearches(ctx context.Context, tenantID string) ([]model.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, tenantID, id string) (*model.SavedSearch, error)
+	UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error
+	DeleteSavedSearch(ctx context.Context, tenantID, id string) error
+	GetScheduledSavedSearches(ctx context.Context) ([]model.SavedSearch, error)
+	ClaimSavedSearchReport(ctx context.Context, search *model.SavedSearch, runTs time.Time) (
+		bool, error)
+	GetIndexingRules(ctx context.Context, tenantID string) (*model.IndexingRules, error)
+	SetIndexingRules(ctx context.Context, rules *model.IndexingRules) error
+	GetReindexState(ctx context.Context, tenantID string) (*model.ReindexState, error)
+	SaveReindexState(ctx context.Context, state *model.ReindexState) error
+	DeleteReindexState(ctx context.Context, tenantID string) error
+	InsertDeadLetter(ctx context.Context, letter *model.DeadLetter) error
+	GetDeadLetters(ctx context.Context, params model.DeadLettersParams) (
+		[]model.DeadLetter, int, error)
+	GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+	InsertAlert(ctx context.Context, alert *model.Alert) error
+	GetAlerts(ctx context.Context, tenantID string) ([]model.Alert, error)
+	GetAlert(ctx context.Context, tenantID, id string) (*model.Alert, error)
+	UpdateAlert(ctx context.Context, alert *model.Alert) error
+	DeleteAlert(ctx context.Context, tenantID, id string) error
+	// GetAllAlerts returns the alerts of all the tenants
+	GetAllAlerts(ctx context.Context) ([]model.Alert, error)
+	ClaimAlertEvaluation(ctx context.Context, alert *model.Alert, evaluatedTs time.Time) (
+		bool, error)
+	SetAlertState(ctx context.Context, alert *model.Alert, state *model.AlertState) error
+	InsertAuditLogEntry(ctx context.Context, entry *model.AuditLogEntry) error
+	GetAuditLogs(ctx context.Context, params model.AuditLogsParams) (
+		[]model.AuditLogEntry, int, error)
+	InsertSearchJob(ctx context.Context, job *model.SearchJob) error
+	GetSearchJob(ctx context.Context, tenantID, id string) (*model.SearchJob, error)
+	UpdateSearchJob(ctx context.Context, job *model.SearchJob) error
+	// InsertDecommissionedDevices records the decommissioned devices whose
+	// deployments history is purged later; the devices already recorded
+	// keep their purge time
+	InsertDecommissionedDevices(ctx context.Context, devices []model.DecommissionedDevice) error
+	// GetDecommissionedDevicesToPurge returns up to limit decommissioned
+	// devices of all the tenants to purge before the given time, the
+	// earliest first
+	GetDecommissionedDevicesToPurge(ctx context.Context, before time.Time, limit int) (
+		[]model.DecommissionedDevice, error)
+	// DeleteDecommissionedDevices deletes the records of the tenant's
+	// decommissioned devices, once purged
+	DeleteDecommissionedDevices(ctx context.Context, tenantID string, deviceIDs []string) error
+	// IncrementTenantQueries adds the numbers of queries of the tenants,
+	// by tenant ID, to their counts of the month
+	IncrementTenantQueries(ctx context.Context, month string, queries map[string]int64) error
+	// GetTenantQueries returns the number of queries of the tenant in the
+	// month
+	GetTenantQueries(ctx context.Context, tenantID, month string) (int64, error)
+	// IncrementAttributesUsage adds the uses of the attributes, by tenant
+	// ID, to their counts, keeping the latest time they were used at
+	IncrementAttributesUsage(ctx context.Context,
+		usage map[string][]model.AttributeUsage) error
+	// GetAttributesUsage returns the usage of the tenant's attributes,
+	// sorted by scope and name
+	GetAttributesUsage(ctx context.Context, tenantID string) ([]model.AttributeUsage, error)
+	// DeleteTenantData deletes all the data of the tenant
+	DeleteTenantData(ctx context.Context, tenantID string) error
 }
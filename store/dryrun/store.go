@@ -0,0 +1,256 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	opIndexDevices      = "devices/index"
+	opRemoveDevices     = "devices/remove"
+	opUpdateDevices     = "devices/update"
+	opRemapAttribute    = "devices/remap"
+	opDeleteDevices     = "devices/delete"
+	opIndexSoftware     = "software/index"
+	opIndexDeployments  = "deployments/index"
+	opDeleteDeployments = "deployments/delete"
+	opDeleteTenant      = "tenant/delete"
+	opUpdateSettings    = "settings/update"
+
+	resultValid   = "valid"
+	resultInvalid = "invalid"
+)
+
+var metricDocuments = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "reporting",
+	Subsystem: "dry_run",
+	Name:      "documents_total",
+	Help: "Number of documents the indexer would have written in dry-run mode, " +
+		"by operation and result of their validation against the mapping.",
+}, []string{"operation", "result"})
+
+func init() {
+	prometheus.MustRegister(metricDocuments)
+}
+
+// dryRunStore logs the writes instead of performing them, validating the
+// devices documents against the mapping of their tenant's index; the reads
+// are performed by the wrapped store
+type dryRunStore struct {
+	store.Store
+}
+
+// NewStore wraps the store in a dry-run store, which never writes to the
+// wrapped store: the documents which would be written are validated, and
+// logged with the result of their validation
+func NewStore(s store.Store) store.Store {
+	return &dryRunStore{
+		Store: s,
+	}
+}
+
+func (s *dryRunStore) record(ctx context.Context, op, result string, doc interface{},
+	problems ...string) {
+	l := log.FromContext(ctx)
+	metricDocuments.WithLabelValues(op, result).Inc()
+	body, err := json.Marshal(doc)
+	if err != nil {
+		body = []byte(err.Error())
+	}
+	if result == resultInvalid {
+		l.Warnf("dry run: %s: invalid document: %s: %s",
+			op, strings.Join(problems, "; "), body)
+	} else {
+		l.Infof("dry run: %s: %s", op, body)
+	}
+}
+
+// validateDevices validates the devices documents against the mapping of
+// their tenants' indices, and records them
+func (s *dryRunStore) validateDevices(
+	ctx context.Context,
+	op string,
+	devices []*model.Device,
+) error {
+	mappings := make(map[string]indexMapping)
+	for _, device := range devices {
+		tenantID := device.GetTenantID()
+		mapping, ok := mappings[tenantID]
+		if !ok {
+			index, err := s.Store.GetDevicesIndexMapping(ctx, tenantID)
+			if err != nil {
+				return errors.Wrapf(err,
+					"failed to get the mapping of the tenant %q", tenantID)
+			}
+			mapping = parseIndexMapping(index)
+			mappings[tenantID] = mapping
+		}
+		var doc map[string]interface{}
+		body, err := json.Marshal(device)
+		if err == nil {
+			err = json.Unmarshal(body, &doc)
+		}
+		if err != nil {
+			s.record(ctx, op, resultInvalid, device.GetID(), err.Error())
+			continue
+		}
+		if problems := mapping.validate(doc); len(problems) > 0 {
+			s.record(ctx, op, resultInvalid, doc, problems...)
+		} else {
+			s.record(ctx, op, resultValid, doc)
+		}
+	}
+	return nil
+}
+
+func (s *dryRunStore) BulkIndexDevices(
+	ctx context.Context,
+	devices, removedDevices []*model.Device,
+) error {
+	for _, device := range removedDevices {
+		s.record(ctx, opRemoveDevices, resultValid, device)
+	}
+	return s.validateDevices(ctx, opIndexDevices, devices)
+}
+
+// BulkIndexDevicesInto validates the devices as if they were indexed in
+// the tenant's current index
+func (s *dryRunStore) BulkIndexDevicesInto(
+	ctx context.Context,
+	index string,
+	devices []*model.Device,
+) error {
+	return s.validateDevices(ctx, opIndexDevices, devices)
+}
+
+// UpdateDevices validates the partial documents of the devices: they only
+// have the fields being updated
+func (s *dryRunStore) UpdateDevices(ctx context.Context, devices []*model.Device) error {
+	return s.validateDevices(ctx, opUpdateDevices, devices)
+}
+
+// ReplaceDevicesTags validates the partial documents of the devices: they
+// only have the tags being replaced
+func (s *dryRunStore) ReplaceDevicesTags(ctx context.Context, devices []*model.Device) error {
+	return s.validateDevices(ctx, opUpdateDevices, devices)
+}
+
+func (s *dryRunStore) BulkIndexSoftware(
+	ctx context.Context,
+	software []*model.DeviceSoftware,
+	removedDevices []*model.Device,
+) error {
+	for _, item := range software {
+		s.record(ctx, opIndexSoftware, resultValid, item)
+	}
+	return nil
+}
+
+func (s *dryRunStore) BulkIndexDeployments(
+	ctx context.Context,
+	deployments []*model.Deployment,
+) error {
+	for _, deployment := range deployments {
+		s.record(ctx, opIndexDeployments, resultValid, deployment)
+	}
+	return nil
+}
+
+func (s *dryRunStore) DeleteDevicesData(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	for _, deviceID := range deviceIDs {
+		s.record(ctx, opDeleteDevices, resultValid,
+			model.NewDevice(tenantID, deviceID))
+	}
+	return nil
+}
+
+func (s *dryRunStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	s.record(ctx, opDeleteTenant, resultValid, map[string]string{
+		model.FieldNameTenantID: tenantID,
+	})
+	return nil
+}
+
+func (s *dryRunStore) DeleteDeploymentsBefore(ctx context.Context, cutoff time.Time) error {
+	s.record(ctx, opDeleteDeployments, resultValid, map[string]time.Time{
+		"finished_before": cutoff,
+	})
+	return nil
+}
+
+// CreateDevicesIndex does not create any index: the tenant's current one
+// is returned, for the devices to be validated against its mapping
+func (s *dryRunStore) CreateDevicesIndex(ctx context.Context, tid string) (string, error) {
+	return s.Store.GetDevicesIndex(tid), nil
+}
+
+func (s *dryRunStore) SwapDevicesIndex(ctx context.Context, tid, index string) error {
+	return nil
+}
+
+func (s *dryRunStore) Migrate(ctx context.Context) error {
+	return nil
+}
+
+func (s *dryRunStore) MigrateMappings(ctx context.Context) error {
+	return nil
+}
+
+// PlanMigrations returns the changes of the store: planning writes nothing
+func (s *dryRunStore) PlanMigrations(ctx context.Context) ([]model.MigrationChange, error) {
+	return s.Store.PlanMigrations(ctx)
+}
+
+func (s *dryRunStore) RemapDevicesAttribute(
+	ctx context.Context,
+	tenantID, scope, attribute string,
+	typ model.Type,
+) error {
+	s.record(ctx, opRemapAttribute, resultValid, map[string]interface{}{
+		model.FieldNameTenantID: tenantID,
+		"scope":                 scope,
+		"attribute":             attribute,
+		"type":                  typ,
+	})
+	return nil
+}
+
+func (s *dryRunStore) UpdateIndexSettings(
+	ctx context.Context,
+	index string,
+	settings *model.IndexSettings,
+) error {
+	s.record(ctx, opUpdateSettings, resultValid, map[string]interface{}{
+		"index":    index,
+		"settings": settings,
+	})
+	return nil
+}
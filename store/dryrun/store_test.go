@@ -0,0 +1,146 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package dryrun
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func devicesIndex() map[string]interface{} {
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"id":            map[string]interface{}{"type": "keyword"},
+				"tenant_id":     map[string]interface{}{"type": "keyword"},
+				"check_in_time": map[string]interface{}{"type": "date"},
+				"location":      map[string]interface{}{"type": "geo_point"},
+				"inventory_attribute1_str": map[string]interface{}{
+					"type": "double",
+				},
+			},
+			"dynamic_templates": []interface{}{
+				map[string]interface{}{"strings": map[string]interface{}{
+					"match":   "*_str",
+					"mapping": map[string]interface{}{"type": "keyword"},
+				}},
+				map[string]interface{}{"nums": map[string]interface{}{
+					"match":   "*_num",
+					"mapping": map[string]interface{}{"type": "double"},
+				}},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	mapping := parseIndexMapping(devicesIndex())
+	testCases := map[string]struct {
+		doc      map[string]interface{}
+		problems []string
+	}{
+		"ok": {
+			doc: map[string]interface{}{
+				"id":                 "1",
+				"tenant_id":          "tenant",
+				"check_in_time":      "2023-05-02T10:00:00Z",
+				"location":           map[string]interface{}{"lat": 1.0, "lon": 2.0},
+				"identity_mac_str":   []interface{}{"00:11:22:33:44:55"},
+				"inventory_cpus_num": []interface{}{4.0},
+				"monitor_alerts_str": nil,
+			},
+		},
+		"ko, not in the mapping": {
+			doc: map[string]interface{}{
+				"id":                   "1",
+				"inventory_debug_bool": []interface{}{true},
+			},
+			problems: []string{
+				"inventory_debug_bool: not in the mapping, it would be mapped dynamically",
+			},
+		},
+		"ko, conflicting types": {
+			doc: map[string]interface{}{
+				"id":                       "1",
+				"check_in_time":            "yesterday",
+				"inventory_attribute1_str": []interface{}{"debian"},
+				"inventory_cpus_num":       []interface{}{4.0, "four"},
+			},
+			problems: []string{
+				"check_in_time: value yesterday does not fit the date type",
+				"inventory_attribute1_str: value [debian] does not fit the double type",
+				"inventory_cpus_num: value [4 four] does not fit the double type",
+			},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.problems, mapping.validate(tc.doc))
+		})
+	}
+}
+
+func TestDryRunStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	valid := model.NewDevice("tenant", "1").
+		SetCheckInTime(time.Date(2023, 5, 2, 10, 0, 0, 0, time.UTC))
+	_ = valid.AppendAttr(model.NewInventoryAttribute(model.ScopeIdentity).
+		SetName("mac").SetVal("00:11:22:33:44:55"))
+	invalid := model.NewDevice("tenant", "2")
+	_ = invalid.AppendAttr(model.NewInventoryAttribute(model.ScopeInventory).
+		SetName("debug").SetVal(true))
+
+	// only the mapping is read, nothing is written
+	st := new(mstore.Store)
+	defer st.AssertExpectations(t)
+	st.On("GetDevicesIndexMapping", ctx, "tenant").
+		Return(devicesIndex(), nil).Once()
+	st.On("GetDevicesIndexMapping", ctx, "other").
+		Return(nil, errors.New("error")).Once()
+	st.On("GetDevicesIndex", "tenant").Return("devices-tenant")
+
+	s := NewStore(st)
+	err := s.BulkIndexDevices(ctx, []*model.Device{valid, invalid},
+		[]*model.Device{model.NewDevice("tenant", "3")})
+	assert.NoError(t, err)
+
+	err = s.UpdateDevices(ctx, []*model.Device{model.NewDevice("other", "1")})
+	assert.EqualError(t, err, `failed to get the mapping of the tenant "other": error`)
+
+	index, err := s.CreateDevicesIndex(ctx, "tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "devices-tenant", index)
+	assert.NoError(t, s.SwapDevicesIndex(ctx, "tenant", index))
+
+	assert.NoError(t, s.DeleteDevicesData(ctx, "tenant", []string{"1"}))
+	assert.NoError(t, s.DeleteTenantData(ctx, "tenant"))
+	assert.NoError(t, s.BulkIndexDeployments(ctx, []*model.Deployment{{}}))
+	assert.NoError(t, s.DeleteDeploymentsBefore(ctx, time.Now()))
+	assert.NoError(t, s.Migrate(ctx))
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package dryrun
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+)
+
+// indexMapping is the mapping of the fields of an index: the explicitly
+// mapped fields, and the templates of the fields mapped dynamically
+type indexMapping struct {
+	properties map[string]string
+	templates  []dynamicTemplate
+}
+
+type dynamicTemplate struct {
+	match     string
+	fieldType string
+}
+
+// parseIndexMapping parses the mapping of the index from its definition,
+// as returned by the store
+func parseIndexMapping(index map[string]interface{}) indexMapping {
+	mapping := indexMapping{properties: map[string]string{}}
+	mappings, _ := index["mappings"].(map[string]interface{})
+	properties, _ := mappings["properties"].(map[string]interface{})
+	for field, property := range properties {
+		property, _ := property.(map[string]interface{})
+		fieldType, _ := property["type"].(string)
+		if fieldType == "" && property["properties"] != nil {
+			fieldType = "object"
+		}
+		mapping.properties[field] = fieldType
+	}
+	templates, _ := mappings["dynamic_templates"].([]interface{})
+	for _, template := range templates {
+		template, _ := template.(map[string]interface{})
+		for _, def := range template {
+			def, _ := def.(map[string]interface{})
+			match, _ := def["match"].(string)
+			fieldMapping, _ := def["mapping"].(map[string]interface{})
+			fieldType, _ := fieldMapping["type"].(string)
+			if match != "" {
+				mapping.templates = append(mapping.templates,
+					dynamicTemplate{match: match, fieldType: fieldType})
+			}
+		}
+	}
+	return mapping
+}
+
+// fieldType returns the type the field is mapped to, explicitly or by the
+// first matching dynamic template, and false if the field is not mapped
+func (m indexMapping) fieldType(field string) (string, bool) {
+	if fieldType, ok := m.properties[field]; ok {
+		return fieldType, true
+	}
+	for _, template := range m.templates {
+		if ok, _ := path.Match(template.match, field); ok {
+			return template.fieldType, true
+		}
+	}
+	return "", false
+}
+
+// validate validates the fields of the document against the mapping,
+// returning one problem per invalid field, sorted by field
+func (m indexMapping) validate(doc map[string]interface{}) []string {
+	var problems []string
+	for field, value := range doc {
+		if value == nil {
+			continue
+		}
+		fieldType, ok := m.fieldType(field)
+		if !ok {
+			problems = append(problems,
+				fmt.Sprintf("%s: not in the mapping, it would be mapped dynamically", field))
+			continue
+		}
+		if !validValue(fieldType, value) {
+			problems = append(problems,
+				fmt.Sprintf("%s: value %v does not fit the %s type", field, value, fieldType))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// validValue returns true if the value, decoded from JSON, can be indexed
+// as the type; the arrays are valid if all their values are
+func validValue(fieldType string, value interface{}) bool {
+	if values, ok := value.([]interface{}); ok {
+		for _, value := range values {
+			if !validValue(fieldType, value) {
+				return false
+			}
+		}
+		return true
+	}
+	switch fieldType {
+	case "keyword", "text", "version", "wildcard":
+		_, ok := value.(string)
+		return ok
+	case "double", "float", "long", "integer", "short":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "date":
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	case "geo_point", "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		// the types not built by the indexer are not validated
+		return true
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	metricQueriesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "store",
+		Name:      "queries_in_flight",
+		Help:      "Number of queries running on the store.",
+	})
+	metricQueriesRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "reporting",
+		Subsystem: "store",
+		Name:      "queries_rejected_total",
+		Help:      "Number of queries rejected because too many queries were running.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(metricQueriesInFlight, metricQueriesRejected)
+}
+
+// limitedStore limits the number of queries running at the same time on
+// the wrapped store; the writes are not limited
+type limitedStore struct {
+	store.Store
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// NewStore wraps the store limiting the number of concurrent queries to
+// maxQueries: the queries over the limit wait up to timeout for a running
+// query to finish, and fail with store.ErrTooManyQueries afterwards
+func NewStore(s store.Store, maxQueries int, timeout time.Duration) store.Store {
+	return &limitedStore{
+		Store:   s,
+		slots:   make(chan struct{}, maxQueries),
+		timeout: timeout,
+	}
+}
+
+// acquire takes a slot for a query, waiting up to the timeout for one to
+// be released; the slot must be released once the query is done
+func (s *limitedStore) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		metricQueriesInFlight.Inc()
+		return nil
+	default:
+	}
+	if s.timeout > 0 {
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		select {
+		case s.slots <- struct{}{}:
+			metricQueriesInFlight.Inc()
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	metricQueriesRejected.Inc()
+	return store.ErrTooManyQueries
+}
+
+func (s *limitedStore) release() {
+	metricQueriesInFlight.Dec()
+	<-s.slots
+}
+
+type queryFunc func(ctx context.Context, query model.Query) (model.M, error)
+
+func (s *limitedStore) limited(
+	ctx context.Context,
+	query model.Query,
+	fn queryFunc,
+) (model.M, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return fn(ctx, query)
+}
+
+func (s *limitedStore) AggregateDevices(ctx context.Context, query model.Query) (model.M, error) {
+	return s.limited(ctx, query, s.Store.AggregateDevices)
+}
+
+func (s *limitedStore) AggregateDeployments(
+	ctx context.Context,
+	query model.Query,
+) (model.M, error) {
+	return s.limited(ctx, query, s.Store.AggregateDeployments)
+}
+
+func (s *limitedStore) AggregateSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	return s.limited(ctx, query, s.Store.AggregateSoftware)
+}
+
+func (s *limitedStore) SearchDevices(ctx context.Context, query model.Query) (model.M, error) {
+	return s.limited(ctx, query, s.Store.SearchDevices)
+}
+
+func (s *limitedStore) CountDevices(ctx context.Context, query model.Query) (int, error) {
+	if err := s.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer s.release()
+	return s.Store.CountDevices(ctx, query)
+}
+
+func (s *limitedStore) SearchDeployments(ctx context.Context, query model.Query) (model.M, error) {
+	return s.limited(ctx, query, s.Store.SearchDeployments)
+}
+
+func (s *limitedStore) SearchSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	return s.limited(ctx, query, s.Store.SearchSoftware)
+}
+
+func (s *limitedStore) SearchPointInTime(ctx context.Context, query model.Query) (model.M, error) {
+	return s.limited(ctx, query, s.Store.SearchPointInTime)
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestLimitedStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	query := model.NewQuery()
+	res := model.M{"hits": map[string]interface{}{}}
+
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	st := &mstore.Store{}
+	defer st.AssertExpectations(t)
+	st.On("SearchDevices", ctx, query).
+		Run(func(mock.Arguments) {
+			running <- struct{}{}
+			<-finish
+		}).
+		Return(res, nil).Times(3)
+	st.On("CountDevices", ctx, query).Return(1, nil).Once()
+
+	s := NewStore(st, 1, 50*time.Millisecond)
+
+	done := make(chan error, 2)
+	go func() {
+		_, err := s.SearchDevices(ctx, query)
+		done <- err
+	}()
+	<-running
+
+	// rejected once the timeout expires
+	_, err := s.AggregateDevices(ctx, query)
+	assert.Equal(t, store.ErrTooManyQueries, err)
+
+	// waits for the running query to finish
+	go func() {
+		_, err := s.SearchDevices(ctx, query)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	finish <- struct{}{}
+	assert.NoError(t, <-done)
+	<-running
+	finish <- struct{}{}
+	assert.NoError(t, <-done)
+
+	// the slots are released
+	count, err := s.CountDevices(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// the waiting queries are cancelled with their context
+	go func() {
+		_, _ = s.SearchDevices(ctx, query)
+	}()
+	<-running
+	defer close(finish)
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.SearchDeployments(cancelledCtx, query)
+	assert.Equal(t, context.Canceled, err)
+}
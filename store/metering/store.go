@@ -0,0 +1,235 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package metering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+var (
+	metricQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "reporting",
+		Subsystem: "tenant",
+		Name:      "queries_total",
+		Help:      "Number of queries of the tenants.",
+	}, []string{"tenant_id"})
+	metricDocuments = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "tenant",
+		Name:      "documents",
+		Help:      "Number of documents of the tenants, by index.",
+	}, []string{"tenant_id", "index"})
+	metricIndexBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "reporting",
+		Subsystem: "tenant",
+		Name:      "index_bytes",
+		Help:      "Space the documents of the tenants take in the indices.",
+	}, []string{"tenant_id"})
+)
+
+func init() {
+	prometheus.MustRegister(metricQueries, metricDocuments, metricIndexBytes)
+}
+
+// Store meters the usage of the wrapped store by the tenants: the queries
+// of the tenants are counted, and flushed to the data store by month, and
+// the usage returned by GetTenantUsage is exported as metrics
+type Store struct {
+	store.Store
+	ds store.DataStore
+
+	mutex   sync.Mutex
+	queries map[string]int64
+	now     func() time.Time
+}
+
+// NewStore wraps the store metering the queries of the tenants, whose
+// counts are flushed to the data store
+func NewStore(s store.Store, ds store.DataStore) *Store {
+	return &Store{
+		Store:   s,
+		ds:      ds,
+		queries: map[string]int64{},
+		now:     time.Now,
+	}
+}
+
+// meter counts the query of the tenant in the context; the queries across
+// all the tenants are not metered
+func (s *Store) meter(ctx context.Context) {
+	id := identity.FromContext(ctx)
+	if id == nil || id.Tenant == "" {
+		return
+	}
+	metricQueries.WithLabelValues(id.Tenant).Inc()
+	s.mutex.Lock()
+	s.queries[id.Tenant]++
+	s.mutex.Unlock()
+}
+
+// Flush adds the queries counted since the last flush to the counts of the
+// current month in the data store; the counts are kept for the next flush
+// if it fails
+func (s *Store) Flush(ctx context.Context) error {
+	s.mutex.Lock()
+	queries := s.queries
+	s.queries = map[string]int64{}
+	s.mutex.Unlock()
+	if len(queries) == 0 {
+		return nil
+	}
+	err := s.ds.IncrementTenantQueries(ctx, model.UsageMonth(s.now()), queries)
+	if err != nil {
+		s.mutex.Lock()
+		for tenantID, count := range queries {
+			s.queries[tenantID] += count
+		}
+		s.mutex.Unlock()
+		return errors.Wrap(err, "failed to flush the queries of the tenants")
+	}
+	return nil
+}
+
+// Run flushes the queries of the tenants every interval, until the context
+// is done
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Flush(ctx); err != nil {
+			l.Error(err)
+		}
+	}
+}
+
+// RefreshUsage refreshes the usage metrics of all the tenants; the tenants
+// whose usage fails to be refreshed are logged and skipped
+func (s *Store) RefreshUsage(ctx context.Context) error {
+	l := log.FromContext(ctx)
+	tenantIDs, err := s.ds.GetTenantIDs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the tenants")
+	}
+	for _, tenantID := range tenantIDs {
+		if _, err := s.GetTenantUsage(ctx, tenantID); err != nil {
+			l.Error(errors.Wrapf(err,
+				"failed to get the usage of the tenant %q", tenantID))
+		}
+	}
+	return nil
+}
+
+// RunUsageRefresh refreshes the usage metrics of all the tenants every
+// interval, starting right away, until the context is done
+func (s *Store) RunUsageRefresh(ctx context.Context, interval time.Duration) {
+	l := log.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RefreshUsage(ctx); err != nil {
+			l.Error(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetTenantUsage returns the usage of the tenant, exporting it as metrics
+func (s *Store) GetTenantUsage(ctx context.Context, tenantID string) (
+	*model.TenantUsage, error) {
+	usage, err := s.Store.GetTenantUsage(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	metricDocuments.WithLabelValues(tenantID, model.IndexDevices).
+		Set(float64(usage.Documents.Devices))
+	metricDocuments.WithLabelValues(tenantID, model.IndexDeployments).
+		Set(float64(usage.Documents.Deployments))
+	metricDocuments.WithLabelValues(tenantID, model.IndexSoftware).
+		Set(float64(usage.Documents.Software))
+	metricIndexBytes.WithLabelValues(tenantID).Set(float64(usage.IndexBytes))
+	return usage, nil
+}
+
+// DeleteTenantData deletes the tenant's data, and its usage metrics
+func (s *Store) DeleteTenantData(ctx context.Context, tenantID string) error {
+	err := s.Store.DeleteTenantData(ctx, tenantID)
+	if err == nil {
+		metricQueries.DeleteLabelValues(tenantID)
+		metricDocuments.DeletePartialMatch(prometheus.Labels{"tenant_id": tenantID})
+		metricIndexBytes.DeleteLabelValues(tenantID)
+	}
+	return err
+}
+
+func (s *Store) AggregateDevices(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.AggregateDevices(ctx, query)
+}
+
+func (s *Store) AggregateDeployments(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.AggregateDeployments(ctx, query)
+}
+
+func (s *Store) AggregateSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.AggregateSoftware(ctx, query)
+}
+
+func (s *Store) SearchDevices(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.SearchDevices(ctx, query)
+}
+
+func (s *Store) CountDevices(ctx context.Context, query model.Query) (int, error) {
+	s.meter(ctx)
+	return s.Store.CountDevices(ctx, query)
+}
+
+func (s *Store) SearchDeployments(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.SearchDeployments(ctx, query)
+}
+
+func (s *Store) SearchSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.SearchSoftware(ctx, query)
+}
+
+func (s *Store) SearchPointInTime(ctx context.Context, query model.Query) (model.M, error) {
+	s.meter(ctx)
+	return s.Store.SearchPointInTime(ctx, query)
+}
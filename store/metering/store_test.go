@@ -0,0 +1,107 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package metering
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/model"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func TestStoreFlush(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx1 := identity.WithContext(ctx, &identity.Identity{Tenant: "tenant1"})
+	ctx2 := identity.WithContext(ctx, &identity.Identity{Tenant: "tenant2"})
+	query := model.NewQuery()
+	now := time.Date(2023, 3, 31, 23, 59, 0, 0, time.UTC)
+
+	st := &mstore.Store{}
+	defer st.AssertExpectations(t)
+	st.On("SearchDevices", ctx1, query).Return(model.M{}, nil).Once()
+	st.On("AggregateDevices", ctx1, query).Return(nil, errors.New("error")).Once()
+	st.On("CountDevices", ctx2, query).Return(1, nil).Once()
+	// the queries across all the tenants are not metered
+	st.On("SearchDevices", ctx, query).Return(model.M{}, nil).Once()
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("IncrementTenantQueries", ctx, "2023-03", map[string]int64{
+		"tenant1": 2,
+		"tenant2": 1,
+	}).Return(errors.New("connection reset")).Once()
+	ds.On("IncrementTenantQueries", ctx, "2023-03", map[string]int64{
+		"tenant1": 3,
+		"tenant2": 1,
+	}).Return(nil).Once()
+
+	s := NewStore(st, ds)
+	s.now = func() time.Time { return now }
+
+	_, _ = s.SearchDevices(ctx1, query)
+	_, _ = s.AggregateDevices(ctx1, query)
+	_, _ = s.CountDevices(ctx2, query)
+	_, _ = s.SearchDevices(ctx, query)
+
+	// the counts are kept if the flush fails
+	err := s.Flush(ctx)
+	assert.EqualError(t, err, "failed to flush the queries of the tenants: connection reset")
+
+	st.On("SearchDevices", ctx1, query).Return(model.M{}, nil).Once()
+	_, _ = s.SearchDevices(ctx1, query)
+	err = s.Flush(ctx)
+	assert.NoError(t, err)
+
+	// nothing to flush
+	err = s.Flush(ctx)
+	assert.NoError(t, err)
+}
+
+func TestStoreRefreshUsage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	usage := &model.TenantUsage{
+		TenantID:   "tenant1",
+		Documents:  model.UsageDocuments{Devices: 10},
+		IndexBytes: 1024,
+	}
+
+	st := &mstore.Store{}
+	defer st.AssertExpectations(t)
+	st.On("GetTenantUsage", ctx, "tenant1").Return(usage, nil).Once()
+	st.On("GetTenantUsage", ctx, "tenant2").Return(nil, errors.New("error")).Once()
+	ds := &mstore.DataStore{}
+	defer ds.AssertExpectations(t)
+	ds.On("GetTenantIDs", ctx).Return([]string{"tenant1", "tenant2"}, nil).Once()
+
+	ds.On("GetTenantIDs", ctx).Return(nil, errors.New("connection reset")).Once()
+
+	s := NewStore(st, ds)
+	// the tenants failing are skipped
+	err := s.RefreshUsage(ctx)
+	assert.NoError(t, err)
+
+	err = s.RefreshUsage(ctx)
+	assert.EqualError(t, err, "failed to get the tenants: connection reset")
+}
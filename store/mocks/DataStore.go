@@ -21,6 +21,8 @@ import (
 
 	model "github.com/mendersoftware/reporting/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // DataStore is an autogenerated mock type for the DataStore type
@@ -28,6 +30,48 @@ type DataStore struct {
 	mock.Mock
 }
 
+// ClaimAlertEvaluation provides a mock function with given fields: ctx, alert, evaluatedTs
+func (_m *DataStore) ClaimAlertEvaluation(ctx context.Context, alert *model.Alert, evaluatedTs time.Time) (bool, error) {
+	ret := _m.Called(ctx, alert, evaluatedTs)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Alert, time.Time) bool); ok {
+		r0 = rf(ctx, alert, evaluatedTs)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Alert, time.Time) error); ok {
+		r1 = rf(ctx, alert, evaluatedTs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimSavedSearchReport provides a mock function with given fields: ctx, search, runTs
+func (_m *DataStore) ClaimSavedSearchReport(ctx context.Context, search *model.SavedSearch, runTs time.Time) (bool, error) {
+	ret := _m.Called(ctx, search, runTs)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SavedSearch, time.Time) bool); ok {
+		r0 = rf(ctx, search, runTs)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SavedSearch, time.Time) error); ok {
+		r1 = rf(ctx, search, runTs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with given fields: ctx
 func (_m *DataStore) Close(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -42,6 +86,104 @@ func (_m *DataStore) Close(ctx context.Context) error {
 	return r0
 }
 
+// CompleteMappingRemap provides a mock function with given fields: ctx, tenantID, attribute
+func (_m *DataStore) CompleteMappingRemap(ctx context.Context, tenantID string, attribute string) error {
+	ret := _m.Called(ctx, tenantID, attribute)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tenantID, attribute)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAlert provides a mock function with given fields: ctx, tenantID, id
+func (_m *DataStore) DeleteAlert(ctx context.Context, tenantID string, id string) error {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteDeadLetter provides a mock function with given fields: ctx, id
+func (_m *DataStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteDecommissionedDevices provides a mock function with given fields: ctx, tenantID, deviceIDs
+func (_m *DataStore) DeleteDecommissionedDevices(ctx context.Context, tenantID string, deviceIDs []string) error {
+	ret := _m.Called(ctx, tenantID, deviceIDs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, tenantID, deviceIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteReindexState provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) DeleteReindexState(ctx context.Context, tenantID string) error {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSavedSearch provides a mock function with given fields: ctx, tenantID, id
+func (_m *DataStore) DeleteSavedSearch(ctx context.Context, tenantID string, id string) error {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTenantData provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DropDatabase provides a mock function with given fields: ctx
 func (_m *DataStore) DropDatabase(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -56,6 +198,241 @@ func (_m *DataStore) DropDatabase(ctx context.Context) error {
 	return r0
 }
 
+// EvictMappingAttributes provides a mock function with given fields: ctx, tenantID, attributes
+func (_m *DataStore) EvictMappingAttributes(ctx context.Context, tenantID string, attributes []string) error {
+	ret := _m.Called(ctx, tenantID, attributes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, tenantID, attributes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAlert provides a mock function with given fields: ctx, tenantID, id
+func (_m *DataStore) GetAlert(ctx context.Context, tenantID string, id string) (*model.Alert, error) {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 *model.Alert
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Alert); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Alert)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAlerts provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) GetAlerts(ctx context.Context, tenantID string) ([]model.Alert, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 []model.Alert
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Alert); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Alert)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllAlerts provides a mock function with given fields: ctx
+func (_m *DataStore) GetAllAlerts(ctx context.Context) ([]model.Alert, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.Alert
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Alert); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Alert)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAttributesUsage provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) GetAttributesUsage(ctx context.Context, tenantID string) ([]model.AttributeUsage, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 []model.AttributeUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.AttributeUsage); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AttributeUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuditLogs provides a mock function with given fields: ctx, params
+func (_m *DataStore) GetAuditLogs(ctx context.Context, params model.AuditLogsParams) ([]model.AuditLogEntry, int, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.AuditLogEntry
+	if rf, ok := ret.Get(0).(func(context.Context, model.AuditLogsParams) []model.AuditLogEntry); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AuditLogEntry)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, model.AuditLogsParams) int); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, model.AuditLogsParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDeadLetter provides a mock function with given fields: ctx, id
+func (_m *DataStore) GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *model.DeadLetter
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.DeadLetter); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.DeadLetter)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeadLetters provides a mock function with given fields: ctx, params
+func (_m *DataStore) GetDeadLetters(ctx context.Context, params model.DeadLettersParams) ([]model.DeadLetter, int, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []model.DeadLetter
+	if rf, ok := ret.Get(0).(func(context.Context, model.DeadLettersParams) []model.DeadLetter); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DeadLetter)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, model.DeadLettersParams) int); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, model.DeadLettersParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDecommissionedDevicesToPurge provides a mock function with given fields: ctx, before, limit
+func (_m *DataStore) GetDecommissionedDevicesToPurge(ctx context.Context, before time.Time, limit int) ([]model.DecommissionedDevice, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	var r0 []model.DecommissionedDevice
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []model.DecommissionedDevice); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DecommissionedDevice)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIndexingRules provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) GetIndexingRules(ctx context.Context, tenantID string) (*model.IndexingRules, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 *model.IndexingRules
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.IndexingRules); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.IndexingRules)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMapping provides a mock function with given fields: ctx, tenantID
 func (_m *DataStore) GetMapping(ctx context.Context, tenantID string) (*model.Mapping, error) {
 	ret := _m.Called(ctx, tenantID)
@@ -79,6 +456,300 @@ func (_m *DataStore) GetMapping(ctx context.Context, tenantID string) (*model.Ma
 	return r0, r1
 }
 
+// GetMappingsToRemap provides a mock function with given fields: ctx
+func (_m *DataStore) GetMappingsToRemap(ctx context.Context) ([]model.Mapping, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.Mapping
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Mapping); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Mapping)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReindexState provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) GetReindexState(ctx context.Context, tenantID string) (*model.ReindexState, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 *model.ReindexState
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.ReindexState); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ReindexState)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSavedSearch provides a mock function with given fields: ctx, tenantID, id
+func (_m *DataStore) GetSavedSearch(ctx context.Context, tenantID string, id string) (*model.SavedSearch, error) {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 *model.SavedSearch
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.SavedSearch); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SavedSearch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSavedSearches provides a mock function with given fields: ctx, tenantID
+func (_m *DataStore) GetSavedSearches(ctx context.Context, tenantID string) ([]model.SavedSearch, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 []model.SavedSearch
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.SavedSearch); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SavedSearch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetScheduledSavedSearches provides a mock function with given fields: ctx
+func (_m *DataStore) GetScheduledSavedSearches(ctx context.Context) ([]model.SavedSearch, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.SavedSearch
+	if rf, ok := ret.Get(0).(func(context.Context) []model.SavedSearch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.SavedSearch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSearchJob provides a mock function with given fields: ctx, tenantID, id
+func (_m *DataStore) GetSearchJob(ctx context.Context, tenantID string, id string) (*model.SearchJob, error) {
+	ret := _m.Called(ctx, tenantID, id)
+
+	var r0 *model.SearchJob
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.SearchJob); ok {
+		r0 = rf(ctx, tenantID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SearchJob)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenantIDs provides a mock function with given fields: ctx
+func (_m *DataStore) GetTenantIDs(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenantQueries provides a mock function with given fields: ctx, tenantID, month
+func (_m *DataStore) GetTenantQueries(ctx context.Context, tenantID string, month string) (int64, error) {
+	ret := _m.Called(ctx, tenantID, month)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, tenantID, month)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenantID, month)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementAttributesUsage provides a mock function with given fields: ctx, usage
+func (_m *DataStore) IncrementAttributesUsage(ctx context.Context, usage map[string][]model.AttributeUsage) error {
+	ret := _m.Called(ctx, usage)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[string][]model.AttributeUsage) error); ok {
+		r0 = rf(ctx, usage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IncrementTenantQueries provides a mock function with given fields: ctx, month, queries
+func (_m *DataStore) IncrementTenantQueries(ctx context.Context, month string, queries map[string]int64) error {
+	ret := _m.Called(ctx, month, queries)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]int64) error); ok {
+		r0 = rf(ctx, month, queries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertAlert provides a mock function with given fields: ctx, alert
+func (_m *DataStore) InsertAlert(ctx context.Context, alert *model.Alert) error {
+	ret := _m.Called(ctx, alert)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Alert) error); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertAuditLogEntry provides a mock function with given fields: ctx, entry
+func (_m *DataStore) InsertAuditLogEntry(ctx context.Context, entry *model.AuditLogEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.AuditLogEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertDeadLetter provides a mock function with given fields: ctx, letter
+func (_m *DataStore) InsertDeadLetter(ctx context.Context, letter *model.DeadLetter) error {
+	ret := _m.Called(ctx, letter)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.DeadLetter) error); ok {
+		r0 = rf(ctx, letter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertDecommissionedDevices provides a mock function with given fields: ctx, devices
+func (_m *DataStore) InsertDecommissionedDevices(ctx context.Context, devices []model.DecommissionedDevice) error {
+	ret := _m.Called(ctx, devices)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []model.DecommissionedDevice) error); ok {
+		r0 = rf(ctx, devices)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertSavedSearch provides a mock function with given fields: ctx, search
+func (_m *DataStore) InsertSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	ret := _m.Called(ctx, search)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SavedSearch) error); ok {
+		r0 = rf(ctx, search)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertSearchJob provides a mock function with given fields: ctx, job
+func (_m *DataStore) InsertSearchJob(ctx context.Context, job *model.SearchJob) error {
+	ret := _m.Called(ctx, job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Migrate provides a mock function with given fields: ctx, version, automigrate
 func (_m *DataStore) Migrate(ctx context.Context, version string, automigrate bool) error {
 	ret := _m.Called(ctx, version, automigrate)
@@ -121,6 +792,76 @@ func (_m *DataStore) Ping(ctx context.Context) error {
 	return r0
 }
 
+// SaveReindexState provides a mock function with given fields: ctx, state
+func (_m *DataStore) SaveReindexState(ctx context.Context, state *model.ReindexState) error {
+	ret := _m.Called(ctx, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.ReindexState) error); ok {
+		r0 = rf(ctx, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetAlertState provides a mock function with given fields: ctx, alert, state
+func (_m *DataStore) SetAlertState(ctx context.Context, alert *model.Alert, state *model.AlertState) error {
+	ret := _m.Called(ctx, alert, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Alert, *model.AlertState) error); ok {
+		r0 = rf(ctx, alert, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetIndexingRules provides a mock function with given fields: ctx, rules
+func (_m *DataStore) SetIndexingRules(ctx context.Context, rules *model.IndexingRules) error {
+	ret := _m.Called(ctx, rules)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.IndexingRules) error); ok {
+		r0 = rf(ctx, rules)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMappingTypes provides a mock function with given fields: ctx, tenantID, types, remaps
+func (_m *DataStore) SetMappingTypes(ctx context.Context, tenantID string, types []model.MappingAttributeType, remaps []string) error {
+	ret := _m.Called(ctx, tenantID, types, remaps)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []model.MappingAttributeType, []string) error); ok {
+		r0 = rf(ctx, tenantID, types, remaps)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAlert provides a mock function with given fields: ctx, alert
+func (_m *DataStore) UpdateAlert(ctx context.Context, alert *model.Alert) error {
+	ret := _m.Called(ctx, alert)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Alert) error); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateAndGetMapping provides a mock function with given fields: ctx, tenantID, inventory
 func (_m *DataStore) UpdateAndGetMapping(ctx context.Context, tenantID string, inventory []string) (*model.Mapping, error) {
 	ret := _m.Called(ctx, tenantID, inventory)
@@ -143,3 +884,31 @@ func (_m *DataStore) UpdateAndGetMapping(ctx context.Context, tenantID string, i
 
 	return r0, r1
 }
+
+// UpdateSavedSearch provides a mock function with given fields: ctx, search
+func (_m *DataStore) UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	ret := _m.Called(ctx, search)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SavedSearch) error); ok {
+		r0 = rf(ctx, search)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateSearchJob provides a mock function with given fields: ctx, job
+func (_m *DataStore) UpdateSearchJob(ctx context.Context, job *model.SearchJob) error {
+	ret := _m.Called(ctx, job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SearchJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
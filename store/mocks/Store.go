@@ -21,6 +21,8 @@ import (
 
 	model "github.com/mendersoftware/reporting/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Store is an autogenerated mock type for the Store type
@@ -74,6 +76,29 @@ func (_m *Store) AggregateDevices(ctx context.Context, query model.Query) (model
 	return r0, r1
 }
 
+// AggregateSoftware provides a mock function with given fields: ctx, query
+func (_m *Store) AggregateSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 model.M
+	if rf, ok := ret.Get(0).(func(context.Context, model.Query) model.M); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(model.M)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Query) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BulkIndexDeployments provides a mock function with given fields: ctx, deployments
 func (_m *Store) BulkIndexDeployments(ctx context.Context, deployments []*model.Deployment) error {
 	ret := _m.Called(ctx, deployments)
@@ -102,6 +127,132 @@ func (_m *Store) BulkIndexDevices(ctx context.Context, devices []*model.Device,
 	return r0
 }
 
+// BulkIndexDevicesInto provides a mock function with given fields: ctx, index, devices
+func (_m *Store) BulkIndexDevicesInto(ctx context.Context, index string, devices []*model.Device) error {
+	ret := _m.Called(ctx, index, devices)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []*model.Device) error); ok {
+		r0 = rf(ctx, index, devices)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BulkIndexSoftware provides a mock function with given fields: ctx, software, removedDevices
+func (_m *Store) BulkIndexSoftware(ctx context.Context, software []*model.DeviceSoftware, removedDevices []*model.Device) error {
+	ret := _m.Called(ctx, software, removedDevices)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.DeviceSoftware, []*model.Device) error); ok {
+		r0 = rf(ctx, software, removedDevices)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClosePointInTime provides a mock function with given fields: ctx, pitID
+func (_m *Store) ClosePointInTime(ctx context.Context, pitID string) error {
+	ret := _m.Called(ctx, pitID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, pitID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountDevices provides a mock function with given fields: ctx, query
+func (_m *Store) CountDevices(ctx context.Context, query model.Query) (int, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, model.Query) int); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Query) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateDevicesIndex provides a mock function with given fields: ctx, tid
+func (_m *Store) CreateDevicesIndex(ctx context.Context, tid string) (string, error) {
+	ret := _m.Called(ctx, tid)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, tid)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteDeploymentsBefore provides a mock function with given fields: ctx, cutoff
+func (_m *Store) DeleteDeploymentsBefore(ctx context.Context, cutoff time.Time) error {
+	ret := _m.Called(ctx, cutoff)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, cutoff)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteDevicesData provides a mock function with given fields: ctx, tenantID, deviceIDs
+func (_m *Store) DeleteDevicesData(ctx context.Context, tenantID string, deviceIDs []string) error {
+	ret := _m.Called(ctx, tenantID, deviceIDs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, tenantID, deviceIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTenantData provides a mock function with given fields: ctx, tenantID
+func (_m *Store) DeleteTenantData(ctx context.Context, tenantID string) error {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetDeploymentsIndex provides a mock function with given fields: tid
 func (_m *Store) GetDeploymentsIndex(tid string) string {
 	ret := _m.Called(tid)
@@ -204,6 +355,75 @@ func (_m *Store) GetDevicesRoutingKey(tid string) string {
 	return r0
 }
 
+// GetTenantUsage provides a mock function with given fields: ctx, tenantID
+func (_m *Store) GetTenantUsage(ctx context.Context, tenantID string) (*model.TenantUsage, error) {
+	ret := _m.Called(ctx, tenantID)
+
+	var r0 *model.TenantUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.TenantUsage); ok {
+		r0 = rf(ctx, tenantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.TenantUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tenantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDevicesIDs provides a mock function with given fields: ctx, tenantID, afterID, limit
+func (_m *Store) ListDevicesIDs(ctx context.Context, tenantID string, afterID string, limit int) ([]string, error) {
+	ret := _m.Called(ctx, tenantID, afterID, limit)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) []string); ok {
+		r0 = rf(ctx, tenantID, afterID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, tenantID, afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDevicesUpdatedAt provides a mock function with given fields: ctx, tenantID, afterID, limit
+func (_m *Store) ListDevicesUpdatedAt(ctx context.Context, tenantID string, afterID string, limit int) (map[string]time.Time, error) {
+	ret := _m.Called(ctx, tenantID, afterID, limit)
+
+	var r0 map[string]time.Time
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) map[string]time.Time); ok {
+		r0 = rf(ctx, tenantID, afterID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]time.Time)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, tenantID, afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Migrate provides a mock function with given fields: ctx
 func (_m *Store) Migrate(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -218,6 +438,41 @@ func (_m *Store) Migrate(ctx context.Context) error {
 	return r0
 }
 
+// MigrateMappings provides a mock function with given fields: ctx
+func (_m *Store) MigrateMappings(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OpenDevicesPointInTime provides a mock function with given fields: ctx, keepAlive
+func (_m *Store) OpenDevicesPointInTime(ctx context.Context, keepAlive time.Duration) (string, error) {
+	ret := _m.Called(ctx, keepAlive)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) string); ok {
+		r0 = rf(ctx, keepAlive)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, keepAlive)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Ping provides a mock function with given fields: ctx
 func (_m *Store) Ping(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -232,6 +487,57 @@ func (_m *Store) Ping(ctx context.Context) error {
 	return r0
 }
 
+// PlanMigrations provides a mock function with given fields: ctx
+func (_m *Store) PlanMigrations(ctx context.Context) ([]model.MigrationChange, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.MigrationChange
+	if rf, ok := ret.Get(0).(func(context.Context) []model.MigrationChange); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.MigrationChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemapDevicesAttribute provides a mock function with given fields: ctx, tenantID, scope, attribute, typ
+func (_m *Store) RemapDevicesAttribute(ctx context.Context, tenantID string, scope string, attribute string, typ model.Type) error {
+	ret := _m.Called(ctx, tenantID, scope, attribute, typ)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, model.Type) error); ok {
+		r0 = rf(ctx, tenantID, scope, attribute, typ)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceDevicesTags provides a mock function with given fields: ctx, devices
+func (_m *Store) ReplaceDevicesTags(ctx context.Context, devices []*model.Device) error {
+	ret := _m.Called(ctx, devices)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Device) error); ok {
+		r0 = rf(ctx, devices)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SearchDeployments provides a mock function with given fields: ctx, query
 func (_m *Store) SearchDeployments(ctx context.Context, query model.Query) (model.M, error) {
 	ret := _m.Called(ctx, query)
@@ -277,3 +583,91 @@ func (_m *Store) SearchDevices(ctx context.Context, query model.Query) (model.M,
 
 	return r0, r1
 }
+
+// SearchPointInTime provides a mock function with given fields: ctx, query
+func (_m *Store) SearchPointInTime(ctx context.Context, query model.Query) (model.M, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 model.M
+	if rf, ok := ret.Get(0).(func(context.Context, model.Query) model.M); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(model.M)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Query) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchSoftware provides a mock function with given fields: ctx, query
+func (_m *Store) SearchSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 model.M
+	if rf, ok := ret.Get(0).(func(context.Context, model.Query) model.M); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(model.M)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Query) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SwapDevicesIndex provides a mock function with given fields: ctx, tid, index
+func (_m *Store) SwapDevicesIndex(ctx context.Context, tid string, index string) error {
+	ret := _m.Called(ctx, tid, index)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, tid, index)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateIndexSettings provides a mock function with given fields: ctx, index, settings
+func (_m *Store) UpdateIndexSettings(ctx context.Context, index string, settings *model.IndexSettings) error {
+	ret := _m.Called(ctx, index, settings)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *model.IndexSettings) error); ok {
+		r0 = rf(ctx, index, settings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateDevices provides a mock function with given fields: ctx, devices
+func (_m *Store) UpdateDevices(ctx context.Context, devices []*model.Device) error {
+	ret := _m.Called(ctx, devices)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Device) error); ok {
+		r0 = rf(ctx, devices)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -19,19 +19,57 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	mopts "go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
 )
 
 const (
-	collNameMapping   = "mapping"
-	keyNameTenantID   = "tenant_id"
-	indexNameTenantID = "tenant_id_ndx"
+	collNameMapping       = "mapping"
+	collNameSavedSearches = "saved_searches"
+	collNameIndexingRules = "indexing_rules"
+	collNameReindexStates = "reindex_states"
+	collNameDeadLetters   = "dead_letters"
+	collNameAlerts        = "alerts"
+	collNameAuditLogs     = "audit_logs"
+	collNameSearchJobs    = "search_jobs"
+	collNameDecommDevices = "decommissioned_devices"
+	collNameTenantUsage   = "tenant_usage"
+	collNameAttrsUsage    = "attributes_usage"
+	keyNameID             = "_id"
+	keyNameTenantID       = "tenant_id"
+	keyNameName           = "name"
+	keyNameReport         = "report"
+	keyNameReportLastRun  = "report.last_run_ts"
+	keyNameCreatedTs      = "created_ts"
+	keyNameState          = "state"
+	keyNameStateEvaluated = "state.evaluated_ts"
+	keyNameSubject        = "subject"
+	keyNameExpireTs       = "expire_ts"
+	keyNameDeviceID       = "device_id"
+	keyNamePurgeTs        = "purge_ts"
+	keyNameMonth          = "month"
+	keyNameQueries        = "queries"
+	keyNameScope          = "scope"
+	keyNameFilters        = "filters"
+	keyNameSorts          = "sorts"
+	keyNameAggregations   = "aggregations"
+	keyNameLastUsedTs     = "last_used_ts"
+	indexNameTenantID     = "tenant_id_ndx"
+	indexNameTenantIDName = "tenant_id_name_ndx"
+	indexNameTenantIDTs   = "tenant_id_created_ts_ndx"
+	indexNameExpireTs     = "expire_ts_ndx"
+	indexNameTenantDevice = "tenant_id_device_id_ndx"
+	indexNamePurgeTs      = "purge_ts_ndx"
+	indexNameTenantMonth  = "tenant_id_month_ndx"
+	indexNameTenantAttr   = "tenant_id_scope_name_ndx"
 )
 
 type MongoStoreConfig struct {
@@ -161,6 +199,24 @@ func (db *MongoStore) GetMapping(ctx context.Context, tenantID string) (*model.M
 	return mapping, nil
 }
 
+// GetTenantIDs returns the IDs of the tenants with a mapping
+func (db *MongoStore) GetTenantIDs(ctx context.Context) ([]string, error) {
+	values, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameMapping).
+		Distinct(ctx, keyNameTenantID, bson.M{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the tenant IDs")
+	}
+	tenantIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if tenantID, ok := value.(string); ok {
+			tenantIDs = append(tenantIDs, tenantID)
+		}
+	}
+	return tenantIDs, nil
+}
+
 // UpdateAndGetMapping updates the mapping and returns it
 func (db *MongoStore) UpdateAndGetMapping(ctx context.Context, tenantID string,
 	inventory []string) (*model.Mapping, error) {
@@ -183,6 +239,7 @@ func (db *MongoStore) UpdateAndGetMapping(ctx context.Context, tenantID string,
 		"inventory": bson.M{
 			"$slice": model.MaxMappingInventoryAttributes,
 		},
+		"types": 1,
 	}
 	opts := mopts.FindOneAndUpdate().
 		SetReturnDocument(mopts.After).
@@ -205,8 +262,1112 @@ func (db *MongoStore) UpdateAndGetMapping(ctx context.Context, tenantID string,
 			).
 			Decode(mapping)
 	}
+	if err == nil {
+		err = db.reuseEvictedSlots(ctx, mapping, inventory)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to update and get the mapping")
 	}
 	return mapping, nil
 }
+
+// reuseEvictedSlots maps the attributes which are missing from the mapping,
+// because it is full, to the slots left empty by the evicted attributes
+func (db *MongoStore) reuseEvictedSlots(ctx context.Context, mapping *model.Mapping,
+	inventory []string) error {
+	mapped := make(map[string]bool, len(mapping.Inventory))
+	for _, attr := range mapping.Inventory {
+		mapped[attr] = true
+	}
+	missing := make([]string, 0, len(inventory))
+	for _, attr := range inventory {
+		if !mapped[attr] {
+			missing = append(missing, attr)
+		}
+	}
+	if len(missing) == 0 || !mapped[""] {
+		return nil
+	}
+
+	collection := db.client.
+		Database(db.config.DbName).
+		Collection(collNameMapping)
+	// drop the attributes beyond the limit, which were never mapped, for
+	// them not to be taken for already mapped ones
+	inventoryOverflowField := fmt.Sprintf("inventory.%d", model.MaxMappingInventoryAttributes)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{
+			keyNameTenantID:        mapping.TenantID,
+			inventoryOverflowField: bson.M{"$exists": true},
+		},
+		bson.M{
+			"$push": bson.M{
+				"inventory": bson.M{
+					"$each":  []string{},
+					"$slice": model.MaxMappingInventoryAttributes,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	slot := 0
+	for _, attr := range missing {
+		for slot < len(mapping.Inventory) && mapping.Inventory[slot] != "" {
+			slot++
+		}
+		if slot == len(mapping.Inventory) {
+			break
+		}
+		// the slot may be taken, or the attribute mapped, concurrently
+		slotField := fmt.Sprintf("inventory.%d", slot)
+		res, err := collection.UpdateOne(ctx,
+			bson.M{
+				keyNameTenantID: mapping.TenantID,
+				slotField:       "",
+				"inventory":     bson.M{"$ne": attr},
+			},
+			bson.M{"$set": bson.M{slotField: attr}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.ModifiedCount == 0 {
+			break
+		}
+		mapping.Inventory[slot] = attr
+	}
+
+	res := collection.FindOne(ctx,
+		bson.M{keyNameTenantID: mapping.TenantID},
+		mopts.FindOne().SetProjection(bson.M{
+			"tenant_id": 1,
+			"inventory": bson.M{
+				"$slice": model.MaxMappingInventoryAttributes,
+			},
+			"types": 1,
+		}),
+	)
+	return res.Decode(mapping)
+}
+
+// EvictMappingAttributes removes the attributes from the tenant's mapping,
+// leaving their slots empty for the following attributes to keep their
+// fields; the empty slots are reused by the attributes mapped afterwards
+func (db *MongoStore) EvictMappingAttributes(ctx context.Context, tenantID string,
+	attributes []string) error {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"inventory.$[attr]": "",
+		},
+	}
+	opts := mopts.Update().SetArrayFilters(mopts.ArrayFilters{
+		Filters: []interface{}{
+			bson.M{"attr": bson.M{"$in": attributes}},
+		},
+	})
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameMapping).
+		UpdateOne(ctx, query, update, opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to evict the attributes from the mapping")
+	}
+	return nil
+}
+
+// SetMappingTypes replaces the types the tenant's attributes are declared
+// to be indexed as, and adds the attributes whose type changed to the
+// pending remaps
+func (db *MongoStore) SetMappingTypes(ctx context.Context, tenantID string,
+	types []model.MappingAttributeType, remaps []string) error {
+	if types == nil {
+		types = []model.MappingAttributeType{}
+	}
+	if remaps == nil {
+		remaps = []string{}
+	}
+	query := bson.M{
+		keyNameTenantID: tenantID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"types": types,
+		},
+		"$addToSet": bson.M{
+			"remaps": bson.M{
+				"$each": remaps,
+			},
+		},
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameMapping).
+		UpdateOne(ctx, query, update, mopts.Update().SetUpsert(true))
+	if err != nil {
+		return errors.Wrap(err, "failed to set the types of the mapping")
+	}
+	return nil
+}
+
+// GetMappingsToRemap returns the mappings with pending remaps
+func (db *MongoStore) GetMappingsToRemap(ctx context.Context) ([]model.Mapping, error) {
+	query := bson.M{
+		"remaps.0": bson.M{
+			"$exists": true,
+		},
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{{Key: keyNameTenantID, Value: 1}})
+	cur, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameMapping).
+		Find(ctx, query, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the mappings to remap")
+	}
+	mappings := []model.Mapping{}
+	if err := cur.All(ctx, &mappings); err != nil {
+		return nil, errors.Wrap(err, "failed to get the mappings to remap")
+	}
+	return mappings, nil
+}
+
+// CompleteMappingRemap removes the attribute from the pending remaps of
+// the tenant's mapping
+func (db *MongoStore) CompleteMappingRemap(ctx context.Context, tenantID,
+	attribute string) error {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"remaps": attribute,
+		},
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameMapping).
+		UpdateOne(ctx, query, update)
+	if err != nil {
+		return errors.Wrap(err, "failed to complete the remap of the mapping")
+	}
+	return nil
+}
+
+// InsertSavedSearch inserts a new saved search
+func (db *MongoStore) InsertSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		InsertOne(ctx, search)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrSavedSearchNameConflict
+	} else if err != nil {
+		return errors.Wrap(err, "failed to insert the saved search")
+	}
+	return nil
+}
+
+// GetSavedSearches returns the saved searches of the tenant, sorted by name
+func (db *MongoStore) GetSavedSearches(
+	ctx context.Context,
+	tenantID string,
+) ([]model.SavedSearch, error) {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{{Key: keyNameName, Value: 1}})
+	cur, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		Find(ctx, query, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the saved searches")
+	}
+
+	searches := []model.SavedSearch{}
+	if err := cur.All(ctx, &searches); err != nil {
+		return nil, errors.Wrap(err, "failed to get the saved searches")
+	}
+	for i := range searches {
+		normalizeSavedSearch(&searches[i])
+	}
+	return searches, nil
+}
+
+// GetSavedSearch returns the saved search of the tenant with the given ID
+func (db *MongoStore) GetSavedSearch(
+	ctx context.Context,
+	tenantID, id string,
+) (*model.SavedSearch, error) {
+	query := bson.M{
+		keyNameID:       id,
+		keyNameTenantID: tenantID,
+	}
+	search := &model.SavedSearch{}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		FindOne(ctx, query).
+		Decode(search)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrSavedSearchNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get the saved search")
+	}
+	normalizeSavedSearch(search)
+	return search, nil
+}
+
+// UpdateSavedSearch replaces the definition of an existing saved search
+func (db *MongoStore) UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	query := bson.M{
+		keyNameID:       search.ID,
+		keyNameTenantID: search.TenantID,
+	}
+	set := bson.M{
+		"name":       search.Name,
+		"filters":    search.Filters,
+		"sort":       search.Sort,
+		"attributes": search.Attributes,
+		"updated_ts": search.UpdatedTs,
+	}
+	update := bson.M{
+		"$set": set,
+	}
+	if search.Report != nil {
+		set[keyNameReport] = search.Report
+	} else {
+		update["$unset"] = bson.M{
+			keyNameReport: "",
+		}
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		UpdateOne(ctx, query, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrSavedSearchNameConflict
+	} else if err != nil {
+		return errors.Wrap(err, "failed to update the saved search")
+	} else if res.MatchedCount == 0 {
+		return store.ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// DeleteSavedSearch removes the saved search of the tenant with the given ID
+func (db *MongoStore) DeleteSavedSearch(ctx context.Context, tenantID, id string) error {
+	query := bson.M{
+		keyNameID:       id,
+		keyNameTenantID: tenantID,
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		DeleteOne(ctx, query)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the saved search")
+	} else if res.DeletedCount == 0 {
+		return store.ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// GetScheduledSavedSearches returns the saved searches, of all the tenants,
+// which have a scheduled report
+func (db *MongoStore) GetScheduledSavedSearches(
+	ctx context.Context,
+) ([]model.SavedSearch, error) {
+	query := bson.M{
+		keyNameReport: bson.M{
+			"$exists": true,
+		},
+	}
+	cur, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		Find(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the scheduled saved searches")
+	}
+
+	searches := []model.SavedSearch{}
+	if err := cur.All(ctx, &searches); err != nil {
+		return nil, errors.Wrap(err, "failed to get the scheduled saved searches")
+	}
+	for i := range searches {
+		normalizeSavedSearch(&searches[i])
+	}
+	return searches, nil
+}
+
+// ClaimSavedSearchReport sets the last run time of the report of the saved
+// search, provided it did not change since the saved search was read; it
+// returns false if another worker claimed the run in the meantime
+func (db *MongoStore) ClaimSavedSearchReport(
+	ctx context.Context,
+	search *model.SavedSearch,
+	runTs time.Time,
+) (bool, error) {
+	if search.Report == nil {
+		return false, errors.New("the saved search has no report")
+	}
+	query := bson.M{
+		keyNameID:       search.ID,
+		keyNameTenantID: search.TenantID,
+	}
+	if search.Report.LastRunTs != nil {
+		query[keyNameReportLastRun] = *search.Report.LastRunTs
+	} else {
+		query[keyNameReportLastRun] = bson.M{
+			"$exists": false,
+		}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyNameReportLastRun: runTs,
+		},
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSavedSearches).
+		UpdateOne(ctx, query, update)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to claim the saved search report")
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func normalizeSavedSearch(search *model.SavedSearch) {
+	normalizeFilters(search.Filters)
+}
+
+// normalizeFilters converts the array filter values decoded from BSON
+// to plain slices, which is what the query builder expects
+func normalizeFilters(filters []model.FilterPredicate) {
+	for i := range filters {
+		if value, ok := filters[i].Value.(primitive.A); ok {
+			filters[i].Value = []interface{}(value)
+		}
+	}
+}
+
+// GetIndexingRules returns the indexing rules of the tenant; if the tenant
+// did not define any, the returned rules have no filters
+func (db *MongoStore) GetIndexingRules(
+	ctx context.Context,
+	tenantID string,
+) (*model.IndexingRules, error) {
+	rules := &model.IndexingRules{}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameIndexingRules).
+		FindOne(ctx, bson.M{keyNameID: tenantID}).
+		Decode(rules)
+	if err == mongo.ErrNoDocuments {
+		return &model.IndexingRules{
+			TenantID: tenantID,
+			Filters:  []model.FilterPredicate{},
+		}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get the indexing rules")
+	}
+	normalizeFilters(rules.Filters)
+	return rules, nil
+}
+
+// SetIndexingRules replaces the indexing rules of the tenant
+func (db *MongoStore) SetIndexingRules(ctx context.Context, rules *model.IndexingRules) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameIndexingRules).
+		ReplaceOne(ctx,
+			bson.M{keyNameID: rules.TenantID},
+			rules,
+			mopts.Replace().SetUpsert(true),
+		)
+	if err != nil {
+		return errors.Wrap(err, "failed to set the indexing rules")
+	}
+	return nil
+}
+
+// GetReindexState returns the state of the rebuild of the tenant's devices
+// index, or nil if the index was never rebuilt
+func (db *MongoStore) GetReindexState(
+	ctx context.Context,
+	tenantID string,
+) (*model.ReindexState, error) {
+	state := &model.ReindexState{}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameReindexStates).
+		FindOne(ctx, bson.M{keyNameID: tenantID}).
+		Decode(state)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get the reindex state")
+	}
+	return state, nil
+}
+
+// SaveReindexState replaces the state of the rebuild of the tenant's
+// devices index
+func (db *MongoStore) SaveReindexState(ctx context.Context, state *model.ReindexState) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameReindexStates).
+		ReplaceOne(ctx,
+			bson.M{keyNameID: state.TenantID},
+			state,
+			mopts.Replace().SetUpsert(true),
+		)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the reindex state")
+	}
+	return nil
+}
+
+// DeleteReindexState deletes the state of the rebuild of the tenant's
+// devices index: the next rebuild starts from scratch
+func (db *MongoStore) DeleteReindexState(ctx context.Context, tenantID string) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameReindexStates).
+		DeleteOne(ctx, bson.M{keyNameID: tenantID})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the reindex state")
+	}
+	return nil
+}
+
+// InsertDeadLetter stores a message the indexer failed to process
+func (db *MongoStore) InsertDeadLetter(ctx context.Context, letter *model.DeadLetter) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDeadLetters).
+		InsertOne(ctx, letter)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert the dead letter")
+	}
+	return nil
+}
+
+// GetDeadLetters returns a page of the dead letters, optionally of a single
+// tenant, sorted from the oldest, and their total count
+func (db *MongoStore) GetDeadLetters(
+	ctx context.Context,
+	params model.DeadLettersParams,
+) ([]model.DeadLetter, int, error) {
+	params.Normalize()
+	query := bson.M{}
+	if params.TenantID != "" {
+		query[keyNameTenantID] = params.TenantID
+	}
+	collection := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDeadLetters)
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to count the dead letters")
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{
+			{Key: keyNameCreatedTs, Value: 1},
+			{Key: keyNameID, Value: 1},
+		}).
+		SetSkip(int64((params.Page - 1) * params.PerPage)).
+		SetLimit(int64(params.PerPage))
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get the dead letters")
+	}
+	letters := []model.DeadLetter{}
+	if err := cur.All(ctx, &letters); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get the dead letters")
+	}
+	return letters, int(total), nil
+}
+
+// GetDeadLetter returns the dead letter with the given ID
+func (db *MongoStore) GetDeadLetter(ctx context.Context, id string) (*model.DeadLetter, error) {
+	letter := &model.DeadLetter{}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDeadLetters).
+		FindOne(ctx, bson.M{keyNameID: id}).
+		Decode(letter)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrDeadLetterNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get the dead letter")
+	}
+	return letter, nil
+}
+
+// DeleteDeadLetter removes the dead letter with the given ID
+func (db *MongoStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDeadLetters).
+		DeleteOne(ctx, bson.M{keyNameID: id})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the dead letter")
+	} else if res.DeletedCount == 0 {
+		return store.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// DeleteTenantData deletes all the data of the tenant, from all the
+// collections
+func (db *MongoStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	database := db.client.Database(db.config.DbName)
+	for collName, key := range map[string]string{
+		collNameMapping:       keyNameTenantID,
+		collNameSavedSearches: keyNameTenantID,
+		collNameDeadLetters:   keyNameTenantID,
+		collNameAlerts:        keyNameTenantID,
+		collNameAuditLogs:     keyNameTenantID,
+		collNameSearchJobs:    keyNameTenantID,
+		collNameDecommDevices: keyNameTenantID,
+		collNameTenantUsage:   keyNameTenantID,
+		collNameAttrsUsage:    keyNameTenantID,
+		collNameIndexingRules: keyNameID,
+		collNameReindexStates: keyNameID,
+	} {
+		_, err := database.Collection(collName).DeleteMany(ctx, bson.M{key: tenantID})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete the tenant's %s", collName)
+		}
+	}
+	return nil
+}
+
+// InsertAlert inserts a new alert
+func (db *MongoStore) InsertAlert(ctx context.Context, alert *model.Alert) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		InsertOne(ctx, alert)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrAlertNameConflict
+	} else if err != nil {
+		return errors.Wrap(err, "failed to insert the alert")
+	}
+	return nil
+}
+
+// GetAlerts returns the alerts of the tenant, sorted by name
+func (db *MongoStore) GetAlerts(ctx context.Context, tenantID string) ([]model.Alert, error) {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{{Key: keyNameName, Value: 1}})
+	return db.findAlerts(ctx, query, opts)
+}
+
+// GetAllAlerts returns the alerts of all the tenants
+func (db *MongoStore) GetAllAlerts(ctx context.Context) ([]model.Alert, error) {
+	return db.findAlerts(ctx, bson.M{})
+}
+
+func (db *MongoStore) findAlerts(
+	ctx context.Context,
+	query bson.M,
+	opts ...*mopts.FindOptions,
+) ([]model.Alert, error) {
+	cur, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		Find(ctx, query, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the alerts")
+	}
+
+	alerts := []model.Alert{}
+	if err := cur.All(ctx, &alerts); err != nil {
+		return nil, errors.Wrap(err, "failed to get the alerts")
+	}
+	for i := range alerts {
+		normalizeFilters(alerts[i].Filters)
+	}
+	return alerts, nil
+}
+
+// GetAlert returns the alert of the tenant with the given ID
+func (db *MongoStore) GetAlert(ctx context.Context, tenantID, id string) (*model.Alert, error) {
+	query := bson.M{
+		keyNameID:       id,
+		keyNameTenantID: tenantID,
+	}
+	alert := &model.Alert{}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		FindOne(ctx, query).
+		Decode(alert)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrAlertNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get the alert")
+	}
+	normalizeFilters(alert.Filters)
+	return alert, nil
+}
+
+// UpdateAlert replaces the definition of an existing alert and resets its
+// state; the secret of the webhook is kept if the new one is empty
+func (db *MongoStore) UpdateAlert(ctx context.Context, alert *model.Alert) error {
+	query := bson.M{
+		keyNameID:       alert.ID,
+		keyNameTenantID: alert.TenantID,
+	}
+	set := bson.M{
+		"name":        alert.Name,
+		"source":      alert.Source,
+		"filters":     alert.Filters,
+		"threshold":   alert.Threshold,
+		"webhook.url": alert.Webhook.URL,
+		"updated_ts":  alert.UpdatedTs,
+	}
+	if alert.Webhook.Secret != "" {
+		set["webhook.secret"] = alert.Webhook.Secret
+	}
+	update := bson.M{
+		"$set": set,
+		"$unset": bson.M{
+			keyNameState: "",
+		},
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		UpdateOne(ctx, query, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrAlertNameConflict
+	} else if err != nil {
+		return errors.Wrap(err, "failed to update the alert")
+	} else if res.MatchedCount == 0 {
+		return store.ErrAlertNotFound
+	}
+	return nil
+}
+
+// DeleteAlert removes the alert of the tenant with the given ID
+func (db *MongoStore) DeleteAlert(ctx context.Context, tenantID, id string) error {
+	query := bson.M{
+		keyNameID:       id,
+		keyNameTenantID: tenantID,
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		DeleteOne(ctx, query)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the alert")
+	} else if res.DeletedCount == 0 {
+		return store.ErrAlertNotFound
+	}
+	return nil
+}
+
+// ClaimAlertEvaluation sets the evaluation time of the alert, provided it
+// did not change since the alert was read; it returns false if another
+// worker claimed the evaluation, or the alert was updated, in the meantime
+func (db *MongoStore) ClaimAlertEvaluation(
+	ctx context.Context,
+	alert *model.Alert,
+	evaluatedTs time.Time,
+) (bool, error) {
+	query := bson.M{
+		keyNameID:       alert.ID,
+		keyNameTenantID: alert.TenantID,
+	}
+	if alert.State != nil && alert.State.EvaluatedTs != nil {
+		query[keyNameStateEvaluated] = *alert.State.EvaluatedTs
+	} else {
+		query[keyNameStateEvaluated] = bson.M{
+			"$exists": false,
+		}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyNameStateEvaluated: evaluatedTs,
+		},
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		UpdateOne(ctx, query, update)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to claim the alert evaluation")
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// SetAlertState sets the state of the alert after an evaluation, provided
+// the evaluation was not claimed again, nor the alert updated, in the
+// meantime
+func (db *MongoStore) SetAlertState(
+	ctx context.Context,
+	alert *model.Alert,
+	state *model.AlertState,
+) error {
+	if state.EvaluatedTs == nil {
+		return errors.New("the alert state has no evaluation time")
+	}
+	query := bson.M{
+		keyNameID:             alert.ID,
+		keyNameTenantID:       alert.TenantID,
+		keyNameStateEvaluated: *state.EvaluatedTs,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			keyNameState: state,
+		},
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAlerts).
+		UpdateOne(ctx, query, update)
+	return errors.Wrap(err, "failed to set the alert state")
+}
+
+// InsertAuditLogEntry stores an entry of the audit trail
+func (db *MongoStore) InsertAuditLogEntry(ctx context.Context, entry *model.AuditLogEntry) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAuditLogs).
+		InsertOne(ctx, entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert the audit log entry")
+	}
+	return nil
+}
+
+// GetAuditLogs returns a page of the audit trail, optionally filtered by
+// tenant, subject and time range, sorted from the newest, and its total
+// count
+func (db *MongoStore) GetAuditLogs(
+	ctx context.Context,
+	params model.AuditLogsParams,
+) ([]model.AuditLogEntry, int, error) {
+	params.Normalize()
+	query := bson.M{}
+	if params.TenantID != "" {
+		query[keyNameTenantID] = params.TenantID
+	}
+	if params.Subject != "" {
+		query[keyNameSubject] = params.Subject
+	}
+	if !params.From.IsZero() || !params.To.IsZero() {
+		createdTs := bson.M{}
+		if !params.From.IsZero() {
+			createdTs["$gte"] = params.From
+		}
+		if !params.To.IsZero() {
+			createdTs["$lte"] = params.To
+		}
+		query[keyNameCreatedTs] = createdTs
+	}
+	collection := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAuditLogs)
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to count the audit log entries")
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{
+			{Key: keyNameCreatedTs, Value: -1},
+			{Key: keyNameID, Value: -1},
+		}).
+		SetSkip(int64((params.Page - 1) * params.PerPage)).
+		SetLimit(int64(params.PerPage))
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get the audit log entries")
+	}
+	entries := []model.AuditLogEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get the audit log entries")
+	}
+	return entries, int(total), nil
+}
+
+// InsertSearchJob inserts a new search job
+func (db *MongoStore) InsertSearchJob(ctx context.Context, job *model.SearchJob) error {
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSearchJobs).
+		InsertOne(ctx, job)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert the search job")
+	}
+	return nil
+}
+
+// GetSearchJob returns the search job of the tenant with the given ID; the
+// expired jobs not deleted yet are not found
+func (db *MongoStore) GetSearchJob(
+	ctx context.Context,
+	tenantID, id string,
+) (*model.SearchJob, error) {
+	query := bson.M{
+		keyNameID:       id,
+		keyNameTenantID: tenantID,
+		keyNameExpireTs: bson.M{
+			"$gt": time.Now(),
+		},
+	}
+	job := &model.SearchJob{}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSearchJobs).
+		FindOne(ctx, query).
+		Decode(job)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrSearchJobNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get the search job")
+	}
+	return job, nil
+}
+
+// UpdateSearchJob replaces the status and the results of the search job
+func (db *MongoStore) UpdateSearchJob(ctx context.Context, job *model.SearchJob) error {
+	query := bson.M{
+		keyNameID:       job.ID,
+		keyNameTenantID: job.TenantID,
+	}
+	res, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameSearchJobs).
+		ReplaceOne(ctx, query, job)
+	if err != nil {
+		return errors.Wrap(err, "failed to update the search job")
+	} else if res.MatchedCount == 0 {
+		return store.ErrSearchJobNotFound
+	}
+	return nil
+}
+
+// InsertDecommissionedDevices records the decommissioned devices whose
+// deployments history is purged later; the devices already recorded keep
+// their purge time
+func (db *MongoStore) InsertDecommissionedDevices(
+	ctx context.Context,
+	devices []model.DecommissionedDevice,
+) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(devices))
+	for _, device := range devices {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				keyNameTenantID: device.TenantID,
+				keyNameDeviceID: device.DeviceID,
+			}).
+			SetUpdate(bson.M{"$setOnInsert": device}).
+			SetUpsert(true))
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDecommDevices).
+		BulkWrite(ctx, models, mopts.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errors.Wrap(err, "failed to insert the decommissioned devices")
+	}
+	return nil
+}
+
+// GetDecommissionedDevicesToPurge returns up to limit decommissioned
+// devices of all the tenants to purge before the given time, the earliest
+// first
+func (db *MongoStore) GetDecommissionedDevicesToPurge(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]model.DecommissionedDevice, error) {
+	query := bson.M{
+		keyNamePurgeTs: bson.M{
+			"$lte": before,
+		},
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{{Key: keyNamePurgeTs, Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDecommDevices).
+		Find(ctx, query, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the decommissioned devices")
+	}
+	devices := []model.DecommissionedDevice{}
+	if err := cur.All(ctx, &devices); err != nil {
+		return nil, errors.Wrap(err, "failed to get the decommissioned devices")
+	}
+	return devices, nil
+}
+
+// DeleteDecommissionedDevices deletes the records of the tenant's
+// decommissioned devices, once purged
+func (db *MongoStore) DeleteDecommissionedDevices(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+		keyNameDeviceID: bson.M{
+			"$in": deviceIDs,
+		},
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameDecommDevices).
+		DeleteMany(ctx, query)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the decommissioned devices")
+	}
+	return nil
+}
+
+// IncrementTenantQueries adds the numbers of queries of the tenants to
+// their counts of the month
+func (db *MongoStore) IncrementTenantQueries(
+	ctx context.Context,
+	month string,
+	queries map[string]int64,
+) error {
+	if len(queries) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(queries))
+	for tenantID, count := range queries {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				keyNameTenantID: tenantID,
+				keyNameMonth:    month,
+			}).
+			SetUpdate(bson.M{"$inc": bson.M{keyNameQueries: count}}).
+			SetUpsert(true))
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameTenantUsage).
+		BulkWrite(ctx, models, mopts.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errors.Wrap(err, "failed to increment the queries of the tenants")
+	}
+	return nil
+}
+
+// GetTenantQueries returns the number of queries of the tenant in the
+// month; zero if the tenant made none
+func (db *MongoStore) GetTenantQueries(
+	ctx context.Context,
+	tenantID, month string,
+) (int64, error) {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+		keyNameMonth:    month,
+	}
+	var usage struct {
+		Queries int64 `bson:"queries"`
+	}
+	err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameTenantUsage).
+		FindOne(ctx, query).
+		Decode(&usage)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.Wrap(err, "failed to get the queries of the tenant")
+	}
+	return usage.Queries, nil
+}
+
+// IncrementAttributesUsage adds the uses of the attributes of the tenants
+// to their counts, keeping the latest time they were used at
+func (db *MongoStore) IncrementAttributesUsage(
+	ctx context.Context,
+	usage map[string][]model.AttributeUsage,
+) error {
+	models := []mongo.WriteModel{}
+	for tenantID, attributes := range usage {
+		for _, attr := range attributes {
+			update := bson.M{
+				"$inc": bson.M{
+					keyNameFilters:      attr.Filters,
+					keyNameSorts:        attr.Sorts,
+					keyNameAggregations: attr.Aggregations,
+				},
+			}
+			if attr.LastUsedTs != nil {
+				update["$max"] = bson.M{keyNameLastUsedTs: *attr.LastUsedTs}
+			}
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{
+					keyNameTenantID: tenantID,
+					keyNameScope:    attr.Scope,
+					keyNameName:     attr.Name,
+				}).
+				SetUpdate(update).
+				SetUpsert(true))
+		}
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	_, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAttrsUsage).
+		BulkWrite(ctx, models, mopts.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errors.Wrap(err, "failed to increment the usage of the attributes")
+	}
+	return nil
+}
+
+// GetAttributesUsage returns the usage of the tenant's attributes, sorted
+// by scope and name
+func (db *MongoStore) GetAttributesUsage(
+	ctx context.Context,
+	tenantID string,
+) ([]model.AttributeUsage, error) {
+	query := bson.M{
+		keyNameTenantID: tenantID,
+	}
+	opts := mopts.Find().
+		SetSort(bson.D{
+			{Key: keyNameScope, Value: 1},
+			{Key: keyNameName, Value: 1},
+		})
+	cur, err := db.client.
+		Database(db.config.DbName).
+		Collection(collNameAttrsUsage).
+		Find(ctx, query, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the usage of the attributes")
+	}
+	usage := []model.AttributeUsage{}
+	if err := cur.All(ctx, &usage); err != nil {
+		return nil, errors.Wrap(err, "failed to get the usage of the attributes")
+	}
+	return usage, nil
+}
@@ -21,7 +21,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mendersoftware/reporting/client/inventory"
 	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -222,3 +224,697 @@ func TestUpdateAndGetMapping(t *testing.T) {
 	assert.Equal(t, tenantID, mapping.TenantID)
 	assert.Len(t, mapping.Inventory, 3+model.MaxMappingInventoryAttributes)
 }
+
+func TestEvictMappingAttributes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestEvictMappingAttributes in short mode.")
+	}
+	ds := GetTestDataStore(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	// apply migrations to add the indexes
+	ds.MigrateLatest(ctx)
+
+	tenantID := "tenant"
+
+	// fill up the mapping, beyond the limit
+	attributes := make([]string, model.MaxMappingInventoryAttributes+1)
+	for i := range attributes {
+		attributes[i] = fmt.Sprintf("e%d", i)
+	}
+	mapping, err := ds.UpdateAndGetMapping(ctx, tenantID, attributes)
+	assert.NoError(t, err)
+	assert.Len(t, mapping.Inventory, model.MaxMappingInventoryAttributes)
+
+	// evict e1 and e2, the following attributes keep their slot
+	err = ds.EvictMappingAttributes(ctx, tenantID, []string{"e1", "e2", "unknown"})
+	assert.NoError(t, err)
+
+	mapping, err = ds.GetMapping(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, "e0", mapping.Inventory[0])
+	assert.Equal(t, "", mapping.Inventory[1])
+	assert.Equal(t, "", mapping.Inventory[2])
+	assert.Equal(t, "e3", mapping.Inventory[3])
+	assert.Equal(t, model.MaxMappingInventoryAttributes-2, mapping.Count())
+
+	// the attribute beyond the limit and a new one reuse the empty slots
+	mapping, err = ds.UpdateAndGetMapping(ctx, tenantID, []string{"e0", "d1", "e100"})
+	assert.NoError(t, err)
+	assert.Len(t, mapping.Inventory, model.MaxMappingInventoryAttributes)
+	assert.Equal(t, "d1", mapping.Inventory[1])
+	assert.Equal(t, "e100", mapping.Inventory[2])
+	assert.Equal(t, model.MaxMappingInventoryAttributes, mapping.Count())
+
+	// no more empty slots
+	mappingAfter, err := ds.UpdateAndGetMapping(ctx, tenantID, []string{"d2"})
+	assert.NoError(t, err)
+	assert.Equal(t, mapping, mappingAfter)
+}
+
+func TestMappingTypes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestMappingTypes in short mode.")
+	}
+	ds := GetTestDataStore(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	// apply migrations to add the indexes
+	ds.MigrateLatest(ctx)
+
+	tenantID := "tenant"
+	_, err := ds.UpdateAndGetMapping(ctx, tenantID, []string{"inventory/uptime"})
+	assert.NoError(t, err)
+
+	types := []model.MappingAttributeType{{
+		Scope: model.ScopeInventory,
+		Name:  "uptime",
+		Type:  model.AttributeTypeNumber,
+	}}
+	err = ds.SetMappingTypes(ctx, tenantID, types, []string{"inventory/uptime"})
+	assert.NoError(t, err)
+
+	// the types are returned with the updated mapping, for the mapper
+	mapping, err := ds.UpdateAndGetMapping(ctx, tenantID, []string{"inventory/built"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"inventory/uptime", "inventory/built"}, mapping.Inventory)
+	assert.Equal(t, types, mapping.Types)
+
+	mappings, err := ds.GetMappingsToRemap(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, mappings, 1) {
+		assert.Equal(t, tenantID, mappings[0].TenantID)
+		assert.Equal(t, []string{"inventory/uptime"}, mappings[0].Remaps)
+	}
+
+	err = ds.CompleteMappingRemap(ctx, tenantID, "inventory/uptime")
+	assert.NoError(t, err)
+	mappings, err = ds.GetMappingsToRemap(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, mappings, 0)
+
+	mapping, err = ds.GetMapping(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, types, mapping.Types)
+}
+
+func TestSavedSearches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestSavedSearches in short mode.")
+	}
+	ds := GetTestDataStore(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	// apply migrations to add the indexes
+	ds.MigrateLatest(ctx)
+
+	tenantID := "tenant"
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	search := &model.SavedSearch{
+		ID:       "a3c6f3e4-5c4a-4d2e-8d4b-2e2c0c6d8a3b",
+		TenantID: tenantID,
+		Name:     "production",
+		Filters: []model.FilterPredicate{{
+			Scope:     model.ScopeSystem,
+			Attribute: model.AttrNameGroup,
+			Type:      "$in",
+			Value:     []interface{}{"prod-eu", "prod-us"},
+		}},
+		Sort: []model.SortCriteria{{
+			Scope:     model.ScopeInventory,
+			Attribute: "artifact_name",
+			Order:     model.SortOrderAsc,
+		}},
+		Attributes: []model.SelectAttribute{{
+			Scope:     model.ScopeInventory,
+			Attribute: "artifact_name",
+		}},
+		CreatedTs: now,
+		UpdatedTs: now,
+	}
+
+	err := ds.InsertSavedSearch(ctx, search)
+	assert.NoError(t, err)
+
+	// the name must be unique per tenant
+	duplicate := *search
+	duplicate.ID = "b0d3f0d6-0e0c-4f8e-9c39-4d8e2a3c6f10"
+	err = ds.InsertSavedSearch(ctx, &duplicate)
+	assert.Equal(t, store.ErrSavedSearchNameConflict, err)
+
+	res, err := ds.GetSavedSearch(ctx, tenantID, search.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, search, res)
+
+	_, err = ds.GetSavedSearch(ctx, "another-tenant", search.ID)
+	assert.Equal(t, store.ErrSavedSearchNotFound, err)
+
+	searches, err := ds.GetSavedSearches(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.SavedSearch{*search}, searches)
+
+	search.Name = "staging"
+	search.UpdatedTs = now.Add(time.Minute)
+	err = ds.UpdateSavedSearch(ctx, search)
+	assert.NoError(t, err)
+
+	res, err = ds.GetSavedSearch(ctx, tenantID, search.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, search, res)
+
+	err = ds.DeleteSavedSearch(ctx, tenantID, search.ID)
+	assert.NoError(t, err)
+
+	err = ds.DeleteSavedSearch(ctx, tenantID, search.ID)
+	assert.Equal(t, store.ErrSavedSearchNotFound, err)
+
+	err = ds.UpdateSavedSearch(ctx, search)
+	assert.Equal(t, store.ErrSavedSearchNotFound, err)
+}
+
+func TestAlerts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAlerts in short mode.")
+	}
+	ds := GetTestDataStore(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	// apply migrations to add the indexes
+	ds.MigrateLatest(ctx)
+
+	tenantID := "tenant"
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	alert := &model.Alert{
+		ID:       "0f1f5a0e-61a9-4d6c-9c1d-5f0c0b7e3a41",
+		TenantID: tenantID,
+		Name:     "failed deployments",
+		Source:   model.AlertSourceDeployments,
+		Filters: []model.FilterPredicate{{
+			Attribute: "device_status",
+			Type:      "$in",
+			Value:     []interface{}{"failure", "aborted"},
+		}},
+		Threshold: model.AlertThreshold{
+			Operator: model.AlertOperatorGreaterThan,
+			Value:    50,
+		},
+		Webhook: model.AlertWebhook{
+			URL:    "https://example.com/hook",
+			Secret: "secret",
+		},
+		CreatedTs: now,
+		UpdatedTs: now,
+	}
+
+	err := ds.InsertAlert(ctx, alert)
+	assert.NoError(t, err)
+
+	// the name must be unique per tenant
+	duplicate := *alert
+	duplicate.ID = "7a0c3a43-9c2f-4c55-a2a4-1b1d8e0f2c6e"
+	err = ds.InsertAlert(ctx, &duplicate)
+	assert.Equal(t, store.ErrAlertNameConflict, err)
+
+	res, err := ds.GetAlert(ctx, tenantID, alert.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, alert, res)
+
+	_, err = ds.GetAlert(ctx, "another-tenant", alert.ID)
+	assert.Equal(t, store.ErrAlertNotFound, err)
+
+	alerts, err := ds.GetAlerts(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Alert{*alert}, alerts)
+
+	// only one worker claims each evaluation
+	evaluatedTs := now.Add(time.Minute)
+	claimed, err := ds.ClaimAlertEvaluation(ctx, alert, evaluatedTs)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = ds.ClaimAlertEvaluation(ctx, alert, evaluatedTs)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	state := &model.AlertState{
+		Triggered:   true,
+		Count:       51,
+		EvaluatedTs: &evaluatedTs,
+		TriggeredTs: &evaluatedTs,
+	}
+	err = ds.SetAlertState(ctx, alert, state)
+	assert.NoError(t, err)
+
+	alerts, err = ds.GetAllAlerts(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, state, alerts[0].State)
+	}
+
+	// updating the alert resets its state and keeps the webhook secret
+	alert.Name = "aborted deployments"
+	alert.Webhook.Secret = ""
+	alert.UpdatedTs = now.Add(2 * time.Minute)
+	err = ds.UpdateAlert(ctx, alert)
+	assert.NoError(t, err)
+
+	res, err = ds.GetAlert(ctx, tenantID, alert.ID)
+	assert.NoError(t, err)
+	alert.Webhook.Secret = "secret"
+	assert.Equal(t, alert, res)
+
+	err = ds.DeleteAlert(ctx, tenantID, alert.ID)
+	assert.NoError(t, err)
+
+	err = ds.DeleteAlert(ctx, tenantID, alert.ID)
+	assert.Equal(t, store.ErrAlertNotFound, err)
+
+	err = ds.UpdateAlert(ctx, alert)
+	assert.Equal(t, store.ErrAlertNotFound, err)
+}
+
+func TestIndexingRules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestIndexingRules in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	tenantID := "tenant"
+
+	// without rules, all the devices are indexed
+	res, err := ds.GetIndexingRules(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.IndexingRules{
+		TenantID: tenantID,
+		Filters:  []model.FilterPredicate{},
+	}, res)
+
+	rules := &model.IndexingRules{
+		TenantID: tenantID,
+		Filters: []model.FilterPredicate{{
+			Scope:     model.ScopeSystem,
+			Attribute: model.AttrNameGroup,
+			Type:      "$in",
+			Value:     []interface{}{"prod-eu", "prod-us"},
+		}},
+		UpdatedTs: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	for i := 0; i < 2; i++ {
+		err = ds.SetIndexingRules(ctx, rules)
+		assert.NoError(t, err)
+
+		res, err = ds.GetIndexingRules(ctx, tenantID)
+		assert.NoError(t, err)
+		assert.Equal(t, rules, res)
+
+		rules.Filters[0].Type = "$nin"
+	}
+}
+
+func TestReindexState(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestReindexState in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	tenantID := "tenant"
+
+	res, err := ds.GetReindexState(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	state := &model.ReindexState{
+		TenantID:     tenantID,
+		Index:        "devices-tenant-000002",
+		Page:         1,
+		LastDeviceID: "100",
+		Processed:    100,
+		Status:       model.ReindexStatusInProgress,
+		StartedTs:    now,
+		UpdatedTs:    now,
+	}
+	for i := 0; i < 2; i++ {
+		err = ds.SaveReindexState(ctx, state)
+		assert.NoError(t, err)
+
+		res, err = ds.GetReindexState(ctx, tenantID)
+		assert.NoError(t, err)
+		assert.Equal(t, state, res)
+
+		state.Status = model.ReindexStatusCompleted
+		state.CompletedTs = &now
+	}
+
+	err = ds.DeleteReindexState(ctx, tenantID)
+	assert.NoError(t, err)
+	res, err = ds.GetReindexState(ctx, tenantID)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestDeadLetters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestDeadLetters in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	letters := []model.DeadLetter{{
+		ID:         "1",
+		TenantID:   "tenant1",
+		Subject:    "WORKFLOWS.reporting",
+		Data:       `{"tenant_id":"tenant1"}`,
+		Reason:     "error",
+		Deliveries: 3,
+		CreatedTs:  now.Add(-2 * time.Minute),
+	}, {
+		ID:        "2",
+		TenantID:  "tenant2",
+		Subject:   "WORKFLOWS.reporting",
+		Data:      `{"tenant_id":"tenant2"}`,
+		CreatedTs: now.Add(-time.Minute),
+	}, {
+		ID:        "3",
+		Subject:   "WORKFLOWS.reporting",
+		Data:      `malformed`,
+		CreatedTs: now,
+	}}
+	for i := range letters {
+		err := ds.InsertDeadLetter(ctx, &letters[i])
+		assert.NoError(t, err)
+	}
+
+	res, total, err := ds.GetDeadLetters(ctx, model.DeadLettersParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, letters, res)
+
+	res, total, err = ds.GetDeadLetters(ctx, model.DeadLettersParams{Page: 2, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, letters[2:], res)
+
+	res, total, err = ds.GetDeadLetters(ctx, model.DeadLettersParams{TenantID: "tenant2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, letters[1:2], res)
+
+	letter, err := ds.GetDeadLetter(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, &letters[0], letter)
+
+	err = ds.DeleteDeadLetter(ctx, "1")
+	assert.NoError(t, err)
+	_, err = ds.GetDeadLetter(ctx, "1")
+	assert.Equal(t, store.ErrDeadLetterNotFound, err)
+	err = ds.DeleteDeadLetter(ctx, "1")
+	assert.Equal(t, store.ErrDeadLetterNotFound, err)
+}
+
+func TestAuditLogs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAuditLogs in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	entries := []model.AuditLogEntry{{
+		ID:        "1",
+		TenantID:  "tenant1",
+		Subject:   "user1",
+		Method:    "POST",
+		Path:      "/api/management/v1/reporting/devices/search",
+		Body:      `{"page":1}`,
+		Status:    200,
+		CreatedTs: now.Add(-2 * time.Minute),
+	}, {
+		ID:        "2",
+		TenantID:  "tenant1",
+		Subject:   "user2",
+		Method:    "GET",
+		Path:      "/api/management/v1/reporting/devices/attributes",
+		Status:    200,
+		CreatedTs: now.Add(-time.Minute),
+	}, {
+		ID:        "3",
+		TenantID:  "tenant2",
+		Subject:   "user3",
+		Method:    "GET",
+		Path:      "/api/management/v1/reporting/devices/saved-searches",
+		Query:     "page=2",
+		Status:    200,
+		CreatedTs: now,
+	}}
+	for i := range entries {
+		err := ds.InsertAuditLogEntry(ctx, &entries[i])
+		assert.NoError(t, err)
+	}
+
+	res, total, err := ds.GetAuditLogs(ctx, model.AuditLogsParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []model.AuditLogEntry{entries[2], entries[1], entries[0]}, res)
+
+	res, total, err = ds.GetAuditLogs(ctx, model.AuditLogsParams{Page: 2, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, entries[:1], res)
+
+	res, total, err = ds.GetAuditLogs(ctx, model.AuditLogsParams{
+		TenantID: "tenant1",
+		Subject:  "user1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, entries[:1], res)
+
+	res, total, err = ds.GetAuditLogs(ctx, model.AuditLogsParams{
+		From: now.Add(-90 * time.Second),
+		To:   now.Add(-30 * time.Second),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, entries[1:2], res)
+}
+
+func TestSearchJobs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestSearchJobs in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	job := &model.SearchJob{
+		ID:        "1",
+		TenantID:  "tenant1",
+		Status:    model.SearchJobStatusPending,
+		CreatedTs: now,
+		ExpireTs:  now.Add(time.Hour),
+	}
+	err := ds.InsertSearchJob(ctx, job)
+	assert.NoError(t, err)
+	expired := &model.SearchJob{
+		ID:        "2",
+		TenantID:  "tenant1",
+		Status:    model.SearchJobStatusDone,
+		CreatedTs: now.Add(-2 * time.Hour),
+		ExpireTs:  now.Add(-time.Hour),
+	}
+	err = ds.InsertSearchJob(ctx, expired)
+	assert.NoError(t, err)
+
+	res, err := ds.GetSearchJob(ctx, "tenant1", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, job, res)
+
+	_, err = ds.GetSearchJob(ctx, "tenant2", "1")
+	assert.Equal(t, store.ErrSearchJobNotFound, err)
+	_, err = ds.GetSearchJob(ctx, "tenant1", "2")
+	assert.Equal(t, store.ErrSearchJobNotFound, err)
+
+	total := 1
+	job.Status = model.SearchJobStatusDone
+	job.Devices = []inventory.Device{{
+		ID: "device1",
+		Attributes: inventory.DeviceAttributes{{
+			Name:  "hostname",
+			Scope: inventory.AttrScopeInventory,
+			Value: "edge-gw-1",
+		}},
+	}}
+	job.Total = &total
+	job.FinishedTs = &now
+	err = ds.UpdateSearchJob(ctx, job)
+	assert.NoError(t, err)
+
+	res, err = ds.GetSearchJob(ctx, "tenant1", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, job, res)
+
+	err = ds.UpdateSearchJob(ctx, &model.SearchJob{ID: "3", TenantID: "tenant1"})
+	assert.Equal(t, store.ErrSearchJobNotFound, err)
+}
+
+func TestDecommissionedDevices(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestDecommissionedDevices in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	devices := []model.DecommissionedDevice{{
+		TenantID:         "tenant1",
+		DeviceID:         "device1",
+		DecommissionedTs: now.Add(-2 * time.Hour),
+		PurgeTs:          now.Add(-time.Hour),
+	}, {
+		TenantID:         "tenant2",
+		DeviceID:         "device1",
+		DecommissionedTs: now.Add(-time.Hour),
+		PurgeTs:          now.Add(-time.Minute),
+	}, {
+		TenantID:         "tenant1",
+		DeviceID:         "device2",
+		DecommissionedTs: now,
+		PurgeTs:          now.Add(time.Hour),
+	}}
+	err := ds.InsertDecommissionedDevices(ctx, devices)
+	assert.NoError(t, err)
+
+	// decommissioned again: the purge time is kept
+	err = ds.InsertDecommissionedDevices(ctx, []model.DecommissionedDevice{{
+		TenantID:         "tenant1",
+		DeviceID:         "device1",
+		DecommissionedTs: now,
+		PurgeTs:          now.Add(time.Hour),
+	}})
+	assert.NoError(t, err)
+
+	res, err := ds.GetDecommissionedDevicesToPurge(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, devices[:2], res)
+
+	res, err = ds.GetDecommissionedDevicesToPurge(ctx, now, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, devices[:1], res)
+
+	err = ds.DeleteDecommissionedDevices(ctx, "tenant1", []string{"device1"})
+	assert.NoError(t, err)
+
+	res, err = ds.GetDecommissionedDevicesToPurge(ctx, now.Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, devices[1:], res)
+}
+
+func TestTenantQueries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestTenantQueries in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	queries, err := ds.GetTenantQueries(ctx, "tenant1", "2023-03")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), queries)
+
+	err = ds.IncrementTenantQueries(ctx, "2023-03", map[string]int64{
+		"tenant1": 3,
+		"tenant2": 1,
+	})
+	assert.NoError(t, err)
+	err = ds.IncrementTenantQueries(ctx, "2023-03", map[string]int64{
+		"tenant1": 2,
+	})
+	assert.NoError(t, err)
+	err = ds.IncrementTenantQueries(ctx, "2023-04", map[string]int64{
+		"tenant1": 7,
+	})
+	assert.NoError(t, err)
+
+	queries, err = ds.GetTenantQueries(ctx, "tenant1", "2023-03")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), queries)
+	queries, err = ds.GetTenantQueries(ctx, "tenant2", "2023-03")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), queries)
+	queries, err = ds.GetTenantQueries(ctx, "tenant1", "2023-04")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), queries)
+}
+
+func TestAttributesUsage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAttributesUsage in short mode.")
+	}
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	usage, err := ds.GetAttributesUsage(ctx, "tenant1")
+	assert.NoError(t, err)
+	assert.Empty(t, usage)
+
+	first := time.Date(2023, 3, 6, 7, 0, 0, 0, time.UTC)
+	later := first.Add(time.Hour)
+	err = ds.IncrementAttributesUsage(ctx, map[string][]model.AttributeUsage{
+		"tenant1": {
+			{Scope: model.ScopeInventory, Name: "mac", Filters: 2, LastUsedTs: &later},
+			{Scope: model.ScopeIdentity, Name: "serial", Sorts: 1, LastUsedTs: &first},
+		},
+		"tenant2": {
+			{Scope: model.ScopeInventory, Name: "mac", Aggregations: 1, LastUsedTs: &first},
+		},
+	})
+	assert.NoError(t, err)
+	err = ds.IncrementAttributesUsage(ctx, map[string][]model.AttributeUsage{
+		"tenant1": {
+			{Scope: model.ScopeInventory, Name: "mac", Filters: 1, Sorts: 1,
+				LastUsedTs: &first},
+		},
+	})
+	assert.NoError(t, err)
+
+	usage, err = ds.GetAttributesUsage(ctx, "tenant1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.AttributeUsage{
+		{Scope: model.ScopeIdentity, Name: "serial", Sorts: 1, LastUsedTs: &first},
+		{Scope: model.ScopeInventory, Name: "mac", Filters: 3, Sorts: 1, LastUsedTs: &later},
+	}, usage)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mendersoftware/go-lib-micro/mongo/migrate"
+)
+
+type migration_1_5_0 struct {
+	client *mongo.Client
+	db     string
+}
+
+// Up creates the indexes of the decommissioned devices: unique by tenant
+// and device, and by purge time for the purge job
+func (m *migration_1_5_0) Up(from migrate.Version) error {
+	ctx := context.Background()
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: keyNameTenantID, Value: 1},
+				{Key: keyNameDeviceID, Value: 1},
+			},
+			Options: options.Index().
+				SetName(indexNameTenantDevice).
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: keyNamePurgeTs, Value: 1},
+			},
+			Options: options.Index().
+				SetName(indexNamePurgeTs),
+		},
+	}
+	indexes := m.client.
+		Database(m.db).
+		Collection(collNameDecommDevices).
+		Indexes()
+
+	_, err := indexes.CreateMany(ctx, indexModels)
+	return err
+}
+
+func (m *migration_1_5_0) Version() migrate.Version {
+	return migrate.MakeVersion(1, 5, 0)
+}
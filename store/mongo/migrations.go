@@ -24,7 +24,7 @@ import (
 
 const (
 	// DbVersion is the current schema version
-	DbVersion = "1.0.0"
+	DbVersion = "1.7.0"
 
 	// DbName is the database name
 	DbName = "reporting"
@@ -52,6 +52,34 @@ func (db *MongoStore) Migrate(ctx context.Context, version string, automigrate b
 			client: db.client,
 			db:     db.config.DbName,
 		},
+		&migration_1_1_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
+		&migration_1_2_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
+		&migration_1_3_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
+		&migration_1_4_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
+		&migration_1_5_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
+		&migration_1_6_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
+		&migration_1_7_0{
+			client: db.client,
+			db:     db.config.DbName,
+		},
 	}
 	err = m.Apply(ctx, *ver, migrations)
 	if err != nil {
@@ -86,13 +86,12 @@ func TestMigrate(t *testing.T) {
 					context.Background(), ds.client, ds.config.DbName,
 				)
 				assert.NoError(t, err)
-				// We don't have any migrations (yet).
 				expectedVersion, err := migrate.NewVersion(DbVersion)
 				assert.NoError(t, err)
-				assert.Equal(t, []migrate.MigrationEntry{{
-					Version:   *expectedVersion,
-					Timestamp: migrationInfo[0].Timestamp,
-				}}, migrationInfo)
+				if assert.NotEmpty(t, migrationInfo) {
+					// migration info is sorted by version, latest first
+					assert.Equal(t, *expectedVersion, migrationInfo[0].Version)
+				}
 			}
 		})
 	}
@@ -0,0 +1,748 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	mopts "go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	collNameDevices     = "devices"
+	collNameDeployments = "deployments"
+	collNameSoftware    = "software"
+
+	indexNameDevicesTenantID     = "devices_tenant_id_ndx"
+	indexNameDeploymentsTenantID = "deployments_tenant_id_ndx"
+	indexNameSoftwareTenantID    = "software_tenant_id_ndx"
+
+	keyNameUpdatedAt     = "updated_at"
+	keyNameStatusHistory = "device_status_history"
+
+	errCodeDuplicateKey = 11000
+)
+
+// SearchStore is a store.Store storing the devices and deployments in
+// MongoDB, for small installations which do not want to run OpenSearch;
+// it translates the subset of the OpenSearch query DSL used by the
+// application, evaluating the aggregations in memory
+type SearchStore struct {
+	db *MongoStore
+}
+
+// NewSearchStore returns a store.Store backed by the given mongo store
+func NewSearchStore(db *MongoStore) *SearchStore {
+	return &SearchStore{
+		db: db,
+	}
+}
+
+func (s *SearchStore) collection(ctx context.Context, name string) *mongo.Collection {
+	return s.db.Database(ctx).Collection(name)
+}
+
+// toDocument converts v to the document stored in MongoDB, with the same
+// fields as the OpenSearch document
+func toDocument(id string, v interface{}) (bson.M, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	doc[keyNameID] = id
+	return doc, nil
+}
+
+// fromDocument converts a MongoDB document to the JSON-like representation
+// of the OpenSearch results
+func fromDocument(raw bson.Raw) (map[string]interface{}, error) {
+	b, err := bson.MarshalExtJSON(raw, false, false)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, keyNameID)
+	return doc, nil
+}
+
+func (s *SearchStore) bulkWrite(ctx context.Context, collName string,
+	models []mongo.WriteModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+	_, err := s.collection(ctx, collName).BulkWrite(ctx, models,
+		mopts.BulkWrite().SetOrdered(false))
+	return errors.Wrap(err, "failed to bulk index")
+}
+
+func (s *SearchStore) BulkIndexDeployments(ctx context.Context,
+	deployments []*model.Deployment) error {
+	models := make([]mongo.WriteModel, 0, len(deployments))
+	for _, deployment := range deployments {
+		doc, err := toDocument(deployment.ID, deployment)
+		if err != nil {
+			return err
+		}
+		transitions, ok := doc[keyNameStatusHistory]
+		if !ok {
+			transitions = bson.A{}
+		}
+		delete(doc, keyNameStatusHistory)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{keyNameID: deployment.ID}).
+			SetUpdate(statusHistoryPipeline(doc, transitions)).
+			SetUpsert(true))
+	}
+	return s.bulkWrite(ctx, collNameDeployments, models)
+}
+
+// statusHistoryPipeline returns the update replacing the deployment with
+// the document, appending the transitions of the device's status to the
+// stored history if the status changed, and dropping the oldest ones
+// beyond the maximum length
+func statusHistoryPipeline(doc bson.M, transitions interface{}) mongo.Pipeline {
+	history := "$" + keyNameStatusHistory
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			keyNameStatusHistory: bson.M{"$reduce": bson.M{
+				"input":        bson.M{"$literal": transitions},
+				"initialValue": bson.M{"$ifNull": bson.A{history, bson.A{}}},
+				"in": bson.M{"$cond": bson.A{
+					bson.M{"$eq": bson.A{
+						bson.M{"$arrayElemAt": bson.A{"$$value.status", -1}},
+						"$$this.status",
+					}},
+					"$$value",
+					bson.M{"$concatArrays": bson.A{"$$value", bson.A{"$$this"}}},
+				}},
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			keyNameStatusHistory: bson.M{
+				"$slice": bson.A{history, -model.MaxDeviceStatusHistory},
+			},
+		}}},
+		{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+			bson.M{"$literal": doc},
+			bson.M{keyNameStatusHistory: history},
+		}}}},
+	}
+}
+
+func (s *SearchStore) BulkIndexDevices(ctx context.Context, devices,
+	removedDevices []*model.Device) error {
+	models := make([]mongo.WriteModel, 0, len(devices)+len(removedDevices))
+	for _, device := range devices {
+		doc, err := toDocument(device.GetID(), device)
+		if err != nil {
+			return err
+		}
+		// the stale devices, older than the stored documents, do not match
+		// the filter and fail to be inserted with a duplicate key error
+		filter := bson.M{keyNameID: device.GetID()}
+		if updatedAt, ok := doc[keyNameUpdatedAt]; ok {
+			filter["$or"] = bson.A{
+				bson.M{keyNameUpdatedAt: bson.M{"$exists": false}},
+				bson.M{keyNameUpdatedAt: bson.M{"$lte": updatedAt}},
+			}
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(filter).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	for _, device := range removedDevices {
+		models = append(models, mongo.NewDeleteOneModel().
+			SetFilter(bson.M{keyNameID: device.GetID()}))
+	}
+	err := s.bulkWrite(ctx, collNameDevices, models)
+	if isStaleWriteError(err) {
+		return nil
+	}
+	return err
+}
+
+// isStaleWriteError returns true if all the write errors of the bulk write
+// are duplicate key errors, i.e. the documents were not replaced because
+// they are more recent
+func isStaleWriteError(err error) bool {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil ||
+		len(bwe.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bwe.WriteErrors {
+		if writeErr.Code != errCodeDuplicateKey {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SearchStore) BulkIndexSoftware(ctx context.Context,
+	software []*model.DeviceSoftware, removedDevices []*model.Device) error {
+	models := make([]mongo.WriteModel, 0, len(software)+len(removedDevices))
+	for _, item := range software {
+		doc, err := toDocument(item.ID, item)
+		if err != nil {
+			return err
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{keyNameID: item.ID}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	for _, device := range removedDevices {
+		models = append(models, mongo.NewDeleteOneModel().
+			SetFilter(bson.M{keyNameID: device.GetID()}))
+	}
+	return s.bulkWrite(ctx, collNameSoftware, models)
+}
+
+func (s *SearchStore) UpdateDevices(ctx context.Context, devices []*model.Device) error {
+	models := make([]mongo.WriteModel, 0, len(devices))
+	for _, device := range devices {
+		doc, err := toDocument(device.GetID(), device)
+		if err != nil {
+			return err
+		}
+		delete(doc, keyNameID)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{keyNameID: device.GetID()}).
+			SetUpdate(bson.M{"$set": doc}))
+	}
+	return s.bulkWrite(ctx, collNameDevices, models)
+}
+
+// ReplaceDevicesTags replaces the tags fields of the devices' documents,
+// with an update pipeline removing the existing ones first
+func (s *SearchStore) ReplaceDevicesTags(ctx context.Context, devices []*model.Device) error {
+	models := make([]mongo.WriteModel, 0, len(devices))
+	for _, device := range devices {
+		tags, err := device.TagsFields()
+		if err != nil {
+			return err
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{keyNameID: device.GetID()}).
+			SetUpdate(bson.A{
+				bson.M{"$replaceWith": bson.M{
+					"$arrayToObject": bson.M{"$filter": bson.M{
+						"input": bson.M{"$objectToArray": "$$ROOT"},
+						"cond": bson.M{"$ne": bson.A{
+							bson.M{"$substrCP": bson.A{"$$this.k", 0,
+								len(model.TagsFieldsPrefix)}},
+							model.TagsFieldsPrefix,
+						}},
+					}},
+				}},
+				bson.M{"$set": bson.M(tags)},
+			}))
+	}
+	return s.bulkWrite(ctx, collNameDevices, models)
+}
+
+func (s *SearchStore) DeleteDevicesData(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	_, err := s.collection(ctx, collNameDevices).DeleteMany(ctx, bson.M{
+		model.FieldNameTenantID: tenantID,
+		keyNameID:               bson.M{"$in": deviceIDs},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices")
+	}
+	_, err = s.collection(ctx, collNameDeployments).DeleteMany(ctx, bson.M{
+		model.FieldNameTenantID: tenantID,
+		model.FieldNameDeviceID: bson.M{"$in": deviceIDs},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices' deployments")
+	}
+	_, err = s.collection(ctx, collNameSoftware).DeleteMany(ctx, bson.M{
+		model.FieldNameTenantID: tenantID,
+		keyNameID:               bson.M{"$in": deviceIDs},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices' software")
+	}
+	return nil
+}
+
+func (s *SearchStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	filter := bson.M{model.FieldNameTenantID: tenantID}
+	if _, err := s.collection(ctx, collNameDevices).DeleteMany(ctx, filter); err != nil {
+		return errors.Wrap(err, "failed to delete the devices")
+	}
+	if _, err := s.collection(ctx, collNameDeployments).DeleteMany(ctx, filter); err != nil {
+		return errors.Wrap(err, "failed to delete the deployments")
+	}
+	if _, err := s.collection(ctx, collNameSoftware).DeleteMany(ctx, filter); err != nil {
+		return errors.Wrap(err, "failed to delete the software")
+	}
+	return nil
+}
+
+func (s *SearchStore) DeleteDeploymentsBefore(ctx context.Context, cutoff time.Time) error {
+	// the dates are stored as in the OpenSearch documents, as RFC3339
+	// strings in UTC, which sort chronologically to the second
+	_, err := s.collection(ctx, collNameDeployments).DeleteMany(ctx, bson.M{
+		"device_finished": bson.M{"$lt": cutoff.UTC().Format(time.RFC3339Nano)},
+	})
+	return errors.Wrap(err, "failed to delete the deployments")
+}
+
+func (s *SearchStore) ListDevicesIDs(
+	ctx context.Context,
+	tenantID, afterID string,
+	limit int,
+) ([]string, error) {
+	filter := bson.M{model.FieldNameTenantID: tenantID}
+	if afterID != "" {
+		filter[keyNameID] = bson.M{"$gt": afterID}
+	}
+	cur, err := s.collection(ctx, collNameDevices).Find(ctx, filter, mopts.Find().
+		SetSort(bson.M{keyNameID: 1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{keyNameID: 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the devices")
+	}
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "failed to list the devices")
+	}
+	deviceIDs := make([]string, len(docs))
+	for i, doc := range docs {
+		deviceIDs[i] = doc.ID
+	}
+	return deviceIDs, nil
+}
+
+func (s *SearchStore) ListDevicesUpdatedAt(
+	ctx context.Context,
+	tenantID, afterID string,
+	limit int,
+) (map[string]time.Time, error) {
+	filter := bson.M{model.FieldNameTenantID: tenantID}
+	if afterID != "" {
+		filter[keyNameID] = bson.M{"$gt": afterID}
+	}
+	cur, err := s.collection(ctx, collNameDevices).Find(ctx, filter, mopts.Find().
+		SetSort(bson.M{keyNameID: 1}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{keyNameID: 1, keyNameUpdatedAt: 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the devices")
+	}
+	var docs []struct {
+		ID        string `bson:"_id"`
+		UpdatedAt string `bson:"updated_at"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "failed to list the devices")
+	}
+	updatedAt := make(map[string]time.Time, len(docs))
+	for _, doc := range docs {
+		updatedAt[doc.ID], _ = time.Parse(time.RFC3339Nano, doc.UpdatedAt)
+	}
+	return updatedAt, nil
+}
+
+// GetDevicesIndex returns the collection name for the tenant tid
+func (s *SearchStore) GetDevicesIndex(tid string) string {
+	return collNameDevices
+}
+
+// GetDevicesRoutingKey returns no routing key: MongoDB does not route
+func (s *SearchStore) GetDevicesRoutingKey(tid string) string {
+	return ""
+}
+
+// GetDeploymentsIndex returns the collection name for the tenant tid
+func (s *SearchStore) GetDeploymentsIndex(tid string) string {
+	return collNameDeployments
+}
+
+// GetDeploymentsRoutingKey returns no routing key: MongoDB does not route
+func (s *SearchStore) GetDeploymentsRoutingKey(tid string) string {
+	return ""
+}
+
+// GetDevicesIndexMapping returns the fields of the tenant's devices, in the
+// format of the OpenSearch index definition
+func (s *SearchStore) GetDevicesIndexMapping(ctx context.Context,
+	tid string) (map[string]interface{}, error) {
+	return s.getMapping(ctx, collNameDevices, tid)
+}
+
+// GetDeploymentsIndexMapping returns the fields of the tenant's deployments,
+// in the format of the OpenSearch index definition
+func (s *SearchStore) GetDeploymentsIndexMapping(ctx context.Context,
+	tid string) (map[string]interface{}, error) {
+	return s.getMapping(ctx, collNameDeployments, tid)
+}
+
+func (s *SearchStore) getMapping(ctx context.Context, collName,
+	tid string) (map[string]interface{}, error) {
+	pipeline := mongo.Pipeline{}
+	if tid != "" {
+		pipeline = append(pipeline, bson.D{
+			{Key: "$match", Value: bson.M{model.FieldNameTenantID: tid}},
+		})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.M{
+			"fields": bson.M{"$objectToArray": "$$ROOT"},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$fields"}},
+		bson.D{{Key: "$group", Value: bson.M{keyNameID: "$fields.k"}}},
+	)
+	cur, err := s.collection(ctx, collName).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the fields, tid %s", tid)
+	}
+	var fields []struct {
+		Name string `bson:"_id"`
+	}
+	if err := cur.All(ctx, &fields); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the fields, tid %s", tid)
+	}
+	properties := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if field.Name != keyNameID {
+			properties[field.Name] = map[string]interface{}{"type": "keyword"}
+		}
+	}
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": properties,
+		},
+	}, nil
+}
+
+// Migrate creates the indexes of the collections
+func (s *SearchStore) Migrate(ctx context.Context) error {
+	for collName, indexName := range map[string]string{
+		collNameDevices:     indexNameDevicesTenantID,
+		collNameDeployments: indexNameDeploymentsTenantID,
+		collNameSoftware:    indexNameSoftwareTenantID,
+	} {
+		_, err := s.collection(ctx, collName).Indexes().CreateOne(ctx,
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: model.FieldNameTenantID, Value: 1},
+					{Key: model.FieldNameID, Value: 1},
+				},
+				Options: mopts.Index().SetName(indexName),
+			})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the index %s", indexName)
+		}
+	}
+	return nil
+}
+
+// MigrateMappings is a no-op: the collections have no mappings
+func (s *SearchStore) MigrateMappings(ctx context.Context) error {
+	return nil
+}
+
+// PlanMigrations returns no changes: the collections have no templates
+// nor mappings, and are migrated with the datastore
+func (s *SearchStore) PlanMigrations(ctx context.Context) ([]model.MigrationChange, error) {
+	return []model.MigrationChange{}, nil
+}
+
+func (s *SearchStore) AggregateDevices(ctx context.Context,
+	query model.Query) (model.M, error) {
+	return s.search(ctx, collNameDevices, query)
+}
+
+func (s *SearchStore) AggregateDeployments(ctx context.Context,
+	query model.Query) (model.M, error) {
+	return s.search(ctx, collNameDeployments, query)
+}
+
+func (s *SearchStore) AggregateSoftware(ctx context.Context,
+	query model.Query) (model.M, error) {
+	return s.search(ctx, collNameSoftware, query)
+}
+
+func (s *SearchStore) SearchSoftware(ctx context.Context,
+	query model.Query) (model.M, error) {
+	return s.search(ctx, collNameSoftware, query)
+}
+
+func (s *SearchStore) SearchDevices(ctx context.Context, query model.Query) (model.M, error) {
+	return s.search(ctx, collNameDevices, query)
+}
+
+func (s *SearchStore) CountDevices(ctx context.Context, query model.Query) (int, error) {
+	req, err := parseSearchRequest(query)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.collection(ctx, collNameDevices).CountDocuments(ctx, req.filter)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count the documents")
+	}
+	return int(count), nil
+}
+
+func (s *SearchStore) SearchDeployments(ctx context.Context,
+	query model.Query) (model.M, error) {
+	return s.search(ctx, collNameDeployments, query)
+}
+
+// OpenDevicesPointInTime returns a point in time ID to paginate through
+// the devices; MongoDB has no snapshots of the collections, so the results
+// may change while paginating
+func (s *SearchStore) OpenDevicesPointInTime(ctx context.Context,
+	keepAlive time.Duration) (string, error) {
+	return collNameDevices + ":" + uuid.NewString(), nil
+}
+
+func (s *SearchStore) SearchPointInTime(ctx context.Context,
+	query model.Query) (model.M, error) {
+	req, err := parseSearchRequest(query)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(req.pitID, ":", 2)
+	if len(parts) != 2 || parts[0] != collNameDevices {
+		return nil, store.ErrPointInTimeNotFound
+	}
+	res, err := s.doSearch(ctx, parts[0], req)
+	if err == nil {
+		res["pit_id"] = req.pitID
+	}
+	return res, err
+}
+
+// ClosePointInTime is a no-op: the points in time hold no resources
+func (s *SearchStore) ClosePointInTime(ctx context.Context, pitID string) error {
+	return nil
+}
+
+// CreateDevicesIndex is not supported: the devices live in a single
+// collection shared by all the tenants
+func (s *SearchStore) CreateDevicesIndex(ctx context.Context, tid string) (string, error) {
+	return "", store.ErrIndexRebuildNotSupported
+}
+
+// BulkIndexDevicesInto is not supported, see CreateDevicesIndex
+func (s *SearchStore) BulkIndexDevicesInto(ctx context.Context, index string,
+	devices []*model.Device) error {
+	return store.ErrIndexRebuildNotSupported
+}
+
+// SwapDevicesIndex is not supported, see CreateDevicesIndex
+func (s *SearchStore) SwapDevicesIndex(ctx context.Context, tid, index string) error {
+	return store.ErrIndexRebuildNotSupported
+}
+
+// RemapDevicesAttribute is not supported: the devices are reindexed to
+// convert their values to the declared types
+func (s *SearchStore) RemapDevicesAttribute(ctx context.Context,
+	tenantID, scope, attribute string, typ model.Type) error {
+	return store.ErrAttributeRemapNotSupported
+}
+
+func (s *SearchStore) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
+func (s *SearchStore) search(ctx context.Context, collName string,
+	query model.Query) (model.M, error) {
+	req, err := parseSearchRequest(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.doSearch(ctx, collName, req)
+}
+
+func (s *SearchStore) doSearch(ctx context.Context, collName string,
+	req *searchRequest) (model.M, error) {
+	collection := s.collection(ctx, collName)
+
+	total, err := collection.CountDocuments(ctx, req.filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count the documents")
+	}
+
+	hits := []interface{}{}
+	if req.size > 0 {
+		filter := req.filter
+		if req.searchAfter != nil {
+			filter = mergeAnd([]bson.M{filter, searchAfterFilter(req.sort, req.searchAfter)})
+		}
+		findOpts := mopts.Find().
+			SetSort(mongoSort(append(req.sort, sortField{field: keyNameID}))).
+			SetSkip(req.from).
+			SetLimit(req.size)
+		if len(req.fields) > 0 {
+			projection := bson.M{model.FieldNameID: 1}
+			for _, field := range req.fields {
+				projection[field] = 1
+			}
+			for _, field := range req.sort {
+				projection[field.field] = 1
+			}
+			findOpts.SetProjection(projection)
+		}
+		cur, err := collection.Find(ctx, filter, findOpts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to search the documents")
+		}
+		defer cur.Close(ctx)
+		for cur.Next(ctx) {
+			doc, err := fromDocument(cur.Current)
+			if err != nil {
+				return nil, err
+			}
+			hit := map[string]interface{}{
+				"_source": doc,
+			}
+			if len(req.sort) > 0 {
+				hit["sort"] = sortValues(doc, req.sort)
+			}
+			hits = append(hits, hit)
+		}
+		if err := cur.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to search the documents")
+		}
+	}
+
+	res := model.M{
+		"hits": map[string]interface{}{
+			"total": map[string]interface{}{
+				"value":    float64(total),
+				"relation": "eq",
+			},
+			"hits": hits,
+		},
+	}
+	if len(req.aggs) > 0 {
+		aggregations, err := s.aggregate(ctx, collection, req)
+		if err != nil {
+			return nil, err
+		}
+		res["aggregations"] = aggregations
+	}
+	return res, nil
+}
+
+// aggregate evaluates the terms aggregations in memory, reading only the
+// fields they need from the matching documents
+func (s *SearchStore) aggregate(ctx context.Context, collection *mongo.Collection,
+	req *searchRequest) (map[string]interface{}, error) {
+	projection := bson.M{}
+	for _, agg := range req.aggs {
+		for _, field := range agg.fields() {
+			projection[field] = 1
+		}
+	}
+	cur, err := collection.Find(ctx, req.filter, mopts.Find().SetProjection(projection))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate the documents")
+	}
+	defer cur.Close(ctx)
+
+	results := newAggregationResults(req.aggs)
+	for cur.Next(ctx) {
+		doc, err := fromDocument(cur.Current)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			result.add(doc)
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate the documents")
+	}
+
+	aggregations := make(map[string]interface{}, len(results))
+	for _, result := range results {
+		aggregations[result.agg.name] = result.result()
+	}
+	return aggregations, nil
+}
+
+// UpdateIndexSettings is not supported: the collections have no dynamic
+// settings
+func (s *SearchStore) UpdateIndexSettings(ctx context.Context, index string,
+	settings *model.IndexSettings) error {
+	return store.ErrIndexSettingsNotSupported
+}
+
+// GetTenantUsage returns the numbers of the tenant's documents in the
+// collections, and the space they take, estimated from the average size of
+// the documents of the collections shared by all the tenants
+func (s *SearchStore) GetTenantUsage(ctx context.Context,
+	tenantID string) (*model.TenantUsage, error) {
+	usage := &model.TenantUsage{TenantID: tenantID}
+	filter := bson.M{model.FieldNameTenantID: tenantID}
+	for collName, documents := range map[string]*int64{
+		collNameDevices:     &usage.Documents.Devices,
+		collNameDeployments: &usage.Documents.Deployments,
+		collNameSoftware:    &usage.Documents.Software,
+	} {
+		count, err := s.collection(ctx, collName).CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to count the %s", collName)
+		}
+		*documents = count
+		if count == 0 {
+			continue
+		}
+		var stats struct {
+			AvgObjSize float64 `bson:"avgObjSize"`
+		}
+		err = s.db.Database(ctx).
+			RunCommand(ctx, bson.D{{Key: "collStats", Value: collName}}).
+			Decode(&stats)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the stats of the %s", collName)
+		}
+		usage.IndexBytes += int64(stats.AvgObjSize * float64(count))
+	}
+	return usage, nil
+}
@@ -0,0 +1,682 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+const defaultSearchSize = 10
+
+// searchRequest is the subset of the OpenSearch query DSL built by the
+// model package, translated for MongoDB
+type searchRequest struct {
+	filter      bson.M
+	sort        []sortField
+	from        int64
+	size        int64
+	fields      []string
+	searchAfter []interface{}
+	pitID       string
+	aggs        []*termsAggregation
+}
+
+type sortField struct {
+	field string
+	desc  bool
+}
+
+// parseSearchRequest translates an OpenSearch query into a MongoDB
+// search request
+func parseSearchRequest(query model.Query) (*searchRequest, error) {
+	b, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, err
+	}
+
+	req := &searchRequest{
+		filter: bson.M{},
+		size:   defaultSearchSize,
+	}
+	if q, ok := body["query"].(map[string]interface{}); ok {
+		if req.filter, err = translateClause(q); err != nil {
+			return nil, err
+		}
+	}
+	if from, ok := body["from"].(float64); ok && from > 0 {
+		req.from = int64(from)
+	}
+	if size, ok := body["size"].(float64); ok && size >= 0 {
+		req.size = int64(size)
+	}
+	if sortS, ok := body["sort"].([]interface{}); ok {
+		if req.sort, err = parseSort(sortS); err != nil {
+			return nil, err
+		}
+	}
+	if fields, ok := body["fields"].([]interface{}); ok {
+		for _, field := range fields {
+			if f, ok := field.(string); ok {
+				req.fields = append(req.fields, f)
+			}
+		}
+	}
+	if searchAfter, ok := body["search_after"].([]interface{}); ok {
+		if len(searchAfter) != len(req.sort) {
+			return nil, errors.New("search_after must match the sort criteria")
+		}
+		req.searchAfter = searchAfter
+	}
+	if pit, ok := body["pit"].(map[string]interface{}); ok {
+		req.pitID, _ = pit["id"].(string)
+	}
+	if aggs, ok := body["aggs"].(map[string]interface{}); ok {
+		if req.aggs, err = parseAggregations(aggs); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// translateClause translates a query clause into a MongoDB filter
+func translateClause(clause map[string]interface{}) (bson.M, error) {
+	and := []bson.M{}
+	for op, arg := range clause {
+		argM, ok := arg.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("malformed %s clause", op)
+		}
+		var conds []bson.M
+		var err error
+		switch op {
+		case "match_all":
+		case "match", "term":
+			conds = translateTerm(argM)
+		case "terms":
+			conds = translateTerms(argM)
+		case "range":
+			conds, err = translateRange(argM)
+		case "exists":
+			conds = translateExists(argM)
+		case "regexp", "wildcard":
+			conds, err = translatePattern(op, argM)
+		case "bool":
+			var cond bson.M
+			cond, err = translateBool(argM)
+			conds = []bson.M{cond}
+		default:
+			// e.g. the free-text and geo filters
+			return nil, errors.Wrapf(store.ErrQueryNotSupported, "query clause %s", op)
+		}
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, conds...)
+	}
+	return mergeAnd(and), nil
+}
+
+func translateTerm(clause map[string]interface{}) []bson.M {
+	conds := make([]bson.M, 0, len(clause))
+	for field, value := range clause {
+		if valueM, ok := value.(map[string]interface{}); ok {
+			if value, ok = valueM["query"]; !ok {
+				value = valueM["value"]
+			}
+		}
+		conds = append(conds, bson.M{field: value})
+	}
+	return conds
+}
+
+func translateTerms(clause map[string]interface{}) []bson.M {
+	conds := make([]bson.M, 0, len(clause))
+	for field, values := range clause {
+		conds = append(conds, bson.M{field: bson.M{"$in": values}})
+	}
+	return conds
+}
+
+func translateRange(clause map[string]interface{}) ([]bson.M, error) {
+	conds := make([]bson.M, 0, len(clause))
+	for field, value := range clause {
+		valueM, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("malformed range clause")
+		}
+		cond := bson.M{}
+		for rangeOp, v := range valueM {
+			switch rangeOp {
+			case "gt", "gte", "lt", "lte":
+				cond["$"+rangeOp] = resolveDateMath(v)
+			default:
+				return nil, errors.Errorf("unsupported range operator: %s", rangeOp)
+			}
+		}
+		conds = append(conds, bson.M{field: cond})
+	}
+	return conds, nil
+}
+
+func translateExists(clause map[string]interface{}) []bson.M {
+	field, _ := clause["field"].(string)
+	return []bson.M{{field: bson.M{"$ne": nil}}}
+}
+
+// translatePattern translates the regexp and wildcard clauses
+func translatePattern(op string, clause map[string]interface{}) ([]bson.M, error) {
+	conds := make([]bson.M, 0, len(clause))
+	for field, value := range clause {
+		if valueM, ok := value.(map[string]interface{}); ok {
+			value = valueM["value"]
+		}
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("malformed %s clause", op)
+		}
+		if op == "wildcard" {
+			pattern = model.WildcardToRegexp(pattern)
+		}
+		// regular expressions match the whole value in OpenSearch
+		conds = append(conds, bson.M{field: bson.M{"$regex": "^(?:" + pattern + ")$"}})
+	}
+	return conds, nil
+}
+
+// dateMathRegexp matches the subset of the OpenSearch date math supported
+// in the range clauses and aggregations: now, optionally minus a duration
+var dateMathRegexp = regexp.MustCompile(`^now(?:-([0-9]+)([smhd]))?$`)
+
+// resolveDateMath resolves the date math expressions into timestamps, in
+// the format of the dates of the documents
+func resolveDateMath(v interface{}) interface{} {
+	expr, ok := v.(string)
+	if !ok {
+		return v
+	}
+	m := dateMathRegexp.FindStringSubmatch(expr)
+	if m == nil {
+		return v
+	}
+	ts := time.Now()
+	if m[1] != "" {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{
+			"s": time.Second,
+			"m": time.Minute,
+			"h": time.Hour,
+			"d": 24 * time.Hour,
+		}[m[2]]
+		ts = ts.Add(-time.Duration(n) * unit)
+	}
+	return ts.UTC().Format(time.RFC3339Nano)
+}
+
+func translateBool(clause map[string]interface{}) (bson.M, error) {
+	translateAll := func(key string) ([]bson.M, error) {
+		clauses, _ := clause[key].([]interface{})
+		if c, ok := clause[key].(map[string]interface{}); ok {
+			clauses = []interface{}{c}
+		}
+		res := make([]bson.M, 0, len(clauses))
+		for _, c := range clauses {
+			cM, ok := c.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("malformed bool %s clause", key)
+			}
+			cond, err := translateClause(cM)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, cond)
+		}
+		return res, nil
+	}
+	and, err := translateAll("must")
+	if err != nil {
+		return nil, err
+	}
+	filter, err := translateAll("filter")
+	if err != nil {
+		return nil, err
+	}
+	and = append(and, filter...)
+	if mustNot, err := translateAll("must_not"); err != nil {
+		return nil, err
+	} else if len(mustNot) > 0 {
+		and = append(and, bson.M{"$nor": mustNot})
+	}
+	if should, err := translateAll("should"); err != nil {
+		return nil, err
+	} else if len(should) > 0 {
+		minimumShouldMatch, _ := clause["minimum_should_match"].(float64)
+		if minimumShouldMatch > 1 {
+			return nil, errors.Wrap(store.ErrQueryNotSupported,
+				"minimum_should_match greater than 1")
+		}
+		// without must clauses, at least one should clause must match
+		if minimumShouldMatch == 1 || len(and) == 0 {
+			and = append(and, bson.M{"$or": should})
+		}
+	}
+	return mergeAnd(and), nil
+}
+
+func mergeAnd(and []bson.M) bson.M {
+	switch len(and) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return and[0]
+	}
+	return bson.M{"$and": and}
+}
+
+func parseSort(sortS []interface{}) ([]sortField, error) {
+	fields := make([]sortField, 0, len(sortS))
+	for _, s := range sortS {
+		switch s := s.(type) {
+		case string:
+			fields = append(fields, sortField{field: s})
+		case map[string]interface{}:
+			for field, opts := range s {
+				order, _ := opts.(string)
+				missing := ""
+				if optsM, ok := opts.(map[string]interface{}); ok {
+					order, _ = optsM["order"].(string)
+					missing, _ = optsM["missing"].(string)
+				}
+				desc := order == model.SortOrderDesc
+				// MongoDB sorts the missing values as the lowest ones
+				if missing == "_first" && desc || missing == "_last" && !desc {
+					return nil, errors.Wrapf(store.ErrQueryNotSupported,
+						"sort on %s: missing values placement", field)
+				}
+				fields = append(fields, sortField{
+					field: field,
+					desc:  desc,
+				})
+			}
+		default:
+			return nil, errors.New("malformed sort criteria")
+		}
+	}
+	return fields, nil
+}
+
+// mongoSort returns the MongoDB sort specification; duplicated fields are
+// dropped, as MongoDB does not accept them
+func mongoSort(fields []sortField) bson.D {
+	res := bson.D{}
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if seen[f.field] {
+			continue
+		}
+		seen[f.field] = true
+		order := 1
+		if f.desc {
+			order = -1
+		}
+		res = append(res, bson.E{Key: f.field, Value: order})
+	}
+	return res
+}
+
+// searchAfterFilter returns the filter selecting the documents sorted after
+// the given sort values; missing values sort first in ascending order and
+// last in descending order
+func searchAfterFilter(fields []sortField, values []interface{}) bson.M {
+	or := []bson.M{}
+	for i := range fields {
+		cond := bson.M{}
+		for j := 0; j < i; j++ {
+			cond[fields[j].field] = values[j]
+		}
+		field, value := fields[i].field, values[i]
+		switch {
+		case value == nil && fields[i].desc:
+			// nothing sorts after a missing value
+			continue
+		case value == nil:
+			cond[field] = bson.M{"$ne": nil}
+		case fields[i].desc:
+			cond = bson.M{"$and": []bson.M{cond, {"$or": []bson.M{
+				{field: bson.M{"$lt": value}},
+				{field: nil},
+			}}}}
+		default:
+			cond[field] = bson.M{"$gt": value}
+		}
+		or = append(or, cond)
+	}
+	if len(or) == 0 {
+		// match nothing
+		return bson.M{"_id": bson.M{"$in": []interface{}{}}}
+	}
+	return bson.M{"$or": or}
+}
+
+// sortValues returns the values a document is sorted by, as returned in
+// the "sort" section of the OpenSearch hits
+func sortValues(doc map[string]interface{}, fields []sortField) []interface{} {
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		value := doc[f.field]
+		if arr, ok := value.([]interface{}); ok {
+			// arrays sort by their lowest value ascending, highest descending
+			value = nil
+			for _, v := range arr {
+				if value == nil ||
+					(!f.desc && compareValues(v, value) < 0) ||
+					(f.desc && compareValues(v, value) > 0) {
+					value = v
+				}
+			}
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// compareValues compares two JSON values following the MongoDB sort order
+func compareValues(a, b interface{}) int {
+	typeOrder := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case float64:
+			return 1
+		case string:
+			return 2
+		case bool:
+			return 4
+		}
+		return 3
+	}
+	if ta, tb := typeOrder(a), typeOrder(b); ta != tb {
+		return ta - tb
+	}
+	switch a := a.(type) {
+	case float64:
+		switch b := b.(float64); {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case bool:
+		if a != b.(bool) {
+			if a {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// termsAggregation is a terms bucket aggregation, or a range bucket
+// aggregation if it has ranges, evaluated in memory
+type termsAggregation struct {
+	name    string
+	field   string
+	size    int
+	include *regexp.Regexp
+	ranges  []aggregationRange
+	subs    []*termsAggregation
+}
+
+// aggregationRange is a bucket of a range aggregation, matching the values
+// from (inclusive) to (exclusive)
+type aggregationRange struct {
+	key  string
+	from interface{}
+	to   interface{}
+}
+
+func (r aggregationRange) contains(value interface{}) bool {
+	return (r.from == nil || compareValues(value, r.from) >= 0) &&
+		(r.to == nil || compareValues(value, r.to) < 0)
+}
+
+func parseRangeAggregation(name string, rangeM map[string]interface{}) (
+	*termsAggregation, error) {
+	field, _ := rangeM["field"].(string)
+	rangesS, _ := rangeM["ranges"].([]interface{})
+	agg := &termsAggregation{
+		name:   name,
+		field:  field,
+		size:   len(rangesS),
+		ranges: make([]aggregationRange, 0, len(rangesS)),
+	}
+	for _, r := range rangesS {
+		rM, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("aggregation %s: malformed range", name)
+		}
+		key, ok := rM["key"].(string)
+		if !ok {
+			return nil, errors.Errorf("aggregation %s: only keyed ranges "+
+				"are supported", name)
+		}
+		agg.ranges = append(agg.ranges, aggregationRange{
+			key:  key,
+			from: resolveDateMath(rM["from"]),
+			to:   resolveDateMath(rM["to"]),
+		})
+	}
+	return agg, nil
+}
+
+func parseAggregations(aggs map[string]interface{}) ([]*termsAggregation, error) {
+	res := make([]*termsAggregation, 0, len(aggs))
+	for name, agg := range aggs {
+		aggM, ok := agg.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("malformed aggregation %s", name)
+		}
+		if rangeM, ok := aggM["range"].(map[string]interface{}); ok {
+			rangeAgg, err := parseRangeAggregation(name, rangeM)
+			if err != nil {
+				return nil, err
+			}
+			if subaggs, ok := aggM["aggs"].(map[string]interface{}); ok {
+				if rangeAgg.subs, err = parseAggregations(subaggs); err != nil {
+					return nil, err
+				}
+			}
+			res = append(res, rangeAgg)
+			continue
+		}
+		terms, ok := aggM["terms"].(map[string]interface{})
+		if !ok {
+			// e.g. the date histograms and the metrics aggregations
+			return nil, errors.Wrapf(store.ErrQueryNotSupported,
+				"aggregation %s: only terms and range aggregations", name)
+		}
+		field, _ := terms["field"].(string)
+		size := defaultSearchSize
+		if s, ok := terms["size"].(float64); ok {
+			size = int(s)
+		}
+		termsAgg := &termsAggregation{
+			name:  name,
+			field: field,
+			size:  size,
+		}
+		if include, ok := terms["include"].(string); ok {
+			// the include patterns match the whole value in OpenSearch
+			re, err := regexp.Compile("^(?:" + include + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "aggregation %s", name)
+			}
+			termsAgg.include = re
+		}
+		if subaggs, ok := aggM["aggs"].(map[string]interface{}); ok {
+			subs, err := parseAggregations(subaggs)
+			if err != nil {
+				return nil, err
+			}
+			termsAgg.subs = subs
+		}
+		res = append(res, termsAgg)
+	}
+	return res, nil
+}
+
+// fields returns the document fields the aggregation needs
+func (a *termsAggregation) fields() []string {
+	fields := []string{a.field}
+	for _, sub := range a.subs {
+		fields = append(fields, sub.fields()...)
+	}
+	return fields
+}
+
+type bucket struct {
+	key   string
+	count int
+	subs  []*aggregationResult
+}
+
+type aggregationResult struct {
+	agg     *termsAggregation
+	buckets map[string]*bucket
+}
+
+func newAggregationResults(aggs []*termsAggregation) []*aggregationResult {
+	res := make([]*aggregationResult, len(aggs))
+	for i, agg := range aggs {
+		res[i] = &aggregationResult{
+			agg:     agg,
+			buckets: map[string]*bucket{},
+		}
+	}
+	return res
+}
+
+// add counts the document in the buckets of its values
+func (r *aggregationResult) add(doc map[string]interface{}) {
+	values, ok := doc[r.agg.field].([]interface{})
+	if !ok {
+		if doc[r.agg.field] == nil {
+			return
+		}
+		values = []interface{}{doc[r.agg.field]}
+	}
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		for _, key := range r.agg.keys(value) {
+			if seen[key] || (r.agg.include != nil && !r.agg.include.MatchString(key)) {
+				continue
+			}
+			seen[key] = true
+			b := r.bucket(key)
+			b.count++
+			for _, sub := range b.subs {
+				sub.add(doc)
+			}
+		}
+	}
+}
+
+// keys returns the keys of the buckets of the value
+func (a *termsAggregation) keys(value interface{}) []string {
+	if a.ranges == nil {
+		return []string{fmt.Sprint(value)}
+	}
+	keys := []string{}
+	for _, r := range a.ranges {
+		if r.contains(value) {
+			keys = append(keys, r.key)
+		}
+	}
+	return keys
+}
+
+func (r *aggregationResult) bucket(key string) *bucket {
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{
+			key:  key,
+			subs: newAggregationResults(r.agg.subs),
+		}
+		r.buckets[key] = b
+	}
+	return b
+}
+
+// result returns the aggregation result in the OpenSearch format: the
+// buckets sorted by descending count and key
+func (r *aggregationResult) result() map[string]interface{} {
+	buckets := make([]*bucket, 0, len(r.buckets))
+	if r.agg.ranges != nil {
+		// the buckets of the range aggregations follow the ranges
+		for _, rng := range r.agg.ranges {
+			buckets = append(buckets, r.bucket(rng.key))
+		}
+	} else {
+		for _, b := range r.buckets {
+			buckets = append(buckets, b)
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].count != buckets[j].count {
+				return buckets[i].count > buckets[j].count
+			}
+			return buckets[i].key < buckets[j].key
+		})
+	}
+	other := 0
+	if len(buckets) > r.agg.size {
+		for _, b := range buckets[r.agg.size:] {
+			other += b.count
+		}
+		buckets = buckets[:r.agg.size]
+	}
+	bucketsS := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		bucketM := map[string]interface{}{
+			"key":       b.key,
+			"doc_count": float64(b.count),
+		}
+		for _, sub := range b.subs {
+			bucketM[sub.agg.name] = sub.result()
+		}
+		bucketsS[i] = bucketM
+	}
+	return map[string]interface{}{
+		"buckets":             bucketsS,
+		"sum_other_doc_count": float64(other),
+	}
+}
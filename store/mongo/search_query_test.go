@@ -0,0 +1,439 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestParseSearchRequest(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		query func() model.Query
+
+		filter bson.M
+		sort   []sortField
+		from   int64
+		size   int64
+		fields []string
+		err    string
+	}{
+		"ok, filters": {
+			query: func() model.Query {
+				q, _ := model.BuildQuery(model.SearchParams{
+					Page:    2,
+					PerPage: 10,
+					Filters: []model.FilterPredicate{{
+						Scope:     model.ScopeInventory,
+						Attribute: "mac",
+						Type:      "$eq",
+						Value:     "00:11:22",
+					}, {
+						Scope:     model.ScopeInventory,
+						Attribute: "mem",
+						Type:      "$gte",
+						Value:     float64(1024),
+					}, {
+						Scope:     model.ScopeInventory,
+						Attribute: "tag",
+						Type:      "$nin",
+						Value:     []interface{}{"a", "b"},
+					}, {
+						Scope:     model.ScopeInventory,
+						Attribute: "name",
+						Type:      "$regex",
+						Value:     "raspberry.*",
+					}, {
+						Scope:     model.ScopeInventory,
+						Attribute: "hostname",
+						Type:      "$wildcard",
+						Value:     "edge-gw-*-prod",
+					}},
+				})
+				return q.Must(model.M{"term": model.M{model.FieldNameTenantID: "tenant"}})
+			},
+
+			filter: bson.M{"$and": []bson.M{
+				{"inventory_mac_str": "00:11:22"},
+				{"inventory_mem_num": bson.M{"$gte": float64(1024)}},
+				{"inventory_name_str": bson.M{"$regex": "^(?:raspberry.*)$"}},
+				{"inventory_hostname_str": bson.M{"$regex": "^(?:edge-gw-.*-prod)$"}},
+				{model.FieldNameTenantID: "tenant"},
+				{"$nor": []bson.M{
+					{"inventory_tag_str": bson.M{"$in": []interface{}{"a", "b"}}},
+				}},
+			}},
+			from: 10,
+			size: 10,
+		},
+		"ok, exists, sort and select": {
+			query: func() model.Query {
+				q, _ := model.BuildQuery(model.SearchParams{
+					Page:    1,
+					PerPage: 20,
+					Filters: []model.FilterPredicate{{
+						Scope:     model.ScopeInventory,
+						Attribute: "mac",
+						Type:      "$exists",
+						Value:     true,
+					}},
+					Sort: []model.SortCriteria{{
+						Scope:     model.ScopeInventory,
+						Attribute: "mac",
+						Order:     model.SortOrderDesc,
+					}},
+					Attributes: []model.SelectAttribute{{
+						Scope:     model.ScopeInventory,
+						Attribute: "mac",
+					}},
+				})
+				return q
+			},
+
+			filter: bson.M{"$or": []bson.M{
+				{"inventory_mac_str": bson.M{"$ne": nil}},
+				{"inventory_mac_num": bson.M{"$ne": nil}},
+				{"inventory_mac_bool": bson.M{"$ne": nil}},
+			}},
+			sort: []sortField{
+				{field: "inventory_mac_str", desc: true},
+				{field: "inventory_mac_num", desc: true},
+			},
+			size: 20,
+			fields: []string{
+				"inventory_mac_str",
+				"inventory_mac_num",
+				"inventory_mac_bool",
+				"id",
+			},
+		},
+		"error, unsupported missing values placement": {
+			query: func() model.Query {
+				q, _ := model.BuildQuery(model.SearchParams{
+					Page:    1,
+					PerPage: 20,
+					Sort: []model.SortCriteria{{
+						Scope:     model.ScopeInventory,
+						Attribute: "mac",
+						Order:     model.SortOrderAsc,
+						Missing:   model.SortMissingLast,
+					}},
+				})
+				return q
+			},
+
+			err: "sort on inventory_mac_str: missing values placement: " +
+				"query not supported by the storage backend",
+		},
+		"error, unsupported clause": {
+			query: func() model.Query {
+				return model.NewQuery().Must(model.M{"fuzzy": model.M{"id": "dev"}})
+			},
+
+			err: "query clause fuzzy: query not supported by the storage backend",
+		},
+		"error, free-text search": {
+			query: func() model.Query {
+				q, _ := model.BuildQuery(model.SearchParams{
+					Page:    1,
+					PerPage: 20,
+					Text:    "raspberry",
+				})
+				return q
+			},
+
+			err: "query clause query_string: " +
+				"query not supported by the storage backend",
+		},
+		"error, geo filter": {
+			query: func() model.Query {
+				q, _ := model.BuildQuery(model.SearchParams{
+					Page:    1,
+					PerPage: 20,
+					Filters: []model.FilterPredicate{{
+						Scope:     model.ScopeSystem,
+						Attribute: model.AttrNameLocation,
+						Type:      model.FilterTypeGeoDistance,
+						Value: map[string]interface{}{
+							"lat":      45.0,
+							"lon":      9.0,
+							"distance": "10km",
+						},
+					}},
+				})
+				return q
+			},
+
+			err: "query clause geo_distance: " +
+				"query not supported by the storage backend",
+		},
+		"error, date histogram": {
+			query: func() model.Query {
+				aggs, _ := model.BuildAggregations([]model.AggregationTerm{{
+					Name:      "updated",
+					Scope:     model.ScopeSystem,
+					Attribute: "updated_ts",
+					Type:      model.AggregationTypeDateHistogram,
+					Interval:  "day",
+				}})
+				return model.NewQuery().With(map[string]interface{}{
+					"aggs": aggs,
+				})
+			},
+
+			err: "aggregation updated: only terms and range aggregations: " +
+				"query not supported by the storage backend",
+		},
+		"error, unsupported aggregation": {
+			query: func() model.Query {
+				return model.NewQuery().With(map[string]interface{}{
+					"aggs": model.M{"stats": model.M{"avg": model.M{"field": "mem"}}},
+				})
+			},
+
+			err: "aggregation stats: only terms and range aggregations: " +
+				"query not supported by the storage backend",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := parseSearchRequest(tc.query())
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.filter, req.filter)
+			assert.Equal(t, tc.sort, req.sort)
+			assert.Equal(t, tc.from, req.from)
+			assert.Equal(t, tc.size, req.size)
+			assert.Equal(t, tc.fields, req.fields)
+		})
+	}
+}
+
+func TestSearchAfterFilter(t *testing.T) {
+	t.Parallel()
+
+	fields := []sortField{
+		{field: "name", desc: true},
+		{field: "id"},
+	}
+	assert.Equal(t, bson.M{"$or": []bson.M{
+		{"$and": []bson.M{{}, {"$or": []bson.M{
+			{"name": bson.M{"$lt": "b"}},
+			{"name": nil},
+		}}}},
+		{"name": "b", "id": bson.M{"$gt": "1"}},
+	}}, searchAfterFilter(fields, []interface{}{"b", "1"}))
+
+	assert.Equal(t, bson.M{"$or": []bson.M{
+		{"name": nil, "id": bson.M{"$gt": "1"}},
+	}}, searchAfterFilter(fields, []interface{}{nil, "1"}))
+}
+
+func TestSortValues(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]interface{}{
+		"name": []interface{}{"b", "a", "c"},
+		"mem":  float64(1),
+	}
+	assert.Equal(t, []interface{}{"a", float64(1), nil},
+		sortValues(doc, []sortField{{field: "name"}, {field: "mem"}, {field: "none"}}))
+	assert.Equal(t, []interface{}{"c"},
+		sortValues(doc, []sortField{{field: "name", desc: true}}))
+}
+
+func TestTermsAggregation(t *testing.T) {
+	t.Parallel()
+
+	aggs, err := parseAggregations(map[string]interface{}{
+		"groups": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"field": "group",
+				"size":  float64(1),
+			},
+			"aggs": map[string]interface{}{
+				"types": map[string]interface{}{
+					"terms": map[string]interface{}{
+						"field": "type",
+					},
+				},
+			},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	results := newAggregationResults(aggs)
+	for _, doc := range []map[string]interface{}{
+		{"group": "prod", "type": []interface{}{"rpi", "rpi"}},
+		{"group": "prod", "type": "qemu"},
+		{"group": "dev", "type": "qemu"},
+		{"type": "qemu"},
+	} {
+		results[0].add(doc)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"buckets": []interface{}{
+			map[string]interface{}{
+				"key":       "prod",
+				"doc_count": float64(2),
+				"types": map[string]interface{}{
+					"buckets": []interface{}{
+						map[string]interface{}{
+							"key":       "qemu",
+							"doc_count": float64(1),
+						},
+						map[string]interface{}{
+							"key":       "rpi",
+							"doc_count": float64(1),
+						},
+					},
+					"sum_other_doc_count": float64(0),
+				},
+			},
+		},
+		"sum_other_doc_count": float64(1),
+	}, results[0].result())
+}
+
+func TestTermsAggregationInclude(t *testing.T) {
+	t.Parallel()
+
+	aggs, err := parseAggregations(map[string]interface{}{
+		"suggestions": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"field":   "hostname",
+				"include": `rasp\.pi.*`,
+			},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	results := newAggregationResults(aggs)
+	for _, doc := range []map[string]interface{}{
+		{"hostname": "rasp.pi-1"},
+		{"hostname": "rasp.pi-1"},
+		{"hostname": "raspXpi"},
+		{"hostname": "my-rasp.pi"},
+	} {
+		results[0].add(doc)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"buckets": []interface{}{
+			map[string]interface{}{
+				"key":       "rasp.pi-1",
+				"doc_count": float64(2),
+			},
+		},
+		"sum_other_doc_count": float64(0),
+	}, results[0].result())
+
+	_, err = parseAggregations(map[string]interface{}{
+		"suggestions": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"field":   "hostname",
+				"include": `rasp(`,
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestRangeAggregation(t *testing.T) {
+	t.Parallel()
+
+	aggs, err := parseAggregations(map[string]interface{}{
+		"connectivity": map[string]interface{}{
+			"range": map[string]interface{}{
+				"field": "check_in_time",
+				"ranges": []interface{}{
+					map[string]interface{}{
+						"key": "offline",
+						"to":  "now-3600s",
+					},
+					map[string]interface{}{
+						"key":  "online",
+						"from": "now-3600s",
+					},
+				},
+			},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	now := time.Now().UTC()
+	results := newAggregationResults(aggs)
+	for _, doc := range []map[string]interface{}{
+		{"check_in_time": now.Add(-time.Minute).Format(time.RFC3339Nano)},
+		{"check_in_time": now.Add(-2 * time.Hour).Format(time.RFC3339Nano)},
+		{"check_in_time": now.Add(-3 * time.Hour).Format(time.RFC3339Nano)},
+		{},
+	} {
+		results[0].add(doc)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"buckets": []interface{}{
+			map[string]interface{}{
+				"key":       "offline",
+				"doc_count": float64(2),
+			},
+			map[string]interface{}{
+				"key":       "online",
+				"doc_count": float64(1),
+			},
+		},
+		"sum_other_doc_count": float64(0),
+	}, results[0].result())
+
+	_, err = parseAggregations(map[string]interface{}{
+		"connectivity": map[string]interface{}{
+			"range": map[string]interface{}{
+				"field": "check_in_time",
+				"ranges": []interface{}{
+					map[string]interface{}{"to": "now-3600s"},
+				},
+			},
+		},
+	})
+	assert.EqualError(t, err, "aggregation connectivity: only keyed ranges are supported")
+}
+
+func TestResolveDateMath(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now().Add(-time.Hour)
+	ts, err := time.Parse(time.RFC3339Nano, resolveDateMath("now-1h").(string))
+	if assert.NoError(t, err) {
+		assert.False(t, ts.Before(before))
+		assert.True(t, ts.Before(before.Add(time.Minute)))
+	}
+	assert.Equal(t, "2023-05-10T12:00:00Z", resolveDateMath("2023-05-10T12:00:00Z"))
+	assert.Equal(t, float64(10), resolveDateMath(float64(10)))
+}
@@ -0,0 +1,260 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestSearchStoreDevices(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping TestSearchStoreDevices in short mode.")
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+	ss := NewSearchStore(ds)
+
+	err := ss.Migrate(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	newDevice := func(tenantID, id, mac string, mem float64) *model.Device {
+		dev := model.NewDevice(tenantID, id)
+		dev.SetUpdatedAt(updatedAt)
+		_ = dev.AppendAttr(model.NewInventoryAttribute(model.ScopeInventory).
+			SetName("mac").
+			SetString(mac))
+		_ = dev.AppendAttr(model.NewInventoryAttribute(model.ScopeInventory).
+			SetName("mem").
+			SetNumeric(mem))
+		return dev
+	}
+	err = ss.BulkIndexDevices(ctx, []*model.Device{
+		newDevice("tenant", "1", "00:00:01", 512),
+		newDevice("tenant", "2", "00:00:02", 1024),
+		newDevice("tenant", "3", "00:00:03", 2048),
+		newDevice("other", "4", "00:00:04", 4096),
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the stale devices do not overwrite the more recent documents
+	stale := newDevice("tenant", "2", "00:00:02", 1)
+	stale.SetUpdatedAt(updatedAt.Add(-time.Hour))
+	err = ss.BulkIndexDevices(ctx, []*model.Device{stale}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ss.BulkIndexDevices(ctx, nil, []*model.Device{
+		model.NewDevice("tenant", "3"),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	query, err := model.BuildQuery(model.SearchParams{
+		Page:    1,
+		PerPage: 10,
+		Filters: []model.FilterPredicate{{
+			Scope:     model.ScopeInventory,
+			Attribute: "mem",
+			Type:      "$gte",
+			Value:     float64(512),
+		}},
+		Sort: []model.SortCriteria{{
+			Scope:     model.ScopeInventory,
+			Attribute: "mem",
+			Order:     model.SortOrderDesc,
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	query = query.Must(model.M{"term": model.M{model.FieldNameTenantID: "tenant"}})
+
+	res, err := ss.SearchDevices(ctx, query)
+	if !assert.NoError(t, err) {
+		return
+	}
+	hits := res["hits"].(map[string]interface{})
+	assert.Equal(t, float64(2), hits["total"].(map[string]interface{})["value"])
+	ids := []interface{}{}
+	for _, hit := range hits["hits"].([]interface{}) {
+		source := hit.(map[string]interface{})["_source"].(map[string]interface{})
+		ids = append(ids, source[model.FieldNameID])
+	}
+	assert.Equal(t, []interface{}{"2", "1"}, ids)
+
+	mapping, err := ss.GetDevicesIndexMapping(ctx, "tenant")
+	if !assert.NoError(t, err) {
+		return
+	}
+	properties := mapping["mappings"].(map[string]interface{})["properties"]
+	assert.Contains(t, properties, "inventory_mac_str")
+	assert.Contains(t, properties, "inventory_mem_num")
+}
+
+func TestSearchStoreDeploymentsStatusHistory(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping TestSearchStoreDeploymentsStatusHistory in short mode.")
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+	ss := NewSearchStore(ds)
+
+	err := ss.Migrate(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	start := time.Now().UTC().Truncate(time.Second)
+	transitions := []model.DeviceStatusTransition{
+		{Status: "downloading", Timestamp: start},
+		{Status: "downloading", Timestamp: start.Add(time.Minute)},
+		{Status: "installing", Timestamp: start.Add(2 * time.Minute)},
+	}
+	for _, transition := range transitions {
+		err = ss.BulkIndexDeployments(ctx, []*model.Deployment{{
+			ID:                  "1",
+			TenantID:            "tenant",
+			DeviceID:            "device",
+			DeviceStatus:        transition.Status,
+			DeviceStatusHistory: []model.DeviceStatusTransition{transition},
+		}})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	query, err := model.BuildDeploymentsQuery(model.DeploymentsSearchParams{
+		Page:    1,
+		PerPage: 10,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	res, err := ss.SearchDeployments(ctx, query)
+	if !assert.NoError(t, err) {
+		return
+	}
+	hits := res["hits"].(map[string]interface{})["hits"].([]interface{})
+	if !assert.Len(t, hits, 1) {
+		return
+	}
+	source := hits[0].(map[string]interface{})["_source"].(map[string]interface{})
+	assert.Equal(t, "installing", source["device_status"])
+	// the transitions to the same status are recorded once
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"status":    "downloading",
+			"timestamp": start.Format(time.RFC3339),
+		},
+		map[string]interface{}{
+			"status":    "installing",
+			"timestamp": start.Add(2 * time.Minute).Format(time.RFC3339),
+		},
+	}, source["device_status_history"])
+}
+
+func TestSearchStoreSoftware(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping TestSearchStoreSoftware in short mode.")
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	ds := GetTestDataStore(t)
+	defer ds.DropDatabase(context.Background())
+	ss := NewSearchStore(ds)
+
+	err := ss.Migrate(ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	newSoftware := func(id string, versions ...string) *model.DeviceSoftware {
+		software := model.NewDeviceSoftware("tenant", id)
+		for _, version := range versions {
+			software.AddAttribute("rootfs-image.version", version)
+		}
+		return software
+	}
+	err = ss.BulkIndexSoftware(ctx, []*model.DeviceSoftware{
+		newSoftware("1", "release-1"),
+		newSoftware("2", "release-1"),
+		newSoftware("3", "release-2"),
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ss.BulkIndexSoftware(ctx, nil, []*model.Device{
+		model.NewDevice("tenant", "2"),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := ss.AggregateSoftware(ctx, model.BuildSoftwareQuery(model.SoftwareParams{
+		Name:     "rootfs-image",
+		TenantID: "tenant",
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	aggregation := res["aggregations"].(map[string]interface{})[model.SoftwareAggregation]
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "rootfs-image=release-1", "doc_count": float64(1)},
+		map[string]interface{}{"key": "rootfs-image=release-2", "doc_count": float64(1)},
+	}, aggregation.(map[string]interface{})["buckets"])
+
+	params := model.SoftwareDevicesParams{
+		Name:     "rootfs-image",
+		Version:  "release-2",
+		TenantID: "tenant",
+	}
+	params.Normalize()
+	res, err = ss.SearchSoftware(ctx, model.BuildSoftwareDevicesQuery(params))
+	if !assert.NoError(t, err) {
+		return
+	}
+	hits := res["hits"].(map[string]interface{})
+	assert.Equal(t, float64(1), hits["total"].(map[string]interface{})["value"])
+	source := hits["hits"].([]interface{})[0].(map[string]interface{})["_source"]
+	assert.Equal(t, "3", source.(map[string]interface{})[model.FieldNameID])
+
+	usage, err := ss.GetTenantUsage(ctx, "tenant")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, model.UsageDocuments{Software: 2}, usage.Documents)
+	assert.Greater(t, usage.IndexBytes, int64(0))
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// remapAttributeScript converts the values of the fields of the attribute
+// to the type of the target field, where they are moved to: the strings are
+// parsed as numbers, ISO-8601 dates or booleans, and the other values are
+// formatted as strings, as model.InventoryAttribute.ConvertTo does; the
+// values which do not convert are dropped
+const remapAttributeScript = `
+Object convert(def value, String type) {
+	if (type == 'str') {
+		if (value instanceof String) {
+			return value;
+		} else if (value instanceof Number) {
+			double num = ((Number) value).doubleValue();
+			if (num == Math.rint(num) && Math.abs(num) < 1e15) {
+				return Long.toString((long) num);
+			}
+			return Double.toString(num);
+		}
+		return String.valueOf(value);
+	} else if (type == 'num') {
+		if (value instanceof Number) {
+			return ((Number) value).doubleValue();
+		} else if (value instanceof String) {
+			try {
+				double num = Double.parseDouble(((String) value).trim());
+				if (!Double.isNaN(num) && !Double.isInfinite(num)) {
+					return num;
+				}
+			} catch (NumberFormatException e) {
+			}
+		}
+	} else if (type == 'bool') {
+		if (value instanceof Boolean) {
+			return value;
+		} else if (value instanceof String) {
+			String str = (String) value;
+			if (str.equalsIgnoreCase('true') || str.equalsIgnoreCase('false')) {
+				return str.equalsIgnoreCase('true');
+			}
+		}
+	} else if (type == 'date' && value instanceof String) {
+		try {
+			return Instant.from(ZonedDateTime.parse(((String) value).trim())).toString();
+		} catch (Exception e) {
+		}
+	}
+	return null;
+}
+
+String type = params.target.substring(params.target.lastIndexOf('_') + 1);
+List values = new ArrayList();
+def current = ctx._source.get(params.target);
+if (current instanceof List) {
+	values.addAll(current);
+} else if (current != null) {
+	values.add(current);
+}
+boolean changed = false;
+for (String field : params.sources) {
+	if (!ctx._source.containsKey(field)) {
+		continue;
+	}
+	def source = ctx._source.remove(field);
+	def sourceValues = source instanceof List ? source : [source];
+	for (def value : sourceValues) {
+		def converted = convert(value, type);
+		if (converted != null) {
+			values.add(converted);
+		}
+	}
+	changed = true;
+}
+if (params.dropped != null && ctx._source.remove(params.dropped) != null) {
+	changed = true;
+}
+if (!changed) {
+	ctx.op = 'noop';
+} else if (values.isEmpty()) {
+	ctx._source.remove(params.target);
+} else {
+	ctx._source.put(params.target, values);
+}
+`
+
+// RemapDevicesAttribute converts the values of the (mapped) attribute of
+// the tenant's devices to the type it is declared to be indexed as, moving
+// them from the fields of the other types to the field of the type; the
+// sortable versions are dropped unless the attribute is indexed as strings.
+// With TypeAny, the dates are moved back to the strings field. The devices
+// are updated by query: the documents indexed meanwhile are skipped, they
+// are indexed with the declared type already.
+func (s *opensearchStore) RemapDevicesAttribute(
+	ctx context.Context,
+	tenantID, scope, attribute string,
+	typ model.Type,
+) error {
+	l := log.FromContext(ctx)
+
+	target, sources := model.TypeStr, []model.Type{model.TypeDate}
+	if typ != model.TypeAny {
+		target, sources = typ, nil
+		for _, t := range []model.Type{model.TypeStr, model.TypeNum,
+			model.TypeBool, model.TypeDate} {
+			if t != typ {
+				sources = append(sources, t)
+			}
+		}
+	}
+	sourceFields := make([]string, 0, len(sources))
+	for _, t := range sources {
+		sourceFields = append(sourceFields, model.ToAttr(scope, attribute, t))
+	}
+	params := model.M{
+		"target":  model.ToAttr(scope, attribute, target),
+		"sources": sourceFields,
+		"dropped": nil,
+	}
+	if target != model.TypeStr {
+		params["dropped"] = model.ToAttr(scope, attribute, model.TypeVersion)
+	}
+	body, err := json.Marshal(model.M{
+		"query": model.M{
+			"bool": model.M{
+				"filter": model.M{
+					"term": model.M{model.FieldNameTenantID: tenantID},
+				},
+			},
+		},
+		"script": model.M{
+			"lang":   "painless",
+			"source": remapAttributeScript,
+			"params": params,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	l.Debugf("es update by query: %s", body)
+
+	updateRequests := []func(*opensearchapi.UpdateByQueryRequest){
+		s.client.UpdateByQuery.WithContext(ctx),
+		s.client.UpdateByQuery.WithBody(bytes.NewReader(body)),
+		s.client.UpdateByQuery.WithIgnoreUnavailable(ignoreUnavailable),
+		s.client.UpdateByQuery.WithConflicts("proceed"),
+	}
+	if routingKey := s.GetDevicesRoutingKey(tenantID); routingKey != "" {
+		updateRequests = append(updateRequests,
+			s.client.UpdateByQuery.WithRouting(routingKey))
+	}
+	resp, err := s.client.UpdateByQuery([]string{s.GetDevicesIndex(tenantID)},
+		updateRequests...)
+	if err != nil {
+		return errors.Wrap(err, "failed to remap the attribute")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return errors.Wrap(errors.New(resp.String()), "failed to remap the attribute")
+	}
+	return nil
+}
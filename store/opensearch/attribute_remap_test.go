@@ -0,0 +1,131 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestRemapDevicesAttribute(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	testCases := map[string]struct {
+		typ    model.Type
+		status int
+
+		params model.M
+		err    string
+	}{
+		"ok, to dates": {
+			typ: model.TypeDate,
+			params: model.M{
+				"target": "inventory_attribute1_date",
+				"sources": []interface{}{
+					"inventory_attribute1_str",
+					"inventory_attribute1_num",
+					"inventory_attribute1_bool",
+				},
+				"dropped": "inventory_attribute1_ver",
+			},
+		},
+		"ok, to strings": {
+			typ: model.TypeStr,
+			params: model.M{
+				"target": "inventory_attribute1_str",
+				"sources": []interface{}{
+					"inventory_attribute1_num",
+					"inventory_attribute1_bool",
+					"inventory_attribute1_date",
+				},
+				"dropped": nil,
+			},
+		},
+		"ok, to the default fields": {
+			typ: model.TypeAny,
+			params: model.M{
+				"target":  "inventory_attribute1_str",
+				"sources": []interface{}{"inventory_attribute1_date"},
+				"dropped": nil,
+			},
+		},
+		"error": {
+			typ:    model.TypeNum,
+			status: http.StatusInternalServerError,
+			err:    "failed to remap the attribute: [500 Internal Server Error] {}",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newTestServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/devices/_update_by_query":
+						assert.Equal(t, http.MethodPost, r.Method)
+						assert.Equal(t, tenantID, r.URL.Query().Get("routing"))
+						assert.Equal(t, "proceed", r.URL.Query().Get("conflicts"))
+						if tc.status != 0 {
+							w.WriteHeader(tc.status)
+							_, _ = w.Write([]byte(`{}`))
+							return
+						}
+						var body struct {
+							Query  model.M `json:"query"`
+							Script struct {
+								Params model.M `json:"params"`
+							} `json:"script"`
+						}
+						assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+						assert.Equal(t, model.M{"bool": map[string]interface{}{
+							"filter": map[string]interface{}{
+								"term": map[string]interface{}{"tenant_id": tenantID},
+							},
+						}}, body.Query)
+						assert.Equal(t, tc.params, body.Script.Params)
+						_, _ = w.Write([]byte(`{"updated": 10}`))
+					default:
+						assert.Failf(t, "unexpected request", "%s %s", r.Method, r.URL.Path)
+						w.WriteHeader(http.StatusNotFound)
+					}
+				}))
+			defer srv.Close()
+
+			store, err := NewStore(
+				WithServerAddresses([]string{srv.URL}),
+				WithDevicesIndexName("devices"),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = store.RemapDevicesAttribute(context.Background(), tenantID,
+				model.ScopeInventory, "attribute1", tc.typ)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
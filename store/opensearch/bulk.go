@@ -0,0 +1,219 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	defaultBulkMaxRetries   = 3
+	defaultBulkRetryBackoff = 100 * time.Millisecond
+
+	bulkRejectedErrorType = "es_rejected_execution_exception"
+)
+
+type bulkItemError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type bulkItemResult struct {
+	ID     string         `json:"_id"`
+	Index  string         `json:"_index"`
+	Status int            `json:"status"`
+	Error  *bulkItemError `json:"error,omitempty"`
+}
+
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+// isRetryableBulkStatus returns true if the item failed for a transient
+// reason, e.g. the write queue of the node was full
+func isRetryableBulkStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// bulkRejectedError is the error of the bulk requests, or items, rejected
+// by the overloaded cluster once the retries are exhausted
+type bulkRejectedError struct {
+	error
+}
+
+func (err bulkRejectedError) Is(target error) bool {
+	return target == store.ErrBulkRejected
+}
+
+func (err bulkRejectedError) Unwrap() error {
+	return err.error
+}
+
+// bulk sends the items to the _bulk API; the items which fail for a
+// transient reason are sent again, with an exponential backoff, up to the
+// configured number of retries, while the deletions and updates of
+// documents which do not exist are not considered failures
+func (s *opensearchStore) bulk(ctx context.Context, items []BulkItem) error {
+	l := log.FromContext(ctx)
+	backoff := s.bulkRetryBackoff
+	for attempt := 0; len(items) > 0; attempt++ {
+		if attempt > 0 {
+			l.Warnf("retrying %d failed bulk items in %s", len(items), backoff)
+			if err := waitBulkBackoff(ctx, backoff); err != nil {
+				return err
+			}
+			backoff *= 2
+		}
+		canRetry := attempt < s.bulkMaxRetries
+		resBody, err := s.sendBulk(ctx, items, canRetry)
+		if err != nil {
+			return err
+		} else if resBody == nil {
+			continue
+		} else if !resBody.Errors {
+			return nil
+		}
+		items, err = classifyBulkItems(items, resBody, canRetry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitBulkBackoff waits for the backoff before the next attempt, or until
+// the context is done
+func waitBulkBackoff(ctx context.Context, backoff time.Duration) error {
+	select {
+	case <-time.After(backoff):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendBulk sends the items in one bulk request; it returns a nil response
+// and no error if the whole request failed for a transient reason and can
+// be retried
+func (s *opensearchStore) sendBulk(
+	ctx context.Context,
+	items []BulkItem,
+	canRetry bool,
+) (*bulkResponse, error) {
+	var data bytes.Buffer
+	for _, item := range items {
+		itemJSON, err := item.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		data.Write(itemJSON)
+	}
+	log.FromContext(ctx).Debugf("opensearch request: %s", data.String())
+
+	req := opensearchapi.BulkRequest{
+		Body: &data,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send the bulk request")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		if isRetryableBulkStatus(res.StatusCode) && canRetry {
+			return nil, nil
+		}
+		err := errors.Errorf("failed to send the bulk request: status %d",
+			res.StatusCode)
+		if res.StatusCode == http.StatusTooManyRequests {
+			err = bulkRejectedError{err}
+		}
+		return nil, err
+	}
+	var resBody bulkResponse
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the bulk response")
+	}
+	return &resBody, nil
+}
+
+// isBulkItemFailure returns true if the result of the item is an error;
+// the deletions and updates of documents which do not exist are not
+func isBulkItemFailure(action string, result bulkItemResult) bool {
+	if result.Error == nil {
+		return false
+	}
+	return result.Status != http.StatusNotFound ||
+		(action != "delete" && action != "update")
+}
+
+// classifyBulkItems sorts the items out by their results: it returns the
+// items which failed for a transient reason, to retry, or the error of the
+// items which failed otherwise
+func classifyBulkItems(
+	items []BulkItem,
+	resBody *bulkResponse,
+	canRetry bool,
+) ([]BulkItem, error) {
+	retry := items[:0:0]
+	var failed int
+	var failure *bulkItemError
+	var rejected bool
+	for i, resItem := range resBody.Items {
+		if i >= len(items) {
+			break
+		}
+		for action, result := range resItem {
+			if !isBulkItemFailure(action, result) {
+				continue
+			}
+			if isRetryableBulkStatus(result.Status) && canRetry {
+				retry = append(retry, items[i])
+			} else {
+				failed++
+				failure = result.Error
+				rejected = result.Status == http.StatusTooManyRequests ||
+					result.Error.Type == bulkRejectedErrorType
+			}
+		}
+	}
+	if failed > 0 {
+		err := errors.Errorf("failed to bulk process %d items: %s: %s",
+			failed, failure.Type, failure.Reason)
+		if rejected {
+			err = bulkRejectedError{err}
+		}
+		return nil, err
+	}
+	return retry, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+func TestBulk(t *testing.T) {
+	t.Parallel()
+
+	items := []BulkItem{{
+		Action: &BulkAction{
+			Type: "index",
+			Desc: &BulkActionDesc{ID: "1", Index: "devices", Routing: "tenant"},
+		},
+		Doc: map[string]string{"id": "1"},
+	}, {
+		Action: &BulkAction{
+			Type: "delete",
+			Desc: &BulkActionDesc{ID: "2", Index: "devices", Routing: "tenant"},
+		},
+	}}
+
+	const (
+		indexOK       = `{"index": {"_id": "1", "status": 201}}`
+		indexRejected = `{"index": {"_id": "1", "status": 429,
+			"error": {"type": "es_rejected_execution_exception", "reason": "full"}}}`
+		indexInvalid = `{"index": {"_id": "1", "status": 400,
+			"error": {"type": "mapper_parsing_exception", "reason": "invalid"}}}`
+		deleteOK       = `{"delete": {"_id": "2", "status": 200}}`
+		deleteNotFound = `{"delete": {"_id": "2", "status": 404,
+			"error": {"type": "not_found", "reason": "missing"}}}`
+		deleteRejected = `{"delete": {"_id": "2", "status": 429,
+			"error": {"type": "es_rejected_execution_exception", "reason": "full"}}}`
+	)
+	response := func(errors bool, items ...string) string {
+		errs := "false"
+		if errors {
+			errs = "true"
+		}
+		return `{"errors": ` + errs + `, "items": [` + strings.Join(items, ",") + `]}`
+	}
+
+	testCases := map[string]struct {
+		responses []string
+		statuses  []int
+		// number of items sent at each request
+		requests []int
+		err      string
+		rejected bool
+	}{
+		"ok": {
+			responses: []string{response(false, indexOK, deleteOK)},
+			requests:  []int{2},
+		},
+		"ok, deleted document not found": {
+			responses: []string{response(true, indexOK, deleteNotFound)},
+			requests:  []int{2},
+		},
+		"ok, rejected item retried": {
+			responses: []string{
+				response(true, indexOK, deleteRejected),
+				response(false, deleteOK),
+			},
+			requests: []int{2, 1},
+		},
+		"ok, rejected request retried": {
+			responses: []string{
+				`{}`,
+				response(false, indexOK, deleteOK),
+			},
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+			requests: []int{2, 2},
+		},
+		"error, invalid item": {
+			responses: []string{response(true, indexInvalid, deleteRejected)},
+			requests:  []int{2},
+			err: "failed to bulk process 1 items: " +
+				"mapper_parsing_exception: invalid",
+		},
+		"error, retries exhausted": {
+			responses: []string{
+				response(true, indexRejected, deleteOK),
+				response(true, indexRejected),
+				response(true, indexRejected),
+			},
+			requests: []int{2, 1, 1},
+			err: "failed to bulk process 1 items: " +
+				"es_rejected_execution_exception: full",
+			rejected: true,
+		},
+		"error, request rejected": {
+			responses: []string{`{}`, `{}`, `{}`},
+			statuses: []int{http.StatusTooManyRequests,
+				http.StatusTooManyRequests, http.StatusTooManyRequests},
+			requests: []int{2, 2, 2},
+			err:      "failed to send the bulk request: status 429",
+			rejected: true,
+		},
+		"error, request failed": {
+			responses: []string{`{}`},
+			statuses:  []int{http.StatusBadRequest},
+			requests:  []int{2},
+			err:       "failed to send the bulk request: status 400",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests []int
+			srv := newTestServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/_bulk", r.URL.Path)
+					body, _ := io.ReadAll(r.Body)
+					// one action line per item, plus the documents to index
+					actions := strings.Count(string(body), `"_index"`)
+					n := len(requests)
+					requests = append(requests, actions)
+					if n >= len(tc.responses) {
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+					if n < len(tc.statuses) {
+						w.WriteHeader(tc.statuses[n])
+					}
+					_, _ = w.Write([]byte(tc.responses[n]))
+				}))
+			defer srv.Close()
+
+			s, err := NewStore(
+				WithServerAddresses([]string{srv.URL}),
+				WithBulkRetries(2, time.Millisecond),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = s.(*opensearchStore).bulk(context.Background(), items)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.Equal(t, tc.rejected, errors.Is(err, store.ErrBulkRejected))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.requests, requests)
+		})
+	}
+}
@@ -20,9 +20,13 @@ const indexDeploymentsTemplate = `{
 	"template": {
 		"settings": {
 			"number_of_shards": %d,
-			"number_of_replicas": %d
+			"number_of_replicas": %d,
+			"refresh_interval": "%s"
 		},
 		"mappings": {
+			"_meta": {
+				"version": %d
+			},
 			"dynamic": false,
 			"date_detection": false,
 			"numeric_detection": false,
@@ -78,6 +82,15 @@ const indexDeploymentsTemplate = `{
 				"deployment_autogenerate_deta": {
 					"type": "boolean"
 				},
+				"deployment_artifact_ids": {
+					"type": "keyword"
+				},
+				"deployment_artifact_device_types": {
+					"type": "keyword"
+				},
+				"deployment_artifact_sizes": {
+					"type": "long"
+				},
 				"device_created": {
 					"type": "date"
 				},
@@ -133,7 +146,7 @@ const indexDeploymentsTemplate = `{
 					"type": "keyword"
 				},
 				"image_size": {
-					"type": "integer"
+					"type": "long"
 				}
 			}
 		}
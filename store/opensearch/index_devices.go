@@ -20,9 +20,13 @@ const indexDevicesTemplate = `{
 	"template": {
 		"settings": {
 			"number_of_shards": %d,
-			"number_of_replicas": %d
+			"number_of_replicas": %d,
+			"refresh_interval": "%s"
 		},
 		"mappings": {
+			"_meta": {
+				"version": %d
+			},
 			"dynamic": true,
 			"date_detection": false,
 			"numeric_detection": false,
@@ -38,9 +42,23 @@ const indexDevicesTemplate = `{
 				},
 				"name": {
 					"type": "keyword"
+				},
+				"location": {
+					"type": "geo_point"
+				},
+				"check_in_time": {
+					"type": "date"
 				}
 			},
 			"dynamic_templates": [
+				{
+					"sortable_versions": {
+						"match": "*_ver",
+						"mapping": {
+							"type": "keyword"
+						}
+					}
+				},
 				{
 					"versions": {
 						"match": "*_version*",
@@ -72,6 +90,14 @@ const indexDevicesTemplate = `{
 							"type": "boolean"
 						}
 					}
+				},
+				{
+					"dates": {
+						"match": "*_date",
+						"mapping": {
+							"type": "date"
+						}
+					}
 				}
 			]
 		}
@@ -0,0 +1,378 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+)
+
+const (
+	// devicesMappingVersion, deploymentsMappingVersion and
+	// softwareMappingVersion are the versions of the mappings of the index
+	// templates: bump them when changing the templates to migrate the
+	// existing indices to the new mappings
+	devicesMappingVersion     = 5
+	deploymentsMappingVersion = 2
+	softwareMappingVersion    = 1
+
+	// baseMappingVersion is the version of the indices created before
+	// the mappings were versioned
+	baseMappingVersion = 1
+
+	// maxCatchUpPasses is the maximum number of passes copying the
+	// documents written while migrating an index, before swapping it
+	maxCatchUpPasses = 5
+	// reindexTaskTimeout is how long to wait for the reindex task to
+	// complete in a single request
+	reindexTaskTimeout = 30 * time.Second
+)
+
+// MigrateMappings migrates the indices whose mapping is older than the one
+// of their index template: the documents are copied in a new index, created
+// from the template, which replaces the old one behind its name, or alias,
+// once all the documents are copied. The indices are searchable and
+// writable throughout the migration: the documents written while copying
+// are copied again in catch-up passes, until a pass copies no documents or
+// the maximum number of passes is reached. The documents deleted while
+// copying are not deleted from the new index.
+func (s *opensearchStore) MigrateMappings(ctx context.Context) error {
+	err := s.migrateMappings(ctx, s.devicesIndexName, devicesMappingVersion)
+	if err == nil {
+		err = s.migrateMappings(ctx, s.deploymentsIndexName, deploymentsMappingVersion)
+	}
+	if err == nil {
+		err = s.migrateMappings(ctx, s.softwareIndexName, softwareMappingVersion)
+	}
+	return err
+}
+
+func (s *opensearchStore) migrateMappings(ctx context.Context, baseName string,
+	version int) error {
+	l := log.FromContext(ctx)
+	outdated, indices, err := s.outdatedIndices(ctx, baseName, version)
+	if err != nil {
+		return err
+	}
+	for _, o := range outdated {
+		l.Infof("migrate the index %s from the mapping version %d to %d",
+			o.index, o.version, version)
+		if err := s.migrateIndex(ctx, o.name, o.index, indices, o.readAliases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outdatedIndex is an index whose mapping is older than the one of its
+// index template
+type outdatedIndex struct {
+	index string
+	// name is the name, or the alias, the index is written to with
+	name        string
+	readAliases []string
+	version     int
+}
+
+// outdatedIndices returns the indices of the base name whose mapping is
+// older than the version, in order, and all the indices of the base name
+func (s *opensearchStore) outdatedIndices(ctx context.Context, baseName string,
+	version int) ([]outdatedIndex, []string, error) {
+	l := log.FromContext(ctx)
+
+	req := opensearchapi.IndicesGetMappingRequest{
+		Index: []string{baseName + "*"},
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get the mappings")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, nil, errors.Errorf("failed to get the mappings of %s*: status %d",
+			baseName, res.StatusCode)
+	}
+	versions, err := parseMappingVersions(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indices := make([]string, 0, len(versions))
+	for index := range versions {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+	var outdated []outdatedIndex
+	for _, index := range indices {
+		if versions[index] > version {
+			l.Warnf("the mapping of the index %s is newer than the template: "+
+				"version %d, expected %d", index, versions[index], version)
+			continue
+		} else if versions[index] == version {
+			continue
+		}
+		name, readAliases, err := s.indexName(ctx, index)
+		if err != nil {
+			return nil, nil, err
+		} else if name == "" {
+			l.Warnf("skipping the index %s: it is not behind any alias", index)
+			continue
+		}
+		outdated = append(outdated, outdatedIndex{
+			index:       index,
+			name:        name,
+			readAliases: readAliases,
+			version:     versions[index],
+		})
+	}
+	return outdated, indices, nil
+}
+
+// parseMappingVersions parses the response of the get mapping API,
+// returning the mapping version of each index
+func parseMappingVersions(body io.Reader) (map[string]int, error) {
+	var mappings map[string]struct {
+		Mappings struct {
+			Meta struct {
+				Version *int `json:"version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(body).Decode(&mappings); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the mappings")
+	}
+	versions := make(map[string]int, len(mappings))
+	for index, mapping := range mappings {
+		versions[index] = baseMappingVersion
+		if mapping.Mappings.Meta.Version != nil {
+			versions[index] = *mapping.Mappings.Meta.Version
+		}
+	}
+	return versions, nil
+}
+
+// indexName returns the name the index is accessed through: its write
+// alias, or the index itself for the strategies which do not use aliases,
+// and the other aliases of the index, e.g. the read alias spanning the
+// monthly indices; an empty name is returned for the indices which are
+// not accessed at all
+func (s *opensearchStore) indexName(ctx context.Context, index string) (
+	string, []string, error) {
+	req := opensearchapi.IndicesGetAliasRequest{
+		Index: []string{index},
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get the aliases")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return "", nil, errors.Errorf("failed to get the aliases of %s: status %d",
+			index, res.StatusCode)
+	}
+	var resBody map[string]struct {
+		Aliases map[string]struct {
+			IsWriteIndex bool `json:"is_write_index"`
+		} `json:"aliases"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return "", nil, errors.Wrap(err, "failed to parse the aliases")
+	}
+	aliases := make([]string, 0, len(resBody[index].Aliases))
+	for alias := range resBody[index].Aliases {
+		aliases = append(aliases, alias)
+	}
+	if len(aliases) == 0 {
+		if s.indexStrategy.IsAlias() {
+			return "", nil, nil
+		}
+		return index, nil, nil
+	}
+	sort.Strings(aliases)
+	name := aliases[0]
+	for _, alias := range aliases {
+		if resBody[index].Aliases[alias].IsWriteIndex {
+			name = alias
+			break
+		}
+	}
+	readAliases := make([]string, 0, len(aliases)-1)
+	for _, alias := range aliases {
+		if alias != name {
+			readAliases = append(readAliases, alias)
+		}
+	}
+	return name, readAliases, nil
+}
+
+// migrateIndex copies the documents of the index into a new one, named
+// after the existing indices, which then replaces the index behind the name
+// and the read aliases
+func (s *opensearchStore) migrateIndex(ctx context.Context, name, index string,
+	indices, readAliases []string) error {
+	l := log.FromContext(ctx)
+
+	newIndex := nextIndexName(name, indices)
+	req := opensearchapi.IndicesCreateRequest{
+		Index: newIndex,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the index")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to create the index %s: status %d",
+			newIndex, res.StatusCode)
+	}
+
+	copied, err := s.copyIndex(ctx, index, newIndex)
+	l.Infof("copied %d documents from the index %s to %s", copied, index, newIndex)
+	for pass := 0; err == nil && copied > 0 && pass < maxCatchUpPasses; pass++ {
+		copied, err = s.copyIndex(ctx, index, newIndex)
+		l.Infof("copied %d documents written meanwhile from the index %s to %s",
+			copied, index, newIndex)
+	}
+	if err != nil {
+		return err
+	}
+
+	actions := []interface{}{
+		map[string]interface{}{
+			"add": map[string]interface{}{
+				"index":          newIndex,
+				"alias":          name,
+				"is_write_index": true,
+			},
+		},
+	}
+	for _, readAlias := range readAliases {
+		actions = append(actions, map[string]interface{}{
+			"add": map[string]interface{}{
+				"index": newIndex,
+				"alias": readAlias,
+			},
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"remove_index": map[string]interface{}{
+			"index": index,
+		},
+	})
+	body, _ := json.Marshal(map[string]interface{}{
+		"actions": actions,
+	})
+	l.Infof("swap the index %s with %s behind %s", index, newIndex, name)
+	aliasesReq := opensearchapi.IndicesUpdateAliasesRequest{
+		Body: strings.NewReader(string(body)),
+	}
+	aliasesRes, err := aliasesReq.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to swap the index")
+	}
+	defer aliasesRes.Body.Close()
+	if aliasesRes.IsError() {
+		return errors.Errorf("failed to swap the index %s with %s: status %d",
+			index, newIndex, aliasesRes.StatusCode)
+	}
+	s.aliases.Delete(name)
+	return nil
+}
+
+// copyIndex copies the documents of the index src which are missing, or
+// older, in the index dst, returning the number of documents copied; the
+// copy runs as a background task, polled until completed
+func (s *opensearchStore) copyIndex(ctx context.Context, src, dst string) (int, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"conflicts": "proceed",
+		"source": map[string]interface{}{
+			"index": src,
+		},
+		"dest": map[string]interface{}{
+			"index":        dst,
+			"version_type": "external",
+		},
+	})
+	waitForCompletion := false
+	req := opensearchapi.ReindexRequest{
+		Body:              strings.NewReader(string(body)),
+		WaitForCompletion: &waitForCompletion,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to copy the index")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return 0, errors.Errorf("failed to copy the index %s to %s: status %d",
+			src, dst, res.StatusCode)
+	}
+	var reindexRes struct {
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&reindexRes); err != nil {
+		return 0, errors.Wrap(err, "failed to parse the reindex response")
+	}
+
+	waitForCompletion = true
+	for {
+		taskReq := opensearchapi.TasksGetRequest{
+			TaskID:            reindexRes.Task,
+			WaitForCompletion: &waitForCompletion,
+			Timeout:           reindexTaskTimeout,
+		}
+		taskRes, err := taskReq.Do(ctx, s.client)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get the reindex task")
+		}
+		var task struct {
+			Completed bool `json:"completed"`
+			Response  struct {
+				Created  int           `json:"created"`
+				Updated  int           `json:"updated"`
+				Failures []interface{} `json:"failures"`
+			} `json:"response"`
+			Error interface{} `json:"error"`
+		}
+		status := taskRes.StatusCode
+		err = json.NewDecoder(taskRes.Body).Decode(&task)
+		taskRes.Body.Close()
+		if status == http.StatusRequestTimeout {
+			// the task is still running
+			continue
+		} else if status >= http.StatusMultipleChoices {
+			return 0, errors.Errorf("failed to get the reindex task %s: status %d",
+				reindexRes.Task, status)
+		} else if err != nil {
+			return 0, errors.Wrap(err, "failed to parse the reindex task")
+		} else if !task.Completed {
+			continue
+		} else if task.Error != nil || len(task.Response.Failures) > 0 {
+			return 0, errors.Errorf("failed to copy the index %s to %s: %v %v",
+				src, dst, task.Error, task.Response.Failures)
+		}
+		return task.Response.Created + task.Response.Updated, nil
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMappingVersions(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		body string
+
+		versions map[string]int
+		err      string
+	}{
+		"ok": {
+			body: `{
+				"devices-000001": {"mappings": {"_meta": {"version": 2}}},
+				"devices-tenant-000001": {"mappings": {"_meta": {"version": 1}}}
+			}`,
+			versions: map[string]int{
+				"devices-000001":        2,
+				"devices-tenant-000001": 1,
+			},
+		},
+		"ok, unversioned mapping": {
+			body: `{"devices": {"mappings": {"properties": {}}}}`,
+			versions: map[string]int{
+				"devices": baseMappingVersion,
+			},
+		},
+		"ok, no indices": {
+			body:     `{}`,
+			versions: map[string]int{},
+		},
+		"error, invalid response": {
+			body: `[]`,
+			err:  "failed to parse the mappings",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			versions, err := parseMappingVersions(strings.NewReader(tc.body))
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.versions, versions)
+			}
+		})
+	}
+}
+
+func TestMigrateMappings(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests []string
+		passes   int
+	)
+	srv := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /devices*/_mapping":
+			// devices-000002 is already at the current version
+			fmt.Fprintf(w, `{
+				"devices-000001": {"mappings": {"_meta": {"version": 0}}},
+				"devices-000002": {"mappings": {"_meta": {"version": %d}}}
+			}`, devicesMappingVersion)
+		case "GET /deployments*/_mapping", "GET /software*/_mapping":
+			_, _ = w.Write([]byte(`{}`))
+		case "GET /devices-000001/_alias":
+			_, _ = w.Write([]byte(`{"devices-000001": {"aliases": {"devices": {}}}}`))
+		case "PUT /devices-000003":
+			_, _ = w.Write([]byte(`{"acknowledged": true}`))
+		case "POST /_reindex":
+			_, _ = w.Write([]byte(`{"task": "node:1"}`))
+		case "GET /_tasks/node:1":
+			// the documents written while copying are copied in a second pass
+			passes++
+			created := 0
+			if passes < 3 {
+				created = 10 / passes
+			}
+			fmt.Fprintf(w, `{"completed": true, "response": {"created": %d}}`, created)
+		case "POST /_aliases":
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"actions": [
+				{"add": {"index": "devices-000003", "alias": "devices",
+					"is_write_index": true}},
+				{"remove_index": {"index": "devices-000001"}}
+			]}`, string(body))
+			_, _ = w.Write([]byte(`{"acknowledged": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithDevicesIndexName("devices"),
+		WithDeploymentsIndexName("deployments"),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = store.MigrateMappings(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, passes)
+	assert.Equal(t, []string{
+		"GET /devices*/_mapping",
+		"GET /devices-000001/_alias",
+		"PUT /devices-000003",
+		"POST /_reindex",
+		"GET /_tasks/node:1",
+		"POST /_reindex",
+		"GET /_tasks/node:1",
+		"POST /_reindex",
+		"GET /_tasks/node:1",
+		"POST /_aliases",
+		"GET /deployments*/_mapping",
+		"GET /software*/_mapping",
+	}, requests)
+}
@@ -0,0 +1,206 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+)
+
+const (
+	ismPoliciesPath = "/_plugins/_ism/policies/"
+	ismAddPath      = "/_plugins/_ism/add/"
+
+	// monthDays is the longest a monthly index is written to: the monthly
+	// indices are deleted once the retention expired for their last day
+	monthDays = 31
+)
+
+// ismPolicy is the part of an ISM policy the store manages
+type ismPolicy struct {
+	Description  string           `json:"description"`
+	DefaultState string           `json:"default_state"`
+	States       []ismState       `json:"states"`
+	ISMTemplate  []ismTemplateDef `json:"ism_template"`
+}
+
+type ismState struct {
+	Name        string                   `json:"name"`
+	Actions     []map[string]interface{} `json:"actions"`
+	Transitions []ismTransition          `json:"transitions"`
+}
+
+type ismTransition struct {
+	StateName  string            `json:"state_name"`
+	Conditions map[string]string `json:"conditions,omitempty"`
+}
+
+type ismTemplateDef struct {
+	IndexPatterns []string `json:"index_patterns"`
+	Priority      int      `json:"priority"`
+}
+
+// equivalent returns true if the policy has the same transitions and
+// index patterns as the other one; the defaults OpenSearch adds to the
+// policies, e.g. the retries of the actions, are ignored
+func (p ismPolicy) equivalent(other ismPolicy) bool {
+	transitions := func(p ismPolicy) string {
+		var s string
+		for _, state := range p.States {
+			s += state.Name + ":"
+			for _, transition := range state.Transitions {
+				s += fmt.Sprintf("%s%v,", transition.StateName, transition.Conditions)
+			}
+			s += ";"
+		}
+		return s
+	}
+	patterns := func(p ismPolicy) string {
+		var s string
+		for _, template := range p.ISMTemplate {
+			s += fmt.Sprintf("%v:%d;", template.IndexPatterns, template.Priority)
+		}
+		return s
+	}
+	return p.DefaultState == other.DefaultState &&
+		transitions(p) == transitions(other) &&
+		patterns(p) == patterns(other)
+}
+
+// deploymentsPolicyName returns the name of the ISM policy of the
+// deployments indices
+func (s *opensearchStore) deploymentsPolicyName() string {
+	return s.deploymentsIndexName + "-retention"
+}
+
+// deploymentsPolicy returns the ISM policy deleting the monthly
+// deployments indices once all their deployments expired
+func (s *opensearchStore) deploymentsPolicy() ismPolicy {
+	return ismPolicy{
+		Description:  "Deletes the monthly deployments indices once expired",
+		DefaultState: "hot",
+		States: []ismState{{
+			Name:    "hot",
+			Actions: []map[string]interface{}{},
+			Transitions: []ismTransition{{
+				StateName: "delete",
+				Conditions: map[string]string{
+					"min_index_age": fmt.Sprintf("%dd", s.deploymentsRetentionDays+monthDays),
+				},
+			}},
+		}, {
+			Name:        "delete",
+			Actions:     []map[string]interface{}{{"delete": map[string]interface{}{}}},
+			Transitions: []ismTransition{},
+		}},
+		ISMTemplate: []ismTemplateDef{{
+			IndexPatterns: []string{s.deploymentsIndexName + "-*"},
+			Priority:      100,
+		}},
+	}
+}
+
+// migrateDeploymentsPolicy creates, or updates if it differs, the ISM policy
+// of the monthly deployments indices, and applies it to the existing ones
+func (s *opensearchStore) migrateDeploymentsPolicy(ctx context.Context) error {
+	l := log.FromContext(ctx)
+	name := s.deploymentsPolicyName()
+	l.Infof("verify the ISM policy %s", name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		ismPoliciesPath+name, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Perform(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the ISM policy")
+	}
+	defer res.Body.Close()
+
+	policy := s.deploymentsPolicy()
+	params := url.Values{}
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		l.Infof("create the ISM policy %s", name)
+	case http.StatusOK:
+		var current struct {
+			SeqNo       int64     `json:"_seq_no"`
+			PrimaryTerm int64     `json:"_primary_term"`
+			Policy      ismPolicy `json:"policy"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&current); err != nil {
+			return errors.Wrap(err, "failed to parse the ISM policy")
+		}
+		if current.Policy.equivalent(policy) {
+			return s.applyDeploymentsPolicy(ctx)
+		}
+		l.Infof("update the ISM policy %s", name)
+		params.Set("if_seq_no", strconv.FormatInt(current.SeqNo, 10))
+		params.Set("if_primary_term", strconv.FormatInt(current.PrimaryTerm, 10))
+	default:
+		return errors.Errorf("failed to get the ISM policy: status %d", res.StatusCode)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"policy": policy})
+	path := ismPoliciesPath + name
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := s.perform(req, nil); err != nil {
+		return errors.Wrap(err, "failed to put the ISM policy")
+	}
+	return s.applyDeploymentsPolicy(ctx)
+}
+
+// applyDeploymentsPolicy applies the ISM policy to the existing monthly
+// deployments indices, which the policy's template does not match as they
+// were created before it; the indices already managed are skipped
+func (s *opensearchStore) applyDeploymentsPolicy(ctx context.Context) error {
+	body, _ := json.Marshal(map[string]string{
+		"policy_id": s.deploymentsPolicyName(),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		ismAddPath+s.deploymentsIndexName+"-*", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var res struct {
+		UpdatedIndices int `json:"updated_indices"`
+	}
+	if err := s.perform(req, &res); err != nil {
+		return errors.Wrap(err, "failed to apply the ISM policy")
+	}
+	if res.UpdatedIndices > 0 {
+		log.FromContext(ctx).Infof("applied the ISM policy %s to %d indices",
+			s.deploymentsPolicyName(), res.UpdatedIndices)
+	}
+	return nil
+}
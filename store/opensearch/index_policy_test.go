@@ -0,0 +1,146 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateDeploymentsPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		policy   string
+		requests []string
+		err      string
+	}{
+		"ok, created": {
+			requests: []string{
+				"GET /_plugins/_ism/policies/deployments-retention",
+				"PUT /_plugins/_ism/policies/deployments-retention",
+				"POST /_plugins/_ism/add/deployments-*",
+			},
+		},
+		"ok, up to date": {
+			// OpenSearch adds the defaults of the actions
+			policy: `{"_seq_no": 3, "_primary_term": 1, "policy": {
+				"description": "Deletes the monthly deployments indices once expired",
+				"default_state": "hot",
+				"states": [{
+					"name": "hot", "actions": [],
+					"transitions": [{"state_name": "delete",
+						"conditions": {"min_index_age": "61d"}}]
+				}, {
+					"name": "delete",
+					"actions": [{"retry": {"count": 3}, "delete": {}}],
+					"transitions": []
+				}],
+				"ism_template": [{"index_patterns": ["deployments-*"],
+					"priority": 100, "last_updated_time": 1682000000000}]
+			}}`,
+			requests: []string{
+				"GET /_plugins/_ism/policies/deployments-retention",
+				"POST /_plugins/_ism/add/deployments-*",
+			},
+		},
+		"ok, updated": {
+			policy: `{"_seq_no": 3, "_primary_term": 1, "policy": {
+				"default_state": "hot",
+				"states": [{
+					"name": "hot", "actions": [],
+					"transitions": [{"state_name": "delete",
+						"conditions": {"min_index_age": "211d"}}]
+				}, {
+					"name": "delete",
+					"actions": [{"delete": {}}],
+					"transitions": []
+				}],
+				"ism_template": [{"index_patterns": ["deployments-*"],
+					"priority": 100}]
+			}}`,
+			requests: []string{
+				"GET /_plugins/_ism/policies/deployments-retention",
+				"PUT /_plugins/_ism/policies/deployments-retention?" +
+					"if_primary_term=1&if_seq_no=3",
+				"POST /_plugins/_ism/add/deployments-*",
+			},
+		},
+		"error, ISM not available": {
+			policy: "error",
+			requests: []string{
+				"GET /_plugins/_ism/policies/deployments-retention",
+			},
+			err: "failed to get the ISM policy: status 400",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests []string
+			srv := newTestServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					request := r.Method + " " + r.URL.Path
+					if r.URL.RawQuery != "" {
+						request += "?" + r.URL.RawQuery
+					}
+					requests = append(requests, request)
+					switch r.Method {
+					case http.MethodGet:
+						switch tc.policy {
+						case "":
+							w.WriteHeader(http.StatusNotFound)
+						case "error":
+							w.WriteHeader(http.StatusBadRequest)
+						default:
+							_, _ = w.Write([]byte(tc.policy))
+						}
+					case http.MethodPut:
+						body, _ := io.ReadAll(r.Body)
+						assert.Contains(t, string(body), `"min_index_age":"61d"`)
+						assert.Contains(t, string(body), `"index_patterns":["deployments-*"]`)
+						w.WriteHeader(http.StatusCreated)
+						_, _ = w.Write([]byte(`{}`))
+					case http.MethodPost:
+						_, _ = w.Write([]byte(`{"updated_indices": 2, "failures": false}`))
+					}
+				}))
+			defer srv.Close()
+
+			store, err := NewStore(
+				WithServerAddresses([]string{srv.URL}),
+				WithDeploymentsIndexName("deployments"),
+				WithDeploymentsRollover(DeploymentsRolloverMonthly),
+				WithDeploymentsRetention(30),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = store.(*opensearchStore).migrateDeploymentsPolicy(context.Background())
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.requests, requests)
+		})
+	}
+}
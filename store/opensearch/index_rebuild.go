@@ -0,0 +1,238 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// CreateDevicesIndex creates the next index behind the tenant's devices
+// alias, without adding it to the alias; it is supported only by the
+// index strategies which use aliases
+func (s *opensearchStore) CreateDevicesIndex(ctx context.Context, tid string) (string, error) {
+	if !s.indexStrategy.IsAlias() || tid == "" {
+		return "", store.ErrIndexRebuildNotSupported
+	}
+	alias := s.GetDevicesIndex(tid)
+	if err := s.ensureIndices(ctx, alias); err != nil {
+		return "", err
+	}
+	indices, err := s.aliasIndices(ctx, alias)
+	if err != nil {
+		return "", err
+	}
+	index := nextIndexName(alias, indices)
+
+	log.FromContext(ctx).Infof("create the index %s to rebuild the alias %s", index, alias)
+	req := opensearchapi.IndicesCreateRequest{
+		Index: index,
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the index")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return "", errors.Errorf("failed to create the index %s: status %d",
+			index, res.StatusCode)
+	}
+	return index, nil
+}
+
+// BulkIndexDevicesInto indexes the devices in the given index, rather
+// than in the index of their tenant
+func (s *opensearchStore) BulkIndexDevicesInto(ctx context.Context, index string,
+	devices []*model.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	items := make([]BulkItem, 0, len(devices))
+	for _, device := range devices {
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "index",
+				Desc: &BulkActionDesc{
+					ID:      device.GetID(),
+					Index:   index,
+					Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
+				},
+			},
+			Doc: device,
+		})
+	}
+	return errors.Wrap(s.bulk(ctx, items), "failed to bulk index")
+}
+
+// SwapDevicesIndex points the tenant's devices alias to the index and
+// deletes the indices previously behind it, in a single atomic operation
+func (s *opensearchStore) SwapDevicesIndex(ctx context.Context, tid, index string) error {
+	if !s.indexStrategy.IsAlias() || tid == "" {
+		return store.ErrIndexRebuildNotSupported
+	}
+	alias := s.GetDevicesIndex(tid)
+	indices, err := s.aliasIndices(ctx, alias)
+	if err != nil {
+		return err
+	}
+	actions := []interface{}{
+		map[string]interface{}{
+			"add": map[string]interface{}{
+				"index":          index,
+				"alias":          alias,
+				"is_write_index": true,
+			},
+		},
+	}
+	for _, oldIndex := range indices {
+		if oldIndex != index {
+			actions = append(actions, map[string]interface{}{
+				"remove_index": map[string]interface{}{
+					"index": oldIndex,
+				},
+			})
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"actions": actions,
+	})
+
+	log.FromContext(ctx).Infof("swap the alias %s to the index %s", alias, index)
+	req := opensearchapi.IndicesUpdateAliasesRequest{
+		Body: strings.NewReader(string(body)),
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to swap the index")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to swap the alias %s to the index %s: status %d",
+			alias, index, res.StatusCode)
+	}
+	return nil
+}
+
+// aliasIndices returns the indices behind the alias
+func (s *opensearchStore) aliasIndices(ctx context.Context, alias string) ([]string, error) {
+	req := opensearchapi.IndicesGetAliasRequest{
+		Name: []string{alias},
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the alias")
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if res.IsError() {
+		return nil, errors.Errorf("failed to get the alias %s: status %d",
+			alias, res.StatusCode)
+	}
+	var resBody map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the alias")
+	}
+	indices := make([]string, 0, len(resBody))
+	for index := range resBody {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// deleteTenantIndices deletes the indices of the tenant's alias, including
+// the ones not behind the alias yet, e.g. created by a rebuild in progress
+func (s *opensearchStore) deleteTenantIndices(ctx context.Context, alias string) error {
+	req := opensearchapi.IndicesGetRequest{
+		Index:           []string{alias + "-*"},
+		AllowNoIndices:  opensearchapi.BoolPtr(true),
+		ExpandWildcards: "all",
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the indices")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to get the indices of the alias %s: status %d",
+			alias, res.StatusCode)
+	}
+	var resBody map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return errors.Wrap(err, "failed to parse the indices")
+	}
+	indices := make([]string, 0, len(resBody))
+	for index := range resBody {
+		if isAliasIndex(alias, index) {
+			indices = append(indices, index)
+		}
+	}
+	// the alias is created again with the next document of the tenant
+	s.aliases.Delete(alias)
+	if len(indices) == 0 {
+		return nil
+	}
+
+	log.FromContext(ctx).Infof("delete the indices %v of the alias %s", indices, alias)
+	deleteReq := opensearchapi.IndicesDeleteRequest{
+		Index: indices,
+	}
+	res, err = deleteReq.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the indices")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to delete the indices of the alias %s: status %d",
+			alias, res.StatusCode)
+	}
+	return nil
+}
+
+// isAliasIndex returns true if the index is one of the numbered indices of
+// the alias, and not the index of another alias sharing its prefix
+func isAliasIndex(alias, index string) bool {
+	suffix := strings.TrimPrefix(index, alias+"-")
+	if suffix == index || len(suffix) != len(firstIndexSuffix)-1 {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+// nextIndexName returns the name of the index following the ones behind
+// the alias, numbered as the first one
+func nextIndexName(alias string, indices []string) string {
+	last := 0
+	for _, index := range indices {
+		n, err := strconv.Atoi(strings.TrimPrefix(index, alias+"-"))
+		if err == nil && n > last {
+			last = n
+		}
+	}
+	return fmt.Sprintf("%s-%06d", alias, last+1)
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextIndexName(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		indices []string
+		index   string
+	}{
+		"no indices": {
+			index: "devices-tenant-000001",
+		},
+		"first index": {
+			indices: []string{"devices-tenant-000001"},
+			index:   "devices-tenant-000002",
+		},
+		"unordered indices": {
+			indices: []string{
+				"devices-tenant-000009",
+				"devices-tenant-000010",
+				"devices-tenant-000002",
+			},
+			index: "devices-tenant-000011",
+		},
+		"foreign indices": {
+			indices: []string{"devices-tenant-old", "devices-tenant-000003"},
+			index:   "devices-tenant-000004",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.index, nextIndexName("devices-tenant", tc.indices))
+		})
+	}
+}
+
+func TestIsAliasIndex(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		index string
+		res   bool
+	}{
+		"first index": {
+			index: "devices-tenant-000001",
+			res:   true,
+		},
+		"rebuilt index": {
+			index: "devices-tenant-000012",
+			res:   true,
+		},
+		"other tenant sharing the prefix": {
+			index: "devices-tenant-2-000001",
+		},
+		"foreign index": {
+			index: "devices-tenant-old",
+		},
+		"other alias": {
+			index: "deployments-tenant-000001",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.res, isAliasIndex("devices-tenant", tc.index))
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+const (
+	// DeploymentsRolloverMonthly stores the deployments in monthly indices,
+	// by creation time, searched through an alias spanning all of them
+	DeploymentsRolloverMonthly = "monthly"
+
+	// rolloverMonthFormat is the format of the month in the names of the
+	// monthly indices
+	rolloverMonthFormat = "2006.01"
+)
+
+// rolloverIndexRegexp matches the names of the indices behind the monthly
+// aliases, capturing the read alias and the month
+var rolloverIndexRegexp = regexp.MustCompile(`^(.+)-(\d{4}\.\d{2})-\d{6}$`)
+
+// deploymentsWriteIndex returns the index, or alias, the deployment is
+// written to: the monthly alias of the month the deployment was created
+// in, if rolling over, so that the updates of the deployment are written
+// to the same index as the deployment
+func (s *opensearchStore) deploymentsWriteIndex(deployment *model.Deployment) string {
+	index := s.GetDeploymentsIndex(deployment.TenantID)
+	if s.deploymentsRollover == "" {
+		return index
+	}
+	created := time.Now()
+	if deployment.DeviceCreated != nil {
+		created = *deployment.DeviceCreated
+	} else if deployment.DeploymentCreated != nil {
+		created = *deployment.DeploymentCreated
+	}
+	return monthlyAlias(index, created)
+}
+
+// monthlyAlias returns the write alias of the month of the read alias
+func monthlyAlias(alias string, t time.Time) string {
+	return alias + "-" + t.UTC().Format(rolloverMonthFormat)
+}
+
+// ensureDeploymentsIndices makes sure the indices the deployments are
+// written to exist; the monthly indices are created with both the monthly
+// write alias and the read alias spanning all the months
+func (s *opensearchStore) ensureDeploymentsIndices(
+	ctx context.Context,
+	indices ...string,
+) error {
+	if s.deploymentsRollover == "" {
+		return s.ensureIndices(ctx, indices...)
+	}
+	for _, alias := range indices {
+		if _, ok := s.aliases.Load(alias); ok {
+			continue
+		}
+		matches := rolloverIndexRegexp.FindStringSubmatch(alias + firstIndexSuffix)
+		if matches == nil {
+			return errors.Errorf("invalid monthly alias: %s", alias)
+		}
+		if err := s.ensureAlias(ctx, alias, matches[1]); err != nil {
+			return err
+		}
+		s.aliases.Store(alias, struct{}{})
+	}
+	return nil
+}
+
+// dropExpiredDeploymentsIndices deletes the monthly deployments indices of
+// the months which ended before the cutoff
+func (s *opensearchStore) dropExpiredDeploymentsIndices(
+	ctx context.Context,
+	cutoff time.Time,
+) error {
+	req := opensearchapi.IndicesGetRequest{
+		Index:          []string{s.deploymentsIndexName + "-*"},
+		AllowNoIndices: opensearchapi.BoolPtr(true),
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the indices")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to get the deployments indices: status %d",
+			res.StatusCode)
+	}
+	var resBody map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return errors.Wrap(err, "failed to parse the indices")
+	}
+	indices := make([]string, 0, len(resBody))
+	for index := range resBody {
+		if isExpiredMonthlyIndex(index, cutoff) {
+			indices = append(indices, index)
+		}
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+
+	log.FromContext(ctx).Infof("delete the expired deployments indices %v", indices)
+	deleteReq := opensearchapi.IndicesDeleteRequest{
+		Index: indices,
+	}
+	res, err = deleteReq.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the indices")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to delete the expired deployments indices: status %d",
+			res.StatusCode)
+	}
+	return nil
+}
+
+// isExpiredMonthlyIndex returns true if the index is a monthly index of a
+// month which ended before the cutoff
+func isExpiredMonthlyIndex(index string, cutoff time.Time) bool {
+	matches := rolloverIndexRegexp.FindStringSubmatch(index)
+	if matches == nil {
+		return false
+	}
+	month, err := time.Parse(rolloverMonthFormat, matches[2])
+	if err != nil {
+		return false
+	}
+	return !month.AddDate(0, 1, 0).After(cutoff)
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestNewStoreDeploymentsRollover(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		strategy string
+		rollover string
+		err      string
+	}{
+		"ok, no rollover": {
+			strategy: IndexStrategyPerTenant,
+		},
+		"ok, monthly": {
+			strategy: IndexStrategyShared,
+			rollover: DeploymentsRolloverMonthly,
+		},
+		"ok, monthly with hashed indices": {
+			strategy: IndexStrategyHashed,
+			rollover: DeploymentsRolloverMonthly,
+		},
+		"error, per-tenant indices": {
+			strategy: IndexStrategyPerTenant,
+			rollover: DeploymentsRolloverMonthly,
+			err: "the rollover of the deployments indices is not supported " +
+				"with per-tenant indices",
+		},
+		"error, unknown rollover": {
+			rollover: "weekly",
+			err:      `unknown deployments rollover: "weekly"`,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewStore(
+				WithIndexStrategy(tc.strategy),
+				WithIndexGroups(4),
+				WithDeploymentsRollover(tc.rollover),
+			)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeploymentsWriteIndex(t *testing.T) {
+	t.Parallel()
+
+	deviceCreated := time.Date(2023, 9, 30, 23, 0, 0, 0, time.UTC)
+	deploymentCreated := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		rollover   string
+		deployment *model.Deployment
+		index      string
+	}{
+		"no rollover": {
+			deployment: &model.Deployment{
+				TenantID:      "tenant",
+				DeviceCreated: &deviceCreated,
+			},
+			index: "deployments",
+		},
+		"monthly, device created": {
+			rollover: DeploymentsRolloverMonthly,
+			deployment: &model.Deployment{
+				TenantID:          "tenant",
+				DeviceCreated:     &deviceCreated,
+				DeploymentCreated: &deploymentCreated,
+			},
+			index: "deployments-2023.09",
+		},
+		"monthly, deployment created": {
+			rollover: DeploymentsRolloverMonthly,
+			deployment: &model.Deployment{
+				TenantID:          "tenant",
+				DeploymentCreated: &deploymentCreated,
+			},
+			index: "deployments-2023.08",
+		},
+		"monthly, no creation time": {
+			rollover: DeploymentsRolloverMonthly,
+			deployment: &model.Deployment{
+				TenantID: "tenant",
+			},
+			index: monthlyAlias("deployments", time.Now()),
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := &opensearchStore{
+				deploymentsIndexName: "deployments",
+				deploymentsRollover:  tc.rollover,
+				indexStrategy:        sharedIndexStrategy{},
+			}
+			assert.Equal(t, tc.index, s.deploymentsWriteIndex(tc.deployment))
+		})
+	}
+}
+
+func TestIsExpiredMonthlyIndex(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		index   string
+		expired bool
+	}{
+		"expired": {
+			index:   "deployments-2023.08-000001",
+			expired: true,
+		},
+		"expired, ended at the cutoff": {
+			index:   "deployments-3-2023.09-000002",
+			expired: true,
+		},
+		"month of the cutoff": {
+			index: "deployments-2023.10-000001",
+		},
+		"not monthly": {
+			index: "deployments-000001",
+		},
+		"hashed, not monthly": {
+			index: "deployments-3",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expired, isExpiredMonthlyIndex(tc.index, cutoff))
+		})
+	}
+}
+
+func TestBulkIndexDeploymentsRollover(t *testing.T) {
+	t.Parallel()
+
+	deviceCreated := time.Date(2023, 9, 30, 23, 0, 0, 0, time.UTC)
+	deployments := []*model.Deployment{{
+		ID:            "1",
+		TenantID:      "tenant",
+		DeviceCreated: &deviceCreated,
+		DeviceStatus:  "downloading",
+		DeviceStatusHistory: []model.DeviceStatusTransition{{
+			Status:    "downloading",
+			Timestamp: deviceCreated,
+		}},
+	}}
+
+	var requests []string
+	srv := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "HEAD /deployments-2023.09":
+			w.WriteHeader(http.StatusNotFound)
+		case "PUT /deployments-2023.09-000001":
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"aliases": {
+				"deployments-2023.09": {"is_write_index": true},
+				"deployments": {}
+			}}`, string(body))
+			_, _ = w.Write([]byte(`{"acknowledged": true}`))
+		case "POST /_bulk":
+			body, _ := io.ReadAll(r.Body)
+			lines := strings.Split(string(body), "\n")
+			assert.JSONEq(t, `{"update": {"_id": "1", "_index": "deployments-2023.09",
+				"routing": "tenant"}}`, lines[0])
+			// the transitions are appended to the indexed history
+			var update struct {
+				Script struct {
+					Params struct {
+						Doc         map[string]interface{}         `json:"doc"`
+						Transitions []model.DeviceStatusTransition `json:"transitions"`
+					} `json:"params"`
+				} `json:"script"`
+				Upsert model.Deployment `json:"upsert"`
+			}
+			if assert.NoError(t, json.Unmarshal([]byte(lines[1]), &update)) {
+				assert.NotContains(t, update.Script.Params.Doc, "device_status_history")
+				assert.Equal(t, deployments[0].DeviceStatusHistory,
+					update.Script.Params.Transitions)
+				assert.Equal(t, *deployments[0], update.Upsert)
+			}
+			_, _ = w.Write([]byte(`{"errors": false, "items": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithDeploymentsIndexName("deployments"),
+		WithDeploymentsRollover(DeploymentsRolloverMonthly),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the monthly index is created only once
+	for i := 0; i < 2; i++ {
+		err = store.BulkIndexDeployments(context.Background(), deployments)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{
+		"HEAD /deployments-2023.09",
+		"PUT /deployments-2023.09-000001",
+		"POST /_bulk",
+		"POST /_bulk",
+	}, requests)
+}
+
+func TestMigrateMappingsRollover(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	srv := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /devices*/_mapping", "GET /software*/_mapping":
+			_, _ = w.Write([]byte(`{}`))
+		case "GET /deployments*/_mapping":
+			fmt.Fprintf(w, `{
+				"deployments-2023.09-000001": {"mappings": {"_meta": {"version": 1}}},
+				"deployments-2023.10-000001": {"mappings": {"_meta": {"version": %d}}}
+			}`, deploymentsMappingVersion)
+		case "GET /deployments-2023.09-000001/_alias":
+			_, _ = w.Write([]byte(`{"deployments-2023.09-000001": {"aliases": {
+				"deployments": {},
+				"deployments-2023.09": {"is_write_index": true}
+			}}}`))
+		case "PUT /deployments-2023.09-000002":
+			_, _ = w.Write([]byte(`{"acknowledged": true}`))
+		case "POST /_reindex":
+			_, _ = w.Write([]byte(`{"task": "node:1"}`))
+		case "GET /_tasks/node:1":
+			_, _ = w.Write([]byte(`{"completed": true, "response": {"created": 0}}`))
+		case "POST /_aliases":
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"actions": [
+				{"add": {"index": "deployments-2023.09-000002",
+					"alias": "deployments-2023.09", "is_write_index": true}},
+				{"add": {"index": "deployments-2023.09-000002",
+					"alias": "deployments"}},
+				{"remove_index": {"index": "deployments-2023.09-000001"}}
+			]}`, string(body))
+			_, _ = w.Write([]byte(`{"acknowledged": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithDevicesIndexName("devices"),
+		WithDeploymentsIndexName("deployments"),
+		WithDeploymentsRollover(DeploymentsRolloverMonthly),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = store.MigrateMappings(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /devices*/_mapping",
+		"GET /deployments*/_mapping",
+		"GET /deployments-2023.09-000001/_alias",
+		"PUT /deployments-2023.09-000002",
+		"POST /_reindex",
+		"GET /_tasks/node:1",
+		"POST /_aliases",
+		"GET /software*/_mapping",
+	}, requests)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// defaultRefreshInterval is the default refresh interval of the indices
+const defaultRefreshInterval = "1s"
+
+// UpdateIndexSettings updates the dynamic settings of all the existing
+// indices of the given kind, whatever the index strategy; the indices
+// created afterwards get the settings of the index templates
+func (s *opensearchStore) UpdateIndexSettings(
+	ctx context.Context,
+	index string,
+	settings *model.IndexSettings,
+) error {
+	var indexName string
+	switch index {
+	case model.IndexDevices:
+		indexName = s.devicesIndexName
+	case model.IndexDeployments:
+		indexName = s.deploymentsIndexName
+	case model.IndexSoftware:
+		indexName = s.softwareIndexName
+	default:
+		return errors.Errorf("unknown index: %q", index)
+	}
+	body, err := json.Marshal(map[string]interface{}{"index": settings})
+	if err != nil {
+		return err
+	}
+	log.FromContext(ctx).Infof("update the settings of the %s indices: %s",
+		indexName, body)
+
+	req := opensearchapi.IndicesPutSettingsRequest{
+		Index:             []string{indexName, indexName + "-*"},
+		Body:              bytes.NewReader(body),
+		AllowNoIndices:    opensearchapi.BoolPtr(true),
+		IgnoreUnavailable: opensearchapi.BoolPtr(true),
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to update the index settings")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		body, _ := ioutil.ReadAll(res.Body)
+		return errors.Errorf("failed to update the index settings: status %d: %s",
+			res.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestUpdateIndexSettings(t *testing.T) {
+	t.Parallel()
+
+	replicas := 2
+	refreshInterval := "-1"
+	testCases := map[string]struct {
+		index    string
+		settings *model.IndexSettings
+		status   int
+
+		path string
+		body string
+		err  string
+	}{
+		"ok, devices": {
+			index: model.IndexDevices,
+			settings: &model.IndexSettings{
+				Replicas:        &replicas,
+				RefreshInterval: &refreshInterval,
+			},
+			path: "/devices,devices-*/_settings",
+			body: `{"index": {"number_of_replicas": 2, "refresh_interval": "-1"}}`,
+		},
+		"ok, software": {
+			index: model.IndexSoftware,
+			settings: &model.IndexSettings{
+				RefreshInterval: &refreshInterval,
+			},
+			path: "/software,software-*/_settings",
+			body: `{"index": {"refresh_interval": "-1"}}`,
+		},
+		"error, unknown index": {
+			index:    "alerts",
+			settings: &model.IndexSettings{Replicas: &replicas},
+			err:      `unknown index: "alerts"`,
+		},
+		"error, opensearch": {
+			index:    model.IndexDeployments,
+			settings: &model.IndexSettings{Replicas: &replicas},
+			status:   http.StatusBadRequest,
+			path:     "/deployments,deployments-*/_settings",
+			body:     `{"index": {"number_of_replicas": 2}}`,
+			err:      "failed to update the index settings: status 400: {}",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newTestServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, http.MethodPut, r.Method)
+					assert.Equal(t, tc.path, r.URL.Path)
+					assert.Equal(t, "true", r.URL.Query().Get("ignore_unavailable"))
+					body, _ := io.ReadAll(r.Body)
+					assert.JSONEq(t, tc.body, string(body))
+					if tc.status != 0 {
+						w.WriteHeader(tc.status)
+					}
+					_, _ = w.Write([]byte(`{}`))
+				}))
+			defer srv.Close()
+
+			store, err := NewStore(
+				WithServerAddresses([]string{srv.URL}),
+				WithDevicesIndexName("devices"),
+				WithDeploymentsIndexName("deployments"),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = store.UpdateIndexSettings(context.Background(), tc.index, tc.settings)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+// defaultSoftwareIndexName is the default name of the software index
+const defaultSoftwareIndexName = "software"
+
+const indexSoftwareTemplate = `{
+	"index_patterns": ["%s*"],
+	"priority": 1,
+	"template": {
+		"settings": {
+			"number_of_shards": %d,
+			"number_of_replicas": %d,
+			"refresh_interval": "%s"
+		},
+		"mappings": {
+			"_meta": {
+				"version": %d
+			},
+			"dynamic": false,
+			"date_detection": false,
+			"numeric_detection": false,
+			"_source": {
+				"enabled": true
+			},
+			"properties": {
+				"id": {
+					"type": "keyword"
+				},
+				"tenant_id": {
+					"type": "keyword"
+				},
+				"group": {
+					"type": "keyword"
+				},
+				"software": {
+					"type": "keyword"
+				},
+				"software_versions": {
+					"type": "keyword"
+				},
+				"updated_at": {
+					"type": "date"
+				}
+			}
+		}
+	}
+}`
@@ -0,0 +1,224 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+)
+
+const (
+	// IndexStrategyShared stores the documents of all the tenants in a
+	// single index, routing them by tenant
+	IndexStrategyShared = "shared"
+	// IndexStrategyPerTenant stores the documents of each tenant in a
+	// dedicated index, accessed through an alias named after the tenant
+	IndexStrategyPerTenant = "per_tenant"
+	// IndexStrategyHashed spreads the tenants across a fixed number of
+	// index groups by hashing the tenant ID, routing them by tenant
+	IndexStrategyHashed = "hashed"
+
+	// firstIndexSuffix is the suffix of the first index behind a
+	// per-tenant alias
+	firstIndexSuffix = "-000001"
+)
+
+// indexStrategy maps the tenants to the index and routing key of their
+// documents
+type indexStrategy interface {
+	// Index returns the index, or alias, holding the tenant's documents
+	Index(baseName, tid string) string
+	// RoutingKey returns the routing key of the tenant's documents
+	RoutingKey(tid string) string
+	// Indices returns the indices to create when migrating
+	Indices(baseName string) []string
+	// IsAlias returns true if the indices are accessed through aliases
+	// which must exist before indexing any document
+	IsAlias() bool
+}
+
+func newIndexStrategy(name string, groups int) (indexStrategy, error) {
+	switch name {
+	case IndexStrategyShared, "":
+		return sharedIndexStrategy{}, nil
+	case IndexStrategyPerTenant:
+		return perTenantIndexStrategy{}, nil
+	case IndexStrategyHashed:
+		if groups < 1 {
+			return nil, errors.Errorf("invalid number of index groups: %d", groups)
+		}
+		return hashedIndexStrategy{groups: uint32(groups)}, nil
+	}
+	return nil, errors.Errorf("unknown index strategy: %q", name)
+}
+
+type sharedIndexStrategy struct{}
+
+func (sharedIndexStrategy) Index(baseName, tid string) string {
+	return baseName
+}
+
+func (sharedIndexStrategy) RoutingKey(tid string) string {
+	return tid
+}
+
+func (sharedIndexStrategy) Indices(baseName string) []string {
+	return []string{baseName}
+}
+
+func (sharedIndexStrategy) IsAlias() bool {
+	return false
+}
+
+type perTenantIndexStrategy struct{}
+
+// Index returns the alias of the tenant's index; documents without tenant,
+// e.g. in single-tenant installations, are stored in the base index
+func (perTenantIndexStrategy) Index(baseName, tid string) string {
+	if tid == "" {
+		return baseName
+	}
+	return baseName + "-" + strings.ToLower(tid)
+}
+
+// RoutingKey returns no routing key: the tenant owns the whole index
+func (perTenantIndexStrategy) RoutingKey(tid string) string {
+	return ""
+}
+
+func (perTenantIndexStrategy) Indices(baseName string) []string {
+	return []string{baseName}
+}
+
+func (perTenantIndexStrategy) IsAlias() bool {
+	return true
+}
+
+type hashedIndexStrategy struct {
+	groups uint32
+}
+
+func (s hashedIndexStrategy) Index(baseName, tid string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tid))
+	return fmt.Sprintf("%s-%d", baseName, h.Sum32()%s.groups)
+}
+
+func (hashedIndexStrategy) RoutingKey(tid string) string {
+	return tid
+}
+
+func (s hashedIndexStrategy) Indices(baseName string) []string {
+	indices := make([]string, s.groups)
+	for i := range indices {
+		indices[i] = fmt.Sprintf("%s-%d", baseName, i)
+	}
+	return indices
+}
+
+func (hashedIndexStrategy) IsAlias() bool {
+	return false
+}
+
+// ensureIndices makes sure the aliases of the given indices exist, creating
+// the first index behind each of them if needed; it is a no-op for the
+// strategies which do not use aliases
+func (s *opensearchStore) ensureIndices(ctx context.Context, aliases ...string) error {
+	if !s.indexStrategy.IsAlias() {
+		return nil
+	}
+	for _, alias := range aliases {
+		if _, ok := s.aliases.Load(alias); ok {
+			continue
+		}
+		if err := s.ensureAlias(ctx, alias); err != nil {
+			return err
+		}
+		s.aliases.Store(alias, struct{}{})
+	}
+	return nil
+}
+
+// ensureAlias makes sure the alias exists, creating the first index behind
+// it, also behind the read aliases, if needed
+func (s *opensearchStore) ensureAlias(
+	ctx context.Context,
+	alias string,
+	readAliases ...string,
+) error {
+	req := opensearchapi.IndicesExistsRequest{
+		Index: []string{alias},
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify the index")
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	} else if res.StatusCode != http.StatusNotFound {
+		return errors.Errorf("failed to verify the index %s: status %d",
+			alias, res.StatusCode)
+	}
+
+	indexName := alias + firstIndexSuffix
+	log.FromContext(ctx).Infof("create the index %s with alias %s", indexName, alias)
+	aliases := map[string]interface{}{
+		alias: map[string]interface{}{
+			"is_write_index": true,
+		},
+	}
+	for _, readAlias := range readAliases {
+		aliases[readAlias] = map[string]interface{}{}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"aliases": aliases,
+	})
+	createReq := opensearchapi.IndicesCreateRequest{
+		Index: indexName,
+		Body:  strings.NewReader(string(body)),
+	}
+	res, err = createReq.Do(ctx, s.client)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the index")
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusBadRequest {
+		// another indexer may have created the index in the meanwhile
+		var resBody struct {
+			Error struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&resBody)
+		if resBody.Error.Type == "resource_already_exists_exception" {
+			return nil
+		}
+	}
+	if res.IsError() {
+		return errors.Errorf("failed to create the index %s: status %d",
+			indexName, res.StatusCode)
+	}
+	return nil
+}
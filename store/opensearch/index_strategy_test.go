@@ -0,0 +1,95 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexStrategy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		strategy string
+		groups   int
+
+		index   string
+		routing string
+		indices []string
+		alias   bool
+		err     string
+	}{
+		"shared": {
+			strategy: IndexStrategyShared,
+
+			index:   "devices",
+			routing: "63f4c2ddb1a0ea0c1f0e1d6a",
+			indices: []string{"devices"},
+		},
+		"default": {
+			index:   "devices",
+			routing: "63f4c2ddb1a0ea0c1f0e1d6a",
+			indices: []string{"devices"},
+		},
+		"per tenant": {
+			strategy: IndexStrategyPerTenant,
+
+			index:   "devices-63f4c2ddb1a0ea0c1f0e1d6a",
+			indices: []string{"devices"},
+			alias:   true,
+		},
+		"hashed": {
+			strategy: IndexStrategyHashed,
+			groups:   4,
+
+			index:   "devices-2",
+			routing: "63f4c2ddb1a0ea0c1f0e1d6a",
+			indices: []string{"devices-0", "devices-1", "devices-2", "devices-3"},
+		},
+		"error, hashed without groups": {
+			strategy: IndexStrategyHashed,
+
+			err: "invalid number of index groups: 0",
+		},
+		"error, unknown strategy": {
+			strategy: "dunno",
+
+			err: `unknown index strategy: "dunno"`,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			strategy, err := newIndexStrategy(tc.strategy, tc.groups)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.index, strategy.Index("devices", "63f4c2ddb1a0ea0c1f0e1d6a"))
+			assert.Equal(t, tc.routing, strategy.RoutingKey("63f4c2ddb1a0ea0c1f0e1d6a"))
+			assert.Equal(t, tc.indices, strategy.Indices("devices"))
+			assert.Equal(t, tc.alias, strategy.IsAlias())
+			// documents without tenant always go to the base index
+			if tc.strategy == IndexStrategyPerTenant {
+				assert.Equal(t, "devices", strategy.Index("devices", ""))
+			}
+		})
+	}
+}
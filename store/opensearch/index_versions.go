@@ -0,0 +1,110 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// indexedVersion is the version of an indexed device document
+type indexedVersion struct {
+	SeqNo       int64
+	PrimaryTerm int64
+	UpdatedAt   *time.Time
+}
+
+type mgetDoc struct {
+	ID          string `json:"_id"`
+	Found       bool   `json:"found"`
+	SeqNo       int64  `json:"_seq_no"`
+	PrimaryTerm int64  `json:"_primary_term"`
+	Source      struct {
+		UpdatedAt *time.Time `json:"updated_at"`
+	} `json:"_source"`
+	Error interface{} `json:"error,omitempty"`
+}
+
+// getIndexedVersions returns the versions of the indexed documents of the
+// devices, by ID; the devices which are not indexed are not returned
+func (s *opensearchStore) getIndexedVersions(
+	ctx context.Context,
+	devices []*model.Device,
+) (map[string]indexedVersion, error) {
+	type docRef struct {
+		Index   string `json:"_index"`
+		ID      string `json:"_id"`
+		Routing string `json:"routing"`
+	}
+	docs := make([]docRef, 0, len(devices))
+	for _, device := range devices {
+		docs = append(docs, docRef{
+			Index:   s.GetDevicesIndex(device.GetTenantID()),
+			ID:      device.GetID(),
+			Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
+		})
+	}
+	body, err := json.Marshal(map[string]interface{}{"docs": docs})
+	if err != nil {
+		return nil, err
+	}
+	req := opensearchapi.MgetRequest{
+		Body:           bytes.NewReader(body),
+		SourceIncludes: []string{"updated_at"},
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the indexed devices")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, errors.Errorf("failed to get the indexed devices: status %d",
+			res.StatusCode)
+	}
+	var resBody struct {
+		Docs []mgetDoc `json:"docs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the indexed devices")
+	}
+	versions := make(map[string]indexedVersion, len(resBody.Docs))
+	for _, doc := range resBody.Docs {
+		if doc.Error != nil {
+			return nil, errors.Errorf("failed to get the indexed device %s: %v",
+				doc.ID, doc.Error)
+		} else if doc.Found {
+			versions[doc.ID] = indexedVersion{
+				SeqNo:       doc.SeqNo,
+				PrimaryTerm: doc.PrimaryTerm,
+				UpdatedAt:   doc.Source.UpdatedAt,
+			}
+		}
+	}
+	return versions, nil
+}
+
+// isStale returns true if the device is stale: the indexed document was
+// built from more recent inventory data
+func (v indexedVersion) isStale(device *model.Device) bool {
+	return v.UpdatedAt != nil && device.UpdatedAt != nil &&
+		v.UpdatedAt.After(*device.UpdatedAt)
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestBulkIndexDevicesVersions(t *testing.T) {
+	t.Parallel()
+
+	updatedAt := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	newDevice := func(id string) *model.Device {
+		device := model.NewDevice("tenant", id)
+		device.SetUpdatedAt(updatedAt)
+		return device
+	}
+
+	var requests []string
+	srv := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /_mget":
+			assert.Equal(t, "updated_at", r.URL.Query().Get("_source_includes"))
+			assert.JSONEq(t, `{"docs": [
+				{"_index": "devices", "_id": "1", "routing": "tenant"},
+				{"_index": "devices", "_id": "2", "routing": "tenant"},
+				{"_index": "devices", "_id": "3", "routing": "tenant"}
+			]}`, string(body))
+			_, _ = w.Write([]byte(`{"docs": [
+				{"_id": "1", "found": true, "_seq_no": 10, "_primary_term": 1,
+					"_source": {"updated_at": "2023-09-01T13:00:00Z"}},
+				{"_id": "2", "found": true, "_seq_no": 0, "_primary_term": 2,
+					"_source": {"updated_at": "2023-09-01T11:00:00Z"}},
+				{"_id": "3", "found": false}
+			]}`))
+		case "POST /_bulk":
+			lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+			if assert.Len(t, lines, 5) {
+				assert.JSONEq(t, `{"index": {"_id": "2", "_index": "devices",
+					"routing": "tenant", "if_seq_no": 0, "if_primary_term": 2}}`,
+					lines[0])
+				assert.JSONEq(t, `{"create": {"_id": "3", "_index": "devices",
+					"routing": "tenant"}}`, lines[2])
+				assert.JSONEq(t, `{"delete": {"_id": "4", "_index": "devices",
+					"routing": "tenant"}}`, lines[4])
+			}
+			_, _ = w.Write([]byte(`{"errors": false, "items": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithDevicesIndexName("devices"),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = store.BulkIndexDevices(context.Background(),
+		[]*model.Device{newDevice("1"), newDevice("2"), newDevice("3")},
+		[]*model.Device{model.NewDevice("tenant", "4")},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"POST /_mget", "POST /_bulk"}, requests)
+}
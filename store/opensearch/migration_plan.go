@@ -0,0 +1,272 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// templateDefinition is the part of the index templates the plan compares
+type templateDefinition struct {
+	IndexPatterns []string `json:"index_patterns"`
+	Template      struct {
+		Settings map[string]interface{} `json:"settings"`
+		Mappings struct {
+			Meta struct {
+				Version *int `json:"version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	} `json:"template"`
+}
+
+// PlanMigrations returns the changes Migrate and MigrateMappings would
+// apply to the cluster, without applying them: the index templates, the
+// indices and aliases to create, the mappings to migrate, and the ISM
+// policy of the deployments indices
+func (s *opensearchStore) PlanMigrations(ctx context.Context) ([]model.MigrationChange, error) {
+	changes := []model.MigrationChange{}
+	for _, template := range s.indexTemplates() {
+		change, err := s.planIndexTemplate(ctx, template)
+		if err != nil {
+			return nil, err
+		} else if change != nil {
+			changes = append(changes, *change)
+		}
+		for _, index := range s.indexStrategy.Indices(template.name) {
+			change := model.MigrationChange{
+				Kind:   model.MigrationKindIndex,
+				Name:   index,
+				Action: model.MigrationActionCreate,
+			}
+			if s.rolledOver(template) {
+				change.Kind = model.MigrationKindAlias
+				change.Name = monthlyAlias(index, time.Now())
+			} else if s.indexStrategy.IsAlias() {
+				change.Kind = model.MigrationKindAlias
+			}
+			exists, err := s.indexExists(ctx, change.Name)
+			if err != nil {
+				return nil, err
+			} else if !exists {
+				changes = append(changes, change)
+			}
+		}
+		outdated, _, err := s.outdatedIndices(ctx, template.name, template.mappingVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range outdated {
+			changes = append(changes, model.MigrationChange{
+				Kind:   model.MigrationKindMapping,
+				Name:   o.index,
+				Action: model.MigrationActionReindex,
+				Details: []string{fmt.Sprintf("mapping version %d -> %d",
+					o.version, template.mappingVersion)},
+				Breaking: true,
+			})
+		}
+	}
+	if s.deploymentsRollover != "" && s.deploymentsRetentionDays > 0 &&
+		s.distribution != DistributionElasticsearch {
+		change, err := s.planDeploymentsPolicy(ctx)
+		if err != nil {
+			return nil, err
+		} else if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+// planIndexTemplate compares the index template with the one of the
+// cluster, returning the change to apply, if any
+func (s *opensearchStore) planIndexTemplate(
+	ctx context.Context,
+	template indexTemplate,
+) (*model.MigrationChange, error) {
+	req := opensearchapi.IndicesGetIndexTemplateRequest{
+		Name:         []string{template.name},
+		FlatSettings: opensearchapi.BoolPtr(true),
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the index template")
+	}
+	defer res.Body.Close()
+	change := &model.MigrationChange{
+		Kind:   model.MigrationKindIndexTemplate,
+		Name:   template.name,
+		Action: model.MigrationActionCreate,
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return change, nil
+	} else if res.IsError() {
+		return nil, errors.Errorf("failed to get the index template %s: status %d",
+			template.name, res.StatusCode)
+	}
+	var resBody struct {
+		IndexTemplates []struct {
+			IndexTemplate templateDefinition `json:"index_template"`
+		} `json:"index_templates"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the index template")
+	} else if len(resBody.IndexTemplates) == 0 {
+		return change, nil
+	}
+	var desired templateDefinition
+	if err := json.Unmarshal([]byte(template.body), &desired); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the index template")
+	}
+	change.Action = model.MigrationActionUpdate
+	change.Details = diffTemplates(resBody.IndexTemplates[0].IndexTemplate, desired)
+	if len(change.Details) == 0 {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// diffTemplates describes the differences between the current index
+// template and the desired one
+func diffTemplates(current, desired templateDefinition) []string {
+	var details []string
+	if !reflect.DeepEqual(current.IndexPatterns, desired.IndexPatterns) {
+		details = append(details, fmt.Sprintf("index_patterns %v -> %v",
+			current.IndexPatterns, desired.IndexPatterns))
+	}
+	currentSettings := flattenSettings(current.Template.Settings)
+	desiredSettings := flattenSettings(desired.Template.Settings)
+	keys := make([]string, 0, len(desiredSettings))
+	for key := range desiredSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if currentSettings[key] != desiredSettings[key] {
+			details = append(details, fmt.Sprintf("%s %q -> %q",
+				key, currentSettings[key], desiredSettings[key]))
+		}
+	}
+	currentVersion, desiredVersion := baseMappingVersion, 0
+	if current.Template.Mappings.Meta.Version != nil {
+		currentVersion = *current.Template.Mappings.Meta.Version
+	}
+	if desired.Template.Mappings.Meta.Version != nil {
+		desiredVersion = *desired.Template.Mappings.Meta.Version
+	}
+	if currentVersion != desiredVersion {
+		details = append(details, fmt.Sprintf("mapping version %d -> %d",
+			currentVersion, desiredVersion))
+	}
+	return details
+}
+
+// flattenSettings returns the settings with their flat "index." names and
+// their values as strings, as returned by the cluster
+func flattenSettings(settings map[string]interface{}) map[string]string {
+	flat := make(map[string]string, len(settings))
+	var flatten func(prefix string, settings map[string]interface{})
+	flatten = func(prefix string, settings map[string]interface{}) {
+		for key, value := range settings {
+			if nested, ok := value.(map[string]interface{}); ok {
+				flatten(prefix+key+".", nested)
+			} else {
+				flat[prefix+key] = fmt.Sprint(value)
+			}
+		}
+	}
+	flatten("", settings)
+	for key, value := range flat {
+		if !strings.HasPrefix(key, "index.") {
+			delete(flat, key)
+			flat["index."+key] = value
+		}
+	}
+	return flat
+}
+
+// indexExists returns true if the index, or the alias, exists
+func (s *opensearchStore) indexExists(ctx context.Context, index string) (bool, error) {
+	req := opensearchapi.IndicesExistsRequest{
+		Index: []string{index},
+	}
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to verify the index")
+	}
+	res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Errorf("failed to verify the index %s: status %d",
+			index, res.StatusCode)
+	}
+}
+
+// planDeploymentsPolicy compares the ISM policy of the monthly deployments
+// indices with the one of the cluster, returning the change to apply, if any
+func (s *opensearchStore) planDeploymentsPolicy(
+	ctx context.Context,
+) (*model.MigrationChange, error) {
+	name := s.deploymentsPolicyName()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		ismPoliciesPath+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Perform(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the ISM policy")
+	}
+	defer res.Body.Close()
+
+	change := &model.MigrationChange{
+		Kind:   model.MigrationKindPolicy,
+		Name:   name,
+		Action: model.MigrationActionCreate,
+	}
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return change, nil
+	case http.StatusOK:
+	default:
+		return nil, errors.Errorf("failed to get the ISM policy: status %d", res.StatusCode)
+	}
+	var current struct {
+		Policy ismPolicy `json:"policy"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&current); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the ISM policy")
+	} else if current.Policy.equivalent(s.deploymentsPolicy()) {
+		return nil, nil
+	}
+	change.Action = model.MigrationActionUpdate
+	return change, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestPlanMigrations(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.URL.Path {
+			case "GET /_index_template/devices":
+				assert.Equal(t, "true", r.URL.Query().Get("flat_settings"))
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"index_templates": [{
+					"name": "devices",
+					"index_template": {
+						"index_patterns": ["devices*"],
+						"template": {
+							"settings": {
+								"index.number_of_shards": "1",
+								"index.number_of_replicas": "0",
+								"index.refresh_interval": "1s"
+							},
+							"mappings": {"_meta": {"version": %d}}
+						}
+					}
+				}]}`, devicesMappingVersion-1)))
+			case "GET /_index_template/software":
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"index_templates": [{
+					"name": "software",
+					"index_template": {
+						"index_patterns": ["software*"],
+						"template": {
+							"settings": {
+								"index.number_of_shards": "1",
+								"index.number_of_replicas": "0",
+								"index.refresh_interval": "1s"
+							},
+							"mappings": {"_meta": {"version": %d}}
+						}
+					}
+				}]}`, softwareMappingVersion)))
+			case "HEAD /devices", "HEAD /software":
+			case "GET /devices*/_mapping":
+				_, _ = w.Write([]byte(fmt.Sprintf(
+					`{"devices": {"mappings": {"_meta": {"version": %d}}}}`,
+					devicesMappingVersion-1)))
+			case "GET /devices/_alias":
+				_, _ = w.Write([]byte(`{"devices": {"aliases": {}}}`))
+			case "GET /software*/_mapping":
+				_, _ = w.Write([]byte(fmt.Sprintf(
+					`{"software": {"mappings": {"_meta": {"version": %d}}}}`,
+					softwareMappingVersion)))
+			case "GET /deployments*/_mapping":
+				_, _ = w.Write([]byte(`{}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{}`))
+			}
+		}))
+	defer srv.Close()
+
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithDevicesIndexName("devices"),
+		WithDevicesIndexReplicas(1),
+		WithDeploymentsIndexName("deployments"),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	changes, err := store.PlanMigrations(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.MigrationChange{{
+		Kind:   model.MigrationKindIndexTemplate,
+		Name:   "devices",
+		Action: model.MigrationActionUpdate,
+		Details: []string{
+			`index.number_of_replicas "0" -> "1"`,
+			fmt.Sprintf("mapping version %d -> %d",
+				devicesMappingVersion-1, devicesMappingVersion),
+		},
+	}, {
+		Kind:   model.MigrationKindMapping,
+		Name:   "devices",
+		Action: model.MigrationActionReindex,
+		Details: []string{fmt.Sprintf("mapping version %d -> %d",
+			devicesMappingVersion-1, devicesMappingVersion)},
+		Breaking: true,
+	}, {
+		Kind:   model.MigrationKindIndexTemplate,
+		Name:   "deployments",
+		Action: model.MigrationActionCreate,
+	}, {
+		Kind:   model.MigrationKindIndex,
+		Name:   "deployments",
+		Action: model.MigrationActionCreate,
+	}}, changes)
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go"
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+
+	"github.com/mendersoftware/reporting/store"
+)
+
+const (
+	defaultReadHealthCheckInterval = 10 * time.Second
+	readHealthCheckTimeout         = 5 * time.Second
+)
+
+// WithReadAddresses sets the addresses of the cluster the queries run on,
+// e.g. a read replica or a search-only cluster, while the documents are
+// written to the server addresses. The health of the read cluster is
+// checked every interval, at most: the queries fail over to the server
+// addresses while the read cluster is unhealthy or fails to answer.
+func WithReadAddresses(addresses []string, healthCheckInterval time.Duration) StoreOption {
+	return func(s *opensearchStore) {
+		s.readAddresses = addresses
+		s.readHealthCheckInterval = healthCheckInterval
+	}
+}
+
+// clusterError is the error of a cluster which failed to answer a query,
+// as opposed to the errors of the query itself
+type clusterError struct {
+	error
+}
+
+func (err clusterError) Unwrap() error {
+	return err.error
+}
+
+// readCluster is the cluster the queries run on, as long as it is healthy
+type readCluster struct {
+	client   *opensearch.Client
+	interval time.Duration
+	now      func() time.Time
+
+	mutex     sync.Mutex
+	healthy   bool
+	checking  bool
+	checkedTs time.Time
+}
+
+func newReadCluster(client *opensearch.Client, interval time.Duration) *readCluster {
+	if interval <= 0 {
+		interval = defaultReadHealthCheckInterval
+	}
+	return &readCluster{
+		client:   client,
+		interval: interval,
+		now:      time.Now,
+		healthy:  true,
+	}
+}
+
+// isHealthy returns whether the read cluster is healthy, checking its
+// health in the background if the last check is older than the interval
+func (r *readCluster) isHealthy(ctx context.Context, check func(context.Context) error) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.checking && r.now().Sub(r.checkedTs) >= r.interval {
+		r.checking = true
+		l := log.FromContext(ctx)
+		go func() {
+			ctx, cancel := context.WithTimeout(
+				log.WithContext(context.Background(), l), readHealthCheckTimeout)
+			defer cancel()
+			err := check(ctx)
+			r.setHealthy(err == nil)
+			if err != nil {
+				l.Warnf("the read cluster is unhealthy, failing over: %s", err)
+			}
+		}()
+	}
+	return r.healthy
+}
+
+func (r *readCluster) setHealthy(healthy bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.healthy = healthy
+	r.checking = false
+	r.checkedTs = r.now()
+}
+
+// reader returns the client of the cluster the queries run on: the read
+// cluster, if configured and healthy, or the write cluster
+func (s *opensearchStore) reader(ctx context.Context) *opensearch.Client {
+	if s.readCluster == nil {
+		return s.client
+	}
+	if s.readCluster.isHealthy(ctx, s.checkReadHealth) {
+		return s.readCluster.client
+	}
+	return s.client
+}
+
+func (s *opensearchStore) checkReadHealth(ctx context.Context) error {
+	return s.checkHealth(ctx, s.readCluster.client)
+}
+
+// query runs the query on the read cluster, failing over to the write
+// cluster if the read cluster fails to answer; the requests cancelled by
+// the client are no failure of the cluster
+func (s *opensearchStore) query(
+	ctx context.Context,
+	fn func(client *opensearch.Client) error,
+) error {
+	client := s.reader(ctx)
+	err := fn(client)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	}
+	var clusterErr clusterError
+	if client != s.client && errors.As(err, &clusterErr) {
+		log.FromContext(ctx).Warnf("the read cluster failed, failing over: %s", err)
+		s.readCluster.setHealthy(false)
+		return fn(s.client)
+	}
+	return err
+}
+
+// pointInTime runs the operation on the point in time on the read cluster,
+// retrying it on the other cluster if the point in time is not found or
+// the cluster fails to answer: the point in time was opened before a
+// failover. The requests cancelled by the client are not retried
+func (s *opensearchStore) pointInTime(
+	ctx context.Context,
+	fn func(client *opensearch.Client) error,
+) error {
+	client := s.reader(ctx)
+	err := fn(client)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	}
+	var clusterErr clusterError
+	if s.readCluster != nil &&
+		(err == store.ErrPointInTimeNotFound || errors.As(err, &clusterErr)) {
+		other := s.readCluster.client
+		if client == other {
+			other = s.client
+		}
+		return fn(other)
+	}
+	return err
+}
+
+// clusterErr wraps the error as a cluster error if the cluster failed to
+// answer: the request failed, or the response is a server error
+func clusterErr(resp *opensearchapi.Response, err error) error {
+	if err != nil {
+		return clusterError{err}
+	} else if resp.StatusCode >= http.StatusInternalServerError {
+		return clusterError{errors.New(resp.String())}
+	}
+	return nil
+}
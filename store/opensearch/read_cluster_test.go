@@ -0,0 +1,208 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+type testCluster struct {
+	*httptest.Server
+	searches int32
+	status   int
+	health   string
+}
+
+func newTestCluster(t *testing.T, status int, health string) *testCluster {
+	c := &testCluster{status: status, health: health}
+	c.Server = newTestServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/_cluster/health":
+				_, _ = w.Write([]byte(`{"status": "` + c.health + `"}`))
+			default:
+				atomic.AddInt32(&c.searches, 1)
+				if c.status != 0 {
+					w.WriteHeader(c.status)
+				}
+				_, _ = w.Write([]byte(`{"hits": {"hits": [], "total": {"value": 0}}}`))
+			}
+		}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestReadCluster(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		readStatus  int
+		readHealthy bool
+		noRead      bool
+		pointInTime bool
+		cancelled   bool
+
+		readSearches  int32
+		writeSearches int32
+		healthy       bool
+	}{
+		"ok, read cluster": {
+			readHealthy:  true,
+			readSearches: 1,
+			healthy:      true,
+		},
+		"ok, no read cluster": {
+			noRead:        true,
+			writeSearches: 1,
+		},
+		"ok, read cluster unhealthy": {
+			writeSearches: 1,
+		},
+		"ok, read cluster failed": {
+			readStatus:    http.StatusInternalServerError,
+			readHealthy:   true,
+			readSearches:  1,
+			writeSearches: 1,
+		},
+		"ok, read cluster bad request": {
+			readStatus:   http.StatusBadRequest,
+			readHealthy:  true,
+			readSearches: 1,
+			healthy:      true,
+		},
+		"ok, point in time not found on the read cluster": {
+			readStatus:    http.StatusNotFound,
+			readHealthy:   true,
+			pointInTime:   true,
+			readSearches:  1,
+			writeSearches: 1,
+			healthy:       true,
+		},
+		"error, cancelled by the client": {
+			readHealthy: true,
+			cancelled:   true,
+			healthy:     true,
+		},
+		"error, point in time cancelled by the client": {
+			readHealthy: true,
+			pointInTime: true,
+			cancelled:   true,
+			healthy:     true,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			writeCluster := newTestCluster(t, 0, "green")
+			readCluster := newTestCluster(t, tc.readStatus, "green")
+			opts := []StoreOption{
+				WithServerAddresses([]string{writeCluster.URL}),
+				WithDevicesIndexName("devices"),
+			}
+			if !tc.noRead {
+				opts = append(opts, WithReadAddresses([]string{readCluster.URL}, time.Hour))
+			}
+			ds, err := NewStore(opts...)
+			if !assert.NoError(t, err) {
+				return
+			}
+			s := ds.(*opensearchStore)
+			if s.readCluster != nil {
+				// no health checks in the background
+				s.readCluster.checkedTs = time.Now()
+				s.readCluster.healthy = tc.readHealthy
+			}
+
+			ctx := identity.WithContext(context.Background(), &identity.Identity{
+				Tenant: "tenant",
+			})
+			if tc.cancelled {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			if tc.pointInTime {
+				_, err = s.SearchPointInTime(ctx, model.NewQuery().With(model.M{
+					"pit": model.M{"id": "1"},
+				}))
+			} else {
+				_, err = s.SearchDevices(ctx, model.NewQuery())
+			}
+			if tc.cancelled {
+				assert.ErrorIs(t, err, context.Canceled)
+			} else if tc.readStatus == http.StatusBadRequest {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.readSearches, atomic.LoadInt32(&readCluster.searches))
+			assert.Equal(t, tc.writeSearches, atomic.LoadInt32(&writeCluster.searches))
+			if s.readCluster != nil {
+				assert.Equal(t, tc.healthy, s.readCluster.healthy)
+			}
+		})
+	}
+}
+
+func TestReadClusterHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	writeCluster := newTestCluster(t, 0, "green")
+	readCluster := newTestCluster(t, 0, "red")
+	ds, err := NewStore(
+		WithServerAddresses([]string{writeCluster.URL}),
+		WithReadAddresses([]string{readCluster.URL}, time.Minute),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s := ds.(*opensearchStore)
+	now := time.Now()
+	s.readCluster.mutex.Lock()
+	s.readCluster.now = func() time.Time { return now }
+	s.readCluster.mutex.Unlock()
+
+	// the first query checks the health in the background
+	assert.Equal(t, s.readCluster.client, s.reader(context.Background()))
+	assert.Eventually(t, func() bool {
+		return s.reader(context.Background()) == s.client
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPointInTimeNotFound(t *testing.T) {
+	t.Parallel()
+
+	writeCluster := newTestCluster(t, http.StatusNotFound, "green")
+	ds, err := NewStore(WithServerAddresses([]string{writeCluster.URL}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ds.SearchPointInTime(context.Background(), model.NewQuery())
+	assert.Equal(t, store.ErrPointInTimeNotFound, err)
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mendersoftware/go-lib-micro/log"
+)
+
+// queryPlaceholder replaces the values in the normalized queries
+const queryPlaceholder = "?"
+
+// WithSlowQueryThreshold sets the latency above which the queries are
+// logged as slow queries, with their normalized shape; zero disables the
+// slow query log
+func WithSlowQueryThreshold(threshold time.Duration) StoreOption {
+	return func(s *opensearchStore) {
+		s.slowQueryThreshold = threshold
+	}
+}
+
+// logSlowQuery logs the query if it took longer than the slow query
+// threshold; the query is normalized, so that the queries with the same
+// structure log the same shape, and no attribute value ends up in the logs
+func (s *opensearchStore) logSlowQuery(ctx context.Context, indexName string,
+	body []byte, took time.Duration) {
+	if s.slowQueryThreshold <= 0 || took < s.slowQueryThreshold {
+		return
+	}
+	l := log.FromContext(ctx).F(log.Ctx{
+		"index":       indexName,
+		"duration_ms": took.Milliseconds(),
+	})
+	var query interface{}
+	if err := json.Unmarshal(body, &query); err != nil {
+		l.Warnf("slow query: %s", err)
+		return
+	}
+	shape, _ := json.Marshal(normalizeQuery(query))
+	l.Warnf("slow query: %s", shape)
+}
+
+// normalizeQuery returns the shape of the query: the keys are kept, while
+// the values are replaced by placeholders and the arrays of values are
+// collapsed to a single placeholder
+func normalizeQuery(query interface{}) interface{} {
+	switch v := query.(type) {
+	case map[string]interface{}:
+		shape := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			shape[key] = normalizeQuery(value)
+		}
+		return shape
+	case []interface{}:
+		shape := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			item = normalizeQuery(item)
+			if item == queryPlaceholder && len(shape) > 0 &&
+				shape[len(shape)-1] == queryPlaceholder {
+				continue
+			}
+			shape = append(shape, item)
+		}
+		return shape
+	default:
+		return queryPlaceholder
+	}
+}
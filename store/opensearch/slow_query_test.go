@@ -0,0 +1,73 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		query string
+		shape string
+	}{
+		"search": {
+			query: `{
+				"query": {"bool": {"must": [
+					{"match": {"inventory_attribute1_str": "raspberrypi4"}},
+					{"terms": {"id": ["1", "2", "3"]}},
+					{"range": {"inventory_attribute2_num": {"gt": 512}}}
+				]}},
+				"sort": [{"id": {"order": "asc"}}],
+				"from": 20,
+				"size": 20
+			}`,
+			shape: `{
+				"query": {"bool": {"must": [
+					{"match": {"inventory_attribute1_str": "?"}},
+					{"terms": {"id": ["?"]}},
+					{"range": {"inventory_attribute2_num": {"gt": "?"}}}
+				]}},
+				"sort": [{"id": {"order": "?"}}],
+				"from": "?",
+				"size": "?"
+			}`,
+		},
+		"mixed array": {
+			query: `{"search_after": [1, "a", {"x": true}, null, false]}`,
+			shape: `{"search_after": ["?", {"x": "?"}, "?"]}`,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var query interface{}
+			if !assert.NoError(t, json.Unmarshal([]byte(tc.query), &query)) {
+				return
+			}
+			shape, err := json.Marshal(normalizeQuery(query))
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, tc.shape, string(shape))
+			}
+		})
+	}
+}
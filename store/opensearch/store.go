@@ -17,14 +17,21 @@ package opensearch
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/opensearch-project/opensearch-go"
 	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/opensearch-project/opensearch-go/signer"
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/go-lib-micro/identity"
@@ -33,29 +40,121 @@ import (
 
 	"github.com/mendersoftware/reporting/model"
 	"github.com/mendersoftware/reporting/store"
+	"github.com/mendersoftware/reporting/tracing"
+	"github.com/mendersoftware/reporting/utils"
+)
+
+// clusterHealthRed is the health status of the cluster when some of the
+// primary shards are not allocated
+const clusterHealthRed = "red"
+
+// ignoreUnavailable is set on the requests to the devices, deployments and
+// software indices: the per-tenant indices are created with the first
+// document, so the requests for the tenants without documents yet must
+// not fail
+const ignoreUnavailable = true
+
+const (
+	// DistributionOpenSearch is the distribution of the OpenSearch clusters
+	DistributionOpenSearch = "opensearch"
+	// DistributionElasticsearch is the distribution of the Elasticsearch 8
+	// clusters: the point in time API differs, and the ISM policies are
+	// not supported
+	DistributionElasticsearch = "elasticsearch"
 )
 
 type StoreOption func(*opensearchStore)
 
 type opensearchStore struct {
-	addresses                []string
-	devicesIndexName         string
-	devicesIndexShards       int
-	devicesIndexReplicas     int
-	deploymentsIndexName     string
-	deploymentsIndexShards   int
-	deploymentsIndexReplicas int
-	client                   *opensearch.Client
+	addresses                       []string
+	devicesIndexName                string
+	devicesIndexShards              int
+	devicesIndexReplicas            int
+	devicesIndexRefreshInterval     string
+	deploymentsIndexName            string
+	deploymentsIndexShards          int
+	deploymentsIndexReplicas        int
+	deploymentsIndexRefreshInterval string
+	softwareIndexName               string
+	softwareIndexShards             int
+	softwareIndexReplicas           int
+	softwareIndexRefreshInterval    string
+	indexStrategyName               string
+	indexGroups                     int
+	deploymentsRollover             string
+	deploymentsRetentionDays        int
+	bulkMaxRetries                  int
+	bulkRetryBackoff                time.Duration
+	slowQueryThreshold              time.Duration
+	indexStrategy                   indexStrategy
+	aliases                         sync.Map
+	tlsConfig                       *tls.Config
+	signer                          signer.Signer
+	distribution                    string
+	username                        string
+	password                        string
+	apiKey                          string
+	readAddresses                   []string
+	readHealthCheckInterval         time.Duration
+	client                          *opensearch.Client
+	readCluster                     *readCluster
 }
 
 func NewStore(opts ...StoreOption) (store.Store, error) {
-	store := &opensearchStore{}
+	store := &opensearchStore{
+		devicesIndexShards:              1,
+		devicesIndexRefreshInterval:     defaultRefreshInterval,
+		deploymentsIndexShards:          1,
+		deploymentsIndexRefreshInterval: defaultRefreshInterval,
+		softwareIndexName:               defaultSoftwareIndexName,
+		softwareIndexShards:             1,
+		softwareIndexRefreshInterval:    defaultRefreshInterval,
+		bulkMaxRetries:                  defaultBulkMaxRetries,
+		bulkRetryBackoff:                defaultBulkRetryBackoff,
+		distribution:                    DistributionOpenSearch,
+	}
 	for _, opt := range opts {
 		opt(store)
 	}
 
+	switch store.distribution {
+	case DistributionOpenSearch, DistributionElasticsearch:
+	default:
+		return nil, errors.Errorf("unknown distribution: %q", store.distribution)
+	}
+
+	indexStrategy, err := newIndexStrategy(store.indexStrategyName, store.indexGroups)
+	if err != nil {
+		return nil, err
+	}
+	store.indexStrategy = indexStrategy
+
+	switch store.deploymentsRollover {
+	case "":
+	case DeploymentsRolloverMonthly:
+		if indexStrategy.IsAlias() {
+			return nil, errors.New(
+				"the rollover of the deployments indices is not supported " +
+					"with per-tenant indices")
+		}
+	default:
+		return nil, errors.Errorf("unknown deployments rollover: %q",
+			store.deploymentsRollover)
+	}
+
 	cfg := opensearch.Config{
 		Addresses: store.addresses,
+		Transport: tracing.NewTransport("opensearch", utils.NewTransport(utils.TransportOptions{
+			TLSConfig: store.tlsConfig,
+		})),
+		Signer:   store.signer,
+		Username: store.username,
+		Password: store.password,
+		// the client refuses to talk to Elasticsearch 8 otherwise
+		UseResponseCheckOnly: store.distribution == DistributionElasticsearch,
+	}
+	if store.apiKey != "" {
+		cfg.Header = http.Header{"Authorization": []string{"ApiKey " + store.apiKey}}
 	}
 	osClient, err := opensearch.NewClient(cfg)
 	if err != nil {
@@ -63,6 +162,15 @@ func NewStore(opts ...StoreOption) (store.Store, error) {
 	}
 
 	store.client = osClient
+
+	if len(store.readAddresses) > 0 {
+		cfg.Addresses = store.readAddresses
+		readClient, err := opensearch.NewClient(cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid OpenSearch read configuration")
+		}
+		store.readCluster = newReadCluster(readClient, store.readHealthCheckInterval)
+	}
 	return store, nil
 }
 
@@ -72,6 +180,48 @@ func WithServerAddresses(addresses []string) StoreOption {
 	}
 }
 
+// WithTLSConfig sets the TLS configuration of the connections to the
+// OpenSearch cluster
+func WithTLSConfig(config *tls.Config) StoreOption {
+	return func(s *opensearchStore) {
+		s.tlsConfig = config
+	}
+}
+
+// WithRequestSigner sets the signer of the requests to the OpenSearch
+// cluster, e.g. with the AWS Signature Version 4 for Amazon OpenSearch
+// Service
+func WithRequestSigner(signer signer.Signer) StoreOption {
+	return func(s *opensearchStore) {
+		s.signer = signer
+	}
+}
+
+// WithDistribution sets the distribution of the cluster:
+// DistributionOpenSearch or DistributionElasticsearch
+func WithDistribution(distribution string) StoreOption {
+	return func(s *opensearchStore) {
+		s.distribution = distribution
+	}
+}
+
+// WithBasicAuth sets the credentials of the HTTP basic authentication to
+// the cluster
+func WithBasicAuth(username, password string) StoreOption {
+	return func(s *opensearchStore) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithAPIKey sets the API key the requests to the cluster are authenticated
+// with, base64-encoded as returned by Elasticsearch
+func WithAPIKey(apiKey string) StoreOption {
+	return func(s *opensearchStore) {
+		s.apiKey = apiKey
+	}
+}
+
 func WithDevicesIndexName(indexName string) StoreOption {
 	return func(s *opensearchStore) {
 		s.devicesIndexName = indexName
@@ -108,6 +258,96 @@ func WithDeploymentsIndexReplicas(indexReplicas int) StoreOption {
 	}
 }
 
+// WithDevicesIndexRefreshInterval sets the refresh interval of the
+// devices indices, e.g. 1s, or -1 to disable the refreshes
+func WithDevicesIndexRefreshInterval(interval string) StoreOption {
+	return func(s *opensearchStore) {
+		s.devicesIndexRefreshInterval = interval
+	}
+}
+
+// WithDeploymentsIndexRefreshInterval sets the refresh interval of the
+// deployments indices
+func WithDeploymentsIndexRefreshInterval(interval string) StoreOption {
+	return func(s *opensearchStore) {
+		s.deploymentsIndexRefreshInterval = interval
+	}
+}
+
+// WithSoftwareIndexShards sets the number of shards of the software
+// inventory indices
+func WithSoftwareIndexShards(indexShards int) StoreOption {
+	return func(s *opensearchStore) {
+		s.softwareIndexShards = indexShards
+	}
+}
+
+// WithSoftwareIndexReplicas sets the number of replicas of the software
+// inventory indices
+func WithSoftwareIndexReplicas(indexReplicas int) StoreOption {
+	return func(s *opensearchStore) {
+		s.softwareIndexReplicas = indexReplicas
+	}
+}
+
+// WithSoftwareIndexRefreshInterval sets the refresh interval of the
+// software inventory indices
+func WithSoftwareIndexRefreshInterval(interval string) StoreOption {
+	return func(s *opensearchStore) {
+		s.softwareIndexRefreshInterval = interval
+	}
+}
+
+// WithSoftwareIndexName sets the name of the index of the devices'
+// software inventory
+func WithSoftwareIndexName(indexName string) StoreOption {
+	return func(s *opensearchStore) {
+		s.softwareIndexName = indexName
+	}
+}
+
+// WithIndexStrategy sets the strategy mapping the tenants to the indices:
+// IndexStrategyShared, IndexStrategyPerTenant or IndexStrategyHashed
+func WithIndexStrategy(strategy string) StoreOption {
+	return func(s *opensearchStore) {
+		s.indexStrategyName = strategy
+	}
+}
+
+// WithIndexGroups sets the number of index groups of IndexStrategyHashed
+func WithIndexGroups(groups int) StoreOption {
+	return func(s *opensearchStore) {
+		s.indexGroups = groups
+	}
+}
+
+// WithDeploymentsRollover sets the rollover of the deployments indices:
+// DeploymentsRolloverMonthly, or empty to store them in a single index
+func WithDeploymentsRollover(rollover string) StoreOption {
+	return func(s *opensearchStore) {
+		s.deploymentsRollover = rollover
+	}
+}
+
+// WithDeploymentsRetention sets the number of days the finished
+// deployments are kept for: with the monthly rollover, the deployments
+// indices are managed by an ISM policy deleting them once expired
+func WithDeploymentsRetention(days int) StoreOption {
+	return func(s *opensearchStore) {
+		s.deploymentsRetentionDays = days
+	}
+}
+
+// WithBulkRetries sets the number of times the bulk items failed for a
+// transient reason are retried, and the backoff before the first retry,
+// doubled at each attempt
+func WithBulkRetries(maxRetries int, backoff time.Duration) StoreOption {
+	return func(s *opensearchStore) {
+		s.bulkMaxRetries = maxRetries
+		s.bulkRetryBackoff = backoff
+	}
+}
+
 type BulkAction struct {
 	Type string
 	Desc *BulkActionDesc
@@ -128,15 +368,23 @@ type BulkItem struct {
 }
 
 func (bad BulkActionDesc) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		ID      string `json:"_id"`
-		Index   string `json:"_index"`
-		Routing string `json:"routing"`
+	desc := struct {
+		ID            string `json:"_id"`
+		Index         string `json:"_index"`
+		Routing       string `json:"routing"`
+		IfSeqNo       *int64 `json:"if_seq_no,omitempty"`
+		IfPrimaryTerm *int64 `json:"if_primary_term,omitempty"`
 	}{
 		ID:      bad.ID,
 		Index:   bad.Index,
 		Routing: bad.Routing,
-	})
+	}
+	// the primary term starts at 1: zero means no concurrency control
+	if bad.IfPrimaryTerm > 0 {
+		desc.IfSeqNo = &bad.IfSeqNo
+		desc.IfPrimaryTerm = &bad.IfPrimaryTerm
+	}
+	return json.Marshal(desc)
 }
 
 func (ba BulkAction) MarshalJSON() ([]byte, error) {
@@ -172,121 +420,525 @@ func (bi BulkItem) Marshal() ([]byte, error) {
 
 func (s *opensearchStore) BulkIndexDeployments(ctx context.Context,
 	deployments []*model.Deployment) error {
-	var data strings.Builder
-
+	indices := make([]string, 0, len(deployments))
 	for _, deployment := range deployments {
-		actionJSON, err := json.Marshal(BulkAction{
-			Type: "index",
-			Desc: &BulkActionDesc{
-				ID:      deployment.ID,
-				Index:   s.GetDeploymentsIndex(deployment.TenantID),
-				Routing: s.GetDeploymentsRoutingKey(deployment.TenantID),
+		indices = append(indices, s.deploymentsWriteIndex(deployment))
+	}
+	if err := s.ensureDeploymentsIndices(ctx, indices...); err != nil {
+		return err
+	}
+
+	items := make([]BulkItem, 0, len(deployments))
+	for i, deployment := range deployments {
+		doc := *deployment
+		doc.DeviceStatusHistory = nil
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "update",
+				Desc: &BulkActionDesc{
+					ID:      deployment.ID,
+					Index:   indices[i],
+					Routing: s.GetDeploymentsRoutingKey(deployment.TenantID),
+				},
+			},
+			Doc: map[string]interface{}{
+				"script": map[string]interface{}{
+					"source": deploymentStatusHistoryScript,
+					"params": map[string]interface{}{
+						"doc":         doc,
+						"transitions": deployment.DeviceStatusHistory,
+						"max":         model.MaxDeviceStatusHistory,
+					},
+				},
+				"upsert": deployment,
 			},
 		})
-		if err != nil {
-			return err
-		}
-		deploymentJSON, err := json.Marshal(deployment)
-		if err != nil {
-			return err
-		}
-		data.WriteString(string(actionJSON) + "\n" + string(deploymentJSON) + "\n")
 	}
-	dataString := data.String()
-
-	l := log.FromContext(ctx)
-	l.Debugf("opensearch request: %s", dataString)
+	return errors.Wrap(s.bulk(ctx, items), "failed to bulk index")
+}
 
-	req := opensearchapi.BulkRequest{
-		Body: strings.NewReader(dataString),
-	}
-	res, err := req.Do(ctx, s.client)
-	if err != nil {
-		return errors.Wrap(err, "failed to bulk index")
+// deploymentStatusHistoryScript replaces the indexed deployment with the
+// new document, appending the transitions of the device's status to the
+// indexed history if the status changed, and dropping the oldest ones
+// beyond the maximum length
+const deploymentStatusHistoryScript = `
+def history = ctx._source.device_status_history;
+if (history == null) {
+	history = new ArrayList();
+}
+for (def transition : params.transitions) {
+	if (history.isEmpty() ||
+		history.get(history.size() - 1).status != transition.status) {
+		history.add(transition);
 	}
-	defer res.Body.Close()
-
-	return nil
 }
+while (history.size() > params.max) {
+	history.remove(0);
+}
+ctx._source.clear();
+ctx._source.putAll(params.doc);
+ctx._source.device_status_history = history;
+`
 
 func (s *opensearchStore) BulkIndexDevices(ctx context.Context, devices []*model.Device,
 	removedDevices []*model.Device) error {
-	var data strings.Builder
+	indices := make([]string, 0, len(devices))
+	for _, device := range devices {
+		indices = append(indices, s.GetDevicesIndex(device.GetTenantID()))
+	}
+	if err := s.ensureIndices(ctx, indices...); err != nil {
+		return err
+	}
 
+	// the devices are written only if the indexed documents did not change
+	// in the meantime, and are not more recent, so that the out-of-order
+	// jobs do not overwrite them with stale data; a conflict fails the
+	// request, for the devices to be indexed again
+	var versions map[string]indexedVersion
+	if len(devices) > 0 {
+		var err error
+		versions, err = s.getIndexedVersions(ctx, devices)
+		if err != nil {
+			return err
+		}
+	}
+	l := log.FromContext(ctx)
+	items := make([]BulkItem, 0, len(devices)+len(removedDevices))
 	for _, device := range devices {
-		actionJSON, err := json.Marshal(BulkAction{
-			Type: "index",
+		action := &BulkAction{
+			Type: "create",
 			Desc: &BulkActionDesc{
 				ID:      device.GetID(),
 				Index:   s.GetDevicesIndex(device.GetTenantID()),
 				Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
 			},
-		})
-		if err != nil {
-			return err
 		}
-		deviceJSON, err := json.Marshal(device)
-		if err != nil {
-			return err
+		if version, ok := versions[device.GetID()]; ok {
+			if version.isStale(device) {
+				l.Debugf("skipping the stale document of the device %s",
+					device.GetID())
+				continue
+			}
+			action.Type = "index"
+			action.Desc.IfSeqNo = version.SeqNo
+			action.Desc.IfPrimaryTerm = version.PrimaryTerm
 		}
-		data.WriteString(string(actionJSON) + "\n" + string(deviceJSON) + "\n")
+		items = append(items, BulkItem{
+			Action: action,
+			Doc:    device,
+		})
 	}
 	for _, device := range removedDevices {
-		actionJSON, err := json.Marshal(BulkAction{
-			Type: "delete",
-			Desc: &BulkActionDesc{
-				ID:      device.GetID(),
-				Index:   s.GetDevicesIndex(device.GetTenantID()),
-				Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "delete",
+				Desc: &BulkActionDesc{
+					ID:      device.GetID(),
+					Index:   s.GetDevicesIndex(device.GetTenantID()),
+					Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
+				},
 			},
 		})
+	}
+	return errors.Wrap(s.bulk(ctx, items), "failed to bulk index")
+}
+
+// BulkIndexSoftware indexes the software inventory of the devices, and
+// deletes the one of the removed devices
+func (s *opensearchStore) BulkIndexSoftware(ctx context.Context,
+	software []*model.DeviceSoftware, removedDevices []*model.Device) error {
+	indices := make([]string, 0, len(software))
+	for _, item := range software {
+		indices = append(indices, s.getSoftwareIndex(item.TenantID))
+	}
+	if err := s.ensureIndices(ctx, indices...); err != nil {
+		return err
+	}
+	items := make([]BulkItem, 0, len(software)+len(removedDevices))
+	for _, item := range software {
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "index",
+				Desc: &BulkActionDesc{
+					ID:      item.ID,
+					Index:   s.getSoftwareIndex(item.TenantID),
+					Routing: s.indexStrategy.RoutingKey(item.TenantID),
+				},
+			},
+			Doc: item,
+		})
+	}
+	for _, device := range removedDevices {
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "delete",
+				Desc: &BulkActionDesc{
+					ID:      device.GetID(),
+					Index:   s.getSoftwareIndex(device.GetTenantID()),
+					Routing: s.indexStrategy.RoutingKey(device.GetTenantID()),
+				},
+			},
+		})
+	}
+	return errors.Wrap(s.bulk(ctx, items), "failed to bulk index the software")
+}
+
+func (s *opensearchStore) UpdateDevices(ctx context.Context, devices []*model.Device) error {
+	items := make([]BulkItem, 0, len(devices))
+	for _, device := range devices {
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "update",
+				Desc: &BulkActionDesc{
+					ID:      device.GetID(),
+					Index:   s.GetDevicesIndex(device.GetTenantID()),
+					Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
+				},
+			},
+			Doc: map[string]interface{}{"doc": device},
+		})
+	}
+	return errors.Wrap(s.bulk(ctx, items), "failed to bulk update")
+}
+
+func (s *opensearchStore) ReplaceDevicesTags(
+	ctx context.Context,
+	devices []*model.Device,
+) error {
+	items := make([]BulkItem, 0, len(devices))
+	for _, device := range devices {
+		tags, err := device.TagsFields()
 		if err != nil {
+			return errors.Wrap(err, "failed to serialize the tags")
+		}
+		items = append(items, BulkItem{
+			Action: &BulkAction{
+				Type: "update",
+				Desc: &BulkActionDesc{
+					ID:      device.GetID(),
+					Index:   s.GetDevicesIndex(device.GetTenantID()),
+					Routing: s.GetDevicesRoutingKey(device.GetTenantID()),
+				},
+			},
+			Doc: map[string]interface{}{
+				"script": map[string]interface{}{
+					"source": replaceTagsScript,
+					"params": map[string]interface{}{
+						"prefix": model.TagsFieldsPrefix,
+						"tags":   tags,
+					},
+				},
+			},
+		})
+	}
+	return errors.Wrap(s.bulk(ctx, items), "failed to bulk update")
+}
+
+// replaceTagsScript removes the tags fields of the indexed device, and
+// sets the new ones
+const replaceTagsScript = `
+ctx._source.keySet().removeIf(field -> field.startsWith(params.prefix));
+ctx._source.putAll(params.tags);
+`
+
+func (s *opensearchStore) DeleteDevicesData(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	err := s.deleteByQuery(ctx, s.GetDevicesIndex(tenantID),
+		s.GetDevicesRoutingKey(tenantID), tenantID,
+		model.M{"terms": model.M{model.FieldNameID: deviceIDs}})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices")
+	}
+	err = s.deleteByQuery(ctx, s.GetDeploymentsIndex(tenantID),
+		s.GetDeploymentsRoutingKey(tenantID), tenantID,
+		model.M{"terms": model.M{model.FieldNameDeviceID: deviceIDs}})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices' deployments")
+	}
+	err = s.deleteByQuery(ctx, s.getSoftwareIndex(tenantID),
+		s.indexStrategy.RoutingKey(tenantID), tenantID,
+		model.M{"terms": model.M{model.FieldNameID: deviceIDs}})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices' software")
+	}
+	return nil
+}
+
+// DeleteTenantData deletes all the documents of the tenant: the tenant's
+// indices are dropped if the index strategy dedicates them to the tenant,
+// otherwise its documents are deleted by query
+func (s *opensearchStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	if s.indexStrategy.IsAlias() && tenantID != "" {
+		for _, alias := range []string{
+			s.GetDevicesIndex(tenantID),
+			s.GetDeploymentsIndex(tenantID),
+			s.getSoftwareIndex(tenantID),
+		} {
+			if err := s.deleteTenantIndices(ctx, alias); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := s.deleteByQuery(ctx, s.GetDevicesIndex(tenantID),
+		s.GetDevicesRoutingKey(tenantID), tenantID)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the devices")
+	}
+	err = s.deleteByQuery(ctx, s.GetDeploymentsIndex(tenantID),
+		s.GetDeploymentsRoutingKey(tenantID), tenantID)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the deployments")
+	}
+	err = s.deleteByQuery(ctx, s.getSoftwareIndex(tenantID),
+		s.indexStrategy.RoutingKey(tenantID), tenantID)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the software")
+	}
+	return nil
+}
+
+// DeleteDeploymentsBefore deletes the deployments which finished before
+// the cutoff from all the deployments indices, whatever the index strategy;
+// with the rollover, the monthly indices of the months which ended before
+// the cutoff are dropped as a whole first
+func (s *opensearchStore) DeleteDeploymentsBefore(ctx context.Context,
+	cutoff time.Time) error {
+	l := log.FromContext(ctx)
+
+	if s.deploymentsRollover != "" {
+		if err := s.dropExpiredDeploymentsIndices(ctx, cutoff); err != nil {
 			return err
 		}
-		data.WriteString(string(actionJSON) + "\n")
 	}
 
-	dataString := data.String()
+	body, err := json.Marshal(model.M{
+		"query": model.M{
+			"range": model.M{
+				"device_finished": model.M{
+					"lt": cutoff.UTC().Format(time.RFC3339Nano),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	l.Debugf("es delete by query: %s", body)
 
+	indices := []string{s.deploymentsIndexName, s.deploymentsIndexName + "-*"}
+	resp, err := s.client.DeleteByQuery(indices, bytes.NewReader(body),
+		s.client.DeleteByQuery.WithContext(ctx),
+		s.client.DeleteByQuery.WithIgnoreUnavailable(ignoreUnavailable),
+		s.client.DeleteByQuery.WithAllowNoIndices(true),
+		s.client.DeleteByQuery.WithConflicts("proceed"),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the deployments")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return errors.Wrap(errors.New(resp.String()), "failed to delete the deployments")
+	}
+	return nil
+}
+
+// deleteByQuery deletes the tenant's documents matching all the filters
+func (s *opensearchStore) deleteByQuery(
+	ctx context.Context,
+	indexName, routingKey, tenantID string,
+	filters ...model.M,
+) error {
 	l := log.FromContext(ctx)
-	l.Debugf("opensearch request: %s", dataString)
 
-	req := opensearchapi.BulkRequest{
-		Body: strings.NewReader(dataString),
+	filters = append([]model.M{
+		{"term": model.M{model.FieldNameTenantID: tenantID}},
+	}, filters...)
+	body, err := json.Marshal(model.M{
+		"query": model.M{
+			"bool": model.M{
+				"filter": filters,
+			},
+		},
+	})
+	if err != nil {
+		return err
 	}
-	res, err := req.Do(ctx, s.client)
+
+	l.Debugf("es delete by query: %s", body)
+
+	deleteRequests := []func(*opensearchapi.DeleteByQueryRequest){
+		s.client.DeleteByQuery.WithContext(ctx),
+		s.client.DeleteByQuery.WithIgnoreUnavailable(ignoreUnavailable),
+		s.client.DeleteByQuery.WithConflicts("proceed"),
+	}
+	if routingKey != "" {
+		deleteRequests = append(deleteRequests, s.client.DeleteByQuery.WithRouting(routingKey))
+	}
+	resp, err := s.client.DeleteByQuery([]string{indexName}, bytes.NewReader(body),
+		deleteRequests...)
 	if err != nil {
-		return errors.Wrap(err, "failed to bulk index")
+		return err
 	}
-	defer res.Body.Close()
+	defer resp.Body.Close()
 
+	if resp.IsError() {
+		return errors.New(resp.String())
+	}
 	return nil
 }
 
-func (s *opensearchStore) Migrate(ctx context.Context) error {
-	indexName := s.GetDevicesIndex("")
-	template := fmt.Sprintf(indexDevicesTemplate,
-		indexName,
-		s.devicesIndexShards,
-		s.devicesIndexReplicas,
-	)
-	err := s.migratePutIndexTemplate(ctx, indexName, template)
-	if err == nil {
-		err = s.migrateCreateIndex(ctx, indexName)
-	}
-	if err == nil {
-		indexName = s.GetDeploymentsIndex("")
-		template = fmt.Sprintf(indexDeploymentsTemplate,
-			indexName,
+func (s *opensearchStore) ListDevicesIDs(
+	ctx context.Context,
+	tenantID, afterID string,
+	limit int,
+) ([]string, error) {
+	query := model.NewQuery().
+		Must(model.M{"term": model.M{model.FieldNameTenantID: tenantID}}).
+		WithSort(model.M{model.FieldNameID: model.M{"order": "asc"}}).
+		WithPage(1, limit).
+		With(model.M{"_source": []string{model.FieldNameID}})
+	if afterID != "" {
+		query = query.With(model.M{"search_after": []string{afterID}})
+	}
+	res, err := s.search(ctx, s.client, s.GetDevicesIndex(tenantID),
+		s.GetDevicesRoutingKey(tenantID), query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the devices")
+	}
+	hits, _ := res["hits"].(map[string]interface{})
+	items, _ := hits["hits"].([]interface{})
+	deviceIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		hit, _ := item.(map[string]interface{})
+		source, _ := hit["_source"].(map[string]interface{})
+		if deviceID, ok := source[model.FieldNameID].(string); ok {
+			deviceIDs = append(deviceIDs, deviceID)
+		}
+	}
+	return deviceIDs, nil
+}
+
+func (s *opensearchStore) ListDevicesUpdatedAt(
+	ctx context.Context,
+	tenantID, afterID string,
+	limit int,
+) (map[string]time.Time, error) {
+	query := model.NewQuery().
+		Must(model.M{"term": model.M{model.FieldNameTenantID: tenantID}}).
+		WithSort(model.M{model.FieldNameID: model.M{"order": "asc"}}).
+		WithPage(1, limit).
+		With(model.M{"_source": []string{model.FieldNameID, model.FieldNameUpdatedAt}})
+	if afterID != "" {
+		query = query.With(model.M{"search_after": []string{afterID}})
+	}
+	res, err := s.search(ctx, s.client, s.GetDevicesIndex(tenantID),
+		s.GetDevicesRoutingKey(tenantID), query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the devices")
+	}
+	hits, _ := res["hits"].(map[string]interface{})
+	items, _ := hits["hits"].([]interface{})
+	updatedAt := make(map[string]time.Time, len(items))
+	for _, item := range items {
+		hit, _ := item.(map[string]interface{})
+		source, _ := hit["_source"].(map[string]interface{})
+		deviceID, ok := source[model.FieldNameID].(string)
+		if !ok {
+			continue
+		}
+		ts, _ := source[model.FieldNameUpdatedAt].(string)
+		updatedAt[deviceID], _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	return updatedAt, nil
+}
+
+// indexTemplate is an index template of the store
+type indexTemplate struct {
+	name           string
+	body           string
+	mappingVersion int
+}
+
+// indexTemplates returns the index templates of the store, in the order
+// they are migrated
+func (s *opensearchStore) indexTemplates() []indexTemplate {
+	return []indexTemplate{{
+		name: s.devicesIndexName,
+		body: fmt.Sprintf(indexDevicesTemplate,
+			s.devicesIndexName,
 			s.devicesIndexShards,
 			s.devicesIndexReplicas,
-		)
-		err = s.migratePutIndexTemplate(ctx, indexName, template)
+			s.devicesIndexRefreshInterval,
+			devicesMappingVersion,
+		),
+		mappingVersion: devicesMappingVersion,
+	}, {
+		name: s.deploymentsIndexName,
+		body: fmt.Sprintf(indexDeploymentsTemplate,
+			s.deploymentsIndexName,
+			s.deploymentsIndexShards,
+			s.deploymentsIndexReplicas,
+			s.deploymentsIndexRefreshInterval,
+			deploymentsMappingVersion,
+		),
+		mappingVersion: deploymentsMappingVersion,
+	}, {
+		name: s.softwareIndexName,
+		body: fmt.Sprintf(indexSoftwareTemplate,
+			s.softwareIndexName,
+			s.softwareIndexShards,
+			s.softwareIndexReplicas,
+			s.softwareIndexRefreshInterval,
+			softwareMappingVersion,
+		),
+		mappingVersion: softwareMappingVersion,
+	}}
+}
+
+// rolledOver returns true if the indices of the template are rolled over
+// monthly
+func (s *opensearchStore) rolledOver(template indexTemplate) bool {
+	return template.name == s.deploymentsIndexName && s.deploymentsRollover != ""
+}
+
+func (s *opensearchStore) Migrate(ctx context.Context) error {
+	for _, template := range s.indexTemplates() {
+		err := s.migratePutIndexTemplate(ctx, template.name, template.body)
+		for _, index := range s.indexStrategy.Indices(template.name) {
+			if err != nil {
+				break
+			}
+			if s.rolledOver(template) {
+				// the read alias spans the monthly indices, starting from
+				// the current month's one
+				err = s.ensureDeploymentsIndices(ctx, monthlyAlias(index, time.Now()))
+			} else {
+				err = s.migrateCreateIndex(ctx, index)
+			}
+		}
+		if err != nil {
+			return err
+		}
 	}
-	if err == nil {
-		err = s.migrateCreateIndex(ctx, indexName)
+	if s.deploymentsRollover != "" && s.deploymentsRetentionDays > 0 {
+		if s.distribution == DistributionElasticsearch {
+			// no ISM: the expired indices are dropped by the retention job
+			log.FromContext(ctx).Info("skip the ISM policy of the deployments " +
+				"indices: not supported by Elasticsearch")
+		} else {
+			return s.migrateDeploymentsPolicy(ctx)
+		}
 	}
-	return err
+	return nil
 }
 
 func (s *opensearchStore) migratePutIndexTemplate(ctx context.Context,
@@ -347,10 +999,31 @@ func (s *opensearchStore) migrateCreateIndex(ctx context.Context, indexName stri
 	return nil
 }
 
+// Ping checks the health of the OpenSearch cluster, failing if it is red:
+// some of the primary shards are not allocated
 func (s *opensearchStore) Ping(ctx context.Context) error {
-	pingRequest := s.client.Ping.WithContext(ctx)
-	_, err := s.client.Ping(pingRequest)
-	return errors.Wrap(err, "failed to ping opensearch")
+	return s.checkHealth(ctx, s.client)
+}
+
+func (s *opensearchStore) checkHealth(ctx context.Context, client *opensearch.Client) error {
+	req := opensearchapi.ClusterHealthRequest{}
+	res, err := req.Do(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "failed to ping opensearch")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.Errorf("failed to ping opensearch: status %d", res.StatusCode)
+	}
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return errors.Wrap(err, "failed to parse the opensearch cluster health")
+	} else if health.Status == clusterHealthRed {
+		return errors.New("opensearch cluster health is red")
+	}
+	return nil
 }
 
 func (s *opensearchStore) AggregateDevices(ctx context.Context,
@@ -358,7 +1031,7 @@ func (s *opensearchStore) AggregateDevices(ctx context.Context,
 	id := identity.FromContext(ctx)
 	indexName := s.GetDevicesIndex(id.Tenant)
 	routingKey := s.GetDevicesRoutingKey(id.Tenant)
-	return s.aggregate(ctx, indexName, routingKey, query)
+	return s.queryAggregate(ctx, indexName, routingKey, query)
 }
 
 func (s *opensearchStore) AggregateDeployments(ctx context.Context,
@@ -366,37 +1039,59 @@ func (s *opensearchStore) AggregateDeployments(ctx context.Context,
 	id := identity.FromContext(ctx)
 	indexName := s.GetDeploymentsIndex(id.Tenant)
 	routingKey := s.GetDeploymentsRoutingKey(id.Tenant)
-	return s.aggregate(ctx, indexName, routingKey, query)
+	return s.queryAggregate(ctx, indexName, routingKey, query)
 }
 
-func (s *opensearchStore) aggregate(ctx context.Context, indexName, routingKey string,
+func (s *opensearchStore) AggregateSoftware(ctx context.Context,
 	query model.Query) (model.M, error) {
+	id := identity.FromContext(ctx)
+	indexName := s.getSoftwareIndex(id.Tenant)
+	routingKey := s.indexStrategy.RoutingKey(id.Tenant)
+	return s.queryAggregate(ctx, indexName, routingKey, query)
+}
+
+// queryAggregate runs the aggregation on the read cluster, if any
+func (s *opensearchStore) queryAggregate(ctx context.Context, indexName, routingKey string,
+	query model.Query) (res model.M, err error) {
+	err = s.query(ctx, func(client *opensearch.Client) error {
+		res, err = s.aggregate(ctx, client, indexName, routingKey, query)
+		return err
+	})
+	return res, err
+}
+
+func (s *opensearchStore) aggregate(ctx context.Context, client *opensearch.Client,
+	indexName, routingKey string, query model.Query) (model.M, error) {
 	l := log.FromContext(ctx)
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+	body, err := json.Marshal(query)
+	if err != nil {
 		return nil, err
 	}
 
-	l.Debugf("es query: %v", buf.String())
+	l.Debugf("es query: %s", body)
 
 	searchRequests := []func(*opensearchapi.SearchRequest){
-		s.client.Search.WithContext(ctx),
-		s.client.Search.WithIndex(indexName),
-		s.client.Search.WithBody(&buf),
-		s.client.Search.WithTrackTotalHits(false),
+		client.Search.WithContext(ctx),
+		client.Search.WithIndex(indexName),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithIgnoreUnavailable(ignoreUnavailable),
+		client.Search.WithTrackTotalHits(false),
 	}
 	if routingKey != "" {
-		searchRequests = append(searchRequests, s.client.Search.WithRouting(routingKey))
+		searchRequests = append(searchRequests, client.Search.WithRouting(routingKey))
 	}
-	resp, err := s.client.Search(searchRequests...)
-	defer resp.Body.Close()
-
+	start := time.Now()
+	resp, err := client.Search(searchRequests...)
+	s.logSlowQuery(ctx, indexName, body, time.Since(start))
 	if err != nil {
-		return nil, err
+		return nil, clusterErr(resp, err)
 	}
+	defer resp.Body.Close()
 
-	if resp.IsError() {
+	if err := clusterErr(resp, nil); err != nil {
+		return nil, err
+	} else if resp.IsError() {
 		return nil, errors.New(resp.String())
 	}
 
@@ -413,7 +1108,70 @@ func (s *opensearchStore) SearchDevices(ctx context.Context, query model.Query)
 	id := identity.FromContext(ctx)
 	indexName := s.GetDevicesIndex(id.Tenant)
 	routingKey := s.GetDevicesRoutingKey(id.Tenant)
-	return s.search(ctx, indexName, routingKey, query)
+	return s.querySearch(ctx, indexName, routingKey, query)
+}
+
+func (s *opensearchStore) CountDevices(
+	ctx context.Context,
+	query model.Query,
+) (count int, err error) {
+	id := identity.FromContext(ctx)
+	indexName := s.GetDevicesIndex(id.Tenant)
+	routingKey := s.GetDevicesRoutingKey(id.Tenant)
+	err = s.query(ctx, func(client *opensearch.Client) error {
+		count, err = s.count(ctx, client, indexName, routingKey, query)
+		return err
+	})
+	return count, err
+}
+
+func (s *opensearchStore) count(ctx context.Context, client *opensearch.Client,
+	indexName, routingKey string, query model.Query) (int, error) {
+	l := log.FromContext(ctx)
+
+	// the count API accepts only the query, without pagination and sorting
+	body, err := json.Marshal(query)
+	if err != nil {
+		return 0, err
+	}
+	var parts map[string]json.RawMessage
+	if err := json.Unmarshal(body, &parts); err != nil {
+		return 0, err
+	}
+	body, _ = json.Marshal(map[string]json.RawMessage{"query": parts["query"]})
+
+	l.Debugf("es count query: %s", body)
+
+	countRequests := []func(*opensearchapi.CountRequest){
+		client.Count.WithContext(ctx),
+		client.Count.WithIndex(indexName),
+		client.Count.WithBody(bytes.NewReader(body)),
+		client.Count.WithIgnoreUnavailable(ignoreUnavailable),
+	}
+	if routingKey != "" {
+		countRequests = append(countRequests, client.Count.WithRouting(routingKey))
+	}
+	start := time.Now()
+	resp, err := client.Count(countRequests...)
+	s.logSlowQuery(ctx, indexName, body, time.Since(start))
+	if err != nil {
+		return 0, clusterErr(resp, err)
+	}
+	defer resp.Body.Close()
+
+	if err := clusterErr(resp, nil); err != nil {
+		return 0, err
+	} else if resp.IsError() {
+		return 0, errors.New(resp.String())
+	}
+
+	var ret struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return 0, err
+	}
+	return ret.Count, nil
 }
 
 func (s *opensearchStore) SearchDeployments(ctx context.Context,
@@ -421,37 +1179,148 @@ func (s *opensearchStore) SearchDeployments(ctx context.Context,
 	id := identity.FromContext(ctx)
 	indexName := s.GetDeploymentsIndex(id.Tenant)
 	routingKey := s.GetDeploymentsRoutingKey(id.Tenant)
-	return s.search(ctx, indexName, routingKey, query)
+	return s.querySearch(ctx, indexName, routingKey, query)
 }
 
-func (s *opensearchStore) search(ctx context.Context, indexName, routingKey string,
+func (s *opensearchStore) SearchSoftware(ctx context.Context,
 	query model.Query) (model.M, error) {
+	id := identity.FromContext(ctx)
+	indexName := s.getSoftwareIndex(id.Tenant)
+	routingKey := s.indexStrategy.RoutingKey(id.Tenant)
+	return s.querySearch(ctx, indexName, routingKey, query)
+}
+
+// querySearch runs the search on the read cluster, if any
+func (s *opensearchStore) querySearch(ctx context.Context, indexName, routingKey string,
+	query model.Query) (res model.M, err error) {
+	err = s.query(ctx, func(client *opensearch.Client) error {
+		res, err = s.search(ctx, client, indexName, routingKey, query)
+		return err
+	})
+	return res, err
+}
+
+func (s *opensearchStore) search(ctx context.Context, client *opensearch.Client,
+	indexName, routingKey string, query model.Query) (model.M, error) {
 	l := log.FromContext(ctx)
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+	body, err := json.Marshal(query)
+	if err != nil {
 		return nil, err
 	}
 
-	l.Debugf("es query: %v", buf.String())
+	l.Debugf("es query: %s", body)
 
 	searchRequests := []func(*opensearchapi.SearchRequest){
-		s.client.Search.WithContext(ctx),
-		s.client.Search.WithIndex(indexName),
-		s.client.Search.WithBody(&buf),
-		s.client.Search.WithTrackTotalHits(true),
+		client.Search.WithContext(ctx),
+		client.Search.WithIndex(indexName),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithIgnoreUnavailable(ignoreUnavailable),
+		client.Search.WithTrackTotalHits(true),
 	}
 	if routingKey != "" {
-		searchRequests = append(searchRequests, s.client.Search.WithRouting(routingKey))
+		searchRequests = append(searchRequests, client.Search.WithRouting(routingKey))
+	}
+	start := time.Now()
+	resp, err := client.Search(searchRequests...)
+	s.logSlowQuery(ctx, indexName, body, time.Since(start))
+	if err != nil {
+		return nil, clusterErr(resp, err)
 	}
-	resp, err := s.client.Search(searchRequests...)
 	defer resp.Body.Close()
 
+	if err := clusterErr(resp, nil); err != nil {
+		return nil, err
+	} else if resp.IsError() {
+		return nil, errors.New(resp.String())
+	}
+
+	var ret map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+
+	l.Debugf("opensearch response: %v", ret)
+	return ret, nil
+}
+
+// OpenDevicesPointInTime opens a point in time of the devices index for the
+// tenant in the context, kept alive for the given duration
+// see: https://opensearch.org/docs/latest/search-plugins/point-in-time-api/
+func (s *opensearchStore) OpenDevicesPointInTime(ctx context.Context,
+	keepAlive time.Duration) (string, error) {
+	id := identity.FromContext(ctx)
+	indexName := s.GetDevicesIndex(id.Tenant)
+	routingKey := s.GetDevicesRoutingKey(id.Tenant)
+
+	params := url.Values{}
+	params.Set("keep_alive", formatKeepAlive(keepAlive))
+	if routingKey != "" {
+		params.Set("routing", routingKey)
+	}
+	path := "/" + indexName + "/_search/point_in_time?"
+	if s.distribution == DistributionElasticsearch {
+		path = "/" + indexName + "/_pit?"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		path+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		PointInTimeID string `json:"pit_id"`
+		// Elasticsearch
+		ID string `json:"id"`
+	}
+	err = s.query(ctx, func(client *opensearch.Client) error {
+		return s.performOn(client, req, &res)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open the point in time")
+	}
+	if res.PointInTimeID == "" {
+		return res.ID, nil
+	}
+	return res.PointInTimeID, nil
+}
+
+// SearchPointInTime runs a search on a point in time; the query must
+// specify the point in time ID in the "pit" section
+func (s *opensearchStore) SearchPointInTime(ctx context.Context,
+	query model.Query) (res model.M, err error) {
+	body, err := json.Marshal(query)
 	if err != nil {
 		return nil, err
 	}
+	err = s.pointInTime(ctx, func(client *opensearch.Client) error {
+		res, err = s.searchPointInTime(ctx, client, body)
+		return err
+	})
+	return res, err
+}
 
-	if resp.IsError() {
+func (s *opensearchStore) searchPointInTime(ctx context.Context,
+	client *opensearch.Client, body []byte) (model.M, error) {
+	l := log.FromContext(ctx)
+
+	l.Debugf("es query: %s", body)
+
+	// searches on a point in time must not specify the index nor routing
+	resp, err := client.Search(
+		client.Search.WithContext(ctx),
+		client.Search.WithBody(bytes.NewReader(body)),
+		client.Search.WithTrackTotalHits(true),
+	)
+	if err != nil {
+		return nil, clusterErr(resp, err)
+	}
+	defer resp.Body.Close()
+
+	if err := clusterErr(resp, nil); err != nil {
+		return nil, err
+	} else if resp.StatusCode == http.StatusNotFound {
+		return nil, store.ErrPointInTimeNotFound
+	} else if resp.IsError() {
 		return nil, errors.New(resp.String())
 	}
 
@@ -464,6 +1333,66 @@ func (s *opensearchStore) search(ctx context.Context, indexName, routingKey stri
 	return ret, nil
 }
 
+// ClosePointInTime closes a point in time, releasing its resources
+func (s *opensearchStore) ClosePointInTime(ctx context.Context, pitID string) error {
+	path := "/_search/point_in_time"
+	body, _ := json.Marshal(map[string]interface{}{
+		"pit_id": []string{pitID},
+	})
+	if s.distribution == DistributionElasticsearch {
+		path = "/_pit"
+		body, _ = json.Marshal(map[string]interface{}{
+			"id": pitID,
+		})
+	}
+	err := s.pointInTime(ctx, func(client *opensearch.Client) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete,
+			path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return s.performOn(client, req, nil)
+	})
+	return errors.Wrap(err, "failed to close the point in time")
+}
+
+// perform sends a request which has no dedicated API in the client,
+// decoding the response in res, if not nil
+func (s *opensearchStore) perform(req *http.Request, res interface{}) error {
+	return s.performOn(s.client, req, res)
+}
+
+func (s *opensearchStore) performOn(
+	client *opensearch.Client,
+	req *http.Request,
+	res interface{},
+) error {
+	resp, err := client.Perform(req)
+	if err != nil {
+		return clusterError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return clusterError{errors.Errorf("status %d: %s", resp.StatusCode, body)}
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("status %d: %s", resp.StatusCode, body)
+	}
+	if res != nil {
+		return json.NewDecoder(resp.Body).Decode(res)
+	}
+	return nil
+}
+
+func formatKeepAlive(keepAlive time.Duration) string {
+	return strconv.FormatInt(keepAlive.Milliseconds(), 10) + "ms"
+}
+
 // GetDevicesIndexMapping retrieves the "devices*" index definition for tenant 'tid'
 // existing fields, incl. inventory attributes, are found under 'properties'
 // see: https://opensearch.org/docs/latest/api-reference/index-apis/get-index/
@@ -495,7 +1424,14 @@ func (s *opensearchStore) getIndexMapping(ctx context.Context,
 	}
 	defer res.Body.Close()
 
-	if res.IsError() {
+	if res.StatusCode == http.StatusNotFound && s.indexStrategy.IsAlias() {
+		// the tenant's index is created with the first document
+		return map[string]interface{}{
+			"mappings": map[string]interface{}{
+				"properties": map[string]interface{}{},
+			},
+		}, nil
+	} else if res.IsError() {
 		return nil, errors.Errorf(
 			"failed to get devices index from store, tid %s, code %d",
 			tid, res.StatusCode,
@@ -508,6 +1444,16 @@ func (s *opensearchStore) getIndexMapping(ctx context.Context,
 	}
 
 	index, ok := indexRes[idx]
+	if !ok && len(indexRes) > 0 {
+		// idx is an alias: the response is keyed by the indices behind
+		// it, created from the same template; take the latest one
+		indices := make([]string, 0, len(indexRes))
+		for name := range indexRes {
+			indices = append(indices, name)
+		}
+		sort.Strings(indices)
+		index, ok = indexRes[indices[len(indices)-1]]
+	}
 	if !ok {
 		return nil, errors.New("can't parse index defintion response")
 	}
@@ -523,20 +1469,25 @@ func (s *opensearchStore) getIndexMapping(ctx context.Context,
 
 // GetDevicesIndex returns the index name for the tenant tid
 func (s *opensearchStore) GetDevicesIndex(tid string) string {
-	return s.devicesIndexName
+	return s.indexStrategy.Index(s.devicesIndexName, tid)
 }
 
 // GetDeploymentsIndex returns the index name for the tenant tid
 func (s *opensearchStore) GetDeploymentsIndex(tid string) string {
-	return s.deploymentsIndexName
+	return s.indexStrategy.Index(s.deploymentsIndexName, tid)
+}
+
+// getSoftwareIndex returns the software index name for the tenant tid
+func (s *opensearchStore) getSoftwareIndex(tid string) string {
+	return s.indexStrategy.Index(s.softwareIndexName, tid)
 }
 
 // GetDevicesRoutingKey returns the routing key for the tenant tid
 func (s *opensearchStore) GetDevicesRoutingKey(tid string) string {
-	return tid
+	return s.indexStrategy.RoutingKey(tid)
 }
 
 // GetDeploymentsRoutingKey returns the routing key for the tenant tid
 func (s *opensearchStore) GetDeploymentsRoutingKey(tid string) string {
-	return tid
+	return s.indexStrategy.RoutingKey(tid)
 }
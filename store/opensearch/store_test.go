@@ -0,0 +1,221 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/client/sigv4"
+)
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		status int
+		body   string
+		err    string
+	}{
+		"ok, green": {
+			status: http.StatusOK,
+			body:   `{"status": "green"}`,
+		},
+		"ok, yellow": {
+			status: http.StatusOK,
+			body:   `{"status": "yellow"}`,
+		},
+		"error, red": {
+			status: http.StatusOK,
+			body:   `{"status": "red"}`,
+			err:    "opensearch cluster health is red",
+		},
+		"error, status": {
+			status: http.StatusUnauthorized,
+			body:   `{}`,
+			err:    "failed to ping opensearch: status 401",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			srv := newTestServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/_cluster/health", r.URL.Path)
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+			defer srv.Close()
+
+			store, err := NewStore(WithServerAddresses([]string{srv.URL}))
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = store.Ping(context.Background())
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRequestSigner(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.Header.Get("Authorization"),
+				"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/" {
+				_, _ = w.Write([]byte(`{"version": ` +
+					`{"number": "2.4.0", "distribution": "opensearch"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status": "green"}`))
+		}))
+	defer srv.Close()
+
+	signer := sigv4.NewSigner(sigv4.Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	}, "us-east-1", sigv4.ServiceOpenSearch)
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithRequestSigner(signer),
+	)
+	if assert.NoError(t, err) {
+		assert.NoError(t, store.Ping(context.Background()))
+	}
+}
+
+func TestPointInTimeDistribution(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		distribution string
+
+		openPath  string
+		openRes   string
+		closePath string
+		closeBody string
+	}{
+		"opensearch": {
+			distribution: DistributionOpenSearch,
+
+			openPath:  "/devices/_search/point_in_time",
+			openRes:   `{"pit_id": "pit"}`,
+			closePath: "/_search/point_in_time",
+			closeBody: `{"pit_id": ["pit"]}`,
+		},
+		"elasticsearch": {
+			distribution: DistributionElasticsearch,
+
+			openPath:  "/devices/_pit",
+			openRes:   `{"id": "pit"}`,
+			closePath: "/_pit",
+			closeBody: `{"id": "pit"}`,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					switch {
+					case r.URL.Path == "/":
+						_, _ = w.Write([]byte(`{"version": ` +
+							`{"number": "2.4.0", "distribution": "opensearch"}}`))
+					case r.Method == http.MethodPost:
+						assert.Equal(t, tc.openPath, r.URL.Path)
+						assert.Equal(t, "60000ms", r.URL.Query().Get("keep_alive"))
+						_, _ = w.Write([]byte(tc.openRes))
+					case r.Method == http.MethodDelete:
+						assert.Equal(t, tc.closePath, r.URL.Path)
+						body, _ := io.ReadAll(r.Body)
+						assert.JSONEq(t, tc.closeBody, string(body))
+						_, _ = w.Write([]byte(`{}`))
+					}
+				}))
+			defer srv.Close()
+
+			store, err := NewStore(
+				WithServerAddresses([]string{srv.URL}),
+				WithDevicesIndexName("devices"),
+				WithDistribution(tc.distribution),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			ctx := identity.WithContext(context.Background(), &identity.Identity{
+				Tenant: "tenant",
+			})
+			pitID, err := store.OpenDevicesPointInTime(ctx, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, "pit", pitID)
+			assert.NoError(t, store.ClosePointInTime(ctx, pitID))
+		})
+	}
+}
+
+func TestNewStoreAuthentication(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// Elasticsearch 8, which the product check refuses
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/" {
+				_, _ = w.Write([]byte(`{"version": {"number": "8.6.2"}}`))
+				return
+			}
+			if r.Header.Get("Authorization") != "ApiKey a2V5" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status": "green"}`))
+		}))
+	defer srv.Close()
+
+	store, err := NewStore(
+		WithServerAddresses([]string{srv.URL}),
+		WithDistribution(DistributionElasticsearch),
+		WithAPIKey("a2V5"),
+	)
+	if assert.NoError(t, err) {
+		assert.NoError(t, store.Ping(context.Background()))
+	}
+
+	_, err = NewStore(WithDistribution("solr"))
+	assert.EqualError(t, err, `unknown distribution: "solr"`)
+}
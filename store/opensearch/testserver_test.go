@@ -0,0 +1,36 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// newTestServer starts a fake OpenSearch server answering in JSON with the
+// handler; the client verifies the server on the first request, so the
+// version info is served before the handler is reached
+func newTestServer(handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodGet && r.URL.Path == "/" {
+				_, _ = w.Write([]byte(
+					`{"version": {"number": "2.4.0", "distribution": "opensearch"}}`))
+				return
+			}
+			handler(w, r)
+		}))
+}
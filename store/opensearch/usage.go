@@ -0,0 +1,155 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+// GetTenantUsage returns the numbers of the tenant's documents in the
+// devices, deployments and software indices, and the space they take in
+// the primary shards; in the indices shared with other tenants, the space
+// is estimated from the tenant's share of the documents
+func (s *opensearchStore) GetTenantUsage(
+	ctx context.Context,
+	tenantID string,
+) (*model.TenantUsage, error) {
+	usage := &model.TenantUsage{TenantID: tenantID}
+	for _, index := range []struct {
+		name       string
+		routingKey string
+		documents  *int64
+	}{
+		{
+			name:       s.GetDevicesIndex(tenantID),
+			routingKey: s.GetDevicesRoutingKey(tenantID),
+			documents:  &usage.Documents.Devices,
+		},
+		{
+			name:       s.GetDeploymentsIndex(tenantID),
+			routingKey: s.GetDeploymentsRoutingKey(tenantID),
+			documents:  &usage.Documents.Deployments,
+		},
+		{
+			name:       s.getSoftwareIndex(tenantID),
+			routingKey: s.indexStrategy.RoutingKey(tenantID),
+			documents:  &usage.Documents.Software,
+		},
+	} {
+		documents, err := s.countDocuments(ctx, index.name, index.routingKey,
+			model.M{"term": model.M{model.FieldNameTenantID: tenantID}})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to count the documents of the %s index",
+				index.name)
+		}
+		*index.documents = documents
+		if documents == 0 {
+			continue
+		}
+		total, err := s.countDocuments(ctx, index.name, "", nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to count the documents of the %s index",
+				index.name)
+		}
+		size, err := s.getIndexSize(ctx, index.name)
+		if err != nil {
+			return nil, err
+		}
+		if total > 0 {
+			usage.IndexBytes += int64(float64(size) * float64(documents) / float64(total))
+		}
+	}
+	return usage, nil
+}
+
+// countDocuments returns the number of documents of the index matching the
+// filter, or of all the documents of the index if the filter is nil
+func (s *opensearchStore) countDocuments(
+	ctx context.Context,
+	indexName, routingKey string,
+	filter model.M,
+) (int64, error) {
+	countRequests := []func(*opensearchapi.CountRequest){
+		s.client.Count.WithContext(ctx),
+		s.client.Count.WithIndex(indexName),
+		s.client.Count.WithIgnoreUnavailable(ignoreUnavailable),
+	}
+	if filter != nil {
+		body, err := json.Marshal(model.M{"query": filter})
+		if err != nil {
+			return 0, err
+		}
+		countRequests = append(countRequests, s.client.Count.WithBody(bytes.NewReader(body)))
+	}
+	if routingKey != "" {
+		countRequests = append(countRequests, s.client.Count.WithRouting(routingKey))
+	}
+	resp, err := s.client.Count(countRequests...)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return 0, errors.New(resp.String())
+	}
+
+	var ret struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return 0, err
+	}
+	return ret.Count, nil
+}
+
+// getIndexSize returns the size of the index, or of the indices of the
+// alias, in the primary shards
+func (s *opensearchStore) getIndexSize(ctx context.Context, indexName string) (int64, error) {
+	resp, err := s.client.Indices.Stats(
+		s.client.Indices.Stats.WithContext(ctx),
+		s.client.Indices.Stats.WithIndex(indexName),
+		s.client.Indices.Stats.WithMetric("store"),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get the index stats")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return 0, errors.Errorf("failed to get the index stats: %s", resp.String())
+	}
+
+	var ret struct {
+		All struct {
+			Primaries struct {
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return 0, errors.Wrap(err, "failed to parse the index stats")
+	}
+	return ret.All.Primaries.Store.SizeInBytes, nil
+}
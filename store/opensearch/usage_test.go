@@ -0,0 +1,118 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/model"
+)
+
+func TestGetTenantUsage(t *testing.T) {
+	t.Parallel()
+
+	const tenantID = "tenant"
+	testCases := map[string]struct {
+		statsStatus int
+
+		usage *model.TenantUsage
+		err   string
+	}{
+		"ok": {
+			usage: &model.TenantUsage{
+				TenantID: tenantID,
+				Documents: model.UsageDocuments{
+					Devices:  10,
+					Software: 5,
+				},
+				// a tenth of the devices, a half of the software
+				IndexBytes: 100 + 250,
+			},
+		},
+		"error, stats": {
+			statsStatus: http.StatusInternalServerError,
+			err:         "failed to get the index stats: [500 Internal Server Error] {}",
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newTestServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					tenantCount := len(body) > 0
+					if tenantCount {
+						assert.JSONEq(t,
+							`{"query": {"term": {"tenant_id": "tenant"}}}`, string(body))
+						assert.Equal(t, tenantID, r.URL.Query().Get("routing"))
+					}
+					switch r.URL.Path {
+					case "/devices/_count":
+						if tenantCount {
+							_, _ = w.Write([]byte(`{"count": 10}`))
+						} else {
+							_, _ = w.Write([]byte(`{"count": 100}`))
+						}
+					case "/deployments/_count":
+						_, _ = w.Write([]byte(`{"count": 0}`))
+					case "/software/_count":
+						if tenantCount {
+							_, _ = w.Write([]byte(`{"count": 5}`))
+						} else {
+							_, _ = w.Write([]byte(`{"count": 10}`))
+						}
+					case "/devices/_stats/store":
+						if tc.statsStatus != 0 {
+							w.WriteHeader(tc.statsStatus)
+							_, _ = w.Write([]byte(`{}`))
+							return
+						}
+						_, _ = w.Write([]byte(
+							`{"_all": {"primaries": {"store": {"size_in_bytes": 1000}}}}`))
+					case "/software/_stats/store":
+						_, _ = w.Write([]byte(
+							`{"_all": {"primaries": {"store": {"size_in_bytes": 500}}}}`))
+					default:
+						assert.Failf(t, "unexpected request", "%s %s", r.Method, r.URL.Path)
+						w.WriteHeader(http.StatusNotFound)
+					}
+				}))
+			defer srv.Close()
+
+			store, err := NewStore(
+				WithServerAddresses([]string{srv.URL}),
+				WithDevicesIndexName("devices"),
+				WithDeploymentsIndexName("deployments"),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			usage, err := store.GetTenantUsage(context.Background(), tenantID)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.usage, usage)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/reporting/client/tenantadm"
+)
+
+const defaultResolverCacheTTL = 5 * time.Minute
+
+// Resolver resolves the clusters the data of the tenants resides in
+type Resolver interface {
+	// ResolveCluster returns the name of the cluster of the tenant, or
+	// an empty string for the default cluster
+	ResolveCluster(ctx context.Context, tenantID string) (string, error)
+}
+
+type resolvedCluster struct {
+	cluster  string
+	expireTs time.Time
+}
+
+type resolver struct {
+	tenantClusters map[string]string
+	tenants        tenantadm.Client
+	ttl            time.Duration
+	now            func() time.Time
+
+	mutex sync.Mutex
+	cache map[string]resolvedCluster
+}
+
+// NewResolver returns a resolver looking up the clusters of the tenants in
+// the tenantClusters routing table, by tenant ID; the clusters of the other
+// tenants are the regions of the tenants in tenantadm, cached for a few
+// minutes, if tenants is not nil, or the default cluster otherwise
+func NewResolver(tenantClusters map[string]string, tenants tenantadm.Client) Resolver {
+	return &resolver{
+		tenantClusters: tenantClusters,
+		tenants:        tenants,
+		ttl:            defaultResolverCacheTTL,
+		now:            time.Now,
+		cache:          map[string]resolvedCluster{},
+	}
+}
+
+func (r *resolver) ResolveCluster(ctx context.Context, tenantID string) (string, error) {
+	if cluster, ok := r.tenantClusters[tenantID]; ok {
+		return cluster, nil
+	}
+	if r.tenants == nil || tenantID == "" {
+		return "", nil
+	}
+	now := r.now()
+	r.mutex.Lock()
+	resolved, ok := r.cache[tenantID]
+	r.mutex.Unlock()
+	if ok && now.Before(resolved.expireTs) {
+		return resolved.cluster, nil
+	}
+
+	// the tenants which can not be resolved are not routed to the default
+	// cluster: their data could reside in another one
+	tenant, err := r.tenants.GetTenant(ctx, tenantID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve the cluster of the tenant %s",
+			tenantID)
+	}
+	r.mutex.Lock()
+	r.cache[tenantID] = resolvedCluster{
+		cluster:  tenant.Region,
+		expireTs: now.Add(r.ttl),
+	}
+	r.mutex.Unlock()
+	return tenant.Region, nil
+}
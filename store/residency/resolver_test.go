@@ -0,0 +1,89 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mendersoftware/reporting/client/tenantadm"
+	mtenantadm "github.com/mendersoftware/reporting/client/tenantadm/mocks"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tenants := &mtenantadm.Client{}
+	defer tenants.AssertExpectations(t)
+	tenants.On("GetTenant", ctx, "eu-tenant").
+		Return(&tenantadm.Tenant{ID: "eu-tenant", Region: "eu"}, nil).
+		Once()
+	tenants.On("GetTenant", ctx, "us-tenant").
+		Return(&tenantadm.Tenant{ID: "us-tenant"}, nil).
+		Once()
+	tenants.On("GetTenant", ctx, "unknown").
+		Return(nil, errors.New("connection refused")).
+		Once()
+
+	now := time.Date(2023, 3, 6, 6, 0, 0, 0, time.UTC)
+	r := NewResolver(map[string]string{"table-tenant": "apac"}, tenants).(*resolver)
+	r.now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		tenantID string
+		cluster  string
+	}{
+		{tenantID: "table-tenant", cluster: "apac"},
+		{tenantID: "eu-tenant", cluster: "eu"},
+		{tenantID: "us-tenant", cluster: ""},
+		{tenantID: "", cluster: ""},
+		// cached
+		{tenantID: "eu-tenant", cluster: "eu"},
+	} {
+		cluster, err := r.ResolveCluster(ctx, tc.tenantID)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.cluster, cluster, tc.tenantID)
+	}
+
+	_, err := r.ResolveCluster(ctx, "unknown")
+	assert.EqualError(t, err,
+		"failed to resolve the cluster of the tenant unknown: connection refused")
+
+	// the cache expires
+	now = now.Add(defaultResolverCacheTTL)
+	tenants.On("GetTenant", ctx, "eu-tenant").
+		Return(&tenantadm.Tenant{ID: "eu-tenant", Region: "eu"}, nil).
+		Once()
+	cluster, err := r.ResolveCluster(ctx, "eu-tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "eu", cluster)
+}
+
+func TestResolverWithoutTenantadm(t *testing.T) {
+	t.Parallel()
+
+	r := NewResolver(map[string]string{"eu-tenant": "eu"}, nil)
+	cluster, err := r.ResolveCluster(context.Background(), "eu-tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "eu", cluster)
+	cluster, err = r.ResolveCluster(context.Background(), "other-tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "", cluster)
+}
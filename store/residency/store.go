@@ -0,0 +1,495 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+)
+
+// DefaultCluster is the name of the cluster of the tenants without data
+// residency requirements
+const DefaultCluster = "default"
+
+// routedStore routes the documents and the queries of the tenants to the
+// stores of the clusters their data resides in
+type routedStore struct {
+	clusters map[string]store.Store
+	names    []string
+	resolver Resolver
+}
+
+// NewStore returns a store routing the documents and the queries of each
+// tenant to the store of the cluster resolved by the resolver; the clusters
+// are stores by name, the default one named DefaultCluster. The stores are
+// expected to share the same indices configuration: the names of the
+// indices and their routing keys are the ones of the default cluster.
+func NewStore(clusters map[string]store.Store, resolver Resolver) (store.Store, error) {
+	if _, ok := clusters[DefaultCluster]; !ok {
+		return nil, errors.New("the default cluster is not configured")
+	}
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &routedStore{
+		clusters: clusters,
+		names:    names,
+		resolver: resolver,
+	}, nil
+}
+
+// cluster returns the store of the cluster of the tenant
+func (s *routedStore) cluster(ctx context.Context, tenantID string) (store.Store, error) {
+	name, err := s.resolver.ResolveCluster(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = DefaultCluster
+	}
+	cluster, ok := s.clusters[name]
+	if !ok {
+		return nil, errors.Errorf("the cluster %q of the tenant %s is not configured",
+			name, tenantID)
+	}
+	return cluster, nil
+}
+
+// contextCluster returns the store of the cluster of the tenant in the
+// context, which the queries run for
+func (s *routedStore) contextCluster(ctx context.Context) (store.Store, error) {
+	var tenantID string
+	if id := identity.FromContext(ctx); id != nil {
+		tenantID = id.Tenant
+	}
+	return s.cluster(ctx, tenantID)
+}
+
+// each calls fn with the store of each of the clusters, in the order of
+// their names, stopping at the first error
+func (s *routedStore) each(fn func(cluster store.Store) error) error {
+	for _, name := range s.names {
+		if err := fn(s.clusters[name]); err != nil {
+			return errors.Wrapf(err, "cluster %s", name)
+		}
+	}
+	return nil
+}
+
+// devicesByCluster groups the devices by the store of the cluster of their
+// tenant
+func (s *routedStore) devicesByCluster(
+	ctx context.Context,
+	devices []*model.Device,
+) (map[store.Store][]*model.Device, error) {
+	res := map[store.Store][]*model.Device{}
+	for _, device := range devices {
+		cluster, err := s.cluster(ctx, device.GetTenantID())
+		if err != nil {
+			return nil, err
+		}
+		res[cluster] = append(res[cluster], device)
+	}
+	return res, nil
+}
+
+// forDevices calls fn with the store of the cluster of the devices, and
+// the devices of its tenants, for each of the clusters of the devices
+func (s *routedStore) forDevices(
+	ctx context.Context,
+	devices []*model.Device,
+	fn func(cluster store.Store, devices []*model.Device) error,
+) error {
+	byCluster, err := s.devicesByCluster(ctx, devices)
+	if err != nil {
+		return err
+	}
+	for cluster, devices := range byCluster {
+		if err := fn(cluster, devices); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *routedStore) BulkIndexDeployments(
+	ctx context.Context,
+	deployments []*model.Deployment,
+) error {
+	byCluster := map[store.Store][]*model.Deployment{}
+	for _, deployment := range deployments {
+		cluster, err := s.cluster(ctx, deployment.TenantID)
+		if err != nil {
+			return err
+		}
+		byCluster[cluster] = append(byCluster[cluster], deployment)
+	}
+	for cluster, deployments := range byCluster {
+		if err := cluster.BulkIndexDeployments(ctx, deployments); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *routedStore) BulkIndexDevices(
+	ctx context.Context,
+	devices, removedDevices []*model.Device,
+) error {
+	byCluster, err := s.devicesByCluster(ctx, devices)
+	if err != nil {
+		return err
+	}
+	removedByCluster, err := s.devicesByCluster(ctx, removedDevices)
+	if err != nil {
+		return err
+	}
+	for cluster := range removedByCluster {
+		if _, ok := byCluster[cluster]; !ok {
+			byCluster[cluster] = nil
+		}
+	}
+	for cluster, devices := range byCluster {
+		err := cluster.BulkIndexDevices(ctx, devices, removedByCluster[cluster])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *routedStore) BulkIndexSoftware(
+	ctx context.Context,
+	software []*model.DeviceSoftware,
+	removedDevices []*model.Device,
+) error {
+	byCluster := map[store.Store][]*model.DeviceSoftware{}
+	for _, sw := range software {
+		cluster, err := s.cluster(ctx, sw.TenantID)
+		if err != nil {
+			return err
+		}
+		byCluster[cluster] = append(byCluster[cluster], sw)
+	}
+	removedByCluster, err := s.devicesByCluster(ctx, removedDevices)
+	if err != nil {
+		return err
+	}
+	for cluster := range removedByCluster {
+		if _, ok := byCluster[cluster]; !ok {
+			byCluster[cluster] = nil
+		}
+	}
+	for cluster, software := range byCluster {
+		err := cluster.BulkIndexSoftware(ctx, software, removedByCluster[cluster])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *routedStore) UpdateDevices(ctx context.Context, devices []*model.Device) error {
+	return s.forDevices(ctx, devices, func(cluster store.Store, devices []*model.Device) error {
+		return cluster.UpdateDevices(ctx, devices)
+	})
+}
+
+func (s *routedStore) ReplaceDevicesTags(ctx context.Context, devices []*model.Device) error {
+	return s.forDevices(ctx, devices, func(cluster store.Store, devices []*model.Device) error {
+		return cluster.ReplaceDevicesTags(ctx, devices)
+	})
+}
+
+func (s *routedStore) DeleteDevicesData(
+	ctx context.Context,
+	tenantID string,
+	deviceIDs []string,
+) error {
+	cluster, err := s.cluster(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return cluster.DeleteDevicesData(ctx, tenantID, deviceIDs)
+}
+
+func (s *routedStore) DeleteTenantData(ctx context.Context, tenantID string) error {
+	cluster, err := s.cluster(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return cluster.DeleteTenantData(ctx, tenantID)
+}
+
+func (s *routedStore) ListDevicesIDs(
+	ctx context.Context,
+	tenantID, afterID string,
+	limit int,
+) ([]string, error) {
+	cluster, err := s.cluster(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.ListDevicesIDs(ctx, tenantID, afterID, limit)
+}
+
+func (s *routedStore) ListDevicesUpdatedAt(
+	ctx context.Context,
+	tenantID, afterID string,
+	limit int,
+) (map[string]time.Time, error) {
+	cluster, err := s.cluster(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.ListDevicesUpdatedAt(ctx, tenantID, afterID, limit)
+}
+
+func (s *routedStore) GetDevicesIndex(tid string) string {
+	return s.clusters[DefaultCluster].GetDevicesIndex(tid)
+}
+
+func (s *routedStore) GetDevicesRoutingKey(tid string) string {
+	return s.clusters[DefaultCluster].GetDevicesRoutingKey(tid)
+}
+
+func (s *routedStore) GetDevicesIndexMapping(
+	ctx context.Context,
+	tid string,
+) (map[string]interface{}, error) {
+	cluster, err := s.cluster(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.GetDevicesIndexMapping(ctx, tid)
+}
+
+func (s *routedStore) DeleteDeploymentsBefore(ctx context.Context, cutoff time.Time) error {
+	return s.each(func(cluster store.Store) error {
+		return cluster.DeleteDeploymentsBefore(ctx, cutoff)
+	})
+}
+
+func (s *routedStore) GetDeploymentsIndex(tid string) string {
+	return s.clusters[DefaultCluster].GetDeploymentsIndex(tid)
+}
+
+func (s *routedStore) GetDeploymentsRoutingKey(tid string) string {
+	return s.clusters[DefaultCluster].GetDeploymentsRoutingKey(tid)
+}
+
+func (s *routedStore) GetDeploymentsIndexMapping(
+	ctx context.Context,
+	tid string,
+) (map[string]interface{}, error) {
+	cluster, err := s.cluster(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.GetDeploymentsIndexMapping(ctx, tid)
+}
+
+func (s *routedStore) Migrate(ctx context.Context) error {
+	return s.each(func(cluster store.Store) error {
+		return cluster.Migrate(ctx)
+	})
+}
+
+func (s *routedStore) MigrateMappings(ctx context.Context) error {
+	return s.each(func(cluster store.Store) error {
+		return cluster.MigrateMappings(ctx)
+	})
+}
+
+// PlanMigrations returns the changes of all the clusters, with the name of
+// their cluster
+func (s *routedStore) PlanMigrations(ctx context.Context) ([]model.MigrationChange, error) {
+	changes := []model.MigrationChange{}
+	for _, name := range s.names {
+		clusterChanges, err := s.clusters[name].PlanMigrations(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cluster %s", name)
+		}
+		for _, change := range clusterChanges {
+			change.Cluster = name
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (s *routedStore) AggregateDevices(ctx context.Context, query model.Query) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.AggregateDevices(ctx, query)
+}
+
+func (s *routedStore) AggregateDeployments(
+	ctx context.Context,
+	query model.Query,
+) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.AggregateDeployments(ctx, query)
+}
+
+func (s *routedStore) AggregateSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.AggregateSoftware(ctx, query)
+}
+
+func (s *routedStore) SearchSoftware(ctx context.Context, query model.Query) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.SearchSoftware(ctx, query)
+}
+
+func (s *routedStore) SearchDevices(ctx context.Context, query model.Query) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.SearchDevices(ctx, query)
+}
+
+func (s *routedStore) CountDevices(ctx context.Context, query model.Query) (int, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return cluster.CountDevices(ctx, query)
+}
+
+func (s *routedStore) SearchDeployments(ctx context.Context, query model.Query) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.SearchDeployments(ctx, query)
+}
+
+// OpenDevicesPointInTime, SearchPointInTime and ClosePointInTime run on
+// the cluster of the tenant in the context, which the point in time
+// belongs to
+func (s *routedStore) OpenDevicesPointInTime(
+	ctx context.Context,
+	keepAlive time.Duration,
+) (string, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return "", err
+	}
+	return cluster.OpenDevicesPointInTime(ctx, keepAlive)
+}
+
+func (s *routedStore) SearchPointInTime(ctx context.Context, query model.Query) (model.M, error) {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.SearchPointInTime(ctx, query)
+}
+
+func (s *routedStore) ClosePointInTime(ctx context.Context, pitID string) error {
+	cluster, err := s.contextCluster(ctx)
+	if err != nil {
+		return err
+	}
+	return cluster.ClosePointInTime(ctx, pitID)
+}
+
+func (s *routedStore) CreateDevicesIndex(ctx context.Context, tid string) (string, error) {
+	cluster, err := s.cluster(ctx, tid)
+	if err != nil {
+		return "", err
+	}
+	return cluster.CreateDevicesIndex(ctx, tid)
+}
+
+func (s *routedStore) BulkIndexDevicesInto(
+	ctx context.Context,
+	index string,
+	devices []*model.Device,
+) error {
+	return s.forDevices(ctx, devices, func(cluster store.Store, devices []*model.Device) error {
+		return cluster.BulkIndexDevicesInto(ctx, index, devices)
+	})
+}
+
+func (s *routedStore) SwapDevicesIndex(ctx context.Context, tid, index string) error {
+	cluster, err := s.cluster(ctx, tid)
+	if err != nil {
+		return err
+	}
+	return cluster.SwapDevicesIndex(ctx, tid, index)
+}
+
+func (s *routedStore) RemapDevicesAttribute(
+	ctx context.Context,
+	tenantID, scope, attribute string,
+	typ model.Type,
+) error {
+	cluster, err := s.cluster(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return cluster.RemapDevicesAttribute(ctx, tenantID, scope, attribute, typ)
+}
+
+func (s *routedStore) UpdateIndexSettings(
+	ctx context.Context,
+	index string,
+	settings *model.IndexSettings,
+) error {
+	return s.each(func(cluster store.Store) error {
+		return cluster.UpdateIndexSettings(ctx, index, settings)
+	})
+}
+
+func (s *routedStore) GetTenantUsage(
+	ctx context.Context,
+	tenantID string,
+) (*model.TenantUsage, error) {
+	cluster, err := s.cluster(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.GetTenantUsage(ctx, tenantID)
+}
+
+func (s *routedStore) Ping(ctx context.Context) error {
+	return s.each(func(cluster store.Store) error {
+		return cluster.Ping(ctx)
+	})
+}
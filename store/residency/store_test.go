@@ -0,0 +1,170 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package residency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mendersoftware/go-lib-micro/identity"
+
+	"github.com/mendersoftware/reporting/model"
+	"github.com/mendersoftware/reporting/store"
+	mstore "github.com/mendersoftware/reporting/store/mocks"
+)
+
+func newTestStore(t *testing.T) (store.Store, *mstore.Store, *mstore.Store) {
+	defaultStore := &mstore.Store{}
+	euStore := &mstore.Store{}
+	t.Cleanup(func() {
+		defaultStore.AssertExpectations(t)
+		euStore.AssertExpectations(t)
+	})
+	s, err := NewStore(map[string]store.Store{
+		DefaultCluster: defaultStore,
+		"eu":           euStore,
+	}, NewResolver(map[string]string{
+		"eu-tenant":      "eu",
+		"unknown-tenant": "apac",
+	}, nil))
+	assert.NoError(t, err)
+	return s, defaultStore, euStore
+}
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewStore(map[string]store.Store{
+		"eu": &mstore.Store{},
+	}, NewResolver(nil, nil))
+	assert.EqualError(t, err, "the default cluster is not configured")
+}
+
+func TestRoutedStoreQueries(t *testing.T) {
+	t.Parallel()
+
+	s, defaultStore, euStore := newTestStore(t)
+	query := model.NewQuery()
+	euCtx := identity.WithContext(context.Background(),
+		&identity.Identity{Tenant: "eu-tenant"})
+	usCtx := identity.WithContext(context.Background(),
+		&identity.Identity{Tenant: "us-tenant"})
+
+	euStore.On("SearchDevices", euCtx, query).
+		Return(model.M{"cluster": "eu"}, nil).Once()
+	defaultStore.On("SearchDevices", usCtx, query).
+		Return(model.M{"cluster": "default"}, nil).Once()
+	euStore.On("CountDevices", euCtx, query).Return(1, nil).Once()
+	euStore.On("OpenDevicesPointInTime", euCtx, time.Minute).Return("pit", nil).Once()
+	euStore.On("ClosePointInTime", euCtx, "pit").Return(nil).Once()
+
+	res, err := s.SearchDevices(euCtx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, model.M{"cluster": "eu"}, res)
+	res, err = s.SearchDevices(usCtx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, model.M{"cluster": "default"}, res)
+	count, err := s.CountDevices(euCtx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	pitID, err := s.OpenDevicesPointInTime(euCtx, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, s.ClosePointInTime(euCtx, pitID))
+
+	// the tenants routed to a cluster which is not configured are not
+	// routed to the default one
+	unknownCtx := identity.WithContext(context.Background(),
+		&identity.Identity{Tenant: "unknown-tenant"})
+	_, err = s.AggregateDevices(unknownCtx, query)
+	assert.EqualError(t, err,
+		`the cluster "apac" of the tenant unknown-tenant is not configured`)
+}
+
+func TestRoutedStoreBulkIndexDevices(t *testing.T) {
+	t.Parallel()
+
+	s, defaultStore, euStore := newTestStore(t)
+	ctx := context.Background()
+	euDevice := model.NewDevice("eu-tenant", "1")
+	usDevice := model.NewDevice("us-tenant", "2")
+	removedEUDevice := model.NewDevice("eu-tenant", "3")
+
+	euStore.On("BulkIndexDevices", ctx,
+		[]*model.Device{euDevice}, []*model.Device{removedEUDevice}).
+		Return(nil).Once()
+	defaultStore.On("BulkIndexDevices", ctx,
+		[]*model.Device{usDevice}, []*model.Device(nil)).
+		Return(nil).Once()
+	err := s.BulkIndexDevices(ctx,
+		[]*model.Device{euDevice, usDevice}, []*model.Device{removedEUDevice})
+	assert.NoError(t, err)
+
+	// removed devices only
+	euStore.On("BulkIndexDevices", ctx,
+		[]*model.Device(nil), []*model.Device{removedEUDevice}).
+		Return(nil).Once()
+	err = s.BulkIndexDevices(ctx, nil, []*model.Device{removedEUDevice})
+	assert.NoError(t, err)
+
+	euStore.On("BulkIndexDeployments", ctx, mock.MatchedBy(
+		func(deployments []*model.Deployment) bool {
+			return len(deployments) == 1 && deployments[0].TenantID == "eu-tenant"
+		})).Return(nil).Once()
+	defaultStore.On("BulkIndexDeployments", ctx, mock.MatchedBy(
+		func(deployments []*model.Deployment) bool {
+			return len(deployments) == 1 && deployments[0].TenantID == "us-tenant"
+		})).Return(nil).Once()
+	err = s.BulkIndexDeployments(ctx, []*model.Deployment{
+		{TenantID: "eu-tenant"},
+		{TenantID: "us-tenant"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestRoutedStoreTenant(t *testing.T) {
+	t.Parallel()
+
+	s, defaultStore, euStore := newTestStore(t)
+	ctx := context.Background()
+
+	euStore.On("DeleteTenantData", ctx, "eu-tenant").Return(nil).Once()
+	defaultStore.On("GetTenantUsage", ctx, "us-tenant").
+		Return(&model.TenantUsage{}, nil).Once()
+	defaultStore.On("GetDevicesIndex", "eu-tenant").Return("devices").Once()
+
+	assert.NoError(t, s.DeleteTenantData(ctx, "eu-tenant"))
+	_, err := s.GetTenantUsage(ctx, "us-tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "devices", s.GetDevicesIndex("eu-tenant"))
+}
+
+func TestRoutedStoreAllClusters(t *testing.T) {
+	t.Parallel()
+
+	s, defaultStore, euStore := newTestStore(t)
+	ctx := context.Background()
+
+	defaultStore.On("Migrate", ctx).Return(nil).Once()
+	euStore.On("Migrate", ctx).Return(nil).Once()
+	assert.NoError(t, s.Migrate(ctx))
+
+	defaultStore.On("Ping", ctx).Return(nil).Once()
+	euStore.On("Ping", ctx).Return(store.ErrTooManyQueries).Once()
+	assert.EqualError(t, s.Ping(ctx), "cluster eu: "+store.ErrTooManyQueries.Error())
+}
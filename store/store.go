@@ -16,24 +16,115 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/mendersoftware/reporting/model"
 )
 
+var (
+	// ErrPointInTimeNotFound is returned when searching on a point in time
+	// which does not exist or expired
+	ErrPointInTimeNotFound = errors.New("point in time not found or expired")
+	// ErrIndexRebuildNotSupported is returned when rebuilding the tenant's
+	// index is not supported by the store or its index strategy
+	ErrIndexRebuildNotSupported = errors.New("rebuilding the tenant's index is not supported")
+	// ErrAttributeRemapNotSupported is returned when converting the indexed
+	// values of an attribute to another type is not supported by the store
+	ErrAttributeRemapNotSupported = errors.New(
+		"remapping the attributes to another type is not supported")
+	// ErrIndexSettingsNotSupported is returned when the store has no
+	// indices whose settings can be updated
+	ErrIndexSettingsNotSupported = errors.New("updating the index settings is not supported")
+	// ErrTooManyQueries is returned when the maximum number of concurrent
+	// queries are running, and the query could not wait for one to finish
+	ErrTooManyQueries = errors.New("too many concurrent queries, please retry later")
+	// ErrBulkRejected is returned when the bulk requests are rejected by
+	// the overloaded cluster, even after retrying them
+	ErrBulkRejected = errors.New("bulk request rejected by the cluster")
+	// ErrQueryNotSupported is returned when the storage backend cannot run
+	// a query, e.g. because it has no equivalent of an OpenSearch clause or
+	// aggregation
+	ErrQueryNotSupported = errors.New("query not supported by the storage backend")
+)
+
 //go:generate ../x/mockgen.sh
 type Store interface {
 	BulkIndexDeployments(ctx context.Context, deployments []*model.Deployment) error
 	BulkIndexDevices(ctx context.Context, devices, removedDevices []*model.Device) error
+	// BulkIndexSoftware indexes the software inventory of the devices, and
+	// deletes the one of the removed devices
+	BulkIndexSoftware(ctx context.Context, software []*model.DeviceSoftware,
+		removedDevices []*model.Device) error
+	// UpdateDevices partially updates the indexed devices with the
+	// attributes set in the documents; the devices not indexed are skipped
+	UpdateDevices(ctx context.Context, devices []*model.Device) error
+	// ReplaceDevicesTags replaces the tags attributes of the indexed
+	// devices with the ones of the documents, removing the others; the
+	// devices not indexed are skipped
+	ReplaceDevicesTags(ctx context.Context, devices []*model.Device) error
+	// DeleteDevicesData deletes the documents of the tenant's devices and
+	// their deployments history, e.g. once the devices are decommissioned
+	DeleteDevicesData(ctx context.Context, tenantID string, deviceIDs []string) error
+	// DeleteTenantData deletes all the documents of the tenant
+	DeleteTenantData(ctx context.Context, tenantID string) error
+	// ListDevicesIDs returns up to limit IDs of the tenant's indexed
+	// devices, in ascending order, following afterID if not empty
+	ListDevicesIDs(ctx context.Context, tenantID, afterID string, limit int) ([]string, error)
+	// ListDevicesUpdatedAt returns the time up to limit of the tenant's
+	// indexed devices were updated in inventory, by device ID, for the
+	// devices following afterID, in ascending order, if not empty
+	ListDevicesUpdatedAt(ctx context.Context, tenantID, afterID string, limit int) (
+		map[string]time.Time, error)
 	GetDevicesIndex(tid string) string
 	GetDevicesRoutingKey(tid string) string
 	GetDevicesIndexMapping(ctx context.Context, tid string) (map[string]interface{}, error)
+	// DeleteDeploymentsBefore deletes the deployments of all the tenants
+	// which finished before the cutoff
+	DeleteDeploymentsBefore(ctx context.Context, cutoff time.Time) error
 	GetDeploymentsIndex(tid string) string
 	GetDeploymentsRoutingKey(tid string) string
 	GetDeploymentsIndexMapping(ctx context.Context, tid string) (map[string]interface{}, error)
 	Migrate(ctx context.Context) error
+	// MigrateMappings migrates the existing indices to the current
+	// version of their mappings, without downtime
+	MigrateMappings(ctx context.Context) error
+	// PlanMigrations returns the changes Migrate and MigrateMappings
+	// would apply, without applying them
+	PlanMigrations(ctx context.Context) ([]model.MigrationChange, error)
 	AggregateDevices(ctx context.Context, query model.Query) (model.M, error)
 	AggregateDeployments(ctx context.Context, query model.Query) (model.M, error)
+	// AggregateSoftware and SearchSoftware aggregate and search the
+	// software inventory of the devices
+	AggregateSoftware(ctx context.Context, query model.Query) (model.M, error)
+	SearchSoftware(ctx context.Context, query model.Query) (model.M, error)
 	SearchDevices(ctx context.Context, query model.Query) (model.M, error)
+	// CountDevices returns the number of devices matching the query,
+	// without retrieving them
+	CountDevices(ctx context.Context, query model.Query) (int, error)
 	SearchDeployments(ctx context.Context, query model.Query) (model.M, error)
+	OpenDevicesPointInTime(ctx context.Context, keepAlive time.Duration) (string, error)
+	SearchPointInTime(ctx context.Context, query model.Query) (model.M, error)
+	ClosePointInTime(ctx context.Context, pitID string) error
+	// CreateDevicesIndex creates a new index to rebuild the tenant's devices
+	// in, not visible to the searches until SwapDevicesIndex is called
+	CreateDevicesIndex(ctx context.Context, tid string) (string, error)
+	// BulkIndexDevicesInto indexes the devices in the given index
+	BulkIndexDevicesInto(ctx context.Context, index string, devices []*model.Device) error
+	// SwapDevicesIndex atomically replaces the tenant's devices index with
+	// the given one, deleting the previous index
+	SwapDevicesIndex(ctx context.Context, tid, index string) error
+	// RemapDevicesAttribute converts the values the tenant's devices are
+	// indexed with for the (mapped) attribute to the type it is declared
+	// to be indexed as, moving them to the field of the type; with
+	// TypeAny, the values are moved back to the default fields
+	RemapDevicesAttribute(ctx context.Context, tenantID, scope, attribute string,
+		typ model.Type) error
+	// UpdateIndexSettings updates the dynamic settings of the existing
+	// indices of the kind: devices, deployments or software
+	UpdateIndexSettings(ctx context.Context, index string, settings *model.IndexSettings) error
+	// GetTenantUsage returns the numbers of the tenant's documents and the
+	// space they take in the indices
+	GetTenantUsage(ctx context.Context, tenantID string) (*model.TenantUsage, error)
 	Ping(ctx context.Context) error
 }
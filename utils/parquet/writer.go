@@ -0,0 +1,182 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+// Package parquet writes Apache Parquet files with flat schemas of optional
+// string, double and boolean columns, on top of xitongsys/parquet-go.
+package parquet
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	String ColumnType = iota
+	Double
+	Boolean
+)
+
+const (
+	// DefaultRowGroupSize is the default number of rows in a row group
+	DefaultRowGroupSize = 10000
+	// parallelism is the number of goroutines marshalling the rows
+	parallelism = 1
+)
+
+// Column is a column of the file
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// metadata returns the schema definition of the column for the CSV writer
+// of parquet-go; the column names can contain the separators of the
+// definition, so they are replaced by a placeholder here and the actual
+// names are set in the schema handler
+func (c Column) metadata(i int) string {
+	var typ string
+	switch c.Type {
+	case Double:
+		typ = "type=DOUBLE"
+	case Boolean:
+		typ = "type=BOOLEAN"
+	default:
+		typ = "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+	return fmt.Sprintf("name=c%d, %s, repetitiontype=OPTIONAL", i, typ)
+}
+
+// Writer writes the rows of a Parquet file: the rows are buffered and
+// written in row groups
+type Writer struct {
+	w            io.Writer
+	pw           *writer.CSVWriter
+	columns      []Column
+	rowGroupSize int
+	rows         int
+}
+
+// NewWriter returns a Writer of the Parquet file with the given columns;
+// nothing is written to w before the first row
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		rowGroupSize: DefaultRowGroupSize,
+	}
+}
+
+// WithRowGroupSize sets the number of rows of the row groups
+func (w *Writer) WithRowGroupSize(size int) *Writer {
+	if size > 0 {
+		w.rowGroupSize = size
+	}
+	return w
+}
+
+// writer returns the parquet-go writer, creating it and writing the header
+// of the file on the first call
+func (w *Writer) writer() (*writer.CSVWriter, error) {
+	if w.pw != nil {
+		return w.pw, nil
+	}
+	md := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		md[i] = column.metadata(i)
+	}
+	pw, err := writer.NewCSVWriterFromWriter(md, w.w, parallelism)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the parquet writer")
+	}
+	// the first schema element is the root
+	for i, column := range w.columns {
+		pw.SchemaHandler.Infos[i+1].ExName = column.Name
+	}
+	pw.SchemaHandler.CreateInExMap()
+	w.pw = pw
+	return pw, nil
+}
+
+// WriteRow writes a row: a value for each column, nil for the null values;
+// the values must be strings for the String columns, float64 for the
+// Double columns and bool for the Boolean columns
+func (w *Writer) WriteRow(values []interface{}) error {
+	if len(values) != len(w.columns) {
+		return errors.Errorf("expected %d values, got %d", len(w.columns), len(values))
+	}
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		var ok bool
+		switch w.columns[i].Type {
+		case String:
+			_, ok = v.(string)
+		case Double:
+			_, ok = v.(float64)
+		case Boolean:
+			_, ok = v.(bool)
+		}
+		if !ok {
+			return errors.Errorf("column %s: unexpected value type %T",
+				w.columns[i].Name, v)
+		}
+	}
+	pw, err := w.writer()
+	if err != nil {
+		return err
+	}
+	// the CSV writer keeps the slice, which belongs to the caller
+	row := make([]interface{}, len(values))
+	copy(row, values)
+	if err := pw.Write(row); err != nil {
+		return errors.Wrap(err, "failed to write the row")
+	}
+	w.rows++
+	if w.rows >= w.rowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows as a row group
+func (w *Writer) Flush() error {
+	if w.pw == nil || w.rows == 0 {
+		return nil
+	}
+	if err := w.pw.Flush(true); err != nil {
+		return errors.Wrap(err, "failed to write the row group")
+	}
+	w.rows = 0
+	return nil
+}
+
+// Close flushes the buffered rows and writes the footer of the file; it
+// doesn't close the underlying writer
+func (w *Writer) Close() error {
+	pw, err := w.writer()
+	if err != nil {
+		return err
+	}
+	if err := pw.WriteStop(); err != nil {
+		return errors.Wrap(err, "failed to write the footer")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package parquet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// readFile reads the file back with the parquet-go reader: it returns the
+// schema elements of the columns, the number of rows of the row groups and
+// the rows
+func readFile(
+	t *testing.T,
+	file []byte,
+) ([]*parquet.SchemaElement, []int64, [][]interface{}) {
+	pf, err := buffer.NewBufferFile(file)
+	require.NoError(t, err)
+	r, err := reader.NewParquetColumnReader(pf, 1)
+	require.NoError(t, err)
+	defer r.ReadStop()
+
+	schema := r.Footer.GetSchema()[1:]
+	for i, element := range schema {
+		// the reader renames the elements to Go identifiers
+		element.Name = r.SchemaHandler.Infos[i+1].ExName
+	}
+	var rowGroups []int64
+	for _, rg := range r.Footer.GetRowGroups() {
+		rowGroups = append(rowGroups, rg.GetNumRows())
+	}
+	numRows := r.GetNumRows()
+	rows := make([][]interface{}, numRows)
+	for i := range rows {
+		rows[i] = make([]interface{}, len(schema))
+	}
+	for j := range schema {
+		values, _, _, err := r.ReadColumnByIndex(int64(j), numRows)
+		require.NoError(t, err)
+		require.Len(t, values, int(numRows))
+		for i, v := range values {
+			rows[i][j] = v
+		}
+	}
+	return schema, rowGroups, rows
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	columns := []Column{
+		{Name: "id", Type: String},
+		{Name: "inventory:cpus", Type: Double},
+		{Name: "inventory:active", Type: Boolean},
+		{Name: "identity:name=value, type", Type: String},
+	}
+	rows := [][]interface{}{
+		{"1", 4.0, true, "a"},
+		{"2", nil, false, nil},
+		{"3", 2.5, nil, "c"},
+		{"4", nil, true, ""},
+		{"5", 1.0, true, "e"},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, columns).WithRowGroupSize(3)
+	for _, row := range rows {
+		require.NoError(t, w.WriteRow(row))
+	}
+	require.NoError(t, w.Close())
+
+	schema, rowGroups, actual := readFile(t, buf.Bytes())
+	types := map[ColumnType]parquet.Type{
+		String:  parquet.Type_BYTE_ARRAY,
+		Double:  parquet.Type_DOUBLE,
+		Boolean: parquet.Type_BOOLEAN,
+	}
+	if assert.Len(t, schema, len(columns)) {
+		for i, column := range columns {
+			assert.Equal(t, column.Name, schema[i].GetName())
+			assert.Equal(t, types[column.Type], schema[i].GetType())
+			assert.Equal(t,
+				parquet.FieldRepetitionType_OPTIONAL,
+				schema[i].GetRepetitionType())
+		}
+		assert.Equal(t, parquet.ConvertedType_UTF8, schema[0].GetConvertedType())
+	}
+	// the rows are split in row groups of 3 rows
+	assert.Equal(t, []int64{3, 2}, rowGroups)
+	assert.Equal(t, rows, actual)
+}
+
+func TestWriterEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: String}})
+	assert.Zero(t, buf.Len())
+	require.NoError(t, w.Close())
+
+	schema, rowGroups, rows := readFile(t, buf.Bytes())
+	if assert.Len(t, schema, 1) {
+		assert.Equal(t, "id", schema[0].GetName())
+	}
+	assert.Empty(t, rowGroups)
+	assert.Empty(t, rows)
+}
+
+func TestWriterErrors(t *testing.T) {
+	t.Parallel()
+
+	w := NewWriter(&bytes.Buffer{}, []Column{
+		{Name: "id", Type: String},
+		{Name: "cpus", Type: Double},
+	})
+	assert.EqualError(t, w.WriteRow([]interface{}{"1"}), "expected 2 values, got 1")
+	assert.EqualError(t, w.WriteRow([]interface{}{"1", "4"}),
+		"column cpus: unexpected value type string")
+}
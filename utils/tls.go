@@ -0,0 +1,81 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions configures the TLS connections of a client
+type TLSOptions struct {
+	// CAFile is the PEM bundle of the CAs the server certificates are
+	// verified with, instead of the system's
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and private key
+	// presented to the servers requesting them (mutual TLS)
+	CertFile string
+	KeyFile  string
+	// MinVersion is the minimum TLS version: 1.0, 1.1, 1.2 or 1.3
+	MinVersion string
+}
+
+// TLSConfig returns the TLS configuration of the options, or nil if they
+// are all empty
+func (o TLSOptions) TLSConfig() (*tls.Config, error) {
+	if o == (TLSOptions{}) {
+		return nil, nil
+	}
+	config := &tls.Config{}
+	if o.MinVersion != "" {
+		version, ok := tlsVersions[o.MinVersion]
+		if !ok {
+			return nil, errors.Errorf(
+				"invalid TLS min version %q, must be 1.0, 1.1, 1.2 or 1.3",
+				o.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the CA bundle")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificate found in the CA bundle %s",
+				o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
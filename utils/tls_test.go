@@ -0,0 +1,117 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key to dir
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "reporting"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	err = os.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	require.NoError(t, err)
+	err = os.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	require.NoError(t, err)
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+	emptyFile := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0600))
+
+	testCases := map[string]struct {
+		options TLSOptions
+		check   func(*testing.T, *tls.Config)
+		err     string
+	}{
+		"ok, empty": {
+			check: func(t *testing.T, config *tls.Config) {
+				assert.Nil(t, config)
+			},
+		},
+		"ok, mutual TLS": {
+			options: TLSOptions{
+				CAFile:     certFile,
+				CertFile:   certFile,
+				KeyFile:    keyFile,
+				MinVersion: "1.3",
+			},
+			check: func(t *testing.T, config *tls.Config) {
+				assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+				assert.NotNil(t, config.RootCAs)
+				assert.Len(t, config.Certificates, 1)
+			},
+		},
+		"ko, invalid min version": {
+			options: TLSOptions{MinVersion: "1.4"},
+			err:     `invalid TLS min version "1.4", must be 1.0, 1.1, 1.2 or 1.3`,
+		},
+		"ko, empty CA bundle": {
+			options: TLSOptions{CAFile: emptyFile},
+			err:     "no certificate found in the CA bundle " + emptyFile,
+		},
+		"ko, missing key": {
+			options: TLSOptions{CertFile: certFile},
+			err: "failed to load the client certificate: " +
+				"open : no such file or directory",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config, err := tc.options.TLSConfig()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else if assert.NoError(t, err) {
+				tc.check(t, config)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package utils
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// TransportOptions configures the HTTP transport of a client; the zero
+// values keep the defaults of http.DefaultTransport
+type TransportOptions struct {
+	TLSConfig *tls.Config
+	// MaxIdleConnsPerHost is the number of idle connections kept alive to
+	// the server, to be reused by the next requests
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept alive
+	IdleConnTimeout time.Duration
+}
+
+// NewTransport returns a copy of the default HTTP transport configured with
+// the options, or nil, meaning the default transport, if they are all zero
+func NewTransport(opts TransportOptions) http.RoundTripper {
+	if opts == (TransportOptions{}) {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		if transport.MaxIdleConns < opts.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = opts.MaxIdleConnsPerHost
+		}
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	return transport
+}
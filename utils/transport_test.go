@@ -0,0 +1,47 @@
+// Copyright 2023 Northern.tech AS
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package utils
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport(t *testing.T) {
+	assert.Nil(t, NewTransport(TransportOptions{}))
+
+	config := &tls.Config{MinVersion: tls.VersionTLS13}
+	transport := NewTransport(TransportOptions{TLSConfig: config})
+	if assert.IsType(t, &http.Transport{}, transport) {
+		transport := transport.(*http.Transport)
+		assert.Same(t, config, transport.TLSClientConfig)
+		assert.Equal(t, 0, transport.MaxIdleConnsPerHost)
+	}
+
+	transport = NewTransport(TransportOptions{
+		MaxIdleConnsPerHost: 200,
+		IdleConnTimeout:     time.Minute,
+	})
+	if assert.IsType(t, &http.Transport{}, transport) {
+		transport := transport.(*http.Transport)
+		assert.Equal(t, 200, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 200, transport.MaxIdleConns)
+		assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	}
+}
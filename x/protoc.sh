@@ -0,0 +1,29 @@
+#!/bin/sh
+# Copyright 2023 Northern.tech AS
+#
+#    Licensed under the Apache License, Version 2.0 (the "License");
+#    you may not use this file except in compliance with the License.
+#    You may obtain a copy of the License at
+#
+#        http://www.apache.org/licenses/LICENSE-2.0
+#
+#    Unless required by applicable law or agreed to in writing, software
+#    distributed under the License is distributed on an "AS IS" BASIS,
+#    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+#    See the License for the specific language governing permissions and
+#    limitations under the License.
+
+# Generates the Go code of the protobuf definitions and of the gRPC
+# services next to the .proto files given as arguments; requires protoc,
+# protoc-gen-go and protoc-gen-go-grpc in the PATH.
+set -e
+
+if [ "$#" -eq 0 ]; then
+    echo "usage: $0 FILE.proto..."
+    exit 1
+fi
+
+protoc \
+    --go_out=paths=source_relative:. \
+    --go-grpc_out=paths=source_relative:. \
+    "$@"